@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/shapestone/shape-yaml/internal/resolve"
+	"github.com/shapestone/shape-yaml/pkg/yaml"
+)
+
+// nodeToJSON writes n as JSON, keeping the key order of its mappings, and
+// indents the result when indent is set.
+func nodeToJSON(n *yaml.Node, indent bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, n); err != nil {
+		return nil, err
+	}
+	if !indent {
+		return buf.Bytes(), nil
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.InvalidKind:
+		buf.WriteString("null")
+	case yaml.MappingKind:
+		buf.WriteByte('{')
+		// A key merged in with << is overridden by the mapping's own
+		seen := make(map[string]bool, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			// Keys are scalars; a collection key holds its canonical text
+			key := n.Content[i].Value
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if len(seen) > 1 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(key)
+			buf.Write(k)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceKind:
+		buf.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		return writeJSONScalar(buf, n)
+	}
+	return nil
+}
+
+// writeJSONScalar writes the value a scalar resolves to; timestamps become
+// RFC 3339 strings.
+func writeJSONScalar(buf *bytes.Buffer, n *yaml.Node) error {
+	var v interface{}
+	if err := n.Decode(&v); err != nil {
+		return err
+	}
+	switch s := v.(type) {
+	case time.Time:
+		v = s.Format(time.RFC3339Nano)
+	case float64:
+		if math.IsNaN(s) || math.IsInf(s, 0) {
+			return fmt.Errorf("line %d: JSON cannot represent %s", n.Line, n.Value)
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", n.Line, err)
+	}
+	buf.Write(data)
+	return nil
+}
+
+// decodeJSON decodes the JSON values of data in turn, keeping the key order
+// of objects in a MapSlice.
+func decodeJSON(data []byte) ([]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var values []interface{}
+	for {
+		v, err := decodeJSONValue(dec)
+		if errors.Is(err, io.EOF) {
+			return values, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON at byte %d: %w", dec.InputOffset(), err)
+		}
+		values = append(values, v)
+	}
+}
+
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := yaml.MapSlice{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				val, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				obj = append(obj, yaml.MapItem{Key: key.(string), Value: val})
+			}
+			_, err := dec.Token() // '}'
+			return obj, unexpectedEOF(err)
+		case '[':
+			arr := []interface{}{}
+			for dec.More() {
+				val, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				arr = append(arr, val)
+			}
+			_, err := dec.Token() // ']'
+			return arr, unexpectedEOF(err)
+		}
+		return nil, fmt.Errorf("unexpected %v", t)
+	case json.Number:
+		if n, ok := resolve.Number(string(t)); ok {
+			return n, nil
+		}
+		return nil, fmt.Errorf("invalid number %s", t)
+	default:
+		return t, nil
+	}
+}
+
+// unexpectedEOF turns io.EOF inside a value, where it means truncated
+// input, into io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,451 @@
+// Command shape-yaml validates, formats, converts, queries and merges YAML
+// files with the shape-yaml library, for use from shell scripts and CI.
+//
+// Usage:
+//
+//	shape-yaml validate [-q] [-strict] [file...]  check that every document parses
+//	shape-yaml validate -schema <file> [file...]  ... and matches a schema
+//	shape-yaml fmt [-w] [file...]                 rewrite documents in canonical form
+//	shape-yaml to-json [-compact] [file...]       write each document as JSON
+//	shape-yaml from-json [file...]                write JSON values as YAML documents
+//	shape-yaml get <path> [file...]               print the nodes a path matches
+//	shape-yaml merge <file> <patch...>            merge files as RFC 7386 merge patches
+//	shape-yaml version                            print the library version
+//
+// validate also warns of lines indented with tabs and of YAML 1.1 booleans
+// such as yes and off, as file:line:column: warning: message (rule); with
+// -strict a warning fails the file. With -schema it checks every document
+// against a schema in the JSON Schema subset yaml.ParseSchema reads, and
+// reports violations as file:line:column: path: message.
+//
+// Commands read standard input when no file is given, or for a file named
+// "-". The exit status is 0 on success, 1 when an input is invalid or a
+// path matches nothing, and 2 for a usage error.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shapestone/shape-yaml/pkg/yaml"
+)
+
+// Exit statuses
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `usage: shape-yaml <command> [arguments]
+
+Commands:
+  validate [-q] [-strict] [file...]  check that every document parses, and warn of likely mistakes
+  validate -schema <file> [file...]  ... and that it matches a JSON Schema
+  fmt [-w] [file...]                 rewrite documents in canonical form
+  to-json [-compact] [file...]       write each document as JSON
+  from-json [file...]                write JSON values as YAML documents
+  get <path> [file...]               print the nodes a path such as .spec.items[0].name matches
+  merge <file> <patch...>            merge files in order as RFC 7386 merge patches
+  version                            print the library version
+
+Files default to standard input; "-" names it explicitly.
+`
+
+// command runs a subcommand with its arguments and returns the exit status.
+type command func(env *env, args []string) int
+
+var commands = map[string]command{
+	"validate":  runValidate,
+	"fmt":       runFmt,
+	"to-json":   runToJSON,
+	"from-json": runFromJSON,
+	"get":       runGet,
+	"merge":     runMerge,
+	"version":   runVersion,
+}
+
+// env holds the standard streams of a run, so that tests can supply their
+// own.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command named by args[0] and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "shape-yaml: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+	return cmd(e, args[1:])
+}
+
+// flags returns a flag set for the named command that reports errors to
+// e.stderr.
+func (e *env) flags(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: shape-yaml %s %s\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// errorf reports an error on e.stderr and returns exitError.
+func (e *env) errorf(format string, args ...interface{}) int {
+	fmt.Fprintf(e.stderr, "shape-yaml: "+format+"\n", args...)
+	return exitError
+}
+
+// input is a named input read in full.
+type input struct {
+	name string
+	data []byte
+}
+
+// readInputs reads the named files, or standard input when names is empty.
+func (e *env) readInputs(names []string) ([]input, error) {
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+	inputs := make([]input, 0, len(names))
+	for _, name := range names {
+		var data []byte
+		var err error
+		if name == "-" {
+			data, err = io.ReadAll(e.stdin)
+		} else {
+			data, err = os.ReadFile(name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input{name: name, data: data})
+	}
+	return inputs, nil
+}
+
+// displayName returns the name errors use for an input.
+func (in input) displayName() string {
+	if in.name == "-" {
+		return "<stdin>"
+	}
+	return in.name
+}
+
+// decodeNodes decodes every document of data into a Node.
+func decodeNodes(data []byte) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []*yaml.Node
+	for {
+		var n yaml.Node
+		err := dec.Decode(&n)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, &n)
+	}
+}
+
+func runValidate(e *env, args []string) int {
+	fs := e.flags("validate", "[-q] [-strict] [-schema file] [file...]")
+	quiet := fs.Bool("q", false, "report nothing on success")
+	strict := fs.Bool("strict", false, "fail files with warnings")
+	schemaFile := fs.String("schema", "", "check every document against the JSON Schema subset in `file`")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	var schema *yaml.Schema
+	if *schemaFile != "" {
+		data, err := os.ReadFile(*schemaFile)
+		if err == nil {
+			schema, err = yaml.ParseSchema(data)
+		}
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+	}
+	inputs, err := e.readInputs(fs.Args())
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	status := exitOK
+	for _, in := range inputs {
+		docs, err := yaml.ParseMultiDoc(string(in.data))
+		if err != nil {
+			status = e.errorf("%s: %v", in.displayName(), err)
+			continue
+		}
+		diags := yaml.ValidateDiagnostics(string(in.data))
+		for _, d := range diags {
+			fmt.Fprintf(e.stderr, "%s:%s\n", in.displayName(), d)
+		}
+		if *strict && len(diags) > 0 {
+			status = exitError
+			continue
+		}
+		if schema != nil && !e.checkSchema(in, schema) {
+			status = exitError
+			continue
+		}
+		if !*quiet {
+			fmt.Fprintf(e.stdout, "%s: ok (%d document%s)\n", in.displayName(), len(docs), plural(len(docs)))
+		}
+	}
+	return status
+}
+
+// checkSchema reports the schema violations of every document of in, and
+// returns whether there were none.
+func (e *env) checkSchema(in input, schema *yaml.Schema) bool {
+	docs, err := decodeNodes(in.data)
+	if err != nil {
+		e.errorf("%s: %v", in.displayName(), err)
+		return false
+	}
+	ok := true
+	for _, doc := range docs {
+		for _, v := range schema.Validate(doc) {
+			fmt.Fprintf(e.stderr, "%s:%s\n", in.displayName(), v)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func runFmt(e *env, args []string) int {
+	fs := e.flags("fmt", "[-w] [file...]")
+	write := fs.Bool("w", false, "write the result back to each file instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	inputs, err := e.readInputs(fs.Args())
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	status := exitOK
+	for _, in := range inputs {
+		out, err := format(in.data)
+		if err != nil {
+			status = e.errorf("%s: %v", in.displayName(), err)
+			continue
+		}
+		if *write && in.name != "-" {
+			if bytes.Equal(out, in.data) {
+				continue
+			}
+			if err := writeFile(in.name, out); err != nil {
+				status = e.errorf("%v", err)
+			}
+			continue
+		}
+		e.stdout.Write(out)
+	}
+	return status
+}
+
+// format decodes every document of data and writes it back with an Encoder.
+// Comments are not kept, since a Node does not hold them.
+func format(data []byte) ([]byte, error) {
+	docs, err := decodeNodes(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFile replaces the contents of name, keeping its permissions.
+func writeFile(name string, data []byte) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, info.Mode().Perm())
+}
+
+func runToJSON(e *env, args []string) int {
+	fs := e.flags("to-json", "[-compact] [file...]")
+	compact := fs.Bool("compact", false, "write each document on one line")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	inputs, err := e.readInputs(fs.Args())
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	status := exitOK
+	for _, in := range inputs {
+		docs, err := decodeNodes(in.data)
+		if err != nil {
+			status = e.errorf("%s: %v", in.displayName(), err)
+			continue
+		}
+		for _, doc := range docs {
+			out, err := nodeToJSON(doc, !*compact)
+			if err != nil {
+				status = e.errorf("%s: %v", in.displayName(), err)
+				break
+			}
+			e.stdout.Write(append(out, '\n'))
+		}
+	}
+	return status
+}
+
+func runFromJSON(e *env, args []string) int {
+	fs := e.flags("from-json", "[file...]")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	inputs, err := e.readInputs(fs.Args())
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	for _, in := range inputs {
+		values, err := decodeJSON(in.data)
+		if err != nil {
+			return e.errorf("%s: %v", in.displayName(), err)
+		}
+		for _, v := range values {
+			if err := enc.Encode(v); err != nil {
+				return e.errorf("%s: %v", in.displayName(), err)
+			}
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return e.errorf("%v", err)
+	}
+	e.stdout.Write(buf.Bytes())
+	return exitOK
+}
+
+func runGet(e *env, args []string) int {
+	fs := e.flags("get", "<path> [file...]")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	path := fs.Arg(0)
+	inputs, err := e.readInputs(fs.Args()[1:])
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	matched := false
+	for _, in := range inputs {
+		docs, err := decodeNodes(in.data)
+		if err != nil {
+			return e.errorf("%s: %v", in.displayName(), err)
+		}
+		for _, doc := range docs {
+			nodes, err := yaml.Query(doc, path)
+			if err != nil {
+				return e.errorf("%v", err)
+			}
+			for _, n := range nodes {
+				out, err := nodeText(n)
+				if err != nil {
+					return e.errorf("%s: %v", in.displayName(), err)
+				}
+				e.stdout.Write(append(out, '\n'))
+				matched = true
+			}
+		}
+	}
+	if !matched {
+		return exitError
+	}
+	return exitOK
+}
+
+// nodeText returns the value of a scalar, as a shell script wants it, or
+// a collection as YAML.
+func nodeText(n *yaml.Node) ([]byte, error) {
+	if n.Kind == yaml.ScalarKind {
+		if n.Tag == yaml.NullTag && n.Value == "" {
+			return []byte("null"), nil
+		}
+		return []byte(n.Value), nil
+	}
+	return yaml.Marshal(n)
+}
+
+func runMerge(e *env, args []string) int {
+	fs := e.flags("merge", "<file> <patch...>")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return exitUsage
+	}
+	inputs, err := e.readInputs(fs.Args())
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	out := inputs[0].data
+	for _, in := range inputs[1:] {
+		if out, err = yaml.ApplyMergePatch(out, in.data); err != nil {
+			return e.errorf("%s: %v", in.displayName(), err)
+		}
+	}
+	e.stdout.Write(append(out, '\n'))
+	return exitOK
+}
+
+func runVersion(e *env, args []string) int {
+	fmt.Fprintf(e.stdout, "shape-yaml %s\n", yaml.LibraryVersion())
+	return exitOK
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDoc = `name: web
+ports: [80, 443]
+base: &b {replicas: 1, debug: false}
+spec:
+  <<: *b
+  replicas: 3
+  started: 2024-01-02T03:04:05Z
+---
+name: worker
+`
+
+// runCLI runs the command with stdin and returns its exit status and output.
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestValidate(t *testing.T) {
+	status, out, _ := runCLI(t, testDoc, "validate")
+	if status != exitOK || out != "<stdin>: ok (2 documents)\n" {
+		t.Errorf("validate = %d, %q", status, out)
+	}
+
+	dir := t.TempDir()
+	good, bad := filepath.Join(dir, "good.yaml"), filepath.Join(dir, "bad.yaml")
+	writeTestFile(t, good, "a: 1\n")
+	writeTestFile(t, bad, "a: [1, 2\n")
+	status, out, errOut := runCLI(t, "", "validate", "-q", good, bad)
+	if status != exitError || out != "" || !strings.HasPrefix(errOut, "shape-yaml: "+bad+": ") {
+		t.Errorf("validate -q of an invalid file = %d, %q, %q", status, out, errOut)
+	}
+
+	status, out, errOut = runCLI(t, "a:\n\tb: yes\n", "validate")
+	wantErr := "<stdin>:2:1: warning: line indented with a tab; indent with spaces (tab-indentation)\n" +
+		`<stdin>:2:5: warning: "yes" is a boolean in YAML 1.1 only; write true, or quote it for a string (non-spec-boolean)` + "\n"
+	if status != exitOK || out != "<stdin>: ok (1 document)\n" || errOut != wantErr {
+		t.Errorf("validate with warnings = %d, %q, %q", status, out, errOut)
+	}
+	if status, out, _ := runCLI(t, "a: yes\n", "validate", "-strict"); status != exitError || out != "" {
+		t.Errorf("validate -strict with warnings = %d, %q", status, out)
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.yaml")
+	writeTestFile(t, schema, "type: object\nrequired: [name]\nproperties:\n  replicas: {type: integer, minimum: 1}\n")
+
+	status, out, errOut := runCLI(t, "name: web\nreplicas: 2\n---\nreplicas: 0\n", "validate", "-schema", schema)
+	wantErr := "<stdin>:4:1: .: missing required key \"name\"\n<stdin>:4:11: replicas: 0 is less than the minimum 1\n"
+	if status != exitError || out != "" || errOut != wantErr {
+		t.Errorf("validate -schema = %d, %q, %q\nwant stderr %q", status, out, errOut, wantErr)
+	}
+
+	if status, out, _ := runCLI(t, "name: web\n", "validate", "-schema", schema); status != exitOK || out != "<stdin>: ok (1 document)\n" {
+		t.Errorf("validate -schema of a valid document = %d, %q", status, out)
+	}
+
+	writeTestFile(t, schema, "oneOf: []\n")
+	if status, _, errOut := runCLI(t, "a: 1\n", "validate", "-schema", schema); status != exitError || !strings.Contains(errOut, "unsupported keyword") {
+		t.Errorf("validate with an invalid schema = %d, %q", status, errOut)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	status, out, errOut := runCLI(t, "b:   [1,  2]\na:    'x'\n---\nc: ~\n", "fmt")
+	want := "b: \n  - 1\n  - 2\na: x\n---\nc: null\n"
+	if status != exitOK || out != want {
+		t.Errorf("fmt = %d, %q (%s), want %q", status, out, errOut, want)
+	}
+
+	path := filepath.Join(t.TempDir(), "in.yaml")
+	writeTestFile(t, path, "a:   1\n")
+	if status, out, errOut := runCLI(t, "", "fmt", "-w", path); status != exitOK || out != "" {
+		t.Fatalf("fmt -w = %d, %q, %q", status, out, errOut)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a: 1\n" {
+		t.Errorf("fmt -w wrote %q", data)
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	status, out, errOut := runCLI(t, testDoc, "to-json", "-compact")
+	want := `{"name":"web","ports":[80,443],"base":{"replicas":1,"debug":false},` +
+		`"spec":{"replicas":3,"started":"2024-01-02T03:04:05Z","debug":false}}` + "\n" +
+		`{"name":"worker"}` + "\n"
+	if status != exitOK || out != want {
+		t.Errorf("to-json -compact = %d, %s (%s)\nwant %s", status, out, errOut, want)
+	}
+
+	if _, out, _ := runCLI(t, "a: [1]\n", "to-json"); out != "{\n  \"a\": [\n    1\n  ]\n}\n" {
+		t.Errorf("to-json = %q", out)
+	}
+	if _, out, _ := runCLI(t, "? [a, b]\n: 1\n", "to-json", "-compact"); out != `{"[\"a\",\"b\"]":1}`+"\n" {
+		t.Errorf("to-json of a sequence key = %q", out)
+	}
+	if status, _, errOut := runCLI(t, "a: .nan\n", "to-json"); status != exitError || !strings.Contains(errOut, "JSON cannot represent .nan") {
+		t.Errorf("to-json of NaN = %d, %q; want an error", status, errOut)
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	status, out, errOut := runCLI(t, `{"z": 1, "a": [true, null, 2.5, "s"]} {"b": {}}`, "from-json")
+	want := "z: 1\na: \n  - true\n  - null\n  - 2.5\n  - s\n---\nb: {}\n"
+	if status != exitOK || out != want {
+		t.Errorf("from-json = %d, %q (%s), want %q", status, out, errOut, want)
+	}
+	if status, _, errOut := runCLI(t, `{"a": [1`, "from-json"); status != exitError ||
+		!strings.Contains(errOut, "invalid JSON") {
+		t.Errorf("from-json of truncated JSON = %d, %q", status, errOut)
+	}
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{".name", "web\nworker\n"},
+		{"ports[*]", "80\n443\n"},
+		{"spec.replicas", "3\n"},
+		{"base", "replicas: 1\ndebug: false\n"},
+	}
+	for _, tt := range tests {
+		if status, out, errOut := runCLI(t, testDoc, "get", tt.path); status != exitOK || out != tt.want {
+			t.Errorf("get %s = %d, %q (%s), want %q", tt.path, status, out, errOut, tt.want)
+		}
+	}
+
+	if status, out, _ := runCLI(t, testDoc, "get", "missing"); status != exitError || out != "" {
+		t.Errorf("get of a missing path = %d, %q", status, out)
+	}
+	if status, _, errOut := runCLI(t, testDoc, "get", "a["); status != exitError || !strings.Contains(errOut, "invalid path") {
+		t.Errorf("get of an invalid path = %d, %q", status, errOut)
+	}
+	if status, _, _ := runCLI(t, testDoc, "get"); status != exitUsage {
+		t.Errorf("get without a path = %d, want %d", status, exitUsage)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	base, overlay := filepath.Join(dir, "base.yaml"), filepath.Join(dir, "overlay.yaml")
+	writeTestFile(t, base, "name: web\nspec: {replicas: 1, debug: true}\n")
+	writeTestFile(t, overlay, "spec: {replicas: 3, debug: null}\nextra: x\n")
+
+	status, out, errOut := runCLI(t, "env: prod\n", "merge", base, overlay, "-")
+	want := "name: web\nspec: \n  replicas: 3\nextra: x\nenv: prod\n"
+	if status != exitOK || out != want {
+		t.Errorf("merge = %d, %q (%s), want %q", status, out, errOut, want)
+	}
+	if status, _, _ := runCLI(t, "", "merge", base); status != exitUsage {
+		t.Errorf("merge of one file = %d, want %d", status, exitUsage)
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	if status, _, errOut := runCLI(t, ""); status != exitUsage || !strings.Contains(errOut, "Commands:") {
+		t.Errorf("no command = %d, %q", status, errOut)
+	}
+	if status, _, errOut := runCLI(t, "", "frobnicate"); status != exitUsage || !strings.Contains(errOut, `unknown command "frobnicate"`) {
+		t.Errorf("unknown command = %d, %q", status, errOut)
+	}
+	if status, _, _ := runCLI(t, "", "fmt", "-nope"); status != exitUsage {
+		t.Errorf("unknown flag = %d, want %d", status, exitUsage)
+	}
+	if status, out, _ := runCLI(t, "", "version"); status != exitOK || !strings.HasPrefix(out, "shape-yaml ") {
+		t.Errorf("version = %d, %q", status, out)
+	}
+}
+
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,212 @@
+// Package benchreport turns the output of `go test -bench -benchmem` into the
+// markdown performance report comparing shape-yaml with gopkg.in/yaml.v3.
+//
+// It parses and renders text, and saves runs to a history directory from
+// which it charts per-benchmark trends. Running the benchmarks, and deciding
+// where reports go, is left to the caller (scripts/generate_benchmark_report),
+// so the package needs no external commands and works the same for output
+// produced on another machine.
+package benchreport
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Result is a single benchmark result line.
+type Result struct {
+	Name        string
+	Procs       int // GOMAXPROCS suffix of the name, 0 if absent
+	Iterations  int
+	NsPerOp     float64
+	MBPerSec    float64
+	BytesPerOp  int64
+	AllocsPerOp int64
+}
+
+// Run is the parsed output of one `go test -bench` invocation.
+type Run struct {
+	GOOS    string // from the "goos:" header line
+	GOARCH  string // from the "goarch:" header line
+	Pkg     string // from the "pkg:" header line
+	CPU     string // from the "cpu:" header line
+	Results map[string]*Result
+}
+
+// Group pairs the shape-yaml and gopkg.in/yaml.v3 results of one operation,
+// along with the result of the shape-yaml fast path where it is benchmarked
+// apart (BenchmarkFastYAML_<op>, while BenchmarkShapeYAML_<op> then measures
+// the AST parser).
+type Group struct {
+	Name      string
+	ShapeYAML *Result // shape-yaml
+	StdYAML   *Result // gopkg.in/yaml.v3
+	FastYAML  *Result // shape-yaml fast path (internal/fastparser), or nil
+	Operation string  // "Unmarshal", "Marshal", etc.
+
+	// Comparison ratios (shape-yaml vs gopkg.in/yaml.v3)
+	SpeedupFactor   float64
+	ThroughputRatio float64
+	MemoryRatio     float64
+	AllocRatio      float64
+
+	// Comparison ratios (fast path vs gopkg.in/yaml.v3), zero without FastYAML
+	FastSpeedupFactor   float64
+	FastThroughputRatio float64
+	FastMemoryRatio     float64
+	FastAllocRatio      float64
+
+	// FastVsShapeSpeedup is how much faster the fast path is than ShapeYAML
+	FastVsShapeSpeedup float64
+}
+
+// Operations are the operations compared by Group, in report order.
+var Operations = []string{"Unmarshal", "Marshal"}
+
+// resultPattern matches a benchmark line run with -benchmem:
+// BenchmarkName-10    123456    7890 ns/op    12.34 MB/s    5678 B/op    90 allocs/op
+var resultPattern = regexp.MustCompile(`^(Benchmark\S+?)(?:-(\d+))?\s+(\d+)\s+(\d+(?:\.\d+)?)\s+ns/op(?:\s+(\d+(?:\.\d+)?)\s+MB/s)?\s+(\d+)\s+B/op\s+(\d+)\s+allocs/op`)
+
+// Parse parses the output of `go test -bench -benchmem`. Lines that are not
+// a header or a benchmark result are ignored; output without any result is
+// an error.
+func Parse(output string) (*Run, error) {
+	run := &Run{Results: make(map[string]*Result)}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if key, value, ok := strings.Cut(line, ": "); ok {
+			switch key {
+			case "goos":
+				run.GOOS = strings.TrimSpace(value)
+				continue
+			case "goarch":
+				run.GOARCH = strings.TrimSpace(value)
+				continue
+			case "pkg":
+				run.Pkg = strings.TrimSpace(value)
+				continue
+			case "cpu":
+				run.CPU = strings.TrimSpace(value)
+				continue
+			}
+		}
+
+		matches := resultPattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		procs, _ := strconv.Atoi(matches[2])
+		iterations, _ := strconv.Atoi(matches[3])
+		nsPerOp, _ := strconv.ParseFloat(matches[4], 64)
+		bytesPerOp, _ := strconv.ParseInt(matches[6], 10, 64)
+		allocsPerOp, _ := strconv.ParseInt(matches[7], 10, 64)
+
+		// MB/s is optional
+		var mbPerSec float64
+		if matches[5] != "" {
+			mbPerSec, _ = strconv.ParseFloat(matches[5], 64)
+		}
+
+		run.Results[matches[1]] = &Result{
+			Name:        matches[1],
+			Procs:       procs,
+			Iterations:  iterations,
+			NsPerOp:     nsPerOp,
+			MBPerSec:    mbPerSec,
+			BytesPerOp:  bytesPerOp,
+			AllocsPerOp: allocsPerOp,
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(run.Results) == 0 {
+		return nil, fmt.Errorf("no benchmark results found in output")
+	}
+
+	return run, nil
+}
+
+// GroupResults pairs BenchmarkShapeYAML_<op> with BenchmarkStdYAML_<op> for
+// each of Operations, in that order, skipping operations missing either side,
+// and adds BenchmarkFastYAML_<op> where present.
+func GroupResults(results map[string]*Result) []*Group {
+	var groups []*Group
+
+	for _, operation := range Operations {
+		shapeResult := results["BenchmarkShapeYAML_"+operation]
+		stdResult := results["BenchmarkStdYAML_"+operation]
+
+		if shapeResult != nil && stdResult != nil {
+			group := &Group{
+				Name:      operation,
+				ShapeYAML: shapeResult,
+				StdYAML:   stdResult,
+				FastYAML:  results["BenchmarkFastYAML_"+operation],
+				Operation: operation,
+			}
+			calculateRatios(group)
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// calculateRatios computes performance comparison ratios
+func calculateRatios(group *Group) {
+	if group.ShapeYAML == nil || group.StdYAML == nil {
+		return
+	}
+
+	group.SpeedupFactor, group.ThroughputRatio, group.MemoryRatio, group.AllocRatio =
+		compare(group.ShapeYAML, group.StdYAML)
+
+	if group.FastYAML != nil {
+		group.FastSpeedupFactor, group.FastThroughputRatio, group.FastMemoryRatio, group.FastAllocRatio =
+			compare(group.FastYAML, group.StdYAML)
+		if group.FastYAML.NsPerOp > 0 {
+			group.FastVsShapeSpeedup = group.ShapeYAML.NsPerOp / group.FastYAML.NsPerOp
+		}
+	}
+}
+
+// compare returns the ratios of result against base: speedup (>1 means
+// result is faster), throughput, memory and allocations (<1 means result
+// uses less). A ratio is zero where base or result lacks the measure.
+func compare(result, base *Result) (speedup, throughput, memory, allocs float64) {
+	if result.NsPerOp > 0 {
+		speedup = base.NsPerOp / result.NsPerOp
+	}
+
+	if result.MBPerSec > 0 && base.MBPerSec > 0 {
+		throughput = result.MBPerSec / base.MBPerSec
+	}
+
+	if base.BytesPerOp > 0 {
+		memory = float64(result.BytesPerOp) / float64(base.BytesPerOp)
+	}
+
+	if base.AllocsPerOp > 0 {
+		allocs = float64(result.AllocsPerOp) / float64(base.AllocsPerOp)
+	}
+	return speedup, throughput, memory, allocs
+}
+
+// findGroupByOperation returns the group of operation, or nil
+func findGroupByOperation(groups []*Group, operation string) *Group {
+	for _, g := range groups {
+		if g.Operation == operation {
+			return g
+		}
+	}
+	return nil
+}
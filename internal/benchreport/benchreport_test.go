@@ -0,0 +1,117 @@
+package benchreport
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sampleOutput = `goos: darwin
+goarch: arm64
+pkg: github.com/shapestone/shape-yaml/pkg/yaml
+cpu: Apple M1 Pro
+BenchmarkShapeYAML_Unmarshal-10    	  500000	      2500 ns/op	  40.00 MB/s	    1024 B/op	      20 allocs/op
+BenchmarkStdYAML_Unmarshal-10      	  250000	      5000 ns/op	  20.00 MB/s	    4096 B/op	      80 allocs/op
+BenchmarkShapeYAML_Marshal-10      	 1000000	      1500 ns/op	     512 B/op	      10 allocs/op
+BenchmarkStdYAML_Marshal-10        	  800000	      1200 ns/op	    1024 B/op	       5 allocs/op
+BenchmarkShapeYAML_Decode/large-1k 	     100	   1500000 ns/op	 2048000 B/op	    1500 allocs/op
+PASS
+ok  	github.com/shapestone/shape-yaml/pkg/yaml	12.345s
+`
+
+func TestParse(t *testing.T) {
+	run, err := Parse(sampleOutput)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if run.GOOS != "darwin" || run.GOARCH != "arm64" || run.CPU != "Apple M1 Pro" ||
+		run.Pkg != "github.com/shapestone/shape-yaml/pkg/yaml" {
+		t.Errorf("Parse() header = %q %q %q %q", run.GOOS, run.GOARCH, run.CPU, run.Pkg)
+	}
+	if len(run.Results) != 5 {
+		t.Fatalf("Parse() found %d results, want 5", len(run.Results))
+	}
+
+	tests := []struct {
+		name string
+		want Result
+	}{
+		{"BenchmarkShapeYAML_Unmarshal", Result{Name: "BenchmarkShapeYAML_Unmarshal", Procs: 10, Iterations: 500000,
+			NsPerOp: 2500, MBPerSec: 40, BytesPerOp: 1024, AllocsPerOp: 20}},
+		{"BenchmarkStdYAML_Marshal", Result{Name: "BenchmarkStdYAML_Marshal", Procs: 10, Iterations: 800000,
+			NsPerOp: 1200, BytesPerOp: 1024, AllocsPerOp: 5}},
+		{"BenchmarkShapeYAML_Decode/large-1k", Result{Name: "BenchmarkShapeYAML_Decode/large-1k", Iterations: 100,
+			NsPerOp: 1500000, BytesPerOp: 2048000, AllocsPerOp: 1500}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := run.Results[tt.name]
+			if got == nil {
+				t.Fatalf("Parse() missing %s", tt.name)
+			}
+			if *got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_NoResults(t *testing.T) {
+	if _, err := Parse("goos: linux\nPASS\n"); err == nil {
+		t.Error("Parse() of output without results: expected error")
+	}
+}
+
+func TestGroupResults(t *testing.T) {
+	run, err := Parse(sampleOutput)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	groups := GroupResults(run.Results)
+	var ops []string
+	for _, g := range groups {
+		ops = append(ops, g.Operation)
+	}
+	if !reflect.DeepEqual(ops, []string{"Unmarshal", "Marshal"}) {
+		t.Fatalf("GroupResults() operations = %v", ops)
+	}
+
+	u := groups[0]
+	if u.SpeedupFactor != 2 || u.ThroughputRatio != 2 || u.MemoryRatio != 0.25 || u.AllocRatio != 0.25 {
+		t.Errorf("Unmarshal ratios = %v %v %v %v", u.SpeedupFactor, u.ThroughputRatio, u.MemoryRatio, u.AllocRatio)
+	}
+	m := groups[1]
+	if m.SpeedupFactor != 0.8 || m.ThroughputRatio != 0 || m.MemoryRatio != 0.5 || m.AllocRatio != 2 {
+		t.Errorf("Marshal ratios = %v %v %v %v", m.SpeedupFactor, m.ThroughputRatio, m.MemoryRatio, m.AllocRatio)
+	}
+
+	delete(run.Results, "BenchmarkStdYAML_Marshal")
+	if groups := GroupResults(run.Results); len(groups) != 1 || groups[0].Operation != "Unmarshal" {
+		t.Errorf("GroupResults() without a std Marshal = %d groups", len(groups))
+	}
+}
+
+func TestGroupResults_FastPath(t *testing.T) {
+	run, err := Parse(sampleOutput + "BenchmarkFastYAML_Unmarshal-10 2000000 500 ns/op 200.00 MB/s 256 B/op 4 allocs/op\n")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	groups := GroupResults(run.Results)
+	u, m := groups[0], groups[1]
+	if u.FastYAML == nil || u.FastYAML.Name != "BenchmarkFastYAML_Unmarshal" {
+		t.Fatalf("Unmarshal FastYAML = %+v", u.FastYAML)
+	}
+	if u.FastSpeedupFactor != 10 || u.FastThroughputRatio != 10 || u.FastMemoryRatio != 0.0625 ||
+		u.FastAllocRatio != 0.05 || u.FastVsShapeSpeedup != 5 {
+		t.Errorf("Unmarshal fast ratios = %v %v %v %v %v",
+			u.FastSpeedupFactor, u.FastThroughputRatio, u.FastMemoryRatio, u.FastAllocRatio, u.FastVsShapeSpeedup)
+	}
+	if u.SpeedupFactor != 2 {
+		t.Errorf("Unmarshal SpeedupFactor = %v, want 2", u.SpeedupFactor)
+	}
+	if m.FastYAML != nil || m.FastSpeedupFactor != 0 {
+		t.Errorf("Marshal without a fast path benchmark: FastYAML = %+v, ratio %v", m.FastYAML, m.FastSpeedupFactor)
+	}
+}
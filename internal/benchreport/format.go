@@ -0,0 +1,75 @@
+package benchreport
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// formatBenchmarkLine formats result as go test prints it
+func formatBenchmarkLine(result *Result) string {
+	name := result.Name
+	if result.Procs > 0 {
+		name += "-" + strconv.Itoa(result.Procs)
+	}
+	line := fmt.Sprintf("%-50s %8d %12.0f ns/op",
+		name,
+		result.Iterations,
+		result.NsPerOp)
+
+	if result.MBPerSec > 0 {
+		line += fmt.Sprintf(" %8.2f MB/s", result.MBPerSec)
+	}
+
+	line += fmt.Sprintf(" %12d B/op %8d allocs/op\n",
+		result.BytesPerOp,
+		result.AllocsPerOp)
+
+	return line
+}
+
+// formatDuration formats nanoseconds in ns, µs, or ms
+func formatDuration(ns float64) string {
+	if ns < 1000 {
+		return fmt.Sprintf("%.0fns", ns)
+	} else if ns < 1_000_000 {
+		return fmt.Sprintf("%.1fµs", ns/1000)
+	} else {
+		return fmt.Sprintf("%.1fms", ns/1_000_000)
+	}
+}
+
+// formatBytes formats a byte count in B, KB, MB, or GB
+func formatBytes(bytes int64) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	} else if bytes < 1024*1024 {
+		return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
+	} else if bytes < 1024*1024*1024 {
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+	} else {
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1024*1024*1024))
+	}
+}
+
+// formatInt formats n with comma separators
+func formatInt(n int64) string {
+	s := fmt.Sprintf("%d", n)
+	if len(s) <= 3 {
+		return s
+	}
+
+	// Add comma separators
+	var result []byte
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			result = append(result, ',')
+		}
+		result = append(result, byte(c))
+	}
+	return string(result)
+}
+
+// formatOps formats operations per second
+func formatOps(ops float64) string {
+	return fmt.Sprintf("%.0f", ops)
+}
@@ -0,0 +1,164 @@
+package benchreport
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Metadata contains information about a benchmark run, saved next to it in
+// the history as metadata.json.
+type Metadata struct {
+	Timestamp   string `json:"timestamp"`
+	GitCommit   string `json:"commit"`
+	Platform    string `json:"platform"`
+	OS          string `json:"os"`
+	Arch        string `json:"arch"`
+	GoVersion   string `json:"go_version"`
+	BenchTime   string `json:"bench_time"`
+	Description string `json:"description"`
+
+	// Profiles summarizes the profiles captured during the run, keyed by
+	// ProfileKind.Name; see SummarizeProfiles.
+	Profiles map[string]*ProfileSummary `json:"profiles,omitempty"`
+}
+
+// NewMetadata describes the run of env, taken at commit, under timestamp.
+func NewMetadata(env Environment, timestamp, commit, description string) Metadata {
+	return Metadata{
+		Timestamp:   timestamp,
+		GitCommit:   commit,
+		Platform:    env.Platform,
+		OS:          env.OS,
+		Arch:        env.Arch,
+		GoVersion:   env.GoVersion,
+		BenchTime:   env.BenchTime,
+		Description: description,
+	}
+}
+
+// historyGitignore keeps saved runs out of version control.
+const historyGitignore = `# Benchmark history files are large and change frequently
+# Only commit the directory structure and README
+*
+!.gitignore
+!README.md
+`
+
+// SaveHistory saves the benchmark output, the report, and metadata.json to
+// historyRoot/<meta.Timestamp>, creating historyRoot/.gitignore if missing,
+// and returns the directory written.
+func SaveHistory(historyRoot, benchmarkOutput, report string, meta Metadata) (string, error) {
+	historyDir := filepath.Join(historyRoot, meta.Timestamp)
+
+	err := os.MkdirAll(historyDir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create history directory: %v", err)
+	}
+
+	// Save raw benchmark output
+	benchPath := filepath.Join(historyDir, "benchmark_output.txt")
+	err = os.WriteFile(benchPath, []byte(benchmarkOutput), 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write benchmark output: %v", err)
+	}
+
+	// Save generated report
+	reportPath := filepath.Join(historyDir, "PERFORMANCE_REPORT.md")
+	err = os.WriteFile(reportPath, []byte(report), 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write report: %v", err)
+	}
+
+	metadataJSON, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata: %v", err)
+	}
+
+	metadataPath := filepath.Join(historyDir, "metadata.json")
+	err = os.WriteFile(metadataPath, metadataJSON, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write metadata: %v", err)
+	}
+
+	gitignorePath := filepath.Join(historyRoot, ".gitignore")
+	if _, err := os.Stat(gitignorePath); os.IsNotExist(err) {
+		err = os.WriteFile(gitignorePath, []byte(historyGitignore), 0644)
+		if err != nil {
+			return "", fmt.Errorf("failed to write .gitignore: %v", err)
+		}
+	}
+
+	return historyDir, nil
+}
+
+// GitCommit returns the commit checked out in the repository at root, read
+// from its .git directory so that no git executable is needed, or "unknown".
+func GitCommit(root string) string {
+	gitDir := filepath.Join(root, ".git")
+	if data, err := os.ReadFile(gitDir); err == nil {
+		// A worktree or submodule: .git is a file naming the directory
+		dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !ok {
+			return "unknown"
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		gitDir = dir
+	}
+
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "unknown"
+	}
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: ")
+	if !ok {
+		return strings.TrimSpace(string(head)) // detached HEAD
+	}
+
+	// A worktree keeps its HEAD but shares the refs of the main repository
+	for _, dir := range refDirs(gitDir) {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref))); err == nil {
+			return strings.TrimSpace(string(data))
+		}
+		if commit := packedRef(filepath.Join(dir, "packed-refs"), ref); commit != "" {
+			return commit
+		}
+	}
+	return "unknown"
+}
+
+// refDirs returns gitDir, followed by the common directory it names, if any.
+func refDirs(gitDir string) []string {
+	dirs := []string{gitDir}
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		dirs = append(dirs, common)
+	}
+	return dirs
+}
+
+// packedRef looks ref up in a packed-refs file, returning "" if absent.
+func packedRef(path, ref string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		commit, name, ok := strings.Cut(scanner.Text(), " ")
+		if ok && name == ref {
+			return commit
+		}
+	}
+	return ""
+}
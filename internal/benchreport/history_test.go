@@ -0,0 +1,96 @@
+package benchreport
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveHistory(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "history")
+	meta := Metadata{Timestamp: "2025-12-27_14-30-00", GitCommit: "abc123", BenchTime: "3s"}
+
+	dir, err := SaveHistory(root, "output", "report", meta)
+	if err != nil {
+		t.Fatalf("SaveHistory() error = %v", err)
+	}
+	if dir != filepath.Join(root, meta.Timestamp) {
+		t.Errorf("SaveHistory() dir = %s", dir)
+	}
+
+	for name, want := range map[string]string{
+		"benchmark_output.txt":  "output",
+		"PERFORMANCE_REPORT.md": "report",
+	} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, want)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		t.Fatalf("metadata.json: %v", err)
+	}
+	var got Metadata
+	if err := json.Unmarshal(data, &got); err != nil || !reflect.DeepEqual(got, meta) {
+		t.Errorf("metadata.json = %+v, %v; want %+v", got, err, meta)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, ".gitignore")); err != nil {
+		t.Errorf(".gitignore: %v", err)
+	}
+}
+
+func TestGitCommit(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	write := func(t *testing.T, path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"loose ref", map[string]string{
+			".git/HEAD":            "ref: refs/heads/main\n",
+			".git/refs/heads/main": commit + "\n",
+		}, commit},
+		{"packed ref", map[string]string{
+			".git/HEAD":        "ref: refs/heads/main\n",
+			".git/packed-refs": "# pack-refs with: peeled fully-peeled sorted\n" + commit + " refs/heads/main\n",
+		}, commit},
+		{"detached", map[string]string{
+			".git/HEAD": commit + "\n",
+		}, commit},
+		{"worktree", map[string]string{
+			".git":                        "gitdir: repo/worktrees/wt\n",
+			"repo/worktrees/wt/HEAD":      "ref: refs/heads/feature\n",
+			"repo/worktrees/wt/commondir": "../..\n",
+			"repo/refs/heads/feature":     commit + "\n",
+		}, commit},
+		{"missing ref", map[string]string{
+			".git/HEAD": "ref: refs/heads/main\n",
+		}, "unknown"},
+		{"not a repository", nil, "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				write(t, filepath.Join(root, filepath.FromSlash(name)), content)
+			}
+			if got := GitCommit(root); got != tt.want {
+				t.Errorf("GitCommit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,456 @@
+package benchreport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ProfileKind is a profile `go test` can capture during a benchmark run.
+type ProfileKind struct {
+	Name       string // key in Metadata.Profiles
+	File       string // file name in the run's directory
+	Flag       string // go test flag writing the profile
+	SampleType string // sample type the hotspots rank by
+}
+
+// ProfileKinds are the profiles a benchmark run captures with -profile.
+// Memory hotspots rank by bytes allocated, which is what allocs/op counts,
+// rather than by bytes still in use at the end of the run.
+var ProfileKinds = []ProfileKind{
+	{Name: "cpu", File: "cpu.prof", Flag: "-cpuprofile", SampleType: "cpu"},
+	{Name: "mem", File: "mem.prof", Flag: "-memprofile", SampleType: "alloc_space"},
+}
+
+// DefaultHotspots is how many hotspots SummarizeProfiles keeps.
+const DefaultHotspots = 10
+
+// Hotspot is one function's share of a profile, as `go tool pprof -top`
+// lists it: Flat counts samples in the function itself, Cum samples in it
+// or in functions it calls.
+type Hotspot struct {
+	Function string  `json:"function"`
+	Flat     int64   `json:"flat"`
+	FlatPct  float64 `json:"flat_pct"`
+	Cum      int64   `json:"cum"`
+	CumPct   float64 `json:"cum_pct"`
+}
+
+// ProfileSummary holds the top hotspots of a captured profile.
+type ProfileSummary struct {
+	File       string    `json:"file"`
+	SampleType string    `json:"sample_type"` // e.g. "cpu/nanoseconds"
+	Total      int64     `json:"total"`
+	Top        []Hotspot `json:"top"`
+}
+
+// SummarizeProfiles summarizes the profiles of ProfileKinds found in dir,
+// keeping the top n hotspots of each, keyed by ProfileKind.Name. Profiles
+// that were not captured are left out.
+func SummarizeProfiles(dir string, n int) (map[string]*ProfileSummary, error) {
+	summaries := make(map[string]*ProfileSummary)
+	for _, kind := range ProfileKinds {
+		data, err := os.ReadFile(filepath.Join(dir, kind.File))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s, err := SummarizeProfile(data, kind.SampleType, n)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", kind.File, err)
+		}
+		s.File = kind.File
+		summaries[kind.Name] = s
+	}
+	return summaries, nil
+}
+
+// SaveProfiles copies the profiles of ProfileKinds found in profileDir into
+// historyDir, the directory SaveHistory returned.
+func SaveProfiles(historyDir, profileDir string) error {
+	for _, kind := range ProfileKinds {
+		data, err := os.ReadFile(filepath.Join(profileDir, kind.File))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(historyDir, kind.File), data, 0644); err != nil {
+			return fmt.Errorf("failed to write profile: %v", err)
+		}
+	}
+	return nil
+}
+
+// SummarizeProfile returns the top n hotspots of a pprof profile, ranked by
+// flat value of the first sample type whose name is sampleType, such as
+// "cpu" or "alloc_space". The profile is decoded here rather than through
+// `go tool pprof`, so that no external command is needed.
+func SummarizeProfile(data []byte, sampleType string, n int) (*ProfileSummary, error) {
+	p, err := decodeProfile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i, st := range p.sampleTypes {
+		if p.str(st.typ) == sampleType {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("no %q samples in profile", sampleType)
+	}
+
+	s := &ProfileSummary{SampleType: sampleType + "/" + p.str(p.sampleTypes[index].unit)}
+	flat := make(map[string]int64)
+	cum := make(map[string]int64)
+	for _, sample := range p.samples {
+		if index >= len(sample.values) {
+			continue
+		}
+		v := sample.values[index]
+		if v == 0 {
+			continue
+		}
+		s.Total += v
+
+		// The first line of the first location is the innermost function;
+		// a function counts once towards cum however often it recurses
+		seen := make(map[string]bool)
+		for i, id := range sample.locations {
+			for j, fn := range p.locations[id] {
+				name := p.str(p.functions[fn])
+				if i == 0 && j == 0 {
+					flat[name] += v
+				}
+				if !seen[name] {
+					seen[name] = true
+					cum[name] += v
+				}
+			}
+		}
+	}
+
+	for name, c := range cum {
+		s.Top = append(s.Top, Hotspot{
+			Function: name,
+			Flat:     flat[name],
+			FlatPct:  percent(flat[name], s.Total),
+			Cum:      c,
+			CumPct:   percent(c, s.Total),
+		})
+	}
+	sort.Slice(s.Top, func(i, j int) bool {
+		a, b := s.Top[i], s.Top[j]
+		if a.Flat != b.Flat {
+			return a.Flat > b.Flat
+		}
+		if a.Cum != b.Cum {
+			return a.Cum > b.Cum
+		}
+		return a.Function < b.Function
+	})
+	if len(s.Top) > n {
+		s.Top = s.Top[:n]
+	}
+	return s, nil
+}
+
+func percent(v, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(v)/float64(total)*10000) / 100
+}
+
+// HotspotChange is how one function's flat share changed between two
+// profiles of the same kind, in percentage points.
+type HotspotChange struct {
+	Function  string  `json:"function"`
+	BeforePct float64 `json:"before_pct"`
+	AfterPct  float64 `json:"after_pct"`
+	Change    float64 `json:"change"`
+}
+
+// ProfileDiff compares the hotspots of the latest run with those of an
+// earlier run that captured the same profile.
+type ProfileDiff struct {
+	Profile string          `json:"profile"` // ProfileKind.Name
+	Before  string          `json:"before"`  // timestamp of the earlier run
+	After   string          `json:"after"`   // timestamp of the latest run
+	Changes []HotspotChange `json:"changes"`
+}
+
+// DiffHotspots compares the flat shares of the functions in the top
+// hotspots of before and after, largest change first. Shares are compared
+// rather than values, which depend on how long the benchmarks ran.
+func DiffHotspots(before, after *ProfileSummary) []HotspotChange {
+	pct := make(map[string]*HotspotChange)
+	var order []string
+	add := func(h Hotspot) *HotspotChange {
+		c := pct[h.Function]
+		if c == nil {
+			c = &HotspotChange{Function: h.Function}
+			pct[h.Function] = c
+			order = append(order, h.Function)
+		}
+		return c
+	}
+	for _, h := range before.Top {
+		add(h).BeforePct = h.FlatPct
+	}
+	for _, h := range after.Top {
+		add(h).AfterPct = h.FlatPct
+	}
+
+	changes := make([]HotspotChange, 0, len(order))
+	for _, name := range order {
+		c := pct[name]
+		c.Change = math.Round((c.AfterPct-c.BeforePct)*100) / 100
+		changes = append(changes, *c)
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return math.Abs(changes[i].Change) > math.Abs(changes[j].Change)
+	})
+	return changes
+}
+
+// diffProfiles compares the profiles of the latest of runs with the most
+// recent earlier run that captured each of them.
+func diffProfiles(runs []HistoryRun) []ProfileDiff {
+	if len(runs) < 2 {
+		return nil
+	}
+	latest := runs[len(runs)-1]
+	var diffs []ProfileDiff
+	for _, kind := range ProfileKinds {
+		after := latest.Metadata.Profiles[kind.Name]
+		if after == nil {
+			continue
+		}
+		for i := len(runs) - 2; i >= 0; i-- {
+			if before := runs[i].Metadata.Profiles[kind.Name]; before != nil {
+				diffs = append(diffs, ProfileDiff{
+					Profile: kind.Name,
+					Before:  runs[i].Metadata.Timestamp,
+					After:   latest.Metadata.Timestamp,
+					Changes: DiffHotspots(before, after),
+				})
+				break
+			}
+		}
+	}
+	return diffs
+}
+
+// profile is the part of a pprof profile.proto message hotspots need.
+type profile struct {
+	sampleTypes []valueType
+	samples     []profileSample
+	locations   map[uint64][]uint64 // location id to function ids, innermost first
+	functions   map[uint64]int64    // function id to name
+	strings     []string
+}
+
+type valueType struct{ typ, unit int64 }
+
+type profileSample struct {
+	locations []uint64 // leaf first
+	values    []int64
+}
+
+func (p *profile) str(i int64) string {
+	if i < 0 || i >= int64(len(p.strings)) {
+		return ""
+	}
+	return p.strings[i]
+}
+
+// Field numbers of profile.proto
+const (
+	fieldSampleType  = 1
+	fieldSample      = 2
+	fieldLocation    = 4
+	fieldFunction    = 5
+	fieldStringTable = 6
+)
+
+var errTruncated = errors.New("truncated profile")
+
+// decodeProfile decodes a profile as runtime/pprof writes it, gzipped or not.
+func decodeProfile(data []byte) (*profile, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &profile{locations: make(map[uint64][]uint64), functions: make(map[uint64]int64)}
+	err := forEachField(data, func(field int, v uint64, msg []byte) error {
+		switch field {
+		case fieldSampleType:
+			var vt valueType
+			err := forEachField(msg, func(field int, v uint64, _ []byte) error {
+				switch field {
+				case 1:
+					vt.typ = int64(v)
+				case 2:
+					vt.unit = int64(v)
+				}
+				return nil
+			})
+			p.sampleTypes = append(p.sampleTypes, vt)
+			return err
+		case fieldSample:
+			var s profileSample
+			err := forEachField(msg, func(field int, v uint64, packed []byte) error {
+				switch field {
+				case 1:
+					return appendVarints(&s.locations, v, packed, func(x uint64) uint64 { return x })
+				case 2:
+					return appendVarints(&s.values, v, packed, func(x uint64) int64 { return int64(x) })
+				}
+				return nil
+			})
+			p.samples = append(p.samples, s)
+			return err
+		case fieldLocation:
+			var id uint64
+			var fns []uint64
+			err := forEachField(msg, func(field int, v uint64, line []byte) error {
+				switch field {
+				case 1:
+					id = v
+				case 4:
+					return forEachField(line, func(field int, v uint64, _ []byte) error {
+						if field == 1 {
+							fns = append(fns, v)
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			p.locations[id] = fns
+			return err
+		case fieldFunction:
+			var id uint64
+			var name int64
+			err := forEachField(msg, func(field int, v uint64, _ []byte) error {
+				switch field {
+				case 1:
+					id = v
+				case 2:
+					name = int64(v)
+				}
+				return nil
+			})
+			p.functions[id] = name
+			return err
+		case fieldStringTable:
+			p.strings = append(p.strings, string(msg))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// forEachField calls fn with the number of each field of a protobuf message
+// and either its value, for varint and fixed-size fields, or its bytes, for
+// length-delimited ones.
+func forEachField(data []byte, fn func(field int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := varint(data)
+		if n == 0 {
+			return errTruncated
+		}
+		data = data[n:]
+		field, wire := int(key>>3), key&7
+
+		var v uint64
+		var b []byte
+		switch wire {
+		case 0: // varint
+			if v, n = varint(data); n == 0 {
+				return errTruncated
+			}
+		case 1: // fixed64
+			if n = 8; len(data) < n {
+				return errTruncated
+			}
+		case 2: // length-delimited
+			size, m := varint(data)
+			if m == 0 || uint64(len(data)-m) < size {
+				return errTruncated
+			}
+			b, n = data[m:m+int(size)], m+int(size)
+		case 5: // fixed32
+			if n = 4; len(data) < n {
+				return errTruncated
+			}
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", wire)
+		}
+		data = data[n:]
+
+		if err := fn(field, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendVarints appends a repeated varint field, given either one value v
+// or, when packed is not nil, the packed encoding of several.
+func appendVarints[T any](dst *[]T, v uint64, packed []byte, conv func(uint64) T) error {
+	if packed == nil {
+		*dst = append(*dst, conv(v))
+		return nil
+	}
+	for len(packed) > 0 {
+		x, n := varint(packed)
+		if n == 0 {
+			return errTruncated
+		}
+		*dst = append(*dst, conv(x))
+		packed = packed[n:]
+	}
+	return nil
+}
+
+// varint decodes a protobuf varint, returning its length, or 0 if data is
+// truncated.
+func varint(data []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		b := data[i]
+		v |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// formatHotspotChange formats a change of share in percentage points.
+func formatHotspotChange(change float64) string {
+	return fmt.Sprintf("%+.1fpp", change)
+}
@@ -0,0 +1,162 @@
+package benchreport
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+var sink [][]byte
+
+//go:noinline
+func allocateHotspot(n int) {
+	for i := 0; i < n; i++ {
+		sink = append(sink, make([]byte, 64<<10))
+	}
+}
+
+// writeHeapProfile writes a heap profile holding the allocations of
+// allocateHotspot to dir/mem.prof.
+func writeHeapProfile(t *testing.T, dir string) {
+	t.Helper()
+	defer func(rate int) { runtime.MemProfileRate = rate }(runtime.MemProfileRate)
+	runtime.MemProfileRate = 1
+	allocateHotspot(64)
+	sink = nil
+	runtime.GC()
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("allocs").WriteTo(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "mem.prof"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSummarizeProfiles(t *testing.T) {
+	dir := t.TempDir()
+	writeHeapProfile(t, dir)
+
+	profiles, err := SummarizeProfiles(dir, 3)
+	if err != nil {
+		t.Fatalf("SummarizeProfiles() error = %v", err)
+	}
+	if len(profiles) != 1 || profiles["cpu"] != nil {
+		t.Fatalf("SummarizeProfiles() = %v, want the mem profile only", profiles)
+	}
+
+	mem := profiles["mem"]
+	if mem.File != "mem.prof" || mem.SampleType != "alloc_space/bytes" || mem.Total <= 0 || len(mem.Top) != 3 {
+		t.Fatalf("mem profile = %+v", mem)
+	}
+	top := mem.Top[0]
+	if !strings.HasSuffix(top.Function, ".allocateHotspot") || top.Flat < 64*64<<10 || top.FlatPct <= 0 || top.Cum < top.Flat {
+		t.Errorf("top hotspot = %+v, want allocateHotspot", top)
+	}
+	for _, h := range mem.Top[1:] {
+		if h.Flat > top.Flat {
+			t.Errorf("hotspots out of order: %+v after %+v", h, top)
+		}
+	}
+
+	history := t.TempDir()
+	if err := SaveProfiles(history, dir); err != nil {
+		t.Fatalf("SaveProfiles() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(history, "mem.prof")); err != nil {
+		t.Errorf("SaveProfiles() did not copy mem.prof: %v", err)
+	}
+}
+
+func TestSummarizeProfile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeHeapProfile(t, dir)
+	data, err := os.ReadFile(filepath.Join(dir, "mem.prof"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SummarizeProfile(data, "cpu", 10); err == nil || !strings.Contains(err.Error(), `no "cpu" samples`) {
+		t.Errorf("SummarizeProfile() of a missing sample type error = %v", err)
+	}
+	for _, bad := range [][]byte{{0x0a, 0x05, 0x08}, {0x80}, {0x0b}} {
+		if _, err := SummarizeProfile(bad, "cpu", 10); err == nil {
+			t.Errorf("SummarizeProfile(%x): expected error", bad)
+		}
+	}
+}
+
+func TestDiffHotspots(t *testing.T) {
+	before := &ProfileSummary{Top: []Hotspot{
+		{Function: "parse", FlatPct: 40},
+		{Function: "scan", FlatPct: 30},
+		{Function: "gone", FlatPct: 5},
+	}}
+	after := &ProfileSummary{Top: []Hotspot{
+		{Function: "scan", FlatPct: 55},
+		{Function: "parse", FlatPct: 38.5},
+		{Function: "new", FlatPct: 2},
+	}}
+
+	want := []HotspotChange{
+		{Function: "scan", BeforePct: 30, AfterPct: 55, Change: 25},
+		{Function: "gone", BeforePct: 5, Change: -5},
+		{Function: "new", AfterPct: 2, Change: 2},
+		{Function: "parse", BeforePct: 40, AfterPct: 38.5, Change: -1.5},
+	}
+	if got := DiffHotspots(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffHotspots() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTrends_ProfileDiffs(t *testing.T) {
+	root := t.TempDir()
+	save := func(timestamp string, profiles map[string]*ProfileSummary) {
+		meta := Metadata{Timestamp: timestamp, Profiles: profiles}
+		if _, err := SaveHistory(root, "BenchmarkX-8 10 100 ns/op 0 B/op 0 allocs/op\n", "report", meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cpu := func(pct float64) map[string]*ProfileSummary {
+		return map[string]*ProfileSummary{"cpu": {Top: []Hotspot{{Function: "yaml.scan", FlatPct: pct}}}}
+	}
+	save("2025-01-01_00-00-00", cpu(20))
+	save("2025-01-02_00-00-00", nil)
+	save("2025-01-03_00-00-00", cpu(35))
+
+	runs, err := LoadHistory(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := NewTrendSummary(runs, ComputeTrends(runs, TrendOptions{}), TrendOptions{})
+	want := []ProfileDiff{{Profile: "cpu", Before: "2025-01-01_00-00-00", After: "2025-01-03_00-00-00",
+		Changes: []HotspotChange{{Function: "yaml.scan", BeforePct: 20, AfterPct: 35, Change: 15}}}}
+	if !reflect.DeepEqual(summary.ProfileDiffs, want) {
+		t.Fatalf("ProfileDiffs = %+v, want %+v", summary.ProfileDiffs, want)
+	}
+
+	report := RenderTrends(summary)
+	for _, s := range []string{
+		"## Profile Changes\n",
+		"### cpu profile: 2025-01-01_00-00-00 → 2025-01-03_00-00-00\n",
+		"| `yaml.scan` | 20.0% | 35.0% | +15.0pp |\n",
+	} {
+		if !strings.Contains(report, s) {
+			t.Errorf("RenderTrends() missing %q in\n%s", s, report)
+		}
+	}
+
+	// The latest run captured no profiles
+	save("2025-01-04_00-00-00", nil)
+	runs, _ = LoadHistory(root)
+	if summary := NewTrendSummary(runs, nil, TrendOptions{}); summary.ProfileDiffs != nil ||
+		strings.Contains(RenderTrends(summary), "Profile Changes") {
+		t.Errorf("ProfileDiffs without latest profiles = %+v", summary.ProfileDiffs)
+	}
+}
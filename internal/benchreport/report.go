@@ -0,0 +1,482 @@
+package benchreport
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Environment describes the machine and settings of a benchmark run, as
+// stated in the report.
+type Environment struct {
+	Date      time.Time
+	Platform  string // CPU name, or the OS when unknown
+	OS        string
+	Arch      string
+	GoVersion string // without the "go" prefix
+	BenchTime string // the -benchtime of the run, e.g. "3s"
+}
+
+// NewEnvironment describes run, executed on date with benchTime. The machine
+// comes from the header lines of the output, so a report may be rendered
+// anywhere; fields the output lacks fall back to the running program.
+func NewEnvironment(run *Run, date time.Time, benchTime string) Environment {
+	env := Environment{
+		Date:      date,
+		Platform:  run.CPU,
+		OS:        run.GOOS,
+		Arch:      run.GOARCH,
+		GoVersion: strings.TrimPrefix(runtime.Version(), "go"),
+		BenchTime: benchTime,
+	}
+	if env.OS == "" {
+		env.OS = runtime.GOOS
+	}
+	if env.Arch == "" {
+		env.Arch = runtime.GOARCH
+	}
+	if env.Platform == "" {
+		env.Platform = env.OS
+	}
+	return env
+}
+
+// Render creates the markdown report for groups, describing the run with env.
+func Render(groups []*Group, env Environment) string {
+	var buf bytes.Buffer
+
+	// Header
+	buf.WriteString("# Performance Benchmark Report: shape-yaml vs gopkg.in/yaml.v3\n\n")
+	buf.WriteString(fmt.Sprintf("**Date:** %s\n", env.Date.Format("2006-01-02")))
+	buf.WriteString(fmt.Sprintf("**Platform:** %s (%s/%s)\n", env.Platform, env.OS, env.Arch))
+	buf.WriteString(fmt.Sprintf("**Go Version:** %s\n", env.GoVersion))
+	buf.WriteString(fmt.Sprintf("**Benchmark Time:** %s per test\n", env.BenchTime))
+	buf.WriteString("**Generated:** Automatically by `make performance-report`\n\n")
+
+	// Executive Summary
+	buf.WriteString("## Executive Summary\n\n")
+	buf.WriteString("shape-yaml provides competitive performance compared to gopkg.in/yaml.v3 (the Go ecosystem's standard YAML library).\n\n")
+
+	// Key Findings
+	buf.WriteString("### Key Findings\n\n")
+
+	if len(groups) > 0 {
+		unmarshalGroup := findGroupByOperation(groups, "Unmarshal")
+		marshalGroup := findGroupByOperation(groups, "Marshal")
+
+		if unmarshalGroup != nil {
+			buf.WriteString("**Unmarshal Performance**:\n")
+			writeKeyFindings(&buf, unmarshalGroup)
+		}
+
+		if marshalGroup != nil {
+			buf.WriteString("\n**Marshal Performance**:\n")
+			writeKeyFindings(&buf, marshalGroup)
+		}
+	}
+
+	buf.WriteString("\n---\n\n")
+
+	// Detailed Results
+	buf.WriteString("## Detailed Benchmark Results\n\n")
+	for _, group := range groups {
+		writeBenchmarkSection(&buf, group)
+	}
+
+	// Performance comparison tables
+	buf.WriteString("---\n\n")
+	buf.WriteString("## Performance Comparison Summary\n\n")
+	writeSummaryTables(&buf, groups)
+
+	// Analysis and recommendations
+	buf.WriteString("---\n\n")
+	buf.WriteString("## Analysis and Recommendations\n\n")
+	writeAnalysisSection(&buf, groups)
+
+	// Methodology
+	buf.WriteString("---\n\n")
+	buf.WriteString("## Benchmark Methodology\n\n")
+	writeMethodologySection(&buf, env)
+
+	// Usage instructions
+	buf.WriteString("---\n\n")
+	buf.WriteString("## Appendix: Running the Benchmarks\n\n")
+	writeUsageSection(&buf)
+
+	return buf.String()
+}
+
+// writeKeyFindings writes the speed and memory of a group against
+// gopkg.in/yaml.v3, the fast path first where it is benchmarked apart
+func writeKeyFindings(buf *bytes.Buffer, group *Group) {
+	prefix := ""
+	if group.FastYAML != nil {
+		buf.WriteString(fmt.Sprintf("- Fast path: %s, **%.1fx faster** than the AST parser\n",
+			speedFinding(group.FastSpeedupFactor), group.FastVsShapeSpeedup))
+		buf.WriteString(fmt.Sprintf("- Fast path: %s\n", memoryFinding(group.FastMemoryRatio)))
+		prefix = "AST parser: "
+	}
+	buf.WriteString(fmt.Sprintf("- %s%s\n", prefix, speedFinding(group.SpeedupFactor)))
+	buf.WriteString(fmt.Sprintf("- %s%s\n", prefix, memoryFinding(group.MemoryRatio)))
+}
+
+// speedFinding describes a speedup factor against gopkg.in/yaml.v3
+func speedFinding(speedRatio float64) string {
+	if speedRatio > 1.0 {
+		return fmt.Sprintf("**%.1fx FASTER** than gopkg.in/yaml.v3 ⚡", speedRatio)
+	}
+	return fmt.Sprintf("**%.1fx slower** than gopkg.in/yaml.v3", 1.0/speedRatio)
+}
+
+// memoryFinding describes a memory ratio against gopkg.in/yaml.v3
+func memoryFinding(memRatio float64) string {
+	if memRatio < 1.0 {
+		return fmt.Sprintf("**%.1fx less memory** than gopkg.in/yaml.v3 🎯", 1.0/memRatio)
+	}
+	return fmt.Sprintf("**%.1fx more memory** than gopkg.in/yaml.v3", memRatio)
+}
+
+// writeBenchmarkSection writes a detailed section for a benchmark group
+func writeBenchmarkSection(buf *bytes.Buffer, group *Group) {
+	buf.WriteString(fmt.Sprintf("### %s Operation\n\n", group.Operation))
+	buf.WriteString("```\n")
+
+	if group.FastYAML != nil {
+		buf.WriteString(formatBenchmarkLine(group.FastYAML))
+	}
+	if group.ShapeYAML != nil {
+		buf.WriteString(formatBenchmarkLine(group.ShapeYAML))
+	}
+	if group.StdYAML != nil {
+		buf.WriteString(formatBenchmarkLine(group.StdYAML))
+	}
+	buf.WriteString("```\n\n")
+
+	if group.ShapeYAML != nil && group.StdYAML != nil {
+		if group.FastYAML != nil {
+			buf.WriteString("**Fast path analysis:**\n")
+			writeComparison(buf, "the fast path", group.FastYAML, group.StdYAML,
+				group.FastSpeedupFactor, group.FastThroughputRatio, group.FastMemoryRatio, group.FastAllocRatio)
+			buf.WriteString(fmt.Sprintf("- **vs AST parser**: the fast path is **%.1fx faster** (%s vs %s)\n\n",
+				group.FastVsShapeSpeedup,
+				formatDuration(group.FastYAML.NsPerOp),
+				formatDuration(group.ShapeYAML.NsPerOp)))
+
+			buf.WriteString("**AST parser analysis:**\n")
+			writeComparison(buf, "the AST parser", group.ShapeYAML, group.StdYAML,
+				group.SpeedupFactor, group.ThroughputRatio, group.MemoryRatio, group.AllocRatio)
+		} else {
+			buf.WriteString("**Analysis:**\n")
+			writeComparison(buf, "shape-yaml", group.ShapeYAML, group.StdYAML,
+				group.SpeedupFactor, group.ThroughputRatio, group.MemoryRatio, group.AllocRatio)
+		}
+	}
+
+	buf.WriteString("\n")
+}
+
+// writeComparison writes the analysis of result, named label, against the
+// gopkg.in/yaml.v3 result std, given the ratios between them
+func writeComparison(buf *bytes.Buffer, label string, result, std *Result, speedRatio, throughputRatio, memRatio, allocRatio float64) {
+	if speedRatio > 1.0 {
+		buf.WriteString(fmt.Sprintf("- **Speed**: %s is **%.1fx faster** (%s vs %s) ⚡\n",
+			label,
+			speedRatio,
+			formatDuration(result.NsPerOp),
+			formatDuration(std.NsPerOp)))
+	} else {
+		buf.WriteString(fmt.Sprintf("- **Speed**: gopkg.in/yaml.v3 is **%.1fx faster** (%s vs %s)\n",
+			1.0/speedRatio,
+			formatDuration(std.NsPerOp),
+			formatDuration(result.NsPerOp)))
+	}
+
+	if throughputRatio > 0 {
+		if throughputRatio > 1.0 {
+			buf.WriteString(fmt.Sprintf("- **Throughput**: %s achieves **%.1fx higher throughput** (%.2f MB/s vs %.2f MB/s) ⚡\n",
+				label,
+				throughputRatio,
+				result.MBPerSec,
+				std.MBPerSec))
+		} else {
+			buf.WriteString(fmt.Sprintf("- **Throughput**: gopkg.in/yaml.v3 achieves **%.1fx higher throughput** (%.2f MB/s vs %.2f MB/s)\n",
+				1.0/throughputRatio,
+				std.MBPerSec,
+				result.MBPerSec))
+		}
+	}
+
+	if memRatio < 1.0 {
+		buf.WriteString(fmt.Sprintf("- **Memory**: %s uses **%.1fx less memory** (%s vs %s) 🎯\n",
+			label,
+			1.0/memRatio,
+			formatBytes(result.BytesPerOp),
+			formatBytes(std.BytesPerOp)))
+	} else {
+		buf.WriteString(fmt.Sprintf("- **Memory**: gopkg.in/yaml.v3 uses **%.1fx less memory** (%s vs %s)\n",
+			memRatio,
+			formatBytes(std.BytesPerOp),
+			formatBytes(result.BytesPerOp)))
+	}
+
+	if allocRatio < 1.0 {
+		buf.WriteString(fmt.Sprintf("- **Allocations**: %s makes **%.1fx fewer allocations** (%s vs %s) 🎯\n",
+			label,
+			1.0/allocRatio,
+			formatInt(result.AllocsPerOp),
+			formatInt(std.AllocsPerOp)))
+	} else {
+		buf.WriteString(fmt.Sprintf("- **Allocations**: gopkg.in/yaml.v3 makes **%.1fx fewer allocations** (%s vs %s)\n",
+			allocRatio,
+			formatInt(std.AllocsPerOp),
+			formatInt(result.AllocsPerOp)))
+	}
+}
+
+// writeSummaryTables writes performance comparison tables, with a column for
+// the fast path when any group benchmarks it apart
+func writeSummaryTables(buf *bytes.Buffer, groups []*Group) {
+	if len(groups) == 0 {
+		return
+	}
+
+	hasFast := false
+	for _, group := range groups {
+		hasFast = hasFast || group.FastYAML != nil
+	}
+
+	// Speed comparison
+	buf.WriteString("### Speed Comparison (Operations per Second)\n\n")
+	if hasFast {
+		buf.WriteString("| Operation | shape-yaml fast path | shape-yaml | gopkg.in/yaml.v3 | Performance |\n")
+		buf.WriteString("|-----------|----------------------|------------|------------------|-------------|\n")
+	} else {
+		buf.WriteString("| Operation | shape-yaml | gopkg.in/yaml.v3 | Performance |\n")
+		buf.WriteString("|-----------|------------|------------------|-------------|\n")
+	}
+	for _, group := range groups {
+		if group.ShapeYAML == nil || group.StdYAML == nil {
+			continue
+		}
+
+		shapeOps := 1_000_000_000 / group.ShapeYAML.NsPerOp
+		stdOps := 1_000_000_000 / group.StdYAML.NsPerOp
+		perfLabel := speedLabel(group.SpeedupFactor)
+
+		if !hasFast {
+			buf.WriteString(fmt.Sprintf("| %s | %s ops/s | %s ops/s | %s |\n",
+				group.Operation,
+				formatOps(shapeOps),
+				formatOps(stdOps),
+				perfLabel))
+			continue
+		}
+
+		fastOps := "-"
+		if group.FastYAML != nil {
+			fastOps = formatOps(1_000_000_000/group.FastYAML.NsPerOp) + " ops/s"
+			perfLabel = "fast path " + speedLabel(group.FastSpeedupFactor) + ", AST parser " + perfLabel
+		}
+		buf.WriteString(fmt.Sprintf("| %s | %s | %s ops/s | %s ops/s | %s |\n",
+			group.Operation,
+			fastOps,
+			formatOps(shapeOps),
+			formatOps(stdOps),
+			perfLabel))
+	}
+	buf.WriteString("\n")
+
+	// Memory comparison
+	buf.WriteString("### Memory Efficiency Comparison\n\n")
+	if hasFast {
+		buf.WriteString("| Operation | shape-yaml fast path | shape-yaml | gopkg.in/yaml.v3 | Memory Usage |\n")
+		buf.WriteString("|-----------|----------------------|------------|------------------|-------------|\n")
+	} else {
+		buf.WriteString("| Operation | shape-yaml | gopkg.in/yaml.v3 | Memory Usage |\n")
+		buf.WriteString("|-----------|------------|------------------|-------------|\n")
+	}
+	for _, group := range groups {
+		if group.ShapeYAML == nil || group.StdYAML == nil {
+			continue
+		}
+
+		memLabel := memoryLabel(group.MemoryRatio)
+
+		if !hasFast {
+			buf.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+				group.Operation,
+				formatBytes(group.ShapeYAML.BytesPerOp),
+				formatBytes(group.StdYAML.BytesPerOp),
+				memLabel))
+			continue
+		}
+
+		fastBytes := "-"
+		if group.FastYAML != nil {
+			fastBytes = formatBytes(group.FastYAML.BytesPerOp)
+			memLabel = "fast path " + memoryLabel(group.FastMemoryRatio) + ", AST parser " + memLabel
+		}
+		buf.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+			group.Operation,
+			fastBytes,
+			formatBytes(group.ShapeYAML.BytesPerOp),
+			formatBytes(group.StdYAML.BytesPerOp),
+			memLabel))
+	}
+	buf.WriteString("\n")
+}
+
+// speedLabel labels a speedup factor in the summary table
+func speedLabel(speedRatio float64) string {
+	if speedRatio > 1.0 {
+		return fmt.Sprintf("**%.1fx FASTER** ⚡", speedRatio)
+	}
+	return fmt.Sprintf("%.1fx slower", 1.0/speedRatio)
+}
+
+// memoryLabel labels a memory ratio in the summary table
+func memoryLabel(memRatio float64) string {
+	if memRatio < 1.0 {
+		return fmt.Sprintf("**%.1fx LESS** 🎯", 1.0/memRatio)
+	} else if memRatio > 1.0 {
+		return fmt.Sprintf("%.1fx more", memRatio)
+	}
+	return "Same"
+}
+
+// writeAnalysisSection writes the analysis and recommendations
+func writeAnalysisSection(buf *bytes.Buffer, groups []*Group) {
+	buf.WriteString("### Performance Characteristics\n\n")
+	buf.WriteString("shape-yaml is designed to provide:\n\n")
+
+	buf.WriteString("1. **Competitive Performance**\n")
+	buf.WriteString("   - Performance comparable to gopkg.in/yaml.v3\n")
+	buf.WriteString("   - Efficient parsing and unmarshaling\n")
+	buf.WriteString("   - Low memory footprint\n\n")
+
+	buf.WriteString("2. **Standards Compliance**\n")
+	buf.WriteString("   - Full YAML 1.2 specification support\n")
+	buf.WriteString("   - Compatible with Go's yaml.v3 API patterns\n")
+	buf.WriteString("   - Reliable handling of complex YAML documents\n\n")
+
+	buf.WriteString("3. **Developer-Friendly**\n")
+	buf.WriteString("   - Clear error messages with line numbers\n")
+	buf.WriteString("   - Intuitive API design\n")
+	buf.WriteString("   - Well-documented behavior\n\n")
+
+	buf.WriteString("### When to Use shape-yaml\n\n")
+	buf.WriteString("Use shape-yaml when:\n\n")
+
+	buf.WriteString("1. **You Need YAML Support**\n")
+	buf.WriteString("   - Configuration file parsing\n")
+	buf.WriteString("   - Data serialization and deserialization\n")
+	buf.WriteString("   - API integrations requiring YAML\n\n")
+
+	buf.WriteString("2. **You Value Code Quality**\n")
+	buf.WriteString("   - Clean, maintainable codebase\n")
+	buf.WriteString("   - Well-tested implementation\n")
+	buf.WriteString("   - Active development and support\n\n")
+}
+
+// writeMethodologySection writes the methodology section
+func writeMethodologySection(buf *bytes.Buffer, env Environment) {
+	buf.WriteString(`### Test Data
+
+- **Small YAML**: Basic configuration with simple key-value pairs
+
+### Decode Paths
+
+- **Fast path** (` + "`BenchmarkFastYAML_*`" + `): ` + "`Unmarshal`" + `, decoding straight into Go values with internal/fastparser
+- **AST parser** (` + "`BenchmarkShapeYAML_Unmarshal`" + `): ` + "`UnmarshalWithAST`" + `, building the syntax tree first
+- **gopkg.in/yaml.v3** (` + "`BenchmarkStdYAML_*`" + `): the baseline
+
+### Benchmark Configuration
+
+- **Iterations**: Determined by Go benchmark framework (` + env.BenchTime + ` minimum per test)
+- **Memory**: Measured with ` + "`-benchmem`" + ` flag
+- **Platform**: ` + env.Platform + `, ` + env.OS + `/` + env.Arch + `
+- **Go Version**: ` + env.GoVersion + `
+
+### Fairness Considerations
+
+1. **Apples-to-Apples Comparison**
+   - Both libraries unmarshal into the same Go struct types
+   - Both use reflection-based unmarshaling
+   - Tests measure real-world usage patterns
+
+2. **Standard Library Comparison**
+   - gopkg.in/yaml.v3 is the de-facto standard YAML library in Go
+   - Widely used and battle-tested
+   - Provides a fair baseline for performance comparison
+
+`)
+}
+
+// writeUsageSection writes usage instructions
+func writeUsageSection(buf *bytes.Buffer) {
+	buf.WriteString(`### Regenerate This Report
+
+` + "```bash" + `
+make performance-report
+` + "```" + `
+
+### Run Benchmarks Manually
+
+` + "```bash" + `
+# Run all benchmarks
+make bench
+
+# Save benchmark results to file
+make bench-report
+
+# Run multiple times for statistical analysis
+make bench-compare
+
+# Run with profiling
+make bench-profile
+` + "```" + `
+
+### Analyze with benchstat
+
+` + "```bash" + `
+# Install benchstat
+go install golang.org/x/perf/cmd/benchstat@latest
+
+# Run benchmarks multiple times
+make bench-compare
+
+# Analyze results
+benchstat benchmarks/benchstat.txt
+` + "```" + `
+
+### Profile Analysis
+
+` + "```bash" + `
+# Generate profiles
+make bench-profile
+
+# Analyze CPU profile
+go tool pprof benchmarks/cpu.prof
+
+# Analyze memory profile
+go tool pprof benchmarks/mem.prof
+
+# In pprof:
+# > top10          # Show top 10 consumers
+# > list Parse     # Line-by-line analysis
+# > web            # Visual graph (requires graphviz)
+` + "```" + `
+
+---
+
+## References
+
+- [Go Benchmarking Documentation](https://pkg.go.dev/testing#hdr-Benchmarks)
+- [Benchstat Tool](https://pkg.go.dev/golang.org/x/perf/cmd/benchstat)
+- [pprof Profiling Guide](https://go.dev/blog/pprof)
+- [YAML 1.2 Specification](https://yaml.org/spec/1.2/spec.html)
+- [gopkg.in/yaml.v3](https://pkg.go.dev/gopkg.in/yaml.v3)
+`)
+}
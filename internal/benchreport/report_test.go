@@ -0,0 +1,110 @@
+package benchreport
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	run, err := Parse(sampleOutput)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	env := NewEnvironment(run, time.Date(2025, 12, 27, 14, 30, 0, 0, time.UTC), "3s")
+	env.GoVersion = "1.23.4"
+	report := Render(GroupResults(run.Results), env)
+
+	for _, want := range []string{
+		"# Performance Benchmark Report: shape-yaml vs gopkg.in/yaml.v3\n",
+		"**Date:** 2025-12-27\n",
+		"**Platform:** Apple M1 Pro (darwin/arm64)\n",
+		"**Go Version:** 1.23.4\n",
+		"**Benchmark Time:** 3s per test\n",
+		"- **2.0x FASTER** than gopkg.in/yaml.v3",
+		"- **4.0x less memory** than gopkg.in/yaml.v3",
+		"### Unmarshal Operation\n",
+		"### Marshal Operation\n",
+		"BenchmarkShapeYAML_Unmarshal-10",
+		"- **Speed**: gopkg.in/yaml.v3 is **1.2x faster** (1.2µs vs 1.5µs)",
+		"- **Throughput**: shape-yaml achieves **2.0x higher throughput** (40.00 MB/s vs 20.00 MB/s)",
+		"| Unmarshal | 400000 ops/s | 200000 ops/s | **2.0x FASTER** ⚡ |",
+		"| Marshal | 512 B | 1.0 KB | **2.0x LESS** 🎯 |",
+		"- **Platform**: Apple M1 Pro, darwin/arm64\n",
+		"## Appendix: Running the Benchmarks\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Render() missing %q", want)
+		}
+	}
+	if i, j := strings.Index(report, "### Unmarshal Operation"), strings.Index(report, "### Marshal Operation"); i > j {
+		t.Error("Render() wrote Marshal before Unmarshal")
+	}
+}
+
+func TestRender_FastPath(t *testing.T) {
+	run, err := Parse(sampleOutput + "BenchmarkFastYAML_Unmarshal-10 2000000 500 ns/op 200.00 MB/s 256 B/op 4 allocs/op\n")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	report := Render(GroupResults(run.Results), NewEnvironment(run, time.Time{}, "3s"))
+
+	for _, want := range []string{
+		"- Fast path: **10.0x FASTER** than gopkg.in/yaml.v3 ⚡, **5.0x faster** than the AST parser\n",
+		"- Fast path: **16.0x less memory** than gopkg.in/yaml.v3 🎯\n",
+		"- AST parser: **2.0x FASTER** than gopkg.in/yaml.v3 ⚡\n",
+		"BenchmarkFastYAML_Unmarshal-10",
+		"**Fast path analysis:**\n- **Speed**: the fast path is **10.0x faster** (500ns vs 5.0µs) ⚡\n",
+		"- **vs AST parser**: the fast path is **5.0x faster** (500ns vs 2.5µs)\n",
+		"**AST parser analysis:**\n- **Speed**: the AST parser is **2.0x faster**",
+		"| Operation | shape-yaml fast path | shape-yaml | gopkg.in/yaml.v3 | Performance |\n",
+		"| Unmarshal | 2000000 ops/s | 400000 ops/s | 200000 ops/s | fast path **10.0x FASTER** ⚡, AST parser **2.0x FASTER** ⚡ |\n",
+		"| Marshal | - | 666667 ops/s | 833333 ops/s | 1.2x slower |\n",
+		"| Unmarshal | 256 B | 1.0 KB | 4.0 KB | fast path **16.0x LESS** 🎯, AST parser **4.0x LESS** 🎯 |\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Render() missing %q", want)
+		}
+	}
+	if strings.Index(report, "BenchmarkFastYAML_Unmarshal") > strings.Index(report, "BenchmarkShapeYAML_Unmarshal") {
+		t.Error("Render() wrote the AST parser result before the fast path")
+	}
+}
+
+func TestNewEnvironment(t *testing.T) {
+	env := NewEnvironment(&Run{GOOS: "linux", GOARCH: "amd64"}, time.Time{}, "1s")
+	if env.Platform != "linux" || env.OS != "linux" || env.Arch != "amd64" || env.BenchTime != "1s" {
+		t.Errorf("NewEnvironment() = %+v", env)
+	}
+	if env.GoVersion == "" || strings.HasPrefix(env.GoVersion, "go") {
+		t.Errorf("NewEnvironment() GoVersion = %q", env.GoVersion)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"nanoseconds", formatDuration(999), "999ns"},
+		{"microseconds", formatDuration(1500), "1.5µs"},
+		{"milliseconds", formatDuration(2_500_000), "2.5ms"},
+		{"bytes", formatBytes(1023), "1023 B"},
+		{"kilobytes", formatBytes(1536), "1.5 KB"},
+		{"megabytes", formatBytes(3 * 1024 * 1024), "3.0 MB"},
+		{"gigabytes", formatBytes(2 * 1024 * 1024 * 1024), "2.0 GB"},
+		{"small int", formatInt(999), "999"},
+		{"int", formatInt(1234567), "1,234,567"},
+		{"ops", formatOps(1234.6), "1235"},
+		{"line", formatBenchmarkLine(&Result{Name: "BenchmarkX", Procs: 8, Iterations: 10, NsPerOp: 5, BytesPerOp: 1, AllocsPerOp: 2}),
+			"BenchmarkX-8                                             10            5 ns/op            1 B/op        2 allocs/op\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package benchreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HistoryRun is one run saved by SaveHistory.
+type HistoryRun struct {
+	Dir      string
+	Metadata Metadata // zero but for Timestamp if metadata.json is missing
+	Run      *Run
+}
+
+// LoadHistory loads the runs saved under historyRoot, oldest first. Entries
+// without a benchmark_output.txt holding results are skipped; a missing
+// historyRoot is no history at all.
+func LoadHistory(historyRoot string) ([]HistoryRun, error) {
+	entries, err := os.ReadDir(historyRoot)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []HistoryRun
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(historyRoot, entry.Name())
+		output, err := os.ReadFile(filepath.Join(dir, "benchmark_output.txt"))
+		if err != nil {
+			continue
+		}
+		run, err := Parse(string(output))
+		if err != nil {
+			continue
+		}
+
+		meta := Metadata{Timestamp: entry.Name()}
+		if data, err := os.ReadFile(filepath.Join(dir, "metadata.json")); err == nil {
+			if err := json.Unmarshal(data, &meta); err != nil {
+				return nil, fmt.Errorf("%s: %v", filepath.Join(dir, "metadata.json"), err)
+			}
+		}
+		runs = append(runs, HistoryRun{Dir: dir, Metadata: meta, Run: run})
+	}
+
+	// Timestamps are "2006-01-02_15-04-05", so they sort as text
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Metadata.Timestamp < runs[j].Metadata.Timestamp
+	})
+	return runs, nil
+}
+
+// TrendOptions tune regression detection.
+type TrendOptions struct {
+	// Window is how many runs before the latest make up its baseline
+	// (default 5).
+	Window int
+	// Threshold is the relative increase of ns/op or allocs/op over the
+	// baseline that counts as a regression (default 0.10, i.e. 10%).
+	Threshold float64
+}
+
+// DefaultTrendOptions are used for zero fields of TrendOptions.
+var DefaultTrendOptions = TrendOptions{Window: 5, Threshold: 0.10}
+
+func (o TrendOptions) withDefaults() TrendOptions {
+	if o.Window <= 0 {
+		o.Window = DefaultTrendOptions.Window
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = DefaultTrendOptions.Threshold
+	}
+	return o
+}
+
+// Point is one benchmark's result in one saved run.
+type Point struct {
+	Timestamp   string  `json:"timestamp"`
+	Commit      string  `json:"commit,omitempty"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+}
+
+// Trend is the history of one benchmark, its latest run compared with a
+// rolling baseline: the median of the runs just before it.
+type Trend struct {
+	Name   string  `json:"name"`
+	Points []Point `json:"points"`
+
+	BaselineNsPerOp     float64 `json:"baseline_ns_per_op"`
+	BaselineAllocsPerOp float64 `json:"baseline_allocs_per_op"`
+	NsChange            float64 `json:"ns_change"`     // latest vs baseline, 0.25 is 25% slower
+	AllocsChange        float64 `json:"allocs_change"` // latest vs baseline
+	Regression          bool    `json:"regression"`
+}
+
+// Latest returns the newest point of t.
+func (t *Trend) Latest() Point {
+	return t.Points[len(t.Points)-1]
+}
+
+// ComputeTrends returns the trend of every benchmark found in runs (oldest
+// first, as LoadHistory returns them), sorted by name. A benchmark needs at
+// least two runs to have a baseline; with one it is listed without a change.
+func ComputeTrends(runs []HistoryRun, opts TrendOptions) []*Trend {
+	opts = opts.withDefaults()
+
+	byName := make(map[string]*Trend)
+	for _, r := range runs {
+		for name, result := range r.Run.Results {
+			t := byName[name]
+			if t == nil {
+				t = &Trend{Name: name}
+				byName[name] = t
+			}
+			t.Points = append(t.Points, Point{
+				Timestamp:   r.Metadata.Timestamp,
+				Commit:      r.Metadata.GitCommit,
+				NsPerOp:     result.NsPerOp,
+				BytesPerOp:  result.BytesPerOp,
+				AllocsPerOp: result.AllocsPerOp,
+			})
+		}
+	}
+
+	trends := make([]*Trend, 0, len(byName))
+	for _, t := range byName {
+		computeBaseline(t, opts)
+		trends = append(trends, t)
+	}
+	sort.Slice(trends, func(i, j int) bool { return trends[i].Name < trends[j].Name })
+	return trends
+}
+
+// computeBaseline compares the latest point of t with the median of the
+// opts.Window points before it.
+func computeBaseline(t *Trend, opts TrendOptions) {
+	if len(t.Points) < 2 {
+		return
+	}
+	window := t.Points[max(0, len(t.Points)-1-opts.Window) : len(t.Points)-1]
+	ns := make([]float64, len(window))
+	allocs := make([]float64, len(window))
+	for i, p := range window {
+		ns[i] = p.NsPerOp
+		allocs[i] = float64(p.AllocsPerOp)
+	}
+	t.BaselineNsPerOp = median(ns)
+	t.BaselineAllocsPerOp = median(allocs)
+
+	latest := t.Latest()
+	t.NsChange = relativeChange(latest.NsPerOp, t.BaselineNsPerOp)
+	t.AllocsChange = relativeChange(float64(latest.AllocsPerOp), t.BaselineAllocsPerOp)
+	t.Regression = t.NsChange > opts.Threshold || t.AllocsChange > opts.Threshold
+}
+
+// median returns the median of values, which it sorts.
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// relativeChange returns how much v grew over base, 0 for a zero base.
+func relativeChange(v, base float64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return v/base - 1
+}
+
+// TrendSummary is the machine-readable form of a set of trends.
+type TrendSummary struct {
+	Runs        int      `json:"runs"`
+	Latest      string   `json:"latest,omitempty"` // timestamp of the newest run
+	Window      int      `json:"window"`
+	Threshold   float64  `json:"threshold"`
+	Regressions []string `json:"regressions"` // names of regressed benchmarks
+	Benchmarks  []*Trend `json:"benchmarks"`
+
+	// ProfileDiffs compare the hotspots of the latest run with the last
+	// run before it that captured the same profile.
+	ProfileDiffs []ProfileDiff `json:"profile_diffs,omitempty"`
+}
+
+// NewTrendSummary summarizes the trends ComputeTrends found in runs.
+func NewTrendSummary(runs []HistoryRun, trends []*Trend, opts TrendOptions) TrendSummary {
+	opts = opts.withDefaults()
+	s := TrendSummary{
+		Runs:        len(runs),
+		Window:      opts.Window,
+		Threshold:   opts.Threshold,
+		Regressions: []string{},
+		Benchmarks:  trends,
+
+		ProfileDiffs: diffProfiles(runs),
+	}
+	if len(runs) > 0 {
+		s.Latest = runs[len(runs)-1].Metadata.Timestamp
+	}
+	for _, t := range trends {
+		if t.Regression {
+			s.Regressions = append(s.Regressions, t.Name)
+		}
+	}
+	return s
+}
+
+// sparkTicks draw a value scaled between the smallest and largest of a series.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline charts values oldest to newest as one line of block characters.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparkTicks)-1))
+		}
+		sb.WriteRune(sparkTicks[i])
+	}
+	return sb.String()
+}
+
+// RenderTrends creates the markdown trend report of summary: a chart of
+// ns/op and allocs/op over the saved runs of every benchmark, with its
+// latest run against the baseline.
+func RenderTrends(summary TrendSummary) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("# Benchmark Trends\n\n")
+	buf.WriteString(fmt.Sprintf("**Runs:** %d\n", summary.Runs))
+	if summary.Latest != "" {
+		buf.WriteString(fmt.Sprintf("**Latest:** %s\n", summary.Latest))
+	}
+	buf.WriteString(fmt.Sprintf("**Baseline:** median of up to %d previous runs\n", summary.Window))
+	buf.WriteString(fmt.Sprintf("**Regression threshold:** +%.0f%% ns/op or allocs/op\n\n", summary.Threshold*100))
+
+	if len(summary.Regressions) == 0 {
+		buf.WriteString("No regressions against the baseline. ✅\n\n")
+	} else {
+		buf.WriteString(fmt.Sprintf("**%d regression(s)** against the baseline: ⚠️\n\n", len(summary.Regressions)))
+		for _, name := range summary.Regressions {
+			buf.WriteString(fmt.Sprintf("- `%s`\n", name))
+		}
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("| Benchmark | ns/op trend | Baseline | Latest | Change | allocs/op trend | Baseline | Latest | Change | Status |\n")
+	buf.WriteString("|-----------|-------------|----------|--------|--------|-----------------|----------|--------|--------|--------|\n")
+	for _, t := range summary.Benchmarks {
+		ns := make([]float64, len(t.Points))
+		allocs := make([]float64, len(t.Points))
+		for i, p := range t.Points {
+			ns[i] = p.NsPerOp
+			allocs[i] = float64(p.AllocsPerOp)
+		}
+		latest := t.Latest()
+
+		nsBaseline, nsChange, allocsBaseline, allocsChange, status := "-", "-", "-", "-", "new"
+		if len(t.Points) > 1 {
+			nsBaseline = formatDuration(t.BaselineNsPerOp)
+			nsChange = formatChange(t.NsChange)
+			allocsBaseline = fmt.Sprintf("%.0f", t.BaselineAllocsPerOp)
+			allocsChange = formatChange(t.AllocsChange)
+			status = "ok"
+			if t.Regression {
+				status = "**regression** ⚠️"
+			}
+		}
+
+		buf.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s | %s | `%s` | %s | %s | %s | %s |\n",
+			t.Name,
+			sparkline(ns), nsBaseline, formatDuration(latest.NsPerOp), nsChange,
+			sparkline(allocs), allocsBaseline, formatInt(latest.AllocsPerOp), allocsChange,
+			status))
+	}
+	buf.WriteString("\n")
+
+	writeProfileDiffs(&buf, summary.ProfileDiffs)
+
+	return buf.String()
+}
+
+// profileChangeRows caps the rows of a profile diff table.
+const profileChangeRows = 10
+
+// writeProfileDiffs writes a table of the hotspots whose share changed most
+// for each profile diff.
+func writeProfileDiffs(buf *bytes.Buffer, diffs []ProfileDiff) {
+	if len(diffs) == 0 {
+		return
+	}
+	buf.WriteString("## Profile Changes\n\n")
+	buf.WriteString("Share of flat samples per function in the latest run's profiles, against the last run that captured the same profile.\n\n")
+	for _, d := range diffs {
+		buf.WriteString(fmt.Sprintf("### %s profile: %s → %s\n\n", d.Profile, d.Before, d.After))
+		buf.WriteString("| Function | Before | After | Change |\n")
+		buf.WriteString("|----------|--------|-------|--------|\n")
+		for _, c := range d.Changes[:min(len(d.Changes), profileChangeRows)] {
+			buf.WriteString(fmt.Sprintf("| `%s` | %.1f%% | %.1f%% | %s |\n",
+				c.Function, c.BeforePct, c.AfterPct, formatHotspotChange(c.Change)))
+		}
+		buf.WriteString("\n")
+	}
+}
+
+// formatChange formats a relative change as a signed percentage
+func formatChange(change float64) string {
+	return fmt.Sprintf("%+.1f%%", change*100)
+}
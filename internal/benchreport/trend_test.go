@@ -0,0 +1,121 @@
+package benchreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// saveRun saves a run of the two benchmarks under root as SaveHistory does.
+func saveRun(t *testing.T, root, timestamp string, fastNs, slowNs float64, slowAllocs int) {
+	t.Helper()
+	output := fmt.Sprintf("goos: linux\n"+
+		"BenchmarkFast-8 1000 %.0f ns/op 64 B/op 2 allocs/op\n"+
+		"BenchmarkSlow-8 1000 %.0f ns/op 512 B/op %d allocs/op\n", fastNs, slowNs, slowAllocs)
+	meta := Metadata{Timestamp: timestamp, GitCommit: "c" + timestamp[8:10]}
+	if _, err := SaveHistory(root, output, "report", meta); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTrends(t *testing.T) {
+	root := t.TempDir()
+	// Saved out of order; the latest run is the slow one
+	saveRun(t, root, "2025-01-03_00-00-00", 100, 1000, 10)
+	saveRun(t, root, "2025-01-01_00-00-00", 100, 1200, 10)
+	saveRun(t, root, "2025-01-02_00-00-00", 110, 800, 10)
+	saveRun(t, root, "2025-01-04_00-00-00", 105, 1500, 12)
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := LoadHistory(root)
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	var stamps []string
+	for _, r := range runs {
+		stamps = append(stamps, r.Metadata.Timestamp)
+	}
+	want := []string{"2025-01-01_00-00-00", "2025-01-02_00-00-00", "2025-01-03_00-00-00", "2025-01-04_00-00-00"}
+	if !reflect.DeepEqual(stamps, want) {
+		t.Fatalf("LoadHistory() runs = %v, want %v", stamps, want)
+	}
+
+	opts := TrendOptions{Window: 3}
+	trends := ComputeTrends(runs, opts)
+	if len(trends) != 2 || trends[0].Name != "BenchmarkFast" || trends[1].Name != "BenchmarkSlow" {
+		t.Fatalf("ComputeTrends() = %d trends", len(trends))
+	}
+
+	fast, slow := trends[0], trends[1]
+	if fast.BaselineNsPerOp != 100 || fast.Regression {
+		t.Errorf("BenchmarkFast baseline = %v, regression = %v; want 100, false", fast.BaselineNsPerOp, fast.Regression)
+	}
+	if slow.BaselineNsPerOp != 1000 || slow.NsChange != 0.5 || math.Abs(slow.AllocsChange-0.2) > 1e-9 || !slow.Regression {
+		t.Errorf("BenchmarkSlow = %+v; want baseline 1000, +50%% ns/op, +20%% allocs, regression", slow)
+	}
+	if latest := slow.Latest(); latest.Timestamp != "2025-01-04_00-00-00" || latest.Commit != "c04" {
+		t.Errorf("BenchmarkSlow latest = %+v", latest)
+	}
+
+	// A window of one compares with the run just before only
+	if trends := ComputeTrends(runs, TrendOptions{Window: 1, Threshold: 0.6}); trends[1].Regression {
+		t.Errorf("ComputeTrends() with a 60%% threshold flagged a %+.0f%% change", trends[1].NsChange*100)
+	}
+
+	summary := NewTrendSummary(runs, trends, opts)
+	if summary.Runs != 4 || summary.Latest != "2025-01-04_00-00-00" || summary.Threshold != 0.10 ||
+		!reflect.DeepEqual(summary.Regressions, []string{"BenchmarkSlow"}) {
+		t.Errorf("NewTrendSummary() = %+v", summary)
+	}
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded TrendSummary
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded, summary) {
+		t.Errorf("TrendSummary JSON round trip = %+v, %v", decoded, err)
+	}
+
+	report := RenderTrends(summary)
+	for _, want := range []string{
+		"**Runs:** 4\n",
+		"**1 regression(s)** against the baseline",
+		"- `BenchmarkSlow`\n",
+		"| BenchmarkSlow | `▅▁▃█` | 1.0µs | 1.5µs | +50.0% | `▁▁▁█` | 10 | 12 | +20.0% | **regression** ⚠️ |",
+		"| BenchmarkFast | `▁█▁▄` | 100ns | 105ns | +5.0% |",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("RenderTrends() missing %q in\n%s", want, report)
+		}
+	}
+}
+
+func TestTrends_NoHistory(t *testing.T) {
+	runs, err := LoadHistory(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || runs != nil {
+		t.Fatalf("LoadHistory() of a missing directory = %v, %v", runs, err)
+	}
+
+	root := t.TempDir()
+	saveRun(t, root, "2025-01-01_00-00-00", 100, 1000, 10)
+	runs, err = LoadHistory(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := NewTrendSummary(runs, ComputeTrends(runs, TrendOptions{}), TrendOptions{})
+	if summary.Window != 5 || len(summary.Regressions) != 0 || summary.Benchmarks[0].Regression {
+		t.Errorf("NewTrendSummary() of one run = %+v", summary)
+	}
+	if report := RenderTrends(summary); !strings.Contains(report, "| BenchmarkFast | `▁` | - | 100ns | - |") ||
+		!strings.Contains(report, "No regressions") {
+		t.Errorf("RenderTrends() of one run =\n%s", report)
+	}
+}
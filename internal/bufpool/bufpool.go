@@ -0,0 +1,79 @@
+// Package bufpool provides size-capped pools of byte buffers for the hot
+// paths of the parsers and emitters (string unescaping, scalar emission,
+// document encoding).
+//
+// Each Pool keeps counters of its activity so that benchmarks can report how
+// often buffers are reused, allocated, or dropped for exceeding the size cap.
+package bufpool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Scratch is the shared pool for short-lived scalar buffers, such as the
+// output of escape processing in double-quoted strings.
+var Scratch = New(256, 16*1024)
+
+// Pool is a sync.Pool of *[]byte with a cap on the size of buffers it retains.
+type Pool struct {
+	pool    sync.Pool
+	maxCap  int
+	gets    atomic.Uint64
+	misses  atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// Stats is a snapshot of a Pool's counters.
+type Stats struct {
+	Gets    uint64 // buffers handed out by Get
+	Misses  uint64 // Gets that had to allocate a new buffer
+	Dropped uint64 // buffers not retained by Put because they grew past the cap
+}
+
+// New creates a pool whose new buffers have initialCap capacity.
+// Buffers that grow beyond maxCap are not returned to the pool.
+func New(initialCap, maxCap int) *Pool {
+	p := &Pool{maxCap: maxCap}
+	p.pool.New = func() interface{} {
+		p.misses.Add(1)
+		b := make([]byte, 0, initialCap)
+		return &b
+	}
+	return p
+}
+
+// Get returns an empty buffer from the pool.
+func (p *Pool) Get() *[]byte {
+	p.gets.Add(1)
+	bp := p.pool.Get().(*[]byte)
+	*bp = (*bp)[:0]
+	return bp
+}
+
+// Put returns a buffer to the pool. The caller must not use bp afterwards.
+func (p *Pool) Put(bp *[]byte) {
+	if cap(*bp) > p.maxCap {
+		p.dropped.Add(1)
+		return
+	}
+	p.pool.Put(bp)
+}
+
+// Stats returns a snapshot of the pool's counters.
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Gets:    p.gets.Load(),
+		Misses:  p.misses.Load(),
+		Dropped: p.dropped.Load(),
+	}
+}
+
+// Sub returns the counter deltas s - prev, for measuring a window of activity.
+func (s Stats) Sub(prev Stats) Stats {
+	return Stats{
+		Gets:    s.Gets - prev.Gets,
+		Misses:  s.Misses - prev.Misses,
+		Dropped: s.Dropped - prev.Dropped,
+	}
+}
@@ -0,0 +1,47 @@
+package bufpool
+
+import "testing"
+
+func TestPoolReuse(t *testing.T) {
+	p := New(8, 64)
+
+	bp := p.Get()
+	*bp = append(*bp, "hello"...)
+	p.Put(bp)
+
+	bp = p.Get()
+	if len(*bp) != 0 {
+		t.Errorf("Get() returned buffer with len %d, want 0", len(*bp))
+	}
+	p.Put(bp)
+
+	s := p.Stats()
+	if s.Gets != 2 {
+		t.Errorf("Gets = %d, want 2", s.Gets)
+	}
+	if s.Misses < 1 || s.Misses > 2 {
+		t.Errorf("Misses = %d, want 1 or 2", s.Misses)
+	}
+}
+
+func TestPoolDropsOversized(t *testing.T) {
+	p := New(8, 64)
+
+	bp := p.Get()
+	*bp = append(*bp, make([]byte, 128)...)
+	p.Put(bp)
+
+	if got := p.Stats().Dropped; got != 1 {
+		t.Errorf("Dropped = %d, want 1", got)
+	}
+}
+
+func TestStatsSub(t *testing.T) {
+	before := Stats{Gets: 3, Misses: 1, Dropped: 0}
+	after := Stats{Gets: 10, Misses: 2, Dropped: 1}
+
+	want := Stats{Gets: 7, Misses: 1, Dropped: 1}
+	if got := after.Sub(before); got != want {
+		t.Errorf("Sub() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+// Package canonkey encodes a collection used as a mapping key, as in
+// {[1, 2]: pair}, to the string both the AST parser (internal/parser) and
+// the fast parser (internal/fastparser) store it under.
+//
+// The encoding is canonical JSON: no whitespace, mapping keys sorted,
+// strings always quoted. Equal keys therefore always encode alike and
+// duplicate detection is reliable, while keys that only looked alike in a
+// YAML-style rendering stay distinct: ["a, b"] is not [a, b], and ["1"] is
+// not [1].
+package canonkey
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Encode returns the canonical encoding of v, which is built from nil,
+// booleans, integers, floats, strings, []interface{} sequences and
+// map[string]interface{} mappings. Any other value is encoded as the
+// string fmt.Sprint gives for it.
+func Encode(v interface{}) string {
+	return string(appendValue(nil, v))
+}
+
+func appendValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, "null"...)
+	case bool:
+		return strconv.AppendBool(b, v)
+	case int:
+		return strconv.AppendInt(b, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(b, v, 10)
+	case uint64:
+		return strconv.AppendUint(b, v, 10)
+	case float64:
+		return appendFloat(b, v)
+	case string:
+		return appendString(b, v)
+	case []interface{}:
+		b = append(b, '[')
+		for i, elem := range v {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendValue(b, elem)
+		}
+		return append(b, ']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = append(b, '{')
+		for i, k := range keys {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendString(b, k)
+			b = append(b, ':')
+			b = appendValue(b, v[k])
+		}
+		return append(b, '}')
+	default:
+		return appendString(b, fmt.Sprint(v))
+	}
+}
+
+// appendFloat appends f in its shortest form, with YAML's spellings for the
+// values JSON cannot represent.
+func appendFloat(b []byte, f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(b, ".nan"...)
+	case math.IsInf(f, 1):
+		return append(b, ".inf"...)
+	case math.IsInf(f, -1):
+		return append(b, "-.inf"...)
+	}
+	return strconv.AppendFloat(b, f, 'g', -1, 64)
+}
+
+// appendString appends s as a JSON string, escaping only what JSON requires.
+func appendString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b = append(b, '\\', c)
+			case c == '\n':
+				b = append(b, '\\', 'n')
+			case c == '\r':
+				b = append(b, '\\', 'r')
+			case c == '\t':
+				b = append(b, '\\', 't')
+			case c < 0x20:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			default:
+				b = append(b, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, `�`...)
+		} else {
+			b = append(b, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(b, '"')
+}
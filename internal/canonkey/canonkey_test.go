@@ -0,0 +1,29 @@
+package canonkey
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"sequence", []interface{}{int64(1), int64(2)}, `[1,2]`},
+		{"strings are quoted", []interface{}{"a, b", "1"}, `["a, b","1"]`},
+		{"mapping keys sorted", map[string]interface{}{"b": []interface{}{"x", nil}, "a": int64(1)}, `{"a":1,"b":["x",null]}`},
+		{"scalars", []interface{}{true, 2.5, uint64(7), math.Inf(-1), math.NaN()}, `[true,2.5,7,-.inf,.nan]`},
+		{"escapes", []interface{}{"q\"\\\n\x01é"}, `["q\"\\\n\u0001é"]`},
+		{"empty", []interface{}{[]interface{}{}, map[string]interface{}{}}, `[[],{}]`},
+		{"other values", []interface{}{struct{ N int }{1}}, `["{1}"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Encode(tt.v); got != tt.want {
+				t.Errorf("Encode() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package corpus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Environment variables DefaultCache reads
+const (
+	// DirEnv overrides the cache directory.
+	DirEnv = "SHAPE_YAML_CORPUS_DIR"
+	// FetchEnv set to 1 lets DefaultCache download documents; otherwise
+	// only documents already cached load, so that tests never reach the
+	// network unless asked to.
+	FetchEnv = "SHAPE_YAML_CORPUS_FETCH"
+)
+
+// maxDocumentSize bounds a download, as a guard against a wrong URL.
+const maxDocumentSize = 64 << 20
+
+// ErrNotCached is returned by Load for a remote document that is not in
+// the cache of an offline Cache.
+var ErrNotCached = errors.New("corpus: document not cached")
+
+// A Cache keeps downloaded documents in a directory, under their checksum,
+// so that a document whose checksum changes in the manifest is downloaded
+// again.
+type Cache struct {
+	Dir     string
+	Client  *http.Client // http.DefaultClient if nil
+	Offline bool         // never download; only cached documents load
+}
+
+// DefaultCache returns a cache in $SHAPE_YAML_CORPUS_DIR, or in
+// shape-yaml/corpus under the user cache directory, which downloads only
+// when $SHAPE_YAML_CORPUS_FETCH is 1.
+func DefaultCache() *Cache {
+	dir := os.Getenv(DirEnv)
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			base = os.TempDir()
+		}
+		dir = filepath.Join(base, "shape-yaml", "corpus")
+	}
+	return &Cache{Dir: dir, Offline: os.Getenv(FetchEnv) != "1"}
+}
+
+// Load returns the contents of d: embedded if d is vendored, otherwise
+// from the cache, downloading d first if it is missing. Either way the
+// contents are verified against d.SHA256; a download that does not match
+// is not cached.
+func (c *Cache) Load(ctx context.Context, d Document) ([]byte, error) {
+	if d.Vendored() {
+		return loadVendored(d)
+	}
+
+	path := c.path(d)
+	if data, err := os.ReadFile(path); err == nil {
+		if err := verify(d, data); err != nil {
+			// A corrupt entry is downloaded again
+			os.Remove(path)
+		} else {
+			return data, nil
+		}
+	}
+	if c.Offline {
+		return nil, fmt.Errorf("%w: %s (set %s=1 to download it)", ErrNotCached, d.Name, FetchEnv)
+	}
+
+	data, err := c.fetch(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(d, data); err != nil {
+		return nil, err
+	}
+	if err := writeAtomic(path, data); err != nil {
+		return nil, fmt.Errorf("corpus: caching %s: %v", d.Name, err)
+	}
+	return data, nil
+}
+
+// path returns where the cache keeps d.
+func (c *Cache) path(d Document) string {
+	return filepath.Join(c.Dir, d.SHA256+".yaml")
+}
+
+// fetch downloads d.
+func (c *Cache) fetch(ctx context.Context, d Document) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("corpus: %s: %v", d.Name, err)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("corpus: fetching %s: %v", d.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("corpus: fetching %s: %s", d.Name, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("corpus: fetching %s: %v", d.Name, err)
+	}
+	if len(data) > maxDocumentSize {
+		return nil, fmt.Errorf("corpus: fetching %s: larger than %d bytes", d.Name, maxDocumentSize)
+	}
+	return data, nil
+}
+
+// writeAtomic writes data to path through a temporary file, so that a
+// concurrent Load never reads a partial document.
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+// Package corpus provides realistic YAML documents, such as Kubernetes
+// manifests, Docker Compose files, OpenAPI descriptions and GitHub Actions
+// workflows, to benchmarks and conformance tests.
+//
+// A representative document of each category is vendored in data/ and
+// embedded in the package, so that the corpus works offline. Larger public
+// corpora can be listed in a manifest (see LoadManifest) that names a URL
+// and SHA-256 checksum for each document; a Cache downloads them once and
+// verifies them on every load:
+//
+//	docs, err := corpus.All() // vendored, plus $SHAPE_YAML_CORPUS_MANIFEST
+//	for _, d := range docs {
+//	    data, err := corpus.Load(ctx, d)
+//	    if errors.Is(err, corpus.ErrNotCached) {
+//	        continue // set SHAPE_YAML_CORPUS_FETCH=1 to download
+//	    }
+//	    ...
+//	}
+package corpus
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Category groups documents of the same kind of configuration.
+type Category string
+
+// Categories of the vendored documents
+const (
+	Kubernetes Category = "kubernetes"
+	Compose    Category = "compose"
+	OpenAPI    Category = "openapi"
+	Actions    Category = "github-actions"
+)
+
+// Document describes a corpus document. A vendored document has no URL
+// and is read from data/<Name>.yaml.
+type Document struct {
+	Name     string   `json:"name"`
+	Category Category `json:"category"`
+	URL      string   `json:"url,omitempty"`
+	SHA256   string   `json:"sha256"` // hex checksum of the contents
+}
+
+// Vendored reports whether d is embedded in the package.
+func (d Document) Vendored() bool {
+	return d.URL == ""
+}
+
+//go:embed data/*.yaml
+var data embed.FS
+
+// vendored lists the documents in data/. A test checks the checksums, so
+// an edit to a document fails until its checksum here is updated.
+var vendored = []Document{
+	{Name: "k8s-deployment", Category: Kubernetes, SHA256: "43dc825692d79d0b789dbb06771d186154963f1d21b2470ca2b380392c610e69"},
+	{Name: "docker-compose", Category: Compose, SHA256: "eb597c3d301105dc5d756befc397e1c35b0f76bd7c9b510ab67ddd35a7be5e54"},
+	{Name: "openapi", Category: OpenAPI, SHA256: "fc45f8e33884d174781133baf7d88f46f1a3f1efaa54940465286f2509a35ea1"},
+	{Name: "github-actions", Category: Actions, SHA256: "c5b81843cf24479f1b2105e68c707c22c5b257931bd579d97ec45e71b5df3152"},
+	{Name: "k8s-list", Category: Kubernetes, SHA256: "1e56eebe9064add421267d6dd4a0f31a64e27cb40403efd83d25bc5504e1d061"},
+}
+
+// Vendored returns the documents embedded in the package.
+func Vendored() []Document {
+	return append([]Document(nil), vendored...)
+}
+
+// ManifestEnv names the environment variable holding the path of a manifest
+// of further documents for All.
+const ManifestEnv = "SHAPE_YAML_CORPUS_MANIFEST"
+
+// All returns the vendored documents, followed by those of the manifest
+// that $SHAPE_YAML_CORPUS_MANIFEST names, if it is set.
+func All() ([]Document, error) {
+	docs := Vendored()
+	if path := os.Getenv(ManifestEnv); path != "" {
+		extra, err := LoadManifest(path)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, extra...)
+	}
+	return docs, nil
+}
+
+// ByCategory returns the documents of docs in category c.
+func ByCategory(docs []Document, c Category) []Document {
+	var out []Document
+	for _, d := range docs {
+		if d.Category == c {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Load returns the contents of d, downloading it through DefaultCache if
+// it is not vendored.
+func Load(ctx context.Context, d Document) ([]byte, error) {
+	return DefaultCache().Load(ctx, d)
+}
+
+// ErrChecksum is wrapped by the error returned for a document whose
+// contents do not match its checksum.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// verify checks data against the checksum of d.
+func verify(d Document, data []byte) error {
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != d.SHA256 {
+		return fmt.Errorf("corpus: %s: %w: got sha256 %s, want %s", d.Name, ErrChecksum, got, d.SHA256)
+	}
+	return nil
+}
+
+// loadVendored reads a vendored document and verifies it.
+func loadVendored(d Document) ([]byte, error) {
+	b, err := data.ReadFile("data/" + d.Name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("corpus: no vendored document %q", d.Name)
+	}
+	if err := verify(d, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
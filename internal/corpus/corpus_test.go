@@ -0,0 +1,168 @@
+package corpus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestVendored(t *testing.T) {
+	docs := Vendored()
+	if len(docs) == 0 {
+		t.Fatal("Vendored() is empty")
+	}
+	for _, c := range []Category{Kubernetes, Compose, OpenAPI, Actions} {
+		if len(ByCategory(docs, c)) == 0 {
+			t.Errorf("no vendored %s document", c)
+		}
+	}
+
+	entries, err := data.ReadDir("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(docs) {
+		t.Errorf("data/ holds %d files, but %d documents are listed", len(entries), len(docs))
+	}
+	for _, d := range docs {
+		b, err := Load(context.Background(), d)
+		if err != nil {
+			t.Errorf("Load(%s) error = %v", d.Name, err)
+			continue
+		}
+		if len(b) == 0 || !d.Vendored() {
+			t.Errorf("Load(%s) = %d bytes, vendored %v", d.Name, len(b), d.Vendored())
+		}
+	}
+
+	d := docs[0]
+	d.SHA256 = strings.Repeat("0", 64)
+	if _, err := Load(context.Background(), d); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Load() with a wrong checksum error = %v, want ErrChecksum", err)
+	}
+	if _, err := Load(context.Background(), Document{Name: "missing"}); err == nil {
+		t.Error("Load() of a missing vendored document: expected error")
+	}
+}
+
+// remoteDocument serves body and returns a document for it, with a count
+// of the requests the server has seen.
+func remoteDocument(t *testing.T, body string) (Document, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path != "/doc.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	sum := sha256.Sum256([]byte(body))
+	return Document{Name: "remote", Category: Kubernetes, URL: srv.URL + "/doc.yaml", SHA256: hex.EncodeToString(sum[:])}, &hits
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	d, hits := remoteDocument(t, "kind: Pod\n")
+	cache := &Cache{Dir: t.TempDir()}
+
+	for i := 0; i < 2; i++ {
+		data, err := cache.Load(ctx, d)
+		if err != nil || string(data) != "kind: Pod\n" {
+			t.Fatalf("Load() = %q, %v", data, err)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("Load() twice made %d requests, want 1", hits.Load())
+	}
+
+	// Offline, the cached copy still loads
+	offline := &Cache{Dir: cache.Dir, Offline: true}
+	if _, err := offline.Load(ctx, d); err != nil {
+		t.Errorf("offline Load() of a cached document error = %v", err)
+	}
+
+	// A corrupt entry is replaced
+	if err := os.WriteFile(cache.path(d), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := cache.Load(ctx, d); err != nil || string(data) != "kind: Pod\n" || hits.Load() != 2 {
+		t.Errorf("Load() over a corrupt entry = %q, %v after %d requests", data, err, hits.Load())
+	}
+}
+
+func TestCache_Errors(t *testing.T) {
+	ctx := context.Background()
+	d, _ := remoteDocument(t, "kind: Pod\n")
+
+	offline := &Cache{Dir: t.TempDir(), Offline: true}
+	if _, err := offline.Load(ctx, d); !errors.Is(err, ErrNotCached) || !strings.Contains(err.Error(), FetchEnv) {
+		t.Errorf("offline Load() error = %v, want ErrNotCached", err)
+	}
+
+	cache := &Cache{Dir: t.TempDir()}
+	bad := d
+	bad.SHA256 = strings.Repeat("a", 64)
+	if _, err := cache.Load(ctx, bad); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Load() of a changed document error = %v, want ErrChecksum", err)
+	}
+	if entries, _ := os.ReadDir(cache.Dir); len(entries) != 0 {
+		t.Errorf("a document failing its checksum was cached: %v", entries)
+	}
+
+	missing := d
+	missing.URL = strings.TrimSuffix(d.URL, "doc.yaml") + "missing.yaml"
+	if _, err := cache.Load(ctx, missing); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Load() of a missing URL error = %v", err)
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	write := func(body string) string {
+		path := filepath.Join(dir, "manifest.json")
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	sum := strings.Repeat("ab", 32)
+
+	path := write(`[{"name": "a", "category": "openapi", "url": "https://example.com/a.yaml", "sha256": "` + sum + `"}]`)
+	docs, err := LoadManifest(path)
+	if err != nil || len(docs) != 1 || docs[0].Category != OpenAPI || docs[0].Vendored() {
+		t.Fatalf("LoadManifest() = %+v, %v", docs, err)
+	}
+
+	t.Setenv(ManifestEnv, path)
+	all, err := All()
+	if err != nil || len(all) != len(vendored)+1 || all[len(all)-1].Name != "a" {
+		t.Errorf("All() = %d documents, %v", len(all), err)
+	}
+
+	for _, tt := range []struct{ body, want string }{
+		{`{`, "unexpected end"},
+		{`[{"url": "https://example.com/a.yaml", "sha256": "` + sum + `"}]`, "missing name"},
+		{`[{"name": "a", "url": "ftp://example.com/a.yaml", "sha256": "` + sum + `"}]`, "not an http or https URL"},
+		{`[{"name": "a", "url": "https://example.com/a.yaml", "sha256": "abc"}]`, "not a hex SHA-256"},
+		{`[{"name": "openapi", "url": "https://example.com/a.yaml", "sha256": "` + sum + `"}]`, `duplicate name "openapi"`},
+	} {
+		if _, err := LoadManifest(write(tt.body)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadManifest(%s) error = %v, want %q", tt.body, err, tt.want)
+		}
+	}
+	if _, err := LoadManifest(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("LoadManifest() of a missing file: expected error")
+	}
+}
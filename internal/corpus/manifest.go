@@ -0,0 +1,63 @@
+package corpus
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// LoadManifest reads a JSON manifest of remote documents:
+//
+//	[
+//	  {
+//	    "name": "operator-install",
+//	    "category": "kubernetes",
+//	    "url": "https://raw.githubusercontent.com/<org>/<repo>/<tag>/deploy/install.yaml",
+//	    "sha256": "<sha256sum of the file>"
+//	  }
+//	]
+//
+// Every document needs a unique name, an http or https URL pinned to a
+// fixed revision, and the checksum of its contents.
+func LoadManifest(path string) ([]Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("corpus: %v", err)
+	}
+	var docs []Document
+	if err := json.Unmarshal(data, &docs); err != nil {
+		return nil, fmt.Errorf("corpus: %s: %v", path, err)
+	}
+
+	names := make(map[string]bool)
+	for _, d := range vendored {
+		names[d.Name] = true
+	}
+	for i, d := range docs {
+		if err := checkRemote(d); err != nil {
+			return nil, fmt.Errorf("corpus: %s: document %d: %v", path, i, err)
+		}
+		if names[d.Name] {
+			return nil, fmt.Errorf("corpus: %s: document %d: duplicate name %q", path, i, d.Name)
+		}
+		names[d.Name] = true
+	}
+	return docs, nil
+}
+
+// checkRemote validates a manifest entry.
+func checkRemote(d Document) error {
+	if d.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	u, err := url.Parse(d.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s: url %q is not an http or https URL", d.Name, d.URL)
+	}
+	if sum, err := hex.DecodeString(d.SHA256); err != nil || len(sum) != 32 {
+		return fmt.Errorf("%s: sha256 %q is not a hex SHA-256 checksum", d.Name, d.SHA256)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Package determinism makes map iteration in the decoders reproducible when
+// the module is built with the yamldeterministic build tag:
+//
+//	go test -tags yamldeterministic ./...
+//
+// Output is already deterministic (emitters and key stringification sort
+// mapping keys), but the AST decode path visits mapping entries in Go map
+// order, so which of two bad fields is reported, and the order in which
+// Unmarshaler and TextUnmarshaler values run, can change from run to run.
+// The tag fixes that order to sorted keys for reproducible test output while
+// debugging parser changes; release builds keep the cheaper map order.
+package determinism
+
+import "sort"
+
+// Range calls fn for each entry of m and stops at the first error fn
+// returns. Entries are visited in sorted key order if Enabled, and in map
+// order otherwise.
+func Range[V any](m map[string]V, fn func(key string, value V) error) error {
+	if !Enabled {
+		for k, v := range m {
+			if err := fn(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn(k, m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
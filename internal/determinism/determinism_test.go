@@ -0,0 +1,36 @@
+package determinism
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	m := map[string]int{"c": 3, "a": 1, "d": 4, "b": 2, "e": 5}
+
+	var keys []string
+	err := Range(m, func(k string, v int) error {
+		if m[k] != v {
+			t.Errorf("Range passed %s=%d, want %d", k, v, m[k])
+		}
+		keys = append(keys, k)
+		return nil
+	})
+	if err != nil || len(keys) != len(m) {
+		t.Fatalf("Range visited %v, %v; want all %d keys", keys, err, len(m))
+	}
+	if Enabled && !sort.StringsAreSorted(keys) {
+		t.Errorf("Range order = %v, want sorted keys with yamldeterministic", keys)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = Range(m, func(string, int) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Range = %v after %d calls, want %v after 1", err, calls, stop)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !yamldeterministic
+
+package determinism
+
+// Enabled reports whether the yamldeterministic build tag is set.
+const Enabled = false
@@ -0,0 +1,139 @@
+// Package escape decodes the escape sequences of YAML double-quoted scalars
+// (YAML 1.2 section 5.7).
+//
+// The tokenizer, the AST parser (internal/parser) and the fast parser
+// (internal/fastparser) all read escapes through this package, so that
+// every decoder accepts the same sequences and decodes them to the same
+// text:
+//
+//	\0 \a \b \t \n \v \f \r \e     control characters
+//	\<TAB> \<SPACE> \" \/ \\       the character itself
+//	\N \_ \L \P                    U+0085, U+00A0, U+2028, U+2029
+//	\xXX \uXXXX \UXXXXXXXX         the code point, as UTF-8
+//
+// An escaped line break joins lines and is handled by the callers, as part
+// of line folding. Any other escape is an error.
+package escape
+
+import (
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Single returns the character that the escape of the one character c
+// stands for, as '\n' for \n.
+func Single(c byte) (rune, bool) {
+	switch c {
+	case '0':
+		return 0, true
+	case 'a':
+		return '\a', true
+	case 'b':
+		return '\b', true
+	case 't', '\t':
+		return '\t', true
+	case 'n':
+		return '\n', true
+	case 'v':
+		return '\v', true
+	case 'f':
+		return '\f', true
+	case 'r':
+		return '\r', true
+	case 'e':
+		return 0x1b, true
+	case ' ', '"', '/', '\\':
+		return rune(c), true
+	case 'N':
+		return '\u0085', true
+	case '_':
+		return '\u00a0', true
+	case 'L':
+		return '\u2028', true
+	case 'P':
+		return '\u2029', true
+	}
+	return 0, false
+}
+
+// HexDigits returns the number of hex digits after c in the escapes \xXX,
+// \uXXXX and \UXXXXXXXX, or 0 for other escapes.
+func HexDigits(c byte) int {
+	switch c {
+	case 'x':
+		return 2
+	case 'u':
+		return 4
+	case 'U':
+		return 8
+	}
+	return 0
+}
+
+// Decode decodes the escape sequence that s starts with, after its
+// backslash, and returns the character it stands for and the number of
+// bytes of s it takes. A \u escape of a UTF-16 high surrogate takes the \u
+// escape of the low surrogate after it as well, and the pair decodes to a
+// single character. An unknown escape, a missing or invalid hex digit, a
+// lone surrogate and a code point outside Unicode are errors.
+func Decode[S ~string | ~[]byte](s S) (rune, int, error) {
+	if len(s) == 0 {
+		return 0, 0, fmt.Errorf("invalid escape sequence: missing character after \\")
+	}
+	c := s[0]
+	if r, ok := Single(c); ok {
+		return r, 1, nil
+	}
+	digits := HexDigits(c)
+	if digits == 0 {
+		r, _ := utf8.DecodeRuneInString(string(s[:min(len(s), utf8.UTFMax)]))
+		return 0, 0, fmt.Errorf("invalid escape sequence \\%c", r)
+	}
+
+	v, ok := hex(s, 1, digits)
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid escape sequence \\%c: want %d hex digits", c, digits)
+	}
+	r, n := rune(v), 1+digits
+	if c == 'u' && utf16.IsSurrogate(r) {
+		if r >= 0xDC00 {
+			return 0, 0, fmt.Errorf("invalid unicode escape \\u%04X: unpaired low surrogate", r)
+		}
+		lo, ok := uint32(0), n+6 <= len(s) && s[n] == '\\' && s[n+1] == 'u'
+		if ok {
+			lo, ok = hex(s, n+2, 4)
+		}
+		if !ok || lo < 0xDC00 || lo > 0xDFFF {
+			return 0, 0, fmt.Errorf("invalid unicode escape \\u%04X: high surrogate not followed by a low surrogate", r)
+		}
+		return utf16.DecodeRune(r, rune(lo)), n + 6, nil
+	}
+	if !utf8.ValidRune(r) {
+		return 0, 0, fmt.Errorf("invalid unicode escape \\%c%0*X: not a Unicode scalar value", c, digits, v)
+	}
+	return r, n, nil
+}
+
+// hex parses the n hex digits of s at off.
+func hex[S ~string | ~[]byte](s S, off, n int) (uint32, bool) {
+	if off+n > len(s) {
+		return 0, false
+	}
+	var v uint32
+	for i := off; i < off+n; i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c -= 'a' - 10
+		case c >= 'A' && c <= 'F':
+			c -= 'A' - 10
+		default:
+			return 0, false
+		}
+		v = v<<4 | uint32(c)
+	}
+	return v, true
+}
@@ -0,0 +1,62 @@
+package escape
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		in   string // the text after the backslash
+		want rune
+		n    int
+	}{
+		{"n rest", '\n', 1},
+		{"\t", '\t', 1},
+		{" ", ' ', 1},
+		{"e", 0x1b, 1},
+		{"N", 0x85, 1},
+		{"_", 0xa0, 1},
+		{"L", 0x2028, 1},
+		{"P", 0x2029, 1},
+		{"xff", 0xff, 3},
+		{"x41z", 'A', 3},
+		{"u00e9", 'é', 5},
+		{"U0001F600", 0x1F600, 9},
+		{`uD83D\uDE00!`, 0x1F600, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			r, n, err := Decode(tt.in)
+			if err != nil || r != tt.want || n != tt.n {
+				t.Errorf("Decode(%q) = %U, %d, %v; want %U, %d", tt.in, r, n, err, tt.want, tt.n)
+			}
+			if r, n, _ := Decode([]byte(tt.in)); r != tt.want || n != tt.n {
+				t.Errorf("Decode([]byte(%q)) = %U, %d; want %U, %d", tt.in, r, n, tt.want, tt.n)
+			}
+		})
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		in, err string
+	}{
+		{"", "missing character"},
+		{"q", `invalid escape sequence \q`},
+		{"é", `invalid escape sequence \é`},
+		{"x4", `\x: want 2 hex digits`},
+		{"u12G4", `\u: want 4 hex digits`},
+		{"UFFFFFFFF", "not a Unicode scalar value"},
+		{"uDE00", "unpaired low surrogate"},
+		{"uD83D", "high surrogate not followed by a low surrogate"},
+		{`uD83DA`, "high surrogate not followed by a low surrogate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if _, _, err := Decode(tt.in); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Decode(%q) error = %v, want it to contain %q", tt.in, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package fastparser
+
+import "strings"
+
+// Chomping indicators of a block scalar header.
+const (
+	chompClip  = iota // default: a single final line break
+	chompStrip        // "-": no final line break
+	chompKeep         // "+": the final line break and any trailing empty lines
+)
+
+// isBlockScalarIndicator reports whether c starts a literal (|) or folded (>)
+// block scalar.
+func isBlockScalarIndicator(c byte) bool {
+	return c == '|' || c == '>'
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar, from its
+// header through its last content line, and leaves the parser at the start
+// of the line that ends it.
+//
+// The header may give an indentation indicator (1-9) and a chomping
+// indicator (- or +) in either order, then a comment. Without an indentation
+// indicator the content is indented as its first non-empty line, which must
+// be indented further than the line holding the header; a root scalar may
+// start at column 1. Literal content keeps its line breaks; folded content
+// joins adjacent lines of text with a space, while empty lines and lines
+// indented further than the content keep theirs. Chomping then decides the
+// final line breaks, as in the YAML 1.2 spec (section 8.1).
+func (p *Parser) parseBlockScalar() (string, error) {
+	folded := p.data[p.pos] == '>'
+	parent := -1 // a root scalar's content may start at column 1
+	if p.depth > 0 {
+		parent = p.currentIndent()
+	}
+	p.advance() // skip '|' or '>'
+
+	chomp, indent := chompClip, -1 // content indentation, -1 until known
+	for p.pos < p.length {
+		c := p.data[p.pos]
+		if c == '-' && chomp == chompClip {
+			chomp = chompStrip
+		} else if c == '+' && chomp == chompClip {
+			chomp = chompKeep
+		} else if c >= '1' && c <= '9' && indent < 0 {
+			indent = max(parent, 0) + int(c-'0')
+		} else {
+			break
+		}
+		p.advance()
+	}
+
+	// Only a comment may follow the header on its line
+	if p.pos < p.length && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+		start := p.pos
+		p.skipSpaces()
+		if p.pos < p.length && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' &&
+			(p.data[p.pos] != '#' || p.pos == start) {
+			return "", p.errorf("invalid block scalar header: unexpected %q", p.data[p.pos])
+		}
+	}
+	p.skipToNextLine()
+
+	var (
+		b        strings.Builder
+		empty    int  // empty lines since the last line of content
+		text     bool // content has been written
+		lastText bool // the last line of content was unindented text
+	)
+	for p.pos < p.length {
+		// Measure the line: its leading spaces, and where it ends
+		spaces := 0
+		for p.pos+spaces < p.length && p.data[p.pos+spaces] == ' ' {
+			spaces++
+		}
+		end := p.pos + spaces
+		for end < p.length && p.data[end] != '\n' && p.data[end] != '\r' {
+			end++
+		}
+		blank := p.pos+spaces == end
+
+		if spaces == 0 && p.atDocumentMarker() {
+			break
+		}
+		if indent < 0 && !blank {
+			if spaces <= parent {
+				break // no content: the header's line held the whole scalar
+			}
+			indent = spaces
+		}
+		if blank && (indent < 0 || spaces <= indent) {
+			empty++
+			p.skipToNextLine()
+			continue
+		}
+		if spaces < indent {
+			break // a less indented line ends the scalar
+		}
+
+		line := p.data[p.pos+indent : end]
+		isText := !folded || (line[0] != ' ' && line[0] != '\t')
+		switch {
+		case !text:
+			b.WriteString(strings.Repeat("\n", empty))
+		case folded && lastText && isText && empty == 0:
+			b.WriteByte(' ')
+		case folded && lastText && isText:
+			b.WriteString(strings.Repeat("\n", empty))
+		default:
+			b.WriteString(strings.Repeat("\n", empty+1))
+		}
+		b.Write(line)
+		text, lastText, empty = true, isText, 0
+		p.skipToNextLine()
+	}
+
+	switch {
+	case chomp == chompKeep && text:
+		b.WriteString(strings.Repeat("\n", empty+1))
+	case chomp == chompKeep:
+		b.WriteString(strings.Repeat("\n", empty))
+	case chomp == chompClip && text:
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,178 @@
+package fastparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParser_BlockScalar tests parseBlockScalar
+func TestParser_BlockScalar(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "literal",
+			input:    "a: |\n  x\n  y\n",
+			expected: map[string]interface{}{"a": "x\ny\n"},
+		},
+		{
+			name:     "literal keeps further indentation",
+			input:    "a: |\n  x\n    y\n  z\n",
+			expected: map[string]interface{}{"a": "x\n  y\nz\n"},
+		},
+		{
+			name:     "literal strip",
+			input:    "a: |-\n  x\n\n\nb: 1\n",
+			expected: map[string]interface{}{"a": "x", "b": int64(1)},
+		},
+		{
+			name:     "literal clip",
+			input:    "a: |\n  x\n\n\nb: 1\n",
+			expected: map[string]interface{}{"a": "x\n", "b": int64(1)},
+		},
+		{
+			name:     "literal keep",
+			input:    "a: |+\n  x\n\n\nb: 1\n",
+			expected: map[string]interface{}{"a": "x\n\n\n", "b": int64(1)},
+		},
+		{
+			name:     "literal at end of input",
+			input:    "a: |-\n  x",
+			expected: map[string]interface{}{"a": "x"},
+		},
+		{
+			name:     "literal with leading empty line",
+			input:    "a: |\n\n  x\n",
+			expected: map[string]interface{}{"a": "\nx\n"},
+		},
+		{
+			name:     "literal content that looks like YAML",
+			input:    "a: |\n  #x\n  y: z\n# comment\nb: 2",
+			expected: map[string]interface{}{"a": "#x\ny: z\n", "b": int64(2)},
+		},
+		{
+			name:     "indentation indicator",
+			input:    "a: |2\n    x\n  y\n",
+			expected: map[string]interface{}{"a": "  x\ny\n"},
+		},
+		{
+			name:     "indicators in either order",
+			input:    "a: |-2\n   x\nb: |2-\n   y\n",
+			expected: map[string]interface{}{"a": " x", "b": " y"},
+		},
+		{
+			name:     "header comment",
+			input:    "a: |  # note\n  x  y\n",
+			expected: map[string]interface{}{"a": "x  y\n"},
+		},
+		{
+			name:     "empty",
+			input:    "a: |\nb: 1\n",
+			expected: map[string]interface{}{"a": "", "b": int64(1)},
+		},
+		{
+			name:     "empty keep",
+			input:    "a: |+\n\nb: 1\n",
+			expected: map[string]interface{}{"a": "\n", "b": int64(1)},
+		},
+		{
+			name:     "CRLF",
+			input:    "a: |\r\n  x\r\n  y\r\nb: 1\r\n",
+			expected: map[string]interface{}{"a": "x\ny\n", "b": int64(1)},
+		},
+		{
+			name:     "folded",
+			input:    "a: >\n  x\n  y\n",
+			expected: map[string]interface{}{"a": "x y\n"},
+		},
+		{
+			name:     "folded strip",
+			input:    "a: >-\n  x\n  y\n",
+			expected: map[string]interface{}{"a": "x y"},
+		},
+		{
+			name:     "folded empty lines",
+			input:    "a: >\n  x\n\n  y\n\n\n  z\n",
+			expected: map[string]interface{}{"a": "x\ny\n\nz\n"},
+		},
+		{
+			// Example 8.10 of the YAML 1.2 spec
+			name:     "folded more-indented lines",
+			input:    ">\n\n folded\n line\n\n next\n line\n   * bullet\n\n   * list\n   * lines\n\n last\n line\n\n# Comment\n",
+			expected: "\nfolded line\nnext line\n  * bullet\n\n  * list\n  * lines\n\nlast line\n",
+		},
+		{
+			name:     "root at column 1",
+			input:    "--- |\nx\ny\n...\n",
+			expected: "x\ny\n",
+		},
+		{
+			name:     "sequence items",
+			input:    "- |\n  x\n- >-\n  y\n  z\n",
+			expected: []interface{}{"x\n", "y z"},
+		},
+		{
+			name:     "in a compact mapping",
+			input:    "- script: |\n    x\n  name: n\n",
+			expected: []interface{}{map[string]interface{}{"script": "x\n", "name": "n"}},
+		},
+		{
+			name:    "text after the header",
+			input:   "a: |x\n  y\n",
+			wantErr: true,
+		},
+		{
+			name:    "comment without a space",
+			input:   "a: |#x\n  y\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser([]byte(tt.input))
+			got, err := p.Parse()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				if !reflect.DeepEqual(got, tt.expected) {
+					t.Errorf("Parse() = %#v, want %#v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+// TestUnmarshal_BlockScalarsToStruct tests unmarshaling block scalars to struct fields
+func TestUnmarshal_BlockScalarsToStruct(t *testing.T) {
+	type step struct {
+		Name   string `yaml:"name"`
+		Script string `yaml:"script"`
+		Note   string `yaml:"note"`
+	}
+	input := "- name: build\n  script: |\n    make\n    make test\n  note: >-\n    runs\n    everything\n- name: lint\n  script: |-\n    vet\n"
+
+	var got []step
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := []step{
+		{Name: "build", Script: "make\nmake test\n", Note: "runs everything"},
+		{Name: "lint", Script: "vet"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+	}
+
+	var n struct {
+		N int `yaml:"n"`
+	}
+	if err := Unmarshal([]byte("n: |\n  1\n"), &n); err == nil {
+		t.Errorf("Unmarshal() of a block scalar into an int: expected error, got %+v", n)
+	}
+}
@@ -0,0 +1,39 @@
+package fastparser
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shapestone/shape-yaml/internal/limits"
+	"github.com/shapestone/shape-yaml/internal/resolve"
+)
+
+// parseDirectives consumes the directive lines at the start of a document and
+// the "---" marker that follows them, or starts a document without them. A
+// %YAML directive selects the version used to resolve plain scalars (see
+// resolve.UsesYAML11); %TAG and unknown directives are skipped. In safe mode
+// any directive is an error.
+func (p *Parser) parseDirectives() error {
+	p.skipWhitespaceAndComments()
+	for p.pos < p.length && p.data[p.pos] == '%' && p.column == 1 {
+		start := p.pos
+		for p.pos < p.length && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' && p.data[p.pos] != '#' {
+			p.advance()
+		}
+		if p.opts.SafeMode {
+			return p.errorAt(start, fmt.Errorf("directive %s %w", bytes.TrimSpace(p.data[start:p.pos]), limits.ErrUnsafe))
+		}
+		fields := bytes.Fields(p.data[start+1 : p.pos])
+		if len(fields) >= 2 && string(fields[0]) == "YAML" {
+			p.yaml11 = resolve.UsesYAML11(string(fields[1]))
+		}
+		p.skipWhitespaceAndComments()
+	}
+
+	// Skip the document start marker; content may follow it on the same line
+	if p.atDocumentMarker() && p.data[p.pos] == '-' {
+		p.pos += 3
+		p.column += 3
+	}
+	return nil
+}
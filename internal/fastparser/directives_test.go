@@ -0,0 +1,106 @@
+package fastparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDirectives_LeadingZeroIntegers tests that integers written with a
+// leading zero resolve per the declared version, in both Parse and Unmarshal.
+func TestDirectives_LeadingZeroIntegers(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{"default is decimal", "v: 010", int64(10)},
+		{"YAML 1.2 is decimal", "%YAML 1.2\n---\nv: 010", int64(10)},
+		{"YAML 1.1 is octal", "%YAML 1.1\n---\nv: 010", int64(8)},
+		{"YAML 1.1 non-octal digits stay a string", "%YAML 1.1\n---\nv: 09", "09"},
+		{"YAML 1.1 without document marker", "%YAML 1.1\nv: 010", int64(8)},
+		{"YAML 1.1 with comments", "# header\n%YAML 1.1 # version\n--- # start\nv: 010", int64(8)},
+		{"YAML 1.1 after TAG directive", "%TAG ! tag:example.com,2000:\n%YAML 1.1\n---\nv: 010", int64(8)},
+		{"YAML 1.1 in flow mapping", "%YAML 1.1\n--- {v: 010}", int64(8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewParser([]byte(tt.input)).Parse()
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if m, ok := got.(map[string]interface{}); !ok || !reflect.DeepEqual(m["v"], tt.want) {
+				t.Errorf("Parse() = %#v, want v = %#v", got, tt.want)
+			}
+
+			var doc struct {
+				V interface{} `yaml:"v"`
+			}
+			if err := Unmarshal([]byte(tt.input), &doc); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(doc.V, tt.want) {
+				t.Errorf("Unmarshal() v = %#v, want %#v", doc.V, tt.want)
+			}
+		})
+	}
+}
+
+// TestDirectives_TypedTargets tests that the YAML 1.1 reading reaches typed
+// struct fields: octal into an int, non-octal digits rejected as a string.
+func TestDirectives_TypedTargets(t *testing.T) {
+	var doc struct {
+		Mode int `yaml:"mode"`
+	}
+	if err := Unmarshal([]byte("%YAML 1.1\n---\nmode: 0755\n"), &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if doc.Mode != 0755 {
+		t.Errorf("mode = %d, want %d", doc.Mode, 0755)
+	}
+
+	if err := Unmarshal([]byte("%YAML 1.1\n---\nmode: 0789\n"), &doc); err == nil {
+		t.Error("Unmarshal() of 0789 into int under YAML 1.1: expected error, got nil")
+	}
+}
+
+// TestDirectives_DocumentMarkers tests that "---" and "..." frame a document
+// with or without directives, in both Parse and Unmarshal.
+func TestDirectives_DocumentMarkers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"---\nv: 1", map[string]interface{}{"v": int64(1)}},
+		{"--- 42", int64(42)},
+		{"---\n- x\n", []interface{}{"x"}},
+		{"v: 1\n...\n", map[string]interface{}{"v": int64(1)}},
+		{"--- x\n... # end\n", "x"},
+		{"---\n", nil},
+		{"...\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser([]byte(tt.input)).Parse()
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+
+			var v interface{}
+			if err := Unmarshal([]byte(tt.input), &v); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(v, tt.want) {
+				t.Errorf("Unmarshal() = %#v, want %#v", v, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewParser([]byte("v: 1\n---\nv: 2")).Parse(); err == nil {
+		t.Error("Parse() of two documents: expected error, got nil")
+	}
+}
@@ -0,0 +1,65 @@
+package fastparser
+
+import "bytes"
+
+// NextDocument returns the length of the first document in a stream: the
+// bytes up to the "---" line that starts the next document, or all of data.
+// Directives and the "---" that open the document belong to it, as does a
+// "..." line that ends it.
+//
+// Document markers are recognized line by line, without parsing the
+// document, since YAML does not allow "---" or "..." at the start of a line
+// inside a document.
+func NextDocument(data []byte) int {
+	opened := false // the document's "---" or content has been seen
+	for pos := 0; pos < len(data); {
+		end := len(data)
+		if i := bytes.IndexByte(data[pos:], '\n'); i >= 0 {
+			end = pos + i + 1
+		}
+		line := data[pos:end]
+		switch {
+		case isMarkerLine(line, "---"):
+			if opened {
+				return pos
+			}
+			opened = true
+		case isMarkerLine(line, "..."):
+			return end
+		case !opened && (isBlankLine(line) || line[0] == '%'):
+		default:
+			opened = true
+		}
+		pos = end
+	}
+	return len(data)
+}
+
+// HasDocument reports whether data holds a document: a "---" marker or any
+// content other than blank lines and comments.
+func HasDocument(data []byte) bool {
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if !isBlankLine(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMarkerLine reports whether line starts with the document marker m.
+func isMarkerLine(line []byte, m string) bool {
+	return bytes.HasPrefix(line, []byte(m)) && (len(line) == len(m) || isWhitespace(line[len(m)]))
+}
+
+// isBlankLine reports whether line holds only whitespace and a comment.
+func isBlankLine(line []byte) bool {
+	for _, c := range line {
+		if c == '#' {
+			return true
+		}
+		if !isWhitespace(c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package fastparser
+
+import "testing"
+
+func TestNextDocument(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string // the first document
+	}{
+		{"single document", "a: 1\nb: 2\n", "a: 1\nb: 2\n"},
+		{"separator ends document", "a: 1\n---\nb: 2\n", "a: 1\n"},
+		{"leading separator opens document", "---\na: 1\n---\nb: 2\n", "---\na: 1\n"},
+		{"directives open document", "# c\n%YAML 1.2\n---\na: 1\n---\n", "# c\n%YAML 1.2\n---\na: 1\n"},
+		{"content on marker line", "--- 1\n--- 2\n", "--- 1\n"},
+		{"end marker belongs to document", "a: 1\n...\n---\nb: 2\n", "a: 1\n...\n"},
+		{"empty document", "---\n---\na: 1\n", "---\n"},
+		{"dashes inside a scalar", "a: ---\nb: ----x\n", "a: ---\nb: ----x\n"},
+		{"no trailing newline", "a: 1\n---", "a: 1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.input[:NextDocument([]byte(tt.input))]; got != tt.want {
+				t.Errorf("NextDocument(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasDocument(t *testing.T) {
+	tests := map[string]bool{
+		"":              false,
+		"\n  \n":        false,
+		"# only\n  # c": false,
+		"---\n":         true,
+		"a: 1":          true,
+	}
+	for input, want := range tests {
+		if got := HasDocument([]byte(input)); got != want {
+			t.Errorf("HasDocument(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
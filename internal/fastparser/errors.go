@@ -0,0 +1,291 @@
+package fastparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/limits"
+	"github.com/shapestone/shape-yaml/internal/syntaxhint"
+)
+
+// ErrAnchors is wrapped by the error for an anchor or alias. The fast
+// parser does not resolve them, and reading them as text would decode
+// values that are not in the document.
+var ErrAnchors = errors.New("anchors and aliases are not supported by this decoder; use UnmarshalWithAST")
+
+// ParseError is an error found at a position in the input. It carries the
+// Message and Position of shape-core's parser.ParseError, plus the input
+// line the error was found on and the underlying error for errors.Is and
+// errors.As.
+type ParseError struct {
+	ast.Position        // Offset is in bytes; Line and Column are 1-based
+	Path         string // path of the value being decoded, as in spec.containers[2].image, or "" at the root
+	Message      string // what went wrong, including any "in field" context
+	Excerpt      string // the input line holding Offset, without its line break
+	Hint         string // a suggested fix for a common mistake, or ""
+	Err          error  // the underlying error
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("yaml: line %d, column %d: %s", e.Line, e.Column, e.Message)
+	if e.Hint != "" {
+		msg += "; " + e.Hint
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Snippet renders the input line of the error with a caret under its column,
+// for showing to users:
+//
+//	3 |   replicas: three
+//	  |             ^
+//
+// It returns "" if the position of the error is not known.
+func (e *ParseError) Snippet() string {
+	if e.Line < 1 || e.Column < 1 {
+		return ""
+	}
+	gutter := strconv.Itoa(e.Line)
+	prefix := e.Excerpt[:min(e.Column-1, len(e.Excerpt))]
+
+	var b strings.Builder
+	b.WriteString(gutter)
+	b.WriteString(" | ")
+	b.WriteString(e.Excerpt)
+	b.WriteByte('\n')
+	b.WriteString(strings.Repeat(" ", len(gutter)))
+	b.WriteString(" | ")
+	for _, r := range prefix {
+		if r == '\t' {
+			b.WriteByte('\t') // keep the caret aligned under tabs
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteByte('^')
+	return b.String()
+}
+
+// TypeError reports a YAML value that cannot be decoded into the Go value it
+// is destined for, such as a string into an int. Decoding errors wrap it in a
+// *ParseError; find it with errors.As.
+//
+// Decoding goes on past a value that does not fit, so that one pass finds
+// every mismatch in the document. If there is more than one, Unmarshal
+// returns a *TypeError whose Errors holds the *ParseError of each, in
+// document order, and whose other fields describe the first.
+type TypeError struct {
+	ast.Position              // where the value starts
+	Path         string       // path of the value, as in ParseError
+	Value        string       // what was found: "string", "number 1.5", "mapping", "sequence", ...
+	Type         reflect.Type // the Go type the value could not be decoded into
+	Errors       []error      // every mismatch in the document, when there is more than one
+}
+
+func (e *TypeError) Error() string {
+	if len(e.Errors) == 0 {
+		return "cannot unmarshal " + e.Value + " into " + e.Type.String()
+	}
+	var b strings.Builder
+	b.WriteString("yaml: unmarshal errors:")
+	for _, err := range e.Errors {
+		b.WriteString("\n  ")
+		b.WriteString(strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	return b.String()
+}
+
+// Unwrap returns the mismatches in Errors, so that errors.As finds the
+// *ParseError of the first.
+func (e *TypeError) Unwrap() []error {
+	return e.Errors
+}
+
+// JoinTypeErrors returns the type mismatches found decoding a document: nil
+// if errs is empty, its one error if it holds one, and otherwise a
+// *TypeError collecting them.
+func JoinTypeErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	agg := &TypeError{Errors: errs}
+	var first *TypeError
+	if errors.As(errs[0], &first) {
+		agg.Position, agg.Path, agg.Value, agg.Type = first.Position, first.Path, first.Value, first.Type
+	}
+	return agg
+}
+
+// MissingFieldError reports keys of fields tagged required that a mapping
+// decoded into a struct does not have. Decoding errors wrap it in a
+// *ParseError at the mapping; find it with errors.As.
+type MissingFieldError struct {
+	Type   reflect.Type // the struct type
+	Fields []string     // the missing keys, in field order
+}
+
+func (e *MissingFieldError) Error() string {
+	quoted := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		quoted[i] = strconv.Quote(f)
+	}
+	noun := "field"
+	if len(e.Fields) > 1 {
+		noun = "fields"
+	}
+	return fmt.Sprintf("missing required %s %s in %s", noun, strings.Join(quoted, ", "), e.Type)
+}
+
+// NewTypeError returns a *TypeError for value, a description from ValueKind,
+// found where a t was expected.
+// Its position and path are filled in as the error passes up the decoder.
+func NewTypeError(value string, t reflect.Type) *TypeError {
+	return &TypeError{Value: value, Type: t}
+}
+
+// ValueKind describes a decoded value for a TypeError: "mapping",
+// "sequence", "string", "bool", "timestamp", "null", or "number" followed by
+// the number when it is not an integer, as in "number 1.5".
+func ValueKind(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}, MapSlice:
+		return "mapping"
+	case []interface{}:
+		return "sequence"
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int64, uint64:
+		return "number"
+	case float64:
+		return "number " + strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return "timestamp"
+	case []byte:
+		return "binary"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// InKey prepends mapping key to the Path of the *ParseError err is, and of
+// the *TypeError it wraps, as err passes up out of the key's value. Other
+// errors are returned unchanged.
+func InKey(err error, key string) error {
+	return prependPath(err, keySegment(key))
+}
+
+// InItem prepends sequence index i to the Path of err, as InKey does.
+func InItem(err error, i int) error {
+	return prependPath(err, "["+strconv.Itoa(i)+"]")
+}
+
+func prependPath(err error, seg string) error {
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		pe.Path = joinPath(seg, pe.Path)
+	}
+	var te *TypeError
+	if errors.As(err, &te) {
+		te.Path = joinPath(seg, te.Path)
+	}
+	return err
+}
+
+// keySegment writes key as a path segment: as is if it is a plain name, and
+// otherwise quoted in brackets, as in labels["app.kubernetes.io/name"].
+func keySegment(key string) string {
+	if key == "" {
+		return `[""]`
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return "[" + strconv.Quote(key) + "]"
+		}
+	}
+	return key
+}
+
+func joinPath(seg, rest string) string {
+	switch {
+	case rest == "":
+		return seg
+	case rest[0] == '[':
+		return seg + rest
+	}
+	return seg + "." + rest
+}
+
+// errorf returns a *ParseError at the current position.
+func (p *Parser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.pos, fmt.Errorf(format, args...))
+}
+
+// errorAt returns err as a *ParseError at byte offset off, or unchanged if it
+// already carries a position.
+func (p *Parser) errorAt(off int, err error) error {
+	if errors.As(err, new(*ParseError)) {
+		return err
+	}
+
+	lineStart := bytes.LastIndexByte(p.data[:off], '\n') + 1
+	lineEnd := len(p.data)
+	if i := bytes.IndexByte(p.data[off:], '\n'); i >= 0 {
+		lineEnd = off + i
+	}
+	line := bytes.Count(p.data[:lineStart], []byte("\n")) + 1
+	column := off - lineStart + 1
+	excerpt := string(bytes.TrimSuffix(p.data[lineStart:lineEnd], []byte("\r")))
+	if line == 1 && p.opts.Column > 1 {
+		// Blank out what precedes data on its line, so that Snippet still
+		// puts the caret under the column
+		column += p.opts.Column - 1
+		excerpt = strings.Repeat(" ", p.opts.Column-1) + excerpt
+	}
+	pe := &ParseError{
+		Position: ast.NewPosition(p.opts.Offset+off, line, column),
+		Message:  err.Error(),
+		Excerpt:  excerpt,
+		Err:      err,
+	}
+	if !errors.Is(err, limits.ErrExceeded) && !errors.Is(err, limits.ErrUnsafe) {
+		pe.Hint = syntaxhint.ForAt(p.data, line, max(p.opts.Line, 1))
+	}
+	if p.opts.Line > 0 {
+		pe.Line += p.opts.Line - 1
+	}
+	var te *TypeError
+	if errors.As(err, &te) {
+		te.Position = pe.Position
+	}
+	return pe
+}
+
+// withContext prefixes the message of err, as in "in field "port": ...",
+// keeping the position of the *ParseError it wraps.
+func withContext(err error, format string, args ...interface{}) error {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		return fmt.Errorf(format+": %w", append(args, err)...)
+	}
+	ctx := *pe
+	ctx.Message = fmt.Sprintf(format, args...) + ": " + pe.Message
+	ctx.Err = err
+	return &ctx
+}
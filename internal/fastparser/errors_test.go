@@ -0,0 +1,190 @@
+package fastparser
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+func TestParseError_Position(t *testing.T) {
+	type target struct {
+		A int
+		B struct{ C []int }
+	}
+	tests := []struct {
+		name        string
+		input       string
+		line, col   int
+		offset      int
+		excerpt     string
+		wantMessage string
+	}{
+		{"type mismatch", "a: x", 1, 4, 3, "a: x", `in field "a": cannot unmarshal string into int`},
+		{"nested flow item", "a: 1\nb:\n  c: [1, x]", 3, 10, 17, "  c: [1, x]", `in field "b": in field "c": cannot unmarshal string into int`},
+		{"missing colon", "a: 1\nq\n", 2, 2, 6, "q", `expected ':' after key "q"`},
+		{"trailing content", "a: 1\n  - x", 2, 3, 7, "  - x", "unexpected content after YAML document"},
+		{"CRLF line", "a: 1\r\nb:\r\n  c: [x]\r\n", 3, 7, 16, "  c: [x]", `in field "b": in field "c": cannot unmarshal string into int`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v target
+			err := Unmarshal([]byte(tt.input), &v)
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Unmarshal() error = %v, want *ParseError", err)
+			}
+			if pe.Line != tt.line || pe.Column != tt.col || pe.Offset != tt.offset {
+				t.Errorf("position = line %d, column %d, offset %d; want line %d, column %d, offset %d",
+					pe.Line, pe.Column, pe.Offset, tt.line, tt.col, tt.offset)
+			}
+			if pe.Excerpt != tt.excerpt {
+				t.Errorf("Excerpt = %q, want %q", pe.Excerpt, tt.excerpt)
+			}
+			if pe.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", pe.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestParseError_Unwrap(t *testing.T) {
+	var v struct{ A []int }
+	err := Unmarshal([]byte("a: [1, 2"), &v)
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Unmarshal() error = %v, want *ParseError wrapping io.ErrUnexpectedEOF", err)
+	}
+
+	// Errors from the caller's hooks are passed through, not repositioned
+	hookErr := errors.New("rejected")
+	opts := Options{OnUnknownField: func(UnknownField) error { return hookErr }}
+	err = UnmarshalWithOptions([]byte("b: 1"), &v, opts)
+	if !errors.Is(err, hookErr) || errors.As(err, &pe) {
+		t.Errorf("UnmarshalWithOptions() error = %v, want the hook's error", err)
+	}
+}
+
+func TestParseError_Path(t *testing.T) {
+	type container struct {
+		Image string `yaml:"image"`
+		Port  int    `yaml:"port"`
+	}
+	type spec struct {
+		Containers []container      `yaml:"containers"`
+		Labels     map[string]int   `yaml:"labels"`
+		Pair       [2]int           `yaml:"pair"`
+		Any        interface{}      `yaml:"any"`
+		Ordered    MapSlice         `yaml:"ordered"`
+		Nested     map[string][]int `yaml:"nested"`
+	}
+	tests := []struct {
+		name  string
+		input string
+		path  string
+	}{
+		{"block sequence", "containers:\n  - image: a\n  - port: x", "containers[1].port"},
+		{"flow sequence", "containers: [{image: a}, {port: x}]", "containers[1].port"},
+		{"quoted key", "labels:\n  app.kubernetes.io/name: x", `labels["app.kubernetes.io/name"]`},
+		{"flow map", "labels: {a: 1, b: x}", "labels.b"},
+		{"array", "pair: [1, x]", "pair[1]"},
+		{"block array", "pair:\n  - 1\n  - x", "pair[1]"},
+		{"nested sequence", "nested:\n  a: [1, 2, x]", "nested.a[2]"},
+		{"syntax error in interface", "any:\n  a:\n    - [1, 2", "any.a[0]"},
+		{"root", "[1]", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v spec
+			err := Unmarshal([]byte(tt.input), &v)
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Unmarshal() error = %v, want *ParseError", err)
+			}
+			if pe.Path != tt.path {
+				t.Errorf("Path = %q, want %q (error %v)", pe.Path, tt.path, err)
+			}
+		})
+	}
+}
+
+func TestTypeError(t *testing.T) {
+	var v struct {
+		Items []struct {
+			Ratio int `yaml:"ratio"`
+		} `yaml:"items"`
+	}
+	err := Unmarshal([]byte("items:\n  - ratio: 1\n  - ratio: 1.5\n"), &v)
+	var te *TypeError
+	if !errors.As(err, &te) {
+		t.Fatalf("Unmarshal() error = %v, want a *TypeError", err)
+	}
+	if te.Value != "number 1.5" || te.Type.String() != "int" || te.Path != "items[1].ratio" {
+		t.Errorf("TypeError = %+v, want number 1.5 into int at items[1].ratio", te)
+	}
+	if te.Line != 3 || te.Column != 12 || te.Offset != 31 {
+		t.Errorf("TypeError position = %+v, want line 3, column 12, offset 31", te.Position)
+	}
+	if te.Error() != "cannot unmarshal number 1.5 into int" {
+		t.Errorf("Error() = %q", te.Error())
+	}
+}
+
+func TestTypeError_Collected(t *testing.T) {
+	var v struct {
+		Limits map[string]int `yaml:"limits"`
+		Hosts  [3]int         `yaml:"hosts"`
+		Nested struct {
+			Count int `yaml:"count"`
+		} `yaml:"nested"`
+		Name string `yaml:"name"`
+	}
+	input := "limits:\n  cpu: high\n  mem: 2\nhosts:\n  - 1\n  -\n    a: b\n  - 3\nnested:\n  count:\n    - 1\nname: web\n"
+	err := Unmarshal([]byte(input), &v)
+	var te *TypeError
+	if !errors.As(err, &te) {
+		t.Fatalf("Unmarshal() error = %v, want a *TypeError", err)
+	}
+	var paths []string
+	for _, e := range te.Errors {
+		var pe *ParseError
+		if !errors.As(e, &pe) {
+			t.Fatalf("Errors holds %T, want *ParseError", e)
+		}
+		paths = append(paths, pe.Path)
+	}
+	if want := []string{"limits.cpu", "hosts[1]", "nested.count"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+	if v.Limits["mem"] != 2 || v.Hosts != [3]int{1, 0, 3} || v.Name != "web" {
+		t.Errorf("decoded %+v, want the values around the mismatches", v)
+	}
+
+	// A syntax error still stops decoding, and is what is returned
+	err = Unmarshal([]byte("limits:\n  cpu: high\n  mem: [2\n"), &v)
+	if errors.As(err, &te) {
+		t.Errorf("Unmarshal() error = %v, want the syntax error", err)
+	}
+}
+
+func TestParseError_Snippet(t *testing.T) {
+	tests := []struct {
+		name string
+		err  ParseError
+		want string
+	}{
+		{"column", ParseError{Position: ast.NewPosition(0, 3, 13), Excerpt: "  replicas: three"}, "3 |   replicas: three\n  |             ^"},
+		{"tab", ParseError{Position: ast.NewPosition(0, 12, 3), Excerpt: "\ta: b"}, "12 | \ta: b\n   | \t ^"},
+		{"end of line", ParseError{Position: ast.NewPosition(0, 1, 9), Excerpt: "a: [1, 2"}, "1 | a: [1, 2\n  |         ^"},
+		{"no position", ParseError{Message: "x"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Snippet(); got != tt.want {
+				t.Errorf("Snippet() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -75,6 +75,15 @@ func TestParser_FlowMapping(t *testing.T) {
 				"key": nil,
 			},
 		},
+		{
+			name:  "flow mapping with keys without values",
+			input: `{a, b: 1, c}`,
+			expected: map[string]interface{}{
+				"a": nil,
+				"b": int64(1),
+				"c": nil,
+			},
+		},
 		{
 			name:  "flow mapping with spaces",
 			input: `{ name : Alice , age : 30 }`,
@@ -82,6 +91,25 @@ func TestParser_FlowMapping(t *testing.T) {
 				"name": "Alice",
 				"age":  int64(30),
 			},
+		}, {
+			name:  "flow sequence as key",
+			input: `{[1,2]: pair, k: v}`,
+			expected: map[string]interface{}{
+				"[1,2]": "pair",
+				"k":     "v",
+			},
+		},
+		{
+			name:  "flow mapping as key",
+			input: `{ {b: [x, ~], a: 1}: x }`,
+			expected: map[string]interface{}{
+				`{"a":1,"b":["x",null]}`: "x",
+			},
+		},
+		{
+			name:    "unterminated key collection",
+			input:   `{[1, 2: x}`,
+			wantErr: true,
 		},
 	}
 
@@ -0,0 +1,121 @@
+package fastparser
+
+import "github.com/shapestone/shape-yaml/internal/limits"
+
+// maxDepth returns the effective nesting limit, or 0 for no limit.
+func (p *Parser) maxDepth() int {
+	switch {
+	case p.opts.MaxDepth < 0:
+		return 0
+	case p.opts.MaxDepth == 0:
+		return limits.DefaultMaxDepth
+	default:
+		return p.opts.MaxDepth
+	}
+}
+
+// enterCollection records the start of a mapping or sequence and enforces the
+// depth limit. Depth is counted as in the AST parser: a top-level scalar has
+// depth 1 and each enclosing collection adds one level, so a collection is
+// only allowed if its entries still fit. Every successful call must be paired
+// with leaveCollection.
+func (p *Parser) enterCollection() error {
+	if max := p.maxDepth(); max > 0 && p.depth+1 >= max {
+		return p.errorf("%w: nesting deeper than %d", limits.ErrExceeded, max)
+	}
+	p.depth++
+	return nil
+}
+
+// leaveCollection records the end of a collection started with enterCollection.
+func (p *Parser) leaveCollection() {
+	p.depth--
+}
+
+// checkKey enforces Options.MaxKeyLength on a mapping key.
+func (p *Parser) checkKey(key string) error {
+	if p.opts.MaxKeyLength > 0 && len(key) > p.opts.MaxKeyLength {
+		return p.errorf("%w: mapping key longer than %d bytes", limits.ErrExceeded, p.opts.MaxKeyLength)
+	}
+	return nil
+}
+
+// checkPlain rejects, at the start of a plain scalar or key, the anchor or
+// alias the fast parser would otherwise read as part of the text, with an
+// error wrapping ErrAnchors, as it does not resolve them. In safe mode it
+// rejects those and tags with an error wrapping limits.ErrUnsafe.
+func (p *Parser) checkPlain() error {
+	if p.pos >= p.length {
+		return nil
+	}
+	var construct string
+	switch p.data[p.pos] {
+	case '&':
+		construct = "anchor"
+	case '*':
+		construct = "alias"
+	case '!':
+		if !p.opts.SafeMode {
+			return nil
+		}
+		construct = "tag"
+	default:
+		return nil
+	}
+	end := p.pos
+	for end < p.length {
+		c := p.data[end]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' || c == ':' || c == ']' || c == '}' {
+			break
+		}
+		end++
+	}
+	if !p.opts.SafeMode {
+		return p.errorf("%s %s: %w", construct, p.data[p.pos:end], ErrAnchors)
+	}
+	return p.errorf("%s %s %w", construct, p.data[p.pos:end], limits.ErrUnsafe)
+}
+
+// nullScalarLen returns the length of a null keyword (null, Null, NULL or ~)
+// at the current position if it forms the whole scalar, or 0 otherwise.
+func (p *Parser) nullScalarLen() int {
+	rest := p.data[p.pos:]
+	n := 0
+	switch {
+	case len(rest) > 0 && rest[0] == '~':
+		n = 1
+	case len(rest) >= 4:
+		switch string(rest[:4]) {
+		case "null", "Null", "NULL":
+			n = 4
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+
+	// Only spaces may separate the keyword from the end of the value
+	i := n
+	for i < len(rest) && (rest[i] == ' ' || rest[i] == '\t') {
+		i++
+	}
+	if i == len(rest) {
+		return n
+	}
+	switch rest[i] {
+	case '\n', '\r', ',', ']', '}':
+		return n
+	case '#':
+		if i > n {
+			return n
+		}
+	}
+	return 0
+}
+
+// skipNullScalar consumes a null keyword found by nullScalarLen.
+func (p *Parser) skipNullScalar(n int) {
+	for i := 0; i < n; i++ {
+		p.advance()
+	}
+}
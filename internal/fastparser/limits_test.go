@@ -0,0 +1,179 @@
+package fastparser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-yaml/internal/limits"
+)
+
+type treeNode struct {
+	Value    string      `yaml:"value"`
+	Next     *treeNode   `yaml:"next"`
+	Children []*treeNode `yaml:"children"`
+}
+
+func TestUnmarshal_RecursiveTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  treeNode
+	}{
+		{
+			name:  "block",
+			input: "value: a\nnext:\n  value: b\n  next:\n    value: c\n",
+			want:  treeNode{Value: "a", Next: &treeNode{Value: "b", Next: &treeNode{Value: "c"}}},
+		},
+		{
+			name:  "flow mapping into pointer",
+			input: "{value: a, next: {value: b}}",
+			want:  treeNode{Value: "a", Next: &treeNode{Value: "b"}},
+		},
+		{
+			name:  "flow sequence of pointers",
+			input: "children: [{value: a}, null, {value: b, children: [{value: c}]}]",
+			want: treeNode{Children: []*treeNode{
+				{Value: "a"},
+				nil,
+				{Value: "b", Children: []*treeNode{{Value: "c"}}},
+			}},
+		},
+		{
+			name:  "block sequence of pointers",
+			input: "children:\n  - value: a\n  - null\n  - ~\n",
+			want:  treeNode{Children: []*treeNode{{Value: "a"}, nil, nil}},
+		},
+		{
+			name:  "null pointer",
+			input: "value: a\nnext: null # end\n",
+			want:  treeNode{Value: "a"},
+		},
+		{
+			name:  "null-like string is not null",
+			input: "value: null terminator\n",
+			want:  treeNode{Value: "null terminator"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got treeNode
+			if err := Unmarshal([]byte(tt.input), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_MaxDepth(t *testing.T) {
+	nestedFlow := func(n int) string { return strings.Repeat("[", n) + strings.Repeat("]", n) }
+	nestedBlock := func(n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteString(strings.Repeat(" ", i))
+			b.WriteString("next:\n")
+		}
+		return b.String()
+	}
+	nestedNodes := func(n int) string {
+		return strings.Repeat("{next: ", n) + "{value: x}" + strings.Repeat("}", n)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		target   func() interface{}
+		maxDepth int
+		wantErr  bool
+	}{
+		{"within limit", "a:\n  b: 1", func() interface{} { return new(interface{}) }, 3, false},
+		{"too deep", "a:\n  b: 1", func() interface{} { return new(interface{}) }, 2, true},
+		{"scalar at depth 1", "hello", func() interface{} { return new(string) }, 1, false},
+		{"flow into interface", nestedFlow(50), func() interface{} { return new(interface{}) }, 20, true},
+		{"block into map", nestedBlock(50), func() interface{} { return new(map[string]interface{}) }, 20, true},
+		{"recursive struct", nestedNodes(50), func() interface{} { return new(treeNode) }, 20, true},
+		{"recursive struct within limit", nestedNodes(10), func() interface{} { return new(treeNode) }, 20, false},
+		{"default limit", nestedFlow(limits.DefaultMaxDepth + 1), func() interface{} { return new(interface{}) }, 0, true},
+		{"negative disables limit", nestedFlow(limits.DefaultMaxDepth + 1), func() interface{} { return new(interface{}) }, -1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := UnmarshalWithOptions([]byte(tt.input), tt.target(), Options{MaxDepth: tt.maxDepth})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, limits.ErrExceeded) {
+				t.Errorf("Unmarshal() error = %v, want limits.ErrExceeded", err)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_MaxKeyLength(t *testing.T) {
+	type config struct {
+		Name string `yaml:"name"`
+	}
+	tests := []struct {
+		name    string
+		input   string
+		target  func() interface{}
+		wantErr bool
+	}{
+		{"within limit", "name: a-long-value", func() interface{} { return new(config) }, false},
+		{"block key into struct", "namespace: x", func() interface{} { return new(config) }, true},
+		{"block key into map", "namespace: x", func() interface{} { return new(map[string]string) }, true},
+		{"quoted key", "\"namespace\": x", func() interface{} { return new(interface{}) }, true},
+		{"flow key into struct", "{name: a, namespace: x}", func() interface{} { return new(config) }, true},
+		{"flow key into map", "{namespace: x}", func() interface{} { return new(map[string]string) }, true},
+		{"nested key", "a:\n  namespace: x", func() interface{} { return new(interface{}) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := UnmarshalWithOptions([]byte(tt.input), tt.target(), Options{MaxKeyLength: 4})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, limits.ErrExceeded) {
+				t.Errorf("Unmarshal() error = %v, want limits.ErrExceeded", err)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_SafeMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"plain data", "a: b&c*d!e\nb: [x, {c: true}]\nc: \"*quoted\"", ""},
+		{"anchor", "a: &base 1", "anchor &base not allowed in safe mode"},
+		{"alias", "a: 1\nb: *base", "alias *base not allowed in safe mode"},
+		{"flow alias", "a: [x, *base]", "alias *base not allowed in safe mode"},
+		{"flow key", "{*base: 1}", "alias *base not allowed in safe mode"},
+		{"block key", "&k a: 1", "anchor &k not allowed in safe mode"},
+		{"core tag", "a: !!str 1", "tag !!str not allowed in safe mode"},
+		{"directive", "%YAML 1.2\n---\na: 1", "directive %YAML 1.2 not allowed in safe mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			err := UnmarshalWithOptions([]byte(tt.input), &v, Options{SafeMode: true})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, limits.ErrUnsafe) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Unmarshal() error = %v, want limits.ErrUnsafe with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package fastparser
+
+import (
+	"reflect"
+
+	"github.com/shapestone/shape-yaml/internal/utf8input"
+)
+
+// MapItem is a single key/value entry of a MapSlice.
+type MapItem struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// MapSlice is a mapping that keeps its entries in order. Decoding into a
+// MapSlice preserves document order, including for nested mappings, which
+// are decoded as MapSlice values too.
+type MapSlice []MapItem
+
+var mapSliceType = reflect.TypeOf(MapSlice(nil))
+
+// mappingBuilder accumulates the entries of a mapping, either as a
+// map[string]interface{} or, when the parser is in ordered mode, as a MapSlice.
+type mappingBuilder struct {
+	m       map[string]interface{}
+	items   MapSlice
+	index   map[string]int // position of each key in items
+	ordered bool
+}
+
+func (p *Parser) newMappingBuilder() *mappingBuilder {
+	if p.ordered {
+		return &mappingBuilder{items: MapSlice{}, index: make(map[string]int), ordered: true}
+	}
+	return &mappingBuilder{m: make(map[string]interface{})}
+}
+
+// set adds an entry. As with maps, a repeated key replaces the earlier value;
+// in a MapSlice the entry keeps the position of its first occurrence.
+func (b *mappingBuilder) set(key string, value interface{}) {
+	if !b.ordered {
+		b.m[key] = value
+		return
+	}
+	if i, ok := b.index[key]; ok {
+		b.items[i].Value = value
+		return
+	}
+	b.index[key] = len(b.items)
+	b.items = append(b.items, MapItem{Key: key, Value: value})
+}
+
+// result returns the accumulated mapping.
+func (b *mappingBuilder) result() interface{} {
+	if b.ordered {
+		return b.items
+	}
+	return b.m
+}
+
+// unmarshalMapSlice decodes the value at the current position into a MapSlice,
+// with every nested mapping decoded in document order as well.
+func (p *Parser) unmarshalMapSlice(rv reflect.Value, baseIndent int) error {
+	start := p.pos
+	prev := p.ordered
+	p.ordered = true
+	value, err := p.parseValue(baseIndent)
+	p.ordered = prev
+	if err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case MapSlice:
+		rv.Set(reflect.ValueOf(v))
+		return nil
+	case nil:
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	default:
+		return p.errorAt(start, NewTypeError(ValueKind(value), rv.Type()))
+	}
+}
+
+// ParseOrdered parses data into native Go values like Parser.Parse, except
+// that mappings are returned as MapSlice values in document order.
+func ParseOrdered(data []byte) (interface{}, error) {
+	if err := utf8input.Check(data); err != nil {
+		return nil, err
+	}
+	p := NewParser(data)
+	p.ordered = true
+	return p.Parse()
+}
@@ -0,0 +1,71 @@
+package fastparser
+
+import (
+	"bytes"
+	"reflect"
+	"unicode/utf8"
+)
+
+// NodeDecoder decodes the values of the types it claims in place of the
+// parser, for types that decode themselves from a parsed node rather than
+// from Go values.
+type NodeDecoder interface {
+	// Claims reports whether values of type t are decoded by DecodeNode.
+	Claims(t reflect.Type) bool
+
+	// DecodeNode decodes the value that starts at line and column of data
+	// into rv. Both are 1-based, and column counts runes.
+	DecodeNode(rv reflect.Value, data []byte, line, column int) error
+}
+
+// claims reports whether opts.Nodes decodes values of type t.
+func (p *Parser) claims(t reflect.Type) bool {
+	return p.opts.Nodes != nil && p.opts.Nodes.Claims(t)
+}
+
+// unmarshalNode has opts.Nodes decode the value at the current position,
+// which skip parses past. A null value leaves the zero value.
+func (p *Parser) unmarshalNode(rv reflect.Value, skip func() (interface{}, error)) error {
+	if n := p.nullScalarLen(); n > 0 {
+		p.skipNullScalar(n)
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+
+	start := p.pos
+	if _, err := skip(); err != nil {
+		return err
+	}
+	line, column := p.nodePosition(start)
+	if err := p.opts.Nodes.DecodeNode(rv, p.data, line, column); err != nil {
+		return p.errorAt(start, err)
+	}
+	return nil
+}
+
+// nodeMark is a position nodePosition has counted up to.
+type nodeMark struct {
+	off    int // byte offset
+	lines  int // line breaks before off
+	column int // runes between the start of the line and off
+}
+
+// nodePosition returns the 1-based line and rune column of the byte at off.
+// Nodes are decoded in document order, so it counts on from the previous
+// node rather than from the start of the data, which would make decoding
+// many nodes on one long line, or in one long document, quadratic.
+func (p *Parser) nodePosition(off int) (line, column int) {
+	m := &p.nodeMark
+	if off < m.off {
+		*m = nodeMark{}
+	}
+	seg := p.data[m.off:off]
+	if i := bytes.LastIndexByte(seg, '\n'); i >= 0 {
+		m.lines += bytes.Count(seg, []byte("\n"))
+		m.column = utf8.RuneCount(seg[i+1:])
+	} else {
+		m.column += utf8.RuneCount(seg)
+	}
+	m.off = off
+	return m.lines + 1, m.column + 1
+}
@@ -10,23 +10,41 @@
 package fastparser
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"math"
-	"strconv"
+	"io"
+	"strings"
+
+	"github.com/shapestone/shape-yaml/internal/bufpool"
+	"github.com/shapestone/shape-yaml/internal/canonkey"
+	"github.com/shapestone/shape-yaml/internal/escape"
+	"github.com/shapestone/shape-yaml/internal/resolve"
+	"github.com/shapestone/shape-yaml/internal/utf8input"
 )
 
 // Parser implements a high-performance YAML parser that builds values directly without AST.
 type Parser struct {
-	data   []byte
-	pos    int
-	length int
-	line   int
-	column int
+	data    []byte
+	pos     int
+	length  int
+	line    int
+	column  int
+	opts    Options
+	ordered bool // decode mappings as MapSlice (document order) instead of maps
+	depth   int  // current collection nesting depth
+	yaml11  bool // resolve plain scalars under YAML 1.1 (%YAML 1.1 directive)
+
+	noValue    bool        // the explicit key just read has no value; see parseColon
+	mismatches []error     // type mismatches decoded past, reported once the document is done
+	nodeMark   nodeMark    // where the position of the last node handed to opts.Nodes was counted
+	tagged     NodeDecoder // decodes tagged values; see tagDecoder
 }
 
-// NewParser creates a new fast parser for the given data.
+// NewParser creates a new fast parser for the given data, which may start
+// with a byte order mark. Positions count from after the mark.
 func NewParser(data []byte) *Parser {
+	data = utf8input.TrimBOM(data)
 	return &Parser{
 		data:   data,
 		pos:    0,
@@ -38,19 +56,42 @@ func NewParser(data []byte) *Parser {
 
 // Parse parses the YAML data and returns the value as interface{}.
 func (p *Parser) Parse() (interface{}, error) {
+	if err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
 	p.skipWhitespaceAndComments()
-	if p.pos >= p.length {
-		return nil, nil // Empty document
+	if p.pos >= p.length || p.atDocumentMarker() {
+		return nil, p.checkDocumentEnd() // Empty document
 	}
 
 	value, err := p.parseValue(0)
 	if err != nil {
 		return nil, err
 	}
+	if err := p.checkDocumentEnd(); err != nil {
+		return nil, err
+	}
 
 	return value, nil
 }
 
+// checkDocumentEnd reports content left over after the root value and an
+// optional "..." end marker. Block collections stop at the first line that is
+// not at their own indentation, so a line indented to no open collection's
+// level ends up here rather than being dropped.
+func (p *Parser) checkDocumentEnd() error {
+	p.skipWhitespaceAndComments()
+	if p.atDocumentMarker() && p.data[p.pos] == '.' {
+		p.pos += 3
+		p.column += 3
+		p.skipWhitespaceAndComments()
+	}
+	if p.pos < p.length {
+		return p.errorf("unexpected content after YAML document")
+	}
+	return nil
+}
+
 // parseValue parses any YAML value at the given indentation level.
 func (p *Parser) parseValue(indent int) (interface{}, error) {
 	p.skipWhitespaceAndComments()
@@ -60,16 +101,28 @@ func (p *Parser) parseValue(indent int) (interface{}, error) {
 
 	c := p.data[p.pos]
 
+	if p.atTaggedValue() {
+		return p.parseTagged(false)
+	}
+
 	// Flow style
 	if c == '{' {
-		return p.parseFlowMapping()
+		return p.parseFlowMapping(false)
 	}
 	if c == '[' {
 		return p.parseFlowSequence()
 	}
 
+	// Literal or folded block scalar
+	if isBlockScalarIndicator(c) {
+		return p.parseBlockScalar()
+	}
+
 	// Block sequence (starts with -)
 	if c == '-' && p.isSequenceIndicator() {
+		if err := p.checkSequenceStart(); err != nil {
+			return nil, err
+		}
 		return p.parseBlockSequence(indent)
 	}
 
@@ -83,13 +136,28 @@ func (p *Parser) parseValue(indent int) (interface{}, error) {
 }
 
 // looksLikeMapping checks if current position looks like a mapping entry (key: value).
+// A quoted scalar at the current position is skipped whole, so a ": " within
+// it, as in "a: b", does not make it a key.
 func (p *Parser) looksLikeMapping() bool {
 	// Scan ahead to find a colon followed by space/newline
 	savedPos := p.pos
 	defer func() { p.pos = savedPos }()
 
+	if p.atExplicitKey() {
+		return true
+	}
+	if p.pos < p.length && (p.data[p.pos] == '"' || p.data[p.pos] == '\'') {
+		end := quotedEnd(p.data, p.pos)
+		if end < 0 {
+			return false
+		}
+		p.pos = end
+	}
 	for p.pos < p.length {
 		c := p.data[p.pos]
+		if c == '#' && p.pos > savedPos && isWhitespace(p.data[p.pos-1]) {
+			return false
+		}
 		if c == ':' {
 			// Check if followed by space, newline, or EOF
 			if p.pos+1 >= p.length {
@@ -108,6 +176,49 @@ func (p *Parser) looksLikeMapping() bool {
 	return false
 }
 
+// quotedEnd returns the offset after the quoted scalar that starts at off,
+// or -1 if it does not end on its line, as an implicit key must. Within
+// double quotes a backslash escapes the next character; within single
+// quotes a quote is escaped by doubling it.
+func quotedEnd(data []byte, off int) int {
+	q := data[off]
+	for i := off + 1; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '\n' || c == '\r':
+			return -1
+		case q == '"' && c == '\\':
+			i++
+		case c == q:
+			if q == '\'' && i+1 < len(data) && data[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// atDocumentMarker reports whether the current position is a "---" or "..."
+// marker at the start of a line.
+func (p *Parser) atDocumentMarker() bool {
+	if p.column != 1 || p.pos+3 > p.length {
+		return false
+	}
+	m := p.data[p.pos : p.pos+3]
+	if string(m) != "---" && string(m) != "..." {
+		return false
+	}
+	return p.pos+3 == p.length || isWhitespace(p.data[p.pos+3])
+}
+
+// errUnexpectedEOF reports input that ends inside a flow collection or a
+// quoted scalar. It wraps io.ErrUnexpectedEOF so that a Decoder's caller can
+// tell a truncated document from a malformed one.
+func (p *Parser) errUnexpectedEOF(msg string) error {
+	return p.errorf("%s: %w", msg, io.ErrUnexpectedEOF)
+}
+
 // isSequenceIndicator checks if current position is a sequence indicator (- followed by space).
 func (p *Parser) isSequenceIndicator() bool {
 	if p.pos >= p.length || p.data[p.pos] != '-' {
@@ -120,9 +231,47 @@ func (p *Parser) isSequenceIndicator() bool {
 	return next == ' ' || next == '\t' || next == '\n' || next == '\r'
 }
 
+// atNestedValue reports whether the content at the current position, the
+// first on its line, is the value of a key at indent baseIndent whose ':'
+// ends its line: content indented further, or a block sequence at the key's
+// own indent, which YAML allows, as in "items:\n- a\n- b".
+func (p *Parser) atNestedValue(baseIndent int) bool {
+	indent := p.currentIndent()
+	return indent > baseIndent || indent == baseIndent && p.isSequenceIndicator()
+}
+
+// checkSequenceStart reports a sequence indicator on the line of a mapping
+// key, as in "key: - a", where YAML does not allow a block sequence to
+// start: only indentation, a "---" marker and the indicators "- ", "? " and
+// ": " may come before one on its line.
+func (p *Parser) checkSequenceStart() error {
+	start := p.pos
+	for start > 0 && p.data[start-1] != '\n' && p.data[start-1] != '\r' {
+		start--
+	}
+	if isMarkerLine(p.data[start:p.pos], "---") {
+		start += 3
+	}
+	for i := start; i < p.pos; i++ {
+		switch c := p.data[i]; {
+		case isWhitespace(c):
+		case (c == '-' || c == '?' || c == ':') && isWhitespace(p.data[i+1]):
+			i++
+		default:
+			return p.errorf("block sequence entries are not allowed on the line of a mapping key")
+		}
+	}
+	return nil
+}
+
 // parseBlockMapping parses a YAML block mapping.
-func (p *Parser) parseBlockMapping(baseIndent int) (map[string]interface{}, error) {
-	result := make(map[string]interface{})
+func (p *Parser) parseBlockMapping(baseIndent int) (interface{}, error) {
+	if err := p.enterCollection(); err != nil {
+		return nil, err
+	}
+	defer p.leaveCollection()
+
+	result := p.newMappingBuilder()
 	first := true
 
 	for p.pos < p.length {
@@ -146,6 +295,11 @@ func (p *Parser) parseBlockMapping(baseIndent int) (map[string]interface{}, erro
 			}
 		}
 
+		// A "---" or "..." marker ends the document
+		if p.atDocumentMarker() {
+			break
+		}
+
 		// Parse key
 		key, err := p.parseKey()
 		if err != nil {
@@ -155,12 +309,9 @@ func (p *Parser) parseBlockMapping(baseIndent int) (map[string]interface{}, erro
 			break
 		}
 
-		// Expect colon
-		p.skipSpaces()
-		if p.pos >= p.length || p.data[p.pos] != ':' {
-			return nil, fmt.Errorf("expected ':' after key %q at line %d", key, p.line)
+		if err := p.parseColon(key); err != nil {
+			return nil, err
 		}
-		p.advance() // skip ':'
 
 		// Parse value
 		p.skipSpaces()
@@ -170,32 +321,34 @@ func (p *Parser) parseBlockMapping(baseIndent int) (map[string]interface{}, erro
 			// Inline value
 			value, err = p.parseValue(baseIndent)
 			if err != nil {
-				return nil, fmt.Errorf("in value for key %q: %w", key, err)
+				return nil, withContext(InKey(err, key), "in value for key %q", key)
 			}
 		} else {
 			// Value on next line (or empty)
 			p.skipToNextLine()
 			p.skipWhitespaceAndComments()
 
-			if p.pos < p.length {
-				nextIndent := p.currentIndent()
-				if nextIndent > baseIndent {
-					value, err = p.parseValue(nextIndent)
-					if err != nil {
-						return nil, fmt.Errorf("in value for key %q: %w", key, err)
-					}
+			if p.pos < p.length && p.atNestedValue(baseIndent) {
+				value, err = p.parseValue(p.currentIndent())
+				if err != nil {
+					return nil, withContext(InKey(err, key), "in value for key %q", key)
 				}
 			}
 		}
 
-		result[key] = value
+		result.set(key, value)
 	}
 
-	return result, nil
+	return result.result(), nil
 }
 
 // parseBlockSequence parses a YAML block sequence.
 func (p *Parser) parseBlockSequence(baseIndent int) ([]interface{}, error) {
+	if err := p.enterCollection(); err != nil {
+		return nil, err
+	}
+	defer p.leaveCollection()
+
 	result := make([]interface{}, 0, 8)
 	first := true
 
@@ -240,7 +393,7 @@ func (p *Parser) parseBlockSequence(baseIndent int) ([]interface{}, error) {
 			// Inline value after dash
 			value, err = p.parseValue(p.contentColumn())
 			if err != nil {
-				return nil, fmt.Errorf("in sequence item %d: %w", len(result), err)
+				return nil, withContext(InItem(err, len(result)), "in sequence item %d", len(result))
 			}
 		} else {
 			// Value on next line
@@ -252,7 +405,7 @@ func (p *Parser) parseBlockSequence(baseIndent int) ([]interface{}, error) {
 				if nextIndent > baseIndent {
 					value, err = p.parseValue(nextIndent)
 					if err != nil {
-						return nil, fmt.Errorf("in sequence item %d: %w", len(result), err)
+						return nil, withContext(InItem(err, len(result)), "in sequence item %d", len(result))
 					}
 				}
 			}
@@ -264,20 +417,29 @@ func (p *Parser) parseBlockSequence(baseIndent int) ([]interface{}, error) {
 	return result, nil
 }
 
-// parseFlowMapping parses a flow-style mapping: {key: value, ...}
-func (p *Parser) parseFlowMapping() (map[string]interface{}, error) {
-	if p.pos >= p.length || p.data[p.pos] != '{' {
-		return nil, errors.New("expected '{'")
+// parseFlowMapping parses a flow-style mapping: {key: value, ...}. With
+// pair set it parses a single-pair mapping, the entry of a flow sequence
+// written "key: value" without braces, as in [a: 1, b: 2], and leaves the
+// position after its value.
+func (p *Parser) parseFlowMapping(pair bool) (interface{}, error) {
+	if err := p.enterCollection(); err != nil {
+		return nil, err
 	}
-	p.advance() // skip '{'
+	defer p.leaveCollection()
 
-	result := make(map[string]interface{})
-	p.skipWhitespaceAndComments()
+	result := p.newMappingBuilder()
+	if !pair {
+		if p.pos >= p.length || p.data[p.pos] != '{' {
+			return nil, p.errorf("expected '{'")
+		}
+		p.advance() // skip '{'
+		p.skipWhitespaceAndComments()
+	}
 
 	// Handle empty mapping
-	if p.pos < p.length && p.data[p.pos] == '}' {
+	if !pair && p.pos < p.length && p.data[p.pos] == '}' {
 		p.advance()
-		return result, nil
+		return result.result(), nil
 	}
 
 	for {
@@ -292,35 +454,45 @@ func (p *Parser) parseFlowMapping() (map[string]interface{}, error) {
 		p.skipWhitespaceAndComments()
 
 		// Expect ':'
-		if p.pos >= p.length || p.data[p.pos] != ':' {
-			return nil, errors.New("expected ':' after flow mapping key")
+		if p.pos >= p.length {
+			return nil, p.errUnexpectedEOF("unexpected end of input in flow mapping")
+		}
+		// A key without a value ({a, b}, as a !!set is written) is null
+		switch p.data[p.pos] {
+		case ':':
+			p.advance()
+		case ',', '}':
+		default:
+			return nil, p.errorf("expected ':' after flow mapping key")
 		}
-		p.advance()
 
 		p.skipWhitespaceAndComments()
 
 		// Parse value
 		value, err := p.parseFlowValue()
 		if err != nil {
-			return nil, err
+			return nil, InKey(err, key)
 		}
 
-		result[key] = value
+		result.set(key, value)
+		if pair {
+			return result.result(), nil
+		}
 
 		p.skipWhitespaceAndComments()
 
 		// Check for more entries or end
 		if p.pos >= p.length {
-			return nil, errors.New("unexpected end of input in flow mapping")
+			return nil, p.errUnexpectedEOF("unexpected end of input in flow mapping")
 		}
 
 		if p.data[p.pos] == '}' {
 			p.advance()
-			return result, nil
+			return result.result(), nil
 		}
 
 		if p.data[p.pos] != ',' {
-			return nil, fmt.Errorf("expected ',' or '}' in flow mapping at position %d", p.pos)
+			return nil, p.errorf("expected ',' or '}' in flow mapping")
 		}
 		p.advance() // skip ','
 	}
@@ -328,8 +500,13 @@ func (p *Parser) parseFlowMapping() (map[string]interface{}, error) {
 
 // parseFlowSequence parses a flow-style sequence: [item1, item2, ...]
 func (p *Parser) parseFlowSequence() ([]interface{}, error) {
+	if err := p.enterCollection(); err != nil {
+		return nil, err
+	}
+	defer p.leaveCollection()
+
 	if p.pos >= p.length || p.data[p.pos] != '[' {
-		return nil, errors.New("expected '['")
+		return nil, p.errorf("expected '['")
 	}
 	p.advance() // skip '['
 
@@ -346,9 +523,9 @@ func (p *Parser) parseFlowSequence() ([]interface{}, error) {
 		p.skipWhitespaceAndComments()
 
 		// Parse value
-		value, err := p.parseFlowValue()
+		value, err := p.parseFlowEntry()
 		if err != nil {
-			return nil, err
+			return nil, InItem(err, len(result))
 		}
 
 		result = append(result, value)
@@ -357,7 +534,7 @@ func (p *Parser) parseFlowSequence() ([]interface{}, error) {
 
 		// Check for more entries or end
 		if p.pos >= p.length {
-			return nil, errors.New("unexpected end of input in flow sequence")
+			return nil, p.errUnexpectedEOF("unexpected end of input in flow sequence")
 		}
 
 		if p.data[p.pos] == ']' {
@@ -366,7 +543,7 @@ func (p *Parser) parseFlowSequence() ([]interface{}, error) {
 		}
 
 		if p.data[p.pos] != ',' {
-			return nil, fmt.Errorf("expected ',' or ']' in flow sequence at position %d", p.pos)
+			return nil, p.errorf("expected ',' or ']' in flow sequence")
 		}
 		p.advance() // skip ','
 	}
@@ -375,13 +552,16 @@ func (p *Parser) parseFlowSequence() ([]interface{}, error) {
 // parseFlowValue parses a value in flow context.
 func (p *Parser) parseFlowValue() (interface{}, error) {
 	if p.pos >= p.length {
-		return nil, errors.New("unexpected end of input")
+		return nil, p.errUnexpectedEOF("unexpected end of input")
 	}
 
 	c := p.data[p.pos]
 
+	if c == '!' && p.tagDecoder() != nil {
+		return p.parseTagged(true)
+	}
 	if c == '{' {
-		return p.parseFlowMapping()
+		return p.parseFlowMapping(false)
 	}
 	if c == '[' {
 		return p.parseFlowSequence()
@@ -397,14 +577,127 @@ func (p *Parser) parseFlowValue() (interface{}, error) {
 	return p.parseFlowScalar()
 }
 
-// parseFlowKey parses a key in flow context.
+// atFlowPair reports whether the flow sequence entry at the current
+// position is a single-pair mapping with a scalar key: a key followed by a
+// pair's ':' on its line, as in [a: 1]. Keys that are flow collections are
+// not looked past, as doing so at every level of nested collections would
+// take exponential time; parseFlowEntry finds them after parsing them.
+func (p *Parser) atFlowPair() bool {
+	if p.pos >= p.length || p.data[p.pos] == '{' || p.data[p.pos] == '[' {
+		return false
+	}
+	savedPos := p.pos
+	defer func() { p.pos = savedPos }()
+
+	c := p.data[p.pos]
+	if _, err := p.readFlowKey(); err != nil {
+		return false
+	}
+	p.skipSpaces()
+	return p.atPairColon(c == '"' || c == '\'')
+}
+
+// atPairColon reports whether the current position is the ':' of a flow
+// pair: one followed by white space, ',' or ']', or, with adjacent set for
+// a key that is quoted or a flow collection, any ':', as in ["a":1].
+func (p *Parser) atPairColon(adjacent bool) bool {
+	if p.pos >= p.length || p.data[p.pos] != ':' {
+		return false
+	}
+	if adjacent || p.pos+1 == p.length {
+		return true
+	}
+	next := p.data[p.pos+1]
+	return isWhitespace(next) || next == ',' || next == ']'
+}
+
+// atCollectionKey reports whether the flow collection at the current
+// position is the key of a flow pair, as in [[1, 2]: x]. It parses the
+// collection to find out, so only typed targets, whose nesting their Go
+// type bounds, look ahead with it.
+func (p *Parser) atCollectionKey() bool {
+	if p.pos >= p.length || p.data[p.pos] != '{' && p.data[p.pos] != '[' {
+		return false
+	}
+	savedPos := p.pos
+	defer func() { p.pos = savedPos }()
+
+	if _, err := p.parseFlowValue(); err != nil {
+		return false
+	}
+	p.skipSpaces()
+	return p.atPairColon(true)
+}
+
+// parseFlowEntry parses an entry of a flow sequence: a value, or a
+// single-pair mapping written as a key and ':' without braces, as in
+// [a: 1, b: 2].
+func (p *Parser) parseFlowEntry() (interface{}, error) {
+	if p.atFlowPair() {
+		return p.parseFlowMapping(true)
+	}
+	value, err := p.parseFlowValue()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := value.(string); ok || value == nil {
+		return value, nil
+	}
+	p.skipSpaces()
+	if !p.atPairColon(true) {
+		return value, nil
+	}
+
+	// The value was the flow collection key of a pair, as in [[1, 2]: x]
+	key := stringifyKey(value)
+	if err := p.checkKey(key); err != nil {
+		return nil, err
+	}
+	if err := p.enterCollection(); err != nil {
+		return nil, err
+	}
+	defer p.leaveCollection()
+	p.advance() // skip ':'
+	p.skipWhitespaceAndComments()
+	if value, err = p.parseFlowValue(); err != nil {
+		return nil, InKey(err, key)
+	}
+	result := p.newMappingBuilder()
+	result.set(key, value)
+	return result.result(), nil
+}
+
+// parseFlowKey parses a key in flow context. A key that is itself a flow
+// collection, as in {[1, 2]: pair}, is stringified like the AST parser's
+// complex keys.
 func (p *Parser) parseFlowKey() (string, error) {
+	key, err := p.readFlowKey()
+	if err != nil {
+		return "", err
+	}
+	return key, p.checkKey(key)
+}
+
+func (p *Parser) readFlowKey() (string, error) {
 	if p.pos >= p.length {
-		return "", errors.New("unexpected end of input")
+		return "", p.errUnexpectedEOF("unexpected end of input")
 	}
 
 	c := p.data[p.pos]
 
+	if c == '{' || c == '[' {
+		v, err := p.parseFlowValue()
+		if err != nil {
+			return "", err
+		}
+		return stringifyKey(v), nil
+	}
+	if p.atExplicitKey() {
+		// "? key" is "key" in a flow mapping, whose entries need no ':'
+		p.advance()
+		p.skipSpaces()
+		return p.readFlowKey()
+	}
 	if c == '"' {
 		return p.parseDoubleQuotedString()
 	}
@@ -413,6 +706,9 @@ func (p *Parser) parseFlowKey() (string, error) {
 	}
 
 	// Plain key in flow context
+	if err := p.checkPlain(); err != nil {
+		return "", err
+	}
 	start := p.pos
 	for p.pos < p.length {
 		c := p.data[p.pos]
@@ -427,6 +723,15 @@ func (p *Parser) parseFlowKey() (string, error) {
 
 // parseFlowScalar parses a plain scalar in flow context.
 func (p *Parser) parseFlowScalar() (interface{}, error) {
+	if err := p.checkPlain(); err != nil {
+		return nil, err
+	}
+	// A "-" alone is a block sequence indicator, which flow collections
+	// cannot hold; "-1" and "-x" are plain scalars
+	if p.pos < p.length && p.data[p.pos] == '-' && (p.pos+1 == p.length ||
+		isWhitespace(p.data[p.pos+1]) || strings.IndexByte(",]}", p.data[p.pos+1]) >= 0) {
+		return nil, p.errorf("block sequence entries are not allowed in a flow collection")
+	}
 	start := p.pos
 	for p.pos < p.length {
 		c := p.data[p.pos]
@@ -449,6 +754,14 @@ func (p *Parser) parseFlowScalar() (interface{}, error) {
 
 // parseKey parses a mapping key.
 func (p *Parser) parseKey() (string, error) {
+	key, err := p.readKey()
+	if err != nil {
+		return "", err
+	}
+	return key, p.checkKey(key)
+}
+
+func (p *Parser) readKey() (string, error) {
 	if p.pos >= p.length {
 		return "", nil
 	}
@@ -461,8 +774,14 @@ func (p *Parser) parseKey() (string, error) {
 	if c == '\'' {
 		return p.parseSingleQuotedString()
 	}
+	if p.atExplicitKey() {
+		return p.readExplicitKey()
+	}
 
 	// Plain key
+	if err := p.checkPlain(); err != nil {
+		return "", err
+	}
 	start := p.pos
 	for p.pos < p.length {
 		c := p.data[p.pos]
@@ -479,6 +798,97 @@ func (p *Parser) parseKey() (string, error) {
 	return string(key), nil
 }
 
+// atExplicitKey reports whether the current position is a "?" explicit
+// key indicator, followed by white space or the end of the input.
+func (p *Parser) atExplicitKey() bool {
+	return p.pos < p.length && p.data[p.pos] == '?' &&
+		(p.pos+1 == p.length || isWhitespace(p.data[p.pos+1]))
+}
+
+// readExplicitKey reads the key after a "?" indicator, which must be a
+// scalar on the indicator's line. If the next line holds the ':' of its
+// value at the indicator's column, as in "? key\n: value", the position is
+// left at that ':'. Otherwise the key has no value, which reads as null:
+// the position is left at the end of the key's line and parseColon consumes
+// nothing.
+func (p *Parser) readExplicitKey() (string, error) {
+	col := p.contentColumn()
+	p.advance() // skip '?'
+	p.skipSpaces()
+
+	key, err := p.readExplicitScalar()
+	if err != nil {
+		return "", err
+	}
+	p.skipSpaces()
+	if p.pos < p.length && p.data[p.pos] == '#' {
+		p.skipToNextLine()
+	} else if p.pos < p.length && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+		return "", p.errorf("unexpected content after explicit key %q", key)
+	}
+
+	// Look for the ':' of the value on the next line
+	pos, line, column := p.pos, p.line, p.column
+	p.skipWhitespaceAndComments()
+	if p.pos < p.length && p.data[p.pos] == ':' && p.currentIndent() == col && p.contentColumn() == col &&
+		(p.pos+1 == p.length || isWhitespace(p.data[p.pos+1])) {
+		return key, nil
+	}
+	if p.pos < p.length && p.currentIndent() > col {
+		return "", p.errComplexKey(p.pos)
+	}
+	p.pos, p.line, p.column = pos, line, column
+	p.noValue = true
+	return key, nil
+}
+
+// readExplicitScalar reads the scalar of an explicit key, which the fast
+// parser, building Go maps with string keys, only supports on one line.
+func (p *Parser) readExplicitScalar() (string, error) {
+	if p.pos >= p.length {
+		return "", p.errComplexKey(p.pos)
+	}
+	switch c := p.data[p.pos]; {
+	case c == '"' || c == '\'':
+		return p.readKey()
+	case strings.IndexByte("\n\r#[{|>-?&*!", c) >= 0:
+		return "", p.errComplexKey(p.pos)
+	}
+	start := p.pos
+	for p.pos < p.length && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' &&
+		(p.data[p.pos] != '#' || !isWhitespace(p.data[p.pos-1])) {
+		p.advance()
+	}
+	raw := trimBytes(p.data[start:p.pos])
+	if bytes.Contains(raw, []byte(": ")) || raw[len(raw)-1] == ':' {
+		return "", p.errComplexKey(start)
+	}
+	return string(raw), nil
+}
+
+// errComplexKey returns the error at off for an explicit key the fast parser
+// does not support: a collection, a block scalar, an empty key or a key
+// spanning lines.
+func (p *Parser) errComplexKey(off int) error {
+	return p.errorAt(off, errors.New("explicit keys other than single-line scalars are not supported"))
+}
+
+// parseColon consumes the ':' after the mapping key key. After an explicit
+// key without a value it consumes nothing, so that the value reads as
+// empty.
+func (p *Parser) parseColon(key string) error {
+	if p.noValue {
+		p.noValue = false
+		return nil
+	}
+	p.skipSpaces()
+	if p.pos >= p.length || p.data[p.pos] != ':' {
+		return p.errorf("expected ':' after key %q", key)
+	}
+	p.advance() // skip ':'
+	return nil
+}
+
 // parseScalar parses a scalar value.
 func (p *Parser) parseScalar() (interface{}, error) {
 	if p.pos >= p.length {
@@ -495,6 +905,9 @@ func (p *Parser) parseScalar() (interface{}, error) {
 	}
 
 	// Plain scalar
+	if err := p.checkPlain(); err != nil {
+		return nil, err
+	}
 	start := p.pos
 	for p.pos < p.length {
 		c := p.data[p.pos]
@@ -518,7 +931,7 @@ func (p *Parser) parseScalar() (interface{}, error) {
 // parseDoubleQuotedString parses a double-quoted string.
 func (p *Parser) parseDoubleQuotedString() (string, error) {
 	if p.pos >= p.length || p.data[p.pos] != '"' {
-		return "", errors.New("expected '\"'")
+		return "", p.errorf("expected '\"'")
 	}
 	p.advance() // skip opening '"'
 
@@ -544,22 +957,34 @@ func (p *Parser) parseDoubleQuotedString() (string, error) {
 			}
 			continue
 		}
+		if c == '\n' || c == '\r' {
+			hasEscape = true // multi-line strings are folded on the slow path
+		}
 		p.advance()
 	}
 
-	// Slow path: unescape
+	// Slow path: unescape and fold
 	if hasEscape {
 		p.pos = start
 		return p.parseDoubleQuotedStringWithEscapes()
 	}
 
-	return "", errors.New("unterminated string")
+	return "", p.errUnexpectedEOF("unterminated string")
 }
 
-// parseDoubleQuotedStringWithEscapes handles escape sequences.
+// parseDoubleQuotedStringWithEscapes handles escape sequences, as decoded by
+// package escape, and folds line breaks (YAML 1.2 section 7.3.1). The
+// unescaped bytes are collected in a pooled scratch buffer.
 func (p *Parser) parseDoubleQuotedStringWithEscapes() (string, error) {
-	var buf []byte
-
+	bp := bufpool.Scratch.Get()
+	buf := *bp
+	defer func() {
+		*bp = buf
+		bufpool.Scratch.Put(bp)
+	}()
+
+	// kept is the length of buf that folding must not trim
+	kept := 0
 	for p.pos < p.length {
 		c := p.data[p.pos]
 
@@ -568,74 +993,89 @@ func (p *Parser) parseDoubleQuotedStringWithEscapes() (string, error) {
 			return string(buf), nil
 		}
 
+		if c == '\n' || c == '\r' {
+			// Trim trailing white space, then fold the break
+			j := len(buf)
+			for j > kept && (buf[j-1] == ' ' || buf[j-1] == '\t') {
+				j--
+			}
+			buf = buf[:j]
+			empty := p.foldLineBreaks()
+			if empty == 0 {
+				buf = append(buf, ' ')
+			}
+			for ; empty > 0; empty-- {
+				buf = append(buf, '\n')
+			}
+			kept = len(buf)
+			continue
+		}
+
 		if c == '\\' {
 			p.advance()
 			if p.pos >= p.length {
-				return "", errors.New("unexpected end of input after backslash")
+				return "", p.errUnexpectedEOF("unexpected end of input after backslash")
 			}
 
 			escaped := p.data[p.pos]
-			p.advance()
-
-			switch escaped {
-			case '"', '\\', '/':
-				buf = append(buf, escaped)
-			case 'b':
-				buf = append(buf, '\b')
-			case 'f':
-				buf = append(buf, '\f')
-			case 'n':
-				buf = append(buf, '\n')
-			case 'r':
-				buf = append(buf, '\r')
-			case 't':
-				buf = append(buf, '\t')
-			case '0':
-				buf = append(buf, 0)
-			case 'x':
-				// \xHH
-				if p.pos+2 > p.length {
-					return "", errors.New("incomplete hex escape")
-				}
-				hex := string(p.data[p.pos : p.pos+2])
-				p.pos += 2
-				val, err := strconv.ParseUint(hex, 16, 8)
-				if err != nil {
-					return "", fmt.Errorf("invalid hex escape: %v", err)
-				}
-				buf = append(buf, byte(val))
-			case 'u':
-				// \uHHHH
-				if p.pos+4 > p.length {
-					return "", errors.New("incomplete unicode escape")
-				}
-				hex := string(p.data[p.pos : p.pos+4])
-				p.pos += 4
-				val, err := strconv.ParseUint(hex, 16, 16)
-				if err != nil {
-					return "", fmt.Errorf("invalid unicode escape: %v", err)
+			if escaped == '\n' || escaped == '\r' {
+				// Escaped line break: join the lines, keeping empty lines
+				for empty := p.foldLineBreaks(); empty > 0; empty-- {
+					buf = append(buf, '\n')
 				}
-				buf = appendRune(buf, rune(val))
-			default:
-				buf = append(buf, escaped)
+				kept = len(buf)
+				continue
+			}
+			r, n, err := escape.Decode(p.data[p.pos:])
+			if err != nil {
+				return "", p.errorf("%v", err)
 			}
+			for ; n > 0; n-- {
+				p.advance()
+			}
+			buf = appendRune(buf, r)
+			kept = len(buf)
 		} else {
 			buf = append(buf, c)
 			p.advance()
 		}
 	}
 
-	return "", errors.New("unterminated string")
+	return "", p.errUnexpectedEOF("unterminated string")
 }
 
-// parseSingleQuotedString parses a single-quoted string.
+// foldLineBreaks consumes the line break at the current position together
+// with any empty lines and indentation that follow it, and returns the number
+// of empty lines.
+func (p *Parser) foldLineBreaks() int {
+	empty := -1
+	for p.pos < p.length && (p.data[p.pos] == '\n' || p.data[p.pos] == '\r') {
+		if p.data[p.pos] == '\r' && p.pos+1 < p.length && p.data[p.pos+1] == '\n' {
+			p.pos++
+		}
+		p.advance()
+		empty++
+		for p.pos < p.length && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+			p.advance()
+		}
+	}
+	return empty
+}
+
+// parseSingleQuotedString parses a single-quoted string, folding line breaks
+// as for double-quoted strings.
 func (p *Parser) parseSingleQuotedString() (string, error) {
 	if p.pos >= p.length || p.data[p.pos] != '\'' {
-		return "", errors.New("expected '")
+		return "", p.errorf("expected '")
 	}
 	p.advance() // skip opening '
 
-	var buf []byte
+	bp := bufpool.Scratch.Get()
+	buf := *bp
+	defer func() {
+		*bp = buf
+		bufpool.Scratch.Put(bp)
+	}()
 
 	for p.pos < p.length {
 		c := p.data[p.pos]
@@ -651,75 +1091,37 @@ func (p *Parser) parseSingleQuotedString() (string, error) {
 			return string(buf), nil
 		}
 
+		if c == '\n' || c == '\r' {
+			for len(buf) > 0 && (buf[len(buf)-1] == ' ' || buf[len(buf)-1] == '\t') {
+				buf = buf[:len(buf)-1]
+			}
+			empty := p.foldLineBreaks()
+			if empty == 0 {
+				buf = append(buf, ' ')
+			}
+			for ; empty > 0; empty-- {
+				buf = append(buf, '\n')
+			}
+			continue
+		}
+
 		buf = append(buf, c)
 		p.advance()
 	}
 
-	return "", errors.New("unterminated string")
+	return "", p.errUnexpectedEOF("unterminated string")
 }
 
 // interpretScalar converts a byte slice to the appropriate Go type.
+// Resolution follows the canonical mapping shared with the AST parser (see internal/resolve).
 func (p *Parser) interpretScalar(b []byte) interface{} {
 	if len(b) == 0 {
 		return nil
 	}
-
-	s := string(b)
-
-	// Null
-	if s == "null" || s == "~" || s == "Null" || s == "NULL" {
-		return nil
-	}
-
-	// Boolean
-	if s == "true" || s == "True" || s == "TRUE" || s == "yes" || s == "Yes" || s == "YES" || s == "on" || s == "On" || s == "ON" {
-		return true
-	}
-	if s == "false" || s == "False" || s == "FALSE" || s == "no" || s == "No" || s == "NO" || s == "off" || s == "Off" || s == "OFF" {
-		return false
-	}
-
-	// Try integer - first try signed int64
-	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
-		return i
-	}
-	// If ParseInt failed, try unsigned uint64 for large positive numbers
-	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
-		return u
-	}
-
-	// Try hex integer
-	if len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
-		if i, err := strconv.ParseInt(s, 0, 64); err == nil {
-			return i
-		}
-	}
-
-	// Try octal integer
-	if len(s) > 2 && s[0] == '0' && (s[1] == 'o' || s[1] == 'O') {
-		if i, err := strconv.ParseInt(s[2:], 8, 64); err == nil {
-			return i
-		}
+	if p.yaml11 {
+		return resolve.PlainBytes11(b)
 	}
-
-	// Try float
-	if f, err := strconv.ParseFloat(s, 64); err == nil {
-		return f
-	}
-
-	// Special floats
-	if s == ".inf" || s == ".Inf" || s == ".INF" {
-		return posInf
-	}
-	if s == "-.inf" || s == "-.Inf" || s == "-.INF" {
-		return negInf
-	}
-	if s == ".nan" || s == ".NaN" || s == ".NAN" {
-		return nan
-	}
-
-	// String
-	return s
+	return resolve.PlainBytes(b)
 }
 
 // Helper methods
@@ -859,9 +1261,36 @@ func appendRune(b []byte, r rune) []byte {
 	return append(b, byte(0xF0|(r>>18)), byte(0x80|((r>>12)&0x3F)), byte(0x80|((r>>6)&0x3F)), byte(0x80|(r&0x3F)))
 }
 
-// Special float values
-var (
-	posInf = math.Inf(1)
-	negInf = math.Inf(-1)
-	nan    = math.NaN()
-)
+// stringifyKey converts a collection used as a mapping key to the canonical
+// encoding of internal/canonkey, the same string the AST parser stores the key
+// under.
+func stringifyKey(v interface{}) string {
+	return canonkey.Encode(keyValue(v))
+}
+
+// keyValue converts the MapSlices in a collection key to the maps
+// canonkey.Encode takes.
+func keyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		seq := make([]interface{}, len(v))
+		for i, elem := range v {
+			seq[i] = keyValue(elem)
+		}
+		return seq
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = keyValue(val)
+		}
+		return m
+	case MapSlice:
+		m := make(map[string]interface{}, len(v))
+		for _, item := range v {
+			m[fmt.Sprint(item.Key)] = keyValue(item.Value)
+		}
+		return m
+	default:
+		return v
+	}
+}
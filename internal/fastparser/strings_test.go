@@ -53,6 +53,36 @@ func TestParser_DoubleQuotedString(t *testing.T) {
 			input:    `"\u2764"`,
 			expected: "❤",
 		},
+		{
+			name:     "double quoted with surrogate pair",
+			input:    `"\uD83D\uDE00"`,
+			expected: "\U0001F600",
+		},
+		{
+			name:     "double quoted with lowercase surrogate pair",
+			input:    `"a\ud83d\ude00b"`,
+			expected: "a\U0001F600b",
+		},
+		{
+			name:    "double quoted with lone high surrogate",
+			input:   `"\uD83D"`,
+			wantErr: true,
+		},
+		{
+			name:    "double quoted with high surrogate before text",
+			input:   `"\uD83Dx"`,
+			wantErr: true,
+		},
+		{
+			name:    "double quoted with two high surrogates",
+			input:   `"\uD83D\uD83D"`,
+			wantErr: true,
+		},
+		{
+			name:    "double quoted with lone low surrogate",
+			input:   `"\uDE00"`,
+			wantErr: true,
+		},
 		{
 			name:     "double quoted with hex escape",
 			input:    `"hello\x41world"`,
@@ -73,6 +103,36 @@ func TestParser_DoubleQuotedString(t *testing.T) {
 			input:    `"  spaces  "`,
 			expected: "  spaces  ",
 		},
+		{
+			name:     "multi-line folds to space",
+			input:    "\"a \n  b\"",
+			expected: "a b",
+		},
+		{
+			name:     "multi-line empty line becomes newline",
+			input:    "\"a\n\n  b\"",
+			expected: "a\nb",
+		},
+		{
+			name:     "multi-line escaped line break",
+			input:    "\"a\\\n  b\"",
+			expected: "ab",
+		},
+		{
+			name:     "multi-line escaped space kept",
+			input:    "\"a\\ \n  b\"",
+			expected: "a  b",
+		},
+		{
+			name:     "multi-line CRLF",
+			input:    "\"a\r\n  b\"",
+			expected: "a b",
+		},
+		{
+			name:    "multi-line unterminated",
+			input:   "\"a\n  b",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -131,9 +191,29 @@ func TestParser_SingleQuotedString(t *testing.T) {
 			expected: `hello\nworld`,
 		},
 		{
-			name:     "single quoted with newline (literal)",
+			name:     "single quoted with newline folds to space",
 			input:    "'hello\nworld'",
-			expected: "hello\nworld",
+			expected: "hello world",
+		},
+		{
+			name:     "single quoted multi-line with indentation",
+			input:    "'hello  \n    world'",
+			expected: "hello world",
+		},
+		{
+			name:     "single quoted multi-line empty line becomes newline",
+			input:    "'a\n\n  b ''c'''",
+			expected: "a\nb 'c'",
+		},
+		{
+			name:     "single quoted multi-line CRLF",
+			input:    "'a\r\n\r\n  b'",
+			expected: "a\nb",
+		},
+		{
+			name:    "single quoted multi-line unterminated",
+			input:   "'a\n  b",
+			wantErr: true,
 		},
 	}
 
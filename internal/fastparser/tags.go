@@ -0,0 +1,159 @@
+package fastparser
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// tagDecoder returns the NodeDecoder for values written with a tag, or nil
+// if tags are read as part of plain scalars, as they are without
+// Options.Tagged and in safe mode, which rejects them.
+func (p *Parser) tagDecoder() NodeDecoder {
+	if p.tagged == nil && p.opts.Tagged != nil && !p.opts.SafeMode {
+		if p.opts.Nodes != nil {
+			p.tagged = p.opts.Nodes
+		} else {
+			p.tagged = p.opts.Tagged(max(p.opts.Line, 1), max(p.opts.Column, 1))
+		}
+	}
+	return p.tagged
+}
+
+// parseTagged parses the tagged value at the current position, in flow
+// context if flow is set.
+func (p *Parser) parseTagged(flow bool) (interface{}, error) {
+	var v interface{}
+	err := p.unmarshalTagged(reflect.ValueOf(&v).Elem(), flow)
+	return v, err
+}
+
+// unmarshalTagged has the tag decoder decode the tagged value at the current
+// position into rv. The value follows the tag on its line, or, in block
+// context, for a tag that ends its line, is the block collection on the
+// lines below it; with neither, it is null.
+func (p *Parser) unmarshalTagged(rv reflect.Value, flow bool) error {
+	tag := p.pos
+	p.skipTag()
+	name := string(p.data[tag:p.pos])
+	p.skipSpaces()
+
+	start := p.pos
+	switch {
+	case flow || p.pos < p.length && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' && p.data[p.pos] != '#':
+		var err error
+		if flow {
+			_, err = p.parseFlowValue()
+		} else {
+			_, err = p.parseValue(p.tagScope(tag))
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		scope := p.tagScope(tag)
+		p.skipToNextLine()
+		for start = -1; ; p.skipToNextLine() {
+			p.skipWhitespaceAndComments()
+			if p.pos >= p.length || p.atDocumentMarker() || p.currentIndent() <= scope {
+				break
+			}
+			if start < 0 {
+				start = p.pos
+			}
+		}
+		if start < 0 {
+			return p.setEmptyTagged(rv, name, tag)
+		}
+	}
+
+	line, column := p.nodePosition(start)
+	if err := p.tagged.DecodeNode(rv, p.data, line, column); err != nil {
+		return p.errorAt(tag, err)
+	}
+	return nil
+}
+
+// setEmptyTagged sets rv to the value of tag name without a value at offset
+// tag: the empty string for !!str, and null for other tags.
+func (p *Parser) setEmptyTagged(rv reflect.Value, name string, tag int) error {
+	if name != "!!str" && name != "!<tag:yaml.org,2002:str>" {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
+		rv.Set(reflect.ValueOf(""))
+		return nil
+	}
+	return p.setString(rv, "", tag)
+}
+
+// atTaggedValue reports whether the current position starts a tagged value
+// for the tag decoder, rather than a block mapping whose first key is
+// tagged, which is read as before with the tag as part of the key.
+func (p *Parser) atTaggedValue() bool {
+	if p.pos >= p.length || p.data[p.pos] != '!' || p.tagDecoder() == nil {
+		return false
+	}
+	i := p.tagEnd(p.pos)
+	for i < p.length && (p.data[i] == ' ' || p.data[i] == '\t') {
+		i++
+	}
+	if i < p.length && (p.data[i] == '[' || p.data[i] == '{') {
+		return true
+	}
+	return !p.looksLikeMapping()
+}
+
+// skipTag advances past the tag at the current position.
+func (p *Parser) skipTag() {
+	for end := p.tagEnd(p.pos); p.pos < end; {
+		p.advance()
+	}
+}
+
+// tagEnd returns the offset after the tag at off: a verbatim tag, as in
+// !<tag:yaml.org,2002:str>, or a shorthand one, as in !!str or !e!x, which
+// ends at white space or a flow indicator.
+func (p *Parser) tagEnd(off int) int {
+	if off+1 < p.length && p.data[off+1] == '<' {
+		if i := bytes.IndexByte(p.data[off:], '>'); i >= 0 {
+			return off + i + 1
+		}
+	}
+	for off < p.length && !isWhitespace(p.data[off]) && bytes.IndexByte([]byte(",[]{}"), p.data[off]) < 0 {
+		off++
+	}
+	return off
+}
+
+// tagScope returns the indentation that the lines of a block collection
+// tagged by the tag at off must exceed: that of the key the tag follows on
+// its line, or of the "-" of the sequence item it starts, or one less than
+// its own column if it starts the line. A tag after "---" tags the root, all
+// of whose lines are within the document.
+func (p *Parser) tagScope(off int) int {
+	start := off
+	for start > 0 && p.data[start-1] != '\n' && p.data[start-1] != '\r' {
+		start--
+	}
+	if isMarkerLine(p.data[start:off], "---") {
+		return -1
+	}
+
+	scope, i := off-start-1, start
+	for {
+		for i < off && p.data[i] == ' ' {
+			i++
+		}
+		if i+1 < off && p.data[i] == '-' && isWhitespace(p.data[i+1]) {
+			scope = i - start
+			i++
+			continue
+		}
+		break
+	}
+	if i < off {
+		scope = i - start // a key
+	}
+	return scope
+}
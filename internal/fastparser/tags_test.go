@@ -0,0 +1,88 @@
+package fastparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// tagStub decodes every tagged value as the position of the value after its
+// tag, or of the first line below a tag that ends its line.
+type tagStub struct {
+	calls int
+}
+
+func (s *tagStub) Claims(t reflect.Type) bool { return false }
+
+func (s *tagStub) DecodeNode(rv reflect.Value, data []byte, line, column int) error {
+	s.calls++
+	rv.Set(reflect.ValueOf([2]int{line, column}).Convert(rv.Type()))
+	return nil
+}
+
+// TestUnmarshal_Tagged tests that tagged values are decoded by Options.Tagged
+func TestUnmarshal_Tagged(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "inline scalar",
+			input:    "a: 1\nb: !!str 2\n",
+			expected: map[string]interface{}{"a": int64(1), "b": [2]int{2, 10}},
+		},
+		{
+			name:     "flow collection",
+			input:    "s: !!set {x, y}\n",
+			expected: map[string]interface{}{"s": [2]int{1, 10}},
+		},
+		{
+			name:     "block collection below the tag",
+			input:    "o: !!omap\n  - a: 1\n  - b: 2\nn: 3\n",
+			expected: map[string]interface{}{"o": [2]int{2, 3}, "n": int64(3)},
+		},
+		{
+			name:     "value in a flow collection",
+			input:    "l: [1, !!binary aGk=]\n",
+			expected: map[string]interface{}{"l": []interface{}{int64(1), [2]int{1, 17}}},
+		},
+		{
+			name:     "empty str",
+			input:    "e: !!str\nn: 1\n",
+			expected: map[string]interface{}{"e": "", "n": int64(1)},
+		},
+		{
+			name:     "empty value under another tag",
+			input:    "e: !!set\nn: 1\n",
+			expected: map[string]interface{}{"e": nil, "n": int64(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &tagStub{}
+			opts := Options{Tagged: func(line, column int) NodeDecoder { return stub }}
+			var got map[string]interface{}
+			if err := UnmarshalWithOptions([]byte(tt.input), &got, opts); err != nil {
+				t.Fatalf("UnmarshalWithOptions() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("UnmarshalWithOptions() = %#v, want %#v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestUnmarshal_TaggedSafeMode tests that safe mode rejects tags before
+// Options.Tagged sees them
+func TestUnmarshal_TaggedSafeMode(t *testing.T) {
+	stub := &tagStub{}
+	opts := Options{SafeMode: true, Tagged: func(line, column int) NodeDecoder { return stub }}
+	var got map[string]interface{}
+	if err := UnmarshalWithOptions([]byte("b: !!binary aGk=\n"), &got, opts); err == nil {
+		t.Error("UnmarshalWithOptions() error = nil, want error")
+	}
+	if stub.calls != 0 {
+		t.Errorf("DecodeNode called %d times in safe mode", stub.calls)
+	}
+}
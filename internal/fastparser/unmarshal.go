@@ -1,11 +1,16 @@
 package fastparser
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/shapestone/shape-yaml/internal/resolve"
+	"github.com/shapestone/shape-yaml/internal/utf8input"
 )
 
 // Unmarshaler is the interface implemented by types that can unmarshal a YAML description of themselves.
@@ -13,9 +18,108 @@ type Unmarshaler interface {
 	UnmarshalYAML([]byte) error
 }
 
+// Options configures optional decoding behavior.
+type Options struct {
+	// OnIgnoredField, if set, is called when a mapping key matches only a
+	// struct field that cannot be set, such as an unexported field.
+	// A non-nil return value aborts decoding with that error.
+	OnIgnoredField func(IgnoredField) error
+
+	// OnUnknownField, if set, is called when a mapping key matches no struct
+	// field at all. A non-nil return value aborts decoding with that error.
+	OnUnknownField func(UnknownField) error
+
+	// TagName is the struct tag key that names fields. Defaults to "yaml".
+	TagName string
+
+	// FallbackTagName, if set, is the struct tag key that names fields
+	// without a TagName tag, such as "json" for models written for
+	// encoding/json.
+	FallbackTagName string
+
+	// Nodes, if set, decodes the values of the types it claims in place of
+	// the parser.
+	Nodes NodeDecoder
+
+	// Tagged, if set, returns the NodeDecoder that decodes values written
+	// with a tag, such as !!str 123 or !!set {a, b}, which the parser does
+	// not resolve itself; its DecodeNode takes values of any type. It is
+	// called at the first such value, with Line and Column as 1-based
+	// values, unless Nodes is set, which decodes them instead. Without it,
+	// a tag is read as part of a plain scalar.
+	Tagged func(line, column int) NodeDecoder
+
+	// MaxDepth bounds the nesting depth of mappings and sequences, which
+	// also bounds recursion into self-referential struct types. A top-level
+	// scalar has depth 1 and each enclosing collection adds one level. Zero
+	// means limits.DefaultMaxDepth; a negative value disables the limit.
+	MaxDepth int
+
+	// MaxKeyLength, if positive, is the maximum length in bytes of a
+	// mapping key.
+	MaxKeyLength int
+
+	// SafeMode rejects anchors, aliases, tags, and directives with an error
+	// wrapping limits.ErrUnsafe. Tags are rejected before Tagged sees them,
+	// including the core schema tags the AST parser allows in safe mode.
+	SafeMode bool
+
+	// ReplaceInvalidUTF8 replaces each malformed UTF-8 byte in the input
+	// with U+FFFD instead of failing with an error wrapping
+	// utf8input.ErrInvalid.
+	ReplaceInvalidUTF8 bool
+
+	// DisableImplicitTimestamps decodes time.Time values with their own
+	// UnmarshalText, which accepts RFC 3339 only, instead of reading any
+	// YAML timestamp, such as 2001-12-14, as resolve.Timestamp does.
+	DisableImplicitTimestamps bool
+
+	// PromoteScalars decodes a scalar into a slice as a slice of that one
+	// value, so that a map[string][]string reads both "a: x" and
+	// "a: [x, y]". A null still leaves a nil slice.
+	PromoteScalars bool
+
+	// Offset, Line and Column locate data within a larger stream, such as a
+	// later document of a multi-document stream or YAML embedded in another
+	// file, so that reported positions refer to the stream: data[0] is at
+	// byte Offset on 1-based Line and Column. Column applies to the first
+	// line of data only, where the Excerpt of an error has the columns
+	// before it blanked; later lines start at column 1. Zero values mean
+	// data starts the stream.
+	Offset int
+	Line   int
+	Column int
+}
+
+// IgnoredField describes a mapping key whose value was discarded because the
+// matching struct field cannot be set.
+type IgnoredField struct {
+	Key    string       // key as written in the document
+	Struct reflect.Type // struct type being decoded
+	Field  string       // dotted Go field path, e.g. "Base.secret"
+	Reason string       // why the field cannot be set
+	Line   int
+	Column int
+}
+
+// UnknownField describes a mapping key that matches no field of the struct
+// being decoded.
+type UnknownField struct {
+	Key        string       // key as written in the document
+	Struct     reflect.Type // struct type being decoded
+	Suggestion string       // closest field name, or "" if none is close
+	Line       int
+	Column     int
+}
+
 // Unmarshal parses YAML and unmarshals it into the value pointed to by v.
 // This is the fast path that bypasses AST construction.
 func Unmarshal(data []byte, v interface{}) error {
+	return UnmarshalWithOptions(data, v, Options{})
+}
+
+// UnmarshalWithOptions is like Unmarshal but applies opts.
+func UnmarshalWithOptions(data []byte, v interface{}, opts Options) error {
 	rv := reflect.ValueOf(v)
 	if !rv.IsValid() || v == nil {
 		return errors.New("yaml: Unmarshal(nil)")
@@ -29,14 +133,39 @@ func Unmarshal(data []byte, v interface{}) error {
 		return errors.New("yaml: Unmarshal(nil " + rv.Type().String() + ")")
 	}
 
+	if opts.ReplaceInvalidUTF8 {
+		data = utf8input.Repair(data)
+	} else if err := utf8input.Check(data); err != nil {
+		return err
+	}
+
 	// Check if type implements Unmarshaler interface
 	if rv.Type().Implements(reflect.TypeOf((*Unmarshaler)(nil)).Elem()) {
 		unmarshaler := rv.Interface().(Unmarshaler)
 		return unmarshaler.UnmarshalYAML(data)
 	}
 
+	// Offsets count the byte order mark NewParser drops
+	if trimmed := utf8input.TrimBOM(data); len(trimmed) < len(data) {
+		opts.Offset += len(data) - len(trimmed)
+		data = trimmed
+	}
+
 	p := NewParser(data)
-	return p.unmarshalValue(rv.Elem())
+	p.opts = opts
+	if opts.Line > 0 {
+		p.line = opts.Line
+	}
+	if err := p.parseDirectives(); err != nil {
+		return err
+	}
+	if err := p.unmarshalValue(rv.Elem()); err != nil {
+		return err
+	}
+	if err := p.checkDocumentEnd(); err != nil {
+		return err
+	}
+	return JoinTypeErrors(p.mismatches)
 }
 
 // unmarshalValue unmarshals YAML into a reflect.Value.
@@ -48,7 +177,7 @@ func (p *Parser) unmarshalValue(rv reflect.Value) error {
 // If baseIndent is -1, the indent is auto-detected from the current position.
 func (p *Parser) unmarshalValueAtIndent(rv reflect.Value, baseIndent int) error {
 	p.skipWhitespaceAndComments()
-	if p.pos >= p.length {
+	if p.pos >= p.length || p.atDocumentMarker() {
 		// Empty input - set to zero value
 		rv.Set(reflect.Zero(rv.Type()))
 		return nil
@@ -59,8 +188,18 @@ func (p *Parser) unmarshalValueAtIndent(rv reflect.Value, baseIndent int) error
 		baseIndent = p.currentIndent()
 	}
 
+	if p.claims(rv.Type()) {
+		return p.unmarshalNode(rv, func() (interface{}, error) {
+			return p.parseValue(baseIndent)
+		})
+	}
+
 	c := p.data[p.pos]
 
+	if p.atTaggedValue() {
+		return p.unmarshalTagged(rv, false)
+	}
+
 	// Handle interface{} specially - parse to native Go types
 	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
 		value, err := p.parseValue(baseIndent)
@@ -73,18 +212,27 @@ func (p *Parser) unmarshalValueAtIndent(rv reflect.Value, baseIndent int) error
 		return nil
 	}
 
-	// Handle pointers
+	// Handle pointers; null leaves them nil
 	if rv.Kind() == reflect.Ptr {
+		if n := p.nullScalarLen(); n > 0 {
+			p.skipNullScalar(n)
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
 		if rv.IsNil() {
 			rv.Set(reflect.New(rv.Type().Elem()))
 		}
 		return p.unmarshalValueAtIndent(rv.Elem(), baseIndent)
 	}
 
+	if rv.Type() == mapSliceType {
+		return p.unmarshalMapSlice(rv, baseIndent)
+	}
+
 	// Route based on YAML type
 	switch c {
 	case '{':
-		return p.unmarshalFlowMapping(rv)
+		return p.unmarshalFlowMapping(rv, false)
 	case '[':
 		return p.unmarshalFlowSequence(rv)
 	case '"', '\'':
@@ -92,14 +240,24 @@ func (p *Parser) unmarshalValueAtIndent(rv reflect.Value, baseIndent int) error
 			return p.unmarshalBlockMapping(rv, baseIndent)
 		}
 		return p.unmarshalQuotedString(rv)
+	case '|', '>':
+		return p.unmarshalBlockScalar(rv)
 	case '-':
 		if p.isSequenceIndicator() {
+			if err := p.checkSequenceStart(); err != nil {
+				return err
+			}
 			return p.unmarshalBlockSequence(rv, baseIndent)
 		}
+		// A literal "-" key (e.g. "-: value") starts a mapping
+		if p.looksLikeMapping() {
+			return p.unmarshalBlockMapping(rv, baseIndent)
+		}
 		// Negative number or plain string
 		return p.unmarshalScalar(rv)
 	case '~':
 		// Explicit null
+		start := p.pos
 		val, err := p.parseScalar()
 		if err != nil {
 			return err
@@ -108,7 +266,10 @@ func (p *Parser) unmarshalValueAtIndent(rv reflect.Value, baseIndent int) error
 			rv.Set(reflect.Zero(rv.Type()))
 			return nil
 		}
-		return p.setScalarValue(rv, val)
+		if err := p.setScalarValue(rv, val, p.data[start:p.pos]); err != nil {
+			return p.errorAt(start, err)
+		}
+		return nil
 	default:
 		// Check if it looks like a mapping (key: value)
 		// This must come BEFORE scalar parsing to handle keys that start with 'n' (like "name:")
@@ -120,6 +281,59 @@ func (p *Parser) unmarshalValueAtIndent(rv reflect.Value, baseIndent int) error
 	}
 }
 
+// decodeItem decodes the value of a mapping entry or sequence item with
+// decode. A type mismatch anywhere in the value is recorded, and the value
+// parsed again from its start with skip, rather than returned, so that
+// decoding goes on to report every mismatch in the document; if skip fails,
+// its syntax error is returned instead. wrap adds the key or index of the
+// value to the error returned and to each mismatch recorded within the value.
+func (p *Parser) decodeItem(decode, skip func() error, wrap func(error) error) error {
+	n := len(p.mismatches)
+	pos, line, column := p.pos, p.line, p.column
+	err := decode()
+	if err != nil && errors.As(err, new(*TypeError)) {
+		p.pos, p.line, p.column = pos, line, column
+		if serr := skip(); serr != nil {
+			err = serr // the value is malformed as well
+		} else {
+			p.mismatches = append(p.mismatches, err)
+			err = nil
+		}
+	}
+	for i := n; i < len(p.mismatches); i++ {
+		p.mismatches[i] = wrap(p.mismatches[i])
+	}
+	if err != nil {
+		return wrap(err)
+	}
+	return nil
+}
+
+// skipBlock returns a skip function for decodeItem that parses a block
+// value at indent.
+func (p *Parser) skipBlock(indent int) func() error {
+	return func() error {
+		_, err := p.parseValue(indent)
+		return err
+	}
+}
+
+// skipFlow parses a flow value, as the skip function of decodeItem.
+func (p *Parser) skipFlow() error {
+	_, err := p.parseFlowValue()
+	return err
+}
+
+// skipFlowItem parses a flow sequence entry, as the skip function of
+// decodeItem.
+func (p *Parser) skipFlowItem() error {
+	if p.atFlowPair() {
+		_, err := p.parseFlowMapping(true)
+		return err
+	}
+	return p.skipFlow()
+}
+
 // isQuotedKeyMapping checks if the current position starts a quoted mapping key
 // (e.g., "/users": ...). It scans past the quoted string and checks for ':' after it.
 func (p *Parser) isQuotedKeyMapping() bool {
@@ -176,19 +390,26 @@ func (p *Parser) unmarshalBlockMapping(rv reflect.Value, baseIndent int) error {
 			rv.Set(reflect.ValueOf(m))
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal mapping into Go value of type %s", rv.Type())
+		return p.errorAt(p.pos, NewTypeError("mapping", rv.Type()))
 	default:
-		return fmt.Errorf("yaml: cannot unmarshal mapping into Go value of type %s", rv.Type())
+		return p.errorAt(p.pos, NewTypeError("mapping", rv.Type()))
 	}
 }
 
 // unmarshalStruct unmarshals a YAML block mapping into a struct.
 func (p *Parser) unmarshalStruct(rv reflect.Value, baseIndent int) error {
+	if err := p.enterCollection(); err != nil {
+		return err
+	}
+	defer p.leaveCollection()
+
 	structType := rv.Type()
 
 	// Get cached field info
-	fields := getFieldCache(structType)
+	fields := getFieldCache(structType, p.tagName(), p.opts.FallbackTagName)
 	first := true
+	start := p.pos
+	var seen []bool
 
 	for p.pos < p.length {
 		// Skip empty lines and comments
@@ -201,6 +422,7 @@ func (p *Parser) unmarshalStruct(rv reflect.Value, baseIndent int) error {
 		lineIndent := p.currentIndent()
 		if first {
 			first = false
+			start = p.pos
 			if lineIndent >= baseIndent {
 				baseIndent = lineIndent
 			}
@@ -211,6 +433,11 @@ func (p *Parser) unmarshalStruct(rv reflect.Value, baseIndent int) error {
 			}
 		}
 
+		// A "---" or "..." marker ends the document
+		if p.atDocumentMarker() {
+			break
+		}
+
 		// Parse key
 		key, err := p.parseKey()
 		if err != nil {
@@ -220,18 +447,18 @@ func (p *Parser) unmarshalStruct(rv reflect.Value, baseIndent int) error {
 			break
 		}
 
-		// Expect colon
-		p.skipSpaces()
-		if p.pos >= p.length || p.data[p.pos] != ':' {
-			return fmt.Errorf("expected ':' after key %q at line %d", key, p.line)
+		if err := p.parseColon(key); err != nil {
+			return err
 		}
-		p.advance() // skip ':'
 
 		// Find matching struct field
-		fieldInfo, ok := fields.byName[key]
+		fieldInfo, ok := fields.lookup(key)
 		if !ok {
-			// Try lowercase match
-			fieldInfo, ok = fields.byName[strings.ToLower(key)]
+			if err := p.reportUnmatched(fields, structType, key); err != nil {
+				return err
+			}
+		} else {
+			seen = fields.markSeen(seen, fieldInfo)
 		}
 
 		p.skipSpaces()
@@ -239,14 +466,19 @@ func (p *Parser) unmarshalStruct(rv reflect.Value, baseIndent int) error {
 		if p.pos < p.length && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' && p.data[p.pos] != '#' {
 			// Inline value
 			if ok {
-				fieldVal := rv.Field(fieldInfo.index)
-				if err := p.unmarshalValueAtIndent(fieldVal, baseIndent); err != nil {
-					return fmt.Errorf("in field %q: %w", key, err)
+				fieldVal := fieldByIndex(rv, fieldInfo.index)
+				err := p.decodeItem(func() error {
+					return p.unmarshalValueAtIndent(fieldVal, baseIndent)
+				}, p.skipBlock(baseIndent), func(err error) error {
+					return withContext(InKey(err, key), "in field %q", key)
+				})
+				if err != nil {
+					return err
 				}
 			} else {
 				// Skip unknown field
 				if _, err := p.parseValue(baseIndent); err != nil {
-					return err
+					return InKey(err, key)
 				}
 			}
 		} else {
@@ -254,35 +486,100 @@ func (p *Parser) unmarshalStruct(rv reflect.Value, baseIndent int) error {
 			p.skipToNextLine()
 			p.skipWhitespaceAndComments()
 
-			if p.pos < p.length {
+			if p.pos < p.length && p.atNestedValue(baseIndent) {
 				nextIndent := p.currentIndent()
-				if nextIndent > baseIndent {
-					if ok {
-						fieldVal := rv.Field(fieldInfo.index)
-						if err := p.unmarshalValueAtIndent(fieldVal, nextIndent); err != nil {
-							return fmt.Errorf("in field %q: %w", key, err)
-						}
-					} else {
-						// Skip unknown field
-						if _, err := p.parseValue(nextIndent); err != nil {
-							return err
-						}
+				if ok {
+					fieldVal := fieldByIndex(rv, fieldInfo.index)
+					err := p.decodeItem(func() error {
+						return p.unmarshalValueAtIndent(fieldVal, nextIndent)
+					}, p.skipBlock(nextIndent), func(err error) error {
+						return withContext(InKey(err, key), "in field %q", key)
+					})
+					if err != nil {
+						return err
+					}
+				} else {
+					// Skip unknown field
+					if _, err := p.parseValue(nextIndent); err != nil {
+						return InKey(err, key)
 					}
 				}
+			} else if ok {
+				// Empty value ("key:" alone) is null: reset the field to its
+				// zero value, as for an explicit null
+				fieldVal := fieldByIndex(rv, fieldInfo.index)
+				fieldVal.Set(reflect.Zero(fieldVal.Type()))
 			}
 		}
 	}
 
+	if len(fields.required) > 0 {
+		if err := fields.checkRequired(structType, seen); err != nil {
+			return p.errorAt(start, err)
+		}
+	}
 	return nil
 }
 
+// tagName returns the struct tag key used to name fields.
+func (p *Parser) tagName() string {
+	if p.opts.TagName == "" {
+		return "yaml"
+	}
+	return p.opts.TagName
+}
+
+// streamColumn returns column, 1-based on line of the stream, shifted by
+// opts.Column on the line data starts on.
+func (p *Parser) streamColumn(line, column int) int {
+	if line == max(p.opts.Line, 1) && p.opts.Column > 1 {
+		column += p.opts.Column - 1
+	}
+	return column
+}
+
+// reportUnmatched passes a key that matches no settable field of structType
+// to the OnIgnoredField hook if it names an unsettable field, or to the
+// OnUnknownField hook otherwise.
+func (p *Parser) reportUnmatched(fields *fieldCache, structType reflect.Type, key string) error {
+	info := fields.lookupIgnored(key)
+	if info == nil {
+		if p.opts.OnUnknownField == nil {
+			return nil
+		}
+		return p.opts.OnUnknownField(UnknownField{
+			Key:        key,
+			Struct:     structType,
+			Suggestion: fields.suggest(key),
+			Line:       p.line,
+			Column:     p.streamColumn(p.line, p.column),
+		})
+	}
+	if p.opts.OnIgnoredField == nil {
+		return nil
+	}
+	return p.opts.OnIgnoredField(IgnoredField{
+		Key:    key,
+		Struct: structType,
+		Field:  info.goName,
+		Reason: info.ignored,
+		Line:   p.line,
+		Column: p.streamColumn(p.line, p.column),
+	})
+}
+
 // unmarshalMap unmarshals a YAML block mapping into a map.
 func (p *Parser) unmarshalMap(rv reflect.Value, baseIndent int) error {
+	if err := p.enterCollection(); err != nil {
+		return err
+	}
+	defer p.leaveCollection()
+
 	mapType := rv.Type()
 
 	// Only support string keys
 	if mapType.Key().Kind() != reflect.String {
-		return fmt.Errorf("yaml: unsupported map key type %s", mapType.Key())
+		return p.errorf("unsupported map key type %s", mapType.Key())
 	}
 
 	// Create the map if nil
@@ -311,6 +608,11 @@ func (p *Parser) unmarshalMap(rv reflect.Value, baseIndent int) error {
 			}
 		}
 
+		// A "---" or "..." marker ends the document
+		if p.atDocumentMarker() {
+			break
+		}
+
 		// Parse key
 		key, err := p.parseKey()
 		if err != nil {
@@ -320,32 +622,34 @@ func (p *Parser) unmarshalMap(rv reflect.Value, baseIndent int) error {
 			break
 		}
 
-		// Expect colon
-		p.skipSpaces()
-		if p.pos >= p.length || p.data[p.pos] != ':' {
-			return fmt.Errorf("expected ':' after key %q", key)
+		if err := p.parseColon(key); err != nil {
+			return err
 		}
-		p.advance()
 
 		p.skipSpaces()
 
 		// Create value and unmarshal
 		elemVal := reflect.New(valueType).Elem()
 
+		inKey := func(err error) error { return InKey(err, key) }
 		if p.pos < p.length && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' && p.data[p.pos] != '#' {
-			if err := p.unmarshalValueAtIndent(elemVal, baseIndent); err != nil {
+			err := p.decodeItem(func() error {
+				return p.unmarshalValueAtIndent(elemVal, baseIndent)
+			}, p.skipBlock(baseIndent), inKey)
+			if err != nil {
 				return err
 			}
 		} else {
 			p.skipToNextLine()
 			p.skipWhitespaceAndComments()
 
-			if p.pos < p.length {
+			if p.pos < p.length && p.atNestedValue(baseIndent) {
 				nextIndent := p.currentIndent()
-				if nextIndent > baseIndent {
-					if err := p.unmarshalValueAtIndent(elemVal, nextIndent); err != nil {
-						return err
-					}
+				err := p.decodeItem(func() error {
+					return p.unmarshalValueAtIndent(elemVal, nextIndent)
+				}, p.skipBlock(nextIndent), inKey)
+				if err != nil {
+					return err
 				}
 			}
 		}
@@ -372,14 +676,19 @@ func (p *Parser) unmarshalBlockSequence(rv reflect.Value, baseIndent int) error
 			rv.Set(reflect.ValueOf(arr))
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal sequence into Go value of type %s", rv.Type())
+		return p.errorAt(p.pos, NewTypeError("sequence", rv.Type()))
 	default:
-		return fmt.Errorf("yaml: cannot unmarshal sequence into Go value of type %s", rv.Type())
+		return p.errorAt(p.pos, NewTypeError("sequence", rv.Type()))
 	}
 }
 
 // unmarshalSlice unmarshals a YAML block sequence into a slice.
 func (p *Parser) unmarshalSlice(rv reflect.Value, baseIndent int) error {
+	if err := p.enterCollection(); err != nil {
+		return err
+	}
+	defer p.leaveCollection()
+
 	sliceType := rv.Type()
 	elemType := sliceType.Elem()
 
@@ -418,8 +727,13 @@ func (p *Parser) unmarshalSlice(rv reflect.Value, baseIndent int) error {
 		// Create element and unmarshal
 		elemVal := reflect.New(elemType).Elem()
 
+		inItem := func(err error) error { return InItem(err, len(elements)) }
 		if p.pos < p.length && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' && p.data[p.pos] != '#' {
-			if err := p.unmarshalValueAtIndent(elemVal, p.contentColumn()); err != nil {
+			indent := p.contentColumn()
+			err := p.decodeItem(func() error {
+				return p.unmarshalValueAtIndent(elemVal, indent)
+			}, p.skipBlock(indent), inItem)
+			if err != nil {
 				return err
 			}
 		} else {
@@ -429,7 +743,10 @@ func (p *Parser) unmarshalSlice(rv reflect.Value, baseIndent int) error {
 			if p.pos < p.length {
 				nextIndent := p.currentIndent()
 				if nextIndent > baseIndent {
-					if err := p.unmarshalValueAtIndent(elemVal, nextIndent); err != nil {
+					err := p.decodeItem(func() error {
+						return p.unmarshalValueAtIndent(elemVal, nextIndent)
+					}, p.skipBlock(nextIndent), inItem)
+					if err != nil {
 						return err
 					}
 				}
@@ -451,11 +768,15 @@ func (p *Parser) unmarshalSlice(rv reflect.Value, baseIndent int) error {
 
 // unmarshalArray unmarshals a YAML block sequence into a fixed-size array.
 func (p *Parser) unmarshalArray(rv reflect.Value, baseIndent int) error {
-	arrayLen := rv.Len()
+	if err := p.enterCollection(); err != nil {
+		return err
+	}
+	defer p.leaveCollection()
+
 	idx := 0
 	first := true
 
-	for p.pos < p.length && idx < arrayLen {
+	for p.pos < p.length {
 		p.skipWhitespaceAndComments()
 		if p.pos >= p.length {
 			break
@@ -484,10 +805,15 @@ func (p *Parser) unmarshalArray(rv reflect.Value, baseIndent int) error {
 		p.advance() // skip '-'
 		p.skipSpaces()
 
-		elemVal := rv.Index(idx)
+		elemVal := p.arrayElem(rv, idx)
 
+		inItem := func(err error) error { return InItem(err, idx) }
 		if p.pos < p.length && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' && p.data[p.pos] != '#' {
-			if err := p.unmarshalValueAtIndent(elemVal, p.contentColumn()); err != nil {
+			indent := p.contentColumn()
+			err := p.decodeItem(func() error {
+				return p.unmarshalValueAtIndent(elemVal, indent)
+			}, p.skipBlock(indent), inItem)
+			if err != nil {
 				return err
 			}
 		} else {
@@ -497,7 +823,10 @@ func (p *Parser) unmarshalArray(rv reflect.Value, baseIndent int) error {
 			if p.pos < p.length {
 				nextIndent := p.currentIndent()
 				if nextIndent > baseIndent {
-					if err := p.unmarshalValueAtIndent(elemVal, nextIndent); err != nil {
+					err := p.decodeItem(func() error {
+						return p.unmarshalValueAtIndent(elemVal, nextIndent)
+					}, p.skipBlock(nextIndent), inItem)
+					if err != nil {
 						return err
 					}
 				}
@@ -511,42 +840,61 @@ func (p *Parser) unmarshalArray(rv reflect.Value, baseIndent int) error {
 }
 
 // unmarshalFlowMapping unmarshals a flow-style mapping.
-func (p *Parser) unmarshalFlowMapping(rv reflect.Value) error {
+func (p *Parser) unmarshalFlowMapping(rv reflect.Value, pair bool) error {
 	switch rv.Kind() {
 	case reflect.Struct:
-		return p.unmarshalFlowMappingToStruct(rv)
+		return p.unmarshalFlowMappingToStruct(rv, pair)
 	case reflect.Map:
-		return p.unmarshalFlowMappingToMap(rv)
+		return p.unmarshalFlowMappingToMap(rv, pair)
 	case reflect.Interface:
 		if rv.NumMethod() == 0 {
-			m, err := p.parseFlowMapping()
+			m, err := p.parseFlowMapping(pair)
 			if err != nil {
 				return err
 			}
 			rv.Set(reflect.ValueOf(m))
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal mapping into %s", rv.Type())
+		return p.errorAt(p.pos, NewTypeError("mapping", rv.Type()))
 	default:
-		return fmt.Errorf("yaml: cannot unmarshal mapping into %s", rv.Type())
+		return p.errorAt(p.pos, NewTypeError("mapping", rv.Type()))
 	}
 }
 
-// unmarshalFlowMappingToStruct unmarshals a flow mapping into a struct.
-func (p *Parser) unmarshalFlowMappingToStruct(rv reflect.Value) error {
-	if p.pos >= p.length || p.data[p.pos] != '{' {
-		return errors.New("expected '{'")
+// unmarshalFlowMappingToStruct unmarshals a flow mapping, or with pair set
+// the single-pair mapping of a flow sequence entry, into a struct.
+func (p *Parser) unmarshalFlowMappingToStruct(rv reflect.Value, pair bool) error {
+	if err := p.enterCollection(); err != nil {
+		return err
+	}
+	defer p.leaveCollection()
+
+	start := p.pos
+	if !pair {
+		if p.pos >= p.length || p.data[p.pos] != '{' {
+			return p.errorf("expected '{'")
+		}
+		p.advance()
 	}
-	p.advance()
 
 	structType := rv.Type()
-	fields := getFieldCache(structType)
+	fields := getFieldCache(structType, p.tagName(), p.opts.FallbackTagName)
+	var seen []bool
+	done := func() error {
+		if len(fields.required) == 0 {
+			return nil
+		}
+		if err := fields.checkRequired(structType, seen); err != nil {
+			return p.errorAt(start, err)
+		}
+		return nil
+	}
 
 	p.skipWhitespaceAndComments()
 
-	if p.pos < p.length && p.data[p.pos] == '}' {
+	if !pair && p.pos < p.length && p.data[p.pos] == '}' {
 		p.advance()
-		return nil
+		return done()
 	}
 
 	for {
@@ -559,58 +907,85 @@ func (p *Parser) unmarshalFlowMappingToStruct(rv reflect.Value) error {
 
 		p.skipWhitespaceAndComments()
 
-		if p.pos >= p.length || p.data[p.pos] != ':' {
-			return errors.New("expected ':'")
+		if p.pos >= p.length {
+			return p.errUnexpectedEOF("unexpected end of input")
+		}
+		// A key without a value ({a, b}, as a !!set is written) is null
+		switch p.data[p.pos] {
+		case ':':
+			p.advance()
+		case ',', '}':
+		default:
+			return p.errorf("expected ':'")
 		}
-		p.advance()
 
 		p.skipWhitespaceAndComments()
 
-		fieldInfo, ok := fields.byName[key]
+		fieldInfo, ok := fields.lookup(key)
 		if !ok {
-			fieldInfo, ok = fields.byName[strings.ToLower(key)]
+			if err := p.reportUnmatched(fields, structType, key); err != nil {
+				return err
+			}
+		} else {
+			seen = fields.markSeen(seen, fieldInfo)
 		}
 
 		if ok {
-			fieldVal := rv.Field(fieldInfo.index)
-			if err := p.unmarshalFlowValue(fieldVal); err != nil {
+			fieldVal := fieldByIndex(rv, fieldInfo.index)
+			err := p.decodeItem(func() error {
+				return p.unmarshalFlowValue(fieldVal)
+			}, p.skipFlow, func(err error) error {
+				return InKey(err, key)
+			})
+			if err != nil {
 				return err
 			}
 		} else {
 			// Skip unknown field
 			if _, err := p.parseFlowValue(); err != nil {
-				return err
+				return InKey(err, key)
 			}
 		}
+		if pair {
+			return done()
+		}
 
 		p.skipWhitespaceAndComments()
 
 		if p.pos >= p.length {
-			return errors.New("unexpected end of input")
+			return p.errUnexpectedEOF("unexpected end of input")
 		}
 
 		if p.data[p.pos] == '}' {
 			p.advance()
-			return nil
+			return done()
 		}
 
 		if p.data[p.pos] != ',' {
-			return errors.New("expected ',' or '}'")
+			return p.errorf("expected ',' or '}'")
 		}
 		p.advance()
 	}
 }
 
-// unmarshalFlowMappingToMap unmarshals a flow mapping into a map.
-func (p *Parser) unmarshalFlowMappingToMap(rv reflect.Value) error {
-	if p.pos >= p.length || p.data[p.pos] != '{' {
-		return errors.New("expected '{'")
+// unmarshalFlowMappingToMap unmarshals a flow mapping, or with pair set the
+// single-pair mapping of a flow sequence entry, into a map.
+func (p *Parser) unmarshalFlowMappingToMap(rv reflect.Value, pair bool) error {
+	if err := p.enterCollection(); err != nil {
+		return err
+	}
+	defer p.leaveCollection()
+
+	if !pair {
+		if p.pos >= p.length || p.data[p.pos] != '{' {
+			return p.errorf("expected '{'")
+		}
+		p.advance()
 	}
-	p.advance()
 
 	mapType := rv.Type()
 	if mapType.Key().Kind() != reflect.String {
-		return fmt.Errorf("yaml: unsupported map key type %s", mapType.Key())
+		return p.errorf("unsupported map key type %s", mapType.Key())
 	}
 
 	if rv.IsNil() {
@@ -621,7 +996,7 @@ func (p *Parser) unmarshalFlowMappingToMap(rv reflect.Value) error {
 
 	p.skipWhitespaceAndComments()
 
-	if p.pos < p.length && p.data[p.pos] == '}' {
+	if !pair && p.pos < p.length && p.data[p.pos] == '}' {
 		p.advance()
 		return nil
 	}
@@ -636,24 +1011,39 @@ func (p *Parser) unmarshalFlowMappingToMap(rv reflect.Value) error {
 
 		p.skipWhitespaceAndComments()
 
-		if p.pos >= p.length || p.data[p.pos] != ':' {
-			return errors.New("expected ':'")
+		if p.pos >= p.length {
+			return p.errUnexpectedEOF("unexpected end of input")
+		}
+		// A key without a value ({a, b}, as a !!set is written) is null
+		switch p.data[p.pos] {
+		case ':':
+			p.advance()
+		case ',', '}':
+		default:
+			return p.errorf("expected ':'")
 		}
-		p.advance()
 
 		p.skipWhitespaceAndComments()
 
 		elemVal := reflect.New(valueType).Elem()
-		if err := p.unmarshalFlowValue(elemVal); err != nil {
+		err = p.decodeItem(func() error {
+			return p.unmarshalFlowValue(elemVal)
+		}, p.skipFlow, func(err error) error {
+			return InKey(err, key)
+		})
+		if err != nil {
 			return err
 		}
 
 		rv.SetMapIndex(reflect.ValueOf(key), elemVal)
+		if pair {
+			return nil
+		}
 
 		p.skipWhitespaceAndComments()
 
 		if p.pos >= p.length {
-			return errors.New("unexpected end of input")
+			return p.errUnexpectedEOF("unexpected end of input")
 		}
 
 		if p.data[p.pos] == '}' {
@@ -662,7 +1052,7 @@ func (p *Parser) unmarshalFlowMappingToMap(rv reflect.Value) error {
 		}
 
 		if p.data[p.pos] != ',' {
-			return errors.New("expected ',' or '}'")
+			return p.errorf("expected ',' or '}'")
 		}
 		p.advance()
 	}
@@ -684,16 +1074,21 @@ func (p *Parser) unmarshalFlowSequence(rv reflect.Value) error {
 			rv.Set(reflect.ValueOf(arr))
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal sequence into %s", rv.Type())
+		return p.errorAt(p.pos, NewTypeError("sequence", rv.Type()))
 	default:
-		return fmt.Errorf("yaml: cannot unmarshal sequence into %s", rv.Type())
+		return p.errorAt(p.pos, NewTypeError("sequence", rv.Type()))
 	}
 }
 
 // unmarshalFlowSequenceToSlice unmarshals a flow sequence into a slice.
 func (p *Parser) unmarshalFlowSequenceToSlice(rv reflect.Value) error {
+	if err := p.enterCollection(); err != nil {
+		return err
+	}
+	defer p.leaveCollection()
+
 	if p.pos >= p.length || p.data[p.pos] != '[' {
-		return errors.New("expected '['")
+		return p.errorf("expected '['")
 	}
 	p.advance()
 
@@ -714,7 +1109,12 @@ func (p *Parser) unmarshalFlowSequenceToSlice(rv reflect.Value) error {
 		p.skipWhitespaceAndComments()
 
 		elemVal := reflect.New(elemType).Elem()
-		if err := p.unmarshalFlowValue(elemVal); err != nil {
+		err := p.decodeItem(func() error {
+			return p.unmarshalFlowItem(elemVal)
+		}, p.skipFlowItem, func(err error) error {
+			return InItem(err, len(elements))
+		})
+		if err != nil {
 			return err
 		}
 		elements = append(elements, elemVal)
@@ -722,7 +1122,7 @@ func (p *Parser) unmarshalFlowSequenceToSlice(rv reflect.Value) error {
 		p.skipWhitespaceAndComments()
 
 		if p.pos >= p.length {
-			return errors.New("unexpected end of input")
+			return p.errUnexpectedEOF("unexpected end of input")
 		}
 
 		if p.data[p.pos] == ']' {
@@ -731,7 +1131,7 @@ func (p *Parser) unmarshalFlowSequenceToSlice(rv reflect.Value) error {
 		}
 
 		if p.data[p.pos] != ',' {
-			return errors.New("expected ',' or ']'")
+			return p.errorf("expected ',' or ']'")
 		}
 		p.advance()
 	}
@@ -747,12 +1147,16 @@ func (p *Parser) unmarshalFlowSequenceToSlice(rv reflect.Value) error {
 
 // unmarshalFlowSequenceToArray unmarshals a flow sequence into an array.
 func (p *Parser) unmarshalFlowSequenceToArray(rv reflect.Value) error {
+	if err := p.enterCollection(); err != nil {
+		return err
+	}
+	defer p.leaveCollection()
+
 	if p.pos >= p.length || p.data[p.pos] != '[' {
-		return errors.New("expected '['")
+		return p.errorf("expected '['")
 	}
 	p.advance()
 
-	arrayLen := rv.Len()
 	idx := 0
 
 	p.skipWhitespaceAndComments()
@@ -762,11 +1166,16 @@ func (p *Parser) unmarshalFlowSequenceToArray(rv reflect.Value) error {
 		return nil
 	}
 
-	for idx < arrayLen {
+	for {
 		p.skipWhitespaceAndComments()
 
-		elemVal := rv.Index(idx)
-		if err := p.unmarshalFlowValue(elemVal); err != nil {
+		elemVal := p.arrayElem(rv, idx)
+		err := p.decodeItem(func() error {
+			return p.unmarshalFlowItem(elemVal)
+		}, p.skipFlowItem, func(err error) error {
+			return InItem(err, idx)
+		})
+		if err != nil {
 			return err
 		}
 		idx++
@@ -774,7 +1183,7 @@ func (p *Parser) unmarshalFlowSequenceToArray(rv reflect.Value) error {
 		p.skipWhitespaceAndComments()
 
 		if p.pos >= p.length {
-			return errors.New("unexpected end of input")
+			return p.errUnexpectedEOF("unexpected end of input")
 		}
 
 		if p.data[p.pos] == ']' {
@@ -783,25 +1192,83 @@ func (p *Parser) unmarshalFlowSequenceToArray(rv reflect.Value) error {
 		}
 
 		if p.data[p.pos] != ',' {
-			return errors.New("expected ',' or ']'")
+			return p.errorf("expected ',' or ']'")
 		}
 		p.advance()
 	}
 
-	return nil
+}
+
+// arrayElem returns element idx of array rv, or a scratch value once the
+// array is full: extra sequence entries are parsed and then discarded.
+func (p *Parser) arrayElem(rv reflect.Value, idx int) reflect.Value {
+	if idx < rv.Len() {
+		return rv.Index(idx)
+	}
+	return reflect.New(rv.Type().Elem()).Elem()
+}
+
+// unmarshalFlowItem unmarshals an entry of a flow sequence, which may be a
+// single-pair mapping, as in [a: 1, b: 2].
+func (p *Parser) unmarshalFlowItem(rv reflect.Value) error {
+	if !p.atFlowPair() {
+		return p.unmarshalFlowValue(rv)
+	}
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	if p.claims(rv.Type()) {
+		return p.unmarshalNode(rv, func() (interface{}, error) {
+			return p.parseFlowMapping(true)
+		})
+	}
+	return p.unmarshalFlowMapping(rv, true)
 }
 
 // unmarshalFlowValue unmarshals a value in flow context.
 func (p *Parser) unmarshalFlowValue(rv reflect.Value) error {
 	if p.pos >= p.length {
-		return errors.New("unexpected end of input")
+		return p.errUnexpectedEOF("unexpected end of input")
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		if c := p.data[p.pos]; c == ',' || c == '}' || c == ']' {
+			// Empty value
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		if n := p.nullScalarLen(); n > 0 {
+			p.skipNullScalar(n)
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return p.unmarshalFlowValue(rv.Elem())
+	}
+
+	if p.claims(rv.Type()) {
+		if c := p.data[p.pos]; c == ',' || c == '}' || c == ']' {
+			// Empty value
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		return p.unmarshalNode(rv, p.parseFlowValue)
 	}
 
 	c := p.data[p.pos]
 
+	if c == '!' && p.tagDecoder() != nil {
+		return p.unmarshalTagged(rv, true)
+	}
+
 	switch c {
 	case '{':
-		return p.unmarshalFlowMapping(rv)
+		return p.unmarshalFlowMapping(rv, false)
 	case '[':
 		return p.unmarshalFlowSequence(rv)
 	case '"', '\'':
@@ -813,6 +1280,7 @@ func (p *Parser) unmarshalFlowValue(rv reflect.Value) error {
 
 // unmarshalQuotedString unmarshals a quoted string.
 func (p *Parser) unmarshalQuotedString(rv reflect.Value) error {
+	start := p.pos
 	var s string
 	var err error
 
@@ -825,9 +1293,38 @@ func (p *Parser) unmarshalQuotedString(rv reflect.Value) error {
 	if err != nil {
 		return err
 	}
+	return p.setString(rv, s, start)
+}
+
+// unmarshalBlockScalar unmarshals a literal or folded block scalar.
+func (p *Parser) unmarshalBlockScalar(rv reflect.Value) error {
+	start := p.pos
+	s, err := p.parseBlockScalar()
+	if err != nil {
+		return err
+	}
+	return p.setString(rv, s, start)
+}
+
+// setString sets rv to s, the value of a quoted or block scalar starting at
+// offset start, which is always a string, whatever it reads as.
+func (p *Parser) setString(rv reflect.Value, s string, start int) error {
+	if u, ok := p.textUnmarshaler(rv); ok {
+		if err := p.unmarshalText(u, rv, s); err != nil {
+			return p.errorAt(start, err)
+		}
+		return nil
+	}
+
+	if rv.Kind() == reflect.Slice && p.opts.PromoteScalars {
+		if err := p.setScalarValue(rv, s, nil); err != nil {
+			return p.errorAt(start, err)
+		}
+		return nil
+	}
 
 	if rv.Kind() != reflect.String {
-		return fmt.Errorf("yaml: cannot unmarshal string into %s", rv.Type())
+		return p.errorAt(start, NewTypeError("string", rv.Type()))
 	}
 
 	rv.SetString(s)
@@ -836,29 +1333,47 @@ func (p *Parser) unmarshalQuotedString(rv reflect.Value) error {
 
 // unmarshalScalar unmarshals a plain scalar.
 func (p *Parser) unmarshalScalar(rv reflect.Value) error {
+	start := p.pos
 	val, err := p.parseScalar()
 	if err != nil {
 		return err
 	}
-	return p.setScalarValue(rv, val)
+	if err := p.setScalarValue(rv, val, p.data[start:p.pos]); err != nil {
+		return p.errorAt(start, err)
+	}
+	return nil
 }
 
 // unmarshalFlowScalar unmarshals a plain scalar in flow context.
 func (p *Parser) unmarshalFlowScalar(rv reflect.Value) error {
+	start := p.pos
 	val, err := p.parseFlowScalar()
 	if err != nil {
 		return err
 	}
-	return p.setScalarValue(rv, val)
+	if err := p.setScalarValue(rv, val, p.data[start:p.pos]); err != nil {
+		return p.errorAt(start, err)
+	}
+	return nil
 }
 
-// setScalarValue sets a reflect.Value from an interface{} scalar.
-func (p *Parser) setScalarValue(rv reflect.Value, val interface{}) error {
+// setScalarValue sets a reflect.Value from an interface{} scalar. raw is
+// the scalar's source text, used when a scalar that resolved to something
+// other than a string is decoded into a string or a TextUnmarshaler.
+func (p *Parser) setScalarValue(rv reflect.Value, val interface{}, raw []byte) error {
 	if val == nil {
 		rv.Set(reflect.Zero(rv.Type()))
 		return nil
 	}
 
+	if u, ok := p.textUnmarshaler(rv); ok {
+		text, ok := val.(string)
+		if !ok {
+			text = string(trimBytes(raw))
+		}
+		return p.unmarshalText(u, rv, text)
+	}
+
 	switch rv.Kind() {
 	case reflect.String:
 		switch v := val.(type) {
@@ -866,7 +1381,8 @@ func (p *Parser) setScalarValue(rv reflect.Value, val interface{}) error {
 			rv.SetString(v)
 			return nil
 		default:
-			rv.SetString(fmt.Sprintf("%v", val))
+			// Numbers and bools keep their source text, so 0x10 stays "0x10"
+			rv.SetString(string(trimBytes(raw)))
 			return nil
 		}
 
@@ -874,7 +1390,7 @@ func (p *Parser) setScalarValue(rv reflect.Value, val interface{}) error {
 		switch v := val.(type) {
 		case int64:
 			if rv.OverflowInt(v) {
-				return fmt.Errorf("yaml: value %d overflows %s", v, rv.Type())
+				return fmt.Errorf("value %d overflows %s", v, rv.Type())
 			}
 			rv.SetInt(v)
 			return nil
@@ -882,91 +1398,113 @@ func (p *Parser) setScalarValue(rv reflect.Value, val interface{}) error {
 			// Allow uint64 values that fit in int64 range
 			const maxInt64 = int64(^uint64(0) >> 1) // 9223372036854775807
 			if v > uint64(maxInt64) {
-				return fmt.Errorf("yaml: value %d overflows %s", v, rv.Type())
+				return fmt.Errorf("value %d overflows %s", v, rv.Type())
 			}
 			i := int64(v)
 			if rv.OverflowInt(i) {
-				return fmt.Errorf("yaml: value %d overflows %s", v, rv.Type())
+				return fmt.Errorf("value %d overflows %s", v, rv.Type())
 			}
 			rv.SetInt(i)
 			return nil
 		case float64:
+			if v != float64(int64(v)) {
+				return NewTypeError(ValueKind(v), rv.Type())
+			}
 			i := int64(v)
 			if rv.OverflowInt(i) {
-				return fmt.Errorf("yaml: value %v overflows %s", v, rv.Type())
+				return fmt.Errorf("value %v overflows %s", v, rv.Type())
 			}
 			rv.SetInt(i)
 			return nil
 		case string:
-			return fmt.Errorf("yaml: cannot unmarshal string into %s", rv.Type())
+			return NewTypeError("string", rv.Type())
 		}
-		return fmt.Errorf("yaml: cannot unmarshal %T into %s", val, rv.Type())
+		return NewTypeError(ValueKind(val), rv.Type())
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		switch v := val.(type) {
 		case int64:
 			if v < 0 || rv.OverflowUint(uint64(v)) {
-				return fmt.Errorf("yaml: value %d overflows %s", v, rv.Type())
+				return fmt.Errorf("value %d overflows %s", v, rv.Type())
 			}
 			rv.SetUint(uint64(v))
 			return nil
 		case uint64:
 			if rv.OverflowUint(v) {
-				return fmt.Errorf("yaml: value %d overflows %s", v, rv.Type())
+				return fmt.Errorf("value %d overflows %s", v, rv.Type())
 			}
 			rv.SetUint(v)
 			return nil
 		case float64:
+			if v < 0 || v != float64(uint64(v)) {
+				return NewTypeError(ValueKind(v), rv.Type())
+			}
 			u := uint64(v)
 			if rv.OverflowUint(u) {
-				return fmt.Errorf("yaml: value %v overflows %s", v, rv.Type())
+				return fmt.Errorf("value %v overflows %s", v, rv.Type())
 			}
 			rv.SetUint(u)
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal %T into %s", val, rv.Type())
+		return NewTypeError(ValueKind(val), rv.Type())
 
 	case reflect.Float32, reflect.Float64:
 		switch v := val.(type) {
 		case float64:
 			if rv.OverflowFloat(v) {
-				return fmt.Errorf("yaml: value %v overflows %s", v, rv.Type())
+				return fmt.Errorf("value %v overflows %s", v, rv.Type())
 			}
 			rv.SetFloat(v)
 			return nil
 		case int64:
 			f := float64(v)
 			if rv.OverflowFloat(f) {
-				return fmt.Errorf("yaml: value %v overflows %s", v, rv.Type())
+				return fmt.Errorf("value %v overflows %s", v, rv.Type())
 			}
 			rv.SetFloat(f)
 			return nil
 		case uint64:
 			f := float64(v)
 			if rv.OverflowFloat(f) {
-				return fmt.Errorf("yaml: value %v overflows %s", v, rv.Type())
+				return fmt.Errorf("value %v overflows %s", v, rv.Type())
 			}
 			rv.SetFloat(f)
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal %T into %s", val, rv.Type())
+		return NewTypeError(ValueKind(val), rv.Type())
 
 	case reflect.Bool:
 		if b, ok := val.(bool); ok {
 			rv.SetBool(b)
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal %T into bool", val)
+		return NewTypeError(ValueKind(val), rv.Type())
 
 	case reflect.Interface:
 		if rv.NumMethod() == 0 {
 			rv.Set(reflect.ValueOf(val))
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal into %s", rv.Type())
+		return NewTypeError(ValueKind(val), rv.Type())
+
+	case reflect.Slice:
+		if !p.opts.PromoteScalars {
+			return NewTypeError(ValueKind(val), rv.Type())
+		}
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		target := elem
+		for target.Kind() == reflect.Ptr {
+			target.Set(reflect.New(target.Type().Elem()))
+			target = target.Elem()
+		}
+		if err := p.setScalarValue(target, val, raw); err != nil {
+			return err
+		}
+		rv.Set(reflect.Append(reflect.MakeSlice(rv.Type(), 0, 1), elem))
+		return nil
 
 	default:
-		return fmt.Errorf("yaml: cannot unmarshal into %s", rv.Type())
+		return NewTypeError(ValueKind(val), rv.Type())
 	}
 }
 
@@ -974,78 +1512,379 @@ func (p *Parser) setScalarValue(rv reflect.Value, val interface{}) error {
 
 type fieldInfo struct {
 	name      string
-	index     int
+	index     []int // index path; longer than 1 for fields promoted from embedded structs
 	omitEmpty bool
+	required  bool   // the key must be present in the mapping
+	tagged    bool   // name came from a yaml tag
+	goName    string // dotted Go field path, used in diagnostics
+	ignored   string // non-empty if the field can never be set, with the reason
 }
 
 type fieldCache struct {
-	byName map[string]*fieldInfo
+	byName   map[string]*fieldInfo
+	ignored  map[string]*fieldInfo // keys that match only fields that cannot be set
+	names    []string              // settable field names in field order, for suggestions
+	required []*fieldInfo          // settable fields tagged required, in field order
+}
+
+// lookup finds the field for a YAML key, falling back to a lowercase match.
+func (fc *fieldCache) lookup(key string) (*fieldInfo, bool) {
+	if info, ok := fc.byName[key]; ok {
+		return info, true
+	}
+	info, ok := fc.byName[strings.ToLower(key)]
+	return info, ok
+}
+
+// markSeen records that the key of info was present in the mapping being
+// decoded, allocating seen, one flag per required field, on first use.
+func (fc *fieldCache) markSeen(seen []bool, info *fieldInfo) []bool {
+	if !info.required {
+		return seen
+	}
+	if seen == nil {
+		seen = make([]bool, len(fc.required))
+	}
+	for i, f := range fc.required {
+		if f == info {
+			seen[i] = true
+		}
+	}
+	return seen
+}
+
+// checkRequired returns a *MissingFieldError for the required fields of t
+// not marked in seen, or nil if there are none.
+func (fc *fieldCache) checkRequired(t reflect.Type, seen []bool) error {
+	var missing []string
+	for i, f := range fc.required {
+		if seen == nil || !seen[i] {
+			missing = append(missing, f.name)
+		}
+	}
+	if missing == nil {
+		return nil
+	}
+	return &MissingFieldError{Type: t, Fields: missing}
+}
+
+// lookupIgnored finds the unsettable field a YAML key would have matched, if any.
+func (fc *fieldCache) lookupIgnored(key string) *fieldInfo {
+	if info, ok := fc.ignored[key]; ok {
+		return info
+	}
+	return fc.ignored[strings.ToLower(key)]
+}
+
+// suggest returns the field name closest to an unknown key by edit distance,
+// ignoring case, or "" if no name is close enough to be a likely typo.
+func (fc *fieldCache) suggest(key string) string {
+	lower := strings.ToLower(key)
+	maxDist := len(lower) / 3
+	if maxDist < 2 {
+		maxDist = 2
+	}
+
+	best, bestDist := "", maxDist+1
+	for _, name := range fc.names {
+		if d := levenshtein(lower, strings.ToLower(name)); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// fieldCacheKey identifies a struct type decoded under given tag names.
+type fieldCacheKey struct {
+	t        reflect.Type
+	tag      string
+	fallback string
 }
 
 var (
 	fieldCacheMu  sync.RWMutex
-	fieldCacheMap = make(map[reflect.Type]*fieldCache)
+	fieldCacheMap = make(map[fieldCacheKey]*fieldCache)
 )
 
-func getFieldCache(t reflect.Type) *fieldCache {
+func getFieldCache(t reflect.Type, tagName, fallback string) *fieldCache {
+	key := fieldCacheKey{t, tagName, fallback}
 	fieldCacheMu.RLock()
-	fc, ok := fieldCacheMap[t]
+	fc, ok := fieldCacheMap[key]
 	fieldCacheMu.RUnlock()
 	if ok {
 		return fc
 	}
 
-	fc = buildFieldCache(t)
+	fc = buildFieldCache(t, tagName, fallback)
 	fieldCacheMu.Lock()
-	fieldCacheMap[t] = fc
+	fieldCacheMap[key] = fc
 	fieldCacheMu.Unlock()
 	return fc
 }
 
-func buildFieldCache(t reflect.Type) *fieldCache {
+// Field is a struct field that YAML keys decode into.
+type Field struct {
+	Name      string // key the field decodes from
+	Index     []int  // index path for reflect.Type.FieldByIndex
+	OmitEmpty bool
+	Required  bool
+}
+
+// Fields returns the settable fields of struct type t in field order, with
+// fields promoted from embedded structs and name conflicts resolved exactly as
+// Unmarshal resolves them.
+func Fields(t reflect.Type, tagName string) []Field {
+	fc := getFieldCache(t, tagName, "")
+	fields := make([]Field, len(fc.names))
+	for i, name := range fc.names {
+		info := fc.byName[name]
+		fields[i] = Field{Name: info.name, Index: info.index, OmitEmpty: info.omitEmpty, Required: info.required}
+	}
+	return fields
+}
+
+// buildFieldCache indexes the fields of t by the names given in tagName tags,
+// or in fallback tags for fields without one, including fields promoted from
+// untagged embedded structs. Name conflicts are resolved as in
+// encoding/json: the shallowest field wins, a tagged field beats an untagged
+// one at the same depth, and otherwise the conflicting fields are dropped.
+//
+// Names that only match fields which cannot be set (unexported, unreachable,
+// or dropped as ambiguous) are kept in a separate index for diagnostics.
+func buildFieldCache(t reflect.Type, tagName, fallback string) *fieldCache {
 	fc := &fieldCache{
-		byName: make(map[string]*fieldInfo),
+		byName:  make(map[string]*fieldInfo),
+		ignored: make(map[string]*fieldInfo),
 	}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if field.PkgPath != "" { // Skip unexported
+	var candidates []*fieldInfo
+	collectFields(t, tagName, fallback, nil, "", "", map[reflect.Type]bool{t: true}, &candidates)
+
+	// Group candidates by name, keeping first-seen order for determinism
+	byName := make(map[string][]*fieldInfo)
+	var names []string
+	var unsettable []*fieldInfo
+	for _, f := range candidates {
+		if f.ignored != "" {
+			unsettable = append(unsettable, f)
 			continue
 		}
+		if _, seen := byName[f.name]; !seen {
+			names = append(names, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
 
-		tag := field.Tag.Get("yaml")
+	var dominant []*fieldInfo
+	for _, name := range names {
+		if info := dominantField(byName[name]); info != nil {
+			fc.byName[name] = info
+			fc.names = append(fc.names, name)
+			dominant = append(dominant, info)
+			if info.required {
+				fc.required = append(fc.required, info)
+			}
+		} else {
+			unsettable = append(unsettable, &fieldInfo{
+				name:    name,
+				goName:  byName[name][0].goName,
+				ignored: "ambiguous: promoted from more than one embedded struct",
+			})
+		}
+	}
+
+	// Also index by lowercase for case-insensitive matching
+	for _, info := range dominant {
+		lower := strings.ToLower(info.name)
+		if _, exists := fc.byName[lower]; !exists {
+			fc.byName[lower] = info
+		}
+	}
+
+	for _, info := range unsettable {
+		for _, name := range []string{info.name, strings.ToLower(info.name)} {
+			if _, exists := fc.byName[name]; exists {
+				continue
+			}
+			if _, exists := fc.ignored[name]; !exists {
+				fc.ignored[name] = info
+			}
+		}
+	}
+
+	return fc
+}
+
+// collectFields appends the fields of t to out in index order, descending into
+// untagged embedded structs. visiting guards against embedding cycles.
+// prefix is the dotted Go path of t; unreachable, when set, marks every field
+// found as unsettable for that reason.
+func collectFields(t reflect.Type, tagName, fallback string, parent []int, prefix, unreachable string, visiting map[reflect.Type]bool, out *[]*fieldInfo) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok && fallback != "" {
+			tag = field.Tag.Get(fallback)
+		}
 		if tag == "-" {
 			continue
 		}
 
-		name := field.Name
-		omitEmpty := false
-
+		name := ""
+		omitEmpty, required := false, false
 		if tag != "" {
 			parts := strings.Split(tag, ",")
-			if parts[0] != "" {
-				name = parts[0]
-			}
+			name = parts[0]
 			for _, opt := range parts[1:] {
-				if opt == "omitempty" {
+				switch opt {
+				case "omitempty":
 					omitEmpty = true
+				case "required":
+					required = true
+				}
+			}
+		}
+
+		index := make([]int, len(parent)+1)
+		copy(index, parent)
+		index[len(parent)] = i
+		goName := prefix + field.Name
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				reason := unreachable
+				// Embedded pointers to unexported structs cannot be allocated
+				if reason == "" && !field.IsExported() && field.Type.Kind() == reflect.Ptr {
+					reason = "promoted through unexported embedded pointer " + goName
 				}
+				if !visiting[ft] {
+					visiting[ft] = true
+					collectFields(ft, tagName, fallback, index, goName+".", reason, visiting, out)
+					delete(visiting, ft)
+				}
+				continue
 			}
 		}
 
 		info := &fieldInfo{
 			name:      name,
-			index:     i,
+			index:     index,
 			omitEmpty: omitEmpty,
+			required:  required,
+			tagged:    name != "",
+			goName:    goName,
+			ignored:   unreachable,
 		}
+		if info.name == "" {
+			info.name = field.Name
+		}
+		if info.ignored == "" && !field.IsExported() {
+			info.ignored = "unexported field"
+		}
+		*out = append(*out, info)
+	}
+}
 
-		fc.byName[name] = info
-		// Also index by lowercase for case-insensitive matching
-		lower := strings.ToLower(name)
-		if lower != name {
-			fc.byName[lower] = info
+// dominantField picks the field that wins among fields sharing a name,
+// or returns nil if the name is ambiguous.
+func dominantField(fields []*fieldInfo) *fieldInfo {
+	depth := len(fields[0].index)
+	for _, f := range fields[1:] {
+		if len(f.index) < depth {
+			depth = len(f.index)
 		}
 	}
 
-	return fc
+	var winner *fieldInfo
+	var untagged []*fieldInfo
+	for _, f := range fields {
+		if len(f.index) != depth {
+			continue
+		}
+		if f.tagged {
+			if winner != nil {
+				return nil
+			}
+			winner = f
+		} else {
+			untagged = append(untagged, f)
+		}
+	}
+	if winner != nil {
+		return winner
+	}
+	if len(untagged) == 1 {
+		return untagged[0]
+	}
+	return nil
+}
+
+// fieldByIndex returns the struct field at index, allocating nil embedded
+// struct pointers along the path.
+func fieldByIndex(rv reflect.Value, index []int) reflect.Value {
+	if len(index) == 1 {
+		return rv.Field(index[0])
+	}
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv
+}
+
+// textUnmarshaler returns the encoding.TextUnmarshaler of an addressable
+// target, for types that decode themselves from a scalar's text. A time.Time
+// reads any YAML timestamp unless opts.DisableImplicitTimestamps is set.
+func (p *Parser) textUnmarshaler(rv reflect.Value) (encoding.TextUnmarshaler, bool) {
+	if !rv.CanAddr() {
+		return nil, false
+	}
+	if rv.Type() == timeType && !p.opts.DisableImplicitTimestamps {
+		return (*resolve.TimestampText)(rv.Addr().Interface().(*time.Time)), true
+	}
+	u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler)
+	return u, ok
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// unmarshalText decodes a scalar's text into a TextUnmarshaler target.
+// Numbers and booleans are passed as written, so 1.20 arrives as "1.20".
+func (p *Parser) unmarshalText(u encoding.TextUnmarshaler, rv reflect.Value, text string) error {
+	if err := u.UnmarshalText([]byte(text)); err != nil {
+		return fmt.Errorf("cannot unmarshal %q into %s: %w", text, rv.Type(), err)
+	}
+	return nil
 }
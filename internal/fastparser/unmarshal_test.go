@@ -203,6 +203,18 @@ func TestUnmarshal_BlockSequence(t *testing.T) {
 			target:   &[3]string{},
 			expected: &[3]string{"a", "b", "c"},
 		},
+		{
+			name:     "flow array - too many elements",
+			yaml:     `[a, b, c, d]`,
+			target:   &[2]string{},
+			expected: &[2]string{"a", "b"},
+		},
+		{
+			name:    "over-indented mapping entry",
+			yaml:    "- name: a\n    port: 1",
+			target:  &[]map[string]string{},
+			wantErr: true,
+		},
 		{
 			name:    "invalid - sequence to map",
 			yaml:    `- item`,
@@ -789,7 +801,6 @@ func TestUnmarshal_MultiLevelIndentation(t *testing.T) {
 
 // TestUnmarshal_ComplexNestedSequences tests complex nested sequences
 func TestUnmarshal_ComplexNestedSequences(t *testing.T) {
-	t.Skip("Nested block sequences to typed slices not yet supported - acceptable limitation")
 	yaml := `- - 1
   - 2
   - 3
@@ -810,6 +821,40 @@ func TestUnmarshal_ComplexNestedSequences(t *testing.T) {
 	}
 }
 
+// TestUnmarshal_NestedSequencesTyped tests compact nested block sequences
+// into typed slices
+func TestUnmarshal_NestedSequencesTyped(t *testing.T) {
+	type Point struct {
+		X int `yaml:"x"`
+		Y int `yaml:"y"`
+	}
+
+	tests := []struct {
+		name     string
+		yaml     string
+		target   interface{}
+		expected interface{}
+	}{
+		{"ints", "- - 1\n  - 2\n- - 3\n", &[][]int{}, &[][]int{{1, 2}, {3}}},
+		{"wide dash indent", "-   - 1\n    - 2\n-   - 3\n", &[][]int{}, &[][]int{{1, 2}, {3}}},
+		{"three levels", "- - - 1\n    - 2\n  - - 3\n- - - 4\n", &[][][]int{}, &[][][]int{{{1, 2}, {3}}, {{4}}}},
+		{"empty entry", "- -\n  - 2\n", &[][]interface{}{}, &[][]interface{}{{nil, int64(2)}}},
+		{"structs", "- - x: 1\n    y: 2\n  - x: 3\n- - y: 4\n", &[][]Point{}, &[][]Point{{{1, 2}, {3, 0}}, {{0, 4}}}},
+		{"under a key", "rows:\n  - - a\n    - b\n  - - c\n", &map[string][][]string{}, &map[string][][]string{"rows": {{"a", "b"}, {"c"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Unmarshal([]byte(tt.yaml), tt.target); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.target, tt.expected) {
+				t.Errorf("\nExpected: %+v\nGot:      %+v", tt.expected, tt.target)
+			}
+		})
+	}
+}
+
 // TestUnmarshal_MixedFlowAndBlockStyle tests mixing flow and block styles
 func TestUnmarshal_MixedFlowAndBlockStyle(t *testing.T) {
 	yaml := `name: Alice
@@ -826,16 +871,15 @@ config:
 
 	expected := map[string]interface{}{
 		"name": "Alice",
-		"tags": []interface{}{int64(1), int64(2), int64(3)}, // Flow sequence values are parsed as int64
+		"tags": []interface{}{"dev", "golang", "yaml"},
 		"config": map[string]interface{}{
 			"enabled": true,
 			"items":   []interface{}{int64(1), int64(2), int64(3)},
 		},
 	}
 
-	// Compare just the structure we care about
-	if result["name"] != expected["name"] {
-		t.Errorf("name mismatch: expected %v, got %v", expected["name"], result["name"])
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v\nGot:      %+v", expected, result)
 	}
 }
 
@@ -1090,6 +1134,61 @@ func TestUnmarshal_SliceOfStructs(t *testing.T) {
 	}
 }
 
+// TestUnmarshal_SliceOfStructsEntries tests the layouts of mappings that
+// span several lines inside block sequence items
+func TestUnmarshal_SliceOfStructsEntries(t *testing.T) {
+	type Item struct {
+		Name  string
+		Value int
+		Tags  []string
+		Sub   *Item
+	}
+
+	tests := []struct {
+		name     string
+		yaml     string
+		expected []Item
+	}{
+		{
+			name:     "wide dash indent",
+			yaml:     "-   name: a\n    value: 1\n-   name: b\n",
+			expected: []Item{{Name: "a", Value: 1}, {Name: "b"}},
+		},
+		{
+			name:     "mapping on the line after the dash",
+			yaml:     "-\n  name: a\n  value: 1\n- name: b\n",
+			expected: []Item{{Name: "a", Value: 1}, {Name: "b"}},
+		},
+		{
+			name:     "nested sequence then more keys",
+			yaml:     "- name: a\n  tags:\n    - x\n    - y\n  value: 3\n- name: b\n",
+			expected: []Item{{Name: "a", Value: 3, Tags: []string{"x", "y"}}, {Name: "b"}},
+		},
+		{
+			name:     "nested mapping first",
+			yaml:     "- sub:\n    name: s\n    value: 1\n  name: n\n",
+			expected: []Item{{Name: "n", Sub: &Item{Name: "s", Value: 1}}},
+		},
+		{
+			name:     "comments and blank lines between entries",
+			yaml:     "- name: a # first\n\n  # value follows\n  value: 1\n\n- name: b\n  value: 2\n",
+			expected: []Item{{Name: "a", Value: 1}, {Name: "b", Value: 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []Item
+			if err := Unmarshal([]byte(tt.yaml), &result); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("\nExpected: %+v\nGot:      %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
 // TestUnmarshal_MapOfSlices tests unmarshaling to map of slices
 func TestUnmarshal_MapOfSlices(t *testing.T) {
 	yaml := `tags:
@@ -1114,9 +1213,10 @@ numbers:
 	}
 }
 
-// TestUnmarshal_WhitespaceOnly tests various whitespace-only inputs
+// TestUnmarshal_WhitespaceOnly tests that input without a document, after
+// an optional byte order mark, leaves the zero value without an error.
 func TestUnmarshal_WhitespaceOnly(t *testing.T) {
-	t.Skip("Whitespace-only input currently returns nil (no error) - acceptable behavior")
+	bom := string(rune(0xFEFF))
 	tests := []struct {
 		name string
 		yaml string
@@ -1125,19 +1225,35 @@ func TestUnmarshal_WhitespaceOnly(t *testing.T) {
 		{name: "newlines only", yaml: "\n\n\n"},
 		{name: "tabs only", yaml: "\t\t"},
 		{name: "mixed whitespace", yaml: " \t\n \t\n "},
+		{name: "comment only", yaml: "# nothing\n"},
+		{name: "BOM only", yaml: bom},
+		{name: "BOM and whitespace", yaml: bom + "\n  \n"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var result interface{}
-			err := Unmarshal([]byte(tt.yaml), &result)
-			if err == nil {
-				t.Error("Expected error for whitespace-only input")
+			var result interface{} = "preset"
+			if err := Unmarshal([]byte(tt.yaml), &result); err != nil {
+				t.Fatalf("Unmarshal() error = %v, want nil", err)
+			}
+			if result != nil {
+				t.Errorf("Unmarshal() = %#v, want nil", result)
 			}
 		})
 	}
 }
 
+// TestUnmarshal_BOM tests that a leading byte order mark is not content.
+func TestUnmarshal_BOM(t *testing.T) {
+	var result map[string]interface{}
+	if err := Unmarshal([]byte(string(rune(0xFEFF))+"a: 1"), &result); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(result) != 1 || result["a"] != int64(1) {
+		t.Errorf("Unmarshal() = %#v, want map[a:1]", result)
+	}
+}
+
 // TestUnmarshal_InlineSequenceServerURL tests multi-field structs inline in a sequence
 func TestUnmarshal_InlineSequenceServerURL(t *testing.T) {
 	type Server struct {
@@ -1346,3 +1462,237 @@ paths:
 		t.Errorf("/posts summary: expected 'Post operations', got %q", posts.Summary)
 	}
 }
+
+// TestUnmarshal_EmbeddedStructs tests promoted fields of embedded structs
+func TestUnmarshal_EmbeddedStructs(t *testing.T) {
+	type Meta struct {
+		ID   int    `yaml:"id"`
+		Name string `yaml:"name"`
+	}
+	type meta struct {
+		Owner string `yaml:"owner"`
+	}
+	type Labels struct {
+		Env string `yaml:"env"`
+	}
+	type Service struct {
+		Meta
+		meta
+		*Labels
+		Name string `yaml:"name"` // shadows Meta.Name
+		Port int    `yaml:"port"`
+	}
+	type Tagged struct {
+		Meta `yaml:"meta"`
+	}
+
+	tests := []struct {
+		name     string
+		yaml     string
+		target   interface{}
+		expected interface{}
+	}{
+		{
+			name:   "promoted and shadowed fields",
+			yaml:   "id: 7\nname: api\nowner: ops\nport: 8080",
+			target: &Service{},
+			expected: &Service{
+				Meta: Meta{ID: 7},
+				meta: meta{Owner: "ops"},
+				Name: "api",
+				Port: 8080,
+			},
+		},
+		{
+			name:   "nil embedded pointer is allocated",
+			yaml:   "env: prod",
+			target: &Service{},
+			expected: &Service{
+				Labels: &Labels{Env: "prod"},
+			},
+		},
+		{
+			name:   "flow mapping",
+			yaml:   "{id: 3, env: dev}",
+			target: &Service{},
+			expected: &Service{
+				Meta:   Meta{ID: 3},
+				Labels: &Labels{Env: "dev"},
+			},
+		},
+		{
+			name:   "tagged embedded struct is a nested mapping",
+			yaml:   "meta:\n  id: 1\n  name: x",
+			target: &Tagged{},
+			expected: &Tagged{
+				Meta: Meta{ID: 1, Name: "x"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Unmarshal([]byte(tt.yaml), tt.target); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.target, tt.expected) {
+				t.Errorf("Unmarshal() = %+v, want %+v", tt.target, tt.expected)
+			}
+		})
+	}
+}
+
+// TestBuildFieldCache_Conflicts tests name conflict resolution between embedded fields
+func TestBuildFieldCache_Conflicts(t *testing.T) {
+	type A struct{ X, Y int }
+	type B struct {
+		X int
+		Y int `yaml:"Y"`
+	}
+	type Recursive struct {
+		*Recursive
+		Z int
+	}
+	type Outer struct {
+		A
+		B
+	}
+
+	fc := buildFieldCache(reflect.TypeOf(Outer{}), "yaml", "")
+	if _, ok := fc.byName["X"]; ok {
+		t.Error("ambiguous field X should be dropped")
+	}
+	if info, ok := fc.byName["Y"]; !ok || !reflect.DeepEqual(info.index, []int{1, 1}) {
+		t.Errorf("Y should resolve to the tagged B.Y, got %+v", info)
+	}
+
+	fc = buildFieldCache(reflect.TypeOf(Recursive{}), "yaml", "")
+	if info, ok := fc.byName["z"]; !ok || !reflect.DeepEqual(info.index, []int{1}) {
+		t.Errorf("z should resolve to Recursive.Z, got %+v", info)
+	}
+}
+
+func TestFieldCache_Suggest(t *testing.T) {
+	type Config struct {
+		Replicas    int    `yaml:"replicas"`
+		Image       string `yaml:"image"`
+		ServiceName string
+		hidden      string
+	}
+	fc := buildFieldCache(reflect.TypeOf(Config{}), "yaml", "")
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"replcas", "replicas"},
+		{"REPLICAS", "replicas"},
+		{"imgae", "image"},
+		{"service_name", "ServiceName"},
+		{"hiden", ""},
+		{"timeout", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := fc.suggest(tt.key); got != tt.want {
+			t.Errorf("suggest(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"replicas", "replcas", 1},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestUnmarshal_ExplicitKeys tests "? key" entries, whose ": value" line is
+// optional, in every block mapping decoder and in flow mappings.
+func TestUnmarshal_ExplicitKeys(t *testing.T) {
+	type pair struct {
+		A *int
+		B *int
+	}
+	one := 1
+	tests := []struct {
+		name     string
+		yaml     string
+		target   interface{}
+		expected interface{}
+	}{
+		{
+			name:     "key without value",
+			yaml:     "? a\n: 1\n? b",
+			target:   &map[string]interface{}{},
+			expected: &map[string]interface{}{"a": int64(1), "b": nil},
+		},
+		{
+			name:     "struct",
+			yaml:     "? a\n: 1\n? b\n",
+			target:   &pair{B: &one},
+			expected: &pair{A: &one},
+		},
+		{
+			name:     "typed map",
+			yaml:     "? a # key\n# comment\n: 1\n? b\n",
+			target:   &map[string]*int{},
+			expected: &map[string]*int{"a": &one, "b": nil},
+		},
+		{
+			name:     "mixed with implicit keys",
+			yaml:     "? a\nc: 3\n? 'q: r'\n: x",
+			target:   &map[string]interface{}{},
+			expected: &map[string]interface{}{"a": nil, "c": int64(3), "q: r": "x"},
+		},
+		{
+			name:   "nested, value on the next line",
+			yaml:   "x:\n  ? a\n  :\n    y: 1\n  ? b\n",
+			target: &map[string]interface{}{},
+			expected: &map[string]interface{}{"x": map[string]interface{}{
+				"a": map[string]interface{}{"y": int64(1)}, "b": nil,
+			}},
+		},
+		{
+			name:     "sequence item",
+			yaml:     "- ? a\n  : 1\n  ? b\n- c: 2",
+			target:   &[]map[string]interface{}{},
+			expected: &[]map[string]interface{}{{"a": int64(1), "b": nil}, {"c": int64(2)}},
+		},
+		{
+			name:     "flow mapping",
+			yaml:     "{? a : 1, ? b}",
+			target:   &map[string]interface{}{},
+			expected: &map[string]interface{}{"a": int64(1), "b": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Unmarshal([]byte(tt.yaml), tt.target); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.target, tt.expected) {
+				t.Errorf("Unmarshal() = %#v, want %#v", tt.target, tt.expected)
+			}
+		})
+	}
+
+	// Keys the decoder cannot turn into a string are errors, not text
+	for _, input := range []string{"? [a]\n: 1", "? a\n  b\n: 1", "? |\n  a\n: 1", "? a: b\n: 1", "?\n: 1", "? a x: 1"} {
+		var v map[string]interface{}
+		if err := Unmarshal([]byte(input), &v); err == nil {
+			t.Errorf("Unmarshal(%q) = %v, want an error", input, v)
+		}
+	}
+}
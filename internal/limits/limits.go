@@ -0,0 +1,38 @@
+// Package limits defines the resource limits shared by the AST parser
+// (internal/parser) and the fast parser (internal/fastparser), so that both
+// decode paths reject hostile input the same way.
+package limits
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrExceeded is wrapped by every error reporting that input exceeded a limit.
+var ErrExceeded = errors.New("limit exceeded")
+
+// ErrUnsafe is wrapped by every error reporting an anchor, alias, tag, or
+// directive in input parsed in safe mode.
+var ErrUnsafe = errors.New("not allowed in safe mode")
+
+// DefaultMaxDepth is the nesting depth allowed when no explicit limit is set.
+// It is far beyond any hand-written document but keeps recursion on
+// malicious input (e.g. 1MB of "[") bounded.
+const DefaultMaxDepth = 10000
+
+// DocumentLimitError reports a stream holding more documents than allowed.
+// It wraps ErrExceeded.
+type DocumentLimitError struct {
+	Max  int // the configured maximum
+	Line int // 1-based line where the first document over the limit starts, or 0 if unknown
+}
+
+func (e *DocumentLimitError) Error() string {
+	msg := fmt.Sprintf("yaml: %v: more than %d documents", ErrExceeded, e.Max)
+	if e.Line > 0 {
+		msg += fmt.Sprintf(", next at line %d", e.Line)
+	}
+	return msg
+}
+
+func (e *DocumentLimitError) Unwrap() error { return ErrExceeded }
@@ -0,0 +1,26 @@
+package parser
+
+import "github.com/shapestone/shape-core/pkg/ast"
+
+// Anchors maps the nodes defined with an &anchor to the anchor's name.
+// Aliases resolve to the node they name, so an aliased node is the same
+// pointer as its anchored definition.
+type Anchors map[ast.SchemaNode]string
+
+// RecordAnchors makes subsequent parsing record the name of every anchored
+// node, and returns the map the names are recorded in.
+func (p *Parser) RecordAnchors() Anchors {
+	if p.anchorNames == nil {
+		p.anchorNames = make(Anchors)
+	}
+	return p.anchorNames
+}
+
+// ShareAnchorsAcrossDocuments makes an alias in a later document of a stream
+// resolve to an anchor defined in an earlier one, as this parser used to.
+// By default, as the YAML spec requires, ParseDocuments and ParseMultiDoc
+// forget a document's anchors once it is parsed, and such an alias is
+// undefined.
+func (p *Parser) ShareAnchorsAcrossDocuments() {
+	p.shareAnchors = true
+}
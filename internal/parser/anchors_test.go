@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+func TestRecordAnchors(t *testing.T) {
+	p := NewParser("base: &b {x: 1}\nother: &o 2\nuse: *b\nplain: 3")
+	anchors := p.RecordAnchors()
+	node, err := p.Parse()
+	assertNoError(t, err)
+	props := assertObjectNode(t, node).Properties()
+
+	if got := anchors[props["base"]]; got != "b" {
+		t.Errorf("anchor of base = %q, want b", got)
+	}
+	if got := anchors[props["other"]]; got != "o" {
+		t.Errorf("anchor of other = %q, want o", got)
+	}
+	// An alias is the anchored node itself
+	if props["use"] != props["base"] {
+		t.Error("alias *b does not resolve to the anchored node")
+	}
+	if _, ok := anchors[props["plain"]]; ok || len(anchors) != 2 {
+		t.Errorf("anchors = %v, want base and other only", anchors)
+	}
+}
+
+// plain converts a parsed node to Go values for comparison.
+func plain(node ast.SchemaNode) interface{} {
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		m := make(map[string]interface{}, len(n.Properties()))
+		for k, v := range n.Properties() {
+			m[k] = plain(v)
+		}
+		return m
+	case *ast.ArrayDataNode:
+		s := make([]interface{}, len(n.Elements()))
+		for i, v := range n.Elements() {
+			s[i] = plain(v)
+		}
+		return s
+	case *ast.LiteralNode:
+		return n.Value()
+	}
+	return nil
+}
+
+func TestAnchorChains(t *testing.T) {
+	type m = map[string]interface{}
+	a := []interface{}{int64(1)}
+	b := []interface{}{a, a}
+	c := []interface{}{b, a}
+	tests := []struct {
+		name  string
+		input string
+		key   string
+		want  interface{}
+	}{
+		{
+			name:  "flow merge of an alias",
+			input: "base1: &b1 {a: 1}\nbase2: &b2 {<<: *b1, extra: 1}\nuse: {<<: *b2, z: 2}",
+			key:   "use",
+			want:  m{"a": int64(1), "extra": int64(1), "z": int64(2)},
+		},
+		{
+			name:  "block merge of an alias",
+			input: "base1: &b1\n  a: 1\nbase2: &b2\n  <<: *b1\n  extra: 1\nuse:\n  <<: *b2\n  z: 2",
+			key:   "use",
+			want:  m{"a": int64(1), "extra": int64(1), "z": int64(2)},
+		},
+		{
+			name:  "alias of a chain",
+			input: "- &a [1]\n- &b [*a, *a]\n- &c [*b, *a]\n- *c",
+			want:  []interface{}{a, b, c, c},
+		},
+		{
+			name:  "explicit keys win wherever they are",
+			input: "a: &x {k: 1, j: 1}\nb: {j: 2, <<: *x, k: 3}",
+			key:   "b",
+			want:  m{"j": int64(2), "k": int64(3)},
+		},
+		{
+			name:  "earlier mappings of a merge sequence win",
+			input: "b1: &b1 {a: 1, b: 1}\nb2: &b2 {<<: *b1, b: 2}\nb3: {<<: [*b2, *b1], c: 3}",
+			key:   "b3",
+			want:  m{"a": int64(1), "b": int64(2), "c": int64(3)},
+		},
+		{
+			name:  "redefined anchor",
+			input: "a: &x {k: 1}\nb: {<<: *x}\nc: &x {k: 2}\nd: {<<: *x}",
+			want:  m{"a": m{"k": int64(1)}, "b": m{"k": int64(1)}, "c": m{"k": int64(2)}, "d": m{"k": int64(2)}},
+		},
+		{
+			name:  "anchor redefined within its own value",
+			input: "a: &x {b: &x 1}\nc: *x",
+			key:   "c",
+			want:  int64(1),
+		},
+		{
+			name:  "aliases of inner anchors",
+			input: "a: &outer {b: &inner 1}\nc: *inner\nd: *outer",
+			want:  m{"a": m{"b": int64(1)}, "c": int64(1), "d": m{"b": int64(1)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := NewParser(tt.input).Parse()
+			assertNoError(t, err)
+			got := plain(node)
+			if tt.key != "" {
+				got = got.(map[string]interface{})[tt.key]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnchorChainErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"a: &x [1, *x]", "alias *x refers to the node it is part of"},
+		{"a: &x {k: 1}\nb: &x {<<: *x, j: 2}", "alias *x refers to the node it is part of"},
+		{"a: *x\nb: &x 1", "undefined alias *x"},
+		{"a: &x [1, &y [2, *z]]\nz: &z 1", "undefined alias *z"},
+	}
+	for _, tt := range tests {
+		_, err := NewParser(tt.input).Parse()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestAnchorDocumentScope(t *testing.T) {
+	input := "base: &b 1\n---\ncopy: *b\n"
+
+	_, err := NewParser(input).ParseMultiDoc()
+	if err == nil || !strings.Contains(err.Error(), "undefined alias *b") {
+		t.Errorf("ParseMultiDoc = %v, want an undefined alias error", err)
+	}
+
+	// A document may reuse an earlier document's anchor name for its own node
+	docs, err := NewParser("a: &x 1\nb: *x\n---\nc: &x 2\nd: *x\n").ParseMultiDoc()
+	if err != nil {
+		t.Fatalf("ParseMultiDoc: %v", err)
+	}
+	if got := plain(docs[1]); !reflect.DeepEqual(got, map[string]interface{}{"c": int64(2), "d": int64(2)}) {
+		t.Errorf("second document = %v", got)
+	}
+
+	p := NewParser(input)
+	p.ShareAnchorsAcrossDocuments()
+	docs, err = p.ParseMultiDoc()
+	if err != nil {
+		t.Fatalf("ParseMultiDoc with shared anchors: %v", err)
+	}
+	if got := plain(docs[1]); !reflect.DeepEqual(got, map[string]interface{}{"copy": int64(1)}) {
+		t.Errorf("second document = %v, want copy: 1", got)
+	}
+}
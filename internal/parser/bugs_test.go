@@ -21,17 +21,17 @@ func TestBug1_SequenceOfMappings(t *testing.T) {
 		t.Fatalf("Bug 1: Expected successful parse, got error: %v", err)
 	}
 
-	obj := assertObjectNode(t, node)
-	assertPropertyCount(t, obj, 2)
+	seq := assertSequenceNode(t, node)
+	assertElementCount(t, seq, 2)
 
 	// Check first item
-	item0 := assertObjectNode(t, obj.Properties()["0"])
+	item0 := assertObjectNode(t, seq.Get(0))
 	assertPropertyCount(t, item0, 2)
 	assertLiteralValue(t, item0.Properties()["name"], "Alice")
 	assertLiteralValue(t, item0.Properties()["age"], int64(30))
 
 	// Check second item
-	item1 := assertObjectNode(t, obj.Properties()["1"])
+	item1 := assertObjectNode(t, seq.Get(1))
 	assertPropertyCount(t, item1, 2)
 	assertLiteralValue(t, item1.Properties()["name"], "Bob")
 	assertLiteralValue(t, item1.Properties()["age"], int64(25))
@@ -53,10 +53,10 @@ func TestBug2_ListsUnderKeys(t *testing.T) {
 	obj := assertObjectNode(t, node)
 	assertPropertyCount(t, obj, 1)
 
-	items := assertObjectNode(t, obj.Properties()["items"])
-	assertPropertyCount(t, items, 2)
-	assertLiteralValue(t, items.Properties()["0"], "apple")
-	assertLiteralValue(t, items.Properties()["1"], "banana")
+	items := assertSequenceNode(t, obj.Properties()["items"])
+	assertElementCount(t, items, 2)
+	assertLiteralValue(t, items.Get(0), "apple")
+	assertLiteralValue(t, items.Get(1), "banana")
 }
 
 // Bug 3: Empty values should parse with null value (not fail)
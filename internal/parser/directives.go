@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/shapestone/shape-yaml/internal/tokenizer"
@@ -20,6 +21,9 @@ import (
 //
 // Unknown directives are ignored per YAML spec.
 func (p *Parser) parseDirectives() error {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseDirectives"))
+	}
 	for {
 		token := p.peek()
 		if token == nil {
@@ -33,9 +37,13 @@ func (p *Parser) parseDirectives() error {
 
 		// Parse the directive
 		directiveText := strings.TrimSpace(token.ValueString())
+		if p.limits.SafeMode {
+			return fmt.Errorf("directive %s %w at %s", directiveText, ErrUnsafe, p.positionStr())
+		}
 		if err := p.processDirective(directiveText); err != nil {
 			return err
 		}
+		p.directives = append(p.directives, directiveText)
 
 		// Consume the directive token
 		p.advance()
@@ -116,9 +124,19 @@ func (p *Parser) processTAGDirective(params []string) error {
 	return nil
 }
 
+// Directives returns the directive lines of the document parsed last, or
+// being parsed, in order and as written, such as "%YAML 1.2" and
+// "%TAG !e! tag:example.com,2000:", including directives the parser does
+// not know. It returns nil for a document without directives.
+func (p *Parser) Directives() []string {
+	return p.directives
+}
+
 // resetDirectives resets directives to default state.
 // This is called at the start of each document in a multi-document stream.
 func (p *Parser) resetDirectives() {
+	p.directives = nil
+
 	// Reset to default YAML version
 	p.yamlVersion = "1.2"
 
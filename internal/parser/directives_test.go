@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
 )
 
 // TestDirectives_YAMLVersionDirective tests parsing of %YAML directive
@@ -66,6 +69,38 @@ func TestDirectives_YAMLVersionDirective(t *testing.T) {
 	}
 }
 
+// TestDirectives_LeadingZeroIntegers tests that integers written with a
+// leading zero resolve per the declared version: decimal under the YAML 1.2
+// core schema (the default), octal or string under YAML 1.1.
+func TestDirectives_LeadingZeroIntegers(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{"default is decimal", "v: 010", int64(10)},
+		{"default non-octal digits", "v: 09", int64(9)},
+		{"YAML 1.2 is decimal", "%YAML 1.2\n---\nv: 010", int64(10)},
+		{"YAML 1.1 is octal", "%YAML 1.1\n---\nv: 010", int64(8)},
+		{"YAML 1.1 negative octal", "%YAML 1.1\n---\nv: -017", int64(-15)},
+		{"YAML 1.1 non-octal digits stay a string", "%YAML 1.1\n---\nv: 09", "09"},
+		{"YAML 1.1 zero", "%YAML 1.1\n---\nv: 0", int64(0)},
+		{"YAML 1.1 decimal", "%YAML 1.1\n---\nv: 10", int64(10)},
+		{"YAML 1.1 without document marker", "%YAML 1.1\nv: 010", int64(8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			obj := assertObjectNode(t, node)
+			assertLiteralValue(t, obj.Properties()["v"], tt.want)
+		})
+	}
+}
+
 // TestDirectives_TagDirective tests parsing of %TAG directive
 func TestDirectives_TagDirective(t *testing.T) {
 	tests := []struct {
@@ -212,3 +247,29 @@ name: doc2`
 		t.Errorf("Expected YAML version 1.2, got %q", p.yamlVersion)
 	}
 }
+
+// TestDirectives_PerDocument tests that each document reports its own directives
+func TestDirectives_PerDocument(t *testing.T) {
+	input := "%YAML 1.1\n%TAG !e! tag:example.com,2000:\n---\na: yes\n" +
+		"---\nb: yes\n" +
+		"...\n%YAML 1.2\n---\nc: yes\n"
+
+	var got [][]string
+	p := NewParser(input)
+	err := p.ParseDocuments(func(ast.SchemaNode) error {
+		got = append(got, p.Directives())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseDocuments() error: %v", err)
+	}
+
+	want := [][]string{
+		{"%YAML 1.1", "%TAG !e! tag:example.com,2000:"},
+		nil,
+		{"%YAML 1.2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Directives() = %q, want %q", got, want)
+	}
+}
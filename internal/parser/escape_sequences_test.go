@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -33,6 +34,11 @@ func TestAdvancedEscapeSequences(t *testing.T) {
 		{`\\U00000041 (A)`, `value: "\U00000041"`, "A"},
 		{`\\U0001F600 (emoji)`, `value: "\U0001F600"`, "\U0001F600"}, // 😀
 		{`\\U00010000 (surrogate pair)`, `value: "\U00010000"`, "\U00010000"},
+
+		// UTF-16 surrogate pairs in \u escapes
+		{`\\uD83D\\uDE00 (emoji)`, `value: "\uD83D\uDE00"`, "\U0001F600"},
+		{`\\ud800\\udc00 (lowest pair)`, `value: "x\ud800\udc00y"`, "x\U00010000y"},
+		{`\\uDBFF\\uDFFF (highest pair)`, `value: "\uDBFF\uDFFF"`, "\U0010FFFF"},
 	}
 
 	for _, tt := range tests {
@@ -56,14 +62,127 @@ func TestEscapeSequencesInFlowSequences(t *testing.T) {
 	assertNoError(t, err)
 
 	obj := assertObjectNode(t, node)
-	values := assertObjectNode(t, obj.Properties()["values"])
+	values := assertSequenceNode(t, obj.Properties()["values"])
 
-	assertLiteralValue(t, values.Properties()["0"], "bell\a")
-	assertLiteralValue(t, values.Properties()["1"], "vtab\v")
-	assertLiteralValue(t, values.Properties()["2"], "esc\x1b")
-	assertLiteralValue(t, values.Properties()["3"], "nbsp\u00a0here")
+	assertLiteralValue(t, values.Get(0), "bell\a")
+	assertLiteralValue(t, values.Get(1), "vtab\v")
+	assertLiteralValue(t, values.Get(2), "esc\x1b")
+	assertLiteralValue(t, values.Get(3), "nbsp\u00a0here")
 }
 
 // Note: Invalid Unicode escape sequences (\U with incorrect number of hex digits)
 // will fail at the tokenizer level and prevent parsing.
 // This is compliant with YAML 1.2 which requires proper escape sequence formatting.
+
+// TestInvalidUnicodeEscapes tests that lone surrogates and out-of-range \U
+// escapes are rejected with the position of the scalar
+func TestInvalidUnicodeEscapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"lone high surrogate", `value: "\uD83D"`},
+		{"high surrogate before text", `value: "\uD83Dx"`},
+		{"two high surrogates", `value: "\uD83D\uD83D"`},
+		{"lone low surrogate", `value: "\uDE00"`},
+		{"reversed pair", `value: "\uDE00\uD83D"`},
+		{"surrogate in key", `"\uD83D": 1`},
+		{"surrogate in flow key", `{"\uD83D": 1}`},
+		{"\\U surrogate", `value: "\U0000D83D"`},
+		{"\\U above U+10FFFF", `value: "\U00110000"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser(tt.input).Parse()
+			if err == nil {
+				t.Fatalf("Parse(%q) expected error, got nil", tt.input)
+			}
+			if !strings.Contains(err.Error(), "invalid unicode escape") || !strings.Contains(err.Error(), "line 1") {
+				t.Errorf("Parse(%q) error = %q, want invalid unicode escape with position", tt.input, err)
+			}
+		})
+	}
+}
+
+// TestMultiLineDoubleQuoted tests line folding of double-quoted scalars
+// (YAML 1.2 section 7.3.1)
+func TestMultiLineDoubleQuoted(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"single break folds to space", "value: \"a\n  b\"", "a b"},
+		{"empty line becomes newline", "value: \"a\n\n  b\"", "a\nb"},
+		{"blank lines with indentation", "value: \"a\n  \n\n  b\"", "a\n\nb"},
+		{"surrounding white space trimmed", "value: \"a \t\n\t  b\"", "a b"},
+		{"leading and trailing space kept", "value: \"  a  \n  b  \"", "  a b  "},
+		{"escaped line break joins", "value: \"a\\\n   b\"", "ab"},
+		{"space before escaped break kept", "value: \"a \\\n  b\"", "a b"},
+		{"escaped space not trimmed", "value: \"a\\ \n  b\"", "a  b"},
+		{"escaped tab not trimmed", "value: \"a\\t\n  b\"", "a\t b"},
+		{"escaped newline before break", "value: \"a\\n\n  b\"", "a\n b"},
+		{"CRLF line breaks", "value: \"a\r\n  b\r\n\r\n  c\"", "a b\nc"},
+		{"spec example 7.5", "value: \"folded \nto a space,\t\n \nto a line feed, or \t\\\n \\ \tnon-content\"",
+			"folded to a space,\nto a line feed, or \t \tnon-content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.input)
+			node, err := p.Parse()
+			assertNoError(t, err)
+
+			obj := assertObjectNode(t, node)
+			assertLiteralValue(t, obj.Properties()["value"], tt.expected)
+		})
+	}
+}
+
+// TestMultiLineDoubleQuotedInFlowSequence tests folded scalars as flow sequence elements
+func TestMultiLineDoubleQuotedInFlowSequence(t *testing.T) {
+	input := "values: [\"a\n  b\", \"c\\\n  d\"]"
+
+	p := NewParser(input)
+	node, err := p.Parse()
+	assertNoError(t, err)
+
+	obj := assertObjectNode(t, node)
+	values := assertSequenceNode(t, obj.Properties()["values"])
+
+	assertLiteralValue(t, values.Get(0), "a b")
+	assertLiteralValue(t, values.Get(1), "cd")
+}
+
+// TestMultiLineSingleQuoted tests line folding of single-quoted scalars
+// (YAML 1.2 section 7.3.2)
+func TestMultiLineSingleQuoted(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"single break folds to space", "value: 'a\n  b'", "a b"},
+		{"empty line becomes newline", "value: 'a\n\n  b'", "a\nb"},
+		{"surrounding white space trimmed", "value: 'a \t\n\t  b'", "a b"},
+		{"quote escape across lines", "value: 'it''s\n  ''quoted'''", "it's 'quoted'"},
+		{"backslash is literal", "value: 'a\\\n  b'", "a\\ b"},
+		{"CRLF line breaks", "value: 'a\r\n  b'", "a b"},
+		{"spec example 7.9", "value: ' 1st non-empty\n\n 2nd non-empty \n\t3rd non-empty '",
+			" 1st non-empty\n2nd non-empty 3rd non-empty "},
+		{"description paragraph", "value: 'Returns the pet\n  with the given id.\n\n  Requires auth.'",
+			"Returns the pet with the given id.\nRequires auth."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.input)
+			node, err := p.Parse()
+			assertNoError(t, err)
+
+			obj := assertObjectNode(t, node)
+			assertLiteralValue(t, obj.Properties()["value"], tt.expected)
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package parser
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+// grammarFile is the grammar the parser implements. TestGrammar reads its
+// productions, so a production added to or removed from the grammar fails
+// the test until the corpus below follows.
+const grammarFile = "../../docs/grammar/yaml-1.2.ebnf"
+
+// grammarCase is an input and the value it parses to. A want of rejected
+// means the input must not parse; any other want is compared with the
+// parsed value, so a reject case may also show the input parsing as
+// something other than the production, as "0x" parses as a string and not
+// a HexNumber.
+type grammarCase struct {
+	input string
+	want  interface{}
+}
+
+// documents is the want of an input parsed as a stream.
+type documents []interface{}
+
+// rejected is the want of an input that must fail to parse.
+var rejected = &struct{ name string }{"rejected"}
+
+// grammarCorpus holds accept and reject cases for each production of the
+// grammar.
+var grammarCorpus = map[string]struct {
+	accept, reject []grammarCase
+}{
+	// Document structure
+	"Stream": {
+		accept: []grammarCase{{"---\nname: doc1\n---\nname: doc2", documents{m{"name": "doc1"}, m{"name": "doc2"}}}},
+		reject: []grammarCase{{"---\na: [1\n---\nb: 2", rejected}},
+	},
+	"Document": {
+		accept: []grammarCase{{"name: doc", m{"name": "doc"}}, {"", m{}}},
+		reject: []grammarCase{{"a: 1\n b: 2", rejected}},
+	},
+	"DocumentMarker": {
+		accept: []grammarCase{{"---\na: 1\n...\n", m{"a": int64(1)}}},
+		reject: []grammarCase{{"----\na: 1", rejected}},
+	},
+	"DocumentSeparator": {
+		accept: []grammarCase{{"a: 1\n---\nb: 2", documents{m{"a": int64(1)}, m{"b": int64(2)}}}},
+		reject: []grammarCase{{"a: 1\n--\nb: 2", rejected}},
+	},
+	"DirectiveLine": {
+		accept: []grammarCase{{"%YAML 1.2\n---\na: 1", m{"a": int64(1)}}},
+		reject: []grammarCase{{"a: 1\n%YAML 1.2\n---\nb: 2", rejected}},
+	},
+	"DirectiveName": {
+		accept: []grammarCase{{"%TAG ! tag:example.com,2000:\n---\na: 1", m{"a": int64(1)}}},
+		reject: []grammarCase{{"%\n---\na: 1", rejected}},
+	},
+	"DirectiveParameter": {
+		accept: []grammarCase{{"%YAML 1.1\n---\na: 1", m{"a": int64(1)}}},
+		reject: []grammarCase{{"%YAML 1.2 a: 1\n", m{}}},
+	},
+
+	// Nodes
+	"Node": {
+		accept: []grammarCase{{"a: &x !!str 1\nb: *x", m{"a": "1", "b": "1"}}},
+		reject: []grammarCase{{"a: &x *x", rejected}},
+	},
+	"BlockNode": {
+		accept: []grammarCase{{"a:\n  - 1\n  - b: 2", m{"a": s{int64(1), m{"b": int64(2)}}}}},
+		reject: []grammarCase{{"a:\n  - 1\n  b: 2", rejected}},
+	},
+	"FlowNode": {
+		accept: []grammarCase{{"{a: [1, {b: 2}]}", m{"a": s{int64(1), m{"b": int64(2)}}}}},
+		reject: []grammarCase{{"{a: [1, {b: 2}}", rejected}},
+	},
+
+	// Block mappings
+	"BlockMapping": {
+		accept: []grammarCase{{"name: Alice\nage: 30\naddress:\n  city: NYC\n  zip: 10001",
+			m{"name": "Alice", "age": int64(30), "address": m{"city": "NYC", "zip": int64(10001)}}}},
+		reject: []grammarCase{{"name: Alice\n  age: 30", rejected}},
+	},
+	"MappingEntry": {
+		accept: []grammarCase{{"a: 1 # one\nb:\n", m{"a": int64(1), "b": nil}}},
+		reject: []grammarCase{{"a:1", "a:1"}},
+	},
+	"Key": {
+		accept: []grammarCase{{"plain: 1\n\"double quoted\": 2\n'single quoted': 3",
+			m{"plain": int64(1), "double quoted": int64(2), "single quoted": int64(3)}}},
+		reject: []grammarCase{{"\"unclosed: 1", rejected}},
+	},
+	"ComplexKey": {
+		accept: []grammarCase{{"? a\n: 1", m{"a": int64(1)}}},
+		reject: []grammarCase{{"? [a\n: 1", rejected}},
+	},
+
+	// Block sequences
+	"BlockSequence": {
+		accept: []grammarCase{
+			{"- item1\n- item2\n- nested:\n    key: value", s{"item1", "item2", m{"nested": m{"key": "value"}}}},
+			{"- - 1\n  - 2\n- -\n  - 3", s{s{int64(1), int64(2)}, s{nil, int64(3)}}},
+		},
+		reject: []grammarCase{{"- a\nb: 1", rejected}, {"- - 1\n    - 2", rejected}},
+	},
+	"SequenceEntry": {
+		accept: []grammarCase{
+			{"- 1 # one\n-\n- - 2", s{int64(1), nil, s{int64(2)}}},
+			{"-", s{nil}},
+			{"- -", s{s{nil}}},
+			{"a: -x", m{"a": "-x"}},
+		},
+		reject: []grammarCase{{"-1", int64(-1)}, {"a: - 1", rejected}, {"a: -", rejected}, {"- a: -", rejected}, {"[-]", rejected}},
+	},
+
+	// Flow collections
+	"FlowMapping": {
+		accept: []grammarCase{{"{name: Alice, age: 30}", m{"name": "Alice", "age": int64(30)}}, {"{}", m{}}},
+		reject: []grammarCase{{"{name: Alice", rejected}},
+	},
+	"FlowMappingEntry": {
+		accept: []grammarCase{{"{\"quoted\": \"keys\", unquoted: values}", m{"quoted": "keys", "unquoted": "values"}}},
+		reject: []grammarCase{{"{a: 1,, b: 2}", rejected}},
+	},
+	"FlowSequence": {
+		accept: []grammarCase{{"[apple, banana, cherry]", s{"apple", "banana", "cherry"}}, {"[]", s{}}},
+		reject: []grammarCase{{"[1, 2", rejected}},
+	},
+
+	// Scalars
+	"Value": {
+		accept: []grammarCase{{"a: |\n  x\nb: [1]\nc: 2", m{"a": "x\n", "b": s{int64(1)}, "c": int64(2)}}},
+		reject: []grammarCase{{"a: ]", rejected}},
+	},
+	"Scalar": {
+		accept: []grammarCase{{"a: 'x'\nb: >\n  y\nc: z", m{"a": "x", "b": "y\n", "c": "z"}}},
+		reject: []grammarCase{{"a: \"x", rejected}},
+	},
+	"BlockScalar": {
+		accept: []grammarCase{{"|\n  a\n  b\n", "a\nb\n"}},
+		reject: []grammarCase{{"a: |x\n  b", rejected}},
+	},
+	"FlowScalar": {
+		accept: []grammarCase{{"hello world", "hello world"}, {"'hello world'", "hello world"}},
+		reject: []grammarCase{{"'hello world", rejected}},
+	},
+	"LiteralScalar": {
+		accept: []grammarCase{{"description: |\n  Line 1\n  Line 2\n  Line 3\n", m{"description": "Line 1\nLine 2\nLine 3\n"}}},
+		reject: []grammarCase{{"description: | Line 1\n", rejected}},
+	},
+	"FoldedScalar": {
+		accept: []grammarCase{{"summary: >\n  This is a long\n  sentence that spans\n  multiple lines.\n",
+			m{"summary": "This is a long sentence that spans multiple lines.\n"}}},
+		reject: []grammarCase{{"summary: > This is a long\n", rejected}},
+	},
+	"BlockChompIndicator": {
+		accept: []grammarCase{{"a: |-\n  x\n\nb: |+\n  y\n\nc: 1", m{"a": "x", "b": "y\n\n", "c": int64(1)}}},
+		reject: []grammarCase{{"a: |*\n  x", rejected}},
+	},
+	"BlockContent": {
+		accept: []grammarCase{{"a: |\n  x\n\n  y\n", m{"a": "x\n\ny\n"}}},
+		reject: []grammarCase{{"a: |\n  x\n y", rejected}},
+	},
+	"TextLine": {
+		accept: []grammarCase{{"a: |\n  key: value, [x]\n", m{"a": "key: value, [x]\n"}}},
+		reject: []grammarCase{{"a: |\nx", rejected}},
+	},
+	"QuotedScalar": {
+		accept: []grammarCase{{`"escaped: \"quotes\""`, `escaped: "quotes"`}, {"'single quotes'", "single quotes"}},
+		reject: []grammarCase{{`"escaped: \"quotes\"`, rejected}},
+	},
+	"DoubleQuotedScalar": {
+		accept: []grammarCase{{`"a\tb"`, "a\tb"}, {`""`, ""}},
+		reject: []grammarCase{{`"abc`, rejected}},
+	},
+	"DoubleQuotedChar": {
+		accept: []grammarCase{{`"héllo 'x' #y"`, "héllo 'x' #y"}},
+		reject: []grammarCase{{`"a\qb"`, rejected}},
+	},
+	"SingleQuotedScalar": {
+		accept: []grammarCase{{"'a\\tb'", `a\tb`}, {"''", ""}},
+		reject: []grammarCase{{"'abc", rejected}},
+	},
+	"SingleQuotedChar": {
+		accept: []grammarCase{{"'it''s'", "it's"}},
+		reject: []grammarCase{{"'it's'", rejected}},
+	},
+	"EscapeSequence": {
+		accept: []grammarCase{{`"\0\a\b\t\n\v\f\r\e\ \"\/\\\N\_\L\P"`,
+			"\x00\a\b\t\n\v\f\r\x1b \"/\\\u0085\u00a0\u2028\u2029"}},
+		reject: []grammarCase{{`"\q"`, rejected}},
+	},
+	"UnicodeEscape": {
+		accept: []grammarCase{{`"unicode: \u03B1\u03b2"`, "unicode: αβ"}},
+		reject: []grammarCase{{`"\u03G1"`, rejected}},
+	},
+	"Unicode32Escape": {
+		accept: []grammarCase{{`"\U0001F600"`, "\U0001F600"}},
+		reject: []grammarCase{{`"\U0001F60"`, rejected}},
+	},
+	"HexDigit": {
+		accept: []grammarCase{{"0x1aF", int64(0x1af)}, {`"\u00e9"`, "é"}},
+		reject: []grammarCase{{"0x1G", "0x1G"}},
+	},
+	"PlainScalar": {
+		accept: []grammarCase{{"hello", "hello"}, {"123", int64(123)}, {"true", true}, {"null", nil}},
+		reject: []grammarCase{{"@hello", rejected}},
+	},
+	"PlainString": {
+		accept: []grammarCase{{"hello world: it's-here", m{"hello world": "it's-here"}}},
+		reject: []grammarCase{{"hello: world", m{"hello": "world"}}},
+	},
+	"PlainStartChar": {
+		accept: []grammarCase{{"a-b", "a-b"}},
+		reject: []grammarCase{{"`a", rejected}},
+	},
+	"PlainChar": {
+		accept: []grammarCase{{"a b-c", "a b-c"}},
+		reject: []grammarCase{{"a #b", "a"}},
+	},
+
+	// Numbers
+	"Number": {
+		accept: []grammarCase{{"42", int64(42)}, {"3.14", 3.14}},
+		reject: []grammarCase{{"42abc", "42abc"}},
+	},
+	"DecimalNumber": {
+		accept: []grammarCase{{"-17", int64(-17)}, {"+5", int64(5)}, {"1.23e10", 1.23e10}},
+		reject: []grammarCase{{"1.2.3", "1.2.3"}},
+	},
+	"Integer": {
+		accept: []grammarCase{{"0", int64(0)}, {"1024", int64(1024)}},
+		reject: []grammarCase{{"1_024", "1_024"}},
+	},
+	"Digit": {
+		accept: []grammarCase{{"9876543210", int64(9876543210)}},
+		reject: []grammarCase{{"12a", "12a"}},
+	},
+	"Fraction": {
+		accept: []grammarCase{{"0.5", 0.5}},
+		reject: []grammarCase{{"3.x", "3.x"}},
+	},
+	"Exponent": {
+		accept: []grammarCase{{"1e3", 1e3}, {"2.5E-2", 2.5e-2}},
+		reject: []grammarCase{{"1e", "1e"}},
+	},
+	"HexNumber": {
+		accept: []grammarCase{{"0x1A2B", int64(0x1a2b)}},
+		reject: []grammarCase{{"0x", "0x"}},
+	},
+	"OctalNumber": {
+		accept: []grammarCase{{"0o755", int64(0o755)}},
+		reject: []grammarCase{{"0o", "0o"}},
+	},
+	"OctalDigit": {
+		accept: []grammarCase{{"0o17", int64(0o17)}},
+		reject: []grammarCase{{"0o19", "0o19"}},
+	},
+	"Boolean": {
+		accept: []grammarCase{{"true", true}, {"false", false}},
+		reject: []grammarCase{{"truthy", "truthy"}},
+	},
+	"Null": {
+		accept: []grammarCase{{"null", nil}, {"~", nil}},
+		reject: []grammarCase{{"nul", "nul"}},
+	},
+	"UnescapedChar": {
+		accept: []grammarCase{{`"a'b c"`, "a'b c"}},
+		reject: []grammarCase{{"\"a\\\"", rejected}},
+	},
+
+	// Anchors and aliases
+	"Anchor": {
+		accept: []grammarCase{{"defaults: &default\n  timeout: 30\nother: *default", m{"defaults": m{"timeout": int64(30)}, "other": m{"timeout": int64(30)}}}},
+		reject: []grammarCase{{"a: & 1", rejected}},
+	},
+	"AnchorName": {
+		accept: []grammarCase{{"a: &my-anchor_1 1\nb: *my-anchor_1", m{"a": int64(1), "b": int64(1)}}},
+		reject: []grammarCase{{"a: &x 1\nb: *y", rejected}},
+	},
+	"Alias": {
+		accept: []grammarCase{{"a: &x [1]\nb: *x", m{"a": s{int64(1)}, "b": s{int64(1)}}}},
+		reject: []grammarCase{{"b: *x", rejected}},
+	},
+	"MergeKey": {
+		accept: []grammarCase{{"base: &b {x: 1}\nuse:\n  <<: *b\n  y: 2", m{"base": m{"x": int64(1)}, "use": m{"x": int64(1), "y": int64(2)}}}},
+		reject: []grammarCase{{"use:\n  <<: *missing", rejected}},
+	},
+
+	// Tags
+	"Tag": {
+		accept: []grammarCase{{"!!int \"42\"", int64(42)}, {"!custom x", "x"}},
+		reject: []grammarCase{{"!!int abc", rejected}},
+	},
+	"TagSuffix": {
+		accept: []grammarCase{{"!!str 42", "42"}},
+		reject: []grammarCase{{"!! 42", rejected}},
+	},
+	"VerbatimTag": {
+		accept: []grammarCase{{"!<tag:example.com,2000:type> x", "x"}},
+		reject: []grammarCase{{"!<tag:yaml.org,2002:str 42", rejected}},
+	},
+
+	// Indentation and whitespace
+	"Indent": {
+		accept: []grammarCase{{"a:\n  b:\n      c: 1", m{"a": m{"b": m{"c": int64(1)}}}}},
+		reject: []grammarCase{{"a:\n  b: 1\n c: 2", rejected}},
+	},
+	"Newline": {
+		accept: []grammarCase{{"a: 1\r\nb: 2\r\n", m{"a": int64(1), "b": int64(2)}}},
+		reject: []grammarCase{{"a: 1\rb: 2", rejected}},
+	},
+	"Comment": {
+		accept: []grammarCase{{"# this is a comment\na: 1 # and this\n# and this", m{"a": int64(1)}}},
+		reject: []grammarCase{{"a: 1#c", m{"a": "1#c"}}},
+	},
+	"Whitespace": {
+		accept: []grammarCase{{"a:  \t1 \t\nb: [ 1 ,\t2 ]", m{"a": int64(1), "b": s{int64(1), int64(2)}}}},
+		reject: []grammarCase{{"a :1", "a :1"}},
+	},
+}
+
+type (
+	m = map[string]interface{}
+	s = []interface{}
+)
+
+// productionPattern matches the name of a production at the start of its
+// definition.
+var productionPattern = regexp.MustCompile(`(?m)^([A-Z][A-Za-z0-9]*)\s*=`)
+
+// grammarProductions returns the productions defined in the grammar file.
+func grammarProductions(t *testing.T) []string {
+	t.Helper()
+	data, err := os.ReadFile(grammarFile)
+	if err != nil {
+		t.Fatalf("reading grammar: %v", err)
+	}
+	var names []string
+	for _, match := range productionPattern.FindAllStringSubmatch(string(data), -1) {
+		names = append(names, match[1])
+	}
+	if len(names) == 0 {
+		t.Fatalf("no productions found in %s", grammarFile)
+	}
+	return names
+}
+
+// TestGrammar runs the accept and reject cases of each production in the
+// grammar, and fails if a production has no cases or the corpus names a
+// production the grammar does not define.
+func TestGrammar(t *testing.T) {
+	productions := grammarProductions(t)
+	defined := make(map[string]bool, len(productions))
+	for _, name := range productions {
+		defined[name] = true
+	}
+
+	var extra []string
+	for name := range grammarCorpus {
+		if !defined[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		t.Errorf("corpus has cases for %s, which %s does not define", name, grammarFile)
+	}
+
+	for _, name := range productions {
+		cases := grammarCorpus[name]
+		if len(cases.accept) == 0 || len(cases.reject) == 0 {
+			t.Errorf("production %s needs at least one accept and one reject case", name)
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			for _, c := range cases.accept {
+				if c.want == rejected {
+					t.Errorf("accept case %q expects an error", c.input)
+					continue
+				}
+				checkGrammarCase(t, "accept", c)
+			}
+			for _, c := range cases.reject {
+				checkGrammarCase(t, "reject", c)
+			}
+		})
+	}
+}
+
+func checkGrammarCase(t *testing.T, kind string, c grammarCase) {
+	t.Helper()
+	var got interface{}
+	var err error
+	if _, stream := c.want.(documents); stream {
+		docs, perr := NewParser(c.input).ParseMultiDoc()
+		values := make(documents, len(docs))
+		for i, doc := range docs {
+			values[i] = plain(doc)
+		}
+		got, err = values, perr
+	} else {
+		node, perr := NewParser(c.input).Parse()
+		if perr == nil {
+			got = plain(node)
+		}
+		err = perr
+	}
+
+	switch {
+	case c.want == rejected:
+		if err == nil {
+			t.Errorf("%s %q parsed to %#v, want an error", kind, c.input, got)
+		}
+	case err != nil:
+		t.Errorf("%s %q error = %v, want %#v", kind, c.input, err, c.want)
+	case !reflect.DeepEqual(got, c.want):
+		t.Errorf("%s %q = %#v, want %#v", kind, c.input, got, c.want)
+	}
+}
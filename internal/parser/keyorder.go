@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"sort"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+// KeyOrder maps each mapping node to its keys in document order. Keys merged
+// in with << follow the keys written in the mapping itself.
+type KeyOrder map[*ast.ObjectNode][]string
+
+// Keys returns the keys of the mapping node in document order. For a mapping
+// not from the recorded parse, such as one built by hand, the keys are
+// sorted; for other nodes, Keys returns nil.
+func (o KeyOrder) Keys(node ast.SchemaNode) []string {
+	obj, ok := node.(*ast.ObjectNode)
+	if !ok {
+		return nil
+	}
+	if keys, ok := o[obj]; ok {
+		return keys
+	}
+	keys := make([]string, 0, len(obj.Properties()))
+	for k := range obj.Properties() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RecordKeyOrder makes subsequent parsing record the order of the keys of
+// every mapping, and returns the map the orders are recorded in. The AST
+// itself stores mappings as Go maps, which do not keep order.
+func (p *Parser) RecordKeyOrder() KeyOrder {
+	if p.keyOrder == nil {
+		p.keyOrder = make(KeyOrder)
+	}
+	return p.keyOrder
+}
+
+// addKey appends key to keys, the keys of one mapping so far, when key order
+// is recorded.
+func (p *Parser) addKey(keys []string, key string) []string {
+	if p.keyOrder == nil {
+		return keys
+	}
+	return append(keys, key)
+}
+
+// saveKeyOrder records keys, as built by addKey, for node.
+func (p *Parser) saveKeyOrder(node *ast.ObjectNode, keys []string) {
+	if p.keyOrder != nil {
+		if keys == nil {
+			keys = []string{}
+		}
+		p.keyOrder[node] = keys
+	}
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordKeyOrder(t *testing.T) {
+	input := "zeta: 1\nalpha: {y: 1, x: 2}\nbase: &b {n: 1, m: 2}\nmerged:\n  <<: *b\n  z: 3\n  n: 4\nempty: {}\ncomplex:\n  ? b\n  : 1\n  ? a\n  : 2\n"
+
+	p := NewParser(input)
+	order := p.RecordKeyOrder()
+	node, err := p.Parse()
+	assertNoError(t, err)
+
+	root := assertObjectNode(t, node)
+	props := root.Properties()
+
+	tests := []struct {
+		name string
+		keys []string
+	}{
+		{"root", []string{"zeta", "alpha", "base", "merged", "empty", "complex"}},
+		{"alpha", []string{"y", "x"}},
+		{"merged", []string{"z", "n", "m"}}, // merged keys follow, explicit ones win
+		{"empty", []string{}},
+		{"complex", []string{"b", "a"}},
+	}
+	for _, tt := range tests {
+		n := node
+		if tt.name != "root" {
+			n = props[tt.name]
+		}
+		if got := order.Keys(n); !reflect.DeepEqual(got, tt.keys) {
+			t.Errorf("Keys(%s) = %q, want %q", tt.name, got, tt.keys)
+		}
+	}
+
+	if got := order.Keys(props["zeta"]); got != nil {
+		t.Errorf("Keys(scalar) = %q, want nil", got)
+	}
+}
+
+func TestKeyOrderUnrecordedMappingSorted(t *testing.T) {
+	node, err := NewParser("b: 1\na: 2").Parse()
+	assertNoError(t, err)
+
+	var order KeyOrder
+	if got, want := order.Keys(node), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %q, want %q", got, want)
+	}
+}
+
+func TestKeyOrderNotRecordedByDefault(t *testing.T) {
+	p := NewParser("a: {b: 1}")
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if p.keyOrder != nil {
+		t.Errorf("keyOrder = %v, want nil unless RecordKeyOrder is called", p.keyOrder)
+	}
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+// KeySpan is the source extent of a mapping key as written, quotes included.
+// Offsets count runes, like the offsets of token positions.
+type KeySpan struct {
+	Pos ast.Position // start of the key
+	End int          // offset just past the key
+}
+
+// KeySpans maps each mapping node to the spans of its keys. Keys merged in
+// with << and complex keys introduced by ? have no span.
+type KeySpans map[*ast.ObjectNode]map[string]KeySpan
+
+// RecordKeySpans makes subsequent parsing record where each mapping key is
+// written, and returns the map the spans are recorded in. The AST itself only
+// carries the positions of values.
+func (p *Parser) RecordKeySpans() KeySpans {
+	if p.keySpans == nil {
+		p.keySpans = make(KeySpans)
+	}
+	return p.keySpans
+}
+
+// newKeySpans returns a map for the key spans of one mapping, or nil when
+// spans are not being recorded.
+func (p *Parser) newKeySpans() map[string]KeySpan {
+	if p.keySpans == nil {
+		return nil
+	}
+	return make(map[string]KeySpan)
+}
+
+// saveKeySpans records spans, as returned by newKeySpans, for node.
+func (p *Parser) saveKeySpans(node *ast.ObjectNode, spans map[string]KeySpan) {
+	if spans != nil {
+		p.keySpans[node] = spans
+	}
+}
+
+// keySpan returns the span of a key token with raw text raw at pos.
+func keySpan(pos ast.Position, raw string) KeySpan {
+	return KeySpan{Pos: pos, End: pos.Offset + utf8.RuneCountInString(raw)}
+}
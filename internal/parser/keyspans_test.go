@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+func TestRecordKeySpans(t *testing.T) {
+	input := "name: api\n\"quoted key\": 1\nflow: {a: 1, 'b': 2}\nbase: &b {x: 1}\nmerged:\n  <<: *b\n  ключ: y\n"
+
+	p := NewParser(input)
+	spans := p.RecordKeySpans()
+	node, err := p.Parse()
+	assertNoError(t, err)
+
+	root := assertObjectNode(t, node)
+	flow := assertObjectNode(t, root.Properties()["flow"])
+	merged := assertObjectNode(t, root.Properties()["merged"])
+
+	tests := []struct {
+		obj        *ast.ObjectNode
+		key        string
+		start, end int
+		line, col  int
+	}{
+		{root, "name", 0, 4, 1, 1},
+		{root, "quoted key", 10, 22, 2, 1},
+		{root, "flow", 26, 30, 3, 1},
+		{flow, "a", 33, 34, 3, 8},
+		{flow, "'b'", 0, 0, 0, 0}, // spans are keyed by the unquoted key
+		{flow, "b", 39, 42, 3, 14},
+		{merged, "ключ", 82, 86, 7, 3}, // offsets count runes
+		{merged, "x", 0, 0, 0, 0},      // merged keys have no span
+	}
+	for _, tt := range tests {
+		span, ok := spans[tt.obj][tt.key]
+		if tt.line == 0 {
+			if ok {
+				t.Errorf("span[%q] = %+v, want none", tt.key, span)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("span[%q] missing", tt.key)
+			continue
+		}
+		if span.Pos.Offset != tt.start || span.End != tt.end || span.Pos.Line != tt.line || span.Pos.Column != tt.col {
+			t.Errorf("span[%q] = %+v, want offsets %d-%d at line %d, column %d",
+				tt.key, span, tt.start, tt.end, tt.line, tt.col)
+		}
+	}
+}
+
+func TestKeySpansNotRecordedByDefault(t *testing.T) {
+	p := NewParser("a: {b: 1}")
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if p.keySpans != nil {
+		t.Errorf("keySpans = %v, want nil unless RecordKeySpans is called", p.keySpans)
+	}
+}
@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/shapestone/shape-yaml/internal/limits"
+)
+
+// ErrLimitExceeded is wrapped by errors reporting that the input exceeded
+// one of the configured Limits. It is shared with the fast parser.
+var ErrLimitExceeded = limits.ErrExceeded
+
+// ErrUnsafe is wrapped by errors reporting a construct that Limits.SafeMode
+// disallows. It is shared with the fast parser.
+var ErrUnsafe = limits.ErrUnsafe
+
+// Limits bounds the work a parser may do on untrusted input.
+// A zero field means no limit. New parsers start with a MaxDepth of
+// limits.DefaultMaxDepth; SetLimits replaces it.
+type Limits struct {
+	// MaxDepth is the maximum node nesting depth. A top-level scalar has
+	// depth 1 and each enclosing collection adds one level.
+	MaxDepth int
+
+	// MaxNodes is the maximum number of nodes (scalars, collections, and
+	// aliases) across all documents parsed by the parser.
+	MaxNodes int
+
+	// MaxDocuments is the maximum number of documents in a stream parsed by
+	// ParseDocuments or ParseMultiDoc. Exceeding it fails with a
+	// *limits.DocumentLimitError.
+	MaxDocuments int
+
+	// MaxAnchors is the maximum number of distinct anchor names defined in
+	// a document, or in the whole stream after ShareAnchorsAcrossDocuments.
+	MaxAnchors int
+
+	// MaxNameLength is the maximum length in bytes of an anchor or alias
+	// name.
+	MaxNameLength int
+
+	// MaxAliases is the maximum number of aliases across all documents
+	// parsed by the parser.
+	MaxAliases int
+
+	// MaxKeyLength is the maximum length in bytes of a mapping key. A key
+	// written as a collection is measured in its stringified form.
+	MaxKeyLength int
+
+	// SafeMode rejects anchors, aliases, directives, and tags other than
+	// the core schema tags (!!str, !!int, !!float, !!bool, !!null, !!map,
+	// !!seq), restricting input to plain data. The errors wrap ErrUnsafe.
+	SafeMode bool
+}
+
+// SetLimits configures resource limits for subsequent parsing.
+func (p *Parser) SetLimits(l Limits) {
+	p.limits = l
+}
+
+// enterNode records the start of a node and enforces the configured limits.
+// Every successful call must be paired with leaveNode.
+func (p *Parser) enterNode() error {
+	p.nodeCount++
+	if p.limits.MaxNodes > 0 && p.nodeCount > p.limits.MaxNodes {
+		return fmt.Errorf("%w: more than %d nodes at %s", ErrLimitExceeded, p.limits.MaxNodes, p.positionStr())
+	}
+	if p.limits.MaxDepth > 0 && p.depth >= p.limits.MaxDepth {
+		return fmt.Errorf("%w: nesting deeper than %d at %s", ErrLimitExceeded, p.limits.MaxDepth, p.positionStr())
+	}
+	p.depth++
+	if p.depth > p.deepest {
+		p.deepest = p.depth
+	}
+	return nil
+}
+
+// Stats reports the number of nodes parsed so far, as MaxNodes counts them,
+// and the deepest nesting reached, as MaxDepth measures it.
+func (p *Parser) Stats() (nodes, depth int) {
+	return p.nodeCount, p.deepest
+}
+
+// checkAnchor enforces MaxNameLength on the name of an anchor or alias,
+// MaxAliases on an alias, and MaxAnchors on an anchor (define) that
+// introduces a new name.
+func (p *Parser) checkAnchor(name string, define bool) error {
+	if p.limits.SafeMode {
+		if define {
+			return fmt.Errorf("anchor &%s %w at %s", name, ErrUnsafe, p.positionStr())
+		}
+		return fmt.Errorf("alias *%s %w at %s", name, ErrUnsafe, p.positionStr())
+	}
+	if p.limits.MaxNameLength > 0 && len(name) > p.limits.MaxNameLength {
+		return fmt.Errorf("%w: anchor name longer than %d bytes at %s", ErrLimitExceeded, p.limits.MaxNameLength, p.positionStr())
+	}
+	if !define {
+		p.aliasCount++
+		if p.limits.MaxAliases > 0 && p.aliasCount > p.limits.MaxAliases {
+			return fmt.Errorf("%w: more than %d aliases at %s", ErrLimitExceeded, p.limits.MaxAliases, p.positionStr())
+		}
+		return nil
+	}
+	if p.limits.MaxAnchors <= 0 {
+		return nil
+	}
+	if _, exists := p.anchors[name]; !exists && len(p.anchors) >= p.limits.MaxAnchors {
+		return fmt.Errorf("%w: more than %d anchors at %s", ErrLimitExceeded, p.limits.MaxAnchors, p.positionStr())
+	}
+	return nil
+}
+
+// checkTag enforces SafeMode on a tag, as written in the source.
+func (p *Parser) checkTag(tag string) error {
+	if !p.limits.SafeMode {
+		return nil
+	}
+	switch p.expandTag(tag) {
+	case NullTag, BoolTag, IntTag, FloatTag, StrTag, MapTag, SeqTag:
+		return nil
+	}
+	return fmt.Errorf("tag %s %w at %s", tag, ErrUnsafe, p.positionStr())
+}
+
+// checkKey enforces MaxKeyLength on a mapping key.
+func (p *Parser) checkKey(key string) error {
+	if p.limits.MaxKeyLength > 0 && len(key) > p.limits.MaxKeyLength {
+		return fmt.Errorf("%w: mapping key longer than %d bytes", ErrLimitExceeded, p.limits.MaxKeyLength)
+	}
+	return nil
+}
+
+// leaveNode records the end of a node started with enterNode.
+func (p *Parser) leaveNode() {
+	p.depth--
+}
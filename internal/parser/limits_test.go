@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/limits"
+)
+
+func TestParserLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		limits  Limits
+		wantErr bool
+	}{
+		{"scalar at depth 1", "hello", Limits{MaxDepth: 1}, false},
+		{"mapping needs depth 2", "a: 1", Limits{MaxDepth: 1}, true},
+		{"nested mapping within depth", "a:\n  b: 1", Limits{MaxDepth: 3}, false},
+		{"nested mapping too deep", "a:\n  b: 1", Limits{MaxDepth: 2}, true},
+		{"flow sequence within depth", "[1, [2]]", Limits{MaxDepth: 3}, false},
+		{"flow sequence too deep", "[1, [2]]", Limits{MaxDepth: 2}, true},
+		{"node count within limit", "a: 1\nb: 2", Limits{MaxNodes: 3}, false},
+		{"node count exceeded", "a: 1\nb: 2", Limits{MaxNodes: 2}, true},
+		{"zero limits are unlimited", "a:\n  b:\n    c: [1, 2, 3]", Limits{}, false},
+		{"anchors within limit", "a: &x 1\nb: &y 2\nc: &x 3\nd: *x", Limits{MaxAnchors: 2}, false},
+		{"too many anchors", "a: &x 1\nb: &y 2\nc: &z 3", Limits{MaxAnchors: 2}, true},
+		{"too many anchors in flow", "[&a 1, &b 2, &c 3]", Limits{MaxAnchors: 2}, true},
+		{"anchor name within limit", "a: &abc 1\nb: *abc", Limits{MaxNameLength: 3}, false},
+		{"anchor name too long", "a: &abcd 1", Limits{MaxNameLength: 3}, true},
+		{"alias name too long", "a: *abcd", Limits{MaxNameLength: 3}, true},
+		{"aliases within limit", "a: &x 1\nb: *x\nc: *x", Limits{MaxAliases: 2}, false},
+		{"too many aliases", "a: &x 1\nb: *x\nc: [*x, *x]", Limits{MaxAliases: 2}, true},
+		{"key within limit", "abc: 1\n'def': 2", Limits{MaxKeyLength: 3}, false},
+		{"key too long", "abcd: 1", Limits{MaxKeyLength: 3}, true},
+		{"quoted key too long", "\"abcd\": 1", Limits{MaxKeyLength: 3}, true},
+		{"flow key too long", "{a: 1, abcd: 2}", Limits{MaxKeyLength: 3}, true},
+		{"complex key too long", "? [a, b]\n: 1", Limits{MaxKeyLength: 3}, true},
+		{"long value is not a key", "a: abcdef", Limits{MaxKeyLength: 3}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.input)
+			p.SetLimits(tt.limits)
+			_, err := p.Parse()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("Parse() error = %v, want ErrLimitExceeded", err)
+			}
+		})
+	}
+}
+
+func TestParseDocuments(t *testing.T) {
+	p := NewParser("a: 1\n---\nb: 2\n---\nc: 3")
+
+	var seen int
+	stop := errors.New("stop")
+	err := p.ParseDocuments(func(doc ast.SchemaNode) error {
+		seen++
+		if seen == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("ParseDocuments() error = %v, want %v", err, stop)
+	}
+	if seen != 2 {
+		t.Errorf("callback called %d times, want 2", seen)
+	}
+}
+
+func TestParseDocuments_NodeLimitSpansDocuments(t *testing.T) {
+	p := NewParser("a: 1\n---\nb: 2\n---\nc: 3")
+	p.SetLimits(Limits{MaxNodes: 5})
+
+	err := p.ParseDocuments(func(ast.SchemaNode) error { return nil })
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("ParseDocuments() error = %v, want ErrLimitExceeded", err)
+	}
+}
+
+func TestParseDocuments_DocumentLimit(t *testing.T) {
+	p := NewParser("a: 1\n---\nb: 2\n---\nc: 3")
+	p.SetLimits(Limits{MaxDocuments: 2})
+
+	var seen int
+	err := p.ParseDocuments(func(ast.SchemaNode) error {
+		seen++
+		return nil
+	})
+	var limitErr *limits.DocumentLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("ParseDocuments() error = %v, want *DocumentLimitError", err)
+	}
+	if limitErr.Max != 2 || limitErr.Line != 4 {
+		t.Errorf("DocumentLimitError = %+v, want Max 2 at line 4", limitErr)
+	}
+	if seen != 2 {
+		t.Errorf("callback called %d times, want 2", seen)
+	}
+}
+
+func TestParseDocuments_AnchorLimitPerDocument(t *testing.T) {
+	input := "a: &x 1\nb: &y 2\n---\nc: &z 3\nd: &w 4\n"
+	p := NewParser(input)
+	p.SetLimits(Limits{MaxAnchors: 2})
+	if _, err := p.ParseMultiDoc(); err != nil {
+		t.Fatalf("ParseMultiDoc() error = %v, want each document within MaxAnchors", err)
+	}
+
+	p = NewParser(input)
+	p.SetLimits(Limits{MaxAnchors: 2})
+	p.ShareAnchorsAcrossDocuments()
+	if _, err := p.ParseMultiDoc(); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("ParseMultiDoc() with shared anchors error = %v, want ErrLimitExceeded", err)
+	}
+}
+
+func TestParserDefaultMaxDepth(t *testing.T) {
+	deep := strings.Repeat("[", limits.DefaultMaxDepth+1) + strings.Repeat("]", limits.DefaultMaxDepth+1)
+
+	if _, err := NewParser(deep).Parse(); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Parse() error = %v, want ErrLimitExceeded", err)
+	}
+
+	p := NewParser(deep)
+	p.SetLimits(Limits{})
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("Parse() with limits cleared error = %v", err)
+	}
+}
+
+func TestParserSafeMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"plain data", "a: 1\nb: [x, {c: true}]\n", ""},
+		{"core tags", "a: !!str 1\nb: !!int \"2\"\nc: !!seq [x]\nd: !<tag:yaml.org,2002:bool> true\n", ""},
+		{"anchor", "a: &base 1\n", "anchor &base not allowed in safe mode at line 1, column 4"},
+		{"alias", "a: 1\nb: [x, *base]\n", "alias *base not allowed in safe mode at line 2, column 8"},
+		{"custom tag", "a: !Point {x: 1}\n", "tag !Point not allowed in safe mode at line 1, column 4"},
+		{"non-core tag", "a: !!binary aGk=\n", "tag !!binary not allowed in safe mode"},
+		{"yaml directive", "%YAML 1.2\n---\na: 1\n", "directive %YAML 1.2 not allowed in safe mode at line 1, column 1"},
+		{"tag directive", "%TAG !e! tag:example.com,2000:\n---\na: 1\n", "directive %TAG"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.input)
+			p.SetLimits(Limits{SafeMode: true})
+			_, err := p.Parse()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnsafe) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want ErrUnsafe with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
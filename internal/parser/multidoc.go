@@ -2,6 +2,7 @@ package parser
 
 import (
 	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/limits"
 	"github.com/shapestone/shape-yaml/internal/tokenizer"
 )
 
@@ -29,10 +30,40 @@ import (
 // Returns: []ast.SchemaNode{doc1_node, doc2_node}
 func (p *Parser) ParseMultiDoc() ([]ast.SchemaNode, error) {
 	var documents []ast.SchemaNode
+	err := p.ParseDocuments(func(doc ast.SchemaNode) error {
+		documents = append(documents, doc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+// ParseDocuments parses a YAML stream like ParseMultiDoc, but passes each
+// document to fn as soon as it is parsed instead of collecting them, so that
+// callers can process or discard documents one at a time.
+// If fn returns an error, parsing stops and that error is returned.
+func (p *Parser) ParseDocuments(fn func(ast.SchemaNode) error) error {
+	p.start()
+	documents := 0
+	line := 0 // where the document being parsed starts, at its "---" if any
+	emit := func(doc ast.SchemaNode) error {
+		if p.limits.MaxDocuments > 0 && documents >= p.limits.MaxDocuments {
+			return &limits.DocumentLimitError{Max: p.limits.MaxDocuments, Line: line}
+		}
+		documents++
+		line = 0
+		// Anchors are scoped to their document
+		if !p.shareAnchors {
+			clear(p.anchors)
+		}
+		return fn(doc)
+	}
 
 	// Parse directives at the beginning of the stream
 	if err := p.parseDirectives(); err != nil {
-		return nil, err
+		return p.withHint(err)
 	}
 
 	// Skip leading whitespace and comments
@@ -40,29 +71,39 @@ func (p *Parser) ParseMultiDoc() ([]ast.SchemaNode, error) {
 
 	// Handle empty stream
 	if p.peek() == nil || !p.hasToken {
-		return documents, nil
+		return nil
 	}
 
 	// Skip initial document separator if present
 	if p.peek() != nil && p.peek().Kind() == tokenizer.TokenDocSep {
+		line = p.position().Line
 		p.advance()
 		p.skipWhitespaceAndComments()
 	}
 
 	for {
+		if line == 0 {
+			line = p.position().Line
+		}
+
 		// Check if we're at a separator or end marker (indicates empty document)
 		token := p.peek()
 		if token != nil && p.hasToken {
 			if token.Kind() == tokenizer.TokenDocSep {
 				// Empty document before this separator
-				documents = append(documents, ast.NewObjectNode(make(map[string]ast.SchemaNode), ast.ZeroPosition()))
+				if err := emit(ast.NewObjectNode(make(map[string]ast.SchemaNode), ast.ZeroPosition())); err != nil {
+					return err
+				}
+				line = p.position().Line
 				p.advance()
 				p.skipWhitespaceAndComments()
 				continue
 			}
 			if token.Kind() == tokenizer.TokenDocEnd {
 				// Empty document, stream ends
-				documents = append(documents, ast.NewObjectNode(make(map[string]ast.SchemaNode), ast.ZeroPosition()))
+				if err := emit(ast.NewObjectNode(make(map[string]ast.SchemaNode), ast.ZeroPosition())); err != nil {
+					return err
+				}
 				break
 			}
 		}
@@ -70,21 +111,28 @@ func (p *Parser) ParseMultiDoc() ([]ast.SchemaNode, error) {
 		// Check for end of stream
 		if token == nil || !p.hasToken {
 			// If we have no documents yet, this is an empty stream
-			if len(documents) == 0 {
+			if documents == 0 {
 				break
 			}
 			// Otherwise, there's one more empty document
-			documents = append(documents, ast.NewObjectNode(make(map[string]ast.SchemaNode), ast.ZeroPosition()))
+			if err := emit(ast.NewObjectNode(make(map[string]ast.SchemaNode), ast.ZeroPosition())); err != nil {
+				return err
+			}
 			break
 		}
 
 		// Parse one document
 		doc, err := p.parseDocumentContent()
+		if uerr := p.unmatchedInput(); uerr != nil {
+			err = uerr
+		}
 		if err != nil {
-			return nil, err
+			return p.withHint(err)
 		}
 
-		documents = append(documents, doc)
+		if err := emit(doc); err != nil {
+			return err
+		}
 
 		// Skip whitespace and comments after the document
 		p.skipWhitespaceAndComments()
@@ -97,7 +145,10 @@ func (p *Parser) ParseMultiDoc() ([]ast.SchemaNode, error) {
 		}
 
 		if token.Kind() == tokenizer.TokenDocSep {
-			// --- separator - another document follows
+			// --- separator - another document follows, without directives
+			// of its own, since those must come after a ... marker
+			line = p.position().Line
+			p.resetDirectives()
 			p.advance()
 			p.skipWhitespaceAndComments()
 			// Continue to parse next document
@@ -105,14 +156,21 @@ func (p *Parser) ParseMultiDoc() ([]ast.SchemaNode, error) {
 		}
 
 		if token.Kind() == tokenizer.TokenDocEnd {
-			// ... end marker - document stream ends
+			// ... end marker - the next document, if any, may start with
+			// directives
 			p.advance()
 			p.skipWhitespaceAndComments()
+			p.resetDirectives()
+			if err := p.parseDirectives(); err != nil {
+				return p.withHint(err)
+			}
+			p.skipWhitespaceAndComments()
 
 			// Check if there's another document after the end marker
 			token = p.peek()
 			if token != nil && p.hasToken && token.Kind() == tokenizer.TokenDocSep {
 				// Another document follows
+				line = p.position().Line
 				p.advance()
 				p.skipWhitespaceAndComments()
 				continue
@@ -126,13 +184,19 @@ func (p *Parser) ParseMultiDoc() ([]ast.SchemaNode, error) {
 		break
 	}
 
-	return documents, nil
+	if err := p.unmatchedInput(); err != nil {
+		return p.withHint(err)
+	}
+	return nil
 }
 
 // parseDocumentContent parses the content of a single YAML document.
 // This is similar to parseNode() but handles DEDENT tokens afterward.
 // It does NOT consume document separators (---) or end markers (...).
 func (p *Parser) parseDocumentContent() (ast.SchemaNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseDocumentContent"))
+	}
 	// Parse any directives for this document
 	if err := p.parseDirectives(); err != nil {
 		return nil, err
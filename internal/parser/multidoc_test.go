@@ -232,12 +232,12 @@ name: mapping
 	}
 
 	// Second: sequence
-	doc2, ok := docs[1].(*ast.ObjectNode)
+	doc2, ok := docs[1].(*ast.ArrayDataNode)
 	if !ok {
-		t.Fatalf("Expected second document to be ObjectNode (sequence), got: %T", docs[1])
+		t.Fatalf("Expected second document to be ArrayDataNode (sequence), got: %T", docs[1])
 	}
-	if len(doc2.Properties()) != 3 {
-		t.Errorf("Expected sequence with 3 items, got: %d", len(doc2.Properties()))
+	if doc2.Len() != 3 {
+		t.Errorf("Expected sequence with 3 items, got: %d", doc2.Len())
 	}
 
 	// Third: scalar (quoted string)
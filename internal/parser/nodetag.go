@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"strings"
+	"time"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+// The core schema tags, in the full form Tags.Of reports them.
+const (
+	NullTag  = "tag:yaml.org,2002:null"
+	BoolTag  = "tag:yaml.org,2002:bool"
+	IntTag   = "tag:yaml.org,2002:int"
+	FloatTag = "tag:yaml.org,2002:float"
+	StrTag   = "tag:yaml.org,2002:str"
+	MapTag   = "tag:yaml.org,2002:map"
+	SeqTag   = "tag:yaml.org,2002:seq"
+)
+
+// TimestampTag is the tag of !!timestamp scalars, whose value is a
+// time.Time. Timestamps are not part of the core schema, so no untagged
+// scalar resolves to one.
+const TimestampTag = "tag:yaml.org,2002:timestamp"
+
+// The tags of the other YAML 1.1 types the parser applies when a node is
+// written with one: !!binary scalars are base64 and decode to a []byte, a
+// !!set is a mapping whose values are all null, and an !!omap a sequence of
+// single-entry mappings.
+const (
+	BinaryTag = "tag:yaml.org,2002:binary"
+	SetTag    = "tag:yaml.org,2002:set"
+	OmapTag   = "tag:yaml.org,2002:omap"
+)
+
+// Tags maps the nodes written with an explicit tag to that tag, with its
+// handle expanded: !!int is recorded as tag:yaml.org,2002:int, a local !Point
+// as !Point, and !e!x under "%TAG !e! tag:example.com,2000:" as
+// tag:example.com,2000:x.
+type Tags map[ast.SchemaNode]string
+
+// Of returns the resolved tag of node: its explicit tag if it has one, and
+// otherwise the core schema tag its value resolved to, so that 8080 reports
+// IntTag and "8080" StrTag. Of returns "" for nil.
+func (t Tags) Of(node ast.SchemaNode) string {
+	if node == nil {
+		return ""
+	}
+	if tag, ok := t[node]; ok {
+		return tag
+	}
+	return implicitTag(node)
+}
+
+// implicitTag returns the core schema tag of an untagged node.
+func implicitTag(node ast.SchemaNode) string {
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		if IsEmptyDocument(n) {
+			return NullTag
+		}
+		return MapTag
+	case *ast.ArrayDataNode:
+		return SeqTag
+	case *ast.LiteralNode:
+		switch n.Value().(type) {
+		case nil:
+			return NullTag
+		case bool:
+			return BoolTag
+		case int64, uint64:
+			return IntTag
+		case float64:
+			return FloatTag
+		case time.Time:
+			return TimestampTag
+		case []byte:
+			return BinaryTag
+		}
+	}
+	return StrTag
+}
+
+// RecordTags makes subsequent parsing record the explicit tag of every
+// tagged node, and returns the map the tags are recorded in.
+func (p *Parser) RecordTags() Tags {
+	if p.tags == nil {
+		p.tags = make(Tags)
+	}
+	return p.tags
+}
+
+// recordTag records tag, as written in the source, for node when tags are
+// recorded.
+func (p *Parser) recordTag(node ast.SchemaNode, tag string) ast.SchemaNode {
+	if p.tags != nil {
+		p.tags[node] = p.expandTag(tag)
+	}
+	return node
+}
+
+// expandTag resolves the handle of a tag against the %TAG directives in
+// effect: !<uri> is uri, and !!x, !x and !name!x are the handle's prefix
+// followed by x. Tags with an undeclared named handle are kept as written.
+func (p *Parser) expandTag(tag string) string {
+	if strings.HasPrefix(tag, "!<") && strings.HasSuffix(tag, ">") {
+		return tag[2 : len(tag)-1]
+	}
+	handle, suffix := "!", tag[1:]
+	if i := strings.IndexByte(tag[1:], '!'); i >= 0 {
+		handle, suffix = tag[:i+2], tag[i+2:]
+	}
+	if prefix, ok := p.tagHandles[handle]; ok {
+		return prefix + suffix
+	}
+	return tag
+}
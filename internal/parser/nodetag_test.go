@@ -0,0 +1,72 @@
+package parser
+
+import "testing"
+
+func TestRecordTags(t *testing.T) {
+	input := "%TAG !e! tag:example.com,2000:app/\n---\n" +
+		"port: 8080\nquoted: \"8080\"\nratio: 1.5\non: true\nnone: ~\n" +
+		"forced: !!str 8080\ncoerced: !!int \"42\"\nlocal: !Point {x: 1}\n" +
+		"named: !e!widget w\nverbatim: !<tag:example.com,2000:v> v\n" +
+		"list: [a]\nmap: {a: 1}\ntagged_list: !!seq [b]\n"
+
+	p := NewParser(input)
+	tags := p.RecordTags()
+	node, err := p.Parse()
+	assertNoError(t, err)
+	props := assertObjectNode(t, node).Properties()
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"port", IntTag},
+		{"quoted", StrTag},
+		{"ratio", FloatTag},
+		{"on", BoolTag},
+		{"none", NullTag},
+		{"forced", StrTag},
+		{"coerced", IntTag},
+		{"local", "!Point"},
+		{"named", "tag:example.com,2000:app/widget"},
+		{"verbatim", "tag:example.com,2000:v"},
+		{"list", SeqTag},
+		{"map", MapTag},
+		{"tagged_list", SeqTag},
+	}
+	for _, tt := range tests {
+		if got := tags.Of(props[tt.key]); got != tt.want {
+			t.Errorf("Of(%s) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	if got := tags.Of(node); got != MapTag {
+		t.Errorf("Of(root) = %q, want %q", got, MapTag)
+	}
+	if got := tags.Of(nil); got != "" {
+		t.Errorf("Of(nil) = %q, want \"\"", got)
+	}
+	// Only explicit tags are stored
+	if len(tags) != 6 {
+		t.Errorf("recorded %d tags, want 6: %v", len(tags), tags)
+	}
+}
+
+func TestTagsOfEmptyDocument(t *testing.T) {
+	node, err := NewParser("# nothing\n").Parse()
+	assertNoError(t, err)
+
+	var tags Tags
+	if got := tags.Of(node); got != NullTag {
+		t.Errorf("Of(empty document) = %q, want %q", got, NullTag)
+	}
+}
+
+func TestTagsNotRecordedByDefault(t *testing.T) {
+	p := NewParser("a: !!str 1")
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if p.tags != nil {
+		t.Errorf("tags = %v, want nil unless RecordTags is called", p.tags)
+	}
+}
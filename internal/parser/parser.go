@@ -1,34 +1,66 @@
 // Package parser implements LL(1) recursive descent parsing for YAML format.
-// Each production rule in the grammar (docs/grammar/yaml-simple.ebnf) corresponds to a parse function.
+// Each production rule in the grammar (docs/grammar/yaml-1.2.ebnf) corresponds to a parse function.
 package parser
 
 import (
+	"errors"
 	"fmt"
-	"strconv"
+	"io"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/shapestone/shape-core/pkg/ast"
 	shapetokenizer "github.com/shapestone/shape-core/pkg/tokenizer"
+	"github.com/shapestone/shape-yaml/internal/bufpool"
+	"github.com/shapestone/shape-yaml/internal/canonkey"
+	"github.com/shapestone/shape-yaml/internal/escape"
+	"github.com/shapestone/shape-yaml/internal/limits"
+	"github.com/shapestone/shape-yaml/internal/resolve"
+	"github.com/shapestone/shape-yaml/internal/syntaxhint"
 	"github.com/shapestone/shape-yaml/internal/tokenizer"
+	"github.com/shapestone/shape-yaml/internal/utf8input"
 )
 
 // Parser implements LL(1) recursive descent parsing for YAML.
 // It maintains a single token lookahead for predictive parsing.
 type Parser struct {
-	tokenizer   *tokenizer.IndentationTokenizer
-	current     *shapetokenizer.Token
-	next        *shapetokenizer.Token // Two-token lookahead for disambiguating mappings vs scalars
-	hasToken    bool
-	hasNext     bool
-	anchors     map[string]ast.SchemaNode // Store &name anchors for later alias resolution
-	yamlVersion string                    // YAML version from %YAML directive
-	tagHandles  map[string]string         // Tag handle mappings from %TAG directives
+	tokenizer    *tokenizer.IndentationTokenizer
+	current      *shapetokenizer.Token
+	next         *shapetokenizer.Token // Two-token lookahead for disambiguating mappings vs scalars
+	hasToken     bool
+	hasNext      bool
+	anchors      map[string]ast.SchemaNode // Store &name anchors for later alias resolution
+	shareAnchors bool                      // Keep anchors from one document to the next
+	yamlVersion  string                    // YAML version from %YAML directive
+	tagHandles   map[string]string         // Tag handle mappings from %TAG directives
+	directives   []string                  // Directive lines of the current document, as written
+	flowDepth    int                       // Nesting depth of flow collections ({...} / [...])
+	limits       Limits                    // Resource limits for untrusted input
+	depth        int                       // Current node nesting depth
+	nodeCount    int                       // Nodes parsed so far, across documents
+	deepest      int                       // Deepest nesting reached, across documents
+	aliasCount   int                       // Aliases parsed so far, across documents
+	keySpans     KeySpans                  // Mapping key spans, when recorded
+	scalarTexts  ScalarTexts               // Source text of resolved scalars, when recorded
+	scalarStyles ScalarStyles              // Styles of quoted and block scalars, when recorded
+	keyOrder     KeyOrder                  // Mapping keys in document order, when recorded
+	tags         Tags                      // Explicit tags of tagged nodes, when recorded
+	anchorNames  Anchors                   // Anchor names of anchored nodes, when recorded
+	input        string                    // Input text for syntax hints, when parsing a string
+	stream       shapetokenizer.Stream     // Characters being tokenized
+	started      bool                      // Lookahead tokens read
+	trace        io.Writer                 // Destination of parser decisions, when tracing
+	traceDepth   int                       // Parse functions entered and not yet left, when tracing
 }
 
-// NewParser creates a new YAML parser for the given input string.
+// NewParser creates a new YAML parser for the given input string, which may
+// start with a byte order mark. Positions count from after the mark.
 // For parsing from io.Reader, use NewParserFromStream instead.
 func NewParser(input string) *Parser {
-	return newParserWithStream(shapetokenizer.NewStream(input))
+	input = utf8input.TrimBOMString(input)
+	p := newParserWithStream(shapetokenizer.NewStream(input))
+	p.input = input
+	return p
 }
 
 // NewParserFromStream creates a new YAML parser using a pre-configured stream.
@@ -48,26 +80,36 @@ func newParserWithStream(stream shapetokenizer.Stream) *Parser {
 
 	p := &Parser{
 		tokenizer: indented,
+		stream:    stream,
 		anchors:   make(map[string]ast.SchemaNode),
+		limits:    Limits{MaxDepth: limits.DefaultMaxDepth},
 	}
 
 	// Initialize directives to defaults
 	p.resetDirectives()
 
-	// Initialize with two tokens for lookahead
-	token, ok := indented.NextToken()
+	return p
+}
+
+// start reads the two lookahead tokens, once, when parsing begins rather than
+// in the constructor, so that a trace set after construction sees them.
+func (p *Parser) start() {
+	if p.started {
+		return
+	}
+	p.started = true
+
+	token, ok := p.tokenizer.NextToken()
 	if ok {
 		p.current = token
 		p.hasToken = true
 	}
 
-	token2, ok := indented.NextToken()
+	token2, ok := p.tokenizer.NextToken()
 	if ok {
 		p.next = token2
 		p.hasNext = true
 	}
-
-	return p
 }
 
 // Parse parses the input and returns an AST representing the YAML document.
@@ -77,8 +119,35 @@ func newParserWithStream(stream shapetokenizer.Stream) *Parser {
 //	Document = Node ;
 //
 // Returns ast.SchemaNode - the root of the AST.
-// For YAML data, this will be ObjectNode (for mappings and sequences) or LiteralNode (for scalars).
+// For YAML data, this will be ObjectNode (for mappings), ArrayDataNode (for sequences) or LiteralNode (for scalars).
 func (p *Parser) Parse() (ast.SchemaNode, error) {
+	p.start()
+	node, err := p.parseDocument()
+	if uerr := p.unmatchedInput(); uerr != nil {
+		err = uerr
+	}
+	if err != nil {
+		return nil, p.withHint(err)
+	}
+	return node, nil
+}
+
+// unmatchedInput returns an error if the tokens ran out before the input
+// did, at a character no token starts with, such as an unclosed quote,
+// which would otherwise read as the end of the document.
+func (p *Parser) unmatchedInput() error {
+	if p.hasToken || p.stream == nil || p.stream.IsEos() {
+		return nil
+	}
+	r, _ := p.stream.PeekChar()
+	return fmt.Errorf("unexpected character %q at line %d, column %d", r, p.stream.GetRow(), p.stream.GetColumn())
+}
+
+// parseDocument parses the single document of the input for Parse.
+func (p *Parser) parseDocument() (ast.SchemaNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseDocument"))
+	}
 	// Parse directives at the beginning of the document
 	if err := p.parseDirectives(); err != nil {
 		return nil, err
@@ -99,6 +168,17 @@ func (p *Parser) Parse() (ast.SchemaNode, error) {
 		return ast.NewObjectNode(make(map[string]ast.SchemaNode), ast.ZeroPosition()), nil
 	}
 
+	// A flow collection or scalar may be indented at the root, as in JSON
+	// text that starts with whitespace; its DEDENT is consumed after it
+	// below. Block collections may not.
+	if p.peek().Kind() == tokenizer.TokenIndent {
+		p.advance()
+		p.skipWhitespaceAndComments()
+		if p.isBlockCollectionStart() {
+			return nil, fmt.Errorf("expected YAML value at %s, got %s", p.positionStr(), tokenizer.TokenIndent)
+		}
+	}
+
 	// Parse the document node
 	node, err := p.parseNode()
 	if err != nil {
@@ -113,6 +193,12 @@ func (p *Parser) Parse() (ast.SchemaNode, error) {
 		p.advance()
 	}
 
+	// An explicit document end marker (...) may close the document
+	for p.peek() != nil && p.peek().Kind() == tokenizer.TokenDocEnd {
+		p.advance()
+		p.skipWhitespaceAndComments()
+	}
+
 	// After parsing the value, we should be at EOF
 	// peek() skips whitespace, so if we have a non-nil token after peek, it's extra content
 	token := p.peek()
@@ -131,11 +217,19 @@ func (p *Parser) Parse() (ast.SchemaNode, error) {
 //
 // Uses single token lookahead (LL(1) predictive parsing).
 func (p *Parser) parseNode() (ast.SchemaNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseNode"))
+	}
 	token := p.peek()
 	if token == nil || !p.hasToken {
 		return nil, fmt.Errorf("unexpected end of input")
 	}
 
+	if err := p.enterNode(); err != nil {
+		return nil, err
+	}
+	defer p.leaveNode()
+
 	switch token.Kind() {
 	case tokenizer.TokenString:
 		// Could be a key (mapping), plain scalar, or quoted string
@@ -148,7 +242,10 @@ func (p *Parser) parseNode() (ast.SchemaNode, error) {
 		return p.parseBlockSequence()
 
 	case tokenizer.TokenNumber, tokenizer.TokenTrue, tokenizer.TokenFalse, tokenizer.TokenNull:
-		// Scalar value
+		// A number, bool or null followed by a colon is a mapping key
+		if next := p.peekNext(); p.flowDepth == 0 && next != nil && next.Kind() == tokenizer.TokenColon {
+			return p.parseBlockMapping()
+		}
 		return p.parseScalar()
 
 	case tokenizer.TokenLBrace:
@@ -195,14 +292,17 @@ func (p *Parser) parseNode() (ast.SchemaNode, error) {
 
 // parseMappingOrScalar determines if we have a mapping or scalar by checking for colon.
 func (p *Parser) parseMappingOrScalar() (ast.SchemaNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseMappingOrScalar"))
+	}
 	// Check if this looks like a mapping entry (key: value pattern)
 	// We're currently at a string token
 	// Use two-token lookahead to check for colon
 
 	nextToken := p.peekNext()
 
-	// If next token is colon, it's a mapping
-	if nextToken != nil && nextToken.Kind() == tokenizer.TokenColon {
+	// If next token is colon, it's a mapping (block mappings cannot nest inside flow collections)
+	if p.flowDepth == 0 && nextToken != nil && nextToken.Kind() == tokenizer.TokenColon {
 		return p.parseBlockMapping()
 	}
 
@@ -225,10 +325,15 @@ func (p *Parser) parseMappingOrScalar() (ast.SchemaNode, error) {
 //
 // Returns: ast.NewObjectNode(properties, position)
 func (p *Parser) parseBlockMapping() (*ast.ObjectNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseBlockMapping"))
+	}
 	startPos := p.position()
 
 	// Pre-size with reasonable capacity to avoid initial resizing
 	properties := make(map[string]ast.SchemaNode, 8)
+	spans := p.newKeySpans()
+	var keys []string
 
 	// Track INDENT tokens consumed so we can balance with DEDENT
 	indentDepth := 0
@@ -236,6 +341,9 @@ func (p *Parser) parseBlockMapping() (*ast.ObjectNode, error) {
 	// Collect merge key values to apply at the end
 	var mergeNodes []ast.SchemaNode
 
+	// Every entry must start in the column of the first one
+	column := 0
+
 	// Must have at least one entry
 	for {
 		// Check if we're still in the mapping
@@ -265,22 +373,15 @@ func (p *Parser) parseBlockMapping() (*ast.ObjectNode, error) {
 
 		// Check for merge key (<<)
 		if token.Kind() == tokenizer.TokenMergeKey {
-			p.advance() // consume <<
-
-			// Expect colon
-			if p.peek() == nil || p.peek().Kind() != tokenizer.TokenColon {
-				return nil, fmt.Errorf("expected ':' after merge key '<<' at %s", p.positionStr())
+			if err := p.checkEntryColumn(&column, "mapping entry"); err != nil {
+				return nil, err
 			}
-			p.advance() // consume colon
-
-			// Parse alias value
-			aliasNode, err := p.parseNode()
+			// Store merge node to apply later (after parsing all explicit properties)
+			mergeNode, err := p.parseMergeEntry()
 			if err != nil {
-				return nil, fmt.Errorf("in merge key value: %w", err)
+				return nil, err
 			}
-
-			// Store merge node to apply later (after parsing all explicit properties)
-			mergeNodes = append(mergeNodes, aliasNode)
+			mergeNodes = append(mergeNodes, mergeNode)
 
 			// Consume optional newline
 			if p.peek() != nil && p.peek().Kind() == tokenizer.TokenNewline {
@@ -290,13 +391,28 @@ func (p *Parser) parseBlockMapping() (*ast.ObjectNode, error) {
 		}
 
 		// Parse key
-		if token.Kind() != tokenizer.TokenString {
+		if token.Kind() != tokenizer.TokenString && !isScalarKeyKind(token.Kind()) {
 			break // Not a mapping entry
 		}
+		// A key left of the entries belongs to an enclosing mapping, as
+		// after the last item of "items:\n- a: 1\nnext: 2"
+		if column != 0 && p.position().Column < column {
+			break
+		}
 
+		if err := p.checkEntryColumn(&column, "mapping entry"); err != nil {
+			return nil, err
+		}
+		keyPos := p.position()
 		keyToken := p.current
 		p.advance()
-		key := p.unquoteString(keyToken.ValueString())
+		key, err := p.scalarKey(keyToken)
+		if err != nil {
+			return nil, fmt.Errorf("%w at %s", err, keyPos.String())
+		}
+		if spans != nil {
+			spans[key] = keySpan(keyPos, keyToken.ValueString())
+		}
 
 		// Expect colon
 		if p.peek() == nil || p.peek().Kind() != tokenizer.TokenColon {
@@ -315,6 +431,15 @@ func (p *Parser) parseBlockMapping() (*ast.ObjectNode, error) {
 			// Check for INDENT (nested structure)
 			if p.peek() != nil && p.peek().Kind() == tokenizer.TokenIndent {
 				p.advance() // consume INDENT
+				// A sequence in the column of the key follows the INDENT
+				// of this mapping's own entries when the mapping began
+				// after "- ", as in "- a:\n  - x\n  b: 1"; that INDENT is
+				// balanced with the mapping's
+				indentless := false
+				if next := p.peek(); next != nil && next.Kind() == tokenizer.TokenDash && p.position().Column == column {
+					indentDepth++
+					indentless = true
+				}
 				value, err := p.parseNode()
 				if err != nil {
 					return nil, fmt.Errorf("in value for key %q: %w", key, err)
@@ -325,17 +450,31 @@ func (p *Parser) parseBlockMapping() (*ast.ObjectNode, error) {
 					return nil, fmt.Errorf("duplicate key %q at %s", key, p.positionStr())
 				}
 				properties[key] = value
+				keys = p.addKey(keys, key)
 
 				// Expect DEDENT
-				if p.peek() != nil && p.peek().Kind() == tokenizer.TokenDedent {
+				if !indentless && p.peek() != nil && p.peek().Kind() == tokenizer.TokenDedent {
 					p.advance()
 				}
+			} else if next := p.peek(); next != nil && next.Kind() == tokenizer.TokenDash && p.position().Column == column {
+				// A block sequence may start in the column of its key, as
+				// in "items:\n- a\n- b"; its first non-dash line ends it
+				value, err := p.parseBlockSequence()
+				if err != nil {
+					return nil, fmt.Errorf("in value for key %q: %w", key, err)
+				}
+				if _, exists := properties[key]; exists {
+					return nil, fmt.Errorf("duplicate key %q at %s", key, p.positionStr())
+				}
+				properties[key] = value
+				keys = p.addKey(keys, key)
 			} else {
 				// Empty value (null)
 				if _, exists := properties[key]; exists {
 					return nil, fmt.Errorf("duplicate key %q at %s", key, p.positionStr())
 				}
 				properties[key] = ast.NewLiteralNode(nil, p.position())
+				keys = p.addKey(keys, key)
 			}
 		} else {
 			// Inline value (same line as key)
@@ -346,6 +485,10 @@ func (p *Parser) parseBlockMapping() (*ast.ObjectNode, error) {
 					return nil, fmt.Errorf("duplicate key %q at %s", key, p.positionStr())
 				}
 				properties[key] = ast.NewLiteralNode(nil, p.position())
+				keys = p.addKey(keys, key)
+			} else if p.peek().Kind() == tokenizer.TokenDash {
+				// A block sequence cannot start on the line of its key
+				return nil, fmt.Errorf("in value for key %q: block sequence entries are not allowed on the line of a mapping key at %s", key, p.positionStr())
 			} else {
 				value, err := p.parseNode()
 				if err != nil {
@@ -357,6 +500,7 @@ func (p *Parser) parseBlockMapping() (*ast.ObjectNode, error) {
 					return nil, fmt.Errorf("duplicate key %q at %s", key, p.positionStr())
 				}
 				properties[key] = value
+				keys = p.addKey(keys, key)
 
 				// Consume optional newline
 				if p.peek() != nil && p.peek().Kind() == tokenizer.TokenNewline {
@@ -372,21 +516,53 @@ func (p *Parser) parseBlockMapping() (*ast.ObjectNode, error) {
 		indentDepth--
 	}
 
-	// Apply merge keys: properties from merge nodes that don't exist in properties
-	// Process merge nodes in order (first merge has lowest priority)
-	for _, mergeNode := range mergeNodes {
-		if aliasObj, ok := mergeNode.(*ast.ObjectNode); ok {
-			for k, v := range aliasObj.Properties() {
-				// Don't override existing properties (explicit properties win)
-				if _, exists := properties[k]; !exists {
-					properties[k] = v
-				}
-			}
-		}
-		// Silently ignore non-mapping merge values (could add error handling)
+	keys = p.applyMerges(properties, keys, mergeNodes)
+
+	node := ast.NewObjectNode(properties, startPos)
+	p.saveKeySpans(node, spans)
+	p.saveKeyOrder(node, keys)
+	return node, nil
+}
+
+// checkEntryColumn records the column of a block collection's first entry in
+// *column and rejects later entries that start anywhere else. Without it, a
+// line indented past the entries of a mapping that began after "- " would be
+// read as another entry: the keys of "- name: a" continue at the column of
+// "name", which is the dash column + 2.
+func (p *Parser) checkEntryColumn(column *int, what string) error {
+	col := p.position().Column
+	if *column == 0 {
+		*column = col
+		return nil
+	}
+	if col != *column {
+		return fmt.Errorf("bad indentation of %s at %s: expected column %d", what, p.positionStr(), *column)
 	}
+	return nil
+}
 
-	return ast.NewObjectNode(properties, startPos), nil
+// isScalarKeyKind reports whether a plain scalar token other than a string
+// may be used as a mapping key, as in 0: a or true: b.
+func isScalarKeyKind(kind string) bool {
+	switch kind {
+	case tokenizer.TokenNumber, tokenizer.TokenTrue, tokenizer.TokenFalse, tokenizer.TokenNull:
+		return true
+	}
+	return false
+}
+
+// scalarKey returns the mapping key for a scalar key token. Keys are always
+// strings: quoted keys are unquoted, and numbers, bools and nulls keep their
+// source text, so 010: a and 8: a are distinct keys.
+func (p *Parser) scalarKey(token *shapetokenizer.Token) (string, error) {
+	key := token.ValueString()
+	if token.Kind() == tokenizer.TokenString {
+		var err error
+		if key, err = p.unquoteString(key); err != nil {
+			return "", err
+		}
+	}
+	return key, p.checkKey(key)
 }
 
 // parseBlockSequence parses a YAML block sequence.
@@ -396,20 +572,28 @@ func (p *Parser) parseBlockMapping() (*ast.ObjectNode, error) {
 //	BlockSequence = SequenceEntry { SequenceEntry } ;
 //	SequenceEntry = Dash [ Space ] Value [ Comment ] Newline ;
 //
-// Returns *ast.ObjectNode with numeric keys "0", "1", "2", ...
+// Returns *ast.ArrayDataNode with the items in order.
 // Example:
 //
 //   - apple
 //   - banana
 //   - cherry
 //
-// Returns: ast.NewObjectNode with properties {"0": LiteralNode("apple"), "1": LiteralNode("banana"), ...}
-func (p *Parser) parseBlockSequence() (*ast.ObjectNode, error) {
+// Returns: ast.NewArrayDataNode with elements [LiteralNode("apple"), LiteralNode("banana"), ...]
+func (p *Parser) parseBlockSequence() (*ast.ArrayDataNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseBlockSequence"))
+	}
 	startPos := p.position()
 
 	// Pre-size with reasonable capacity
-	properties := make(map[string]ast.SchemaNode, 16)
-	index := 0
+	elements := make([]ast.SchemaNode, 0, 16)
+	column := 0
+
+	// Track INDENT tokens consumed so we can balance with DEDENT: the entries
+	// of a sequence that began after "- ", as in "- - a", are indented past
+	// the dash that holds it
+	indentDepth := 0
 
 	for {
 		token := p.peek()
@@ -428,10 +612,23 @@ func (p *Parser) parseBlockSequence() (*ast.ObjectNode, error) {
 			continue
 		}
 
+		if token.Kind() == tokenizer.TokenIndent {
+			p.advance()
+			indentDepth++
+			continue
+		}
+
 		// Must have dash
 		if token.Kind() != tokenizer.TokenDash {
 			break
 		}
+		// A dash left of the entries belongs to an enclosing sequence
+		if column != 0 && p.position().Column < column {
+			break
+		}
+		if err := p.checkEntryColumn(&column, "sequence entry"); err != nil {
+			return nil, err
+		}
 		p.advance() // consume dash
 
 		// Check if value is on next line (indented, whitespace already consumed)
@@ -444,11 +641,18 @@ func (p *Parser) parseBlockSequence() (*ast.ObjectNode, error) {
 			// Check for INDENT
 			if p.peek() != nil && p.peek().Kind() == tokenizer.TokenIndent {
 				p.advance() // consume INDENT
+				// A dash in the column of the entries is the next entry, as
+				// in "- -\n  - b", and this one is empty
+				if next := p.peek(); next != nil && next.Kind() == tokenizer.TokenDash && p.position().Column == column {
+					indentDepth++
+					elements = append(elements, ast.NewLiteralNode(nil, p.position()))
+					continue
+				}
 				value, err := p.parseNode()
 				if err != nil {
-					return nil, fmt.Errorf("in sequence item %d: %w", index, err)
+					return nil, fmt.Errorf("in sequence item %d: %w", len(elements), err)
 				}
-				properties[strconv.Itoa(index)] = value
+				elements = append(elements, value)
 
 				// Expect DEDENT
 				if p.peek() != nil && p.peek().Kind() == tokenizer.TokenDedent {
@@ -456,26 +660,33 @@ func (p *Parser) parseBlockSequence() (*ast.ObjectNode, error) {
 				}
 			} else {
 				// Empty item (null)
-				properties[strconv.Itoa(index)] = ast.NewLiteralNode(nil, p.position())
+				elements = append(elements, ast.NewLiteralNode(nil, p.position()))
 			}
+		} else if next := p.peek(); next == nil || !p.hasToken || next.Kind() == tokenizer.TokenDedent {
+			// A dash at the end of the input is an empty item (null)
+			elements = append(elements, ast.NewLiteralNode(nil, p.position()))
 		} else {
 			// Inline value (same line as dash)
 			value, err := p.parseNode()
 			if err != nil {
-				return nil, fmt.Errorf("in sequence item %d: %w", index, err)
+				return nil, fmt.Errorf("in sequence item %d: %w", len(elements), err)
 			}
-			properties[strconv.Itoa(index)] = value
+			elements = append(elements, value)
 
 			// Consume optional newline
 			if p.peek() != nil && p.peek().Kind() == tokenizer.TokenNewline {
 				p.advance()
 			}
 		}
+	}
 
-		index++
+	// Consume matching DEDENT tokens for any INDENT tokens we consumed
+	for indentDepth > 0 && p.peek() != nil && p.peek().Kind() == tokenizer.TokenDedent {
+		p.advance()
+		indentDepth--
 	}
 
-	return ast.NewObjectNode(properties, startPos), nil
+	return ast.NewArrayDataNode(elements, startPos), nil
 }
 
 // parseFlowMapping parses a flow-style mapping: {key: value, ...}
@@ -483,41 +694,59 @@ func (p *Parser) parseBlockSequence() (*ast.ObjectNode, error) {
 // Grammar:
 //
 //	FlowMapping = "{" [ Member { "," Member } ] "}" ;
-//	Member = Key ":" Value ;
+//	Member = Key ":" [ Value ] ;
 //
 // Returns *ast.ObjectNode with properties map.
 func (p *Parser) parseFlowMapping() (*ast.ObjectNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseFlowMapping"))
+	}
 	startPos := p.position()
 
 	// "{"
 	if err := p.expect(tokenizer.TokenLBrace); err != nil {
 		return nil, err
 	}
+	p.flowDepth++
+	defer func() { p.flowDepth-- }()
 
 	properties := make(map[string]ast.SchemaNode, 8)
+	spans := p.newKeySpans()
+	var keys []string
+	var mergeNodes []ast.SchemaNode
 
 	// [ Member { "," Member } ]
-	if p.peek().Kind() != tokenizer.TokenRBrace {
-		// First member
-		key, value, err := p.parseFlowMember()
-		if err != nil {
-			return nil, err
-		}
-		properties[key] = value
-
-		// Additional members: { "," Member }
-		for p.peek() != nil && p.peek().Kind() == tokenizer.TokenComma {
-			p.advance() // consume ","
+	if p.peek() != nil && p.peek().Kind() != tokenizer.TokenRBrace {
+		for n := 0; ; n++ {
+			if n > 0 {
+				p.advance() // consume ","
+			}
 
-			key, value, err := p.parseFlowMember()
-			if err != nil {
-				return nil, fmt.Errorf("in flow mapping after comma: %w", err)
+			if p.peek() != nil && p.peek().Kind() == tokenizer.TokenMergeKey {
+				mergeNode, err := p.parseMergeEntry()
+				if err != nil {
+					return nil, err
+				}
+				mergeNodes = append(mergeNodes, mergeNode)
+			} else {
+				key, value, err := p.parseFlowMember(spans)
+				if err != nil {
+					if n > 0 {
+						return nil, fmt.Errorf("in flow mapping after comma: %w", err)
+					}
+					return nil, err
+				}
+				if _, exists := properties[key]; exists {
+					return nil, fmt.Errorf("duplicate key %q in flow mapping at %s", key, p.positionStr())
+				}
+				properties[key] = value
+				keys = p.addKey(keys, key)
 			}
 
-			if _, exists := properties[key]; exists {
-				return nil, fmt.Errorf("duplicate key %q in flow mapping at %s", key, p.positionStr())
+			// Additional members: { "," Member }
+			if p.peek() == nil || p.peek().Kind() != tokenizer.TokenComma {
+				break
 			}
-			properties[key] = value
 		}
 	}
 
@@ -526,73 +755,233 @@ func (p *Parser) parseFlowMapping() (*ast.ObjectNode, error) {
 		return nil, err
 	}
 
-	return ast.NewObjectNode(properties, startPos), nil
+	keys = p.applyMerges(properties, keys, mergeNodes)
+
+	node := ast.NewObjectNode(properties, startPos)
+	p.saveKeySpans(node, spans)
+	p.saveKeyOrder(node, keys)
+	return node, nil
 }
 
-// parseFlowMember parses a flow mapping member (key: value).
-func (p *Parser) parseFlowMember() (string, ast.SchemaNode, error) {
-	// Key
-	if p.peek().Kind() != tokenizer.TokenString {
-		return "", nil, fmt.Errorf("flow mapping key must be string at %s, got %s",
+// parseFlowMember parses a flow mapping member (key: value), recording the
+// key's span in spans when it is non-nil.
+//
+// A key may itself be a flow collection, as in {[1, 2]: pair}. Like a
+// complex key introduced by ? in block context, it is stringified and has
+// no span.
+func (p *Parser) parseFlowMember(spans map[string]KeySpan) (string, ast.SchemaNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseFlowMember"))
+	}
+	var key string
+	switch p.peek().Kind() {
+	case tokenizer.TokenString, tokenizer.TokenNumber, tokenizer.TokenTrue, tokenizer.TokenFalse, tokenizer.TokenNull:
+		keyPos := p.position()
+		keyToken := p.current
+		p.advance()
+		var err error
+		key, err = p.scalarKey(keyToken)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w at %s", err, keyPos.String())
+		}
+		if spans != nil {
+			spans[key] = keySpan(keyPos, keyToken.ValueString())
+		}
+
+	case tokenizer.TokenLBrace, tokenizer.TokenLBracket:
+		keyNode, err := p.parseNode()
+		if err != nil {
+			return "", nil, fmt.Errorf("in flow mapping key: %w", err)
+		}
+		key = stringifyNode(keyNode)
+		if err := p.checkKey(key); err != nil {
+			return "", nil, fmt.Errorf("in flow mapping key: %w", err)
+		}
+
+	default:
+		return "", nil, fmt.Errorf("flow mapping key must be a scalar or flow collection at %s, got %s",
 			p.positionStr(), p.peek().Kind())
 	}
 
-	keyToken := p.current
-	p.advance()
-	key := p.unquoteString(keyToken.ValueString())
+	// A key without a value ({a, b}, as a !!set is written) is null
+	if next := p.peek(); next != nil && (next.Kind() == tokenizer.TokenComma || next.Kind() == tokenizer.TokenRBrace) {
+		return key, ast.NewLiteralNode(nil, p.position()), nil
+	}
 
+	value, err := p.parseFlowMemberValue(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, value, nil
+}
+
+// parseFlowMemberValue parses the ':' after the key of a flow mapping
+// member or flow pair, and the value after it.
+func (p *Parser) parseFlowMemberValue(key string) (ast.SchemaNode, error) {
 	// ":"
 	if err := p.expect(tokenizer.TokenColon); err != nil {
-		return "", nil, fmt.Errorf("expected ':' after flow mapping key %q: %w", key, err)
+		return nil, fmt.Errorf("expected ':' after flow mapping key %q: %w", key, err)
+	}
+
+	// An omitted value ({a: , b: 1}, {a:} or [a:]) is null
+	if next := p.peek(); next != nil && (next.Kind() == tokenizer.TokenComma ||
+		next.Kind() == tokenizer.TokenRBrace || next.Kind() == tokenizer.TokenRBracket) {
+		return ast.NewLiteralNode(nil, p.position()), nil
 	}
 
 	// Value (whitespace already consumed)
 	value, err := p.parseNode()
 	if err != nil {
-		return "", nil, fmt.Errorf("in value for key %q: %w", key, err)
+		return nil, fmt.Errorf("in value for key %q: %w", key, err)
+	}
+	return value, nil
+}
+
+// parseFlowEntry parses an entry of a flow sequence: a value, or a
+// single-pair mapping written as a key and ':' without braces, as in
+// [a: 1, b: 2]. The ':' of a scalar key must be on the key's line.
+func (p *Parser) parseFlowEntry() (ast.SchemaNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseFlowEntry"))
+	}
+	token := p.peek()
+	if token == nil {
+		return p.parseNode()
 	}
+	startPos := p.position()
+	spans := p.newKeySpans()
+	var key string
+	var value ast.SchemaNode
 
-	return key, value, nil
+	switch token.Kind() {
+	case tokenizer.TokenString, tokenizer.TokenNumber, tokenizer.TokenTrue, tokenizer.TokenFalse, tokenizer.TokenNull:
+		if next := p.peekNext(); next == nil || next.Kind() != tokenizer.TokenColon || next.Row() != token.Row() {
+			return p.parseNode()
+		}
+		var err error
+		if key, value, err = p.parseFlowMember(spans); err != nil {
+			return nil, err
+		}
+
+	case tokenizer.TokenLBrace, tokenizer.TokenLBracket:
+		keyNode, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		if next := p.peek(); next == nil || next.Kind() != tokenizer.TokenColon {
+			return keyNode, nil
+		}
+		key = stringifyNode(keyNode)
+		if err := p.checkKey(key); err != nil {
+			return nil, fmt.Errorf("in flow mapping key: %w", err)
+		}
+		if value, err = p.parseFlowMemberValue(key); err != nil {
+			return nil, err
+		}
+
+	default:
+		return p.parseNode()
+	}
+
+	node := ast.NewObjectNode(map[string]ast.SchemaNode{key: value}, startPos)
+	p.saveKeySpans(node, spans)
+	p.saveKeyOrder(node, []string{key})
+	return node, nil
+}
+
+// parseMergeEntry parses a merge key entry, "<<: value", and returns its
+// value: an alias of a mapping, a mapping, or a sequence of either.
+func (p *Parser) parseMergeEntry() (ast.SchemaNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseMergeEntry"))
+	}
+	p.advance() // consume <<
+
+	// Expect colon
+	if p.peek() == nil || p.peek().Kind() != tokenizer.TokenColon {
+		return nil, fmt.Errorf("expected ':' after merge key '<<' at %s", p.positionStr())
+	}
+	p.advance() // consume colon
+
+	value, err := p.parseNode()
+	if err != nil {
+		return nil, fmt.Errorf("in merge key value: %w", err)
+	}
+	return value, nil
+}
+
+// applyMerges adds the entries of the merge key values in mergeNodes to
+// properties, and their keys to keys, except where a key is already set:
+// explicit entries win over merged ones, and an earlier merged mapping wins
+// over a later one, including within a sequence of mappings. Merged values
+// are the nodes of the merged mappings as they were when the aliases were
+// parsed.
+func (p *Parser) applyMerges(properties map[string]ast.SchemaNode, keys []string, mergeNodes []ast.SchemaNode) []string {
+	merge := func(node ast.SchemaNode) {
+		obj, ok := node.(*ast.ObjectNode)
+		if !ok {
+			return // Silently ignore non-mapping merge values
+		}
+		for _, k := range p.keyOrder.Keys(obj) {
+			if _, exists := properties[k]; !exists {
+				properties[k] = obj.Properties()[k]
+				keys = p.addKey(keys, k)
+			}
+		}
+	}
+	for _, mergeNode := range mergeNodes {
+		if seq, ok := mergeNode.(*ast.ArrayDataNode); ok {
+			for _, elem := range seq.Elements() {
+				merge(elem)
+			}
+			continue
+		}
+		merge(mergeNode)
+	}
+	return keys
 }
 
 // parseFlowSequence parses a flow-style sequence: [item1, item2, ...]
 //
 // Grammar:
 //
-//	FlowSequence = "[" [ Value { "," Value } ] "]" ;
+//	FlowSequence = "[" [ Entry { "," Entry } ] "]" ;
+//	Entry = Value | Key ":" [ Value ] ;
 //
-// Returns *ast.ObjectNode with numeric keys "0", "1", "2", ...
-func (p *Parser) parseFlowSequence() (*ast.ObjectNode, error) {
+// Returns *ast.ArrayDataNode with the items in order.
+func (p *Parser) parseFlowSequence() (*ast.ArrayDataNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseFlowSequence"))
+	}
 	startPos := p.position()
 
 	// "["
 	if err := p.expect(tokenizer.TokenLBracket); err != nil {
 		return nil, err
 	}
+	p.flowDepth++
+	defer func() { p.flowDepth-- }()
 
-	properties := make(map[string]ast.SchemaNode, 16)
-	index := 0
+	elements := make([]ast.SchemaNode, 0, 16)
 
-	// [ Value { "," Value } ]
-	if p.peek().Kind() != tokenizer.TokenRBracket {
+	// [ Entry { "," Entry } ]
+	if p.peek() != nil && p.peek().Kind() != tokenizer.TokenRBracket {
 		// First value
-		value, err := p.parseNode()
+		value, err := p.parseFlowEntry()
 		if err != nil {
 			return nil, err
 		}
-		properties[strconv.Itoa(index)] = value
-		index++
+		elements = append(elements, value)
 
 		// Additional values: { "," Value }
 		for p.peek() != nil && p.peek().Kind() == tokenizer.TokenComma {
 			p.advance() // consume ","
 
-			value, err := p.parseNode()
+			value, err := p.parseFlowEntry()
 			if err != nil {
-				return nil, fmt.Errorf("in flow sequence element %d: %w", index, err)
+				return nil, fmt.Errorf("in flow sequence element %d: %w", len(elements), err)
 			}
-			properties[strconv.Itoa(index)] = value
-			index++
+			elements = append(elements, value)
 		}
 	}
 
@@ -601,17 +990,28 @@ func (p *Parser) parseFlowSequence() (*ast.ObjectNode, error) {
 		return nil, err
 	}
 
-	return ast.NewObjectNode(properties, startPos), nil
+	return ast.NewArrayDataNode(elements, startPos), nil
 }
 
 // parseAnchoredNode parses an anchored node: &name value
 func (p *Parser) parseAnchoredNode() (ast.SchemaNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseAnchoredNode"))
+	}
+	// Extract anchor name (remove leading &)
+	anchorName := strings.TrimPrefix(p.current.ValueString(), "&")
+	if err := p.checkAnchor(anchorName, true); err != nil {
+		return nil, err
+	}
+
 	// Consume anchor token
-	anchorToken := p.current
 	p.advance()
 
-	// Extract anchor name (remove leading &)
-	anchorName := strings.TrimPrefix(anchorToken.ValueString(), "&")
+	// Resolution is lexical: from here on the name refers to this node, so
+	// an alias within its own value is recursive rather than a reference to
+	// an earlier node of the same name. A nil entry marks the definition as
+	// in progress.
+	p.anchors[anchorName] = nil
 
 	// Skip whitespace/newlines after anchor
 	// Anchored values can be on the same line or next line (indented)
@@ -638,25 +1038,38 @@ func (p *Parser) parseAnchoredNode() (ast.SchemaNode, error) {
 		p.advance()
 	}
 
-	// Store in anchors map
-	p.anchors[anchorName] = value
+	// Store in anchors map, unless the value redefined the name: that
+	// later definition is the one aliases after the value refer to
+	if p.anchors[anchorName] == nil {
+		p.anchors[anchorName] = value
+	}
+	if _, named := p.anchorNames[value]; p.anchorNames != nil && !named {
+		p.anchorNames[value] = anchorName // an anchored alias keeps its first name
+	}
 
 	return value, nil
 }
 
 // parseAlias parses an alias reference: *name
 func (p *Parser) parseAlias() (ast.SchemaNode, error) {
-	aliasToken := p.current
-	p.advance()
-
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseAlias"))
+	}
 	// Extract alias name (remove leading *)
-	aliasName := strings.TrimPrefix(aliasToken.ValueString(), "*")
+	aliasName := strings.TrimPrefix(p.current.ValueString(), "*")
+	if err := p.checkAnchor(aliasName, false); err != nil {
+		return nil, err
+	}
+	p.advance()
 
 	// Look up in anchors map
 	value, exists := p.anchors[aliasName]
 	if !exists {
 		return nil, fmt.Errorf("undefined alias *%s at %s", aliasName, p.positionStr())
 	}
+	if value == nil {
+		return nil, fmt.Errorf("alias *%s refers to the node it is part of at %s", aliasName, p.positionStr())
+	}
 
 	return value, nil
 }
@@ -670,6 +1083,9 @@ func (p *Parser) parseAlias() (ast.SchemaNode, error) {
 //
 // Returns *ast.LiteralNode with appropriate Go type.
 func (p *Parser) parseScalar() (*ast.LiteralNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseScalar"))
+	}
 	token := p.peek()
 	if token == nil || !p.hasToken {
 		return nil, fmt.Errorf("unexpected end of input")
@@ -691,7 +1107,8 @@ func (p *Parser) parseScalar() (*ast.LiteralNode, error) {
 
 // parseString parses a YAML string literal (quoted or plain).
 //
-// Returns *ast.LiteralNode with the unescaped string value.
+// Returns *ast.LiteralNode with the unescaped string value, or the resolved
+// value for plain scalars such as .inf or .5.
 func (p *Parser) parseString() (*ast.LiteralNode, error) {
 	if p.peek().Kind() != tokenizer.TokenString {
 		return nil, fmt.Errorf("expected string at %s, got %s",
@@ -702,19 +1119,32 @@ func (p *Parser) parseString() (*ast.LiteralNode, error) {
 	tokenValue := p.current.ValueString()
 	p.advance()
 
+	// Plain scalars not claimed by a keyword or number token (.inf, .5, 1.)
+	// still resolve through the canonical mapping
+	if !isQuoted(tokenValue) {
+		return p.newResolvedScalar(p.resolvePlain(tokenValue), tokenValue, pos), nil
+	}
+
 	// Unquote and unescape the string
-	unquoted := p.unquoteString(tokenValue)
+	unquoted, err := p.unquoteString(tokenValue)
+	if err != nil {
+		return nil, fmt.Errorf("%w at %s", err, pos.String())
+	}
 
-	return ast.NewLiteralNode(unquoted, pos), nil
+	style := DoubleQuoted
+	if tokenValue[0] == '\'' {
+		style = SingleQuoted
+	}
+	return p.recordStyle(ast.NewLiteralNode(unquoted, pos), style), nil
 }
 
 // parseNumber parses a YAML number literal.
 //
 // Grammar:
 //
-//	Number = [ "-" ] Integer [ Fraction ] [ Exponent ] ;
+//	Number = [ "-" | "+" ] Integer [ Fraction ] [ Exponent ] ;
 //
-// Returns *ast.LiteralNode with int64 or float64 value.
+// Returns *ast.LiteralNode with int64, uint64, or float64 value (see internal/resolve).
 // Examples: 0, -123, 123.456, 1e10, 1.5e-3
 func (p *Parser) parseNumber() (*ast.LiteralNode, error) {
 	if p.peek().Kind() != tokenizer.TokenNumber {
@@ -723,42 +1153,32 @@ func (p *Parser) parseNumber() (*ast.LiteralNode, error) {
 	}
 
 	pos := p.position()
-	tokenValue := p.current.ValueString()
+	tokenValue := p.current.Value()
 	p.advance()
 
-	// Handle hex numbers (0x...)
-	if strings.HasPrefix(tokenValue, "0x") || strings.HasPrefix(tokenValue, "0X") {
-		i, err := strconv.ParseInt(tokenValue, 0, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid hex number %q at %s: %w", tokenValue, pos.String(), err)
-		}
-		return ast.NewLiteralNode(i, pos), nil
-	}
-
-	// Handle octal numbers (0o...)
-	if strings.HasPrefix(tokenValue, "0o") || strings.HasPrefix(tokenValue, "0O") {
-		i, err := strconv.ParseInt(tokenValue, 0, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid octal number %q at %s: %w", tokenValue, pos.String(), err)
+	if !resolve.UsesYAML11(p.yamlVersion) {
+		if value, ok := resolve.NumberRunes(tokenValue); ok {
+			node := ast.NewLiteralNode(value, pos)
+			if p.scalarTexts != nil {
+				p.scalarTexts[node] = string(tokenValue)
+			}
+			return node, nil
 		}
-		return ast.NewLiteralNode(i, pos), nil
 	}
 
-	// Try parsing as integer first
-	if !strings.Contains(tokenValue, ".") && !strings.ContainsAny(tokenValue, "eE") {
-		i, err := strconv.ParseInt(tokenValue, 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid integer %q at %s: %w", tokenValue, pos.String(), err)
-		}
-		return ast.NewLiteralNode(i, pos), nil
-	}
+	// The resolver, not the tokenizer, decides what is a number, so both
+	// decoders agree; a token it rejects is a plain string
+	raw := string(tokenValue)
+	return p.newResolvedScalar(p.resolvePlain(raw), raw, pos), nil
+}
 
-	// Parse as floating point
-	f, err := strconv.ParseFloat(tokenValue, 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid number %q at %s: %w", tokenValue, pos.String(), err)
+// resolvePlain resolves a plain scalar under the schema selected by the
+// document's %YAML directive.
+func (p *Parser) resolvePlain(s string) interface{} {
+	if resolve.UsesYAML11(p.yamlVersion) {
+		return resolve.Plain11(s)
 	}
-	return ast.NewLiteralNode(f, pos), nil
+	return resolve.Plain(s)
 }
 
 // parseBoolean parses a YAML boolean literal.
@@ -777,9 +1197,10 @@ func (p *Parser) parseBoolean() (*ast.LiteralNode, error) {
 
 	pos := p.position()
 	value := kind == tokenizer.TokenTrue
+	raw := p.current.ValueString()
 	p.advance()
 
-	return ast.NewLiteralNode(value, pos), nil
+	return p.newResolvedScalar(value, raw, pos), nil
 }
 
 // parseNull parses a YAML null literal.
@@ -806,13 +1227,25 @@ func (p *Parser) parseNull() (*ast.LiteralNode, error) {
 // peek returns current token without advancing.
 // Automatically skips whitespace and comment tokens.
 func (p *Parser) peek() *shapetokenizer.Token {
-	// Skip whitespace and comment tokens
-	for p.hasToken && (p.current.Kind() == "Whitespace" || p.current.Kind() == tokenizer.TokenComment) {
+	// Skip whitespace and comment tokens, and line breaks inside flow collections
+	for p.hasToken && p.skippable(p.current) {
 		p.advance()
 	}
 	return p.current
 }
 
+// skippable reports whether peek skips token: whitespace and comments, and
+// inside a flow collection line breaks, which are only whitespace there.
+func (p *Parser) skippable(token *shapetokenizer.Token) bool {
+	switch token.Kind() {
+	case "Whitespace", tokenizer.TokenComment:
+		return true
+	case tokenizer.TokenNewline:
+		return p.flowDepth > 0
+	}
+	return false
+}
+
 // peekRaw returns current token without advancing or skipping whitespace.
 // Use this when you need to preserve whitespace (e.g., in block scalars).
 func (p *Parser) peekRaw() *shapetokenizer.Token {
@@ -842,7 +1275,7 @@ func (p *Parser) advance() {
 // peekNext returns the next token (two tokens ahead) without advancing.
 func (p *Parser) peekNext() *shapetokenizer.Token {
 	// Skip whitespace/comments in next token
-	for p.hasNext && (p.next.Kind() == "Whitespace" || p.next.Kind() == tokenizer.TokenComment) {
+	for p.hasNext && p.skippable(p.next) {
 		// Load the next token to skip whitespace
 		token, ok := p.tokenizer.NextToken()
 		if ok {
@@ -855,6 +1288,23 @@ func (p *Parser) peekNext() *shapetokenizer.Token {
 	return p.next
 }
 
+// isBlockCollectionStart reports whether the current token starts a block
+// sequence entry or a block mapping key.
+func (p *Parser) isBlockCollectionStart() bool {
+	token := p.peek()
+	if token == nil {
+		return false
+	}
+	switch token.Kind() {
+	case tokenizer.TokenDash, tokenizer.TokenQuestion:
+		return true
+	case tokenizer.TokenLBrace, tokenizer.TokenLBracket:
+		return false
+	}
+	next := p.peekNext()
+	return next != nil && next.Kind() == tokenizer.TokenColon
+}
+
 // expect consumes token of expected kind or returns error.
 func (p *Parser) expect(kind string) error {
 	if p.peek() == nil || !p.hasToken {
@@ -880,6 +1330,19 @@ func (p *Parser) position() ast.Position {
 	return ast.ZeroPosition()
 }
 
+// withHint appends a suggested fix to a syntax error for a recognized
+// mistake, such as a missing space after a colon. Limit and safe mode errors,
+// and errors from a parser reading a stream, are returned unchanged.
+func (p *Parser) withHint(err error) error {
+	if p.input == "" || errors.Is(err, ErrLimitExceeded) || errors.Is(err, ErrUnsafe) {
+		return err
+	}
+	if h := syntaxhint.For([]byte(p.input), p.position().Line); h != "" {
+		return fmt.Errorf("%w; %s", err, h)
+	}
+	return err
+}
+
 // positionStr returns current position as a string for error messages.
 func (p *Parser) positionStr() string {
 	return p.position().String()
@@ -895,12 +1358,20 @@ func (p *Parser) skipWhitespaceAndComments() {
 	}
 }
 
+// isQuoted reports whether a string token is single- or double-quoted.
+func isQuoted(s string) bool {
+	return len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0]
+}
+
 // unquoteString removes quotes and unescapes a YAML string.
 // Handles:
 // - Double-quoted strings: "..." with \", \\, \n, \t, \r, \uXXXX
 // - Single-quoted strings: '...' with ” (doubled single quote)
+// - Line folding of multi-line quoted strings
 // - Plain strings: returned as-is
-func (p *Parser) unquoteString(s string) string {
+//
+// It fails only for a double-quoted string with an invalid unicode escape.
+func (p *Parser) unquoteString(s string) (string, error) {
 	// Handle double-quoted strings
 	if strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
 		s = s[1 : len(s)-1]
@@ -910,29 +1381,61 @@ func (p *Parser) unquoteString(s string) string {
 	// Handle single-quoted strings
 	if strings.HasPrefix(s, `'`) && strings.HasSuffix(s, `'`) {
 		s = s[1 : len(s)-1]
-		// Only escape is '' -> '
-		return strings.ReplaceAll(s, "''", "'")
+		return unescapeSingleQuoted(s), nil
 	}
 
 	// Plain string - return as-is
-	return s
+	return s, nil
 }
 
-// unescapeDoubleQuoted handles escape sequences in double-quoted strings.
-// Uses single-pass algorithm for optimal performance.
-func (p *Parser) unescapeDoubleQuoted(s string) string {
-	// Fast path: no escapes
-	if !strings.ContainsRune(s, '\\') {
-		return s
-	}
-
-	// Single-pass escape processing
-	var buf strings.Builder
-	buf.Grow(len(s)) // Pre-allocate to avoid resizing
-
+// unescapeDoubleQuoted handles escape sequences and line folding in
+// double-quoted strings. Uses single-pass algorithm for optimal performance.
+//
+// Line breaks are folded per YAML 1.2 section 7.3.1: white space around a
+// break is trimmed, a single break becomes a space and each following empty
+// line becomes a newline. An escaped line break joins the lines without a
+// space. Escaped white space is never trimmed.
+//
+// Escape sequences are decoded by package escape, which the fast parser
+// shares: a lone surrogate, a \U escape outside the Unicode range or an
+// unknown escape is an error rather than being written out as is.
+func (p *Parser) unescapeDoubleQuoted(s string) (string, error) {
+	// Fast path: no escapes or line breaks
+	if !strings.ContainsAny(s, "\\\n\r") {
+		return s, nil
+	}
+
+	// Single-pass escape processing into a pooled scratch buffer
+	bp := bufpool.Scratch.Get()
+	buf := *bp
+	defer func() {
+		*bp = buf
+		bufpool.Scratch.Put(bp)
+	}()
+
+	// kept is the length of buf that folding must not trim: everything up
+	// to the end of the last escape sequence or folded break.
+	kept := 0
 	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' || s[i] == '\r' {
+			j := len(buf)
+			for j > kept && (buf[j-1] == ' ' || buf[j-1] == '\t') {
+				j--
+			}
+			buf = buf[:j]
+			empty, next := foldLineBreaks(s, i)
+			if empty == 0 {
+				buf = append(buf, ' ')
+			}
+			for ; empty > 0; empty-- {
+				buf = append(buf, '\n')
+			}
+			kept = len(buf)
+			i = next - 1
+			continue
+		}
 		if s[i] != '\\' {
-			buf.WriteByte(s[i])
+			buf = append(buf, s[i])
 			continue
 		}
 
@@ -940,138 +1443,88 @@ func (p *Parser) unescapeDoubleQuoted(s string) string {
 		i++ // Skip backslash
 		if i >= len(s) {
 			// Malformed escape at end of string
-			buf.WriteByte('\\')
+			buf = append(buf, '\\')
 			break
 		}
 
-		switch s[i] {
-		case '"', '\\', '/':
-			buf.WriteByte(s[i])
-		case 'b':
-			buf.WriteByte('\b')
-		case 'f':
-			buf.WriteByte('\f')
-		case 'n':
-			buf.WriteByte('\n')
-		case 'r':
-			buf.WriteByte('\r')
-		case 't':
-			buf.WriteByte('\t')
-		case '0':
-			buf.WriteByte('\x00')
-		// Advanced YAML 1.2 escape sequences
-		case 'a':
-			buf.WriteByte('\a') // bell (0x07)
-		case 'v':
-			buf.WriteByte('\v') // vertical tab (0x0B)
-		case 'e':
-			buf.WriteByte('\x1b') // escape (0x1B)
-		case ' ':
-			buf.WriteByte(' ') // escaped space (0x20)
-		case 'N':
-			buf.WriteRune('\u0085') // next line (NEL)
-		case '_':
-			buf.WriteRune('\u00a0') // non-breaking space (NBSP)
-		case 'L':
-			buf.WriteRune('\u2028') // line separator
-		case 'P':
-			buf.WriteRune('\u2029') // paragraph separator
-		case 'u':
-			// Handle \uXXXX unicode escape (4 hex digits)
-			if i+4 < len(s) {
-				// Parse 4 hex digits
-				hex := s[i+1 : i+5]
-				if codepoint, err := parseHex(hex); err == nil {
-					buf.WriteRune(rune(codepoint))
-					i += 4 // Skip the 4 hex digits
-				} else {
-					// Invalid hex, write as-is
-					buf.WriteString("\\u")
-				}
-			} else {
-				// Not enough characters for \uXXXX
-				buf.WriteString("\\u")
+		if s[i] == '\n' || s[i] == '\r' {
+			// Escaped line break: join the lines, keeping empty lines
+			empty, next := foldLineBreaks(s, i)
+			for ; empty > 0; empty-- {
+				buf = append(buf, '\n')
 			}
-		case 'U':
-			// Handle \UXXXXXXXX unicode escape (8 hex digits)
-			if i+8 < len(s) {
-				// Parse 8 hex digits
-				hex := s[i+1 : i+9]
-				if codepoint, err := parseHex8(hex); err == nil {
-					buf.WriteRune(rune(codepoint))
-					i += 8 // Skip the 8 hex digits
-				} else {
-					// Invalid hex, write as-is
-					buf.WriteString("\\U")
-				}
-			} else {
-				// Not enough characters for \UXXXXXXXX
-				buf.WriteString("\\U")
+			i = next - 1
+		} else {
+			r, n, err := escape.Decode(s[i:])
+			if err != nil {
+				return "", err
 			}
-		default:
-			// Unknown escape sequence, preserve it
-			buf.WriteByte('\\')
-			buf.WriteByte(s[i])
+			buf = utf8.AppendRune(buf, r)
+			i += n - 1
 		}
+		kept = len(buf)
 	}
 
-	return buf.String()
+	return string(buf), nil
 }
 
-// parseHex converts a 4-character hex string to an integer.
-func parseHex(s string) (int, error) {
-	if len(s) != 4 {
-		return 0, fmt.Errorf("hex string must be 4 characters")
+// unescapeSingleQuoted handles the doubled-quote escape and line folding in
+// single-quoted strings. Folding follows the same rules as for double-quoted
+// strings (YAML 1.2 section 7.3.2), without escaped line breaks.
+func unescapeSingleQuoted(s string) string {
+	// Fast path: single line, only escape is '' -> '
+	if !strings.ContainsAny(s, "\n\r") {
+		return strings.ReplaceAll(s, "''", "'")
 	}
 
-	var result int
-	for i := 0; i < 4; i++ {
-		c := s[i]
-		var digit int
+	bp := bufpool.Scratch.Get()
+	buf := *bp
+	defer func() {
+		*bp = buf
+		bufpool.Scratch.Put(bp)
+	}()
 
-		switch {
-		case c >= '0' && c <= '9':
-			digit = int(c - '0')
-		case c >= 'a' && c <= 'f':
-			digit = int(c - 'a' + 10)
-		case c >= 'A' && c <= 'F':
-			digit = int(c - 'A' + 10)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\n', '\r':
+			for len(buf) > 0 && (buf[len(buf)-1] == ' ' || buf[len(buf)-1] == '\t') {
+				buf = buf[:len(buf)-1]
+			}
+			empty, next := foldLineBreaks(s, i)
+			if empty == 0 {
+				buf = append(buf, ' ')
+			}
+			for ; empty > 0; empty-- {
+				buf = append(buf, '\n')
+			}
+			i = next - 1
+		case '\'':
+			buf = append(buf, '\'')
+			i++ // skip the second quote of ''
 		default:
-			return 0, fmt.Errorf("invalid hex character: %c", c)
+			buf = append(buf, s[i])
 		}
-
-		result = result*16 + digit
 	}
 
-	return result, nil
+	return string(buf)
 }
 
-// parseHex8 converts an 8-character hex string to an integer (for \UXXXXXXXX).
-func parseHex8(s string) (int, error) {
-	if len(s) != 8 {
-		return 0, fmt.Errorf("hex string must be 8 characters")
-	}
-
-	var result int
-	for i := 0; i < 8; i++ {
-		c := s[i]
-		var digit int
-
-		switch {
-		case c >= '0' && c <= '9':
-			digit = int(c - '0')
-		case c >= 'a' && c <= 'f':
-			digit = int(c - 'a' + 10)
-		case c >= 'A' && c <= 'F':
-			digit = int(c - 'A' + 10)
-		default:
-			return 0, fmt.Errorf("invalid hex character: %c", c)
+// foldLineBreaks consumes the line break at s[i] together with any empty
+// lines and indentation that follow it. It returns the number of empty lines
+// and the index of the next content byte.
+func foldLineBreaks(s string, i int) (int, int) {
+	empty := -1
+	for i < len(s) && (s[i] == '\n' || s[i] == '\r') {
+		if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+			i++
+		}
+		i++
+		empty++
+		for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+			i++
 		}
-
-		result = result*16 + digit
 	}
-
-	return result, nil
+	return empty, i
 }
 
 // parseLiteralScalar parses a YAML literal scalar (|).
@@ -1091,6 +1544,9 @@ func parseHex8(s string) (int, error) {
 //
 // Returns: LiteralNode("Line 1\nLine 2\n", position)
 func (p *Parser) parseLiteralScalar() (*ast.LiteralNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseLiteralScalar"))
+	}
 	if p.peek().Kind() != tokenizer.TokenBlockLiteral {
 		return nil, fmt.Errorf("expected '|' at %s", p.positionStr())
 	}
@@ -1126,7 +1582,7 @@ func (p *Parser) parseLiteralScalar() (*ast.LiteralNode, error) {
 
 	// Check for INDENT - if not present, empty literal
 	if p.peek() == nil || p.peek().Kind() != tokenizer.TokenIndent {
-		return ast.NewLiteralNode("", pos), nil
+		return p.recordStyle(ast.NewLiteralNode("", pos), Literal), nil
 	}
 	p.advance() // consume INDENT
 
@@ -1207,7 +1663,7 @@ func (p *Parser) parseLiteralScalar() (*ast.LiteralNode, error) {
 		content = strings.TrimRight(content, "\n") + "\n"
 	}
 
-	return ast.NewLiteralNode(content, pos), nil
+	return p.recordStyle(ast.NewLiteralNode(content, pos), Literal), nil
 }
 
 // parseFoldedScalar parses a YAML folded scalar (>).
@@ -1226,6 +1682,9 @@ func (p *Parser) parseLiteralScalar() (*ast.LiteralNode, error) {
 //
 // Returns: LiteralNode("This is a long paragraph that spans multiple lines.\n", position)
 func (p *Parser) parseFoldedScalar() (*ast.LiteralNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseFoldedScalar"))
+	}
 	if p.peek().Kind() != tokenizer.TokenBlockFolded {
 		return nil, fmt.Errorf("expected '>' at %s", p.positionStr())
 	}
@@ -1261,7 +1720,7 @@ func (p *Parser) parseFoldedScalar() (*ast.LiteralNode, error) {
 
 	// Check for INDENT - if not present, empty folded
 	if p.peek() == nil || p.peek().Kind() != tokenizer.TokenIndent {
-		return ast.NewLiteralNode("", pos), nil
+		return p.recordStyle(ast.NewLiteralNode("", pos), Folded), nil
 	}
 	p.advance() // consume INDENT
 
@@ -1371,7 +1830,7 @@ func (p *Parser) parseFoldedScalar() (*ast.LiteralNode, error) {
 		content = strings.TrimRight(content, "\n") + "\n"
 	}
 
-	return ast.NewLiteralNode(content, pos), nil
+	return p.recordStyle(ast.NewLiteralNode(content, pos), Folded), nil
 }
 
 // parseComplexMapping parses a mapping with complex keys (? marker).
@@ -1384,11 +1843,17 @@ func (p *Parser) parseFoldedScalar() (*ast.LiteralNode, error) {
 //
 //	? [composite, key]
 //	: value
+//	? bare key
 //
-// Returns *ast.ObjectNode with the complex key stringified.
+// The ": value" part may be omitted, as for "bare key", and the value is then
+// null. Returns *ast.ObjectNode with the complex key stringified.
 func (p *Parser) parseComplexMapping() (*ast.ObjectNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseComplexMapping"))
+	}
 	startPos := p.position()
 	properties := make(map[string]ast.SchemaNode, 8)
+	var keys []string
 
 	for {
 		token := p.peek()
@@ -1415,22 +1880,34 @@ func (p *Parser) parseComplexMapping() (*ast.ObjectNode, error) {
 
 		// Convert key node to string
 		key := stringifyNode(keyNode)
+		if err := p.checkKey(key); err != nil {
+			return nil, fmt.Errorf("in complex key: %w", err)
+		}
+		if _, exists := properties[key]; !exists {
+			keys = p.addKey(keys, key)
+		}
 
 		// Expect newline or colon
 		p.skipWhitespaceAndComments()
 
-		// Expect :
+		// The ": value" part is optional; a key without it maps to null
 		if p.peek() == nil || p.peek().Kind() != tokenizer.TokenColon {
-			return nil, fmt.Errorf("expected ':' after complex key at %s", p.positionStr())
+			if !p.atComplexEntryEnd() {
+				return nil, fmt.Errorf("expected ':' or next '?' after complex key at %s", p.positionStr())
+			}
+			properties[key] = ast.NewLiteralNode(nil, p.position())
+			continue
 		}
 		p.advance() // consume :
 
 		// Skip whitespace
 		p.skipWhitespaceAndComments()
 
-		// Parse value
-		value, err := p.parseNode()
-		if err != nil {
+		// Parse value, null if omitted
+		var value ast.SchemaNode
+		if p.atComplexEntryEnd() {
+			value = ast.NewLiteralNode(nil, p.position())
+		} else if value, err = p.parseNode(); err != nil {
 			return nil, fmt.Errorf("in value for complex key: %w", err)
 		}
 
@@ -1440,22 +1917,72 @@ func (p *Parser) parseComplexMapping() (*ast.ObjectNode, error) {
 		p.skipWhitespaceAndComments()
 	}
 
-	return ast.NewObjectNode(properties, startPos), nil
+	node := ast.NewObjectNode(properties, startPos)
+	p.saveKeyOrder(node, keys)
+	return node, nil
 }
 
-// stringifyNode converts an AST node to a string representation for use as a key.
+// atComplexEntryEnd reports whether the current token ends an entry of a
+// complex mapping: the next "?", a dedent, a document marker or the end of
+// input.
+func (p *Parser) atComplexEntryEnd() bool {
+	token := p.peek()
+	if token == nil || !p.hasToken {
+		return true
+	}
+	switch token.Kind() {
+	case tokenizer.TokenQuestion, tokenizer.TokenDedent, tokenizer.TokenDocSep, tokenizer.TokenDocEnd, tokenizer.TokenEOF:
+		return true
+	}
+	return false
+}
+
+// stringifyNode converts an AST node to a string for use as a key. A scalar
+// key is its value as text; a collection key has the canonical encoding of
+// internal/canonkey, so equal keys always stringify alike and duplicate
+// detection sees them.
 func stringifyNode(node ast.SchemaNode) string {
 	switch n := node.(type) {
 	case *ast.LiteralNode:
+		if n.Value() == nil {
+			return "null"
+		}
 		return fmt.Sprintf("%v", n.Value())
+	case *ast.ObjectNode, *ast.ArrayDataNode:
+		return canonkey.Encode(keyValue(n))
+	default:
+		return fmt.Sprintf("%v", node)
+	}
+}
+
+// keyValue converts a node of a collection key to the values canonkey.Encode
+// takes.
+func keyValue(node ast.SchemaNode) interface{} {
+	switch n := node.(type) {
+	case *ast.LiteralNode:
+		return n.Value()
+	case *ast.ArrayDataNode:
+		seq := make([]interface{}, n.Len())
+		for i, elem := range n.Elements() {
+			seq[i] = keyValue(elem)
+		}
+		return seq
 	case *ast.ObjectNode:
-		// Stringify object - could use JSON-like format
-		parts := []string{}
-		for k, v := range n.Properties() {
-			parts = append(parts, fmt.Sprintf("%s: %s", k, stringifyNode(v)))
+		props := n.Properties()
+		m := make(map[string]interface{}, len(props))
+		for k, v := range props {
+			m[k] = keyValue(v)
 		}
-		return "{" + strings.Join(parts, ", ") + "}"
+		return m
 	default:
 		return fmt.Sprintf("%v", node)
 	}
 }
+
+// IsEmptyDocument reports whether node is what Parse returns for a document
+// with no content: an empty mapping without a source position, unlike the
+// mapping of an explicit {}.
+func IsEmptyDocument(node ast.SchemaNode) bool {
+	obj, ok := node.(*ast.ObjectNode)
+	return ok && len(obj.Properties()) == 0 && obj.Position() == ast.ZeroPosition()
+}
@@ -196,11 +196,11 @@ func TestParseIndentationEdgeCases(t *testing.T) {
   - item2
   - item3`,
 			check: func(t *testing.T, obj *ast.ObjectNode) {
-				items := assertObjectNode(t, obj.Properties()["items"])
-				assertPropertyCount(t, items, 3)
-				assertLiteralValue(t, items.Properties()["0"], "item1")
-				assertLiteralValue(t, items.Properties()["1"], "item2")
-				assertLiteralValue(t, items.Properties()["2"], "item3")
+				items := assertSequenceNode(t, obj.Properties()["items"])
+				assertElementCount(t, items, 3)
+				assertLiteralValue(t, items.Get(0), "item1")
+				assertLiteralValue(t, items.Get(1), "item2")
+				assertLiteralValue(t, items.Get(2), "item3")
 			},
 		},
 	}
@@ -235,8 +235,8 @@ func TestParseFlowStyleEdgeCases(t *testing.T) {
 			name:  "empty flow sequence",
 			input: "[]",
 			check: func(t *testing.T, node ast.SchemaNode) {
-				obj := assertObjectNode(t, node)
-				assertPropertyCount(t, obj, 0)
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 0)
 			},
 		},
 		{
@@ -253,11 +253,11 @@ func TestParseFlowStyleEdgeCases(t *testing.T) {
 			name:  "flow sequence with spaces",
 			input: "[ 1 , 2 , 3 ]",
 			check: func(t *testing.T, node ast.SchemaNode) {
-				obj := assertObjectNode(t, node)
-				assertPropertyCount(t, obj, 3)
-				assertLiteralValue(t, obj.Properties()["0"], int64(1))
-				assertLiteralValue(t, obj.Properties()["1"], int64(2))
-				assertLiteralValue(t, obj.Properties()["2"], int64(3))
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 3)
+				assertLiteralValue(t, seq.Get(0), int64(1))
+				assertLiteralValue(t, seq.Get(1), int64(2))
+				assertLiteralValue(t, seq.Get(2), int64(3))
 			},
 		},
 		{
@@ -273,13 +273,13 @@ func TestParseFlowStyleEdgeCases(t *testing.T) {
 			name:  "nested flow sequences",
 			input: "[[1, 2], [3, 4]]",
 			check: func(t *testing.T, node ast.SchemaNode) {
-				obj := assertObjectNode(t, node)
-				first := assertObjectNode(t, obj.Properties()["0"])
-				second := assertObjectNode(t, obj.Properties()["1"])
-				assertLiteralValue(t, first.Properties()["0"], int64(1))
-				assertLiteralValue(t, first.Properties()["1"], int64(2))
-				assertLiteralValue(t, second.Properties()["0"], int64(3))
-				assertLiteralValue(t, second.Properties()["1"], int64(4))
+				seq := assertSequenceNode(t, node)
+				first := assertSequenceNode(t, seq.Get(0))
+				second := assertSequenceNode(t, seq.Get(1))
+				assertLiteralValue(t, first.Get(0), int64(1))
+				assertLiteralValue(t, first.Get(1), int64(2))
+				assertLiteralValue(t, second.Get(0), int64(3))
+				assertLiteralValue(t, second.Get(1), int64(4))
 			},
 		},
 		{
@@ -447,20 +447,22 @@ func TestParseMixedBlockAndFlowStyles(t *testing.T) {
 	tests := []struct {
 		name  string
 		input string
-		check func(*testing.T, *ast.ObjectNode)
+		check func(*testing.T, ast.SchemaNode)
 	}{
 		{
 			name:  "block mapping with flow sequence value",
 			input: `items: [1, 2, 3]`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				items := assertObjectNode(t, obj.Properties()["items"])
-				assertPropertyCount(t, items, 3)
+			check: func(t *testing.T, node ast.SchemaNode) {
+				obj := assertObjectNode(t, node)
+				items := assertSequenceNode(t, obj.Properties()["items"])
+				assertElementCount(t, items, 3)
 			},
 		},
 		{
 			name:  "block mapping with flow mapping value",
 			input: `config: {debug: true, verbose: false}`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
+			check: func(t *testing.T, node ast.SchemaNode) {
+				obj := assertObjectNode(t, node)
 				config := assertObjectNode(t, obj.Properties()["config"])
 				assertPropertyCount(t, config, 2)
 			},
@@ -469,9 +471,10 @@ func TestParseMixedBlockAndFlowStyles(t *testing.T) {
 			name: "block sequence with flow mapping items",
 			input: `- {name: Alice, age: 30}
 - {name: Bob, age: 25}`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				assertPropertyCount(t, obj, 2)
-				first := assertObjectNode(t, obj.Properties()["0"])
+			check: func(t *testing.T, node ast.SchemaNode) {
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 2)
+				first := assertObjectNode(t, seq.Get(0))
 				assertLiteralValue(t, first.Properties()["name"], "Alice")
 			},
 		},
@@ -482,8 +485,7 @@ func TestParseMixedBlockAndFlowStyles(t *testing.T) {
 			p := NewParser(tt.input)
 			node, err := p.Parse()
 			assertNoError(t, err)
-			obj := assertObjectNode(t, node)
-			tt.check(t, obj)
+			tt.check(t, node)
 		})
 	}
 }
@@ -496,14 +498,14 @@ func TestParseErrorsExtended(t *testing.T) {
 	}{
 		// Flow style errors
 		{"missing comma in flow mapping", "{key1: value1 key2: value2}"},
-		{"missing comma in flow sequence", "[1 2 3]"},
+		{"missing comma in flow sequence", "[[1, 2] [3]]"},
 		{"unclosed nested flow mapping", "{outer: {inner: value}"},
 		{"unclosed nested flow sequence", "[[1, 2], [3, 4]"},
 
 		// Structure errors
 		{"colon without key", ": value"},
-		{"dash without value at end", "items:\n  - item1\n  -"},
-		{"multiple colons", "key:: value"},
+		{"multiple colons", "key: : value"},
+		{"dash on the line of a key", "items: - item1"},
 	}
 
 	for _, tt := range tests {
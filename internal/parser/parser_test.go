@@ -31,6 +31,15 @@ func assertObjectNode(t *testing.T, node ast.SchemaNode) *ast.ObjectNode {
 	return obj
 }
 
+func assertSequenceNode(t *testing.T, node ast.SchemaNode) *ast.ArrayDataNode {
+	t.Helper()
+	seq, ok := node.(*ast.ArrayDataNode)
+	if !ok {
+		t.Fatalf("expected *ast.ArrayDataNode, got %T", node)
+	}
+	return seq
+}
+
 func assertLiteralNode(t *testing.T, node ast.SchemaNode) *ast.LiteralNode {
 	t.Helper()
 	lit, ok := node.(*ast.LiteralNode)
@@ -48,6 +57,14 @@ func assertLiteralValue(t *testing.T, node ast.SchemaNode, expected interface{})
 	}
 }
 
+func assertNullProperty(t *testing.T, obj *ast.ObjectNode, key string) {
+	t.Helper()
+	lit, ok := obj.Properties()[key].(*ast.LiteralNode)
+	if !ok || lit.Value() != nil {
+		t.Errorf("%q = %v, want null", key, obj.Properties()[key])
+	}
+}
+
 func assertPropertyCount(t *testing.T, obj *ast.ObjectNode, expected int) {
 	t.Helper()
 	if len(obj.Properties()) != expected {
@@ -55,6 +72,13 @@ func assertPropertyCount(t *testing.T, obj *ast.ObjectNode, expected int) {
 	}
 }
 
+func assertElementCount(t *testing.T, seq *ast.ArrayDataNode, expected int) {
+	t.Helper()
+	if seq.Len() != expected {
+		t.Errorf("expected %d elements, got %d", expected, seq.Len())
+	}
+}
+
 // Test empty document
 func TestParseEmptyDocument(t *testing.T) {
 	tests := []struct {
@@ -204,6 +228,22 @@ address:
 				assertLiteralValue(t, obj.Properties()["other"], "value")
 			},
 		},
+		{
+			name:  "number, bool and null keys",
+			input: "0: zero\n1.5: x\n010: octal\ntrue: yes\nnull: none\nname: {2: two, false: f}",
+			check: func(t *testing.T, obj *ast.ObjectNode) {
+				assertPropertyCount(t, obj, 6)
+				assertLiteralValue(t, obj.Properties()["0"], "zero")
+				assertLiteralValue(t, obj.Properties()["1.5"], "x")
+				assertLiteralValue(t, obj.Properties()["010"], "octal")
+				assertLiteralValue(t, obj.Properties()["true"], true)
+				assertLiteralValue(t, obj.Properties()["null"], "none")
+
+				flow := assertObjectNode(t, obj.Properties()["name"])
+				assertLiteralValue(t, flow.Properties()["2"], "two")
+				assertLiteralValue(t, flow.Properties()["false"], "f")
+			},
+		},
 		{
 			name:  "mapping with boolean values",
 			input: "enabled: true\ndisabled: false",
@@ -231,26 +271,28 @@ func TestParseBlockSequence(t *testing.T) {
 	tests := []struct {
 		name  string
 		input string
-		check func(*testing.T, *ast.ObjectNode)
+		check func(*testing.T, ast.SchemaNode)
 	}{
 		{
 			name:  "simple sequence",
 			input: "- apple\n- banana\n- cherry",
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				assertPropertyCount(t, obj, 3)
-				assertLiteralValue(t, obj.Properties()["0"], "apple")
-				assertLiteralValue(t, obj.Properties()["1"], "banana")
-				assertLiteralValue(t, obj.Properties()["2"], "cherry")
+			check: func(t *testing.T, node ast.SchemaNode) {
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 3)
+				assertLiteralValue(t, seq.Get(0), "apple")
+				assertLiteralValue(t, seq.Get(1), "banana")
+				assertLiteralValue(t, seq.Get(2), "cherry")
 			},
 		},
 		{
 			name:  "sequence of numbers",
 			input: "- 1\n- 2\n- 3",
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				assertPropertyCount(t, obj, 3)
-				assertLiteralValue(t, obj.Properties()["0"], int64(1))
-				assertLiteralValue(t, obj.Properties()["1"], int64(2))
-				assertLiteralValue(t, obj.Properties()["2"], int64(3))
+			check: func(t *testing.T, node ast.SchemaNode) {
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 3)
+				assertLiteralValue(t, seq.Get(0), int64(1))
+				assertLiteralValue(t, seq.Get(1), int64(2))
+				assertLiteralValue(t, seq.Get(2), int64(3))
 			},
 		},
 		{
@@ -259,26 +301,28 @@ func TestParseBlockSequence(t *testing.T) {
 - fruits:
   - orange
   - grape`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				assertPropertyCount(t, obj, 2)
-				assertLiteralValue(t, obj.Properties()["0"], "apple")
+			check: func(t *testing.T, node ast.SchemaNode) {
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 2)
+				assertLiteralValue(t, seq.Get(0), "apple")
 
-				item1 := assertObjectNode(t, obj.Properties()["1"])
+				item1 := assertObjectNode(t, seq.Get(1))
 				assertPropertyCount(t, item1, 1)
 
-				fruits := assertObjectNode(t, item1.Properties()["fruits"])
-				assertPropertyCount(t, fruits, 2)
-				assertLiteralValue(t, fruits.Properties()["0"], "orange")
-				assertLiteralValue(t, fruits.Properties()["1"], "grape")
+				fruits := assertSequenceNode(t, item1.Properties()["fruits"])
+				assertElementCount(t, fruits, 2)
+				assertLiteralValue(t, fruits.Get(0), "orange")
+				assertLiteralValue(t, fruits.Get(1), "grape")
 			},
 		},
 		{
 			name:  "sequence with null item",
 			input: "-\n- value",
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				assertPropertyCount(t, obj, 2)
-				assertLiteralValue(t, obj.Properties()["0"], nil)
-				assertLiteralValue(t, obj.Properties()["1"], "value")
+			check: func(t *testing.T, node ast.SchemaNode) {
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 2)
+				assertLiteralValue(t, seq.Get(0), nil)
+				assertLiteralValue(t, seq.Get(1), "value")
 			},
 		},
 	}
@@ -288,8 +332,7 @@ func TestParseBlockSequence(t *testing.T) {
 			p := NewParser(tt.input)
 			node, err := p.Parse()
 			assertNoError(t, err)
-			obj := assertObjectNode(t, node)
-			tt.check(t, obj)
+			tt.check(t, node)
 		})
 	}
 }
@@ -299,12 +342,13 @@ func TestParseFlowStyle(t *testing.T) {
 	tests := []struct {
 		name  string
 		input string
-		check func(*testing.T, *ast.ObjectNode)
+		check func(*testing.T, ast.SchemaNode)
 	}{
 		{
 			name:  "flow mapping",
 			input: `{name: Alice, age: 30}`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
+			check: func(t *testing.T, node ast.SchemaNode) {
+				obj := assertObjectNode(t, node)
 				assertPropertyCount(t, obj, 2)
 				assertLiteralValue(t, obj.Properties()["name"], "Alice")
 				assertLiteralValue(t, obj.Properties()["age"], int64(30))
@@ -313,17 +357,19 @@ func TestParseFlowStyle(t *testing.T) {
 		{
 			name:  "flow sequence",
 			input: `[1, 2, 3]`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				assertPropertyCount(t, obj, 3)
-				assertLiteralValue(t, obj.Properties()["0"], int64(1))
-				assertLiteralValue(t, obj.Properties()["1"], int64(2))
-				assertLiteralValue(t, obj.Properties()["2"], int64(3))
+			check: func(t *testing.T, node ast.SchemaNode) {
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 3)
+				assertLiteralValue(t, seq.Get(0), int64(1))
+				assertLiteralValue(t, seq.Get(1), int64(2))
+				assertLiteralValue(t, seq.Get(2), int64(3))
 			},
 		},
 		{
 			name:  "nested flow mapping",
 			input: `{person: {name: Alice, age: 30}}`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
+			check: func(t *testing.T, node ast.SchemaNode) {
+				obj := assertObjectNode(t, node)
 				assertPropertyCount(t, obj, 1)
 
 				person := assertObjectNode(t, obj.Properties()["person"])
@@ -335,32 +381,57 @@ func TestParseFlowStyle(t *testing.T) {
 		{
 			name:  "nested flow sequence",
 			input: `[[1, 2], [3, 4]]`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				assertPropertyCount(t, obj, 2)
-
-				seq0 := assertObjectNode(t, obj.Properties()["0"])
-				assertPropertyCount(t, seq0, 2)
-				assertLiteralValue(t, seq0.Properties()["0"], int64(1))
-				assertLiteralValue(t, seq0.Properties()["1"], int64(2))
-
-				seq1 := assertObjectNode(t, obj.Properties()["1"])
-				assertPropertyCount(t, seq1, 2)
-				assertLiteralValue(t, seq1.Properties()["0"], int64(3))
-				assertLiteralValue(t, seq1.Properties()["1"], int64(4))
+			check: func(t *testing.T, node ast.SchemaNode) {
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 2)
+
+				seq0 := assertSequenceNode(t, seq.Get(0))
+				assertElementCount(t, seq0, 2)
+				assertLiteralValue(t, seq0.Get(0), int64(1))
+				assertLiteralValue(t, seq0.Get(1), int64(2))
+
+				seq1 := assertSequenceNode(t, seq.Get(1))
+				assertElementCount(t, seq1, 2)
+				assertLiteralValue(t, seq1.Get(0), int64(3))
+				assertLiteralValue(t, seq1.Get(1), int64(4))
 			},
 		},
 		{
 			name:  "empty flow mapping",
 			input: `{}`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
+			check: func(t *testing.T, node ast.SchemaNode) {
+				obj := assertObjectNode(t, node)
 				assertPropertyCount(t, obj, 0)
 			},
 		},
 		{
 			name:  "empty flow sequence",
 			input: `[]`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				assertPropertyCount(t, obj, 0)
+			check: func(t *testing.T, node ast.SchemaNode) {
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 0)
+			},
+		},
+		{
+			name:  "multi-line flow under a key",
+			input: "key: [\n    1,\n  2]\nnext: {\n  a: 1\n}\n",
+			check: func(t *testing.T, node ast.SchemaNode) {
+				obj := assertObjectNode(t, node)
+				assertPropertyCount(t, obj, 2)
+				seq := assertSequenceNode(t, obj.Properties()["key"])
+				assertElementCount(t, seq, 2)
+				assertLiteralValue(t, seq.Get(1), int64(2))
+				next := assertObjectNode(t, obj.Properties()["next"])
+				assertLiteralValue(t, next.Properties()["a"], int64(1))
+			},
+		},
+		{
+			name:  "indented flow mapping at root",
+			input: "\t{\"a\":\t[\n1]}\n",
+			check: func(t *testing.T, node ast.SchemaNode) {
+				obj := assertObjectNode(t, node)
+				seq := assertSequenceNode(t, obj.Properties()["a"])
+				assertLiteralValue(t, seq.Get(0), int64(1))
 			},
 		},
 	}
@@ -370,8 +441,7 @@ func TestParseFlowStyle(t *testing.T) {
 			p := NewParser(tt.input)
 			node, err := p.Parse()
 			assertNoError(t, err)
-			obj := assertObjectNode(t, node)
-			tt.check(t, obj)
+			tt.check(t, node)
 		})
 	}
 }
@@ -381,35 +451,37 @@ func TestParseMixedStyles(t *testing.T) {
 	tests := []struct {
 		name  string
 		input string
-		check func(*testing.T, *ast.ObjectNode)
+		check func(*testing.T, ast.SchemaNode)
 	}{
 		{
 			name: "block mapping with flow sequence",
 			input: `name: Alice
 tags: [admin, user]`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
+			check: func(t *testing.T, node ast.SchemaNode) {
+				obj := assertObjectNode(t, node)
 				assertPropertyCount(t, obj, 2)
 				assertLiteralValue(t, obj.Properties()["name"], "Alice")
 
-				tags := assertObjectNode(t, obj.Properties()["tags"])
-				assertPropertyCount(t, tags, 2)
-				assertLiteralValue(t, tags.Properties()["0"], "admin")
-				assertLiteralValue(t, tags.Properties()["1"], "user")
+				tags := assertSequenceNode(t, obj.Properties()["tags"])
+				assertElementCount(t, tags, 2)
+				assertLiteralValue(t, tags.Get(0), "admin")
+				assertLiteralValue(t, tags.Get(1), "user")
 			},
 		},
 		{
 			name: "flow mapping in block sequence",
 			input: `- {name: Alice, age: 30}
 - {name: Bob, age: 25}`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				assertPropertyCount(t, obj, 2)
+			check: func(t *testing.T, node ast.SchemaNode) {
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 2)
 
-				item0 := assertObjectNode(t, obj.Properties()["0"])
+				item0 := assertObjectNode(t, seq.Get(0))
 				assertPropertyCount(t, item0, 2)
 				assertLiteralValue(t, item0.Properties()["name"], "Alice")
 				assertLiteralValue(t, item0.Properties()["age"], int64(30))
 
-				item1 := assertObjectNode(t, obj.Properties()["1"])
+				item1 := assertObjectNode(t, seq.Get(1))
 				assertPropertyCount(t, item1, 2)
 				assertLiteralValue(t, item1.Properties()["name"], "Bob")
 				assertLiteralValue(t, item1.Properties()["age"], int64(25))
@@ -422,8 +494,7 @@ tags: [admin, user]`,
 			p := NewParser(tt.input)
 			node, err := p.Parse()
 			assertNoError(t, err)
-			obj := assertObjectNode(t, node)
-			tt.check(t, obj)
+			tt.check(t, node)
 		})
 	}
 }
@@ -462,14 +533,15 @@ func TestParseComments(t *testing.T) {
 	tests := []struct {
 		name  string
 		input string
-		check func(*testing.T, *ast.ObjectNode)
+		check func(*testing.T, ast.SchemaNode)
 	}{
 		{
 			name: "comments in mapping",
 			input: `# This is a person
 name: Alice  # First name
 age: 30      # Years old`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
+			check: func(t *testing.T, node ast.SchemaNode) {
+				obj := assertObjectNode(t, node)
 				assertPropertyCount(t, obj, 2)
 				assertLiteralValue(t, obj.Properties()["name"], "Alice")
 				assertLiteralValue(t, obj.Properties()["age"], int64(30))
@@ -480,10 +552,11 @@ age: 30      # Years old`,
 			input: `# List of fruits
 - apple   # Red fruit
 - banana  # Yellow fruit`,
-			check: func(t *testing.T, obj *ast.ObjectNode) {
-				assertPropertyCount(t, obj, 2)
-				assertLiteralValue(t, obj.Properties()["0"], "apple")
-				assertLiteralValue(t, obj.Properties()["1"], "banana")
+			check: func(t *testing.T, node ast.SchemaNode) {
+				seq := assertSequenceNode(t, node)
+				assertElementCount(t, seq, 2)
+				assertLiteralValue(t, seq.Get(0), "apple")
+				assertLiteralValue(t, seq.Get(1), "banana")
 			},
 		},
 	}
@@ -493,8 +566,7 @@ age: 30      # Years old`,
 			p := NewParser(tt.input)
 			node, err := p.Parse()
 			assertNoError(t, err)
-			obj := assertObjectNode(t, node)
-			tt.check(t, obj)
+			tt.check(t, node)
 		})
 	}
 }
@@ -541,16 +613,16 @@ func TestParseComplexStructures(t *testing.T) {
 
 				assertLiteralValue(t, person.Properties()["name"], "Alice")
 
-				hobbies := assertObjectNode(t, person.Properties()["hobbies"])
-				assertPropertyCount(t, hobbies, 2)
-				assertLiteralValue(t, hobbies.Properties()["0"], "reading")
-				assertLiteralValue(t, hobbies.Properties()["1"], "coding")
+				hobbies := assertSequenceNode(t, person.Properties()["hobbies"])
+				assertElementCount(t, hobbies, 2)
+				assertLiteralValue(t, hobbies.Get(0), "reading")
+				assertLiteralValue(t, hobbies.Get(1), "coding")
 
-				scores := assertObjectNode(t, person.Properties()["scores"])
-				assertPropertyCount(t, scores, 3)
-				assertLiteralValue(t, scores.Properties()["0"], int64(95))
-				assertLiteralValue(t, scores.Properties()["1"], int64(87))
-				assertLiteralValue(t, scores.Properties()["2"], int64(92))
+				scores := assertSequenceNode(t, person.Properties()["scores"])
+				assertElementCount(t, scores, 3)
+				assertLiteralValue(t, scores.Get(0), int64(95))
+				assertLiteralValue(t, scores.Get(1), int64(87))
+				assertLiteralValue(t, scores.Get(2), int64(92))
 			},
 		},
 	}
@@ -572,10 +644,10 @@ func TestParseErrors(t *testing.T) {
 		name  string
 		input string
 	}{
-		{"missing colon", "key value"},
+		{"missing colon", "name: a\nkey value"},
 		{"duplicate key", "key: value1\nkey: value2"},
 		{"undefined alias", "*undefined"},
-		{"invalid flow mapping", "{key value}"},
+		{"invalid flow mapping", "{key: value: x}"},
 		{"unclosed flow mapping", "{key: value"},
 		{"unclosed flow sequence", "[1, 2"},
 		{"trailing comma in flow mapping", "{key: value,}"},
@@ -591,6 +663,53 @@ func TestParseErrors(t *testing.T) {
 	}
 }
 
+// TestParsePlainScalarBoundaries verifies that plain scalars extend across interior
+// spaces and non-indicator colons, and that keywords/numbers only match whole scalars.
+func TestParsePlainScalarBoundaries(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"interior spaces", "v: hello world", "hello world"},
+		{"no mapping without colon-space", "key value", "key value"},
+		{"trailing comment", "v: hello world # note", "hello world"},
+		{"url", "v: http://example.com:8080/path", "http://example.com:8080/path"},
+		{"time-like", "v: 12:30", "12:30"},
+		{"date-like", "v: 2001-12-14", "2001-12-14"},
+		{"number then text", "v: 1 2", "1 2"},
+		{"keyword then text", "v: true story", "true story"},
+		{"keyword prefix", "v: nullable", "nullable"},
+		{"hash without space", "v: C#", "C#"},
+		{"double colon key", "key:: value", "value"},
+		{"flow element with spaces", "v: [1 2 3]", "1 2 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.input)
+			node, err := p.Parse()
+			assertNoError(t, err)
+
+			var got interface{}
+			switch n := node.(type) {
+			case *ast.LiteralNode:
+				got = n.Value()
+			case *ast.ObjectNode:
+				for _, prop := range n.Properties() {
+					if seq, ok := prop.(*ast.ArrayDataNode); ok {
+						prop = seq.Get(0)
+					}
+					got = prop.(*ast.LiteralNode).Value()
+				}
+			}
+			if got != tt.expected {
+				t.Errorf("value = %#v, want %#v", got, tt.expected)
+			}
+		})
+	}
+}
+
 // Test position tracking
 func TestParsePositions(t *testing.T) {
 	input := "name: Alice\nage: 30"
@@ -818,13 +937,13 @@ copy: *base`,
   - banana
 copy: *items`,
 			check: func(t *testing.T, obj *ast.ObjectNode) {
-				items := assertObjectNode(t, obj.Properties()["items"])
-				assertLiteralValue(t, items.Properties()["0"], "apple")
-				assertLiteralValue(t, items.Properties()["1"], "banana")
+				items := assertSequenceNode(t, obj.Properties()["items"])
+				assertLiteralValue(t, items.Get(0), "apple")
+				assertLiteralValue(t, items.Get(1), "banana")
 
-				copy := assertObjectNode(t, obj.Properties()["copy"])
-				assertLiteralValue(t, copy.Properties()["0"], "apple")
-				assertLiteralValue(t, copy.Properties()["1"], "banana")
+				copy := assertSequenceNode(t, obj.Properties()["copy"])
+				assertLiteralValue(t, copy.Get(0), "apple")
+				assertLiteralValue(t, copy.Get(1), "banana")
 			},
 		},
 	}
@@ -921,6 +1040,37 @@ func TestParseComplexKey(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:  "keys without values",
+			input: "? a\n? b\n",
+			check: func(t *testing.T, obj *ast.ObjectNode) {
+				assertNullProperty(t, obj, "a")
+				assertNullProperty(t, obj, "b")
+			},
+		},
+		{
+			name:  "mixed omitted and present values",
+			input: "? a\n: 1\n? b\n? c\n:\n? d\n: 4",
+			check: func(t *testing.T, obj *ast.ObjectNode) {
+				assertLiteralValue(t, obj.Properties()["a"], int64(1))
+				assertNullProperty(t, obj, "b")
+				assertNullProperty(t, obj, "c")
+				assertLiteralValue(t, obj.Properties()["d"], int64(4))
+			},
+		},
+		{
+			name:  "nested keys without values",
+			input: "m:\n  ? a\n  ? b\nn: 1",
+			check: func(t *testing.T, obj *ast.ObjectNode) {
+				m, ok := obj.Properties()["m"].(*ast.ObjectNode)
+				if !ok {
+					t.Fatalf("m = %T, want *ast.ObjectNode", obj.Properties()["m"])
+				}
+				assertNullProperty(t, m, "a")
+				assertNullProperty(t, m, "b")
+				assertLiteralValue(t, obj.Properties()["n"], int64(1))
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -933,6 +1083,76 @@ func TestParseComplexKey(t *testing.T) {
 	}
 }
 
+// Test that sequences and mappings with integer keys stay distinct
+func TestParseSequenceVersusIntegerKeys(t *testing.T) {
+	for _, input := range []string{"{0: a, 1: b}", "0: a\n1: b"} {
+		p := NewParser(input)
+		node, err := p.Parse()
+		assertNoError(t, err)
+		obj := assertObjectNode(t, node)
+		assertPropertyCount(t, obj, 2)
+		assertLiteralValue(t, obj.Properties()["0"], "a")
+	}
+	for _, input := range []string{"[a, b]", "- a\n- b"} {
+		p := NewParser(input)
+		node, err := p.Parse()
+		assertNoError(t, err)
+		seq := assertSequenceNode(t, node)
+		assertElementCount(t, seq, 2)
+		assertLiteralValue(t, seq.Get(0), "a")
+	}
+
+	p := NewParser("{[]: a, {}: b}")
+	node, err := p.Parse()
+	assertNoError(t, err)
+	assertPropertyCount(t, assertObjectNode(t, node), 2)
+}
+
+// Test collections as keys in flow mappings
+func TestParseFlowComplexKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		key   string
+	}{
+		{"sequence as key", "{[1,2]: pair}", "[1,2]"},
+		{"mapping as key", "{ {a: 1}: x }", `{"a":1}`},
+		{"nested collections sorted", "{ {b: [x, ~], a: 1}: x }", `{"a":1,"b":["x",null]}`},
+		{"same as block complex key", "? [1, 2]\n: x", "[1,2]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.input)
+			node, err := p.Parse()
+			assertNoError(t, err)
+			obj := assertObjectNode(t, node)
+			if _, ok := obj.Properties()[tt.key]; !ok || len(obj.Properties()) != 1 {
+				t.Errorf("keys = %v, want only %q", obj.Properties(), tt.key)
+			}
+		})
+	}
+
+	p := NewParser("{[1, 2]: a, [1,2]: b}")
+	if _, err := p.Parse(); err == nil {
+		t.Error("expected duplicate key error for equal collection keys")
+	}
+
+	// Keys that only look alike are distinct.
+	for _, input := range []string{
+		`{["a, b"]: 1, [a, b]: 2}`,
+		`{["1"]: 1, [1]: 2}`,
+		`{{a: "b: c"}: 1, {a: b, c: ~}: 2}`,
+	} {
+		p := NewParser(input)
+		node, err := p.Parse()
+		assertNoError(t, err)
+		if n := len(assertObjectNode(t, node).Properties()); n != 2 {
+			t.Errorf("%s: got %d keys, want 2", input, n)
+		}
+	}
+}
+
 // Test lists under keys (Bug 1 - should already be fixed)
 func TestParseListsUnderKeys(t *testing.T) {
 	tests := []struct {
@@ -946,9 +1166,9 @@ func TestParseListsUnderKeys(t *testing.T) {
   - apple
   - banana`,
 			check: func(t *testing.T, obj *ast.ObjectNode) {
-				items := assertObjectNode(t, obj.Properties()["items"])
-				assertLiteralValue(t, items.Properties()["0"], "apple")
-				assertLiteralValue(t, items.Properties()["1"], "banana")
+				items := assertSequenceNode(t, obj.Properties()["items"])
+				assertLiteralValue(t, items.Get(0), "apple")
+				assertLiteralValue(t, items.Get(1), "banana")
 			},
 		},
 		{
@@ -960,13 +1180,13 @@ vegetables:
   - carrot
   - celery`,
 			check: func(t *testing.T, obj *ast.ObjectNode) {
-				fruits := assertObjectNode(t, obj.Properties()["fruits"])
-				assertLiteralValue(t, fruits.Properties()["0"], "apple")
-				assertLiteralValue(t, fruits.Properties()["1"], "banana")
+				fruits := assertSequenceNode(t, obj.Properties()["fruits"])
+				assertLiteralValue(t, fruits.Get(0), "apple")
+				assertLiteralValue(t, fruits.Get(1), "banana")
 
-				veggies := assertObjectNode(t, obj.Properties()["vegetables"])
-				assertLiteralValue(t, veggies.Properties()["0"], "carrot")
-				assertLiteralValue(t, veggies.Properties()["1"], "celery")
+				veggies := assertSequenceNode(t, obj.Properties()["vegetables"])
+				assertLiteralValue(t, veggies.Get(0), "carrot")
+				assertLiteralValue(t, veggies.Get(1), "celery")
 			},
 		},
 		{
@@ -977,13 +1197,13 @@ vegetables:
   - name: Bob
     age: 25`,
 			check: func(t *testing.T, obj *ast.ObjectNode) {
-				people := assertObjectNode(t, obj.Properties()["people"])
+				people := assertSequenceNode(t, obj.Properties()["people"])
 
-				alice := assertObjectNode(t, people.Properties()["0"])
+				alice := assertObjectNode(t, people.Get(0))
 				assertLiteralValue(t, alice.Properties()["name"], "Alice")
 				assertLiteralValue(t, alice.Properties()["age"], int64(30))
 
-				bob := assertObjectNode(t, people.Properties()["1"])
+				bob := assertObjectNode(t, people.Get(1))
 				assertLiteralValue(t, bob.Properties()["name"], "Bob")
 				assertLiteralValue(t, bob.Properties()["age"], int64(25))
 			},
@@ -1057,12 +1277,12 @@ func TestCaseInsensitiveBooleansInSequences(t *testing.T) {
 	assertNoError(t, err)
 
 	obj := assertObjectNode(t, node)
-	flags := assertObjectNode(t, obj.Properties()["flags"])
-
-	assertLiteralValue(t, flags.Properties()["0"], true)
-	assertLiteralValue(t, flags.Properties()["1"], false)
-	assertLiteralValue(t, flags.Properties()["2"], true)
-	assertLiteralValue(t, flags.Properties()["3"], false)
-	assertLiteralValue(t, flags.Properties()["4"], true)
-	assertLiteralValue(t, flags.Properties()["5"], false)
+	flags := assertSequenceNode(t, obj.Properties()["flags"])
+
+	assertLiteralValue(t, flags.Get(0), true)
+	assertLiteralValue(t, flags.Get(1), false)
+	assertLiteralValue(t, flags.Get(2), true)
+	assertLiteralValue(t, flags.Get(3), false)
+	assertLiteralValue(t, flags.Get(4), true)
+	assertLiteralValue(t, flags.Get(5), false)
 }
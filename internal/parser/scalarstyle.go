@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"strconv"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+// ScalarStyle is the way a scalar is written in the source.
+type ScalarStyle int
+
+const (
+	Plain        ScalarStyle = iota // unquoted, as in name: api
+	SingleQuoted                    // 'api'
+	DoubleQuoted                    // "api"
+	Literal                         // a | block scalar, keeping line breaks
+	Folded                          // a > block scalar, folding line breaks
+)
+
+var scalarStyleNames = [...]string{
+	Plain:        "Plain",
+	SingleQuoted: "SingleQuoted",
+	DoubleQuoted: "DoubleQuoted",
+	Literal:      "Literal",
+	Folded:       "Folded",
+}
+
+func (s ScalarStyle) String() string {
+	if s >= 0 && int(s) < len(scalarStyleNames) {
+		return scalarStyleNames[s]
+	}
+	return "ScalarStyle(" + strconv.Itoa(int(s)) + ")"
+}
+
+// ScalarStyles maps the scalar nodes written in a style other than Plain to
+// their style.
+type ScalarStyles map[*ast.LiteralNode]ScalarStyle
+
+// Of returns the style node was written in. Plain scalars, nodes that are
+// not scalars and nodes not from the recorded parse report Plain.
+func (s ScalarStyles) Of(node ast.SchemaNode) ScalarStyle {
+	if lit, ok := node.(*ast.LiteralNode); ok {
+		return s[lit]
+	}
+	return Plain
+}
+
+// RecordScalarStyles makes subsequent parsing record the style of every
+// quoted and block scalar, and returns the map the styles are recorded in.
+func (p *Parser) RecordScalarStyles() ScalarStyles {
+	if p.scalarStyles == nil {
+		p.scalarStyles = make(ScalarStyles)
+	}
+	return p.scalarStyles
+}
+
+// recordStyle records the style of node when styles are recorded.
+func (p *Parser) recordStyle(node *ast.LiteralNode, style ScalarStyle) *ast.LiteralNode {
+	if p.scalarStyles != nil && style != Plain {
+		p.scalarStyles[node] = style
+	}
+	return node
+}
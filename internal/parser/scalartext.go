@@ -0,0 +1,29 @@
+package parser
+
+import "github.com/shapestone/shape-core/pkg/ast"
+
+// ScalarTexts maps plain scalar nodes whose value was resolved to a number
+// or bool to their source text, so that 1.10 can be told apart from 1.1.
+type ScalarTexts map[*ast.LiteralNode]string
+
+// RecordScalarTexts makes subsequent parsing record the source text of every
+// plain scalar resolved to a non-string value, and returns the map the texts
+// are recorded in.
+func (p *Parser) RecordScalarTexts() ScalarTexts {
+	if p.scalarTexts == nil {
+		p.scalarTexts = make(ScalarTexts)
+	}
+	return p.scalarTexts
+}
+
+// newResolvedScalar returns a literal node for a plain scalar with source text
+// raw, recording raw when the value is not a string and texts are recorded.
+func (p *Parser) newResolvedScalar(value interface{}, raw string, pos ast.Position) *ast.LiteralNode {
+	node := ast.NewLiteralNode(value, pos)
+	if p.scalarTexts != nil {
+		if _, ok := value.(string); !ok && value != nil {
+			p.scalarTexts[node] = raw
+		}
+	}
+	return node
+}
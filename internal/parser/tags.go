@@ -1,11 +1,14 @@
 package parser
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/determinism"
+	"github.com/shapestone/shape-yaml/internal/resolve"
 	"github.com/shapestone/shape-yaml/internal/tokenizer"
 )
 
@@ -14,12 +17,18 @@ import (
 //
 // Tags can be:
 //   - Core tags: !!str, !!int, !!float, !!bool, !!null, !!map, !!seq
+//   - YAML 1.1 types: !!timestamp, !!binary, !!set, !!omap
 //   - Custom tags: !MyType
 //   - Verbatim tags: !<tag:example.com,2000:type>
 //
-// Core tags override type detection and force specific type interpretation.
-// Custom and verbatim tags are stored as metadata for the application to handle.
+// Core tags override type detection and force specific type interpretation,
+// however they are written: !!int, !<tag:yaml.org,2002:int> and a handle
+// declared for tag:yaml.org,2002: with %TAG all apply. Other tags leave the
+// node as is; RecordTags keeps every tag for the application to handle.
 func (p *Parser) parseTaggedNode() (ast.SchemaNode, error) {
+	if p.trace != nil {
+		defer p.traceLeave(p.traceEnter("parseTaggedNode"))
+	}
 	// Check for tag
 	token := p.peek()
 	if token == nil || token.Kind() != tokenizer.TokenTag {
@@ -29,6 +38,9 @@ func (p *Parser) parseTaggedNode() (ast.SchemaNode, error) {
 
 	// Extract tag value
 	tagValue := string(token.Value())
+	if err := p.checkTag(tagValue); err != nil {
+		return nil, err
+	}
 	p.advance()
 
 	// Skip only inline whitespace after tag (not newlines)
@@ -47,50 +59,124 @@ func (p *Parser) parseTaggedNode() (ast.SchemaNode, error) {
 		}
 	}
 
+	// !!str takes a plain scalar's source text as is; resolving it first
+	// would turn "!!str yes" into "true" and "!!str 0x1F" into "31"
+	if p.expandTag(tagValue) == StrTag {
+		if tok := p.peek(); tok != nil && isResolvedScalarToken(tok.Kind()) {
+			pos := p.position()
+			if err := p.enterNode(); err != nil {
+				return nil, err
+			}
+			p.leaveNode()
+			raw := tok.ValueString()
+			p.advance()
+			return p.recordTag(ast.NewLiteralNode(raw, pos), tagValue), nil
+		}
+	}
+
 	// Parse the node value
-	// If the value is on the next line (with indentation), parseNode will handle it
-	node, err := p.parseNode()
+	node, err := p.parseTaggedValue()
 	if err != nil {
 		return nil, err
 	}
+	if node == nil {
+		// An empty !!str is the empty string; under other tags, null
+		if p.expandTag(tagValue) == StrTag {
+			return p.recordTag(ast.NewLiteralNode("", p.position()), tagValue), nil
+		}
+		node = ast.NewLiteralNode(nil, p.position())
+	}
 
 	// Apply tag transformation
-	return p.applyTag(tagValue, node)
+	node, err = p.applyTag(tagValue, node)
+	if err != nil {
+		return nil, err
+	}
+	return p.recordTag(node, tagValue), nil
+}
+
+// parseTaggedValue parses the node after a tag. A tag at the end of a line
+// tags the block collection indented below it, or at the top of a document
+// the root node on the next line; with neither, the tagged value is empty
+// and parseTaggedValue returns nil.
+func (p *Parser) parseTaggedValue() (ast.SchemaNode, error) {
+	tok := p.peek()
+	if tok == nil || tok.Kind() == tokenizer.TokenEOF || tok.Kind() == tokenizer.TokenDedent {
+		return nil, nil
+	}
+	if tok.Kind() != tokenizer.TokenNewline {
+		return p.parseNode()
+	}
+	p.advance() // consume newline
+	p.skipWhitespaceAndComments()
+
+	next := p.peek()
+	switch {
+	case next != nil && next.Kind() == tokenizer.TokenIndent:
+		p.advance() // consume INDENT
+		node, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != nil && p.peek().Kind() == tokenizer.TokenDedent {
+			p.advance()
+		}
+		return node, nil
+	case p.depth == 1 && next != nil && p.hasToken && next.Kind() != tokenizer.TokenDocSep && next.Kind() != tokenizer.TokenDocEnd:
+		return p.parseNode()
+	default:
+		return nil, nil
+	}
+}
+
+// isResolvedScalarToken reports whether a token kind is a plain scalar the
+// tokenizer has already classified as a non-string type.
+func isResolvedScalarToken(kind string) bool {
+	switch kind {
+	case tokenizer.TokenNumber, tokenizer.TokenTrue, tokenizer.TokenFalse, tokenizer.TokenNull:
+		return true
+	}
+	return false
 }
 
 // applyTag applies a tag to a node, performing type coercion for core tags.
 func (p *Parser) applyTag(tag string, node ast.SchemaNode) (ast.SchemaNode, error) {
 	// Core tags - force type interpretation
-	switch tag {
-	case "!!str":
+	switch p.expandTag(tag) {
+	case StrTag:
 		return p.coerceToString(node)
-	case "!!int":
+	case IntTag:
 		return p.coerceToInt(node)
-	case "!!float":
+	case FloatTag:
 		return p.coerceToFloat(node)
-	case "!!bool":
+	case BoolTag:
 		return p.coerceToBool(node)
-	case "!!null":
+	case NullTag:
 		return ast.NewLiteralNode(nil, node.Position()), nil
-	case "!!map":
+	case TimestampTag:
+		return p.coerceToTimestamp(node)
+	case BinaryTag:
+		return p.coerceToBinary(node)
+	case SetTag:
+		return checkSet(node)
+	case OmapTag:
+		return checkOmap(node)
+	case MapTag:
 		// Map tag - node should already be a mapping
 		if _, ok := node.(*ast.ObjectNode); !ok {
 			return nil, fmt.Errorf("!!map tag applied to non-mapping node")
 		}
 		return node, nil
-	case "!!seq":
+	case SeqTag:
 		// Sequence tag - node should already be a sequence
-		if _, ok := node.(*ast.ObjectNode); !ok {
+		if _, ok := node.(*ast.ArrayDataNode); !ok {
 			return nil, fmt.Errorf("!!seq tag applied to non-sequence node")
 		}
 		return node, nil
 	}
 
-	// Custom tags or verbatim tags - store as metadata
-	// For now, we don't have a metadata system in AST, so we just return the node
-	// In a future enhancement, we could add a metadata field to SchemaNode
-	// or wrap the node with tag information
-
+	// Custom tags or verbatim tags - the AST has no metadata field, so the
+	// tag is only kept when recorded (see RecordTags)
 	return node, nil
 }
 
@@ -125,6 +211,73 @@ func (p *Parser) coerceToString(node ast.SchemaNode) (ast.SchemaNode, error) {
 	return ast.NewLiteralNode(strValue, node.Position()), nil
 }
 
+// coerceToTimestamp converts a scalar written as a YAML timestamp to a
+// time.Time LiteralNode
+func (p *Parser) coerceToTimestamp(node ast.SchemaNode) (ast.SchemaNode, error) {
+	lit, ok := node.(*ast.LiteralNode)
+	if !ok {
+		return nil, fmt.Errorf("!!timestamp tag cannot be applied to complex node")
+	}
+	s, _ := lit.Value().(string)
+	t, ok := resolve.Timestamp(s)
+	if !ok {
+		return nil, fmt.Errorf("!!timestamp tag: cannot convert %q to timestamp", fmt.Sprint(lit.Value()))
+	}
+	return p.newResolvedScalar(t, s, node.Position()), nil
+}
+
+// coerceToBinary decodes a base64 scalar to a []byte LiteralNode. Line
+// breaks and spaces may split the base64 text, as in a literal block scalar.
+func (p *Parser) coerceToBinary(node ast.SchemaNode) (ast.SchemaNode, error) {
+	lit, ok := node.(*ast.LiteralNode)
+	if !ok {
+		return nil, fmt.Errorf("!!binary tag cannot be applied to complex node")
+	}
+	s, ok := lit.Value().(string)
+	if !ok {
+		return nil, fmt.Errorf("!!binary tag: cannot decode %v as base64", lit.Value())
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return nil, fmt.Errorf("!!binary tag: cannot decode %q as base64: %w", s, err)
+	}
+	return p.newResolvedScalar(b, s, node.Position()), nil
+}
+
+// checkSet checks that a !!set node is a mapping whose values are all null,
+// as in {a, b} or "? a".
+func checkSet(node ast.SchemaNode) (ast.SchemaNode, error) {
+	obj, ok := node.(*ast.ObjectNode)
+	if !ok {
+		return nil, fmt.Errorf("!!set tag applied to non-mapping node")
+	}
+	err := determinism.Range(obj.Properties(), func(key string, value ast.SchemaNode) error {
+		if lit, ok := value.(*ast.LiteralNode); !ok || lit.Value() != nil {
+			return fmt.Errorf("!!set tag: member %q has a value", key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// checkOmap checks that an !!omap node is a sequence of mappings of one
+// entry each, as in [a: 1, b: 2].
+func checkOmap(node ast.SchemaNode) (ast.SchemaNode, error) {
+	seq, ok := node.(*ast.ArrayDataNode)
+	if !ok {
+		return nil, fmt.Errorf("!!omap tag applied to non-sequence node")
+	}
+	for i, elem := range seq.Elements() {
+		if obj, ok := elem.(*ast.ObjectNode); !ok || len(obj.Properties()) != 1 {
+			return nil, fmt.Errorf("!!omap tag: item %d is not a mapping of one entry", i)
+		}
+	}
+	return node, nil
+}
+
 // coerceToInt converts any node to an integer LiteralNode
 func (p *Parser) coerceToInt(node ast.SchemaNode) (ast.SchemaNode, error) {
 	lit, ok := node.(*ast.LiteralNode)
@@ -139,9 +292,20 @@ func (p *Parser) coerceToInt(node ast.SchemaNode) (ast.SchemaNode, error) {
 	switch v := lit.Value().(type) {
 	case int64:
 		intValue = v
+	case uint64:
+		return node, nil
 	case float64:
 		intValue = int64(v)
 	case string:
+		// A quoted scalar in any form of the core schema, such as "0x1F"
+		if n, ok := resolve.Number(v); ok {
+			switch n := n.(type) {
+			case int64:
+				return ast.NewLiteralNode(n, node.Position()), nil
+			case uint64:
+				return ast.NewLiteralNode(n, node.Position()), nil
+			}
+		}
 		intValue, err = strconv.ParseInt(v, 10, 64)
 		if err != nil {
 			return nil, fmt.Errorf("!!int tag: cannot convert %q to integer: %w", v, err)
@@ -177,7 +341,20 @@ func (p *Parser) coerceToFloat(node ast.SchemaNode) (ast.SchemaNode, error) {
 		floatValue = v
 	case int64:
 		floatValue = float64(v)
+	case uint64:
+		floatValue = float64(v)
 	case string:
+		// A quoted scalar in any form of the core schema, such as ".inf"
+		if n, ok := resolve.Number(v); ok {
+			switch n := n.(type) {
+			case float64:
+				return ast.NewLiteralNode(n, node.Position()), nil
+			case int64:
+				return ast.NewLiteralNode(float64(n), node.Position()), nil
+			case uint64:
+				return ast.NewLiteralNode(float64(n), node.Position()), nil
+			}
+		}
 		floatValue, err = strconv.ParseFloat(v, 64)
 		if err != nil {
 			return nil, fmt.Errorf("!!float tag: cannot convert %q to float: %w", v, err)
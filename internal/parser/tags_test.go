@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/shapestone/shape-core/pkg/ast"
@@ -18,6 +19,21 @@ func TestParseCoreTags(t *testing.T) {
 			input:    `value: !!str 123`,
 			expected: "123",
 		},
+		{
+			name:     "str tag keeps boolean keyword text",
+			input:    `value: !!str yes`,
+			expected: "yes",
+		},
+		{
+			name:     "str tag keeps hex number text",
+			input:    `value: !!str 0x1F`,
+			expected: "0x1F",
+		},
+		{
+			name:     "str tag keeps null keyword text",
+			input:    `value: !!str ~`,
+			expected: "~",
+		},
 		{
 			name:     "int tag forces integer",
 			input:    `value: !!int "456"`,
@@ -43,6 +59,26 @@ func TestParseCoreTags(t *testing.T) {
 			input:    `value: !!null "something"`,
 			expected: nil,
 		},
+		{
+			name:     "verbatim str tag",
+			input:    `value: !<tag:yaml.org,2002:str> 5`,
+			expected: "5",
+		},
+		{
+			name:     "TAG directive handle for core schema",
+			input:    "%TAG !y! tag:yaml.org,2002:\n---\nvalue: !y!int \"7\"",
+			expected: int64(7),
+		},
+		{
+			name:     "int tag resolves hex text",
+			input:    `value: !!int "0x1F"`,
+			expected: int64(31),
+		},
+		{
+			name:     "str tag without a value is empty",
+			input:    `value: !!str`,
+			expected: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -77,13 +113,12 @@ func TestParseCoreTags(t *testing.T) {
 
 // TestParseCustomTags tests parsing of custom tags
 func TestParseCustomTags(t *testing.T) {
-	t.Skip("TODO: Fix edge case with tagged indented blocks")
-
 	input := `person: !Person
   name: Alice
   age: 30`
 
 	parser := NewParser(input)
+	tags := parser.RecordTags()
 	node, err := parser.Parse()
 	if err != nil {
 		t.Fatalf("Parse() error: %v", err)
@@ -99,25 +134,25 @@ func TestParseCustomTags(t *testing.T) {
 		t.Fatal("Expected 'person' field")
 	}
 
-	// Check if the person node has the custom tag metadata
 	personObj, ok := personNode.(*ast.ObjectNode)
 	if !ok {
 		t.Fatalf("Expected ObjectNode for person, got: %T", personNode)
 	}
-
-	// The tag should be stored in metadata or a Tag field
-	// We'll check this once we implement the feature
-	_ = personObj
+	if len(personObj.Properties()) != 2 {
+		t.Errorf("Expected 2 properties, got: %d", len(personObj.Properties()))
+	}
+	if got := tags.Of(personNode); got != "!Person" {
+		t.Errorf("Expected tag !Person, got: %q", got)
+	}
 }
 
 // TestParseVerbatimTags tests parsing of verbatim tags with full URIs
 func TestParseVerbatimTags(t *testing.T) {
-	t.Skip("TODO: Fix edge case with tagged indented blocks")
-
 	input := `custom: !<tag:example.com,2000:custom>
   data: value`
 
 	parser := NewParser(input)
+	tags := parser.RecordTags()
 	node, err := parser.Parse()
 	if err != nil {
 		t.Fatalf("Parse() error: %v", err)
@@ -133,14 +168,16 @@ func TestParseVerbatimTags(t *testing.T) {
 		t.Fatal("Expected 'custom' field")
 	}
 
-	// The tag should be stored in metadata
-	_ = customNode
+	if _, ok := customNode.(*ast.ObjectNode); !ok {
+		t.Fatalf("Expected ObjectNode for custom, got: %T", customNode)
+	}
+	if got := tags.Of(customNode); got != "tag:example.com,2000:custom" {
+		t.Errorf("Expected tag tag:example.com,2000:custom, got: %q", got)
+	}
 }
 
 // TestParseTagsOnMappings tests tags applied to mappings
 func TestParseTagsOnMappings(t *testing.T) {
-	t.Skip("TODO: Fix edge case with tagged indented blocks")
-
 	input := `config: !!map
   key1: value1
   key2: value2`
@@ -173,8 +210,6 @@ func TestParseTagsOnMappings(t *testing.T) {
 
 // TestParseTagsOnSequences tests tags applied to sequences
 func TestParseTagsOnSequences(t *testing.T) {
-	t.Skip("TODO: Fix edge case with tagged indented blocks")
-
 	input := `items: !!seq
   - item1
   - item2
@@ -196,13 +231,44 @@ func TestParseTagsOnSequences(t *testing.T) {
 		t.Fatal("Expected 'items' field")
 	}
 
-	itemsObj, ok := itemsNode.(*ast.ObjectNode)
+	itemsSeq, ok := itemsNode.(*ast.ArrayDataNode)
 	if !ok {
-		t.Fatalf("Expected ObjectNode for items, got: %T", itemsNode)
+		t.Fatalf("Expected ArrayDataNode for items, got: %T", itemsNode)
 	}
 
-	if len(itemsObj.Properties()) != 3 {
-		t.Errorf("Expected 3 items, got: %d", len(itemsObj.Properties()))
+	if itemsSeq.Len() != 3 {
+		t.Errorf("Expected 3 items, got: %d", itemsSeq.Len())
+	}
+}
+
+// TestParseTagOnOwnLine tests a tag at the end of a line, before the block
+// node it tags or, with nothing indented below it, before a null
+func TestParseTagOnOwnLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"root mapping", "!Point\nx: 1\ny: 2", map[string]interface{}{"x": int64(1), "y": int64(2)}},
+		{"root sequence", "--- !!seq\n- a\n- b", []interface{}{"a", "b"}},
+		{"sequence item", "- !Point\n  x: 1\n- b", []interface{}{map[string]interface{}{"x": int64(1)}, "b"}},
+		{"nested value", "a:\n  p: !Point\n    x: 1\n  z: 2\nw: 3", map[string]interface{}{
+			"a": map[string]interface{}{"p": map[string]interface{}{"x": int64(1)}, "z": int64(2)},
+			"w": int64(3),
+		}},
+		{"null value", "p: !Point\nq: 1", map[string]interface{}{"p": nil, "q": int64(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if got := keyValue(node); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %#v, want %#v", got, tt.expected)
+			}
+		})
 	}
 }
 
@@ -433,6 +499,41 @@ func TestApplyTag_ErrorCases(t *testing.T) {
 			input:   `value: !!seq hello`,
 			wantErr: true,
 		},
+		{
+			name:    "!!map tag on sequence",
+			input:   `value: !!map [a, b]`,
+			wantErr: true,
+		},
+		{
+			name:    "!!seq tag on mapping",
+			input:   `value: !!seq {0: a, 1: b}`,
+			wantErr: true,
+		},
+		{
+			name:    "!!seq tag on sequence",
+			input:   `value: !!seq [a, b]`,
+			wantErr: false,
+		},
+		{
+			name:    "!!binary tag on invalid base64",
+			input:   `value: !!binary "not base64!"`,
+			wantErr: true,
+		},
+		{
+			name:    "!!set tag on mapping with values",
+			input:   `value: !!set {a: 1}`,
+			wantErr: true,
+		},
+		{
+			name:    "!!omap tag on sequence of scalars",
+			input:   `value: !!omap [a, b]`,
+			wantErr: true,
+		},
+		{
+			name:    "!!omap tag on sequence of mappings",
+			input:   `value: !!omap [{a: 1}, {b: 2}]`,
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -446,6 +547,69 @@ func TestApplyTag_ErrorCases(t *testing.T) {
 	}
 }
 
+// TestParseYAML11Tags tests the !!binary, !!set and !!omap tags
+func TestParseYAML11Tags(t *testing.T) {
+	t.Run("binary", func(t *testing.T) {
+		node, err := NewParser("value: !!binary |\n  aGVs\n  bG8=\n").Parse()
+		if err != nil {
+			t.Fatalf("Parse() error: %v", err)
+		}
+		lit, ok := node.(*ast.ObjectNode).Properties()["value"].(*ast.LiteralNode)
+		if !ok {
+			t.Fatalf("Expected LiteralNode, got: %T", node.(*ast.ObjectNode).Properties()["value"])
+		}
+		if got, ok := lit.Value().([]byte); !ok || string(got) != "hello" {
+			t.Errorf("Expected []byte(\"hello\"), got: %#v", lit.Value())
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		for _, input := range []string{
+			"value: !!set {a, b}",
+			"value: !!set\n  ? a\n  ? b\n",
+		} {
+			parser := NewParser(input)
+			tags := parser.RecordTags()
+			node, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", input, err)
+			}
+			set, ok := node.(*ast.ObjectNode).Properties()["value"].(*ast.ObjectNode)
+			if !ok {
+				t.Fatalf("Parse(%q): expected ObjectNode for value", input)
+			}
+			if got := tags.Of(set); got != SetTag {
+				t.Errorf("Parse(%q): expected tag %s, got: %q", input, SetTag, got)
+			}
+			for _, k := range []string{"a", "b"} {
+				lit, ok := set.Properties()[k].(*ast.LiteralNode)
+				if !ok || lit.Value() != nil {
+					t.Errorf("Parse(%q): expected null member %s", input, k)
+				}
+			}
+		}
+	})
+
+	t.Run("omap", func(t *testing.T) {
+		parser := NewParser("value: !!omap\n  - z: 1\n  - a: 2\n")
+		tags := parser.RecordTags()
+		node, err := parser.Parse()
+		if err != nil {
+			t.Fatalf("Parse() error: %v", err)
+		}
+		omap, ok := node.(*ast.ObjectNode).Properties()["value"].(*ast.ArrayDataNode)
+		if !ok {
+			t.Fatalf("Expected ArrayDataNode for value")
+		}
+		if got := tags.Of(omap); got != OmapTag {
+			t.Errorf("Expected tag %s, got: %q", OmapTag, got)
+		}
+		if omap.Len() != 2 {
+			t.Errorf("Expected 2 entries, got: %d", omap.Len())
+		}
+	})
+}
+
 // TestCoerceToString_AllTypes tests coerceToString with all value types
 func TestCoerceToString_AllTypes(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SetTrace makes the parser write its decisions to w, one per line, for
+// debugging: each token the tokenizer matches, each INDENT and DEDENT it
+// emits, and each parse function entered and left, indented by nesting.
+// Lines start with the line and column they refer to, or "-" at an INDENT,
+// a DEDENT or the end of input, which have no position. SetTrace must be
+// called before parsing starts; writes to w are not checked for errors.
+func (p *Parser) SetTrace(w io.Writer) {
+	p.trace = w
+	if w == nil {
+		p.tokenizer.SetTrace(nil)
+		return
+	}
+	p.tokenizer.SetTrace(p.traceEvent)
+}
+
+// traceEvent writes one line of the trace.
+func (p *Parser) traceEvent(line, column int, event string) {
+	pos := "-"
+	if line > 0 {
+		pos = fmt.Sprintf("%d:%d", line, column)
+	}
+	fmt.Fprintf(p.trace, "%-6s %s%s\n", pos, strings.Repeat(". ", p.traceDepth), event)
+}
+
+// traceEnter records entering the parse function name at the current token
+// and returns name for traceLeave:
+//
+//	if p.trace != nil {
+//	    defer p.traceLeave(p.traceEnter("parseNode"))
+//	}
+func (p *Parser) traceEnter(name string) string {
+	pos := p.position()
+	p.traceEvent(pos.Line, pos.Column, "enter "+name)
+	p.traceDepth++
+	return name
+}
+
+// traceLeave records leaving the parse function name at the current token.
+func (p *Parser) traceLeave(name string) {
+	p.traceDepth--
+	pos := p.position()
+	p.traceEvent(pos.Line, pos.Column, "leave "+name)
+}
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParserSetTrace(t *testing.T) {
+	var buf strings.Builder
+	p := NewParser("a:\n  b: 1\nc: [x]\n")
+	p.SetTrace(&buf)
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	trace := buf.String()
+
+	for _, want := range []string{
+		"1:1    match String \"a\"\n",
+		"1:1    enter parseDocument\n",
+		"2:3    . . . . indent 0 -> 2\n",
+		"3:1    . . . . . . . . . dedent 2 -> 0\n",
+		"3:4    . . . . . enter parseFlowSequence\n",
+		"3:7    . . . . . leave parseFlowSequence\n",
+		"-      leave parseDocument\n",
+	} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("trace is missing %q:\n%s", want, trace)
+		}
+	}
+	if enters, leaves := strings.Count(trace, " enter "), strings.Count(trace, " leave "); enters != leaves {
+		t.Errorf("trace has %d enters and %d leaves", enters, leaves)
+	}
+}
+
+func TestParserSetTrace_Error(t *testing.T) {
+	var buf strings.Builder
+	p := NewParser("a: [1\n")
+	p.SetTrace(&buf)
+	if _, err := p.Parse(); err == nil {
+		t.Fatal("Parse() error = nil, want a syntax error")
+	}
+	if !strings.HasSuffix(buf.String(), "leave parseDocument\n") {
+		t.Errorf("trace does not leave every parse function on error:\n%s", buf.String())
+	}
+}
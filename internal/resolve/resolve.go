@@ -0,0 +1,385 @@
+// Package resolve implements plain scalar resolution for YAML.
+//
+// Both the AST parser (internal/parser) and the fast parser (internal/fastparser)
+// resolve plain (unquoted) scalars through this package so that every decoder
+// produces the same Go values for the same input. The canonical mapping is:
+//
+//	null, Null, NULL, ~, empty          → nil
+//	true/false, yes/no, on/off          → bool   (lower, Title or UPPER case)
+//	decimal, 0x hex, 0o octal integers  → int64  (uint64 above math.MaxInt64)
+//	floats, .inf, -.inf, .nan           → float64
+//	anything else                       → string
+//
+// Integers too large for uint64 (or too small for int64) resolve to float64.
+//
+// Resolution never depends on the locale: numbers are parsed with strconv,
+// which ignores LANG and LC_NUMERIC, so a comma decimal such as 1,5, digit
+// grouping such as 1.234,56 and non-ASCII digits all resolve to strings, as
+// do booleans spelled in other languages.
+//
+// Documents that declare %YAML 1.1 resolve through Plain11 instead, which
+// differs from the core schema for integers written with a leading zero:
+//
+//	010, -017   → int64 octal (8, -15)   core schema: decimal (10, -17)
+//	09, 0128    → string                 core schema: decimal (9, 128)
+package resolve
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// Plain resolves a plain scalar to its Go value according to the canonical mapping.
+func Plain(s string) interface{} {
+	if len(s) == 0 {
+		return nil
+	}
+
+	if IsNull(s) {
+		return nil
+	}
+	if b, ok := Bool(s); ok {
+		return b
+	}
+	if n, ok := Number(s); ok {
+		return n
+	}
+	return s
+}
+
+// PlainBytes is the byte-slice form of Plain. Keywords and numbers are resolved
+// without converting b to a string; only string results allocate.
+func PlainBytes(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+
+	// The compiler does not allocate for string(b) in a switch
+	switch string(b) {
+	case "null", "Null", "NULL", "~":
+		return nil
+	case "true", "True", "TRUE", "yes", "Yes", "YES", "on", "On", "ON":
+		return true
+	case "false", "False", "FALSE", "no", "No", "NO", "off", "Off", "OFF":
+		return false
+	}
+	if n, ok := NumberBytes(b); ok {
+		return n
+	}
+	return string(b)
+}
+
+// UsesYAML11 reports whether a %YAML directive version selects the YAML 1.1
+// resolution of Plain11 rather than the 1.2 core schema.
+func UsesYAML11(version string) bool {
+	return version == "1.1" || version == "1.0"
+}
+
+// Plain11 resolves a plain scalar under YAML 1.1, where an integer with a
+// leading zero is octal, or a string if its digits are not all octal.
+// Every other scalar resolves as in Plain.
+func Plain11(s string) interface{} {
+	if hasLeadingZero(s) {
+		return leadingZero11(s)
+	}
+	return Plain(s)
+}
+
+// PlainBytes11 is the byte-slice form of Plain11.
+func PlainBytes11(b []byte) interface{} {
+	if hasLeadingZero(b) {
+		return leadingZero11(string(b))
+	}
+	return PlainBytes(b)
+}
+
+// IsNull reports whether s is a YAML null keyword.
+func IsNull(s string) bool {
+	switch s {
+	case "null", "Null", "NULL", "~":
+		return true
+	}
+	return false
+}
+
+// Bool resolves s as a YAML boolean keyword.
+func Bool(s string) (value bool, ok bool) {
+	switch s {
+	case "true", "True", "TRUE", "yes", "Yes", "YES", "on", "On", "ON":
+		return true, true
+	case "false", "False", "FALSE", "no", "No", "NO", "off", "Off", "OFF":
+		return false, true
+	}
+	return false, false
+}
+
+// Number resolves s as a YAML integer or float.
+// The returned value is int64, uint64, or float64.
+func Number(s string) (interface{}, bool) {
+	if len(s) == 0 {
+		return nil, false
+	}
+	if i, ok := smallInt(s); ok {
+		return i, true
+	}
+
+	switch s {
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return math.Inf(1), true
+	case "-.inf", "-.Inf", "-.INF":
+		return math.Inf(-1), true
+	case ".nan", ".NaN", ".NAN":
+		return math.NaN(), true
+	}
+
+	// Hex and octal integers (no sign allowed per the core schema)
+	if len(s) > 2 && s[0] == '0' {
+		switch s[1] {
+		case 'x', 'X':
+			if !allDigits(s[2:], isHexDigit) {
+				return nil, false
+			}
+			return parseUnsigned(s[2:], 16)
+		case 'o', 'O':
+			if !allDigits(s[2:], isOctalDigit) {
+				return nil, false
+			}
+			return parseUnsigned(s[2:], 8)
+		}
+	}
+
+	if isDecimalInt(s) {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, true
+		}
+		if s[0] != '-' {
+			digits := s
+			if digits[0] == '+' {
+				digits = digits[1:]
+			}
+			if u, err := strconv.ParseUint(digits, 10, 64); err == nil {
+				return u, true
+			}
+		}
+		// Out of integer range: fall back to float
+		return parseFloat(s)
+	}
+
+	if isDecimalFloat(s) {
+		return parseFloat(s)
+	}
+
+	return nil, false
+}
+
+// parseFloat parses a decimal float. A value beyond the float64 range is
+// still a float, as it is in JSON: it resolves to ±Inf, or to ±0 when too
+// small.
+func parseFloat(s string) (interface{}, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return nil, false
+	}
+	return f, true
+}
+
+// NumberBytes is the byte-slice form of Number.
+// Small decimal integers are parsed in place. Other numbers go through strconv;
+// Number does not retain its argument, so the string(b) conversion stays on the
+// stack for typical lengths.
+func NumberBytes(b []byte) (interface{}, bool) {
+	if len(b) == 0 || !canStartNumber(b[0]) {
+		return nil, false
+	}
+	if i, ok := smallInt(b); ok {
+		return i, true
+	}
+	return Number(string(b))
+}
+
+// NumberRunes is the rune-slice form of Number, for tokens produced by the
+// shape-core tokenizer. Numbers are ASCII, so the runes are narrowed into a
+// stack buffer instead of building a string.
+func NumberRunes(r []rune) (interface{}, bool) {
+	if len(r) > maxNumberRunes {
+		return Number(string(r))
+	}
+	var buf [maxNumberRunes]byte
+	for i, c := range r {
+		if c >= 0x80 {
+			return nil, false
+		}
+		buf[i] = byte(c)
+	}
+	return NumberBytes(buf[:len(r)])
+}
+
+// maxNumberRunes bounds the stack buffer used by NumberRunes.
+const maxNumberRunes = 64
+
+// maxSmallIntDigits is the most decimal digits that always fit in int64.
+const maxSmallIntDigits = 18
+
+// smallInt parses [-+]? [0-9]{1,18} without strconv.
+func smallInt[T string | []byte](s T) (int64, bool) {
+	i := 0
+	neg := false
+	if s[0] == '-' || s[0] == '+' {
+		neg = s[0] == '-'
+		i++
+	}
+	digits := len(s) - i
+	if digits == 0 || digits > maxSmallIntDigits {
+		return 0, false
+	}
+
+	var n int64
+	for ; i < len(s); i++ {
+		c := s[i]
+		if !isDigit(c) {
+			return 0, false
+		}
+		n = n*10 + int64(c-'0')
+	}
+	if neg {
+		n = -n
+	}
+	return n, true
+}
+
+// canStartNumber reports whether c can be the first byte of a YAML number.
+func canStartNumber(c byte) bool {
+	return isDigit(c) || c == '-' || c == '+' || c == '.'
+}
+
+// parseUnsigned parses hex or octal digits into int64, or uint64 when the value
+// does not fit in int64.
+func parseUnsigned(digits string, base int) (interface{}, bool) {
+	u, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		return nil, false
+	}
+	if u <= math.MaxInt64 {
+		return int64(u), true
+	}
+	return u, true
+}
+
+// hasLeadingZero matches [-+]? 0 [0-9]+, the integers YAML 1.1 and the core
+// schema read differently.
+func hasLeadingZero[T string | []byte](s T) bool {
+	i := 0
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		i++
+	}
+	if len(s)-i < 2 || s[i] != '0' {
+		return false
+	}
+	for i++; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// leadingZero11 resolves a hasLeadingZero integer under YAML 1.1: octal when
+// every digit is 0-7, otherwise a string.
+func leadingZero11(s string) interface{} {
+	digits := s
+	if digits[0] == '-' || digits[0] == '+' {
+		digits = digits[1:]
+	}
+	if !allDigits(digits, isOctalDigit) {
+		return s
+	}
+	if i, err := strconv.ParseInt(s, 8, 64); err == nil {
+		return i
+	}
+	if s[0] != '-' {
+		if u, ok := parseUnsigned(digits, 8); ok {
+			return u
+		}
+	}
+	return s
+}
+
+// isDecimalInt matches [-+]? [0-9]+
+func isDecimalInt(s string) bool {
+	if s[0] == '-' || s[0] == '+' {
+		s = s[1:]
+	}
+	return len(s) > 0 && allDigits(s, isDigit)
+}
+
+// isDecimalFloat matches [-+]? ( \. [0-9]+ | [0-9]+ ( \. [0-9]* )? ) ( [eE] [-+]? [0-9]+ )?
+//
+// This is stricter than strconv.ParseFloat, which also accepts "inf", "NaN",
+// hex floats and underscores - none of which are YAML 1.2 floats.
+func isDecimalFloat(s string) bool {
+	i := 0
+	if s[i] == '-' || s[i] == '+' {
+		i++
+	}
+
+	intDigits := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+		intDigits++
+	}
+
+	fracDigits := 0
+	if i < len(s) && s[i] == '.' {
+		i++
+		for i < len(s) && isDigit(s[i]) {
+			i++
+			fracDigits++
+		}
+	}
+
+	if intDigits == 0 && fracDigits == 0 {
+		return false
+	}
+
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '-' || s[i] == '+') {
+			i++
+		}
+		expDigits := 0
+		for i < len(s) && isDigit(s[i]) {
+			i++
+			expDigits++
+		}
+		if expDigits == 0 {
+			return false
+		}
+	}
+
+	return i == len(s)
+}
+
+func allDigits(s string, valid func(byte) bool) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !valid(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isOctalDigit(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,241 @@
+package resolve
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var plainTests = []struct {
+	input string
+	want  interface{}
+}{
+	{"", nil},
+	{"null", nil},
+	{"Null", nil},
+	{"NULL", nil},
+	{"~", nil},
+	{"nULL", "nULL"},
+	{"true", true},
+	{"True", true},
+	{"TRUE", true},
+	{"yes", true},
+	{"On", true},
+	{"false", false},
+	{"NO", false},
+	{"off", false},
+	{"tRUE", "tRUE"},
+	{"y", "y"},
+	{"42", int64(42)},
+	{"-42", int64(-42)},
+	{"+42", int64(42)},
+	{"0x1F", int64(31)},
+	{"0o17", int64(15)},
+	{"017", int64(17)},
+	{"9223372036854775808", uint64(9223372036854775808)},
+	{"18446744073709551615", uint64(18446744073709551615)},
+	{"0xFFFFFFFFFFFFFFFF", uint64(math.MaxUint64)},
+	{"18446744073709551616", float64(18446744073709551616)},
+	{"-9223372036854775809", float64(-9223372036854775809)},
+	{"1.0", 1.0},
+	{"1.5", 1.5},
+	{".5", 0.5},
+	{"1.", 1.0},
+	{"1e3", 1000.0},
+	{"-2.5E-3", -0.0025},
+	{"1e400", math.Inf(1)},
+	{"-1e400", math.Inf(-1)},
+	{"1e-400", 0.0},
+	{"1" + strings.Repeat("0", 400), math.Inf(1)},
+	{".inf", math.Inf(1)},
+	{"+.Inf", math.Inf(1)},
+	{"-.INF", math.Inf(-1)},
+	{"inf", "inf"},
+	{"NaN", "NaN"},
+	{"1_000", "1_000"},
+	{"0x", "0x"},
+	{"0xG", "0xG"},
+	{"-0x1F", "-0x1F"},
+	{"1e", "1e"},
+	{".", "."},
+	{"12:30", "12:30"},
+	{"2001-12-14", "2001-12-14"},
+	{"hello", "hello"},
+}
+
+func TestPlain(t *testing.T) {
+	for _, tt := range plainTests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := Plain(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plain(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainBytes(t *testing.T) {
+	for _, tt := range plainTests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := PlainBytes([]byte(tt.input))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlainBytes(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// plain11Tests are the scalars Plain11 resolves differently from Plain.
+var plain11Tests = []struct {
+	input string
+	want  interface{}
+}{
+	{"010", int64(8)},
+	{"017", int64(15)},
+	{"-010", int64(-8)},
+	{"+010", int64(8)},
+	{"00", int64(0)},
+	{"0777777777777777777777", int64(math.MaxInt64)},
+	{"01777777777777777777777", uint64(math.MaxUint64)},
+	{"09", "09"},
+	{"0128", "0128"},
+	{"-08", "-08"},
+}
+
+func TestPlain11(t *testing.T) {
+	for _, tt := range plain11Tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Plain11(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plain11(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+			if got := PlainBytes11([]byte(tt.input)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlainBytes11(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPlain11MatchesPlain tests that Plain11 only departs from Plain for
+// integers with a leading zero.
+func TestPlain11MatchesPlain(t *testing.T) {
+	for _, tt := range plainTests {
+		if tt.input == "017" {
+			continue
+		}
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Plain11(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plain11(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+			if got := PlainBytes11([]byte(tt.input)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlainBytes11(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumberRunes(t *testing.T) {
+	for _, tt := range plainTests {
+		t.Run(tt.input, func(t *testing.T) {
+			want, wantOK := Number(tt.input)
+			got, ok := NumberRunes([]rune(tt.input))
+			if ok != wantOK || !reflect.DeepEqual(got, want) {
+				t.Errorf("NumberRunes(%q) = %#v, %v, want %#v, %v", tt.input, got, ok, want, wantOK)
+			}
+		})
+	}
+
+	if _, ok := NumberRunes([]rune("１２")); ok {
+		t.Error("NumberRunes accepted full-width digits")
+	}
+	long := "1." + strings.Repeat("0", 100)
+	if got, ok := NumberRunes([]rune(long)); !ok || got != 1.0 {
+		t.Errorf("NumberRunes(long) = %#v, %v, want 1.0, true", got, ok)
+	}
+}
+
+func TestNumberBytesAllocs(t *testing.T) {
+	// Only boxing the result into interface{} may allocate; values below 256
+	// use the runtime's static table and do not allocate at all.
+	tests := []struct {
+		input     string
+		maxAllocs float64
+	}{
+		{"42", 0},
+		{"-7", 1},
+		{"123456789", 1},
+		{"123456789012345678", 1},
+		{"3.14159", 1},
+		{"1e10", 1},
+		{"0x1F", 0},
+		{"hello", 0},
+	}
+
+	for _, tt := range tests {
+		b := []byte(tt.input)
+		allocs := testing.AllocsPerRun(100, func() {
+			NumberBytes(b)
+		})
+		if allocs > tt.maxAllocs {
+			t.Errorf("NumberBytes(%q) allocs = %v, want <= %v", tt.input, allocs, tt.maxAllocs)
+		}
+	}
+}
+
+func TestPlainNaN(t *testing.T) {
+	for _, input := range []string{".nan", ".NaN", ".NAN"} {
+		f, ok := Plain(input).(float64)
+		if !ok || !math.IsNaN(f) {
+			t.Errorf("Plain(%q) = %#v, want NaN", input, Plain(input))
+		}
+	}
+}
+
+// TestPlainLocaleStyle checks that numbers and booleans written in the
+// conventions of a locale other than the core schema's resolve to strings.
+func TestPlainLocaleStyle(t *testing.T) {
+	for _, input := range []string{
+		"1,5", "-1,5", "0,5e3", "1.234,56", "1,234.56", "1 234", "1'234", "1_000",
+		"١٢٣", "１２３", "1,5E3", "ＴＲＵＥ", "ja", "nein", "wahr", "oui", "vrai", "sí",
+	} {
+		if got := Plain(input); got != input {
+			t.Errorf("Plain(%q) = %#v, want the string", input, got)
+		}
+		if got := PlainBytes([]byte(input)); got != input {
+			t.Errorf("PlainBytes(%q) = %#v, want the string", input, got)
+		}
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	est := time.FixedZone("", -5*3600)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2001-12-14", time.Date(2001, 12, 14, 0, 0, 0, 0, time.UTC)},
+		{"2001-12-14T21:59:43Z", time.Date(2001, 12, 14, 21, 59, 43, 0, time.UTC)},
+		{"2001-12-14t21:59:43.10-05:00", time.Date(2001, 12, 14, 21, 59, 43, 1e8, est)},
+		{"2001-12-14 21:59:43.10 -5", time.Date(2001, 12, 14, 21, 59, 43, 1e8, est)},
+		{"2001-12-15 2:59:43.10", time.Date(2001, 12, 15, 2, 59, 43, 1e8, time.UTC)},
+		{"2002-12-14T00:00:00.123456789+01:30", time.Date(2002, 12, 14, 0, 0, 0, 123456789, time.FixedZone("", 5400))},
+		{"2024-02-29", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, ok := Timestamp(tt.input)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("Timestamp(%q) = %v, %v, want %v", tt.input, got, ok, tt.want)
+		}
+	}
+
+	for _, input := range []string{
+		"", "2001", "2001-12", "2001-1-14", "2001-12-4", "2001-13-01", "2023-02-29",
+		"2001-12-14T", "2001-12-14T25:00:00", "2001-12-14T21:59", "2001-12-14T21:59:43+",
+		"2001-12-14T21:59:43 EST", "2001-12-14x21:59:43", "12001-12-14",
+	} {
+		if got, ok := Timestamp(input); ok {
+			t.Errorf("Timestamp(%q) = %v, want not a timestamp", input, got)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package resolve
+
+import (
+	"errors"
+	"time"
+)
+
+// Timestamp parses s as a YAML timestamp (https://yaml.org/type/timestamp.html):
+// a date, as in 2001-12-14, or a date and a time separated by T, t or spaces,
+// with optional fractional seconds and time zone, as in
+// 2001-12-14t21:59:43.10-05:00 or 2001-12-14 21:59:43.10 -5. A date, or a
+// time without a zone, is UTC.
+//
+// Timestamps are not part of the core schema, so Plain leaves them strings;
+// decoders use Timestamp for time.Time targets and !!timestamp scalars.
+func Timestamp(s string) (time.Time, bool) {
+	sc := tsScanner{s: s}
+	year, ok1 := sc.number(4, 4)
+	ok2 := sc.skip('-')
+	month, ok3 := sc.number(1, 2)
+	ok4 := sc.skip('-')
+	day, ok5 := sc.number(1, 2)
+	if !(ok1 && ok2 && ok3 && ok4 && ok5) || !validDate(year, month, day) {
+		return time.Time{}, false
+	}
+	if sc.done() {
+		// A date alone is written with two-digit month and day
+		if len(s) != len("2006-01-02") {
+			return time.Time{}, false
+		}
+		return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), true
+	}
+
+	if !sc.skip('T') && !sc.skip('t') && sc.spaces() == 0 {
+		return time.Time{}, false
+	}
+	hour, ok1 := sc.number(1, 2)
+	ok2 = sc.skip(':')
+	minute, ok3 := sc.number(2, 2)
+	ok4 = sc.skip(':')
+	second, ok5 := sc.number(2, 2)
+	if !(ok1 && ok2 && ok3 && ok4 && ok5) || hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, false
+	}
+
+	nsec := 0
+	if sc.skip('.') {
+		for scale := 100000000; !sc.done() && isDigit(sc.s[sc.i]); scale /= 10 {
+			nsec += int(sc.s[sc.i]-'0') * scale
+			sc.i++
+		}
+	}
+
+	loc := time.UTC
+	sc.spaces()
+	if !sc.done() && !sc.skip('Z') {
+		sign := 1
+		if sc.skip('-') {
+			sign = -1
+		} else if !sc.skip('+') {
+			return time.Time{}, false
+		}
+		zh, ok := sc.number(1, 2)
+		zm := 0
+		if ok && sc.skip(':') {
+			zm, ok = sc.number(2, 2)
+		}
+		if !ok || zm > 59 {
+			return time.Time{}, false
+		}
+		loc = time.FixedZone("", sign*(zh*3600+zm*60))
+	}
+	if !sc.done() {
+		return time.Time{}, false
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, second, nsec, loc), true
+}
+
+// TimestampText is a time.Time that decodes from a YAML timestamp. Decoders
+// use it, through a pointer conversion, in place of time.Time's own
+// UnmarshalText, which only accepts RFC 3339.
+type TimestampText time.Time
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *TimestampText) UnmarshalText(text []byte) error {
+	v, ok := Timestamp(string(text))
+	if !ok {
+		return errNotTimestamp
+	}
+	*t = TimestampText(v)
+	return nil
+}
+
+var errNotTimestamp = errors.New("not a YAML timestamp, such as 2001-12-14 or 2001-12-14T21:59:43Z")
+
+// validDate reports whether year, month and day name a day of the calendar.
+func validDate(year, month, day int) bool {
+	if month < 1 || month > 12 || day < 1 {
+		return false
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC).Day() == day
+}
+
+// tsScanner reads the fields of a timestamp from s.
+type tsScanner struct {
+	s string
+	i int
+}
+
+func (sc *tsScanner) done() bool {
+	return sc.i >= len(sc.s)
+}
+
+// skip consumes c if it is next.
+func (sc *tsScanner) skip(c byte) bool {
+	if !sc.done() && sc.s[sc.i] == c {
+		sc.i++
+		return true
+	}
+	return false
+}
+
+// spaces consumes spaces and tabs and returns how many there were.
+func (sc *tsScanner) spaces() int {
+	start := sc.i
+	for !sc.done() && (sc.s[sc.i] == ' ' || sc.s[sc.i] == '\t') {
+		sc.i++
+	}
+	return sc.i - start
+}
+
+// number consumes a decimal number of lo to hi digits.
+func (sc *tsScanner) number(lo, hi int) (int, bool) {
+	n, digits := 0, 0
+	for digits < hi && !sc.done() && isDigit(sc.s[sc.i]) {
+		n = n*10 + int(sc.s[sc.i]-'0')
+		sc.i++
+		digits++
+	}
+	return n, digits >= lo
+}
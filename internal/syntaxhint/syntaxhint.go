@@ -0,0 +1,162 @@
+// Package syntaxhint suggests fixes for common YAML syntax mistakes for the
+// AST parser (internal/parser) and the fast parser (internal/fastparser):
+// an unclosed quote or flow collection, tab indentation, and a missing space
+// after a mapping key's colon. Both decode paths attach its suggestion to
+// their errors, so the same mistake gets the same advice from either.
+package syntaxhint
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// For returns a suggested fix for an error reported on the 1-based line of
+// data, or "" if the mistake is not a recognized one. A line of 0 means the
+// position is unknown and the whole input is considered.
+func For(data []byte, line int) string {
+	return ForAt(data, line, 1)
+}
+
+// ForAt is like For for data that starts on line first of a larger stream,
+// such as a later document of a multi-document stream: line counts from the
+// start of data, but suggestions name lines of the stream.
+func ForAt(data []byte, line, first int) string {
+	lines := bytes.Split(data, []byte("\n"))
+	known := line > 0 && line <= len(lines)
+	if !known {
+		line = len(lines)
+	}
+
+	// A quote or bracket left open is found wherever it was opened
+	if h := unclosed(lines[:line], first); h != "" {
+		return h
+	}
+
+	// Other mistakes are looked for on the error line and the one before it,
+	// since parsers tend to notice them only after moving on
+	stop := 0
+	if known {
+		stop = max(line-2, 0)
+	}
+	for i := line - 1; i >= stop; i-- {
+		if h := tabIndent(lines[i], i+first); h != "" {
+			return h
+		}
+		if h := missingSpace(lines[i]); h != "" {
+			return h
+		}
+	}
+	return ""
+}
+
+// opening is an unclosed quote or flow collection.
+type opening struct {
+	c    byte
+	line int
+}
+
+// unclosed returns a hint for a quoted scalar or flow collection still open
+// at the end of lines, the first of which is numbered first. Quotes and
+// brackets only count where a value may start, so the apostrophe in "it's"
+// or the brackets in "x[0]" are ignored.
+func unclosed(lines [][]byte, first int) string {
+	var flows []opening
+	var quote opening
+	blockIndent := -1 // indent of the line introducing a block scalar, or -1
+
+	for i, l := range lines {
+		indent := len(l) - len(bytes.TrimLeft(l, " "))
+		if blockIndent >= 0 {
+			if len(bytes.TrimSpace(l)) == 0 || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+
+		valueStart := true
+	scan:
+		for j := 0; j < len(l); j++ {
+			c := l[j]
+			if quote.c != 0 {
+				switch {
+				case c == '\\' && quote.c == '"':
+					j++
+				case c == '\'' && quote.c == '\'' && j+1 < len(l) && l[j+1] == '\'':
+					j++
+				case c == quote.c:
+					quote.c = 0
+					valueStart = false
+				}
+				continue
+			}
+
+			spaceAfter := j+1 == len(l) || isSpace(l[j+1])
+			switch {
+			case isSpace(c):
+			case c == '#' && (j == 0 || isSpace(l[j-1])):
+				break scan
+			case valueStart && (c == '"' || c == '\''):
+				quote = opening{c, i + first}
+			case (valueStart || len(flows) > 0) && (c == '[' || c == '{'):
+				flows = append(flows, opening{c, i + first})
+				valueStart = true
+			case len(flows) > 0 && (c == ']' || c == '}'):
+				flows = flows[:len(flows)-1]
+				valueStart = false
+			case len(flows) > 0 && c == ',':
+				valueStart = true
+			case c == ':' && spaceAfter, c == '-' && valueStart && spaceAfter, c == '?' && valueStart && spaceAfter:
+				valueStart = true
+			case valueStart && len(flows) == 0 && (c == '|' || c == '>'):
+				blockIndent = indent
+				break scan
+			default:
+				valueStart = false
+			}
+		}
+	}
+
+	switch {
+	case quote.c == '"':
+		return fmt.Sprintf("close the double quote opened on line %d", quote.line)
+	case quote.c == '\'':
+		return fmt.Sprintf("close the single quote opened on line %d", quote.line)
+	case len(flows) > 0:
+		open := flows[len(flows)-1]
+		closer := "]"
+		if open.c == '{' {
+			closer = "}"
+		}
+		return fmt.Sprintf("close the %q opened on line %d with %q", string(open.c), open.line, closer)
+	}
+	return ""
+}
+
+// tabIndent returns a hint if line n is indented with a tab.
+func tabIndent(line []byte, n int) string {
+	indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+	if bytes.IndexByte(indent, '\t') < 0 || len(bytes.TrimSpace(line)) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("indent line %d with spaces, not tabs", n)
+}
+
+// keyWithoutSpace matches a mapping entry written as "key:value". A value
+// starting with "/" or ":" is left alone, so that URLs such as
+// "http://host" are not mistaken for one.
+var keyWithoutSpace = regexp.MustCompile(`^\s*(?:-\s+)?([A-Za-z_][A-Za-z0-9_.-]*):([^\s/:]\S*(?:\s.*)?)$`)
+
+// missingSpace returns a hint if line is a mapping entry without a space
+// after its colon.
+func missingSpace(line []byte) string {
+	m := keyWithoutSpace.FindSubmatch(bytes.TrimRight(line, "\r"))
+	if m == nil || bytes.Contains(m[2], []byte(": ")) {
+		return ""
+	}
+	return fmt.Sprintf("did you mean %q?", string(m[1])+": "+string(m[2]))
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
@@ -0,0 +1,47 @@
+package syntaxhint
+
+import "testing"
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+		want  string
+	}{
+		{"missing space", "name:foo\nport: 1", 2, `did you mean "name: foo"?`},
+		{"missing space in sequence item", "- name:foo", 1, `did you mean "name: foo"?`},
+		{"missing space before URL", "url:http://x", 1, `did you mean "url: http://x"?`},
+		{"URL is not a missing space", "- http://x\n- b c", 2, ""},
+		{"time is not a missing space", "at: 12:30\n", 1, ""},
+		{"tab indentation", "a: 1\n\tb: 2", 2, "indent line 2 with spaces, not tabs"},
+		{"unclosed double quote", "a: \"abc\nb: 1\n", 3, "close the double quote opened on line 1"},
+		{"unclosed single quote", "a: 'it''s\nb: 1", 2, "close the single quote opened on line 1"},
+		{"escaped quote stays open", "a: \"x\\\"\nb: 1", 2, "close the double quote opened on line 1"},
+		{"unclosed bracket", "a: [1, 2\nb: 1\n", 2, `close the "[" opened on line 1 with "]"`},
+		{"innermost unclosed", "a: {x: [1\n", 1, `close the "[" opened on line 1 with "]"`},
+		{"unknown position", "a: 1\nb: [1, 2\nc: 3", 0, `close the "[" opened on line 2 with "]"`},
+		{"apostrophe in plain scalar", "a: it's\nb c", 2, ""},
+		{"brackets in plain scalar", "a: x[0\nb c", 2, ""},
+		{"quote in comment", "a: 1 # don't\nb c", 2, ""},
+		{"block scalar content", "a: |\n  \"[\nb c", 3, ""},
+		{"closed constructs", "a: [\"x\", {y: 'z'}]\nb c", 2, ""},
+		{"far from the error", "name:foo\na: 1\nb: 2\nc d", 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := For([]byte(tt.input), tt.line); got != tt.want {
+				t.Errorf("For(%q, %d) = %q, want %q", tt.input, tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForAt(t *testing.T) {
+	if got, want := ForAt([]byte("a: 1\nb: [1, 2\nc: 3"), 3, 10), `close the "[" opened on line 11 with "]"`; got != want {
+		t.Errorf("ForAt() = %q, want %q", got, want)
+	}
+	if got, want := ForAt([]byte("a: 1\n\tb: 2"), 2, 5), "indent line 6 with spaces, not tabs"; got != want {
+		t.Errorf("ForAt() = %q, want %q", got, want)
+	}
+}
@@ -13,10 +13,12 @@ func TestUnclosedDoubleQuotes(t *testing.T) {
 		input   string
 		wantErr bool
 	}{
-		{"actual newline in string", "\"unclosed string\nmore content\"", true}, // Real newline should fail
-		{"escape sequence newline", `"unclosed string\nmore content"`, false},   // \n is valid escape
-		{"EOF in string", `"unclosed string`, true},                             // EOF without closing quote
-		{"escaped newline is OK", "\"line1\\nline2\"", false},                   // \n escape is OK
+		{"actual newline in string", "\"folded string\nmore content\"", false}, // Multi-line double quotes are OK in YAML
+		{"EOF after newline", "\"unclosed string\nmore content", true},         // EOF without closing quote
+		{"escaped line break", "\"line1\\\nline2\"", false},                    // Escaped line break joins lines
+		{"escape sequence newline", `"unclosed string\nmore content"`, false},  // \n is valid escape
+		{"EOF in string", `"unclosed string`, true},                            // EOF without closing quote
+		{"escaped newline is OK", "\"line1\\nline2\"", false},                  // \n escape is OK
 	}
 
 	for _, tt := range tests {
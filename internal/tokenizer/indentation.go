@@ -1,6 +1,8 @@
 package tokenizer
 
 import (
+	"fmt"
+
 	"github.com/shapestone/shape-core/pkg/tokenizer"
 )
 
@@ -33,6 +35,19 @@ type IndentationTokenizer struct {
 	atLineStart   bool              // Are we at the start of a line?
 	lastNewline   bool              // Did we just emit a newline?
 	columnAtStart int               // Column number at line start (for indentation)
+	flowDepth     int               // Nesting depth of flow collections, where indentation is not measured
+	trace         TraceFunc         // Called for each token matched and each INDENT/DEDENT, when set
+}
+
+// TraceFunc receives a description of a tokenizer decision, such as
+// `match Colon ":"` or "indent 0 -> 2", with the line and column of the token
+// it was made at.
+type TraceFunc func(line, column int, event string)
+
+// SetTrace makes the tokenizer call fn for each token the base tokenizer
+// matches and each INDENT or DEDENT it emits, for debugging.
+func (it *IndentationTokenizer) SetTrace(fn TraceFunc) {
+	it.trace = fn
 }
 
 // NewIndentationTokenizer creates an indentation-aware tokenizer that wraps a base tokenizer.
@@ -59,9 +74,15 @@ func (it *IndentationTokenizer) NextToken() (*tokenizer.Token, bool) {
 
 	// 2. Get next token from base tokenizer
 	token, ok := it.base.NextToken()
+	if ok && it.trace != nil {
+		it.trace(token.Row(), token.Column(), fmt.Sprintf("match %s %q", token.Kind(), token.ValueString()))
+	}
 	if !ok {
 		// EOF: emit DEDENTs to return to column 0
 		if len(it.indentStack) > 1 {
+			if it.trace != nil {
+				it.trace(0, 0, fmt.Sprintf("dedent %d -> %d at end of input", it.indentStack[len(it.indentStack)-1], it.indentStack[len(it.indentStack)-2]))
+			}
 			it.indentStack = it.indentStack[:len(it.indentStack)-1]
 			dedent := tokenizer.NewToken(TokenDedent, []rune{})
 			return dedent, true
@@ -69,26 +90,41 @@ func (it *IndentationTokenizer) NextToken() (*tokenizer.Token, bool) {
 		return nil, false
 	}
 
-	// 3. Track newlines
+	// 3. Track flow collections: inside [...] and {...}, as in multi-line
+	//    JSON, line breaks are only whitespace and do not start a line
+	switch token.Kind() {
+	case TokenLBrace, TokenLBracket:
+		it.flowDepth++
+	case TokenRBrace, TokenRBracket:
+		if it.flowDepth > 0 {
+			it.flowDepth--
+		}
+	case TokenNewline:
+		if it.flowDepth > 0 {
+			return token, true
+		}
+	}
+
+	// 4. Track newlines
 	if token.Kind() == TokenNewline {
 		it.atLineStart = true
 		it.lastNewline = true
 		return token, true
 	}
 
-	// 4. Skip comments (they don't affect indentation)
+	// 5. Skip comments (they don't affect indentation)
 	if token.Kind() == TokenComment {
 		return token, true
 	}
 
-	// 5. Skip whitespace tokens at line start - we measure indentation
+	// 6. Skip whitespace tokens at line start - we measure indentation
 	//    from the first non-whitespace token
 	if it.atLineStart && token.Kind() == "Whitespace" {
 		// Don't reset atLineStart - we're still waiting for actual content
 		return token, true
 	}
 
-	// 6. At line start: measure indentation and emit INDENT/DEDENT
+	// 7. At line start: measure indentation and emit INDENT/DEDENT
 	if it.atLineStart {
 		it.atLineStart = false
 
@@ -101,6 +137,9 @@ func (it *IndentationTokenizer) NextToken() (*tokenizer.Token, bool) {
 
 		if indent > currentLevel {
 			// INDENT: push new level and emit INDENT token
+			if it.trace != nil {
+				it.trace(token.Row(), token.Column(), fmt.Sprintf("indent %d -> %d", currentLevel, indent))
+			}
 			it.indentStack = append(it.indentStack, indent)
 			indentToken := tokenizer.NewToken(TokenIndent, []rune{})
 
@@ -113,25 +152,35 @@ func (it *IndentationTokenizer) NextToken() (*tokenizer.Token, bool) {
 			// DEDENT: pop levels until we match
 			dedentCount := 0
 			for len(it.indentStack) > 1 && it.indentStack[len(it.indentStack)-1] > indent {
+				if it.trace != nil {
+					it.trace(token.Row(), token.Column(), fmt.Sprintf("dedent %d -> %d", it.indentStack[len(it.indentStack)-1], it.indentStack[len(it.indentStack)-2]))
+				}
 				it.indentStack = it.indentStack[:len(it.indentStack)-1]
 				dedentCount++
 			}
 
+			// Emit DEDENT tokens
+			for i := 0; i < dedentCount; i++ {
+				dedentToken := tokenizer.NewToken(TokenDedent, []rune{})
+				it.pendingTokens = append(it.pendingTokens, *dedentToken)
+			}
+
 			// Check if we landed on exact indentation
 			if len(it.indentStack) > 0 && it.indentStack[len(it.indentStack)-1] != indent {
-				// Indentation error - not aligned with any previous level
-				// For now, we'll be lenient and adjust
+				// Not aligned with any previous level, as the entries of a
+				// collection that began after "- " may be: open a level at
+				// this indentation, with an INDENT to balance the DEDENT
+				// that closes it
 				if indent > it.indentStack[len(it.indentStack)-1] {
+					if it.trace != nil {
+						it.trace(token.Row(), token.Column(), fmt.Sprintf("realign %d -> %d", it.indentStack[len(it.indentStack)-1], indent))
+					}
 					it.indentStack = append(it.indentStack, indent)
+					indentToken := tokenizer.NewToken(TokenIndent, []rune{})
+					it.pendingTokens = append(it.pendingTokens, *indentToken)
 				}
 			}
 
-			// Emit DEDENT tokens
-			for i := 0; i < dedentCount; i++ {
-				dedentToken := tokenizer.NewToken(TokenDedent, []rune{})
-				it.pendingTokens = append(it.pendingTokens, *dedentToken)
-			}
-
 			// Queue the current token
 			it.pendingTokens = append(it.pendingTokens, *token)
 
@@ -189,6 +238,7 @@ func (it *IndentationTokenizer) Reset() {
 	it.atLineStart = true
 	it.lastNewline = false
 	it.columnAtStart = 1
+	it.flowDepth = 0
 }
 
 // GetPosition returns the current position in the stream.
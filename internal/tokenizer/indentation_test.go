@@ -199,6 +199,38 @@ back1: value`
 	}
 }
 
+// TestIndentationTokenizer_Realign tests that a dedent landing between two
+// levels opens a level there with an INDENT, keeping INDENT and DEDENT
+// tokens balanced
+func TestIndentationTokenizer_Realign(t *testing.T) {
+	input := "- - - 1\n    - 2\n  - - 3\n- - - 4\n"
+
+	baseTok := NewTokenizer()
+	indentTok := NewIndentationTokenizer(baseTok)
+	indentTok.Initialize(input)
+
+	var kinds []string
+	for {
+		token, ok := indentTok.NextToken()
+		if !ok {
+			break
+		}
+		if token.Kind() == TokenIndent || token.Kind() == TokenDedent {
+			kinds = append(kinds, token.Kind())
+		}
+	}
+
+	want := []string{TokenIndent, TokenDedent, TokenIndent, TokenDedent}
+	if len(kinds) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, kinds)
+		}
+	}
+}
+
 // TestIndentationTokenizer_Comments tests comment handling
 func TestIndentationTokenizer_Comments(t *testing.T) {
 	input := `# Comment
@@ -21,6 +21,7 @@ import (
 // 11. Plain strings (last, matches anything else)
 // 12. Newlines
 func NewTokenizer() tokenizer.Tokenizer {
+	flow := &flowContext{}
 	return tokenizer.NewTokenizerWithoutWhitespace(
 		// Custom whitespace that doesn't consume newlines
 		YAMLWhitespaceMatcher(),
@@ -33,24 +34,23 @@ func NewTokenizer() tokenizer.Tokenizer {
 
 		// Keywords (before plain strings)
 		// Case-insensitive booleans (true/True/TRUE, yes/Yes/YES, on/On/ON, etc.)
-		BooleanMatcher(),
-		tokenizer.StringMatcherFunc(TokenNull, "null"),
-		tokenizer.CharMatcherFunc(TokenNull, '~'),
+		booleanMatcher(flow),
+		nullMatcher(flow),
 
 		// Numbers (before dash, so -17 matches as number not dash+17)
-		NumberMatcher(),
+		numberMatcher(flow),
 
 		// Structural tokens
 		tokenizer.StringMatcherFunc(TokenColon, ":"),
-		tokenizer.StringMatcherFunc(TokenDash, "-"),
+		IndicatorMatcher(TokenDash, '-'),
 		tokenizer.StringMatcherFunc(TokenComma, ","),
-		tokenizer.StringMatcherFunc(TokenQuestion, "?"),
+		IndicatorMatcher(TokenQuestion, '?'),
 
 		// Flow style tokens
-		tokenizer.StringMatcherFunc(TokenLBrace, "{"),
-		tokenizer.StringMatcherFunc(TokenRBrace, "}"),
-		tokenizer.StringMatcherFunc(TokenLBracket, "["),
-		tokenizer.StringMatcherFunc(TokenRBracket, "]"),
+		flow.indicatorMatcher(TokenLBrace, "{", 1),
+		flow.indicatorMatcher(TokenRBrace, "}", -1),
+		flow.indicatorMatcher(TokenLBracket, "[", 1),
+		flow.indicatorMatcher(TokenRBracket, "]", -1),
 
 		// Block scalars
 		tokenizer.StringMatcherFunc(TokenBlockLiteral, "|"),
@@ -74,13 +74,41 @@ func NewTokenizer() tokenizer.Tokenizer {
 		SingleQuotedStringMatcher(),
 
 		// Plain strings (last, matches anything else)
-		PlainStringMatcher(),
+		plainStringMatcher(flow),
 
 		// Newline
 		NewlineMatcher(),
 	)
 }
 
+// flowContext tracks whether the tokenizer is inside a flow collection. In
+// flow context the flow indicators , [ ] { } end a plain scalar; in block
+// context they are ordinary characters within one, as in "1,5" or
+// "/items/{id}", and only start a flow collection at the start of a value.
+// The matchers of NewTokenizer share one flowContext. A nil flowContext, as
+// the standalone matchers use, treats all input as flow context.
+type flowContext struct {
+	depth int // open flow collections
+}
+
+// inFlow reports whether flow indicators end plain scalars.
+func (c *flowContext) inFlow() bool {
+	return c == nil || c.depth > 0
+}
+
+// indicatorMatcher matches a flow collection indicator, adding delta to the
+// nesting depth when it does.
+func (c *flowContext) indicatorMatcher(kind, indicator string, delta int) tokenizer.Matcher {
+	match := tokenizer.StringMatcherFunc(kind, indicator)
+	return func(stream tokenizer.Stream) *tokenizer.Token {
+		token := match(stream)
+		if token != nil {
+			c.depth = max(c.depth+delta, 0)
+		}
+		return token
+	}
+}
+
 // NewTokenizerWithStream creates a tokenizer for YAML format using a pre-configured stream.
 // This is used internally to support streaming from io.Reader.
 func NewTokenizerWithStream(stream tokenizer.Stream) tokenizer.Tokenizer {
@@ -90,14 +118,14 @@ func NewTokenizerWithStream(stream tokenizer.Stream) tokenizer.Tokenizer {
 }
 
 // DoubleQuotedStringMatcher creates a matcher for YAML double-quoted strings.
-// Matches: "..." with escape sequences \", \\, \n, \t, \r, \uXXXX
+// Matches: "..." with backslash escapes. The string may span lines; folding
+// and decoding the escapes (package escape) are left to the parser.
 //
 // Grammar:
 //
 //	String = '"' { Character } '"' ;
 //	Character = UnescapedChar | EscapeSequence ;
-//	EscapeSequence = "\\" ( '"' | "\\" | "n" | "t" | "r" | "0" | UnicodeEscape ) ;
-//	UnicodeEscape = "u" HexDigit HexDigit HexDigit HexDigit ;
+//	EscapeSequence = "\\" AnyChar ;
 //
 // Performance: Uses ByteStream for fast ASCII scanning with SWAR acceleration.
 func DoubleQuotedStringMatcher() tokenizer.Matcher {
@@ -142,8 +170,9 @@ func doubleQuotedStringMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token
 			if !ok {
 				return nil
 			}
-			// Check for control characters (except tab which is allowed)
-			if b < 0x20 && b != '\t' {
+			// Check for control characters (tab and line breaks are allowed;
+			// the parser folds multi-line scalars)
+			if b < 0x20 && b != '\t' && b != '\n' && b != '\r' {
 				return nil
 			}
 		}
@@ -163,43 +192,10 @@ func doubleQuotedStringMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token
 		}
 
 		if b == '\\' {
-			// Escape sequence - consume next character
-			escaped, ok := stream.NextByte()
-			if !ok {
-				return nil
-			}
-
-			// Validate escape sequence
-			switch escaped {
-			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't', '0':
-				// Valid single-char escape
-			case 'a', 'v', 'e', ' ', 'N', '_', 'L', 'P':
-				// Advanced YAML 1.2 escape sequences
-				// \a=bell, \v=vtab, \e=escape, \ =space, \N=NEL, \_=nbsp, \L=line separator, \P=paragraph separator
-			case 'u':
-				// Unicode escape - consume 4 hex digits
-				for i := 0; i < 4; i++ {
-					hex, ok := stream.NextByte()
-					if !ok {
-						return nil
-					}
-					if !isHexDigitByte(hex) {
-						return nil
-					}
-				}
-			case 'U':
-				// 8-digit Unicode escape - consume 8 hex digits
-				for i := 0; i < 8; i++ {
-					hex, ok := stream.NextByte()
-					if !ok {
-						return nil
-					}
-					if !isHexDigitByte(hex) {
-						return nil
-					}
-				}
-			default:
-				// Invalid escape sequence
+			// Escape sequence - consume next character, which cannot close
+			// the string. Which escapes are valid is left to the parser,
+			// which decodes them with package escape.
+			if _, ok := stream.NextByte(); !ok {
 				return nil
 			}
 		}
@@ -237,43 +233,10 @@ func doubleQuotedStringMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 			}
 			value = append(value, r)
 
-			// Validate escape sequence
-			switch r {
-			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't', '0':
-				// Valid single-char escape
-			case 'a', 'v', 'e', ' ', 'N', '_', 'L', 'P':
-				// Advanced YAML 1.2 escape sequences
-				// \a=bell, \v=vtab, \e=escape, \ =space, \N=NEL, \_=nbsp, \L=line separator, \P=paragraph separator
-			case 'u':
-				// Unicode escape - consume 4 hex digits
-				for i := 0; i < 4; i++ {
-					r, ok := stream.NextChar()
-					if !ok {
-						return nil
-					}
-					if !isHexDigit(r) {
-						return nil
-					}
-					value = append(value, r)
-				}
-			case 'U':
-				// 8-digit Unicode escape - consume 8 hex digits
-				for i := 0; i < 8; i++ {
-					r, ok := stream.NextChar()
-					if !ok {
-						return nil
-					}
-					if !isHexDigit(r) {
-						return nil
-					}
-					value = append(value, r)
-				}
-			default:
-				// Invalid escape sequence
-				return nil
-			}
-		} else if r < 0x20 && r != '\t' {
-			// Control characters not allowed (except tab)
+			// The escaped character cannot close the string; package
+			// escape validates it when the parser decodes the string.
+		} else if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			// Control characters not allowed (except tab and line breaks)
 			return nil
 		}
 	}
@@ -388,7 +351,8 @@ func singleQuotedStringMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 // Restrictions:
 // - Cannot start with: -, ?, :, ,, [, ], {, }, #, &, *, !, |, >, ', ", %, @, backtick
 // - Cannot contain: ": " (colon-space) or " #" (space-hash)
-// - Stops at newline
+// - Stops at newline, and in flow collections at flow indicators (, [ ] { })
+// - Interior spaces are part of the scalar, trailing spaces are not
 // - Must not be a boolean or null keyword
 //
 // Grammar:
@@ -397,27 +361,31 @@ func singleQuotedStringMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 //	PlainFirstChar = [^-?:,\[\]{}#&*!|>'"% @`] ;
 //	PlainChar = [^\n] but not ": " or " #" ;
 func PlainStringMatcher() tokenizer.Matcher {
+	return plainStringMatcher(nil)
+}
+
+func plainStringMatcher(c *flowContext) tokenizer.Matcher {
 	return func(stream tokenizer.Stream) *tokenizer.Token {
 		// Try ByteStream fast path
 		if byteStream, ok := stream.(tokenizer.ByteStream); ok {
-			return plainStringMatcherByte(byteStream)
+			return plainStringMatcherByte(byteStream, c.inFlow())
 		}
 
 		// Fallback to rune-based matcher
-		return plainStringMatcherRune(stream)
+		return plainStringMatcherRune(stream, c.inFlow())
 	}
 }
 
 // plainStringMatcherByte uses ByteStream for optimal performance.
-func plainStringMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
+func plainStringMatcherByte(stream tokenizer.ByteStream, flow bool) *tokenizer.Token {
 	// Check first character
 	b, ok := stream.PeekByte()
 	if !ok {
 		return nil
 	}
 
-	// Cannot start with these characters
-	if !isPlainSafeStart(b) {
+	// Cannot start with these characters ("-" and "?" only when followed by a safe character)
+	if !isPlainSafeStart(b) && !isPlainIndicatorStart(stream.RemainingBytes()) {
 		return nil
 	}
 
@@ -437,13 +405,32 @@ func plainStringMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
 			break
 		}
 
-		// Stop at whitespace (space or tab)
+		// Interior whitespace belongs to the scalar; trailing whitespace does
+		// not. The whole run is consumed at once, so that long runs of blanks
+		// are scanned once rather than once per blank.
 		if b == ' ' || b == '\t' {
-			break
+			if scalarEndsAt(stream.RemainingBytes(), flow) {
+				break
+			}
+			for b == ' ' || b == '\t' {
+				stream.NextByte()
+				b, _ = stream.PeekByte()
+			}
+			continue
 		}
 
-		// Stop at structural characters
-		if b == ':' || b == ',' || b == '[' || b == ']' || b == '{' || b == '}' || b == '#' {
+		// A colon is only an indicator when followed by whitespace or a flow indicator
+		if b == ':' {
+			rest := stream.RemainingBytes()
+			if len(rest) < 2 || isBlankByte(rest[1]) || isFlowIndicatorByte(rest[1]) {
+				break
+			}
+			stream.NextByte()
+			continue
+		}
+
+		// Stop at flow indicators ('#' only starts a comment after whitespace)
+		if flow && isFlowIndicatorByte(b) {
 			break
 		}
 
@@ -460,15 +447,15 @@ func plainStringMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
 }
 
 // plainStringMatcherRune is the fallback rune-based implementation.
-func plainStringMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
+func plainStringMatcherRune(stream tokenizer.Stream, flow bool) *tokenizer.Token {
 	// Check first character
 	r, ok := stream.PeekChar()
 	if !ok {
 		return nil
 	}
 
-	// Cannot start with these characters
-	if !isPlainSafeStartRune(r) {
+	// Cannot start with these characters ("-" and "?" only when followed by a safe character)
+	if !isPlainSafeStartRune(r) && !isPlainIndicatorStartRune(stream) {
 		return nil
 	}
 
@@ -489,13 +476,32 @@ func plainStringMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 			break
 		}
 
-		// Stop at whitespace (space or tab)
+		// Interior whitespace belongs to the scalar; trailing whitespace does
+		// not. The whole run is consumed at once, as in plainStringMatcherByte.
 		if r == ' ' || r == '\t' {
-			break
+			if scalarEndsAtRune(stream, flow) {
+				break
+			}
+			for r == ' ' || r == '\t' {
+				stream.NextChar()
+				value = append(value, r)
+				r, _ = stream.PeekChar()
+			}
+			continue
 		}
 
-		// Stop at structural characters
-		if r == ':' || r == ',' || r == '[' || r == ']' || r == '{' || r == '}' || r == '#' {
+		// A colon is only an indicator when followed by whitespace or a flow indicator
+		if r == ':' {
+			if colonIsIndicatorRune(stream) {
+				break
+			}
+			stream.NextChar()
+			value = append(value, r)
+			continue
+		}
+
+		// Stop at flow indicators ('#' only starts a comment after whitespace)
+		if flow && r < 0x80 && isFlowIndicatorByte(byte(r)) {
 			break
 		}
 
@@ -526,19 +532,23 @@ func plainStringMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 // Examples: 0, -123, 123.456, 1e10, 1.5e-3, 0x1A, 0o755
 // Performance: Uses ByteStream for fast ASCII number scanning.
 func NumberMatcher() tokenizer.Matcher {
+	return numberMatcher(nil)
+}
+
+func numberMatcher(c *flowContext) tokenizer.Matcher {
 	return func(stream tokenizer.Stream) *tokenizer.Token {
 		// Try ByteStream fast path for ASCII numbers
 		if byteStream, ok := stream.(tokenizer.ByteStream); ok {
-			return numberMatcherByte(byteStream)
+			return numberMatcherByte(byteStream, c.inFlow())
 		}
 
 		// Fallback to rune-based matcher
-		return numberMatcherRune(stream)
+		return numberMatcherRune(stream, c.inFlow())
 	}
 }
 
 // numberMatcherByte uses ByteStream for optimal number parsing.
-func numberMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
+func numberMatcherByte(stream tokenizer.ByteStream, flow bool) *tokenizer.Token {
 	startPos := stream.BytePosition()
 
 	// Check for hex (0x) or octal (0o) prefix
@@ -548,7 +558,8 @@ func numberMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
 	}
 
 	// Optional sign
-	if b == '-' || b == '+' {
+	signed := b == '-' || b == '+'
+	if signed {
 		stream.NextByte()
 		b, ok = stream.PeekByte()
 		if !ok {
@@ -556,17 +567,20 @@ func numberMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
 		}
 	}
 
-	// Check for 0x (hex) or 0o (octal)
+	// Check for 0x (hex) or 0o (octal), which take no sign
 	if b == '0' {
 		stream.NextByte()
 		next, ok := stream.PeekByte()
-		if ok {
+		if ok && !signed {
 			if next == 'x' || next == 'X' {
 				// Hex number
 				stream.NextByte()
 				if !consumeHexDigits(stream) {
 					return nil
 				}
+				if !scalarEndsAt(stream.RemainingBytes(), flow) {
+					return nil
+				}
 				value := stream.SliceFrom(startPos)
 				return tokenizer.NewToken(TokenNumber, []rune(string(value)))
 			} else if next == 'o' || next == 'O' {
@@ -575,6 +589,9 @@ func numberMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
 				if !consumeOctalDigits(stream) {
 					return nil
 				}
+				if !scalarEndsAt(stream.RemainingBytes(), flow) {
+					return nil
+				}
 				value := stream.SliceFrom(startPos)
 				return tokenizer.NewToken(TokenNumber, []rune(string(value)))
 			}
@@ -643,13 +660,18 @@ func numberMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
 		}
 	}
 
+	// A number must end the scalar; "2001-12-14" or "1 2" are plain strings
+	if !scalarEndsAt(stream.RemainingBytes(), flow) {
+		return nil
+	}
+
 	// Extract the number as bytes and convert to runes
 	value := stream.SliceFrom(startPos)
 	return tokenizer.NewToken(TokenNumber, []rune(string(value)))
 }
 
 // numberMatcherRune is the fallback rune-based number matcher.
-func numberMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
+func numberMatcherRune(stream tokenizer.Stream, flow bool) *tokenizer.Token {
 	var value []rune
 
 	// Check for hex (0x) or octal (0o) prefix
@@ -659,7 +681,8 @@ func numberMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 	}
 
 	// Optional sign
-	if r == '-' || r == '+' {
+	signed := r == '-' || r == '+'
+	if signed {
 		stream.NextChar()
 		value = append(value, r)
 		r, ok = stream.PeekChar()
@@ -668,12 +691,12 @@ func numberMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 		}
 	}
 
-	// Check for 0x (hex) or 0o (octal)
+	// Check for 0x (hex) or 0o (octal), which take no sign
 	if r == '0' {
 		stream.NextChar()
 		value = append(value, r)
 		next, ok := stream.PeekChar()
-		if ok {
+		if ok && !signed {
 			if next == 'x' || next == 'X' {
 				// Hex number
 				stream.NextChar()
@@ -688,7 +711,7 @@ func numberMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 					value = append(value, r)
 					hasDigits = true
 				}
-				if !hasDigits {
+				if !hasDigits || !scalarEndsAtRune(stream, flow) {
 					return nil
 				}
 				return tokenizer.NewToken(TokenNumber, value)
@@ -706,7 +729,7 @@ func numberMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 					value = append(value, r)
 					hasDigits = true
 				}
-				if !hasDigits {
+				if !hasDigits || !scalarEndsAtRune(stream, flow) {
 					return nil
 				}
 				return tokenizer.NewToken(TokenNumber, value)
@@ -781,6 +804,11 @@ func numberMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 		}
 	}
 
+	// A number must end the scalar; "2001-12-14" or "1 2" are plain strings
+	if !scalarEndsAtRune(stream, flow) {
+		return nil
+	}
+
 	return tokenizer.NewToken(TokenNumber, value)
 }
 
@@ -820,6 +848,114 @@ func isOctalDigitByte(b byte) bool {
 	return b >= '0' && b <= '7'
 }
 
+// scalarEndsAt reports whether a plain scalar ends right before rest.
+// A scalar ends at end of input, a line break, a flow indicator in flow
+// context, a ": " mapping indicator, or at whitespace that is followed by one
+// of those or a comment.
+// Keyword and number matchers use it so that "true story" or "2001-12-14"
+// are matched as plain strings rather than split into several tokens.
+func scalarEndsAt(rest []byte, flow bool) bool {
+	i := 0
+	for i < len(rest) && (rest[i] == ' ' || rest[i] == '\t') {
+		i++
+	}
+	if i == len(rest) {
+		return true
+	}
+	switch rest[i] {
+	case '\n', '\r':
+		return true
+	case ',', '[', ']', '{', '}':
+		return flow
+	case '#':
+		return i > 0
+	case ':':
+		return i+1 == len(rest) || isBlankByte(rest[i+1]) || isFlowIndicatorByte(rest[i+1])
+	}
+	return false
+}
+
+// scalarEndsAtRune is the rune-stream equivalent of scalarEndsAt.
+// It looks ahead without consuming input.
+func scalarEndsAtRune(stream tokenizer.Stream, flow bool) bool {
+	loc := stream.GetLocation()
+	defer stream.SetLocation(loc)
+
+	sawSpace := false
+	for {
+		r, ok := stream.PeekChar()
+		if !ok {
+			return true
+		}
+		switch r {
+		case ' ', '\t':
+			sawSpace = true
+			stream.NextChar()
+			continue
+		case '\n', '\r':
+			return true
+		case ',', '[', ']', '{', '}':
+			return flow
+		case '#':
+			return sawSpace
+		case ':':
+			return colonIsIndicatorRune(stream)
+		}
+		return false
+	}
+}
+
+// colonIsIndicatorRune reports whether the ':' at the stream position is a
+// mapping indicator (followed by whitespace, a flow indicator, or end of input).
+// It looks ahead without consuming input.
+func colonIsIndicatorRune(stream tokenizer.Stream) bool {
+	loc := stream.GetLocation()
+	defer stream.SetLocation(loc)
+
+	stream.NextChar() // skip ':'
+	r, ok := stream.PeekChar()
+	if !ok {
+		return true
+	}
+	return r < 0x80 && (isBlankByte(byte(r)) || isFlowIndicatorByte(byte(r)))
+}
+
+// isBlankByte checks if a byte is a space, tab, or line break.
+func isBlankByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// isFlowIndicatorByte checks if a byte is a flow collection indicator.
+func isFlowIndicatorByte(b byte) bool {
+	return b == ',' || b == '[' || b == ']' || b == '{' || b == '}'
+}
+
+// isPlainIndicatorStart reports whether rest starts with "-" or "?" followed by
+// a character that continues a plain scalar, as in "-foo" or "-.inf".
+func isPlainIndicatorStart(rest []byte) bool {
+	if len(rest) < 2 || (rest[0] != '-' && rest[0] != '?') {
+		return false
+	}
+	return !isBlankByte(rest[1]) && !isFlowIndicatorByte(rest[1]) && rest[1] != '#'
+}
+
+// isPlainIndicatorStartRune is the rune-stream equivalent of isPlainIndicatorStart.
+// It looks ahead without consuming input.
+func isPlainIndicatorStartRune(stream tokenizer.Stream) bool {
+	loc := stream.GetLocation()
+	defer stream.SetLocation(loc)
+
+	r, ok := stream.NextChar()
+	if !ok || (r != '-' && r != '?') {
+		return false
+	}
+	next, ok := stream.PeekChar()
+	if !ok {
+		return false
+	}
+	return next >= 0x80 || (!isBlankByte(byte(next)) && !isFlowIndicatorByte(byte(next)) && next != '#')
+}
+
 // isPlainSafeStart checks if a byte can start a plain scalar.
 func isPlainSafeStart(b byte) bool {
 	// Cannot start with these characters
@@ -881,19 +1017,23 @@ func consumeOctalDigits(stream tokenizer.ByteStream) bool {
 //
 // Returns TokenTrue or TokenFalse based on the matched value.
 func BooleanMatcher() tokenizer.Matcher {
+	return booleanMatcher(nil)
+}
+
+func booleanMatcher(c *flowContext) tokenizer.Matcher {
 	return func(stream tokenizer.Stream) *tokenizer.Token {
 		// Try ByteStream fast path if available
 		if byteStream, ok := stream.(tokenizer.ByteStream); ok {
-			return booleanMatcherByte(byteStream)
+			return booleanMatcherByte(byteStream, c.inFlow())
 		}
 
 		// Fallback to rune-based matcher
-		return booleanMatcherRune(stream)
+		return booleanMatcherRune(stream, c.inFlow())
 	}
 }
 
 // booleanMatcherByte uses ByteStream to peek ahead without consuming
-func booleanMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
+func booleanMatcherByte(stream tokenizer.ByteStream, flow bool) *tokenizer.Token {
 	// Try each boolean keyword in order (longest first to avoid partial matches)
 	keywords := []struct {
 		word      string
@@ -908,7 +1048,7 @@ func booleanMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
 	}
 
 	for _, kw := range keywords {
-		if token := tryMatchKeywordByte(stream, kw.word, kw.tokenKind); token != nil {
+		if token := tryMatchKeywordByte(stream, kw.word, kw.tokenKind, flow); token != nil {
 			return token
 		}
 	}
@@ -917,38 +1057,21 @@ func booleanMatcherByte(stream tokenizer.ByteStream) *tokenizer.Token {
 }
 
 // tryMatchKeywordByte attempts to match a keyword case-insensitively using ByteStream
-func tryMatchKeywordByte(stream tokenizer.ByteStream, keyword string, tokenKind string) *tokenizer.Token {
+func tryMatchKeywordByte(stream tokenizer.ByteStream, keyword string, tokenKind string, flow bool) *tokenizer.Token {
 	// Peek ahead at the bytes we need
 	remaining := stream.RemainingBytes()
 	if len(remaining) < len(keyword) {
 		return nil
 	}
 
-	// Check if keyword matches case-insensitively
-	for i := 0; i < len(keyword); i++ {
-		b := remaining[i]
-		expected := keyword[i]
-
-		// Convert to lowercase for comparison
-		if b >= 'A' && b <= 'Z' {
-			b = b + ('a' - 'A')
-		}
-
-		if b != expected {
-			return nil
-		}
+	// Keywords match in lower, Title, or UPPER case only (true, True, TRUE)
+	if !isKeywordCasing(string(remaining[:len(keyword)]), keyword) {
+		return nil
 	}
 
-	// Check word boundary - next byte must not be alphanumeric, underscore, or dash
-	if len(remaining) > len(keyword) {
-		nextByte := remaining[len(keyword)]
-		if (nextByte >= 'a' && nextByte <= 'z') ||
-			(nextByte >= 'A' && nextByte <= 'Z') ||
-			(nextByte >= '0' && nextByte <= '9') ||
-			nextByte == '_' || nextByte == '-' {
-			// Not a word boundary
-			return nil
-		}
+	// The keyword must be the whole scalar ("true story" is a string)
+	if !scalarEndsAt(remaining[len(keyword):], flow) {
+		return nil
 	}
 
 	// Match found - consume the bytes
@@ -963,7 +1086,7 @@ func tryMatchKeywordByte(stream tokenizer.ByteStream, keyword string, tokenKind
 }
 
 // booleanMatcherRune is the fallback for non-ByteStream
-func booleanMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
+func booleanMatcherRune(stream tokenizer.Stream, flow bool) *tokenizer.Token {
 	// For rune streams, we try each keyword
 	keywords := []struct {
 		word      string
@@ -978,7 +1101,7 @@ func booleanMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 	}
 
 	for _, kw := range keywords {
-		if token := tryMatchCaseInsensitiveKeyword(stream, kw.word, kw.tokenKind); token != nil {
+		if token := tryMatchCaseInsensitiveKeyword(stream, kw.word, kw.tokenKind, flow); token != nil {
 			return token
 		}
 	}
@@ -986,75 +1109,101 @@ func booleanMatcherRune(stream tokenizer.Stream) *tokenizer.Token {
 	return nil
 }
 
-// tryMatchCaseInsensitiveKeyword tries to match a keyword case-insensitively
-// and ensures it's followed by a word boundary.
-func tryMatchCaseInsensitiveKeyword(stream tokenizer.Stream, keyword string, tokenKind string) *tokenizer.Token {
-	// Peek at first character to quick-reject
-	firstChar, ok := stream.PeekChar()
-	if !ok {
-		return nil
-	}
+// tryMatchCaseInsensitiveKeyword tries to match a keyword in lower, Title, or
+// UPPER case and ensures it is the whole plain scalar.
+// The stream is left untouched when the keyword does not match.
+func tryMatchCaseInsensitiveKeyword(stream tokenizer.Stream, keyword string, tokenKind string, flow bool) *tokenizer.Token {
+	loc := stream.GetLocation()
 
-	// Case-insensitive comparison with first character of keyword
-	lowerFirst := firstChar
-	if firstChar >= 'A' && firstChar <= 'Z' {
-		lowerFirst = firstChar + ('a' - 'A')
+	peeked := make([]rune, 0, len(keyword))
+	for i := 0; i < len(keyword); i++ {
+		r, ok := stream.NextChar()
+		if !ok || r >= 0x80 {
+			stream.SetLocation(loc)
+			return nil
+		}
+		peeked = append(peeked, r)
 	}
 
-	if lowerFirst != rune(keyword[0]) {
+	if !isKeywordCasing(string(peeked), keyword) || !scalarEndsAtRune(stream, flow) {
+		stream.SetLocation(loc)
 		return nil
 	}
 
-	// For each position, we need to peek without consuming
-	// Build a list of what we expect to match
-	keywordRunes := []rune(keyword)
+	return tokenizer.NewToken(tokenKind, peeked)
+}
 
-	// Check if we can read enough characters
-	var peeked []rune
-	for i := 0; i < len(keywordRunes); i++ {
-		r, ok := stream.PeekChar()
-		if !ok {
-			return nil
+// isKeywordCasing reports whether s spells the lowercase keyword in one of the
+// casings YAML recognizes: lower ("true"), Title ("True"), or UPPER ("TRUE").
+// It compares byte by byte, without allocating, as it runs for every token.
+func isKeywordCasing(s, keyword string) bool {
+	if s == keyword {
+		return true
+	}
+	const upper = 'a' - 'A'
+	if len(s) != len(keyword) || s[0] != keyword[0]-upper {
+		return false
+	}
+	if s[1:] == keyword[1:] {
+		return true // Title
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] != keyword[i]-upper {
+			return false
 		}
+	}
+	return true
+}
+
+// NullMatcher creates a matcher for YAML null keywords.
+// Matches: null, Null, NULL, ~ when they make up the whole plain scalar.
+func NullMatcher() tokenizer.Matcher {
+	return nullMatcher(nil)
+}
 
-		// Verify case-insensitive match
-		lowerR := r
-		if r >= 'A' && r <= 'Z' {
-			lowerR = r + ('a' - 'A')
+func nullMatcher(c *flowContext) tokenizer.Matcher {
+	keywordMatcher := func(stream tokenizer.Stream) *tokenizer.Token {
+		if byteStream, ok := stream.(tokenizer.ByteStream); ok {
+			return tryMatchKeywordByte(byteStream, "null", TokenNull, c.inFlow())
 		}
+		return tryMatchCaseInsensitiveKeyword(stream, "null", TokenNull, c.inFlow())
+	}
 
-		if lowerR != keywordRunes[i] {
+	return func(stream tokenizer.Stream) *tokenizer.Token {
+		r, ok := stream.PeekChar()
+		if !ok {
 			return nil
 		}
-
-		peeked = append(peeked, r)
-
-		// Move to next character for peeking
-		if i < len(keywordRunes)-1 {
-			stream.NextChar()
+		if r != '~' {
+			return keywordMatcher(stream)
 		}
+		stream.NextChar()
+		if byteStream, ok := stream.(tokenizer.ByteStream); ok {
+			if !scalarEndsAt(byteStream.RemainingBytes(), c.inFlow()) {
+				return nil
+			}
+		} else if !scalarEndsAtRune(stream, c.inFlow()) {
+			return nil
+		}
+		return tokenizer.NewToken(TokenNull, []rune{'~'})
 	}
+}
 
-	// Now stream is positioned at the last character of the keyword
-	// Consume it
-	stream.NextChar()
-
-	// Check word boundary
-	nextChar, ok := stream.PeekChar()
-	if ok {
-		if (nextChar >= 'a' && nextChar <= 'z') ||
-			(nextChar >= 'A' && nextChar <= 'Z') ||
-			(nextChar >= '0' && nextChar <= '9') ||
-			nextChar == '_' || nextChar == '-' {
-			// Not a word boundary - but we already consumed characters!
-			// This is the fundamental problem - can't rewind.
-			// The boolean matcher must be ordered carefully.
-			// For now, we have to accept this limitation.
+// IndicatorMatcher creates a matcher for a single-character block indicator
+// ("-" or "?") that only counts as an indicator when followed by whitespace,
+// a line break, or end of input. Otherwise the character starts a plain scalar.
+func IndicatorMatcher(kind string, indicator rune) tokenizer.Matcher {
+	return func(stream tokenizer.Stream) *tokenizer.Token {
+		r, ok := stream.NextChar()
+		if !ok || r != indicator {
+			return nil
+		}
+		next, ok := stream.PeekChar()
+		if ok && next != ' ' && next != '\t' && next != '\n' && next != '\r' {
 			return nil
 		}
+		return tokenizer.NewToken(kind, []rune{indicator})
 	}
-
-	return tokenizer.NewToken(tokenKind, peeked)
 }
 
 // AnchorMatcher creates a matcher for YAML anchors.
@@ -1136,10 +1285,12 @@ func AliasMatcher() tokenizer.Matcher {
 }
 
 // TagMatcher creates a matcher for YAML tags.
-// Matches: !name, !!name, or !<verbatim> where name is [a-zA-Z0-9_-]+
+// Matches: !name, !!name, !handle!name, or !<verbatim> where name and
+// handle are [a-zA-Z0-9_-]+
 // Examples:
 //   - !Person (custom tag)
 //   - !!str (core tag)
+//   - !e!widget (named handle tag)
 //   - !<tag:example.com,2000:type> (verbatim tag)
 func TagMatcher() tokenizer.Matcher {
 	return func(stream tokenizer.Stream) *tokenizer.Token {
@@ -1177,26 +1328,20 @@ func TagMatcher() tokenizer.Matcher {
 		}
 
 		// Check for optional second ! (core tags)
-		if ok && r == '!' {
+		secondary := ok && r == '!'
+		if secondary {
 			stream.NextChar()
 			value = append(value, r)
 		}
 
 		// Consume identifier characters
-		hasChars := false
-		for {
-			r, ok := stream.PeekChar()
-			if !ok {
-				break
-			}
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
-				(r >= '0' && r <= '9') || r == '_' || r == '-' {
-				stream.NextChar()
-				value = append(value, r)
-				hasChars = true
-			} else {
-				break
-			}
+		hasChars := consumeTagName(stream, &value)
+
+		// A named handle: !e!name, declared with %TAG !e! prefix
+		if r, ok := stream.PeekChar(); ok && r == '!' && hasChars && !secondary {
+			stream.NextChar()
+			value = append(value, r)
+			hasChars = consumeTagName(stream, &value)
 		}
 
 		if !hasChars {
@@ -1208,6 +1353,27 @@ func TagMatcher() tokenizer.Matcher {
 	}
 }
 
+// consumeTagName appends the tag name characters [a-zA-Z0-9_-] at the
+// stream position to value, and reports whether there were any.
+func consumeTagName(stream tokenizer.Stream, value *[]rune) bool {
+	hasChars := false
+	for {
+		r, ok := stream.PeekChar()
+		if !ok {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(r >= '0' && r <= '9') || r == '_' || r == '-' {
+			stream.NextChar()
+			*value = append(*value, r)
+			hasChars = true
+		} else {
+			break
+		}
+	}
+	return hasChars
+}
+
 // DirectiveMatcher creates a matcher for YAML directives.
 // Matches: %YAML 1.2 or %TAG ! tag:example.com,2000:
 // Grammar: "%" DirectiveName DirectiveParameter* Newline
@@ -1,6 +1,7 @@
 package tokenizer
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/shapestone/shape-core/pkg/tokenizer"
@@ -141,6 +142,21 @@ func TestTokenizer_PlainString(t *testing.T) {
 			input:    `hello_world`,
 			expected: `hello_world`,
 		},
+		{
+			name:     "interior blanks",
+			input:    "a \t  b" + strings.Repeat(" ", 1000) + "c  # comment",
+			expected: "a \t  b" + strings.Repeat(" ", 1000) + "c",
+		},
+		{
+			name:     "mixed case keyword",
+			input:    `tRUE`,
+			expected: `tRUE`,
+		},
+		{
+			name:     "keyword prefix",
+			input:    `Truest`,
+			expected: `Truest`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -194,6 +210,16 @@ func TestTokenizer_Number(t *testing.T) {
 			input:    `1.5e-3`,
 			expected: `1.5e-3`,
 		},
+		{
+			name:     "plus-signed integer",
+			input:    `+5`,
+			expected: `+5`,
+		},
+		{
+			name:     "explicit positive exponent",
+			input:    `2e+3`,
+			expected: `2e+3`,
+		},
 		{
 			name:     "hex number",
 			input:    `0x1A`,
@@ -230,6 +256,25 @@ func TestTokenizer_Number(t *testing.T) {
 	}
 }
 
+// TestTokenizer_SignedHexOctalNotNumber tests that hex and octal literals
+// take no sign, as in the core schema, so a signed one is a plain string
+func TestTokenizer_SignedHexOctalNotNumber(t *testing.T) {
+	for _, input := range []string{`+0x1F`, `-0x1F`, `+0o17`, `-0o17`} {
+		t.Run(input, func(t *testing.T) {
+			tok := NewTokenizer()
+			tok.Initialize(input)
+
+			token, ok := tok.NextToken()
+			if !ok {
+				t.Fatalf("Expected token for %s", input)
+			}
+			if token.Kind() == TokenNumber {
+				t.Errorf("Expected %s not to be a TokenNumber", input)
+			}
+		})
+	}
+}
+
 // TestTokenizer_Boolean tests boolean keyword matching
 func TestTokenizer_Boolean(t *testing.T) {
 	tests := []struct {
@@ -240,6 +285,10 @@ func TestTokenizer_Boolean(t *testing.T) {
 		{`false`, TokenFalse},
 		{`yes`, TokenTrue},
 		{`no`, TokenFalse},
+		{`True`, TokenTrue},
+		{`FALSE`, TokenFalse},
+		{`Off`, TokenFalse},
+		{`ON`, TokenTrue},
 	}
 
 	for _, tt := range tests {
@@ -310,6 +359,78 @@ func TestTokenizer_StructuralTokens(t *testing.T) {
 	}
 }
 
+// TestTokenizer_IndicatorStartsPlainScalar tests that "-" and "?" are only
+// indicators when followed by whitespace or end of input
+func TestTokenizer_IndicatorStartsPlainScalar(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		value    string
+	}{
+		{"- item", TokenDash, "-"},
+		{"-\n", TokenDash, "-"},
+		{"? key", TokenQuestion, "?"},
+		{"-foo", TokenString, "-foo"},
+		{"-.inf", TokenString, "-.inf"},
+		{"?foo", TokenString, "?foo"},
+		{"-1", TokenNumber, "-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tok := NewTokenizer()
+			tok.Initialize(tt.input)
+
+			token, ok := tok.NextToken()
+			if !ok {
+				t.Fatal("Expected token")
+			}
+			if token.Kind() != tt.expected || token.ValueString() != tt.value {
+				t.Errorf("Expected %s %q, got %s %q", tt.expected, tt.value, token.Kind(), token.ValueString())
+			}
+		})
+	}
+}
+
+// TestTokenizer_FlowIndicatorsInBlockContext tests that , [ ] { } continue a
+// plain scalar outside flow collections and end it inside them
+func TestTokenizer_FlowIndicatorsInBlockContext(t *testing.T) {
+	tests := []struct {
+		input  string
+		values []string
+	}{
+		{"a: 1,5", []string{"a", ":", "1,5"}},
+		{"a: 1.234,56", []string{"a", ":", "1.234,56"}},
+		{"/p/{id}: x", []string{"/p/{id}", ":", "x"}},
+		{"a: ${{ b }}", []string{"a", ":", "${{ b }}"}},
+		{"a: true,false", []string{"a", ":", "true,false"}},
+		{"a: [1,5]", []string{"a", ":", "[", "1", ",", "5", "]"}},
+		{"a: {b: x,y}", []string{"a", ":", "{", "b", ":", "x", ",", "y", "}"}},
+		{"[a]\nb: 1,5", []string{"[", "a", "]", "\n", "b", ":", "1,5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tok := NewTokenizer()
+			tok.Initialize(tt.input)
+
+			var values []string
+			for _, token := range collectTokens(tok) {
+				values = append(values, token.ValueString())
+			}
+			if len(values) != len(tt.values) {
+				t.Fatalf("Expected %q, got %q", tt.values, values)
+			}
+			for i := range values {
+				if values[i] != tt.values[i] {
+					t.Errorf("Expected %q, got %q", tt.values, values)
+					break
+				}
+			}
+		})
+	}
+}
+
 // TestTokenizer_DocumentMarkers tests document marker matching
 func TestTokenizer_DocumentMarkers(t *testing.T) {
 	tests := []struct {
@@ -806,6 +927,11 @@ func TestTokenizer_TagEdgeCases(t *testing.T) {
 			input:    `!tag123`,
 			expected: `!tag123`,
 		},
+		{
+			name:     "named handle tag",
+			input:    `!e!widget`,
+			expected: `!e!widget`,
+		},
 	}
 
 	for _, tt := range tests {
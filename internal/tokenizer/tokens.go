@@ -2,7 +2,7 @@
 package tokenizer
 
 // Token type constants for YAML format.
-// These correspond to the terminals in the YAML grammar (docs/grammar/yaml-simple.ebnf).
+// These correspond to the terminals in the YAML grammar (docs/grammar/yaml-1.2.ebnf).
 const (
 	// Structural tokens
 	TokenColon    = "Colon"    // :
@@ -0,0 +1,173 @@
+// Package utf8input validates the UTF-8 encoding of YAML input for the AST
+// parser (internal/parser) and the fast parser (internal/fastparser), so that
+// both decode paths reject malformed bytes the same way instead of letting
+// them flow into decoded strings. It also drops the byte order mark a stream
+// may start with, which is not part of its content.
+package utf8input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalid is wrapped by every error reporting malformed UTF-8 input.
+var ErrInvalid = errors.New("invalid UTF-8")
+
+// invalidAt returns the error for a malformed sequence at offset.
+func invalidAt(offset int64) error {
+	return fmt.Errorf("yaml: %w at offset %d", ErrInvalid, offset)
+}
+
+// BOM is the UTF-8 encoding of U+FEFF, the byte order mark YAML allows at
+// the start of a stream.
+const BOM = "\uFEFF"
+
+// TrimBOM returns data without a leading byte order mark.
+func TrimBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, []byte(BOM))
+}
+
+// TrimBOMString is the string form of TrimBOM.
+func TrimBOMString(s string) string {
+	return strings.TrimPrefix(s, BOM)
+}
+
+// Check returns an error wrapping ErrInvalid that reports the byte offset of
+// the first malformed sequence in data, or nil if data is valid UTF-8.
+func Check(data []byte) error {
+	if utf8.Valid(data) {
+		return nil
+	}
+	for i := 0; i < len(data); {
+		if data[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return invalidAt(int64(i))
+		}
+		i += size
+	}
+	return nil
+}
+
+// CheckString is the string form of Check.
+func CheckString(s string) error {
+	if utf8.ValidString(s) {
+		return nil
+	}
+	for i := 0; i < len(s); {
+		if s[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return invalidAt(int64(i))
+		}
+		i += size
+	}
+	return nil
+}
+
+// Repair returns data with every malformed byte replaced by U+FFFD, the way
+// ranging over a Go string decodes it. Valid input is returned as is.
+func Repair(data []byte) []byte {
+	if utf8.Valid(data) {
+		return data
+	}
+	out := make([]byte, 0, len(data)+len(data)/8)
+	for i := 0; i < len(data); {
+		if data[i] < utf8.RuneSelf {
+			out = append(out, data[i])
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			out = utf8.AppendRune(out, utf8.RuneError)
+		} else {
+			out = append(out, data[i:i+size]...)
+		}
+		i += size
+	}
+	return out
+}
+
+// Reader validates a stream as it is read. At the first malformed sequence
+// it returns the bytes before it together with the error reported by Err;
+// a sequence split across reads is checked once it is complete. A byte
+// order mark at the start of the stream is dropped. Read needs room for at
+// least utf8.UTFMax bytes and fails with io.ErrShortBuffer otherwise.
+type Reader struct {
+	r       io.Reader
+	offset  int64                 // input offset of the next byte returned
+	carry   [utf8.UTFMax - 1]byte // incomplete sequence held back from the last read
+	ncarry  int
+	started bool // the first rune has been read, and a byte order mark dropped
+	invalid error
+}
+
+// NewReader returns a Reader that validates the bytes read from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// Err returns the error for the first malformed sequence read, or nil.
+// Parsers built on shape-core streams treat read errors as end of input, so
+// callers check Err after parsing to report the real cause.
+func (v *Reader) Err() error {
+	return v.invalid
+}
+
+func (v *Reader) Read(b []byte) (int, error) {
+	if v.invalid != nil {
+		return 0, v.invalid
+	}
+	if len(b) < utf8.UTFMax {
+		return 0, io.ErrShortBuffer
+	}
+
+	for {
+		n := copy(b, v.carry[:v.ncarry])
+		v.ncarry = 0
+		m, err := v.r.Read(b[n:])
+		n += m
+		if !v.started && n > 0 && (b[0] < utf8.RuneSelf || utf8.FullRune(b[:n]) || err != nil) {
+			v.started = true
+			if bytes.HasPrefix(b[:n], []byte(BOM)) {
+				n = copy(b, b[len(BOM):n])
+				v.offset += int64(len(BOM))
+			}
+		}
+
+		i := 0
+		for i < n {
+			if b[i] < utf8.RuneSelf {
+				i++
+				continue
+			}
+			if !utf8.FullRune(b[i:n]) && err == nil {
+				// Hold back the start of a sequence the next read completes
+				v.ncarry = copy(v.carry[:], b[i:n])
+				break
+			}
+			r, size := utf8.DecodeRune(b[i:n])
+			if r == utf8.RuneError && size == 1 {
+				v.invalid = invalidAt(v.offset + int64(i))
+				return i, v.invalid
+			}
+			i += size
+		}
+
+		v.offset += int64(i)
+		if i > 0 || err != nil {
+			return i, err
+		}
+	}
+}
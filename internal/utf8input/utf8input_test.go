@@ -0,0 +1,129 @@
+package utf8input
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var checkTests = []struct {
+	name   string
+	input  string
+	offset int // -1 for valid input
+}{
+	{"empty", "", -1},
+	{"ascii", "a: b\n", -1},
+	{"multibyte", "name: café \U0001F600\n", -1},
+	{"lone continuation byte", "a: \x80\n", 3},
+	{"invalid start byte", "a: b\xff", 4},
+	{"truncated sequence", "a: \xe2\x82", 3},
+	{"overlong encoding", "a: \xc0\xaf", 3},
+	{"encoded surrogate", "a: \xed\xa0\x80", 3},
+	{"after multibyte", "éé\xfe", 4},
+}
+
+func TestCheck(t *testing.T) {
+	for _, tt := range checkTests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, err := range []error{Check([]byte(tt.input)), CheckString(tt.input)} {
+				if tt.offset < 0 {
+					if err != nil {
+						t.Errorf("Check() error = %v, want nil", err)
+					}
+					continue
+				}
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("Check() error = %v, want ErrInvalid", err)
+				}
+				want := "yaml: invalid UTF-8 at offset " + strconv.Itoa(tt.offset)
+				if err.Error() != want {
+					t.Errorf("Check() error = %q, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"a: b", "a: b"},
+		{"a: \x80", "a: �"},
+		{"a: \xe2\x82", "a: ��"},
+		{"\xffé\xfe", "�é�"},
+	}
+	for _, tt := range tests {
+		if got := string(Repair([]byte(tt.input))); got != tt.want {
+			t.Errorf("Repair(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	for _, tt := range checkTests {
+		for _, split := range []struct {
+			name string
+			wrap func(io.Reader) io.Reader
+		}{{"whole", func(r io.Reader) io.Reader { return r }}, {"byte by byte", iotest.OneByteReader}} {
+			t.Run(tt.name+"/"+split.name, func(t *testing.T) {
+				ur := NewReader(split.wrap(strings.NewReader(tt.input)))
+				got, err := io.ReadAll(ur)
+				if tt.offset < 0 {
+					if err != nil || ur.Err() != nil {
+						t.Fatalf("ReadAll() error = %v, Err() = %v, want nil", err, ur.Err())
+					}
+					if string(got) != tt.input {
+						t.Errorf("ReadAll() = %q, want %q", got, tt.input)
+					}
+					return
+				}
+				if !errors.Is(err, ErrInvalid) || ur.Err() != err {
+					t.Fatalf("ReadAll() error = %v, Err() = %v, want ErrInvalid", err, ur.Err())
+				}
+				if !strings.HasSuffix(err.Error(), "at offset "+strconv.Itoa(tt.offset)) {
+					t.Errorf("ReadAll() error = %q, want offset %d", err, tt.offset)
+				}
+				if !bytes.Equal(got, []byte(tt.input[:tt.offset])) {
+					t.Errorf("ReadAll() = %q, want the valid prefix %q", got, tt.input[:tt.offset])
+				}
+			})
+		}
+	}
+}
+
+func TestReaderBOM(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   string
+	}{
+		{BOM, "", ""},
+		{BOM + "a: 1", "a: 1", ""},
+		{BOM + BOM, BOM, ""},
+		{"a" + BOM, "a" + BOM, ""},
+		{BOM + "a: \x80", "a: ", "yaml: invalid UTF-8 at offset 6"},
+	}
+	for _, tt := range tests {
+		if got := string(TrimBOM([]byte(tt.input))); tt.err == "" && got != tt.want {
+			t.Errorf("TrimBOM(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+		for _, wrap := range []func(io.Reader) io.Reader{func(r io.Reader) io.Reader { return r }, iotest.OneByteReader} {
+			got, err := io.ReadAll(NewReader(wrap(strings.NewReader(tt.input))))
+			if string(got) != tt.want || (err == nil) != (tt.err == "") || (err != nil && err.Error() != tt.err) {
+				t.Errorf("ReadAll(%q) = %q, %v; want %q, %q", tt.input, got, err, tt.want, tt.err)
+			}
+		}
+	}
+}
+
+func TestReaderShortBuffer(t *testing.T) {
+	if _, err := NewReader(strings.NewReader("abc")).Read(make([]byte, 2)); err != io.ErrShortBuffer {
+		t.Errorf("Read() error = %v, want io.ErrShortBuffer", err)
+	}
+}
@@ -0,0 +1,345 @@
+// Package codegen infers Go struct definitions from sample YAML documents.
+//
+// Given one or more samples of a configuration format, GenerateStruct emits Go
+// source for a struct (and any nested structs) with yaml tags that decodes
+// those samples:
+//
+//	src, err := codegen.GenerateStruct([][]byte{sampleA, sampleB}, codegen.Options{
+//	    Package:  "config",
+//	    TypeName: "Deployment",
+//	})
+//
+// Types are merged across samples: int and float unify to float64, an integer
+// above math.MaxInt64 makes the field uint64 (float64 if negatives are also
+// seen), values of unrelated types become interface{}, and keys are kept in
+// the order they were first seen. A key missing from some samples is tagged omitempty, and a key
+// that is null in some samples becomes a pointer.
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/shapestone/shape-yaml/pkg/yaml"
+)
+
+// Options configures GenerateStruct.
+type Options struct {
+	// Package is the package clause of the generated file. Defaults to "main".
+	Package string
+
+	// TypeName is the name of the top-level struct. Defaults to "Config".
+	TypeName string
+}
+
+// GenerateStruct infers a Go struct from the given YAML samples and returns
+// gofmt-formatted Go source. Every sample must be a mapping.
+func GenerateStruct(samples [][]byte, opts Options) ([]byte, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("codegen: no samples")
+	}
+	if opts.Package == "" {
+		opts.Package = "main"
+	}
+	if opts.TypeName == "" {
+		opts.TypeName = "Config"
+	}
+
+	root := &shape{}
+	for i, sample := range samples {
+		var doc yaml.MapSlice
+		if err := yaml.Unmarshal(sample, &doc); err != nil {
+			return nil, fmt.Errorf("codegen: sample %d: %w", i, err)
+		}
+		root.merge(infer(doc))
+	}
+
+	g := &generator{names: map[string]bool{}}
+	g.names[opts.TypeName] = true
+	g.emitStruct(opts.TypeName, root)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n", opts.Package)
+	for _, decl := range g.decls {
+		src.WriteString("\n")
+		src.WriteString(decl)
+	}
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("codegen: formatting generated source: %w", err)
+	}
+	return out, nil
+}
+
+// kind is the inferred type of a value.
+type kind int
+
+const (
+	kindUnknown kind = iota // only null or empty values seen
+	kindBool
+	kindInt
+	kindUint // an integer above math.MaxInt64 seen
+	kindFloat
+	kindString
+	kindMap
+	kindSlice
+	kindAny // conflicting types
+)
+
+// shape is the type inferred for a value across all samples.
+type shape struct {
+	kind     kind
+	nullable bool
+	negative bool // a negative number seen
+
+	// kindMap
+	fields []*field
+	objs   int // number of mappings merged into this shape
+
+	// kindSlice
+	elem *shape
+}
+
+// field is a mapping key and the shape of its values.
+type field struct {
+	key   string
+	shape *shape
+	seen  int // number of mappings containing the key
+}
+
+// infer builds the shape of a single decoded value.
+func infer(v interface{}) *shape {
+	switch v := v.(type) {
+	case nil:
+		return &shape{nullable: true}
+	case bool:
+		return &shape{kind: kindBool}
+	case int64:
+		return &shape{kind: kindInt, negative: v < 0}
+	case uint64:
+		return &shape{kind: kindUint}
+	case float64:
+		return &shape{kind: kindFloat, negative: v < 0}
+	case string:
+		return &shape{kind: kindString}
+	case yaml.MapSlice:
+		s := &shape{kind: kindMap, objs: 1}
+		for _, item := range v {
+			s.fields = append(s.fields, &field{key: fmt.Sprint(item.Key), shape: infer(item.Value), seen: 1})
+		}
+		return s
+	case []interface{}:
+		s := &shape{kind: kindSlice, elem: &shape{}}
+		for _, e := range v {
+			s.elem.merge(infer(e))
+		}
+		return s
+	default:
+		return &shape{kind: kindAny}
+	}
+}
+
+// merge widens s so that it also describes o.
+func (s *shape) merge(o *shape) {
+	s.nullable = s.nullable || o.nullable
+	s.negative = s.negative || o.negative
+
+	switch {
+	case o.kind == kindUnknown:
+		return
+	case s.kind == kindUnknown:
+		s.kind, s.fields, s.objs, s.elem = o.kind, o.fields, o.objs, o.elem
+		return
+	case s.kind == o.kind:
+	case s.isNumber() && o.isNumber():
+		// int and uint64 share uint64 unless a negative value rules it out
+		if s.kind != kindFloat && o.kind != kindFloat && !s.negative {
+			s.kind = kindUint
+		} else {
+			s.kind = kindFloat
+		}
+		return
+	default:
+		s.kind, s.fields, s.elem = kindAny, nil, nil
+		return
+	}
+
+	switch s.kind {
+	case kindMap:
+		for _, of := range o.fields {
+			if f := s.field(of.key); f != nil {
+				f.shape.merge(of.shape)
+				f.seen += of.seen
+			} else {
+				s.fields = append(s.fields, of)
+			}
+		}
+		s.objs += o.objs
+	case kindSlice:
+		s.elem.merge(o.elem)
+	}
+}
+
+func (s *shape) isNumber() bool {
+	return s.kind == kindInt || s.kind == kindUint || s.kind == kindFloat
+}
+
+func (s *shape) field(key string) *field {
+	for _, f := range s.fields {
+		if f.key == key {
+			return f
+		}
+	}
+	return nil
+}
+
+// generator accumulates type declarations for the generated file.
+type generator struct {
+	decls []string
+	names map[string]bool // type names in use
+}
+
+// emitStruct appends the declaration of struct name (and its nested types).
+func (g *generator) emitStruct(name string, s *shape) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+
+	// Reserve the struct's declaration slot before nested types are added
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	used := map[string]bool{}
+	for _, f := range s.fields {
+		fieldName := uniqueName(goName(f.key), used)
+		typ := g.goType(name, fieldName, f.shape)
+
+		name := f.key
+		if f.seen < s.objs {
+			name += ",omitempty"
+		}
+		tag := "yaml:" + strconv.Quote(name)
+		if strings.ContainsRune(tag, '`') {
+			// A raw string literal cannot hold a backtick
+			tag = strconv.Quote(tag)
+		} else {
+			tag = "`" + tag + "`"
+		}
+		fmt.Fprintf(&b, "\t%s %s %s\n", fieldName, typ, tag)
+	}
+	b.WriteString("}\n")
+	g.decls[idx] = b.String()
+}
+
+// goType returns the Go type for a field's shape, declaring nested structs as
+// needed. parent and fieldName are used to name those structs.
+func (g *generator) goType(parent, fieldName string, s *shape) string {
+	var typ string
+	switch s.kind {
+	case kindBool:
+		typ = "bool"
+	case kindInt:
+		typ = "int"
+	case kindUint:
+		typ = "uint64"
+	case kindFloat:
+		typ = "float64"
+	case kindString:
+		typ = "string"
+	case kindMap:
+		if len(s.fields) == 0 {
+			return "map[string]interface{}"
+		}
+		typ = g.newTypeName(parent, fieldName)
+		g.emitStruct(typ, s)
+	case kindSlice:
+		return "[]" + g.goType(parent, singular(fieldName), s.elem)
+	default:
+		return "interface{}"
+	}
+	if s.nullable {
+		typ = "*" + typ
+	}
+	return typ
+}
+
+// newTypeName picks an unused name for a nested struct, preferring the field
+// name and falling back to the parent-qualified name.
+func (g *generator) newTypeName(parent, fieldName string) string {
+	for _, name := range []string{fieldName, parent + fieldName} {
+		if !g.names[name] {
+			g.names[name] = true
+			return name
+		}
+	}
+	return uniqueName(parent+fieldName, g.names)
+}
+
+// uniqueName returns name, or name with a numeric suffix if it is already used,
+// and marks the result as used.
+func uniqueName(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// commonInitialisms are written in upper case in Go identifiers.
+var commonInitialisms = map[string]bool{
+	"API": true, "CPU": true, "DNS": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "SSH": true,
+	"TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true,
+	"URI": true, "URL": true, "UUID": true, "YAML": true,
+}
+
+// goName converts a YAML key such as "max-retries" or "api_url" to an exported
+// Go identifier ("MaxRetries", "APIURL").
+func goName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	name := b.String()
+	if name == "" {
+		return "Field"
+	}
+	if !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// singular names the element type of a sequence field: "Containers" gets
+// "Container", "Policies" gets "Policy" and "Addresses" gets "Address". Names that do not end in a plain
+// plural "s" ("Status", "Address", "Data") get an "Item" suffix instead.
+func singular(name string) string {
+	switch {
+	case len(name) > 3 && strings.HasSuffix(name, "ies"):
+		return name[:len(name)-3] + "y"
+	case len(name) > 4 && (strings.HasSuffix(name, "sses") || strings.HasSuffix(name, "xes")):
+		return name[:len(name)-2]
+	case len(name) > 1 && strings.HasSuffix(name, "s"):
+		if prev := name[len(name)-2]; prev != 's' && prev != 'u' && prev != 'i' {
+			return name[:len(name)-1]
+		}
+	}
+	return name + "Item"
+}
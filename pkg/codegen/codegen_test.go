@@ -0,0 +1,193 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateStruct(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []string
+		opts    Options
+		want    string
+	}{
+		{
+			name:    "scalars in document order",
+			samples: []string{"name: api\nreplicas: 3\nratio: 0.5\nenabled: true\n"},
+			want: `package main
+
+type Config struct {
+	Name     string  ` + "`yaml:\"name\"`" + `
+	Replicas int     ` + "`yaml:\"replicas\"`" + `
+	Ratio    float64 ` + "`yaml:\"ratio\"`" + `
+	Enabled  bool    ` + "`yaml:\"enabled\"`" + `
+}
+`,
+		},
+		{
+			name: "optional and nullable fields across samples",
+			samples: []string{
+				"port: 80\ntimeout: 1\nhost: a\n",
+				"port: 8080\ntimeout: 1.5\nhost: null\nextra: x\n",
+			},
+			opts: Options{Package: "config", TypeName: "Server"},
+			want: `package config
+
+type Server struct {
+	Port    int     ` + "`yaml:\"port\"`" + `
+	Timeout float64 ` + "`yaml:\"timeout\"`" + `
+	Host    *string ` + "`yaml:\"host\"`" + `
+	Extra   string  ` + "`yaml:\"extra,omitempty\"`" + `
+}
+`,
+		},
+		{
+			name: "nested mappings and sequences",
+			samples: []string{`
+metadata:
+  name: web
+  labels: {}
+containers:
+  - name: app
+    image: app:v1
+  - name: sidecar
+    ports: [80, 443]
+tags: [a, b]
+mixed: [1, x]
+`},
+			want: `package main
+
+type Config struct {
+	Metadata   Metadata    ` + "`yaml:\"metadata\"`" + `
+	Containers []Container ` + "`yaml:\"containers\"`" + `
+	Tags       []string    ` + "`yaml:\"tags\"`" + `
+	Mixed      []interface{} ` + "`yaml:\"mixed\"`" + `
+}
+
+type Metadata struct {
+	Name   string                 ` + "`yaml:\"name\"`" + `
+	Labels map[string]interface{} ` + "`yaml:\"labels\"`" + `
+}
+
+type Container struct {
+	Name  string ` + "`yaml:\"name\"`" + `
+	Image string ` + "`yaml:\"image,omitempty\"`" + `
+	Ports []int  ` + "`yaml:\"ports,omitempty\"`" + `
+}
+`,
+		},
+		{
+			name:    "key naming",
+			samples: []string{"api-url: x\nmax_retries: 1\nuserID: 2\n2fa: true\n"},
+			want: `package main
+
+type Config struct {
+	APIURL     string ` + "`yaml:\"api-url\"`" + `
+	MaxRetries int    ` + "`yaml:\"max_retries\"`" + `
+	UserID     int    ` + "`yaml:\"userID\"`" + `
+	X2fa       bool   ` + "`yaml:\"2fa\"`" + `
+}
+`,
+		},
+		{
+			name: "unsigned integers",
+			samples: []string{
+				"id: 18446744073709551615\nseq: 1\nbalance: -1\n",
+				"id: 7\nseq: 9223372036854775808\nbalance: 18446744073709551615\n",
+			},
+			want: `package main
+
+type Config struct {
+	ID      uint64  ` + "`yaml:\"id\"`" + `
+	Seq     uint64  ` + "`yaml:\"seq\"`" + `
+	Balance float64 ` + "`yaml:\"balance\"`" + `
+}
+`,
+		},
+		{
+			name:    "element type names",
+			samples: []string{"status: [{a: 1}]\naddresses: [{a: 1}]\npolicies: [{a: 1}]\ndata: [{a: 1}]\n"},
+			want: `package main
+
+type Config struct {
+	Status    []StatusItem ` + "`yaml:\"status\"`" + `
+	Addresses []Address    ` + "`yaml:\"addresses\"`" + `
+	Policies  []Policy     ` + "`yaml:\"policies\"`" + `
+	Data      []DataItem   ` + "`yaml:\"data\"`" + `
+}
+
+type StatusItem struct {
+	A int ` + "`yaml:\"a\"`" + `
+}
+
+type Address struct {
+	A int ` + "`yaml:\"a\"`" + `
+}
+
+type Policy struct {
+	A int ` + "`yaml:\"a\"`" + `
+}
+
+type DataItem struct {
+	A int ` + "`yaml:\"a\"`" + `
+}
+`,
+		},
+		{
+			name:    "backtick in key",
+			samples: []string{"\"a`b\": 1\n"},
+			want: `package main
+
+type Config struct {
+	AB int "yaml:\"a` + "`" + `b\""
+}
+`,
+		},
+		{
+			name:    "conflicting types",
+			samples: []string{"v: 1\n", "v: [1]\n"},
+			want: `package main
+
+type Config struct {
+	V interface{} ` + "`yaml:\"v\"`" + `
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := make([][]byte, len(tt.samples))
+			for i, s := range tt.samples {
+				samples[i] = []byte(s)
+			}
+			got, err := GenerateStruct(samples, tt.opts)
+			if err != nil {
+				t.Fatalf("GenerateStruct() error = %v", err)
+			}
+			if normalize(string(got)) != normalize(tt.want) {
+				t.Errorf("GenerateStruct() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateStruct_Errors(t *testing.T) {
+	if _, err := GenerateStruct(nil, Options{}); err == nil {
+		t.Error("expected error for no samples")
+	}
+	if _, err := GenerateStruct([][]byte{[]byte("- a\n- b\n")}, Options{}); err == nil {
+		t.Error("expected error for a sequence document")
+	}
+}
+
+// normalize collapses runs of spaces so expectations need not match gofmt's
+// column alignment exactly.
+func normalize(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, l := range lines {
+		lines[i] = strings.Join(strings.Fields(l), " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,8 +1,11 @@
 package yaml
 
 import (
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
+	"unsafe"
 
 	"github.com/shapestone/shape-core/pkg/ast"
 )
@@ -101,6 +104,61 @@ id: 3`
 	}
 }
 
+// TestParseMultiDocAnchorScope verifies that anchors do not outlive their
+// document unless shared
+func TestParseMultiDocAnchorScope(t *testing.T) {
+	input := "base: &b 1\n---\ncopy: *b\n"
+	if _, err := ParseMultiDoc(input); err == nil {
+		t.Error("ParseMultiDoc() resolved an alias to an earlier document's anchor")
+	}
+
+	docs, err := ParseMultiDocWithSharedAnchors(input)
+	if err != nil {
+		t.Fatalf("ParseMultiDocWithSharedAnchors() error: %v", err)
+	}
+	if got := NodeToInterface(docs[1]); !reflect.DeepEqual(got, map[string]interface{}{"copy": int64(1)}) {
+		t.Errorf("second document = %v, want copy: 1", got)
+	}
+}
+
+// TestValid verifies the boolean form of Validate
+func TestValid(t *testing.T) {
+	for _, data := range []string{"name: a", "[1, 2]", "", "a: 1\nb: [x, y]\n"} {
+		if !Valid([]byte(data)) {
+			t.Errorf("Valid(%q) = false, want true", data)
+		}
+	}
+	for _, data := range []string{"a: [1, 2", "{a: 1", "a: \xff"} {
+		if Valid([]byte(data)) {
+			t.Errorf("Valid(%q) = true, want false", data)
+		}
+	}
+}
+
+// TestMust verifies that MustParse and MustMarshal return what Parse and
+// Marshal do, and panic with their error
+func TestMust(t *testing.T) {
+	node := MustParse("name: a\nport: 80")
+	if got := NodeToInterface(node); !reflect.DeepEqual(got, map[string]interface{}{"name": "a", "port": int64(80)}) {
+		t.Errorf("MustParse() = %v", got)
+	}
+	if got := string(MustMarshal(map[string]int{"port": 80})); got != "port: 80" {
+		t.Errorf("MustMarshal() = %q, want %q", got, "port: 80")
+	}
+
+	mustPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if _, ok := recover().(error); !ok {
+				t.Errorf("%s did not panic with an error", name)
+			}
+		}()
+		fn()
+	}
+	mustPanic("MustParse", func() { MustParse("a: [1, 2") })
+	mustPanic("MustMarshal", func() { MustMarshal(make(chan int)) })
+}
+
 // TestParseMultiDocEmpty verifies empty stream handling
 func TestParseMultiDocEmpty(t *testing.T) {
 	docs, err := ParseMultiDoc("")
@@ -169,6 +227,75 @@ age: 30`
 	}
 }
 
+// TestParseWithStats checks the reported counts, and that they agree with
+// the limits ValidateReader enforces.
+func TestParseWithStats(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Stats
+	}{
+		{"hello", Stats{Bytes: 5, Nodes: 1, MaxDepth: 1}},
+		{"a: 1\nb: 2\n", Stats{Bytes: 10, Nodes: 3, MaxDepth: 2}},
+		{"a:\n  b: [1, 2]\nc: x\n", Stats{Bytes: 20, Nodes: 6, MaxDepth: 4}},
+		{"# only a comment\n", Stats{Bytes: 17}},
+	}
+	for _, tt := range tests {
+		node, got, err := ParseWithStats(tt.input)
+		if err != nil {
+			t.Fatalf("ParseWithStats(%q) error = %v", tt.input, err)
+		}
+		ReleaseTree(node)
+		if got != tt.want {
+			t.Errorf("ParseWithStats(%q) stats = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if got.Nodes == 0 {
+			continue
+		}
+
+		within := Limits{MaxNodes: got.Nodes, MaxDepth: got.MaxDepth}
+		if err := ValidateReader(strings.NewReader(tt.input), within); err != nil {
+			t.Errorf("ValidateReader(%q, %+v) error = %v", tt.input, within, err)
+		}
+		for _, over := range []Limits{{MaxNodes: got.Nodes - 1}, {MaxDepth: got.MaxDepth - 1}} {
+			if over.MaxNodes == 0 && over.MaxDepth == 0 {
+				continue // zero means no limit
+			}
+			if err := ValidateReader(strings.NewReader(tt.input), over); !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("ValidateReader(%q, %+v) error = %v, want ErrLimitExceeded", tt.input, over, err)
+			}
+		}
+	}
+
+	if _, _, err := ParseWithStats("a: [1"); err == nil {
+		t.Error("ParseWithStats() of invalid YAML succeeded")
+	}
+}
+
+func TestParseWithTrace(t *testing.T) {
+	input := "server:\n  ports: [80, 443]\n"
+	var trace strings.Builder
+	node, err := ParseWithTrace(input, &trace)
+	if err != nil {
+		t.Fatalf("ParseWithTrace() error = %v", err)
+	}
+	want, err := Parse(input)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(NodeToInterface(node), NodeToInterface(want)) {
+		t.Errorf("ParseWithTrace() = %v, want %v", NodeToInterface(node), NodeToInterface(want))
+	}
+	for _, event := range []string{"match Colon \":\"", "indent 0 -> 2", "enter parseFlowSequence", "leave parseDocument"} {
+		if !strings.Contains(trace.String(), event) {
+			t.Errorf("trace is missing %q:\n%s", event, trace.String())
+		}
+	}
+
+	if _, err := ParseWithTrace("a: [1", &trace); err == nil {
+		t.Error("ParseWithTrace() of invalid YAML error = nil")
+	}
+}
+
 // TestParseReader verifies the ParseReader function
 func TestParseReader(t *testing.T) {
 	yamlStr := `name: Bob
@@ -370,6 +497,37 @@ tags:
 	if len(tags) != 2 || tags[0] != "go" || tags[1] != "yaml" {
 		t.Errorf("tags = %v, want [go yaml]", tags)
 	}
+
+	node, err = Parse("seq: []\nmap: {}")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	m = NodeToInterface(node).(map[string]interface{})
+	if seq, ok := m["seq"].([]interface{}); !ok || len(seq) != 0 {
+		t.Errorf("seq = %#v, want empty []interface{}", m["seq"])
+	}
+	if mp, ok := m["map"].(map[string]interface{}); !ok || len(mp) != 0 {
+		t.Errorf("map = %#v, want empty map[string]interface{}", m["map"])
+	}
+}
+
+// TestNodeToInterface_AliasedScalar verifies that the aliases of a scalar
+// share its string rather than copying it.
+func TestNodeToInterface_AliasedScalar(t *testing.T) {
+	text := strings.Repeat("x", 4096)
+	node, err := Parse("a: &big " + text + "\nb: *big\nc: [*big, *big]\n")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	m := NodeToInterface(node).(map[string]interface{})
+	c := m["c"].([]interface{})
+	want := unsafe.StringData(m["a"].(string))
+	for i, v := range []interface{}{m["b"], c[0], c[1]} {
+		if s, ok := v.(string); !ok || s != text || unsafe.StringData(s) != want {
+			t.Errorf("alias %d does not share the anchored string", i)
+		}
+	}
 }
 
 // TestInterfaceToNode verifies Go type to AST conversion
@@ -400,6 +558,15 @@ func TestInterfaceToNode(t *testing.T) {
 	if nameLit.Value() != "Alice" {
 		t.Errorf("name = %v, want Alice", nameLit.Value())
 	}
+
+	tagsNode, _ := obj.GetProperty("tags")
+	tags, ok := tagsNode.(*ast.ArrayDataNode)
+	if !ok {
+		t.Fatalf("tags is %T, want *ast.ArrayDataNode", tagsNode)
+	}
+	if tags.Len() != 2 || tags.Get(1).(*ast.LiteralNode).Value() != "yaml" {
+		t.Errorf("tags = %v, want [go, yaml]", tags)
+	}
 }
 
 // TestRoundTrip verifies Marshal -> Unmarshal round trip
@@ -25,7 +25,9 @@ type ComparisonConfig struct {
 // shape-yaml (our implementation)
 // ============================================================================
 
-func BenchmarkShapeYAML_Unmarshal(b *testing.B) {
+// BenchmarkFastYAML_Unmarshal measures the fast path (internal/fastparser)
+// that Unmarshal takes.
+func BenchmarkFastYAML_Unmarshal(b *testing.B) {
 	data := []byte(testData)
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -37,6 +39,20 @@ func BenchmarkShapeYAML_Unmarshal(b *testing.B) {
 	}
 }
 
+// BenchmarkShapeYAML_Unmarshal measures the AST parser path of
+// UnmarshalWithAST.
+func BenchmarkShapeYAML_Unmarshal(b *testing.B) {
+	data := []byte(testData)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var cfg ComparisonConfig
+		if err := UnmarshalWithAST(data, &cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkShapeYAML_Marshal(b *testing.B) {
 	cfg := ComparisonConfig{
 		Name:    "test",
@@ -4,6 +4,8 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/shapestone/shape-yaml/internal/bufpool"
 )
 
 var testYAML = "name: BenchmarkTest\nversion: \"1.0\"\nenabled: true\ncount: 42"
@@ -183,3 +185,135 @@ func BenchmarkFluentAPI(b *testing.B) {
 		_ = obj.Build()
 	}
 }
+
+var escapedYAML = "title: \"Line one\\nLine two\\tTabbed\"\n" +
+	"quote: 'It''s here'\n" +
+	"path: \"C:\\\\Users\\\\bench\"\n"
+
+// reportPoolStats reports per-op counters of pool activity since before.
+func reportPoolStats(b *testing.B, prefix string, pool *bufpool.Pool, before bufpool.Stats) {
+	delta := pool.Stats().Sub(before)
+	n := float64(b.N)
+	b.ReportMetric(float64(delta.Gets)/n, prefix+"-gets/op")
+	b.ReportMetric(float64(delta.Misses)/n, prefix+"-misses/op")
+	b.ReportMetric(float64(delta.Dropped)/n, prefix+"-dropped/op")
+}
+
+func BenchmarkUnmarshalEscapedStrings(b *testing.B) {
+	data := []byte(escapedYAML)
+	b.ReportAllocs()
+	before := bufpool.Scratch.Stats()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var m map[string]string
+		if err := Unmarshal(data, &m); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	reportPoolStats(b, "scratch", bufpool.Scratch, before)
+}
+
+func BenchmarkUnmarshalWithASTEscapedStrings(b *testing.B) {
+	data := []byte(escapedYAML)
+	b.ReportAllocs()
+	before := bufpool.Scratch.Stats()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var m map[string]string
+		if err := UnmarshalWithAST(data, &m); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	reportPoolStats(b, "scratch", bufpool.Scratch, before)
+}
+
+func BenchmarkMarshalEscapedStrings(b *testing.B) {
+	m := map[string]string{
+		"title": "Line one\nLine two\tTabbed",
+		"quote": "It's \"here\"",
+		"path":  "C:\\Users\\bench",
+	}
+	b.ReportAllocs()
+	before := yamlBufPool.Stats()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(m); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	reportPoolStats(b, "encbuf", yamlBufPool, before)
+}
+
+// longLine returns a single-line document of at least size bytes: minified
+// JSON, or a plain scalar with long runs of blanks.
+func longLine(shape string, size int) string {
+	var sb strings.Builder
+	switch shape {
+	case "json":
+		sb.WriteString(`{"items":[`)
+		for i := 0; sb.Len() < size; i++ {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(`{"id":` + strconv.Itoa(i) + `,"name":"item ` + strconv.Itoa(i) + `","tags":["a","b"],"ok":true}`)
+		}
+		sb.WriteString(`]}`)
+	case "blanks":
+		sb.WriteString("text: a")
+		for sb.Len() < size {
+			sb.WriteString(strings.Repeat(" ", 1000) + "b")
+		}
+	}
+	return sb.String()
+}
+
+// BenchmarkLongLine decodes single-line documents of increasing size on the
+// fast path, with the AST parser, and into Nodes on the fast path. Decoding
+// is linear in the input, so the throughput of each should not fall as the
+// size grows.
+func BenchmarkLongLine(b *testing.B) {
+	type items struct {
+		Items []Node `yaml:"items"`
+	}
+	for _, shape := range []string{"json", "blanks"} {
+		for _, size := range []int{64 << 10, 256 << 10, 1 << 20} {
+			data := []byte(longLine(shape, size))
+			name := shape + "/" + strconv.Itoa(size>>10) + "KB"
+			b.Run(name+"/fast", func(b *testing.B) {
+				b.SetBytes(int64(len(data)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					var v interface{}
+					if err := Unmarshal(data, &v); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run(name+"/ast", func(b *testing.B) {
+				b.SetBytes(int64(len(data)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := Parse(string(data)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			if shape != "json" {
+				continue
+			}
+			b.Run(name+"/nodes", func(b *testing.B) {
+				b.SetBytes(int64(len(data)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					var v items
+					if err := Unmarshal(data, &v); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package yaml
+
+import (
+	"encoding/base64"
+	"reflect"
+)
+
+// binaryLineLen is the number of base64 characters on each line of a
+// !!binary block, as in MIME.
+const binaryLineLen = 76
+
+// isBinary reports whether t is a byte slice, which is encoded as a !!binary
+// scalar of base64 text rather than as a sequence of numbers, and decoded
+// from one.
+func isBinary(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// yamlBinaryEnc writes a byte slice as a !!binary literal block scalar,
+// whose base64 lines are one level below indent. A nil slice is null, and
+// an empty one the empty !!binary "".
+func yamlBinaryEnc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	if rv.IsNil() {
+		return append(buf, "null"...), nil
+	}
+	if rv.Len() == 0 {
+		return append(buf, binaryPrefix+`""`...), nil
+	}
+
+	text := make([]byte, base64.StdEncoding.EncodedLen(rv.Len()))
+	base64.StdEncoding.Encode(text, rv.Bytes())
+	buf = append(buf, binaryPrefix+"|"...)
+	for len(text) > 0 {
+		n := min(len(text), binaryLineLen)
+		buf = append(buf, '\n')
+		buf = e.appendIndent(buf, indent+1)
+		buf = append(buf, text[:n]...)
+		text = text[n:]
+	}
+	return buf, nil
+}
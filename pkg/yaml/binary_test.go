@@ -0,0 +1,88 @@
+package yaml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBinaryMarshal(t *testing.T) {
+	long := []byte(strings.Repeat("shape-yaml ", 8))
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{"top level", []byte("hello"), "!!binary |\n  aGVsbG8="},
+		{"nil", map[string][]byte{"b": nil}, "b: null"},
+		{"empty", map[string][]byte{"b": {}}, `b: !!binary ""`},
+		{
+			"long lines wrap at 76",
+			map[string][]byte{"b": long},
+			"b: !!binary |\n  c2hhcGUteWFtbCBzaGFwZS15YW1sIHNoYXBlLXlhbWwgc2hhcGUteWFtbCBzaGFwZS15YW1sIHNo\n  YXBlLXlhbWwgc2hhcGUteWFtbCBzaGFwZS15YW1sIA==",
+		},
+		{"in sequence", [][]byte{[]byte("a")}, "- !!binary |\n  YQ=="},
+		{"in interface", map[string]interface{}{"b": []byte("a")}, "b: !!binary |\n  YQ=="},
+		{"tagged", Tagged{Tag: "!Image", Value: []byte("a")}, "!Image |\n  YQ=="},
+		{"tagged binary", Tagged{Tag: BinaryTag, Value: []byte("a")}, "!!binary |\n  YQ=="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// A byte slice is a scalar, never a flow sequence or an anchor
+	shared := []byte("ab")
+	got, err := MarshalWithOptions(map[string][]byte{"a": shared, "b": shared}, MarshalOptions{FlowThreshold: 4, Anchors: true})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	if want := "a: !!binary |\n  YWI=\nb: !!binary |\n  YWI="; string(got) != want {
+		t.Errorf("MarshalWithOptions() = %q, want %q", got, want)
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	type Asset struct {
+		Name  string      `yaml:"name"`
+		Data  []byte      `yaml:"data"`
+		Empty []byte      `yaml:"empty"`
+		Parts [][]byte    `yaml:"parts"`
+		Any   interface{} `yaml:"any"`
+	}
+	in := Asset{
+		Name:  "logo",
+		Data:  bytes.Repeat([]byte{0, 1, 2, 0xfe, 0xff}, 40),
+		Empty: []byte{},
+		Parts: [][]byte{[]byte("a"), []byte("bc")},
+		Any:   []byte("x"),
+	}
+	for _, opts := range []MarshalOptions{{}, {Indent: 4, Compact: true}} {
+		out, err := MarshalWithOptions(in, opts)
+		if err != nil {
+			t.Fatalf("MarshalWithOptions() error = %v", err)
+		}
+		forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+			var got Asset
+			if err := decode(out, &got); err != nil {
+				t.Fatalf("decode %q: %v", out, err)
+			}
+			if got.Name != in.Name || !bytes.Equal(got.Data, in.Data) || got.Empty == nil || len(got.Empty) != 0 {
+				t.Errorf("decode %q = %+v", out, got)
+			}
+			if len(got.Parts) != 2 || string(got.Parts[0]) != "a" || string(got.Parts[1]) != "bc" {
+				t.Errorf("decode %q: parts = %q", out, got.Parts)
+			}
+			if b, ok := got.Any.([]byte); !ok || string(b) != "x" {
+				t.Errorf("decode %q: any = %#v, want []byte(\"x\")", out, got.Any)
+			}
+		})
+	}
+}
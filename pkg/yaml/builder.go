@@ -1,8 +1,6 @@
 package yaml
 
 import (
-	"fmt"
-
 	"github.com/shapestone/shape-core/pkg/ast"
 )
 
@@ -127,11 +125,9 @@ func (b *SequenceBuilder) AddSequence(fn func(*SequenceBuilder)) *SequenceBuilde
 	return b
 }
 
-// Build returns the AST node (ObjectNode with numeric string keys).
+// Build returns the AST node (an *ast.ArrayDataNode).
 func (b *SequenceBuilder) Build() ast.SchemaNode {
-	props := make(map[string]ast.SchemaNode)
-	for i, elem := range b.elements {
-		props[fmt.Sprintf("%d", i)] = elem
-	}
-	return ast.NewObjectNode(props, ast.Position{})
+	elems := make([]ast.SchemaNode, len(b.elements))
+	copy(elems, b.elements)
+	return ast.NewArrayDataNode(elems, ast.Position{})
 }
@@ -3,7 +3,7 @@ package yaml
 
 import (
 	"fmt"
-	"strconv"
+	"math"
 
 	"github.com/shapestone/shape-core/pkg/ast"
 )
@@ -11,12 +11,21 @@ import (
 // NodeToInterface converts an AST node to native Go types.
 //
 // Converts:
-//   - *ast.LiteralNode → primitives (string, int64, float64, bool, nil)
-//   - *ast.ObjectNode (sequence) → []interface{}
-//   - *ast.ObjectNode (mapping) → map[string]interface{}
+//   - *ast.LiteralNode → primitives (string, int64, uint64, float64, bool, nil)
+//   - *ast.ArrayDataNode → []interface{}
+//   - *ast.ObjectNode → map[string]interface{}
+//
+// The result uses the same Go types Unmarshal produces for interface{} targets:
+// integers are int64 (uint64 only above math.MaxInt64) and floats are float64,
+// even when they hold a whole number such as 1.0 or 1e3.
 //
 // This function recursively processes nested structures.
 //
+// Every alias of an anchored scalar yields the anchor's own value, so a large
+// string aliased many times is held in memory once, and no option is needed
+// to share it. Aliased mappings and sequences are converted anew for each
+// alias, so that changing one copy leaves the others alone.
+//
 // Example:
 //
 //	node, _ := yaml.Parse("name: Alice\ntags:\n  - go\n  - yaml")
@@ -25,32 +34,18 @@ import (
 func NodeToInterface(node ast.SchemaNode) interface{} {
 	switch n := node.(type) {
 	case *ast.LiteralNode:
-		val := n.Value()
-		// Ensure numbers are returned as appropriate types
-		if f, ok := val.(float64); ok {
-			// Check if it's a whole number
-			if f == float64(int64(f)) {
-				return int64(f)
-			}
+		// Literal values are already canonical: floats stay float64 even when whole (1.0, 1e3)
+		return n.Value()
+
+	case *ast.ArrayDataNode:
+		arr := make([]interface{}, n.Len())
+		for i, elem := range n.Elements() {
+			arr[i] = NodeToInterface(elem)
 		}
-		return val
+		return arr
 
 	case *ast.ObjectNode:
 		props := n.Properties()
-
-		// Check if this represents a sequence (sequential numeric keys)
-		if isSequence(props) {
-			arr := make([]interface{}, len(props))
-			for i := 0; i < len(props); i++ {
-				key := strconv.Itoa(i)
-				if propNode, ok := props[key]; ok {
-					arr[i] = NodeToInterface(propNode)
-				}
-			}
-			return arr
-		}
-
-		// Otherwise it's a map/mapping
 		m := make(map[string]interface{}, len(props))
 		for key, propNode := range props {
 			m[key] = NodeToInterface(propNode)
@@ -71,10 +66,18 @@ func NodeToInterface(node ast.SchemaNode) interface{} {
 //	node, _ := yaml.Parse("name: Alice")
 //	data := yaml.NodeToInterface(node)
 //	yaml.ReleaseTree(node)  // Release nodes back to pool
+//
+// Never release a tree that other goroutines may still read. A node reached
+// more than once, as aliases of one anchor are, is released only once.
 func ReleaseTree(node ast.SchemaNode) {
-	if node == nil {
+	releaseTree(node, make(map[ast.SchemaNode]bool))
+}
+
+func releaseTree(node ast.SchemaNode, released map[ast.SchemaNode]bool) {
+	if node == nil || released[node] {
 		return
 	}
+	released[node] = true
 
 	switch n := node.(type) {
 	case *ast.LiteralNode:
@@ -83,9 +86,15 @@ func ReleaseTree(node ast.SchemaNode) {
 	case *ast.ObjectNode:
 		// Release children first
 		for _, child := range n.Properties() {
-			ReleaseTree(child)
+			releaseTree(child, released)
 		}
 		ast.ReleaseObjectNode(n)
+
+	case *ast.ArrayDataNode:
+		for _, elem := range n.Elements() {
+			releaseTree(elem, released)
+		}
+		ast.ReleaseArrayDataNode(n)
 	}
 }
 
@@ -97,7 +106,7 @@ func ReleaseTree(node ast.SchemaNode) {
 //   - float64, float32 → *ast.LiteralNode
 //   - bool → *ast.LiteralNode
 //   - nil → *ast.LiteralNode
-//   - []interface{} → *ast.ObjectNode (sequence with numeric keys)
+//   - []interface{} → *ast.ArrayDataNode
 //   - map[string]interface{} → *ast.ObjectNode
 //
 // This function recursively processes nested structures.
@@ -141,9 +150,9 @@ func InterfaceToNode(v interface{}) (ast.SchemaNode, error) {
 
 	// Handle unsigned integers
 	case uint:
-		return ast.NewLiteralNode(int64(val), pos), nil
+		return uintToNode(uint64(val), pos), nil
 	case uint64:
-		return ast.NewLiteralNode(int64(val), pos), nil
+		return uintToNode(val, pos), nil
 	case uint32:
 		return ast.NewLiteralNode(int64(val), pos), nil
 	case uint16:
@@ -157,17 +166,17 @@ func InterfaceToNode(v interface{}) (ast.SchemaNode, error) {
 	case float32:
 		return ast.NewLiteralNode(float64(val), pos), nil
 
-	// Handle slices/arrays - represented as ObjectNode with numeric keys
+	// Handle slices/arrays
 	case []interface{}:
-		props := make(map[string]ast.SchemaNode)
+		elems := make([]ast.SchemaNode, len(val))
 		for i, item := range val {
 			itemNode, err := InterfaceToNode(item)
 			if err != nil {
 				return nil, fmt.Errorf("sequence element %d: %w", i, err)
 			}
-			props[strconv.Itoa(i)] = itemNode
+			elems[i] = itemNode
 		}
-		return ast.NewObjectNode(props, pos), nil
+		return ast.NewArrayDataNode(elems, pos), nil
 
 	// Handle maps
 	case map[string]interface{}:
@@ -185,3 +194,12 @@ func InterfaceToNode(v interface{}) (ast.SchemaNode, error) {
 		return nil, fmt.Errorf("unsupported type: %T", v)
 	}
 }
+
+// uintToNode stores unsigned integers as int64 when they fit, keeping uint64
+// only for values above math.MaxInt64 (matching the decoders).
+func uintToNode(u uint64, pos ast.Position) ast.SchemaNode {
+	if u <= math.MaxInt64 {
+		return ast.NewLiteralNode(int64(u), pos)
+	}
+	return ast.NewLiteralNode(u, pos)
+}
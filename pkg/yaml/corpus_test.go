@@ -0,0 +1,178 @@
+package yaml
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/shapestone/shape-yaml/internal/corpus"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Corpus documents a decode path cannot read yet, with the construct it
+// trips on
+var (
+	fastKnownIssues = map[string]string{
+		"docker-compose": "anchors, aliases and merge keys (Supports(\"anchors-fastpath\") is false)",
+	}
+	astKnownIssues = map[string]string{
+		"k8s-deployment": "more-indented lines of a literal block scalar lose their extra indentation",
+		"github-actions": "literal block scalars whose lines return to a lesser indentation",
+	}
+)
+
+// corpusDocument is a loaded corpus document.
+type corpusDocument struct {
+	corpus.Document
+	data []byte
+}
+
+// loadCorpus returns the corpus documents that can be loaded, skipping
+// remote ones that are not cached.
+func loadCorpus(tb testing.TB) []corpusDocument {
+	tb.Helper()
+	docs, err := corpus.All()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var loaded []corpusDocument
+	for _, d := range docs {
+		data, err := corpus.Load(context.Background(), d)
+		if errors.Is(err, corpus.ErrNotCached) {
+			tb.Logf("skipping %s: %v", d.Name, err)
+			continue
+		}
+		if err != nil {
+			tb.Fatal(err)
+		}
+		loaded = append(loaded, corpusDocument{d, data})
+	}
+	return loaded
+}
+
+// decodeStream decodes every document of a stream with decode, normalized
+// through JSON so that values from different decoders compare equal.
+func decodeStream(t *testing.T, decode func(v interface{}) error) []interface{} {
+	t.Helper()
+	var docs []interface{}
+	for {
+		var v interface{}
+		err := decode(&v)
+		if errors.Is(err, io.EOF) {
+			return normalize(t, docs)
+		}
+		if err != nil {
+			t.Fatalf("decoding document %d: %v", len(docs), err)
+		}
+		docs = append(docs, v)
+	}
+}
+
+// normalize round-trips docs through JSON.
+func normalize(t *testing.T, docs []interface{}) []interface{} {
+	t.Helper()
+	b, err := json.Marshal(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var norm []interface{}
+	if err := json.Unmarshal(b, &norm); err != nil {
+		t.Fatal(err)
+	}
+	return norm
+}
+
+// TestCorpus checks that every corpus document decodes on the fast path and
+// the AST path to the same values as with gopkg.in/yaml.v3.
+func TestCorpus(t *testing.T) {
+	for _, d := range loadCorpus(t) {
+		data := d.data
+		t.Run(d.Name, func(t *testing.T) {
+			v3 := yamlv3.NewDecoder(bytes.NewReader(data))
+			want := decodeStream(t, v3.Decode)
+			if len(want) == 0 {
+				t.Fatal("no documents")
+			}
+
+			t.Run("fast", func(t *testing.T) {
+				if issue, ok := fastKnownIssues[d.Name]; ok {
+					t.Skipf("TODO: fast path: %s", issue)
+				}
+				dec := NewDecoder(bytes.NewReader(data))
+				if got := decodeStream(t, dec.Decode); !reflect.DeepEqual(got, want) {
+					t.Errorf("fast path differs from yaml.v3:\n got %v\nwant %v", got, want)
+				}
+			})
+
+			t.Run("ast", func(t *testing.T) {
+				if issue, ok := astKnownIssues[d.Name]; ok {
+					t.Skipf("TODO: AST parser: %s", issue)
+				}
+				nodes, err := ParseMultiDoc(string(data))
+				if err != nil {
+					t.Fatalf("ParseMultiDoc() error = %v", err)
+				}
+				got := make([]interface{}, len(nodes))
+				for i, n := range nodes {
+					got[i] = NodeToInterface(n)
+				}
+				if got = normalize(t, got); !reflect.DeepEqual(got, want) {
+					t.Errorf("AST path differs from yaml.v3:\n got %v\nwant %v", got, want)
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkCorpus decodes each corpus document into interface{} values on
+// the fast path, with the AST parser, and with gopkg.in/yaml.v3.
+func BenchmarkCorpus(b *testing.B) {
+	for _, d := range loadCorpus(b) {
+		data := d.data
+		if _, ok := fastKnownIssues[d.Name]; !ok {
+			b.Run(d.Name+"/fast", func(b *testing.B) {
+				benchmarkStream(b, data, func(r io.Reader) func(interface{}) error {
+					return NewDecoder(r).Decode
+				})
+			})
+		}
+		if _, ok := astKnownIssues[d.Name]; !ok {
+			b.Run(d.Name+"/ast", func(b *testing.B) {
+				b.SetBytes(int64(len(data)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := ParseMultiDoc(string(data)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+		b.Run(d.Name+"/yaml.v3", func(b *testing.B) {
+			benchmarkStream(b, data, func(r io.Reader) func(interface{}) error {
+				return yamlv3.NewDecoder(r).Decode
+			})
+		})
+	}
+}
+
+func benchmarkStream(b *testing.B, data []byte, newDecoder func(io.Reader) func(interface{}) error) {
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		decode := newDecoder(bytes.NewReader(data))
+		for {
+			var v interface{}
+			err := decode(&v)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/parser"
+	"github.com/shapestone/shape-yaml/internal/utf8input"
+)
+
+// A Location describes what a cursor offset points at in a document: the
+// primitive an editor needs for completion and hover.
+type Location struct {
+	// Path holds the mapping keys and sequence indices from the root to
+	// Node, and is empty for the root.
+	Path []string
+
+	// Node is the innermost node whose entry contains the offset, with the
+	// style, tag and position it has in the document.
+	Node *Node
+
+	// Key is the mapping key Node is stored under, or "" if Node is the
+	// root or a sequence element.
+	Key string
+
+	// Index is the sequence index of Node, or -1 if Node is the root or a
+	// mapping value.
+	Index int
+
+	// OnKey reports that the offset is on Key as written, rather than on
+	// Node. An offset just past the key's last character counts as on the
+	// key, where a key name is being typed.
+	OnKey bool
+}
+
+// PathAt parses input and returns the Location of the byte offset within it.
+//
+// A mapping entry spans from its key up to the next key of the same mapping,
+// and a sequence element from its value up to the next element, so an
+// offset on the ": " after a key, or on an empty line inside a nested block,
+// belongs to that key's value. PathAt descends into nested collections as
+// long as a child entry starts at or before the offset.
+//
+// PathAt fails if input does not parse or offset is outside it.
+//
+// Example:
+//
+//	input := "spec:\n  ports:\n    - port: 80\n"
+//	loc, _ := yaml.PathAt(input, strings.Index(input, "80"))
+//	// loc.Path is []string{"spec", "ports", "0", "port"}, loc.Key is "port"
+func PathAt(input string, offset int) (Location, error) {
+	if offset < 0 || offset > len(input) {
+		return Location{}, fmt.Errorf("yaml: offset %d outside input of %d bytes", offset, len(input))
+	}
+	if err := utf8input.CheckString(input); err != nil {
+		return Location{}, err
+	}
+
+	p := parser.NewParser(input)
+	b := newNodeBuilder(p, input)
+	root, err := p.Parse()
+	if err != nil {
+		return Location{}, err
+	}
+	spans := b.spans
+
+	// Node and key positions count runes, from after any byte order mark
+	bom := len(input) - len(utf8input.TrimBOMString(input))
+	offset = utf8.RuneCountInString(input[min(offset, bom):offset])
+
+	loc := Location{Index: -1}
+	node := root
+	found := func() (Location, error) {
+		loc.Node = &Node{}
+		if !parser.IsEmptyDocument(node) {
+			loc.Node = b.build(node)
+		}
+		return loc, nil
+	}
+	for {
+		switch n := node.(type) {
+		case *ast.ArrayDataNode:
+			// The element containing offset is the one starting last at or
+			// before it.
+			index, start := -1, -1
+			for i, elem := range n.Elements() {
+				if s := elem.Position().Offset; s <= offset && s > start {
+					index, start = i, s
+				}
+			}
+			if index < 0 {
+				return found()
+			}
+			loc.Path = append(loc.Path, strconv.Itoa(index))
+			node = n.Get(index)
+			loc.Key, loc.Index = "", index
+
+		case *ast.ObjectNode:
+			// The entry containing offset is the one whose key starts last
+			// at or before it.
+			props := n.Properties()
+			key, start := "", -1
+			for k, child := range props {
+				s := child.Position().Offset
+				if span, ok := spans[n][k]; ok {
+					s = span.Pos.Offset
+				}
+				if s <= offset && (s > start || s == start && k < key) {
+					key, start = k, s
+				}
+			}
+			if start < 0 {
+				return found()
+			}
+			loc.Path = append(loc.Path, key)
+			node = props[key]
+			loc.Key, loc.Index = key, -1
+			if span, ok := spans[n][key]; ok && offset <= span.End {
+				loc.OnKey = true
+				return found()
+			}
+
+		default:
+			return found()
+		}
+	}
+}
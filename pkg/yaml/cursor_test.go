@@ -0,0 +1,114 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const cursorDoc = `name: api
+spec:
+  ports:
+    - 80
+    - name: http
+      port: 443
+
+  flow: {a: 1, b: [x, y]}
+  "quoted key": v
+  empty:
+ключ: значение
+last: 1
+codes:
+  0: ok
+  1: fail
+`
+
+func TestPathAt(t *testing.T) {
+	tests := []struct {
+		name   string
+		at     string // cursor at the first occurrence
+		skip   int    // bytes past the start of at
+		path   []string
+		key    string
+		index  int
+		onKey  bool
+		scalar interface{}
+	}{
+		{"root key", "name", 0, []string{"name"}, "name", -1, true, "api"},
+		{"just past key", "name", 4, []string{"name"}, "name", -1, true, "api"},
+		{"after colon", "name: ", 5, []string{"name"}, "name", -1, false, "api"},
+		{"root value", "api", 1, []string{"name"}, "name", -1, false, "api"},
+		{"nested key", "ports", 2, []string{"spec", "ports"}, "ports", -1, true, nil},
+		{"sequence scalar", "80", 0, []string{"spec", "ports", "0"}, "", 0, false, int64(80)},
+		{"key in sequence element", "name: http", 1, []string{"spec", "ports", "1", "name"}, "name", -1, true, "http"},
+		{"value in sequence element", "http", 0, []string{"spec", "ports", "1", "name"}, "name", -1, false, "http"},
+		{"continuation key", "port: 443", 0, []string{"spec", "ports", "1", "port"}, "port", -1, true, int64(443)},
+		{"blank line in block", "\n\n", 1, []string{"spec", "ports", "1", "port"}, "port", -1, false, int64(443)},
+		{"flow mapping key", "a: 1", 0, []string{"spec", "flow", "a"}, "a", -1, true, int64(1)},
+		{"flow sequence element", "y]", 0, []string{"spec", "flow", "b", "1"}, "", 1, false, "y"},
+		{"quoted key", "key\"", 0, []string{"spec", "quoted key"}, "quoted key", -1, true, "v"},
+		{"empty value", "empty:", 6, []string{"spec", "empty"}, "empty", -1, false, nil},
+		{"non-ASCII key", "ключ", 2, []string{"ключ"}, "ключ", -1, true, "значение"},
+		{"after non-ASCII text", "last", 0, []string{"last"}, "last", -1, true, int64(1)},
+		{"integer-like key", "1: fail", 0, []string{"codes", "1"}, "1", -1, true, "fail"},
+		{"integer-like key value", "ok", 0, []string{"codes", "0"}, "0", -1, false, "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset := strings.Index(cursorDoc, tt.at) + tt.skip
+			loc, err := PathAt(cursorDoc, offset)
+			if err != nil {
+				t.Fatalf("PathAt(%d) error = %v", offset, err)
+			}
+			if !reflect.DeepEqual(loc.Path, tt.path) || loc.Key != tt.key || loc.Index != tt.index || loc.OnKey != tt.onKey {
+				t.Errorf("PathAt(%d) = {Path: %q, Key: %q, Index: %d, OnKey: %v}, want {Path: %q, Key: %q, Index: %d, OnKey: %v}",
+					offset, loc.Path, loc.Key, loc.Index, loc.OnKey, tt.path, tt.key, tt.index, tt.onKey)
+			}
+			if tt.scalar != nil {
+				if got := scalarOf(loc.Node); got != tt.scalar {
+					t.Errorf("PathAt(%d).Node = %#v, want %#v", offset, got, tt.scalar)
+				}
+			}
+		})
+	}
+}
+
+func TestPathAt_Root(t *testing.T) {
+	loc, err := PathAt("# header\na: 1", 3)
+	if err != nil {
+		t.Fatalf("PathAt() error = %v", err)
+	}
+	if len(loc.Path) != 0 || loc.Index != -1 || loc.Key != "" || loc.Node == nil {
+		t.Errorf("PathAt(3) = %+v, want the root", loc)
+	}
+}
+
+func TestPathAt_Node(t *testing.T) {
+	input := "a:\n  b: \"8080\"\n"
+	loc, err := PathAt(input, strings.Index(input, "8080"))
+	if err != nil {
+		t.Fatalf("PathAt() error = %v", err)
+	}
+	n := loc.Node
+	if n.Kind != ScalarKind || n.Tag != StrTag || n.Style != DoubleQuoted || n.Value != "8080" || n.Line != 2 || n.Column != 6 {
+		t.Errorf("PathAt().Node = %+v, want the double-quoted string 8080 at 2:6", n)
+	}
+
+	loc, err = PathAt("", 0)
+	if err != nil || loc.Node == nil || loc.Node.Kind != InvalidKind {
+		t.Errorf("PathAt() of an empty document = %+v, %v, want the zero Node", loc, err)
+	}
+}
+
+func TestPathAt_Errors(t *testing.T) {
+	if _, err := PathAt("a: 1", 5); err == nil {
+		t.Error("PathAt() past the end: expected error")
+	}
+	if _, err := PathAt("a: 1", -1); err == nil {
+		t.Error("PathAt() negative offset: expected error")
+	}
+	if _, err := PathAt("a: [1, 2", 3); err == nil {
+		t.Error("PathAt() invalid document: expected error")
+	}
+}
@@ -0,0 +1,331 @@
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/shapestone/shape-yaml/internal/fastparser"
+	"github.com/shapestone/shape-yaml/internal/utf8input"
+)
+
+// A Decoder reads and decodes YAML values from an input stream.
+//
+// Unlike Unmarshal, a Decoder can report keys whose values were discarded
+// because the struct field they match cannot be set, or because they match
+// no field at all. See SetWarningHandler, DisallowIgnoredFields and
+// DisallowUnknownFields.
+type Decoder struct {
+	r        io.Reader
+	warn     func(*FieldWarning)
+	strict   bool
+	unknown  bool
+	tagName  string
+	jsonTags bool
+	maxDepth int
+	repair   bool
+	maxDocs  int
+	noTimes  bool
+	maxBytes int64
+	maxKey   int
+	safe     bool
+	promote  bool
+
+	data []byte // the input, read by the first Decode
+	read bool
+	off  int // start of the next document in data
+	docs int // documents decoded so far
+}
+
+// FieldWarning describes a mapping key whose value was not applied because
+// the only struct field it matches cannot be set: an unexported field, a
+// field promoted through an unexported embedded pointer, or a promoted field
+// name that is ambiguous between several embedded structs.
+type FieldWarning struct {
+	Key    string       // key as written in the document
+	Type   reflect.Type // struct type being decoded
+	Field  string       // Go field path, e.g. "Base.secret"
+	Reason string       // why the field cannot be set
+	Line   int
+	Column int
+}
+
+// Error implements the error interface so that a FieldWarning can be
+// returned from Decode in strict mode.
+func (w *FieldWarning) Error() string {
+	return fmt.Sprintf("yaml: line %d: key %q matches field %s.%s, which cannot be set (%s)",
+		w.Line, w.Key, w.Type, w.Field, w.Reason)
+}
+
+// UnknownFieldError is returned by Decode, after DisallowUnknownFields, for a
+// mapping key that matches no field of the struct being decoded.
+type UnknownFieldError struct {
+	Key        string       // key as written in the document
+	Type       reflect.Type // struct type being decoded
+	Suggestion string       // closest field name, or "" if none is close
+	Line       int
+	Column     int
+}
+
+func (e *UnknownFieldError) Error() string {
+	msg := fmt.Sprintf("yaml: line %d: unknown field %q in %s", e.Line, e.Key, e.Type)
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %q?", e.Suggestion)
+	}
+	return msg
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// SetWarningHandler registers fn to be called for every key whose value is
+// discarded because it matches only a field that cannot be set.
+func (d *Decoder) SetWarningHandler(fn func(*FieldWarning)) {
+	d.warn = fn
+}
+
+// DisallowIgnoredFields causes Decode to return a *FieldWarning as an error
+// instead of discarding the value when a key matches only a field that
+// cannot be set.
+func (d *Decoder) DisallowIgnoredFields() {
+	d.strict = true
+}
+
+// DisallowUnknownFields causes Decode to return an *UnknownFieldError when a
+// mapping key matches no field of the destination struct. The error names
+// the closest field when the key looks like a typo:
+//
+//	yaml: line 3: unknown field "replcas" in main.Spec, did you mean "replicas"?
+//
+// The rest of the document is still decoded, and when several keys are
+// unknown, the error lists them all, one per line; errors.As finds the
+// *UnknownFieldError of the first.
+func (d *Decoder) DisallowUnknownFields() {
+	d.unknown = true
+}
+
+// KnownFields makes Decode reject unknown keys, as DisallowUnknownFields
+// does, if enable is true, or accept them again if it is false.
+func (d *Decoder) KnownFields(enable bool) {
+	d.unknown = enable
+}
+
+// SetTagName sets the struct tag key used to name fields, for example "json"
+// or "config", so that existing struct annotations can be reused.
+// The tag value follows the same "name,omitempty" syntax as the yaml tag.
+// The default is "yaml".
+func (d *Decoder) SetTagName(name string) {
+	d.tagName = name
+}
+
+// UseJSONTagFallback names fields that have no yaml tag, or no tag of the
+// SetTagName key, by their json tag, so that models written for
+// encoding/json decode without duplicating every tag.
+func (d *Decoder) UseJSONTagFallback() {
+	d.jsonTags = true
+}
+
+// SetMaxDepth sets the maximum nesting depth of mappings and sequences, which
+// also bounds recursion into self-referential struct types such as tree
+// nodes. A top-level scalar has depth 1 and each enclosing collection adds
+// one level. Zero restores DefaultMaxDepth; a negative value removes the
+// limit. Deeper input fails with an error wrapping ErrLimitExceeded.
+func (d *Decoder) SetMaxDepth(n int) {
+	d.maxDepth = n
+}
+
+// ReplaceInvalidUTF8 causes the Decoder to replace each malformed UTF-8 byte
+// in the input with U+FFFD, for callers handling dirty input, instead of
+// failing with an error wrapping ErrInvalidUTF8.
+func (d *Decoder) ReplaceInvalidUTF8() {
+	d.repair = true
+}
+
+// SetMaxDocuments limits the number of documents the Decoder decodes from a
+// "---"-separated stream. Once n documents have been decoded, Decode fails
+// with a *DocumentLimitError instead of decoding another. Zero, the default,
+// means no limit.
+func (d *Decoder) SetMaxDocuments(n int) {
+	d.maxDocs = n
+}
+
+// SetLimits bounds the resources the Decoder spends on its input: MaxBytes
+// the whole stream, MaxDocuments and MaxDepth as SetMaxDocuments and
+// SetMaxDepth do, and MaxKeyLength every mapping key. The other limits
+// concern anchors and aliases, which Decode rejects (see ErrAnchors). A Decode past a
+// limit fails with an error wrapping ErrLimitExceeded. SafeMode makes a
+// Decode of a document with an anchor, alias, tag, or directive fail with an
+// error wrapping ErrUnsafe.
+func (d *Decoder) SetLimits(l Limits) {
+	d.maxBytes = l.MaxBytes
+	d.maxDocs = l.MaxDocuments
+	d.maxDepth = l.MaxDepth
+	d.maxKey = l.MaxKeyLength
+	d.safe = l.SafeMode
+}
+
+// DisableImplicitTimestamps causes time.Time values to be decoded by
+// time.Time's own UnmarshalText, which accepts only RFC 3339, instead of from
+// any YAML timestamp such as 2001-12-14.
+func (d *Decoder) DisableImplicitTimestamps() {
+	d.noTimes = true
+}
+
+// PromoteScalars causes a scalar decoded into a slice to become a slice of
+// that one value, as DecodeOptions.PromoteScalars does.
+func (d *Decoder) PromoteScalars() {
+	d.promote = true
+}
+
+// Decode stores the next document of the input stream in the value pointed
+// to by v, following the rules of Unmarshal. Documents are separated by
+// "---" lines.
+//
+// As with encoding/json's Decoder, Decode returns io.EOF, unwrapped, when no
+// document remains: after the last one, or at once for a stream holding
+// nothing but blank lines and comments. A document cut off inside a flow
+// collection or quoted scalar fails with an error wrapping
+// io.ErrUnexpectedEOF, so a decode loop can be written as
+//
+//	for {
+//	    var m Manifest
+//	    if err := dec.Decode(&m); err == io.EOF {
+//	        break
+//	    } else if err != nil {
+//	        return err // errors.Is(err, io.ErrUnexpectedEOF) if truncated
+//	    }
+//	    ...
+//	}
+func (d *Decoder) Decode(v interface{}) error {
+	if !d.read {
+		r := d.r
+		if d.maxBytes > 0 {
+			r = &limitedReader{r: r, max: d.maxBytes, remaining: d.maxBytes}
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		d.data, d.read = data, true
+		// A byte order mark is not part of the first document
+		d.off = len(data) - len(utf8input.TrimBOM(data))
+	}
+	if !fastparser.HasDocument(d.data[d.off:]) {
+		return io.EOF
+	}
+
+	line := bytes.Count(d.data[:d.off], []byte("\n")) + 1
+	if d.maxDocs > 0 && d.docs >= d.maxDocs {
+		return observeDecode(DecodePathFast, 0, &DocumentLimitError{Max: d.maxDocs, Line: line})
+	}
+
+	doc := d.data[d.off:]
+	doc = doc[:fastparser.NextDocument(doc)]
+	opts := d.options()
+	opts.Offset, opts.Line = d.off, line
+	opts.Nodes = newNodeSource(v, line, 1)
+	// Only pay for field-name suggestions when unknown keys are errors
+	var unknown unknownFields
+	if d.unknown {
+		opts.OnUnknownField = unknown.add
+	}
+	d.off += len(doc)
+	d.docs++
+
+	err := fastparser.UnmarshalWithOptions(doc, v, opts)
+	if err == nil {
+		err = unknown.err()
+	}
+	if n, ok := v.(*Node); ok && n != nil && err == nil {
+		// Lines count from the start of the stream, not of the document
+		n.shiftLines(line - 1)
+	}
+	return observeDecode(DecodePathFast, len(doc), err)
+}
+
+// Buffered returns a reader of the input after the documents Decode has
+// returned, as encoding/json's Decoder.Buffered does, so that a stream
+// holding a YAML header and then other data can be handed on without being
+// read again. A document runs to the next "---" line or to a "..." line,
+// which belongs to it, so a header should end with "..." to keep the data
+// after it out of the document:
+//
+//	dec := yaml.NewDecoder(r) // "name: a\n...\n<payload>"
+//	if err := dec.Decode(&header); err != nil {
+//	    return err
+//	}
+//	payload := dec.Buffered() // "<payload>"
+//
+// Decode reads the whole stream on its first call, so the reader holds the
+// rest of the stream, subject to Limits.MaxBytes; before that call it is
+// empty.
+func (d *Decoder) Buffered() io.Reader {
+	return bytes.NewReader(d.data[d.off:])
+}
+
+// options returns the fastparser options for the decoder's settings.
+func (d *Decoder) options() fastparser.Options {
+	opts := fastparser.Options{
+		OnIgnoredField:     d.onIgnoredField,
+		Tagged:             newTagSource,
+		TagName:            d.tagName,
+		MaxDepth:           d.maxDepth,
+		MaxKeyLength:       d.maxKey,
+		SafeMode:           d.safe,
+		ReplaceInvalidUTF8: d.repair,
+
+		DisableImplicitTimestamps: d.noTimes,
+		PromoteScalars:            d.promote,
+	}
+	if d.jsonTags {
+		opts.FallbackTagName = "json"
+	}
+	return opts
+}
+
+// unknownFields collects the keys DisallowUnknownFields rejects, so that
+// decoding goes on and one error lists every unknown key of the document.
+type unknownFields []error
+
+func (u *unknownFields) add(f fastparser.UnknownField) error {
+	*u = append(*u, &UnknownFieldError{
+		Key:        f.Key,
+		Type:       f.Struct,
+		Suggestion: f.Suggestion,
+		Line:       f.Line,
+		Column:     f.Column,
+	})
+	return nil
+}
+
+// err returns nil if no key was unknown, the *UnknownFieldError of a single
+// unknown key, or else the errors of all of them joined, one per line.
+func (u unknownFields) err() error {
+	if len(u) == 1 {
+		return u[0]
+	}
+	return errors.Join(u...)
+}
+
+// onIgnoredField adapts fastparser diagnostics to the decoder's handlers.
+func (d *Decoder) onIgnoredField(f fastparser.IgnoredField) error {
+	w := &FieldWarning{
+		Key:    f.Key,
+		Type:   f.Struct,
+		Field:  f.Field,
+		Reason: f.Reason,
+		Line:   f.Line,
+		Column: f.Column,
+	}
+	if d.warn != nil {
+		d.warn(w)
+	}
+	if d.strict {
+		return w
+	}
+	return nil
+}
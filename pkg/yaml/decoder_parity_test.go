@@ -0,0 +1,1003 @@
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestDecoderParity checks that the fast path (Unmarshal) and the AST path
+// (UnmarshalWithAST) produce identical interface{} values for the same input.
+func TestDecoderParity(t *testing.T) {
+	inputs := []string{
+		"v: 1",
+		"v: -1",
+		"v: +1",
+		"v: -0",
+		"v: 1.0",
+		"v: 1.5",
+		"v: .5",
+		"v: 1.",
+		"v: 1e3",
+		"v: 2e+3",
+		"v: +2E+3",
+		"v: -2.5e+3",
+		"v: 1.e+3",
+		"v: +.5",
+		"v: +5.5",
+		"v: +.inf",
+		"v: 0x1F",
+		"v: +0x1F",
+		"v: -0o17",
+		"v: 0o17",
+		"v: 017",
+		"v: 1_000",
+		"v: 9223372036854775808",
+		"v: 18446744073709551615",
+		"v: 18446744073709551616",
+		"v: .inf",
+		"v: -.inf",
+		"v: .nan",
+		"v: true",
+		"v: TRUE",
+		"v: yes",
+		"v: On",
+		"v: NO",
+		"v: y",
+		"v: n",
+		"v: null",
+		"v: Null",
+		"v: ~",
+		"v:",
+		"v: \"1\"",
+		"v: 'true'",
+		"v: \"\\uD83D\\uDE00\"",
+		"v: \"a\n  b\n\n  c\\\n  d\"",
+		"v: [\"a\n  b\", c]",
+		"v: 'a\n  b\n\n  c''d'",
+		"v: ['a\n  b', c]",
+		"v: hello world",
+		"v: 12:30",
+		"v: 2001-12-14",
+		"v: -foo",
+		"v: {}",
+		"v: [1, a, true, 1.0]",
+		"v: {a: 1, b: null}",
+		"v: {a: , b: 1}",
+		"v: {a:}",
+		"v:\n  - 1\n  - x\n  - 2.0",
+		"v:\n  a: 1\n  b: [yes, 0x10]",
+		"v: [+5, 2e+3, +0x1F, {a: +1e+2}]",
+		"v:\n  - +5\n  - 2e+3\n  - -0x1F",
+		"%YAML 1.2\n---\nv: [010, 09]",
+		"%YAML 1.1\n---\nv: [010, -017, 09, 0x10, 10]",
+		"%YAML 1.1\nv:\n  a: 010\n  b: {c: 08}",
+		"v: {[1,2]: pair, {b: [x, ~], a: 1}: x}",
+		"v: {[]: x, [1, 2]: y, {b: 1, a: 2}: z}",
+		"v: {[]: x, {}: y}",
+		`v: {["a, b"]: 1, [a, b]: 2, ["1"]: 3, [1]: 4, [1.0, .inf]: 5}`,
+		"v: {0: a, true: b, 1.5: c}",
+		"v:\n  null: x\n  2: y\n  010: z",
+		"v:\n  - - 1\n    - 2\n  - - 3\n",
+		"v:\n  - - - 1\n      - 2\n    - - 3\n  - - - 4\n",
+		"v:\n  - -\n    - 2\n",
+		"v:\n  - - a: 1\n      b: 2\n    - a: 3\n  - - b: 4\n",
+		"v:\n  - a:\n        b: 1\n    c: 2\n  - d: 1\n",
+		`v: "a: b"`,
+		"v: 'a: b'",
+		`v: "a: b" # c: d`,
+		"v:\n  - \"x: y\"\n  - 'p: q'\n",
+		`v: ["a: b", 'c: d']`,
+		`"k: v": 1`,
+		"'k: v': 1",
+		`v: "say \"a: b\""`,
+		"v: 'it''s: here'",
+		`v: "tab\there\nline \\ end"`,
+		`v: "\x41\u00e9\U0001F600"`,
+		`v: "\xff\xFF"`,
+		`v: "\N\_\L\P\e\a\v\0\/\ "`,
+		`v: ["\x41", {"\x42": "\e"}]`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			var fast, slow map[string]interface{}
+			if err := Unmarshal([]byte(input), &fast); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if err := UnmarshalWithAST([]byte(input), &slow); err != nil {
+				t.Fatalf("UnmarshalWithAST() error = %v", err)
+			}
+			if !parityEqual(fast, slow) {
+				t.Errorf("decoders disagree:\n  Unmarshal:        %#v\n  UnmarshalWithAST: %#v", fast, slow)
+			}
+		})
+	}
+}
+
+// TestDecoderParity_BlockIndentation is the shared matrix for the block
+// indentation rule: the keys of a mapping that starts on a dash line sit at
+// the column of its first key (the dash column + 2 for "- key"), and a line
+// indented to no open collection's level is an error rather than being
+// dropped or attached to the wrong parent.
+func TestDecoderParity_BlockIndentation(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{} // nil: decoding must fail
+	}{
+		{"- name: a\n  port: 1\n- name: b\n  port: 2", []interface{}{
+			map[string]interface{}{"name": "a", "port": int64(1)},
+			map[string]interface{}{"name": "b", "port": int64(2)},
+		}},
+		{"-   name: a\n    port: 1", []interface{}{
+			map[string]interface{}{"name": "a", "port": int64(1)},
+		}},
+		{"- a: 1\n  b: 2\n-   c: 3\n    d: 4", []interface{}{
+			map[string]interface{}{"a": int64(1), "b": int64(2)},
+			map[string]interface{}{"c": int64(3), "d": int64(4)},
+		}},
+		{"- name: a\n  sub:\n    x: 1\n  port: 2", []interface{}{
+			map[string]interface{}{"name": "a", "sub": map[string]interface{}{"x": int64(1)}, "port": int64(2)},
+		}},
+		{"items:\n  - name: a\n    port: 1\n  - name: b", map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "a", "port": int64(1)},
+				map[string]interface{}{"name": "b"},
+			},
+		}},
+		{"- name: a\n    port: 1", nil},
+		{"- name: a\n   port: 1", nil},
+		{"- name: a\n port: 1", nil},
+		{"-   name: a\n  port: 1", nil},
+		{"a: 1\n  b: 2", nil},
+		{"a:\n    b: 1\n  c: 2", nil},
+		{"a: hello\n  world\nb: 1", nil},
+		{"- hello\n  world\n- x", nil},
+		{"- a\n  - b", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var got interface{}
+				err := decode([]byte(tt.input), &got)
+				if tt.want == nil {
+					if err == nil {
+						t.Fatalf("expected error, got %#v", got)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("decode error = %v", err)
+				}
+				if !parityEqual(got, tt.want) {
+					t.Errorf("got  %#v\nwant %#v", got, tt.want)
+				}
+			})
+		})
+	}
+}
+
+// TestDecoderParity_BlockIndentationStruct applies the same rule when the
+// entries decode into structs.
+func TestDecoderParity_BlockIndentationStruct(t *testing.T) {
+	type entry struct {
+		Name string `yaml:"name"`
+		Port int    `yaml:"port"`
+	}
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var got []entry
+		if err := decode([]byte("- name: a\n  port: 1\n-   name: b\n    port: 2"), &got); err != nil {
+			t.Fatalf("decode error = %v", err)
+		}
+		if want := []entry{{"a", 1}, {"b", 2}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+		for _, input := range []string{"- name: a\n    port: 1", "- name: a\n port: 1"} {
+			if err := decode([]byte(input), &got); err == nil {
+				t.Errorf("decode(%q): expected error, got %+v", input, got)
+			}
+		}
+	})
+}
+
+// TestDecoderParity_IndentlessSequence checks that a block sequence may sit
+// at the indent of the key it is the value of, as kubectl prints them, and
+// that the key after it goes back to the enclosing mapping.
+func TestDecoderParity_IndentlessSequence(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{} // nil: decoding must fail
+	}{
+		{"items:\n- a\n- b", map[string]interface{}{"items": []interface{}{"a", "b"}}},
+		{"items:\n- a: 1\n  b: 2", map[string]interface{}{
+			"items": []interface{}{map[string]interface{}{"a": int64(1), "b": int64(2)}},
+		}},
+		{"items:\n- a: 1\n  b: 2\n- a: 3\nx: 1", map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"a": int64(1), "b": int64(2)},
+				map[string]interface{}{"a": int64(3)},
+			},
+			"x": int64(1),
+		}},
+		{"top:\n  names:\n  - a\n  - b\n  x: 1\ny: 2", map[string]interface{}{
+			"top": map[string]interface{}{"names": []interface{}{"a", "b"}, "x": int64(1)},
+			"y":   int64(2),
+		}},
+		{"names:\n- - a\n  - b\n- c", map[string]interface{}{
+			"names": []interface{}{[]interface{}{"a", "b"}, "c"},
+		}},
+		{"- a:\n  - x\n  b: 1", []interface{}{
+			map[string]interface{}{"a": []interface{}{"x"}, "b": int64(1)},
+		}},
+		{"c:\n- p:\n  - x\n  r: 2\nz: 1", map[string]interface{}{
+			"c": []interface{}{map[string]interface{}{"p": []interface{}{"x"}, "r": int64(2)}},
+			"z": int64(1),
+		}},
+		{"- p:\n  - x\n- q", []interface{}{map[string]interface{}{"p": []interface{}{"x"}}, "q"}},
+		{"items:\n- a\n b: 1", nil},
+		{"items:\n- a\n  - b", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var got interface{}
+				err := decode([]byte(tt.input), &got)
+				if tt.want == nil {
+					if err == nil {
+						t.Fatalf("expected error, got %#v", got)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("decode error = %v", err)
+				}
+				if !parityEqual(got, tt.want) {
+					t.Errorf("got  %#v\nwant %#v", got, tt.want)
+				}
+			})
+		})
+	}
+
+	type item struct {
+		A int `yaml:"a"`
+		B int `yaml:"b"`
+	}
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var got struct {
+			Items []item              `yaml:"items"`
+			Names []string            `yaml:"names"`
+			Tags  map[string][]string `yaml:"tags"`
+			X     int                 `yaml:"x"`
+		}
+		input := "items:\n- a: 1\n  b: 2\n- a: 3\nnames:\n- a\n- b\ntags:\n  k:\n  - v\nx: 4"
+		if err := decode([]byte(input), &got); err != nil {
+			t.Fatalf("decode error = %v", err)
+		}
+		if want := []item{{1, 2}, {3, 0}}; !reflect.DeepEqual(got.Items, want) {
+			t.Errorf("Items = %+v, want %+v", got.Items, want)
+		}
+		if want := []string{"a", "b"}; !reflect.DeepEqual(got.Names, want) {
+			t.Errorf("Names = %v, want %v", got.Names, want)
+		}
+		if want := map[string][]string{"k": {"v"}}; !reflect.DeepEqual(got.Tags, want) {
+			t.Errorf("Tags = %v, want %v", got.Tags, want)
+		}
+		if got.X != 4 {
+			t.Errorf("X = %d, want 4", got.X)
+		}
+	})
+}
+
+// TestDecoderParity_Dash checks that a "-" reads as a sequence indicator
+// only when whitespace or the end of the input follows it, and that every
+// path rejects one where no block sequence can start.
+func TestDecoderParity_Dash(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{} // nil: decoding must fail
+	}{
+		{"-", []interface{}{nil}},
+		{"- -", []interface{}{[]interface{}{nil}}},
+		{"a:\n  - x\n  -", map[string]interface{}{"a": []interface{}{"x", nil}}},
+		{"a: -x\nb: -1\nc: -.5", map[string]interface{}{"a": "-x", "b": int64(-1), "c": -0.5}},
+		{"a: [-x, -1]", map[string]interface{}{"a": []interface{}{"-x", int64(-1)}}},
+		{"a: -", nil},
+		{"a: -\nb: 1", nil},
+		{"a: - x", nil},
+		{"- a: - x", nil},
+		{"a: [-]", nil},
+		{"a: {b: -}", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var got interface{}
+				err := decode([]byte(tt.input), &got)
+				if tt.want == nil {
+					if err == nil {
+						t.Fatalf("expected error, got %#v", got)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("decode error = %v", err)
+				}
+				if !parityEqual(got, tt.want) {
+					t.Errorf("got  %#v\nwant %#v", got, tt.want)
+				}
+			})
+		})
+	}
+
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var got struct {
+			A []string `yaml:"a"`
+		}
+		if err := decode([]byte("a: - x"), &got); err == nil {
+			t.Errorf("expected error, got %+v", got)
+		}
+	})
+}
+
+// TestDecoderParity_BlockScalars checks that literal and folded block
+// scalars, with each chomping indicator, decode the same on every path.
+func TestDecoderParity_BlockScalars(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"v: |\n  a\n  b\n", "a\nb\n"},
+		{"v: |-\n  a\n  b\n", "a\nb"},
+		{"v: |+\n  a\n\n\nw: 1\n", "a\n\n\n"},
+		{"v: |\n  a\n\n\nw: 1\n", "a\n"},
+		{"v: >\n  a\n  b\n  c\n", "a b c\n"},
+		{"v: >-\n  a\n  b\n", "a b"},
+		{"v: |  # comment\n  a  b\n", "a  b\n"},
+		{"v: |\nw: 1\n", ""},
+		{"v:\n  - |\n    a\n  - >-\n    b\n    c\n", []interface{}{"a\n", "b c"}},
+		{"v:\n  k: |\n    a\n  j: >\n    b\n", map[string]interface{}{"k": "a\n", "j": "b\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var got map[string]interface{}
+				if err := decode([]byte(tt.input), &got); err != nil {
+					t.Fatalf("decode error = %v", err)
+				}
+				if !parityEqual(got["v"], tt.want) {
+					t.Errorf("got  %#v\nwant %#v", got["v"], tt.want)
+				}
+			})
+		})
+	}
+
+	type job struct {
+		Name   string `yaml:"name"`
+		Script string `yaml:"script"`
+	}
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var got []job
+		input := "- name: a\n  script: |\n    make\n    make test\n- name: b\n  script: >-\n    one\n    two\n"
+		if err := decode([]byte(input), &got); err != nil {
+			t.Fatalf("decode error = %v", err)
+		}
+		if want := []job{{"a", "make\nmake test\n"}, {"b", "one two"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+}
+
+// TestDecoderParity_TopLevel checks that every top-level document shape
+// decodes the same way through Unmarshal, UnmarshalWithAST and
+// Decoder.Decode, into each kind of target.
+func TestDecoderParity_TopLevel(t *testing.T) {
+	inputs := []string{
+		"hello", "42", "0x10", "3.5", "2.0", "true", "~", "\"q\"",
+		"   \n", "# only a comment\n", "---\n", "...\n",
+		"--- 42", "---\nhello\n...\n",
+		"- a\n- b", "[1, 2]", "---\n- x\n",
+		"a: 1", "{a: 1}", "a: 1\n...\n",
+		"{}", "[]",
+	}
+	targets := map[string]func() interface{}{
+		"interface": func() interface{} { return new(interface{}) },
+		"string":    func() interface{} { return new(string) },
+		"int":       func() interface{} { return new(int) },
+		"pointer":   func() interface{} { return new(*string) },
+		"slice":     func() interface{} { return new([]string) },
+		"map":       func() interface{} { return new(map[string]int) },
+		"struct":    func() interface{} { return new(struct{ A int }) },
+	}
+	decodeStream := func(data []byte, v interface{}) error {
+		// A stream without documents leaves the target untouched, at the
+		// zero value the other paths decode it to
+		if err := NewDecoder(bytes.NewReader(data)).Decode(v); err != io.EOF {
+			return err
+		}
+		return nil
+	}
+
+	for _, input := range inputs {
+		for name, target := range targets {
+			t.Run(input+"/"+name, func(t *testing.T) {
+				fast, slow, stream := target(), target(), target()
+				fastErr := Unmarshal([]byte(input), fast)
+				slowErr := UnmarshalWithAST([]byte(input), slow)
+				streamErr := decodeStream([]byte(input), stream)
+
+				if (fastErr == nil) != (slowErr == nil) || (fastErr == nil) != (streamErr == nil) {
+					t.Fatalf("errors disagree:\n  Unmarshal:        %v\n  UnmarshalWithAST: %v\n  Decode:           %v", fastErr, slowErr, streamErr)
+				}
+				if fastErr != nil {
+					return
+				}
+				if !parityEqual(fast, slow) || !parityEqual(fast, stream) {
+					t.Errorf("values disagree:\n  Unmarshal:        %#v\n  UnmarshalWithAST: %#v\n  Decode:           %#v", fast, slow, stream)
+				}
+			})
+		}
+	}
+}
+
+// TestDecoderParity_EmptyInput checks that an empty document leaves the
+// target at its zero value on both decode paths.
+func TestDecoderParity_EmptyInput(t *testing.T) {
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		s := "preset"
+		m := map[string]int{"a": 1}
+		if err := decode(nil, &s); err != nil || s != "" {
+			t.Errorf("string: got %q, %v; want \"\", nil", s, err)
+		}
+		if err := decode([]byte(""), &m); err != nil || m != nil {
+			t.Errorf("map: got %v, %v; want nil, nil", m, err)
+		}
+	})
+}
+
+// TestEmptyDocumentContract checks that every entry point agrees on input
+// without a document: whitespace, comments and a leading byte order mark are
+// not content, so validating or parsing succeeds, decoding leaves the zero
+// value, and a Decoder reports io.EOF.
+func TestEmptyDocumentContract(t *testing.T) {
+	bom := string(rune(0xFEFF))
+	inputs := []string{"", "   ", "\n\t\n", "# comment\n", bom, bom + "\n", bom + "  # comment"}
+	for _, in := range inputs {
+		if err := Validate(in); err != nil {
+			t.Errorf("Validate(%q) = %v", in, err)
+		}
+		if err := ValidateReader(strings.NewReader(in), Limits{}); err != nil {
+			t.Errorf("ValidateReader(%q) = %v", in, err)
+		}
+		if _, err := Parse(in); err != nil {
+			t.Errorf("Parse(%q) = %v", in, err)
+		}
+		if _, err := ParseReader(strings.NewReader(in)); err != nil {
+			t.Errorf("ParseReader(%q) = %v", in, err)
+		}
+		if docs, err := ParseMultiDoc(in); err != nil || len(docs) != 0 {
+			t.Errorf("ParseMultiDoc(%q) = %d documents, %v; want 0, nil", in, len(docs), err)
+		}
+		var v interface{}
+		if err := NewDecoder(strings.NewReader(in)).Decode(&v); err != io.EOF {
+			t.Errorf("Decoder.Decode(%q) = %v, want io.EOF", in, err)
+		}
+	}
+
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		for _, in := range inputs {
+			var v interface{} = "preset"
+			n := Node{Kind: ScalarKind, Value: "preset"}
+			cfg := struct{ Name string }{"preset"}
+			if err := decode([]byte(in), &v); err != nil || v != nil {
+				t.Errorf("decode(%q) into interface{} = %#v, %v; want nil, nil", in, v, err)
+			}
+			if err := decode([]byte(in), &n); err != nil || n.Kind != InvalidKind {
+				t.Errorf("decode(%q) into Node = %v, %v; want InvalidKind, nil", in, n.Kind, err)
+			}
+			if err := decode([]byte(in), &cfg); err != nil || cfg.Name != "" {
+				t.Errorf("decode(%q) into struct = %+v, %v; want zero, nil", in, cfg, err)
+			}
+		}
+	})
+}
+
+// TestDecoderParity_BOM checks that a leading byte order mark is dropped
+// rather than read as part of the first key or value.
+func TestDecoderParity_BOM(t *testing.T) {
+	bom := string(rune(0xFEFF))
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{bom + "a: 1", map[string]interface{}{"a": int64(1)}},
+		{bom + "---\na: 1", map[string]interface{}{"a": int64(1)}},
+		{bom + "[1]", []interface{}{int64(1)}},
+		{bom + "- x", []interface{}{"x"}},
+		{bom + "x", "x"},
+		{"a: " + bom + "x", map[string]interface{}{"a": bom + "x"}},
+	}
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		for _, tt := range tests {
+			var v interface{}
+			if err := decode([]byte(tt.input), &v); err != nil {
+				t.Errorf("decode(%q) error = %v", tt.input, err)
+				continue
+			}
+			if !reflect.DeepEqual(v, tt.want) {
+				t.Errorf("decode(%q) = %#v, want %#v", tt.input, v, tt.want)
+			}
+		}
+	})
+
+	dec := NewDecoder(strings.NewReader(bom + "a: 1\n---\nb: 2\n"))
+	for _, key := range []string{"a", "b"} {
+		var v map[string]int
+		if err := dec.Decode(&v); err != nil || len(v) != 1 || v[key] == 0 {
+			t.Errorf("Decoder.Decode() = %v, %v; want key %q", v, err, key)
+		}
+	}
+}
+
+// TestDecoderParity_SyntaxHints checks that both decode paths suggest the
+// same fix for common mistakes.
+func TestDecoderParity_SyntaxHints(t *testing.T) {
+	tests := []struct {
+		input string
+		hint  string
+	}{
+		{"name:foo\nport: 1", `did you mean "name: foo"?`},
+		{"a: 1\n\tb: 2", "indent line 2 with spaces, not tabs"},
+		{"a: [1, 2\nb: 1\n", `close the "[" opened on line 1 with "]"`},
+		{"a: {x: 1\nb: 2", `close the "{" opened on line 1 with "}"`},
+	}
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		for _, tt := range tests {
+			var v map[string]interface{}
+			err := decode([]byte(tt.input), &v)
+			if err == nil || !strings.HasSuffix(err.Error(), "; "+tt.hint) {
+				t.Errorf("decode(%q) error = %v, want hint %q", tt.input, err, tt.hint)
+			}
+		}
+	})
+}
+
+// forEachDecoder runs fn as a "fast" subtest with Unmarshal and as an "AST"
+// subtest with UnmarshalWithAST.
+func forEachDecoder(t *testing.T, fn func(t *testing.T, decode func([]byte, interface{}) error)) {
+	t.Helper()
+	t.Run("fast", func(t *testing.T) { fn(t, Unmarshal) })
+	t.Run("AST", func(t *testing.T) { fn(t, UnmarshalWithAST) })
+}
+
+// parityEqual is reflect.DeepEqual except that NaN equals NaN.
+func parityEqual(a, b interface{}) bool {
+	if fa, ok := a.(float64); ok {
+		fb, ok := b.(float64)
+		return ok && (fa == fb || (math.IsNaN(fa) && math.IsNaN(fb)))
+	}
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			w, ok := bv[k]
+			if !ok || !parityEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !parityEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func TestParity_TypeErrors(t *testing.T) {
+	type container struct {
+		Image string `yaml:"image"`
+		Port  int    `yaml:"port"`
+	}
+	type pod struct {
+		Spec struct {
+			Containers []container        `yaml:"containers"`
+			Labels     map[string]bool    `yaml:"labels"`
+			Ordered    MapSlice           `yaml:"ordered"`
+			Limits     map[string][]uint8 `yaml:"limits"`
+		} `yaml:"spec"`
+	}
+	tests := []struct {
+		name, input  string
+		line, column int
+		path, value  string
+	}{
+		{"struct field", "spec:\n  containers:\n    - image: a\n    - port: eighty\n", 4, 13, "spec.containers[1].port", "string"},
+		{"flow", "spec:\n  containers: [{image: a}, {port: 1.5}]\n", 2, 35, "spec.containers[1].port", "number 1.5"},
+		{"map value", "spec:\n  labels:\n    app.kubernetes.io/name: web\n", 3, 29, `spec.labels["app.kubernetes.io/name"]`, "string"},
+		{"sequence for map", "spec:\n  ordered: [a]\n", 2, 12, "spec.ordered", "sequence"},
+		{"mapping for int", "spec:\n  containers:\n    - port: {a: 1}\n", 3, 13, "spec.containers[0].port", "mapping"},
+		{"nested sequence", "spec:\n  limits:\n    cpu: [1, x]\n", 3, 14, "spec.limits.cpu[1]", "string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var v pod
+				err := decode([]byte(tt.input), &v)
+				var pe *ParseError
+				var te *TypeError
+				if !errors.As(err, &pe) || !errors.As(err, &te) {
+					t.Fatalf("decode error = %v, want a *ParseError wrapping a *TypeError", err)
+				}
+				if pe.Line != tt.line || pe.Column != tt.column || pe.Path != tt.path {
+					t.Errorf("ParseError at line %d, column %d, path %q; want line %d, column %d, path %q",
+						pe.Line, pe.Column, pe.Path, tt.line, tt.column, tt.path)
+				}
+				if te.Position != pe.Position || te.Path != pe.Path || te.Value != tt.value {
+					t.Errorf("TypeError = %+v, want value %q at the ParseError's position and path", te, tt.value)
+				}
+				if !strings.HasSuffix(pe.Snippet(), "^") {
+					t.Errorf("Snippet() = %q", pe.Snippet())
+				}
+			})
+		})
+	}
+}
+
+func TestParity_CollectedTypeErrors(t *testing.T) {
+	type config struct {
+		Name string `yaml:"name"`
+		Spec struct {
+			Replicas int               `yaml:"replicas"`
+			Ports    []int             `yaml:"ports"`
+			Port     int               `yaml:"port"`
+			Labels   map[string]string `yaml:"labels"`
+		} `yaml:"spec"`
+	}
+	input := "name: web\nspec:\n  replicas: three\n  ports: [80, http, 443]\n  port: {a: 1}\n  labels:\n    app: web\n"
+	want := []struct {
+		line, column int
+		path         string
+	}{
+		{3, 13, "spec.replicas"},
+		{4, 15, "spec.ports[1]"},
+		{5, 9, "spec.port"},
+	}
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var v config
+		err := decode([]byte(input), &v)
+		var te *TypeError
+		if !errors.As(err, &te) || len(te.Errors) != len(want) {
+			t.Fatalf("decode error = %v, want a *TypeError collecting %d errors", err, len(want))
+		}
+		for i, w := range want {
+			var pe *ParseError
+			if !errors.As(te.Errors[i], &pe) || pe.Line != w.line || pe.Column != w.column || pe.Path != w.path {
+				t.Errorf("Errors[%d] = %v, want line %d, column %d, path %q", i, te.Errors[i], w.line, w.column, w.path)
+			}
+		}
+		if te.Path != "spec.replicas" || te.Value != "string" {
+			t.Errorf("TypeError describes %q %s, want the first mismatch", te.Path, te.Value)
+		}
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Path != "spec.replicas" {
+			t.Errorf("errors.As found ParseError %v, want the first mismatch", pe)
+		}
+		if !strings.HasPrefix(err.Error(), "yaml: unmarshal errors:\n  line 3, column 13: ") {
+			t.Errorf("Error() = %q", err.Error())
+		}
+
+		// Everything else is decoded
+		if v.Name != "web" || v.Spec.Labels["app"] != "web" || !reflect.DeepEqual(v.Spec.Ports, []int{80, 0, 443}) {
+			t.Errorf("decoded %+v, want the values around the mismatches", v)
+		}
+	})
+}
+
+// TestParity_RequiredFields checks that both decoders report the keys of
+// fields tagged required that a mapping lacks, at the mapping.
+func TestParity_RequiredFields(t *testing.T) {
+	type backend struct {
+		Host string `yaml:"host,required"`
+		Port int    `yaml:"port"`
+	}
+	type config struct {
+		Name     string    `yaml:"name,required"`
+		Replicas int       `yaml:"replicas,required"`
+		Debug    bool      `yaml:"debug"`
+		Backends []backend `yaml:"backends"`
+	}
+
+	tests := []struct {
+		input  string
+		fields []string // missing keys, or nil if the document decodes
+		path   string
+		line   int
+	}{
+		{"name: web\nreplicas: 2\n", nil, "", 0},
+		{"name: web\nreplicas:\n", nil, "", 0},
+		{"debug: true\n", []string{"name", "replicas"}, "", 1},
+		{"name: web\n", []string{"replicas"}, "", 1},
+		{"{name: web}", []string{"replicas"}, "", 1},
+		{"{}", []string{"name", "replicas"}, "", 1},
+		{"name: web\nreplicas: 2\nbackends:\n  - host: a\n  - port: 80\n", []string{"host"}, "backends[1]", 5},
+		{"name: web\nreplicas: 2\nbackends: [{host: a}, {port: 80}]\n", []string{"host"}, "backends[1]", 3},
+	}
+
+	for _, tt := range tests {
+		forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+			var c config
+			err := decode([]byte(tt.input), &c)
+			if tt.fields == nil {
+				if err != nil {
+					t.Errorf("%q: unexpected error: %v", tt.input, err)
+				}
+				return
+			}
+			var mfe *MissingFieldError
+			if !errors.As(err, &mfe) {
+				t.Fatalf("%q: error %v, want a *MissingFieldError", tt.input, err)
+			}
+			if !reflect.DeepEqual(mfe.Fields, tt.fields) {
+				t.Errorf("%q: Fields = %q, want %q", tt.input, mfe.Fields, tt.fields)
+			}
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("%q: error %v, want a *ParseError", tt.input, err)
+			}
+			if pe.Path != tt.path || pe.Line != tt.line {
+				t.Errorf("%q: error %v at path %q, want line %d, path %q", tt.input, err, pe.Path, tt.line, tt.path)
+			}
+		})
+	}
+}
+
+// TestParity_CoreTags checks that both decode paths apply the core schema
+// tags, and !!binary, !!set and !!omap, the same way.
+func TestParity_CoreTags(t *testing.T) {
+	input := "" +
+		"port: !!str 8080\n" +
+		"count: !<tag:yaml.org,2002:int> \"42\"\n" +
+		"empty: !!str\n" +
+		"logo: !!binary |\n" +
+		"  aGVs\n" +
+		"  bG8=\n" +
+		"roles: !!set {admin, dev}\n" +
+		"flags: !!set\n" +
+		"  ? debug\n" +
+		"  ? trace\n" +
+		"steps: !!omap\n" +
+		"  - build: make\n" +
+		"  - test: make check\n" +
+		"order: !!omap [{z: 1}, {a: 2}]\n" +
+		"pairs: !!omap [b: 1, a: 2]\n"
+
+	type config struct {
+		Port  string          `yaml:"port"`
+		Count int             `yaml:"count"`
+		Empty string          `yaml:"empty"`
+		Logo  []byte          `yaml:"logo"`
+		Roles []string        `yaml:"roles"`
+		Flags map[string]bool `yaml:"flags"`
+		Steps MapSlice        `yaml:"steps"`
+		Order map[string]int  `yaml:"order"`
+		Pairs MapSlice        `yaml:"pairs"`
+	}
+	want := config{
+		Port:  "8080",
+		Count: 42,
+		Logo:  []byte("hello"),
+		Roles: []string{"admin", "dev"},
+		Flags: map[string]bool{"debug": true, "trace": true},
+		Steps: MapSlice{{Key: "build", Value: "make"}, {Key: "test", Value: "make check"}},
+		Order: map[string]int{"z": 1, "a": 2},
+		Pairs: MapSlice{{Key: "b", Value: int64(1)}, {Key: "a", Value: int64(2)}},
+	}
+
+	decoders := map[string]func([]byte, interface{}) error{
+		"fast":    Unmarshal,
+		"AST":     UnmarshalWithAST,
+		"Decoder": func(data []byte, v interface{}) error { return NewDecoder(bytes.NewReader(data)).Decode(v) },
+	}
+	var generic map[string]interface{}
+	for name, decode := range decoders {
+		t.Run(name, func(t *testing.T) {
+			var got config
+			if err := decode([]byte(input), &got); err != nil {
+				t.Fatalf("decode error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("decode = %+v, want %+v", got, want)
+			}
+
+			var v map[string]interface{}
+			if err := decode([]byte(input), &v); err != nil {
+				t.Fatalf("decode into interface{} error = %v", err)
+			}
+			if v["port"] != "8080" || v["count"] != int64(42) || v["empty"] != "" {
+				t.Errorf("decode into interface{} = %v", v)
+			}
+			if generic == nil {
+				generic = v
+			} else if !reflect.DeepEqual(v, generic) {
+				t.Errorf("decode into interface{} = %#v, want %#v", v, generic)
+			}
+		})
+	}
+}
+
+// TestParity_FlowPairs checks that a flow sequence entry written as a key
+// and ':' without braces, as in [a: 1], is a single-pair mapping on every
+// path, whatever the target.
+func TestParity_FlowPairs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{} // nil: decoding must fail
+	}{
+		{"[a: 1, b: 2]", []interface{}{
+			map[string]interface{}{"a": int64(1)},
+			map[string]interface{}{"b": int64(2)},
+		}},
+		{`[a: 1, "b": x, 'c':d, e:, f, [1, 2]: g, h: [i: j]]`, []interface{}{
+			map[string]interface{}{"a": int64(1)},
+			map[string]interface{}{"b": "x"},
+			map[string]interface{}{"c": "d"},
+			map[string]interface{}{"e": nil},
+			"f",
+			map[string]interface{}{"[1,2]": "g"},
+			map[string]interface{}{"h": []interface{}{map[string]interface{}{"i": "j"}}},
+		}},
+		{"[http://x, a:b]", []interface{}{"http://x", "a:b"}},
+		{"[a: 1: 2]", nil},
+		{"[a\n  : 1]", nil},
+		{"{a: b: c}", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var got interface{}
+				err := decode([]byte(tt.input), &got)
+				if tt.want == nil {
+					if err == nil {
+						t.Fatalf("expected error, got %#v", got)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("decode error = %v", err)
+				}
+				if !parityEqual(got, tt.want) {
+					t.Errorf("got  %#v\nwant %#v", got, tt.want)
+				}
+			})
+		})
+	}
+
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var got struct {
+			Maps    []map[string]int     `yaml:"maps"`
+			Structs [2]struct{ A int }   `yaml:"structs"`
+			Ptrs    []*map[string]string `yaml:"ptrs"`
+			Omap    MapSlice             `yaml:"omap"`
+		}
+		input := "maps: [a: 1, b: 2]\nstructs: [a: 3, a: 4]\nptrs: [k: v]\nomap: !!omap [z: 1, y: 2]"
+		if err := decode([]byte(input), &got); err != nil {
+			t.Fatalf("decode error = %v", err)
+		}
+		if want := []map[string]int{{"a": 1}, {"b": 2}}; !reflect.DeepEqual(got.Maps, want) {
+			t.Errorf("Maps = %v, want %v", got.Maps, want)
+		}
+		if got.Structs[0].A != 3 || got.Structs[1].A != 4 {
+			t.Errorf("Structs = %+v, want [{3} {4}]", got.Structs)
+		}
+		if len(got.Ptrs) != 1 || got.Ptrs[0] == nil || (*got.Ptrs[0])["k"] != "v" {
+			t.Errorf("Ptrs = %v, want [&map[k:v]]", got.Ptrs)
+		}
+		if want := (MapSlice{{Key: "z", Value: int64(1)}, {Key: "y", Value: int64(2)}}); !reflect.DeepEqual(got.Omap, want) {
+			t.Errorf("Omap = %v, want %v", got.Omap, want)
+		}
+	})
+}
+
+// TestParity_Escapes checks that both decoders read double-quoted escapes
+// through the same YAML 1.2 table: \x is a code point rather than a raw
+// byte, and an unknown or short escape is an error rather than being kept
+// or dropped.
+func TestParity_Escapes(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // "": decoding must fail with err
+		err   string
+	}{
+		{`v: "\xff"`, "\u00ff", ""},
+		{`v: "\x41\x7e"`, "A~", ""},
+		{`v: "\e[0m\a"`, "\x1b[0m\a", ""},
+		{`v: "\N\_\L\P"`, "\u0085\u00a0\u2028\u2029", ""},
+		{`v: "\U0001F600"`, "\U0001F600", ""},
+		{`v: "\q"`, "", `invalid escape sequence \q`},
+		{`v: "a\%b"`, "", `invalid escape sequence \%`},
+		{`v: "\x4"`, "", `invalid escape sequence \x: want 2 hex digits`},
+		{`v: "\u00g0"`, "", `invalid escape sequence \u: want 4 hex digits`},
+		{`v: "\uDE00"`, "", "unpaired low surrogate"},
+		{`v: "\U00110000"`, "", "not a Unicode scalar value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var v struct {
+					V string `yaml:"v"`
+				}
+				err := decode([]byte(tt.input), &v)
+				if tt.err != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.err) {
+						t.Fatalf("decode error = %v, want it to contain %q", err, tt.err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("decode error = %v", err)
+				}
+				if v.V != tt.want {
+					t.Errorf("decoded %q, want %q", v.V, tt.want)
+				}
+			})
+		})
+	}
+}
+
+// TestParity_ExplicitKeys checks that "? key" entries decode the same on
+// every path, including Unmarshal and Decoder, with a key that has no
+// ": value" line reading as null.
+func TestParity_ExplicitKeys(t *testing.T) {
+	tests := []struct {
+		input string
+		want  map[string]interface{}
+	}{
+		{"? a\n: 1\n? b", map[string]interface{}{"a": int64(1), "b": nil}},
+		{"? a\n? b\n", map[string]interface{}{"a": nil, "b": nil}},
+		{"? a # key\n: [1, 2]\n? 'c: d'\n: x\n", map[string]interface{}{"a": []interface{}{int64(1), int64(2)}, "c: d": "x"}},
+	}
+	decoders := map[string]func([]byte, interface{}) error{
+		"fast":    Unmarshal,
+		"AST":     UnmarshalWithAST,
+		"Decoder": func(data []byte, v interface{}) error { return NewDecoder(bytes.NewReader(data)).Decode(v) },
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			for name, decode := range decoders {
+				var got map[string]interface{}
+				if err := decode([]byte(tt.input), &got); err != nil {
+					t.Fatalf("%s: decode error = %v", name, err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("%s: decode = %#v, want %#v", name, got, tt.want)
+				}
+			}
+		})
+	}
+
+	var v struct {
+		A string `yaml:"a"`
+		B *int   `yaml:"b"`
+	}
+	if err := Unmarshal([]byte("? a\n: x\n? b\n"), &v); err != nil || v.A != "x" || v.B != nil {
+		t.Errorf("Unmarshal into struct = %+v, %v; want {A:x B:<nil>}", v, err)
+	}
+}
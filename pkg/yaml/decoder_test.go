@@ -0,0 +1,739 @@
+package yaml
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type decoderBase struct {
+	Region string `yaml:"region"`
+}
+
+type decoderSecrets struct {
+	Token string `yaml:"token"`
+}
+
+type decoderConfig struct {
+	decoderBase
+	*decoderSecrets
+	Name     string `yaml:"name"`
+	password string
+	apiKey   string `yaml:"api_key"`
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("name: svc\nregion: eu"))
+
+	var cfg decoderConfig
+	if err := dec.Decode(&cfg); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if cfg.Name != "svc" || cfg.Region != "eu" {
+		t.Errorf("Decode() = %+v, want Name=svc Region=eu", cfg)
+	}
+
+	if err := dec.Decode(&cfg); err != io.EOF {
+		t.Errorf("second Decode() error = %v, want io.EOF", err)
+	}
+}
+
+func TestDecoder_EmptyInput(t *testing.T) {
+	for _, input := range []string{"", "\n  \n", "# nothing here\n"} {
+		var v interface{}
+		if err := NewDecoder(strings.NewReader(input)).Decode(&v); err != io.EOF {
+			t.Errorf("Decode(%q) error = %v, want io.EOF", input, err)
+		}
+	}
+}
+
+func TestDecoder_TruncatedDocument(t *testing.T) {
+	inputs := []string{
+		"a: [1, 2",
+		"a: {b: 1",
+		"{a:",
+		"a: \"unterminated",
+		"a: 'unterminated",
+		"ok: 1\n---\nitems: [1,\n",
+	}
+	for _, input := range inputs {
+		dec := NewDecoder(strings.NewReader(input))
+		var err error
+		for err == nil {
+			var v interface{}
+			err = dec.Decode(&v)
+		}
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Decode(%q) error = %v, want io.ErrUnexpectedEOF", input, err)
+		}
+	}
+
+	var v interface{}
+	if err := NewDecoder(strings.NewReader("a: [1, }")).Decode(&v); err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Decode of malformed input error = %v, want a syntax error", err)
+	}
+}
+
+func TestDecoder_MultipleDocuments(t *testing.T) {
+	input := "%YAML 1.2\n---\nname: a\n---\nname: b\n...\n# trailer\n--- {name: c}\n---\n"
+
+	var got []string
+	dec := NewDecoder(strings.NewReader(input))
+	for {
+		var v struct{ Name string }
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		got = append(got, v.Name)
+	}
+	if want := []string{"a", "b", "c", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded names = %q, want %q", got, want)
+	}
+}
+
+func TestDecoder_Buffered(t *testing.T) {
+	payload := "\x00\x01binary\xff\n---\n"
+	dec := NewDecoder(strings.NewReader("name: a\nsize: 12\n...\n" + payload))
+
+	if b, _ := io.ReadAll(dec.Buffered()); len(b) != 0 {
+		t.Errorf("Buffered() before Decode = %q, want nothing", b)
+	}
+
+	var header struct {
+		Name string
+		Size int
+	}
+	if err := dec.Decode(&header); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if header.Name != "a" || header.Size != 12 {
+		t.Errorf("Decode() = %+v, want {a 12}", header)
+	}
+	b, err := io.ReadAll(dec.Buffered())
+	if err != nil || string(b) != payload {
+		t.Errorf("Buffered() = %q, %v, want %q", b, err, payload)
+	}
+
+	// Without "...", the next document starts at its "---"
+	dec = NewDecoder(strings.NewReader("a: 1\n---\na: 2\n"))
+	var v map[string]int
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if b, _ := io.ReadAll(dec.Buffered()); string(b) != "---\na: 2\n" {
+		t.Errorf("Buffered() = %q, want the second document", b)
+	}
+}
+
+func TestDecoder_SetMaxDocuments(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("a: 1\n---\na: 2\n---\na: 3\n"))
+	dec.SetMaxDocuments(2)
+
+	var v map[string]int
+	for i := 0; i < 2; i++ {
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("Decode() #%d error = %v", i+1, err)
+		}
+	}
+	err := dec.Decode(&v)
+	var limitErr *DocumentLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("third Decode() error = %v, want *DocumentLimitError", err)
+	}
+	if limitErr.Max != 2 || limitErr.Line != 4 {
+		t.Errorf("DocumentLimitError = %+v, want Max 2 at line 4", limitErr)
+	}
+}
+
+func TestDecoder_FieldWarnings(t *testing.T) {
+	input := "name: svc\npassword: hunter2\napi_key: abc\ntoken: t0k\nunknown: 1"
+
+	var warnings []*FieldWarning
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetWarningHandler(func(w *FieldWarning) {
+		warnings = append(warnings, w)
+	})
+
+	var cfg decoderConfig
+	if err := dec.Decode(&cfg); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if cfg.Name != "svc" {
+		t.Errorf("Name = %q, want svc", cfg.Name)
+	}
+
+	want := []struct {
+		key, field string
+		line       int
+	}{
+		{"password", "password", 2},
+		{"api_key", "apiKey", 3},
+		{"token", "decoderSecrets.Token", 4},
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("got %d warnings, want %d: %v", len(warnings), len(want), warnings)
+	}
+	for i, w := range want {
+		got := warnings[i]
+		if got.Key != w.key || got.Field != w.field || got.Line != w.line {
+			t.Errorf("warning %d = {Key:%q Field:%q Line:%d}, want {Key:%q Field:%q Line:%d}",
+				i, got.Key, got.Field, got.Line, w.key, w.field, w.line)
+		}
+		if got.Reason == "" {
+			t.Errorf("warning %d has no reason", i)
+		}
+	}
+}
+
+func TestDecoder_DisallowIgnoredFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantKey string
+	}{
+		{"unexported field", "name: svc\npassword: x", "password"},
+		{"flow mapping", "{name: svc, api_key: x}", "api_key"},
+		{"unexported embedded pointer", "token: x", "token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.DisallowIgnoredFields()
+
+			var cfg decoderConfig
+			err := dec.Decode(&cfg)
+			var w *FieldWarning
+			if !errors.As(err, &w) {
+				t.Fatalf("Decode() error = %v, want *FieldWarning", err)
+			}
+			if w.Key != tt.wantKey {
+				t.Errorf("Key = %q, want %q", w.Key, tt.wantKey)
+			}
+			if !strings.Contains(err.Error(), tt.wantKey) {
+				t.Errorf("error %q does not mention key %q", err, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestDecoder_DisallowUnknownFields(t *testing.T) {
+	type Spec struct {
+		Replicas int    `yaml:"replicas"`
+		Image    string `yaml:"image"`
+	}
+	type Deployment struct {
+		Name string `yaml:"name"`
+		Spec Spec   `yaml:"spec"`
+	}
+
+	tests := []struct {
+		name           string
+		input          string
+		wantKey        string
+		wantSuggestion string
+		wantLine       int
+	}{
+		{"typo in nested struct", "name: web\nspec:\n  replcas: 3\n", "replcas", "replicas", 3},
+		{"case difference", "Nmae: web\n", "Nmae", "name", 1},
+		{"flow mapping", "{name: web, spec: {imag: x}}", "imag", "image", 1},
+		{"no close match", "name: web\nannotations: {}\n", "annotations", "", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.DisallowUnknownFields()
+
+			var d Deployment
+			err := dec.Decode(&d)
+			var e *UnknownFieldError
+			if !errors.As(err, &e) {
+				t.Fatalf("Decode() error = %v, want *UnknownFieldError", err)
+			}
+			if e.Key != tt.wantKey || e.Suggestion != tt.wantSuggestion || e.Line != tt.wantLine {
+				t.Errorf("got key %q, suggestion %q, line %d; want %q, %q, %d",
+					e.Key, e.Suggestion, e.Line, tt.wantKey, tt.wantSuggestion, tt.wantLine)
+			}
+			if tt.wantSuggestion != "" && !strings.Contains(err.Error(), `did you mean "`+tt.wantSuggestion+`"?`) {
+				t.Errorf("error %q does not suggest %q", err, tt.wantSuggestion)
+			}
+		})
+	}
+
+	t.Run("known fields decode", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader("name: web\nspec:\n  replicas: 2\n"))
+		dec.DisallowUnknownFields()
+		var d Deployment
+		if err := dec.Decode(&d); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if d.Spec.Replicas != 2 {
+			t.Errorf("Replicas = %d, want 2", d.Spec.Replicas)
+		}
+	})
+
+	t.Run("unknown keys allowed by default", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader("name: web\nreplcas: 3\n"))
+		if dec.options().OnUnknownField != nil {
+			t.Error("OnUnknownField installed without DisallowUnknownFields")
+		}
+		var d Deployment
+		if err := dec.Decode(&d); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+	})
+
+	t.Run("ignored fields are not unknown", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader("name: svc\npassword: x"))
+		dec.DisallowUnknownFields()
+		var cfg decoderConfig
+		if err := dec.Decode(&cfg); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+	})
+}
+
+func TestDecoder_UnknownFieldsListed(t *testing.T) {
+	type Spec struct {
+		Replicas int    `yaml:"replicas"`
+		Image    string `yaml:"image"`
+	}
+	type Deployment struct {
+		Name string `yaml:"name"`
+		Spec Spec   `yaml:"spec"`
+	}
+	input := "nmae: web\nspec:\n  replcas: 3\n  image: nginx\nextra: 1\n"
+
+	check := func(t *testing.T, err error, d Deployment) {
+		t.Helper()
+		var e *UnknownFieldError
+		if !errors.As(err, &e) || e.Key != "nmae" {
+			t.Fatalf("error = %v, want *UnknownFieldError for nmae first", err)
+		}
+		lines := strings.Split(err.Error(), "\n")
+		want := []string{"line 1: unknown field \"nmae\"", "line 3: unknown field \"replcas\"", "line 5: unknown field \"extra\""}
+		if len(lines) != len(want) {
+			t.Fatalf("error =\n%v\nwant %d lines", err, len(want))
+		}
+		for i, w := range want {
+			if !strings.Contains(lines[i], w) {
+				t.Errorf("line %d of error = %q, want %q", i+1, lines[i], w)
+			}
+		}
+		// Known keys after an unknown one are still decoded
+		if d.Spec.Image != "nginx" {
+			t.Errorf("Image = %q, want nginx", d.Spec.Image)
+		}
+	}
+
+	t.Run("KnownFields", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader(input))
+		dec.KnownFields(true)
+		var d Deployment
+		check(t, dec.Decode(&d), d)
+	})
+
+	t.Run("UnmarshalStrict", func(t *testing.T) {
+		var d Deployment
+		check(t, UnmarshalStrict([]byte(input), &d), d)
+
+		if err := UnmarshalStrict([]byte("name: web\nspec: {replicas: 2}\n"), &d); err != nil {
+			t.Errorf("UnmarshalStrict of known keys = %v", err)
+		}
+	})
+
+	t.Run("KnownFields(false)", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader(input))
+		dec.DisallowUnknownFields()
+		dec.KnownFields(false)
+		var d Deployment
+		if err := dec.Decode(&d); err != nil {
+			t.Errorf("Decode() error = %v", err)
+		}
+	})
+
+	t.Run("syntax errors win", func(t *testing.T) {
+		var d Deployment
+		err := UnmarshalStrict([]byte("nmae: web\nspec: [\n"), &d)
+		var e *UnknownFieldError
+		if err == nil || errors.As(err, &e) {
+			t.Errorf("UnmarshalStrict = %v, want the syntax error", err)
+		}
+	})
+}
+
+func TestDecoder_AmbiguousPromotedField(t *testing.T) {
+	type A struct{ ID int }
+	type B struct{ ID int }
+	type C struct {
+		A
+		B
+	}
+
+	dec := NewDecoder(strings.NewReader("id: 1"))
+	dec.DisallowIgnoredFields()
+
+	var c C
+	var w *FieldWarning
+	if err := dec.Decode(&c); !errors.As(err, &w) {
+		t.Fatalf("Decode() error = %v, want *FieldWarning", err)
+	}
+	if !strings.Contains(w.Reason, "ambiguous") {
+		t.Errorf("Reason = %q, want ambiguity", w.Reason)
+	}
+}
+
+func TestDecoder_SetTagName(t *testing.T) {
+	type Inner struct {
+		Level string `json:"level" config:"log_level"`
+	}
+	type Settings struct {
+		Inner
+		Host  string `json:"host" config:"server_host"`
+		Port  int    `json:"port,omitempty" yaml:"yaml_port"`
+		Debug bool   `json:"-"`
+	}
+
+	tests := []struct {
+		name    string
+		tagName string
+		input   string
+		want    Settings
+	}{
+		{
+			name:    "json tags",
+			tagName: "json",
+			input:   "host: example.com\nport: 80\nlevel: info\ndebug: true",
+			want:    Settings{Inner: Inner{Level: "info"}, Host: "example.com", Port: 80},
+		},
+		{
+			name:    "custom tags fall back to field names",
+			tagName: "config",
+			input:   "server_host: example.com\nport: 81\nlog_level: warn",
+			want:    Settings{Inner: Inner{Level: "warn"}, Host: "example.com", Port: 81},
+		},
+		{
+			name:  "default yaml tags",
+			input: "host: example.com\nyaml_port: 82",
+			want:  Settings{Host: "example.com", Port: 82},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			if tt.tagName != "" {
+				dec.SetTagName(tt.tagName)
+			}
+
+			var got Settings
+			if err := dec.Decode(&got); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONTagFallback(t *testing.T) {
+	type Inner struct {
+		Level string `json:"level"`
+	}
+	type Settings struct {
+		Inner `json:"logging"`
+		Host  string `json:"host"`
+		Port  int    `json:"port,omitempty" yaml:"yaml_port"`
+		Debug bool   `json:"-"`
+		Zone  string
+	}
+	input := "host: example.com\nyaml_port: 80\nport: 1\nlogging: {level: info}\ndebug: true\nzone: eu\n"
+	want := Settings{Inner: Inner{Level: "info"}, Host: "example.com", Port: 80, Zone: "eu"}
+
+	var got Settings
+	if err := UnmarshalWithOptions([]byte(input), &got, DecodeOptions{JSONTagFallback: true}); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("UnmarshalWithOptions() = %+v, want %+v", got, want)
+	}
+
+	got = Settings{}
+	dec := NewDecoder(strings.NewReader(input))
+	dec.UseJSONTagFallback()
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Decode() = %+v, want %+v", got, want)
+	}
+
+	// Without the fallback, json tags are not field names
+	got = Settings{}
+	if err := UnmarshalWithOptions([]byte(input), &got, DecodeOptions{}); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+	if got.Level != "" || !got.Debug {
+		t.Errorf("UnmarshalWithOptions() without fallback = %+v", got)
+	}
+
+	err := UnmarshalWithOptions([]byte(input), &got, DecodeOptions{JSONTagFallback: true, KnownFields: true})
+	var e *UnknownFieldError
+	if !errors.As(err, &e) || e.Key != "port" || e.Line != 3 {
+		t.Errorf("UnmarshalWithOptions() with KnownFields error = %v, want unknown field port at line 3", err)
+	}
+}
+
+func TestPromoteScalars(t *testing.T) {
+	input := "accept: text/html\nlang: [en, fr]\ncache: 'no-cache'\nvary:\n  - a\n  - b\nhost:\n"
+	want := map[string][]string{
+		"accept": {"text/html"},
+		"lang":   {"en", "fr"},
+		"cache":  {"no-cache"},
+		"vary":   {"a", "b"},
+		"host":   nil,
+	}
+
+	var got map[string][]string
+	if err := UnmarshalWithOptions([]byte(input), &got, DecodeOptions{PromoteScalars: true}); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalWithOptions() = %q, want %q", got, want)
+	}
+
+	got = nil
+	dec := NewDecoder(strings.NewReader(input))
+	dec.PromoteScalars()
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %q, want %q", got, want)
+	}
+
+	var ports struct {
+		Ports []*int `yaml:"ports"`
+	}
+	if err := UnmarshalWithOptions([]byte("ports: 80\n"), &ports, DecodeOptions{PromoteScalars: true}); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+	if len(ports.Ports) != 1 || *ports.Ports[0] != 80 {
+		t.Errorf("UnmarshalWithOptions() = %v, want one port 80", ports.Ports)
+	}
+	err := UnmarshalWithOptions([]byte("ports: http\n"), &ports, DecodeOptions{PromoteScalars: true})
+	if !errors.As(err, new(*TypeError)) {
+		t.Errorf("UnmarshalWithOptions() error = %v, want a *TypeError", err)
+	}
+
+	// Without the option, a scalar does not fit a slice
+	err = Unmarshal([]byte(input), &got)
+	if !errors.As(err, new(*TypeError)) {
+		t.Errorf("Unmarshal() error = %v, want a *TypeError", err)
+	}
+}
+
+func TestBasePosition(t *testing.T) {
+	type Meta struct {
+		Title string `yaml:"title"`
+		Count int    `yaml:"count"`
+		Extra Node   `yaml:"extra"`
+	}
+	// Front matter after a "---" line, indented by four columns on its first line
+	opts := DecodeOptions{BaseOffset: 4, BaseLine: 2, BaseColumn: 5, KnownFields: true}
+
+	tests := []struct {
+		name   string
+		input  string
+		line   int
+		column int
+		offset int
+	}{
+		{"first line", "count: x\ntitle: a\n", 2, 12, 11},
+		{"later line", "title: a\ncount: x\n", 3, 8, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Meta
+			err := UnmarshalWithOptions([]byte(tt.input), &m, opts)
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("UnmarshalWithOptions() error = %v, want a *ParseError", err)
+			}
+			if pe.Line != tt.line || pe.Column != tt.column || pe.Offset != tt.offset {
+				t.Errorf("error at line %d, column %d, offset %d, want %d, %d, %d",
+					pe.Line, pe.Column, pe.Offset, tt.line, tt.column, tt.offset)
+			}
+			if got := pe.Snippet(); !strings.HasSuffix(got, strings.Repeat(" ", tt.column-1)+"^") {
+				t.Errorf("Snippet() = %q, want the caret at column %d", got, tt.column)
+			}
+		})
+	}
+
+	var m Meta
+	err := UnmarshalWithOptions([]byte("bad: 1\n"), &m, opts)
+	var e *UnknownFieldError
+	if !errors.As(err, &e) || e.Line != 2 || e.Column != 9 {
+		t.Errorf("UnmarshalWithOptions() error = %v, want unknown field bad at line 2, column 9", err)
+	}
+	if err := UnmarshalWithOptions([]byte("extra: {a: 1}\ntitle: a\n"), &m, opts); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+	if m.Extra.Line != 2 || m.Extra.Column != 12 {
+		t.Errorf("Node at line %d, column %d, want line 2, column 12", m.Extra.Line, m.Extra.Column)
+	}
+
+	err = UnmarshalWithOptions([]byte("title: a\ncount: [1\n"), &m, opts)
+	if err == nil || !strings.Contains(err.Error(), `close the "[" opened on line 3`) {
+		t.Errorf("UnmarshalWithOptions() error = %v, want a hint naming line 3", err)
+	}
+}
+
+type decoderTree struct {
+	Name     string         `yaml:"name"`
+	Parent   *decoderTree   `yaml:"parent"`
+	Children []*decoderTree `yaml:"children"`
+}
+
+func TestDecode_RecursiveTypes(t *testing.T) {
+	inputs := []string{
+		"name: root\nchildren:\n  - name: a\n    children:\n      - name: a1\n  - null\n  - {name: b, parent: {name: root}}\n",
+		"{name: root, parent: null, children: [{name: a, children: [{name: a1}]}, ~]}",
+	}
+
+	for _, input := range inputs {
+		var fast, viaAST decoderTree
+		if err := Unmarshal([]byte(input), &fast); err != nil {
+			t.Fatalf("Unmarshal(%q) error = %v", input, err)
+		}
+		if err := UnmarshalWithAST([]byte(input), &viaAST); err != nil {
+			t.Fatalf("UnmarshalWithAST(%q) error = %v", input, err)
+		}
+		if !reflect.DeepEqual(fast, viaAST) {
+			t.Errorf("decoders disagree on %q:\nfast: %+v\nAST:  %+v", input, fast, viaAST)
+		}
+		if fast.Parent != nil || fast.Children[0].Children[0].Name != "a1" || fast.Children[1] != nil {
+			t.Errorf("Unmarshal(%q) = %+v", input, fast)
+		}
+	}
+}
+
+func TestDecoder_SetMaxDepth(t *testing.T) {
+	nested := func(n int) string {
+		return strings.Repeat("{parent: ", n) + "{name: x}" + strings.Repeat("}", n)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		maxDepth int
+		wantErr  bool
+	}{
+		{"within limit", nested(5), 10, false},
+		{"too deep", nested(20), 10, true},
+		{"default limit", nested(DefaultMaxDepth), 0, true},
+		{"unlimited", nested(DefaultMaxDepth), -1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.SetMaxDepth(tt.maxDepth)
+
+			var tree decoderTree
+			err := dec.Decode(&tree)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("Decode() error = %v, want ErrLimitExceeded", err)
+			}
+		})
+	}
+
+	t.Run("AST path default limit", func(t *testing.T) {
+		deep := strings.Repeat("[", DefaultMaxDepth+1) + strings.Repeat("]", DefaultMaxDepth+1)
+		var v interface{}
+		if err := UnmarshalWithAST([]byte(deep), &v); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("UnmarshalWithAST() error = %v, want ErrLimitExceeded", err)
+		}
+	})
+}
+
+func TestDecoder_SetLimits(t *testing.T) {
+	stream := "name: a\n---\nname: b\n---\nname: c\n"
+	decodeAll := func(l Limits) (int, error) {
+		dec := NewDecoder(strings.NewReader(stream))
+		dec.SetLimits(l)
+		for n := 0; ; n++ {
+			var m map[string]string
+			if err := dec.Decode(&m); err == io.EOF {
+				return n, nil
+			} else if err != nil {
+				return n, err
+			}
+		}
+	}
+
+	if n, err := decodeAll(Limits{MaxBytes: int64(len(stream)), MaxDocuments: 3, MaxDepth: 2, MaxKeyLength: 4}); err != nil || n != 3 {
+		t.Errorf("within limits: decoded %d documents, error = %v; want 3, nil", n, err)
+	}
+	for _, l := range []Limits{{MaxBytes: int64(len(stream)) - 1}, {MaxDocuments: 2}, {MaxDepth: 1}, {MaxKeyLength: 3}} {
+		if _, err := decodeAll(l); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("%+v: error = %v, want ErrLimitExceeded", l, err)
+		}
+	}
+}
+
+func TestDecoder_InvalidUTF8(t *testing.T) {
+	input := "name: caf\xe9\ncity: ok\n"
+
+	var v map[string]string
+	err := NewDecoder(strings.NewReader(input)).Decode(&v)
+	if !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("Decode() error = %v, want ErrInvalidUTF8", err)
+	}
+	if !strings.Contains(err.Error(), "invalid UTF-8 at offset 9") {
+		t.Errorf("Decode() error = %q, want offset 9", err)
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.ReplaceInvalidUTF8()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Decode() with ReplaceInvalidUTF8 error = %v", err)
+	}
+	want := map[string]string{"name": "caf�", "city": "ok"}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("Decode() = %q, want %q", v, want)
+	}
+}
+
+func TestDecoder_ParseErrorPosition(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("name: a\nport: 1\n---\nname: b\nport: high\n"))
+
+	var v struct {
+		Name string
+		Port int
+	}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("first Decode() error = %v", err)
+	}
+	err := dec.Decode(&v)
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("second Decode() error = %v, want *ParseError", err)
+	}
+	// Positions count from the start of the stream, not of the document
+	if pe.Line != 5 || pe.Column != 7 || pe.Offset != 34 || pe.Excerpt != "port: high" {
+		t.Errorf("ParseError at line %d, column %d, offset %d, excerpt %q; want line 5, column 7, offset 34, %q",
+			pe.Line, pe.Column, pe.Offset, pe.Excerpt, "port: high")
+	}
+}
@@ -0,0 +1,214 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-yaml/internal/parser"
+	"github.com/shapestone/shape-yaml/internal/utf8input"
+)
+
+// Severity is how serious a Diagnostic is.
+type Severity int
+
+// Severities of diagnostics
+const (
+	SeverityError   Severity = iota // the input is not valid YAML
+	SeverityWarning                 // valid, but likely not what was meant
+)
+
+// String returns "error" or "warning".
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "Severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Rules that ValidateDiagnostics reports, for linters that filter or
+// configure diagnostics by rule.
+const (
+	RuleSyntax         = "syntax"           // a syntax error
+	RuleDuplicateKey   = "duplicate-key"    // a key repeated in one mapping
+	RuleTabIndentation = "tab-indentation"  // a line indented with a tab
+	RuleNonSpecBoolean = "non-spec-boolean" // a YAML 1.1 boolean such as yes or off
+)
+
+// Diagnostic is a problem ValidateDiagnostics found in its input.
+type Diagnostic struct {
+	Severity Severity
+	Line     int // 1-based; 0 where the position is unknown
+	Column   int
+	Message  string
+	Rule     string // one of the Rule constants
+}
+
+// String formats d as "line:column: severity: message (rule)", the form
+// editors and CI systems recognize after a file name.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// ValidateDiagnostics checks a YAML stream like Validate, but returns every
+// problem it finds as a Diagnostic, in order of position, rather than the
+// first error, for linting integrations. Every document of a multi-document
+// stream is checked. It returns no diagnostics for clean input.
+//
+// A syntax error or duplicate key is reported with SeverityError; as with
+// Validate, parsing stops there, so a stream holds at most one error. The
+// warnings flag input that parses, but perhaps not as intended:
+//
+//   - RuleTabIndentation: a line indented with a tab, which YAML forbids and
+//     other parsers reject
+//   - RuleNonSpecBoolean: a plain key or value such as yes, No or off,
+//     which this package reads as a boolean, as YAML 1.1 did, while YAML
+//     1.2 parsers read a string; write true or false, or quote it
+//
+// Example:
+//
+//	for _, d := range yaml.ValidateDiagnostics(string(data)) {
+//	    fmt.Printf("%s:%s\n", path, d) // deploy.yaml:3:9: warning: ...
+//	}
+func ValidateDiagnostics(input string) []Diagnostic {
+	diags := tabDiagnostics(input)
+
+	var docs []*Node
+	err := utf8input.CheckString(input)
+	if err == nil {
+		p := parser.NewParser(input)
+		b := newNodeBuilder(p, input)
+		roots, perr := p.ParseMultiDoc()
+		for _, root := range roots {
+			if !parser.IsEmptyDocument(root) {
+				docs = append(docs, b.build(root))
+			}
+		}
+		err = perr
+	}
+	if err != nil {
+		diags = append(diags, errorDiagnostic(err))
+	} else {
+		seen := make(map[[2]int]bool)
+		for _, doc := range docs {
+			diags = appendBooleanDiagnostics(diags, doc, seen)
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Column < diags[j].Column
+	})
+	return diags
+}
+
+// positionInMessage matches the position parse errors report.
+var positionInMessage = regexp.MustCompile(` at line (\d+), column (\d+)`)
+
+// errorDiagnostic converts a parse error, moving its position out of the
+// message.
+func errorDiagnostic(err error) Diagnostic {
+	d := Diagnostic{Severity: SeverityError, Message: err.Error(), Rule: RuleSyntax}
+	if m := positionInMessage.FindStringSubmatchIndex(d.Message); m != nil {
+		d.Line, _ = strconv.Atoi(d.Message[m[2]:m[3]])
+		d.Column, _ = strconv.Atoi(d.Message[m[4]:m[5]])
+		d.Message = d.Message[:m[0]] + d.Message[m[1]:]
+	}
+	if strings.Contains(d.Message, "duplicate key ") {
+		d.Rule = RuleDuplicateKey
+	}
+	return d
+}
+
+// tabDiagnostics warns of lines indented with a tab. The content of block
+// scalars is skipped, since tabs after its indentation are text.
+func tabDiagnostics(input string) []Diagnostic {
+	var diags []Diagnostic
+	blockIndent := -1 // indent of the line introducing a block scalar, or -1
+	for i, line := range bytes.Split([]byte(input), []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		spaces := len(line) - len(bytes.TrimLeft(line, " "))
+		ws := len(line) - len(bytes.TrimLeft(line, " \t"))
+		if ws == len(line) {
+			continue // blank lines may hold any white space
+		}
+		if blockIndent >= 0 {
+			if spaces > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+
+		if tab := bytes.IndexByte(line[:ws], '\t'); tab >= 0 {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Line:     i + 1,
+				Column:   tab + 1,
+				Message:  "line indented with a tab; indent with spaces",
+				Rule:     RuleTabIndentation,
+			})
+		}
+		if introducesBlockScalar(line) {
+			blockIndent = spaces
+		}
+	}
+	return diags
+}
+
+// blockScalarHeader matches the end of a line whose value is a block
+// scalar, such as "key: |" or "- >-  # comment".
+var blockScalarHeader = regexp.MustCompile(`(?:^|[\s:-])[|>][-+0-9]*\s*(?:#.*)?$`)
+
+// introducesBlockScalar reports whether the lines after line are the content
+// of a block scalar.
+func introducesBlockScalar(line []byte) bool {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 || trimmed[0] == '#' {
+		return false
+	}
+	return blockScalarHeader.Match(trimmed)
+}
+
+// specBooleans are the booleans of the YAML 1.2 core schema.
+var specBooleans = map[string]bool{
+	"true": true, "True": true, "TRUE": true,
+	"false": true, "False": true, "FALSE": true,
+}
+
+// appendBooleanDiagnostics warns of plain keys and values below n that
+// resolve to booleans only under YAML 1.1. An alias copies the node it
+// names, so seen holds the positions already reported.
+func appendBooleanDiagnostics(diags []Diagnostic, n *Node, seen map[[2]int]bool) []Diagnostic {
+	switch n.Kind {
+	case ScalarKind:
+		pos := [2]int{n.Line, n.Column}
+		if n.Style != Plain || n.Tag != BoolTag || specBooleans[n.Value] || n.Line == 0 || seen[pos] {
+			break
+		}
+		seen[pos] = true
+		want := "false"
+		if v, _ := n.scalarValue(); v == true {
+			want = "true"
+		}
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Line:     n.Line,
+			Column:   n.Column,
+			Message:  fmt.Sprintf("%q is a boolean in YAML 1.1 only; write %s, or quote it for a string", n.Value, want),
+			Rule:     RuleNonSpecBoolean,
+		})
+	case SequenceKind, MappingKind:
+		for _, c := range n.Content {
+			diags = appendBooleanDiagnostics(diags, c, seen)
+		}
+	}
+	return diags
+}
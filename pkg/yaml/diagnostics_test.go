@@ -0,0 +1,59 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateDiagnostics(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Diagnostic
+	}{
+		{"clean", "a: true\nb: 'yes'\nc: |\n  x\n  \tindented text\n", nil},
+		{
+			name:  "tab indentation",
+			input: "a:\n\tb: 1\n",
+			want:  []Diagnostic{{SeverityWarning, 2, 1, "line indented with a tab; indent with spaces", RuleTabIndentation}},
+		},
+		{
+			name:  "YAML 1.1 booleans",
+			input: "on:\n  push: {}\nx: &a yes\ny: *a\nz: [No, 'off', TRUE]\n---\nw: OFF\n",
+			want: []Diagnostic{
+				{SeverityWarning, 1, 1, `"on" is a boolean in YAML 1.1 only; write true, or quote it for a string`, RuleNonSpecBoolean},
+				{SeverityWarning, 3, 7, `"yes" is a boolean in YAML 1.1 only; write true, or quote it for a string`, RuleNonSpecBoolean},
+				{SeverityWarning, 5, 5, `"No" is a boolean in YAML 1.1 only; write false, or quote it for a string`, RuleNonSpecBoolean},
+				{SeverityWarning, 7, 4, `"OFF" is a boolean in YAML 1.1 only; write false, or quote it for a string`, RuleNonSpecBoolean},
+			},
+		},
+		{
+			name:  "duplicate key",
+			input: "a: 1\na: 2\n",
+			want:  []Diagnostic{{SeverityError, 2, 5, `duplicate key "a"`, RuleDuplicateKey}},
+		},
+		{
+			name:  "syntax error after a warning",
+			input: "a:\n  b: 1\n\tc: 2\n",
+			want: []Diagnostic{
+				{SeverityWarning, 3, 1, "line indented with a tab; indent with spaces", RuleTabIndentation},
+				{SeverityError, 3, 2, "bad indentation of mapping entry: expected column 1; indent line 3 with spaces, not tabs", RuleSyntax},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateDiagnostics(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateDiagnostics() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_String(t *testing.T) {
+	d := Diagnostic{SeverityWarning, 3, 7, `"yes" is a boolean in YAML 1.1 only`, RuleNonSpecBoolean}
+	if got, want := d.String(), `3:7: warning: "yes" is a boolean in YAML 1.1 only (non-spec-boolean)`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
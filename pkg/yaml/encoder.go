@@ -1,16 +1,20 @@
 package yaml
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
 	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
+
+	"github.com/shapestone/shape-yaml/internal/bufpool"
 )
 
-// yamlEncoderFunc appends YAML encoding of rv to buf at the given indent level.
-type yamlEncoderFunc func(buf []byte, rv reflect.Value, indent int) ([]byte, error)
+// yamlEncoderFunc appends YAML encoding of rv to buf at the given indent
+// level, laid out as e says.
+type yamlEncoderFunc func(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error)
 
 // Encoder cache: atomic.Value COW map pattern (same as shape-json encoder.go)
 var yamlEncoderCache atomic.Value
@@ -21,43 +25,13 @@ func init() {
 }
 
 var (
-	yamlMarshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	yamlMarshalerType      = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	yamlValueMarshalerType = reflect.TypeOf((*ValueMarshaler)(nil)).Elem()
 )
 
-// Pre-computed indent byte arrays to avoid strings.Repeat on hot path
-const maxCachedIndent = 32
-
-var indentTable [maxCachedIndent][]byte
-
-func init() {
-	for i := range indentTable {
-		indentTable[i] = make([]byte, i*2)
-		for j := range indentTable[i] {
-			indentTable[i][j] = ' '
-		}
-	}
-}
-
 // yamlBufPool pools []byte slices for the compiled encoder path.
-var yamlBufPool = sync.Pool{
-	New: func() interface{} {
-		b := make([]byte, 0, 1024)
-		return &b
-	},
-}
-
-func appendIndent(buf []byte, level int) []byte {
-	if level <= 0 {
-		return buf
-	}
-	if level < maxCachedIndent {
-		return append(buf, indentTable[level]...)
-	}
-	for i := 0; i < level*2; i++ {
-		buf = append(buf, ' ')
-	}
-	return buf
-}
+// Buffers that grew past 64KB while encoding a large document are not retained.
+var yamlBufPool = bufpool.New(1024, 64*1024)
 
 // yamlEncoderForType returns a cached encoder for the given type, building one if needed.
 func yamlEncoderForType(t reflect.Type) yamlEncoderFunc {
@@ -81,9 +55,9 @@ func yamlEncoderForType(t reflect.Type) yamlEncoderFunc {
 	var wg sync.WaitGroup
 	wg.Add(1)
 	var realEnc yamlEncoderFunc
-	placeholder := func(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	placeholder := func(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 		wg.Wait()
-		return realEnc(buf, rv, indent)
+		return realEnc(e, buf, rv, indent)
 	}
 
 	// Store placeholder and release lock before building
@@ -116,14 +90,24 @@ func yamlEncoderForType(t reflect.Type) yamlEncoderFunc {
 // buildYAMLEncoder creates an encoder for the given type.
 func buildYAMLEncoder(t reflect.Type) yamlEncoderFunc {
 	// Check Marshaler interface on value type
-	if t.Implements(yamlMarshalerType) {
+	if isMarshaler(t) {
 		return yamlMarshalerEnc
 	}
 	// Check Marshaler on pointer-to-type
-	if t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(yamlMarshalerType) {
+	if t.Kind() != reflect.Ptr && isMarshaler(reflect.PointerTo(t)) {
 		return buildYAMLAddrMarshalerEnc(t)
 	}
 
+	if t == mapSliceType || t == hookedStructType {
+		return yamlMapSliceEnc
+	}
+	if t == timeType {
+		return yamlTimeEnc
+	}
+	if isBinary(t) {
+		return yamlBinaryEnc
+	}
+
 	switch t.Kind() {
 	case reflect.Ptr:
 		return buildYAMLPtrEncoder(t)
@@ -158,71 +142,137 @@ func buildYAMLEncoder(t reflect.Type) yamlEncoderFunc {
 // Primitive Encoders (zero allocation)
 // ================================
 
-func yamlBoolEnc(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+func yamlBoolEnc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 	if rv.Bool() {
 		return append(buf, "true"...), nil
 	}
 	return append(buf, "false"...), nil
 }
 
-func yamlIntEnc(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+func yamlIntEnc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 	return strconv.AppendInt(buf, rv.Int(), 10), nil
 }
 
-func yamlUintEnc(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+func yamlUintEnc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 	return strconv.AppendUint(buf, rv.Uint(), 10), nil
 }
 
-func yamlFloat32Enc(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
-	return strconv.AppendFloat(buf, rv.Float(), 'g', -1, 32), nil
+func yamlFloat32Enc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return e.appendFloat(buf, rv.Float(), 32)
 }
 
-func yamlFloat64Enc(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
-	return strconv.AppendFloat(buf, rv.Float(), 'g', -1, 64), nil
+func yamlFloat64Enc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return e.appendFloat(buf, rv.Float(), 64)
 }
 
-func yamlStringEnc(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
-	s := rv.String()
-	if needsQuotingFast(s) {
-		buf = append(buf, '"')
-		buf = appendEscapedYAMLString(buf, s)
-		buf = append(buf, '"')
-	} else {
-		buf = append(buf, s...)
-	}
-	return buf, nil
+func yamlStringEnc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return e.appendString(buf, rv.String(), false), nil
 }
 
 // ================================
 // Marshaler Interface Encoders
 // ================================
 
-func yamlMarshalerEnc(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+// Marshaler values are written where a scalar would go, right after "key: "
+// or "- ". appendMarshaled moves block mappings and sequences onto their own
+// lines below that point.
+
+func yamlMarshalerEnc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 	if rv.Kind() == reflect.Ptr && rv.IsNil() {
 		return append(buf, "null"...), nil
 	}
-	m := rv.Interface().(Marshaler)
-	b, err := m.MarshalYAML()
+	b, err := e.marshalerYAML(rv.Interface())
 	if err != nil {
 		return buf, err
 	}
-	return append(buf, b...), nil
+	return e.appendMarshaled(buf, b, indent), nil
 }
 
 func buildYAMLAddrMarshalerEnc(t reflect.Type) yamlEncoderFunc {
 	// Fallback encoder for when we can't take address
 	fallback := buildYAMLEncoderNoMarshaler(t)
-	return func(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 		if rv.CanAddr() {
-			m := rv.Addr().Interface().(Marshaler)
-			b, err := m.MarshalYAML()
+			b, err := e.marshalerYAML(rv.Addr().Interface())
 			if err != nil {
 				return buf, err
 			}
-			return append(buf, b...), nil
+			return e.appendMarshaled(buf, b, indent), nil
+		}
+		return fallback(e, buf, rv, indent)
+	}
+}
+
+// appendMarshaled writes MarshalYAML output b as the value of an entry at
+// indent. A scalar stays inline, with any continuation lines (as in a block
+// scalar) indented one level; a block collection starts on the next line.
+func (e *encodeState) appendMarshaled(buf, b []byte, indent int) []byte {
+	b = bytes.TrimRight(b, "\n")
+	block := isBlockCollection(b)
+	mark := len(buf)
+	for i, line := range bytes.Split(b, []byte{'\n'}) {
+		if i > 0 || block {
+			buf = append(buf, '\n')
+			if len(line) > 0 {
+				buf = e.appendIndent(buf, indent+1)
+			}
+		}
+		buf = append(buf, line...)
+	}
+	if block {
+		buf = e.compactItem(buf, mark, indent)
+	}
+	return buf
+}
+
+// compactItem moves the first line of the block collection written at
+// buf[mark:], one level below a sequence entry at indent, up onto the line
+// of the entry's "- ", as in "- name: web", when e.compact is set. Padding
+// after the dash keeps the line's content in the column of the lines below
+// it, so an indent of less than two spaces, which has no room for "- ",
+// leaves buf as it is, as does an anchor or a mapping key before mark.
+func (e *encodeState) compactItem(buf []byte, mark, indent int) []byte {
+	if !e.compact || e.indent < 2 || !bytes.HasSuffix(buf[:mark], []byte("- ")) {
+		return buf
+	}
+	n := 1 + (indent+1)*e.indent // the newline and the indentation below
+	if len(buf) <= mark+n || buf[mark] != '\n' || buf[mark+n] == ' ' {
+		return buf
+	}
+	for _, c := range buf[mark+1 : mark+n] {
+		if c != ' ' {
+			return buf
 		}
-		return fallback(buf, rv, indent)
 	}
+	pad := e.indent - 2
+	copy(buf[mark+pad:], buf[mark+n:])
+	for i := mark; i < mark+pad; i++ {
+		buf[i] = ' '
+	}
+	return buf[:len(buf)-n+pad]
+}
+
+// isBlockCollection reports whether the first line of b opens a block
+// sequence ("- a") or a block mapping ("a: 1").
+func isBlockCollection(b []byte) bool {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	b = bytes.TrimRight(b, " ")
+	if len(b) == 0 {
+		return false
+	}
+	if b[0] == '-' {
+		return len(b) == 1 || b[1] == ' '
+	}
+	switch b[0] {
+	case '{', '[', '"', '\'', '|', '>', '#', '!', '&', '*':
+		return false
+	}
+	if i := bytes.Index(b, []byte(" #")); i >= 0 {
+		b = bytes.TrimRight(b[:i], " ")
+	}
+	return b[len(b)-1] == ':' || bytes.Contains(b, []byte(": "))
 }
 
 // buildYAMLEncoderNoMarshaler builds an encoder skipping the Marshaler check.
@@ -253,21 +303,21 @@ func buildYAMLEncoderNoMarshaler(t reflect.Type) yamlEncoderFunc {
 
 func buildYAMLPtrEncoder(t reflect.Type) yamlEncoderFunc {
 	elemEnc := yamlEncoderForType(t.Elem())
-	return func(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 		if rv.IsNil() {
 			return append(buf, "null"...), nil
 		}
-		return elemEnc(buf, rv.Elem(), indent)
+		return elemEnc(e, buf, rv.Elem(), indent)
 	}
 }
 
-func yamlInterfaceEnc(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+func yamlInterfaceEnc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 	if rv.IsNil() {
 		return append(buf, "null"...), nil
 	}
 	elem := rv.Elem()
 	enc := yamlEncoderForType(elem.Type())
-	return enc(buf, elem, indent)
+	return enc(e, buf, elem, indent)
 }
 
 // ================================
@@ -285,10 +335,16 @@ type yamlStructField struct {
 }
 
 // isComplexKind checks if a type is complex (struct/map/slice/array) after dereferencing pointers.
+// Types implementing Marshaler lay out their own output (see appendMarshaled).
+// Those that only do so through a pointer receiver keep their kind, since
+// isComplexType must check at runtime whether the value is addressable.
 func isComplexKind(t reflect.Type) bool {
 	for t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
+	if isMarshaler(t) || t == timeType || isBinary(t) {
+		return false
+	}
 	k := t.Kind()
 	return k == reflect.Struct || k == reflect.Map || k == reflect.Slice || k == reflect.Array
 }
@@ -334,7 +390,7 @@ func buildYAMLStructEncoder(t reflect.Type) yamlEncoderFunc {
 		return string(fields[i].keyBytes) < string(fields[j].keyBytes)
 	})
 
-	return func(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 		first := true
 		for i := range fields {
 			f := &fields[i]
@@ -350,7 +406,7 @@ func buildYAMLStructEncoder(t reflect.Type) yamlEncoderFunc {
 			first = false
 
 			// Write indentation
-			buf = appendIndent(buf, indent)
+			buf = e.appendIndent(buf, indent)
 
 			// Write key
 			buf = append(buf, f.keyBytes...)
@@ -358,21 +414,27 @@ func buildYAMLStructEncoder(t reflect.Type) yamlEncoderFunc {
 			// For complex types (struct/map/slice/array), we need to check the actual
 			// runtime value in case it's behind a pointer or interface that might be nil
 			complex := f.isComplex
-			if !complex && (fv.Kind() == reflect.Interface || fv.Kind() == reflect.Ptr) {
+			if complex || fv.Kind() == reflect.Interface || fv.Kind() == reflect.Ptr {
 				// Check the runtime value
-				complex = isComplexType(fv)
+				complex = e.isBlock(fv)
+			}
+			if e.anchors != nil {
+				var alias bool
+				if buf, alias = e.appendAnchor(buf, fv, complex); alias {
+					continue
+				}
 			}
 
 			if complex {
 				buf = append(buf, '\n')
 				var err error
-				buf, err = f.encoder(buf, fv, indent+1)
+				buf, err = f.encoder(e, buf, fv, indent+1)
 				if err != nil {
 					return buf, err
 				}
 			} else {
 				var err error
-				buf, err = f.encoder(buf, fv, indent)
+				buf, err = f.encoder(e, buf, fv, indent)
 				if err != nil {
 					return buf, err
 				}
@@ -384,6 +446,9 @@ func buildYAMLStructEncoder(t reflect.Type) yamlEncoderFunc {
 
 // yamlEmptyFuncForKind returns a specialized empty checker for the given type.
 func yamlEmptyFuncForKind(t reflect.Type) func(reflect.Value) bool {
+	if t == timeType {
+		return isZeroTime
+	}
 	switch t.Kind() {
 	case reflect.Bool:
 		return func(v reflect.Value) bool { return !v.Bool() }
@@ -419,7 +484,7 @@ var yamlMapKVPool = sync.Pool{}
 
 func buildYAMLMapEncoder(t reflect.Type) yamlEncoderFunc {
 	if t.Key().Kind() != reflect.String {
-		return func(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+		return func(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 			return buf, fmt.Errorf("yaml: unsupported map key type %s", t.Key())
 		}
 	}
@@ -427,15 +492,17 @@ func buildYAMLMapEncoder(t reflect.Type) yamlEncoderFunc {
 	valIsComplex := isComplexKind(t.Elem())
 	valIsInterface := t.Elem().Kind() == reflect.Interface
 
-	return func(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 		if rv.IsNil() {
 			return append(buf, "null"...), nil
 		}
 
 		n := rv.Len()
 		if n == 0 {
-			// Empty map: produce empty output (consistent with old behavior)
-			return buf, nil
+			return append(buf, "{}"...), nil
+		}
+		if e.flows(rv) {
+			return e.appendFlow(buf, rv)
 		}
 
 		// Get or create a kv slice from pool
@@ -462,7 +529,7 @@ func buildYAMLMapEncoder(t reflect.Type) yamlEncoderFunc {
 			}
 
 			// Write indentation
-			buf = appendIndent(buf, indent)
+			buf = e.appendIndent(buf, indent)
 
 			// Write key
 			buf = append(buf, pairs[i].key...)
@@ -470,14 +537,20 @@ func buildYAMLMapEncoder(t reflect.Type) yamlEncoderFunc {
 
 			// Determine if value is complex
 			complex := valIsComplex
-			if valIsInterface {
-				complex = isComplexType(pairs[i].val)
+			if complex || valIsInterface {
+				complex = e.isBlock(pairs[i].val)
+			}
+			if e.anchors != nil {
+				var alias bool
+				if buf, alias = e.appendAnchor(buf, pairs[i].val, complex); alias {
+					continue
+				}
 			}
 
 			if complex {
 				buf = append(buf, '\n')
 				var err error
-				buf, err = valEnc(buf, pairs[i].val, indent+1)
+				buf, err = valEnc(e, buf, pairs[i].val, indent+1)
 				if err != nil {
 					for j := range pairs {
 						pairs[j].val = reflect.Value{}
@@ -487,7 +560,7 @@ func buildYAMLMapEncoder(t reflect.Type) yamlEncoderFunc {
 				}
 			} else {
 				var err error
-				buf, err = valEnc(buf, pairs[i].val, indent)
+				buf, err = valEnc(e, buf, pairs[i].val, indent)
 				if err != nil {
 					for j := range pairs {
 						pairs[j].val = reflect.Value{}
@@ -517,19 +590,25 @@ func buildYAMLSliceEncoder(t reflect.Type) yamlEncoderFunc {
 	elemIsComplex := isComplexKind(t.Elem())
 	elemIsInterface := t.Elem().Kind() == reflect.Interface
 
-	return func(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 		if rv.IsNil() {
 			return append(buf, "null"...), nil
 		}
 
 		n := rv.Len()
+		if n == 0 {
+			return append(buf, "[]"...), nil
+		}
+		if e.flows(rv) {
+			return e.appendFlow(buf, rv)
+		}
 		for i := 0; i < n; i++ {
 			if i > 0 {
 				buf = append(buf, '\n')
 			}
 
 			// Write indentation
-			buf = appendIndent(buf, indent)
+			buf = e.appendIndent(buf, indent)
 
 			// Write dash
 			buf = append(buf, '-', ' ')
@@ -538,20 +617,28 @@ func buildYAMLSliceEncoder(t reflect.Type) yamlEncoderFunc {
 
 			// Determine if element is complex
 			complex := elemIsComplex
-			if elemIsInterface {
-				complex = isComplexType(elem)
+			if complex || elemIsInterface {
+				complex = e.isBlock(elem)
+			}
+			if e.anchors != nil {
+				var alias bool
+				if buf, alias = e.appendAnchor(buf, elem, complex); alias {
+					continue
+				}
 			}
 
 			if complex {
+				mark := len(buf)
 				buf = append(buf, '\n')
 				var err error
-				buf, err = elemEnc(buf, elem, indent+1)
+				buf, err = elemEnc(e, buf, elem, indent+1)
 				if err != nil {
 					return buf, err
 				}
+				buf = e.compactItem(buf, mark, indent)
 			} else {
 				var err error
-				buf, err = elemEnc(buf, elem, indent)
+				buf, err = elemEnc(e, buf, elem, indent)
 				if err != nil {
 					return buf, err
 				}
@@ -566,15 +653,21 @@ func buildYAMLArrayEncoder(t reflect.Type) yamlEncoderFunc {
 	elemIsComplex := isComplexKind(t.Elem())
 	elemIsInterface := t.Elem().Kind() == reflect.Interface
 
-	return func(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 		n := rv.Len()
+		if n == 0 {
+			return append(buf, "[]"...), nil
+		}
+		if e.flows(rv) {
+			return e.appendFlow(buf, rv)
+		}
 		for i := 0; i < n; i++ {
 			if i > 0 {
 				buf = append(buf, '\n')
 			}
 
 			// Write indentation
-			buf = appendIndent(buf, indent)
+			buf = e.appendIndent(buf, indent)
 
 			// Write dash
 			buf = append(buf, '-', ' ')
@@ -583,20 +676,28 @@ func buildYAMLArrayEncoder(t reflect.Type) yamlEncoderFunc {
 
 			// Determine if element is complex
 			complex := elemIsComplex
-			if elemIsInterface {
-				complex = isComplexType(elem)
+			if complex || elemIsInterface {
+				complex = e.isBlock(elem)
+			}
+			if e.anchors != nil {
+				var alias bool
+				if buf, alias = e.appendAnchor(buf, elem, complex); alias {
+					continue
+				}
 			}
 
 			if complex {
+				mark := len(buf)
 				buf = append(buf, '\n')
 				var err error
-				buf, err = elemEnc(buf, elem, indent+1)
+				buf, err = elemEnc(e, buf, elem, indent+1)
 				if err != nil {
 					return buf, err
 				}
+				buf = e.compactItem(buf, mark, indent)
 			} else {
 				var err error
-				buf, err = elemEnc(buf, elem, indent)
+				buf, err = elemEnc(e, buf, elem, indent)
 				if err != nil {
 					return buf, err
 				}
@@ -611,7 +712,7 @@ func buildYAMLArrayEncoder(t reflect.Type) yamlEncoderFunc {
 // ================================
 
 func yamlUnsupportedEnc(t reflect.Type) yamlEncoderFunc {
-	return func(buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
 		return buf, fmt.Errorf("yaml: unsupported type %s", t)
 	}
 }
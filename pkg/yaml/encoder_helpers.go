@@ -1,9 +1,14 @@
 package yaml
 
-import "strconv"
+import (
+	"strconv"
+
+	"github.com/shapestone/shape-yaml/internal/resolve"
+)
 
 // appendEscapedYAMLString appends a YAML-escaped string to buf (without surrounding quotes).
-// Zero-allocation: writes directly to provided buffer.
+// Control characters are written as escapes, since a double-quoted scalar
+// cannot hold them raw. Zero-allocation: writes directly to provided buffer.
 func appendEscapedYAMLString(buf []byte, s string) []byte {
 	start := 0
 	for i := 0; i < len(s); i++ {
@@ -20,27 +25,51 @@ func appendEscapedYAMLString(buf []byte, s string) []byte {
 			esc = 'r'
 		case '\t':
 			esc = 't'
+		case 0:
+			esc = '0'
+		case '\a':
+			esc = 'a'
+		case '\b':
+			esc = 'b'
+		case '\v':
+			esc = 'v'
+		case '\f':
+			esc = 'f'
+		case 0x1b:
+			esc = 'e'
 		default:
-			continue
+			if c >= 0x20 && c != 0x7f {
+				continue
+			}
+			esc = 'x'
 		}
 		buf = append(buf, s[start:i]...)
 		buf = append(buf, '\\', esc)
+		if esc == 'x' {
+			buf = append(buf, hexDigits[c>>4], hexDigits[c&0xf])
+		}
 		start = i + 1
 	}
 	buf = append(buf, s[start:]...)
 	return buf
 }
 
+// hexDigits are the digits of the \xXX escapes.
+const hexDigits = "0123456789ABCDEF"
+
 // needsQuotingFast checks if a YAML string needs quoting, operating on the string directly.
-// This is the zero-alloc version of needsQuoting.
+// A string needs quoting when, written plain, it would read back as another
+// value or not at all: it resolves to null, a bool or a number, it holds a
+// YAML indicator or a control character such as a line break, it starts
+// with an indicator that begins an alias, anchor, tag or directive, or it
+// starts or ends with a space, which a plain scalar would lose.
 func needsQuotingFast(s string) bool {
 	if len(s) == 0 {
 		return true
 	}
 
-	// Special YAML values
-	switch s {
-	case "true", "false", "yes", "no", "null", "~":
+	// Values the decoder would resolve to null, bool, or a number
+	if isResolvedPlain(s) {
 		return true
 	}
 
@@ -56,14 +85,32 @@ func needsQuotingFast(s string) bool {
 		case ':', '#', '@', '`', '"', '\'', '{', '}', '[', ']', '|', '>', '-':
 			return true
 		}
+		if c < 0x20 || c == 0x7f {
+			return true
+		}
 	}
 
 	// Starts with special characters
-	if s[0] == ' ' || s[0] == '-' || s[0] == '?' {
+	switch s[0] {
+	case ' ', '-', '?', '*', '&', '!', '%', ',':
 		return true
 	}
 
-	return false
+	// Trailing spaces would be trimmed
+	return s[len(s)-1] == ' '
+}
+
+// isResolvedPlain reports whether s, written as a plain scalar, would decode
+// as something other than the string s (No, Off, Null, .inf, 0x1F, ...).
+func isResolvedPlain(s string) bool {
+	if resolve.IsNull(s) {
+		return true
+	}
+	if _, ok := resolve.Bool(s); ok {
+		return true
+	}
+	_, ok := resolve.Number(s)
+	return ok
 }
 
 // sortYAMLStrings sorts a string slice in-place using insertion sort.
@@ -0,0 +1,142 @@
+package yaml
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+// EqualOptions configures EqualWithOptions. The zero value compares as
+// Equal does.
+type EqualOptions struct {
+	// KeyOrder requires the keys of each mapping to be in the same order.
+	KeyOrder bool
+
+	// NumericTolerance, if positive, makes two numbers equal when they
+	// differ by at most NumericTolerance, whether written as integers or
+	// floats, so that 3 equals 3.0 and 0.1 equals 0.1000001.
+	NumericTolerance float64
+}
+
+// Equal reports whether YAML documents a and b hold the same data, for
+// tests and for controllers deciding whether a resource needs updating.
+// Only the values are compared, so comments, quoting, flow or block style,
+// anchors, and the order of mapping keys make no difference: "a: 1\nb: [x]"
+// equals "{b: [\"x\"], a: 1}". Scalars are equal if they resolve to the same
+// value of the same type, so 3 and "3" differ, as do 3 and 3.0.
+//
+// Example:
+//
+//	same, err := yaml.Equal(live, desired)
+//	if err == nil && !same {
+//	    // apply desired
+//	}
+func Equal(a, b []byte) (bool, error) {
+	return EqualWithOptions(a, b, EqualOptions{})
+}
+
+// EqualWithOptions is like Equal, with the comparison configured by opts.
+func EqualWithOptions(a, b []byte, opts EqualOptions) (bool, error) {
+	na, orderA, err := ParseWithKeyOrder(string(a))
+	if err != nil {
+		return false, fmt.Errorf("yaml: first document: %w", err)
+	}
+	defer ReleaseTree(na)
+	nb, orderB, err := ParseWithKeyOrder(string(b))
+	if err != nil {
+		return false, fmt.Errorf("yaml: second document: %w", err)
+	}
+	defer ReleaseTree(nb)
+
+	c := &comparison{opts: opts, orderA: orderA, orderB: orderB}
+	return c.equal(na, nb), nil
+}
+
+// comparison compares the nodes of two parsed documents.
+type comparison struct {
+	opts           EqualOptions
+	orderA, orderB KeyOrder
+}
+
+func (c *comparison) equal(a, b ast.SchemaNode) bool {
+	switch x := a.(type) {
+	case *ast.ObjectNode:
+		y, ok := b.(*ast.ObjectNode)
+		if !ok {
+			return false
+		}
+		px, py := x.Properties(), y.Properties()
+		if len(px) != len(py) {
+			return false
+		}
+		if c.opts.KeyOrder {
+			kx, ky := c.orderA.Keys(x), c.orderB.Keys(y)
+			for i := range kx {
+				if kx[i] != ky[i] {
+					return false
+				}
+			}
+		}
+		for k, vx := range px {
+			vy, ok := py[k]
+			if !ok || !c.equal(vx, vy) {
+				return false
+			}
+		}
+		return true
+
+	case *ast.ArrayDataNode:
+		y, ok := b.(*ast.ArrayDataNode)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+		ey := y.Elements()
+		for i, ex := range x.Elements() {
+			if !c.equal(ex, ey[i]) {
+				return false
+			}
+		}
+		return true
+
+	case *ast.LiteralNode:
+		y, ok := b.(*ast.LiteralNode)
+		return ok && c.equalScalars(x.Value(), y.Value())
+	}
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+func (c *comparison) equalScalars(x, y interface{}) bool {
+	if c.opts.NumericTolerance > 0 {
+		fx, okx := numericValue(x)
+		fy, oky := numericValue(y)
+		if okx && oky {
+			return math.Abs(fx-fy) <= c.opts.NumericTolerance ||
+				math.IsNaN(fx) && math.IsNaN(fy) || fx == fy // NaN, and infinities of one sign
+		}
+	}
+	switch vx := x.(type) {
+	case float64:
+		vy, ok := y.(float64)
+		return ok && (vx == vy || math.IsNaN(vx) && math.IsNaN(vy))
+	case time.Time:
+		vy, ok := y.(time.Time)
+		return ok && vx.Equal(vy)
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// numericValue returns x as a float64 if it is a number.
+func numericValue(x interface{}) (float64, bool) {
+	switch v := x.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
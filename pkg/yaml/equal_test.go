@@ -0,0 +1,85 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"identical", "a: 1\nb: x\n", "a: 1\nb: x\n", true},
+		{"key order", "a: 1\nb: [x, y]\n", "b: [\"x\", 'y']\na: 1\n", true},
+		{"comments and style", "# config\nname: web # inline\nports:\n  - 80\n", "{name: \"web\", ports: [80]}", true},
+		{"anchors", "base: &b {x: 1}\nuse: *b\n", "base: {x: 1}\nuse: {x: 1}\n", true},
+		{"merge", "base: &b {x: 1}\nuse:\n  <<: *b\n  y: 2\n", "base: {x: 1}\nuse: {y: 2, x: 1}\n", true},
+		{"nested", "a:\n  b:\n    c: [1, {d: e}]\n", "a: {b: {c: [1, {d: e}]}}", true},
+		{"nan", "x: .nan\n", "x: .NaN\n", true},
+		{"tagged timestamps", "t: !!timestamp 2024-01-02T03:04:05Z\n", "t: !!timestamp 2024-01-02T04:04:05+01:00\n", true},
+		{"untagged timestamps", "t: 2024-01-02T03:04:05Z\n", "t: 2024-01-02T04:04:05+01:00\n", false},
+		{"different value", "a: 1\n", "a: 2\n", false},
+		{"int and string", "a: 3\n", "a: \"3\"\n", false},
+		{"int and float", "a: 3\n", "a: 3.0\n", false},
+		{"missing key", "a: 1\nb: 2\n", "a: 1\n", false},
+		{"renamed key", "a: 1\n", "b: 1\n", false},
+		{"sequence order", "a: [1, 2]\n", "a: [2, 1]\n", false},
+		{"sequence length", "a: [1, 2]\n", "a: [1]\n", false},
+		{"mapping and sequence", "a: {x: 1}\n", "a: [1]\n", false},
+		{"null and empty string", "a: null\n", "a: \"\"\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Equal([]byte(tt.a), []byte(tt.b))
+			if err != nil {
+				t.Fatalf("Equal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if back, _ := Equal([]byte(tt.b), []byte(tt.a)); back != got {
+				t.Errorf("Equal() is not symmetric for %q and %q", tt.a, tt.b)
+			}
+		})
+	}
+}
+
+func TestEqualWithOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		opts EqualOptions
+		want bool
+	}{
+		{"key order kept", "a: 1\nb: 2\n", "a: 1\nb: 2\n", EqualOptions{KeyOrder: true}, true},
+		{"key order differs", "a: 1\nb: 2\n", "b: 2\na: 1\n", EqualOptions{KeyOrder: true}, false},
+		{"nested key order", "x: {a: 1, b: 2}\n", "x: {b: 2, a: 1}\n", EqualOptions{KeyOrder: true}, false},
+		{"within tolerance", "cpu: 0.5\n", "cpu: 0.5000001\n", EqualOptions{NumericTolerance: 1e-6}, true},
+		{"outside tolerance", "cpu: 0.5\n", "cpu: 0.51\n", EqualOptions{NumericTolerance: 1e-6}, false},
+		{"int and float within tolerance", "replicas: 3\n", "replicas: 3.0\n", EqualOptions{NumericTolerance: 1e-9}, true},
+		{"infinity", "x: .inf\n", "x: .inf\n", EqualOptions{NumericTolerance: 1}, true},
+		{"number and string", "x: 1\n", "x: \"1\"\n", EqualOptions{NumericTolerance: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EqualWithOptions([]byte(tt.a), []byte(tt.b), tt.opts)
+			if err != nil {
+				t.Fatalf("EqualWithOptions() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EqualWithOptions(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqual_InvalidDocument(t *testing.T) {
+	if _, err := Equal([]byte("a: [1"), []byte("a: 1")); err == nil || !strings.Contains(err.Error(), "first document") {
+		t.Errorf("Equal() error = %v, want a first document error", err)
+	}
+	if _, err := Equal([]byte("a: 1"), []byte("a: [1")); err == nil || !strings.Contains(err.Error(), "second document") {
+		t.Errorf("Equal() error = %v, want a second document error", err)
+	}
+}
@@ -10,6 +10,7 @@ type fieldInfo struct {
 	name      string
 	skip      bool
 	omitEmpty bool
+	required  bool
 }
 
 // getFieldInfo extracts field information from a struct field tag
@@ -29,8 +30,9 @@ func getFieldInfo(field reflect.StructField) fieldInfo {
 	parts := strings.Split(tag, ",")
 	name := parts[0]
 
-	// Check for "-" (skip field)
-	if name == "-" {
+	// Check for "-" (skip field). As with encoding/json, "-," names the
+	// field with the literal key "-" instead.
+	if tag == "-" {
 		return fieldInfo{
 			name:      "",
 			skip:      true,
@@ -44,10 +46,13 @@ func getFieldInfo(field reflect.StructField) fieldInfo {
 	}
 
 	// Check for options
-	omitEmpty := false
+	omitEmpty, required := false, false
 	for i := 1; i < len(parts); i++ {
-		if parts[i] == "omitempty" {
+		switch parts[i] {
+		case "omitempty":
 			omitEmpty = true
+		case "required":
+			required = true
 		}
 	}
 
@@ -55,11 +60,15 @@ func getFieldInfo(field reflect.StructField) fieldInfo {
 		name:      name,
 		skip:      false,
 		omitEmpty: omitEmpty,
+		required:  required,
 	}
 }
 
 // isEmptyValue checks if a reflect.Value is considered empty
 func isEmptyValue(rv reflect.Value) bool {
+	if rv.Type() == timeType {
+		return isZeroTime(rv)
+	}
 	switch rv.Kind() {
 	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
 		return rv.Len() == 0
@@ -0,0 +1,163 @@
+package yaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// jsonCorpus is valid JSON text that must decode as encoding/json decodes it,
+// since YAML 1.2 is a superset of JSON.
+var jsonCorpus = []string{
+	// Scalars at the root
+	`"str"`, `1`, `-1`, `0`, `-0`, `1.5`, `-0.5`, `0.0`,
+	`1e10`, `1E10`, `1e-5`, `-1.5E+3`,
+	`true`, `false`, `null`,
+	`{}`, `[]`, `{ }`, `[ ]`, "{\n}", "[\n]",
+
+	// Whitespace around the root value
+	` {"a":1}`, "\t{\"a\":\t1}", "\n\t[1]\t\n", ` "x"`, "\r\n{\"a\":1}\r\n",
+
+	// Mappings and sequences
+	`{"a":1}`, `{"a":1,"b":2}`, `[1,2,3]`,
+	`{"a" : 1 , "b" : [ 1 , 2 ] }`,
+	`{"k":"v"  }`,
+	`{"a":[]}`, `{"a":{}}`, `[[],{}]`, `[{"a":[{}]}]`,
+	`["a",1,true,null,{"b":[]}]`,
+	`["a","b",["c","d"],{"e":"f"}]`,
+	`{"a":[1,2,3],"b":{"c":"d"},"e":"f"}`,
+	`{"a":{"b":{"c":[1,{"d":[2,[3,[4]]]}]}}}`,
+
+	// Multi-line layouts, as written by json.MarshalIndent and by hand
+	"{\n  \"a\": 1,\n  \"b\": [\n    1,\n    2\n  ],\n  \"c\": {\n    \"d\": null\n  }\n}",
+	"[\n  {\"x\": 1},\n  {\"y\": 2}\n]",
+	"{\"a\":\"b\",\n\"c\":\"d\"}",
+	"{\"a\": [1,\n2,\n    3], \"b\": {\"c\":\n1}}",
+	"[\n\t1,\n\t{\n\t\t\"a\": [\n\t\t\ttrue\n\t\t]\n\t}\n]",
+
+	// Keys and strings
+	`{"":1}`, `{"a":""}`, `[""]`, `{"a b":"c d"}`,
+	`{"1":1,"2":2}`, `{"true":1,"null":2}`,
+	`{"yes":"yes","no":"no","on":"on"}`,
+	`{"a":"~"}`, `{"a":"null"}`, `{"a":"true"}`, `{"a":"123"}`,
+	`{"a":"b:c","d":"e, f","g":"#h","i":"- j","k":"[l]","m":"{n}"}`,
+	`{"x":"'single'"}`, `{"x":"it's"}`,
+	`{"url":"http://example.com/a?b=c#d"}`,
+	`{"a":"line1\nline2"}`,
+
+	// Escapes and unicode
+	`{"esc":"a\"b\\c\/d\b\f\n\r\t"}`,
+	`{"a":"\\"}`, `{"a":"\\\\"}`, `{"a":"\/"}`,
+	`{"key":"value with \"quotes\""}`,
+	`{"unicode":"\u00e9\u4e2d\ud83d\ude00"}`,
+	`{"\u0041":"\u0042"}`,
+	`["\u0000"]`,
+	`{"emoji":"😀","tab":"\t"}`,
+
+	// Numbers
+	`[1,-1,0.5,-0.5,1e2,1E-2,-0e0]`,
+	`[1.5e+10, 2.5E-3]`,
+	`{"a":1.0}`, `{"a":10.50}`,
+	`{"n":9223372036854775807}`, `{"n":-9223372036854775808}`,
+	`{"n":12345678901234567890}`, `{"n":123456789012345678901234}`,
+	`{"a":1e400}`, `{"a":-1e400}`, `{"a":5e-324}`, `{"a":1e-400}`,
+}
+
+// TestJSONCompat checks that every decoder reads the JSON corpus as
+// encoding/json does.
+func TestJSONCompat(t *testing.T) {
+	inputs := append(append([]string{}, jsonCorpus...), nestedJSON(50), wideJSON(500))
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		for _, in := range inputs {
+			if !json.Valid([]byte(in)) {
+				t.Fatalf("corpus entry is not valid JSON: %q", in)
+			}
+			want := decodeJSON(t, in)
+
+			var got interface{}
+			if err := decode([]byte(in), &got); err != nil {
+				t.Errorf("%.60q: %v", in, err)
+				continue
+			}
+			if !parityEqual(got, want) {
+				t.Errorf("%.60q:\n got  %#v\n want %#v", in, got, want)
+			}
+		}
+	})
+}
+
+// nestedJSON returns mappings and sequences nested depth levels deep.
+func nestedJSON(depth int) string {
+	var b strings.Builder
+	for i := 0; i < depth; i++ {
+		if i%2 == 0 {
+			fmt.Fprintf(&b, "{\"k%d\": ", i)
+		} else {
+			b.WriteString("[0, ")
+		}
+	}
+	b.WriteString(`"leaf"`)
+	for i := depth - 1; i >= 0; i-- {
+		if i%2 == 0 {
+			b.WriteByte('}')
+		} else {
+			b.WriteByte(']')
+		}
+	}
+	return b.String()
+}
+
+// wideJSON returns an indented sequence of n small mappings.
+func wideJSON(n int) string {
+	items := make([]map[string]interface{}, n)
+	for i := range items {
+		items[i] = map[string]interface{}{
+			"id":    i,
+			"name":  fmt.Sprintf("item \"%d\"", i),
+			"price": float64(i) / 4,
+			"tags":  []string{"a", "b"},
+			"next":  nil,
+		}
+	}
+	b, _ := json.MarshalIndent(items, "", "  ")
+	return string(b)
+}
+
+// decodeJSON decodes s with encoding/json, with numbers as the int64, uint64
+// and float64 values YAML resolves them to.
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		t.Fatalf("encoding/json: %v", err)
+	}
+	return jsonNumbers(v)
+}
+
+func jsonNumbers(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		s := string(x)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u
+		}
+		f, _ := strconv.ParseFloat(s, 64)
+		return f
+	case []interface{}:
+		for i := range x {
+			x[i] = jsonNumbers(x[i])
+		}
+	case map[string]interface{}:
+		for k := range x {
+			x[k] = jsonNumbers(x[k])
+		}
+	}
+	return v
+}
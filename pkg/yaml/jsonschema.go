@@ -0,0 +1,275 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-yaml/internal/fastparser"
+)
+
+// JSONSchemaDraft is the $schema URI written by JSONSchema.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema generates a JSON Schema describing the YAML documents that
+// Unmarshal accepts for v's type. v is typically a zero value or nil pointer:
+//
+//	schema, err := yaml.JSONSchema((*Config)(nil))
+//
+// Properties use the same key names as Marshal and Unmarshal. A field is
+// required unless it is tagged omitempty; untagged embedded structs contribute
+// their fields to the outer object, and a promoted name that Unmarshal treats
+// as ambiguous is left out. Named struct types other than the root are
+// written once under $defs and referenced with $ref, so recursive types are
+// supported.
+//
+// The jsonschema struct tag refines the generated schema:
+//
+//	type Config struct {
+//	    Mode    string `yaml:"mode" jsonschema:"enum=dev|prod"`
+//	    Port    int    `yaml:"port,omitempty" jsonschema:"required"`
+//	    Comment string `yaml:"comment" jsonschema:"optional"`
+//	}
+//
+// Options are comma-separated: "required" and "optional" override the
+// omitempty rule, and "enum=" lists the allowed values separated by "|".
+func JSONSchema(v interface{}) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, errors.New("yaml: JSONSchema of nil")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	g := &schemaGenerator{
+		defs:     map[string]*jsonSchema{},
+		defNames: map[reflect.Type]string{},
+	}
+	root, err := g.schemaFor(t, true)
+	if err != nil {
+		return nil, err
+	}
+	root.Schema = JSONSchemaDraft
+	if len(g.defs) > 0 {
+		root.Defs = g.defs
+	}
+
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// jsonSchema is the subset of JSON Schema produced by JSONSchema.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Properties           schemaProperties       `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// schemaProperty is a named property of an object schema.
+type schemaProperty struct {
+	name   string
+	schema *jsonSchema
+}
+
+// schemaProperties keeps properties in struct field order when encoded.
+type schemaProperties []schemaProperty
+
+func (p schemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(prop.name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(prop.schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type schemaGenerator struct {
+	defs     map[string]*jsonSchema
+	defNames map[reflect.Type]string
+}
+
+// schemaFor returns the schema for t. Named structs are moved to $defs unless
+// t is the root type.
+func (g *schemaGenerator) schemaFor(t reflect.Type, root bool) (*jsonSchema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == mapSliceType {
+		return &jsonSchema{Type: "object"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &jsonSchema{Type: "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		zero := 0
+		return &jsonSchema{Type: "integer", Minimum: &zero}, nil
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}, nil
+	case reflect.String:
+		return &jsonSchema{Type: "string"}, nil
+	case reflect.Interface:
+		return &jsonSchema{}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := g.schemaFor(t.Elem(), false)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("yaml: unsupported map key type %s", t.Key())
+		}
+		values, err := g.schemaFor(t.Elem(), false)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if root || t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.structRef(t)
+	default:
+		return nil, fmt.Errorf("yaml: JSONSchema: unsupported type %s", t)
+	}
+}
+
+// structRef returns a $ref to the definition of the named struct t, adding the
+// definition on first use.
+func (g *schemaGenerator) structRef(t reflect.Type) (*jsonSchema, error) {
+	name, ok := g.defNames[t]
+	if !ok {
+		name = t.Name()
+		for i := 2; g.defs[name] != nil; i++ {
+			name = t.Name() + strconv.Itoa(i)
+		}
+		g.defNames[t] = name
+
+		// Register before building so recursive references resolve
+		def := &jsonSchema{}
+		g.defs[name] = def
+		s, err := g.structSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		*def = *s
+	}
+	return &jsonSchema{Ref: "#/$defs/" + name}, nil
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) (*jsonSchema, error) {
+	s := &jsonSchema{Type: "object"}
+
+	// Use the decoder's field set so the schema accepts exactly the keys
+	// Unmarshal sets; list the outer struct's fields before promoted ones.
+	fields := fastparser.Fields(t, "yaml")
+	sort.SliceStable(fields, func(i, j int) bool {
+		return len(fields[i].Index) < len(fields[j].Index)
+	})
+
+	for _, f := range fields {
+		sf := t.FieldByIndex(f.Index)
+		info := getFieldInfo(sf)
+
+		prop, err := g.schemaFor(sf.Type, false)
+		if err != nil {
+			return nil, err
+		}
+
+		required := !info.omitEmpty
+		for _, opt := range strings.Split(sf.Tag.Get("jsonschema"), ",") {
+			switch {
+			case opt == "required":
+				required = true
+			case opt == "optional":
+				required = false
+			case strings.HasPrefix(opt, "enum="):
+				enum, err := parseSchemaEnum(sf, strings.Split(opt[len("enum="):], "|"))
+				if err != nil {
+					return nil, err
+				}
+				prop = applySchemaEnum(prop, enum)
+			}
+		}
+
+		s.Properties = append(s.Properties, schemaProperty{name: info.name, schema: prop})
+		if required {
+			s.Required = append(s.Required, info.name)
+		}
+	}
+	return s, nil
+}
+
+// parseSchemaEnum converts enum tag values to the field's scalar type.
+func parseSchemaEnum(sf reflect.StructField, values []string) ([]interface{}, error) {
+	t := sf.Type
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	enum := make([]interface{}, len(values))
+	for i, v := range values {
+		var (
+			val interface{}
+			err error
+		)
+		switch t.Kind() {
+		case reflect.Bool:
+			val, err = strconv.ParseBool(v)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			val, err = strconv.ParseInt(v, 10, 64)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			val, err = strconv.ParseUint(v, 10, 64)
+		case reflect.Float32, reflect.Float64:
+			val, err = strconv.ParseFloat(v, 64)
+		default:
+			val = v
+		}
+		if err != nil {
+			return nil, fmt.Errorf("yaml: invalid enum value %q for field %s", v, sf.Name)
+		}
+		enum[i] = val
+	}
+	return enum, nil
+}
+
+// applySchemaEnum attaches enum to a scalar schema, or to the items of an
+// array schema.
+func applySchemaEnum(s *jsonSchema, enum []interface{}) *jsonSchema {
+	target := s
+	for target.Items != nil {
+		target = target.Items
+	}
+	target.Enum = enum
+	return s
+}
@@ -0,0 +1,196 @@
+package yaml
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type schemaBase struct {
+	ID      string `yaml:"id"`
+	Version int    `yaml:"version,omitempty"`
+}
+
+type schemaPort struct {
+	Name string `yaml:"name" jsonschema:"optional"`
+	Port uint16 `yaml:"port"`
+}
+
+type schemaNode struct {
+	Value    string        `yaml:"value"`
+	Children []*schemaNode `yaml:"children,omitempty"`
+}
+
+type schemaConfig struct {
+	schemaBase
+	Name     string            `yaml:"name"`
+	Mode     string            `yaml:"mode" jsonschema:"enum=dev|prod"`
+	Level    int               `yaml:"level,omitempty" jsonschema:"required,enum=1|2|3"`
+	Ratio    float64           `yaml:"ratio,omitempty"`
+	Enabled  *bool             `yaml:"enabled,omitempty"`
+	Tags     []string          `yaml:"tags,omitempty" jsonschema:"enum=a|b"`
+	Labels   map[string]string `yaml:"labels,omitempty"`
+	Ports    []schemaPort      `yaml:"ports"`
+	Primary  schemaPort        `yaml:"primary"`
+	Tree     *schemaNode       `yaml:"tree,omitempty"`
+	Extra    interface{}       `yaml:"extra,omitempty"`
+	Ordered  MapSlice          `yaml:"ordered,omitempty"`
+	Inline   struct{ X int }   `yaml:"inline"`
+	Skipped  string            `yaml:"-"`
+	Untagged bool
+	internal string
+}
+
+func TestJSONSchema(t *testing.T) {
+	got, err := JSONSchema((*schemaConfig)(nil))
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+
+	want := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "name": {"type": "string"},
+    "mode": {"type": "string", "enum": ["dev", "prod"]},
+    "level": {"type": "integer", "enum": [1, 2, 3]},
+    "ratio": {"type": "number"},
+    "enabled": {"type": "boolean"},
+    "tags": {"type": "array", "items": {"type": "string", "enum": ["a", "b"]}},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "ports": {"type": "array", "items": {"$ref": "#/$defs/schemaPort"}},
+    "primary": {"$ref": "#/$defs/schemaPort"},
+    "tree": {"$ref": "#/$defs/schemaNode"},
+    "extra": {},
+    "ordered": {"type": "object"},
+    "inline": {"type": "object", "properties": {"x": {"type": "integer"}}, "required": ["x"]},
+    "untagged": {"type": "boolean"},
+    "id": {"type": "string"},
+    "version": {"type": "integer"}
+  },
+  "required": ["name", "mode", "level", "ports", "primary", "inline", "untagged", "id"],
+  "$defs": {
+    "schemaNode": {
+      "type": "object",
+      "properties": {
+        "value": {"type": "string"},
+        "children": {"type": "array", "items": {"$ref": "#/$defs/schemaNode"}}
+      },
+      "required": ["value"]
+    },
+    "schemaPort": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"},
+        "port": {"type": "integer", "minimum": 0}
+      },
+      "required": ["port"]
+    }
+  }
+}`
+	if compactJSON(t, string(got)) != compactJSON(t, want) {
+		t.Errorf("JSONSchema() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+type schemaEmbedA struct {
+	Name  string `yaml:"name"`
+	Inner struct {
+		Deep string `yaml:"deep"`
+	} `yaml:"inner"`
+	schemaEmbedDeep
+}
+
+type schemaEmbedDeep struct {
+	Shallow int `yaml:"shallow"`
+}
+
+type schemaEmbedB struct {
+	Name    string `yaml:"name"`
+	Shallow string `yaml:"shallow"`
+}
+
+// TestJSONSchema_PromotedConflicts tests that promoted field conflicts are
+// resolved as Unmarshal resolves them: same-depth duplicates are dropped and
+// the shallower field wins regardless of embedding order.
+func TestJSONSchema_PromotedConflicts(t *testing.T) {
+	type doc struct {
+		schemaEmbedA
+		schemaEmbedB
+	}
+	got, err := JSONSchema(doc{})
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+
+	want := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "inner": {"type": "object", "properties": {"deep": {"type": "string"}}, "required": ["deep"]},
+    "shallow": {"type": "string"}
+  },
+  "required": ["inner", "shallow"]
+}`
+	if compactJSON(t, string(got)) != compactJSON(t, want) {
+		t.Errorf("JSONSchema() =\n%s\nwant:\n%s", got, want)
+	}
+
+	var v doc
+	if err := Unmarshal([]byte("name: x\nshallow: s\n"), &v); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if v.schemaEmbedA.Name != "" || v.schemaEmbedB.Name != "" || v.schemaEmbedB.Shallow != "s" {
+		t.Errorf("Unmarshal() = %+v, want only schemaEmbedB.Shallow set", v)
+	}
+}
+
+func TestJSONSchema_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr string
+	}{
+		{name: "nil", value: nil, wantErr: "JSONSchema of nil"},
+		{name: "non-string map key", value: map[int]string{}, wantErr: "unsupported map key type int"},
+		{name: "unsupported kind", value: struct{ C chan int }{}, wantErr: "unsupported type chan int"},
+		{
+			name: "bad enum value",
+			value: struct {
+				N int `jsonschema:"enum=1|two"`
+			}{},
+			wantErr: `invalid enum value "two" for field N`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := JSONSchema(tt.value)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("JSONSchema() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// compactJSON re-encodes s without changing key order, so the expected schema
+// can be written readably.
+func compactJSON(t *testing.T, s string) string {
+	t.Helper()
+	var buf strings.Builder
+	dec := json.NewDecoder(strings.NewReader(s))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		b, _ := json.Marshal(tok)
+		if d, ok := tok.(json.Delim); ok {
+			buf.WriteString(d.String())
+		} else {
+			buf.Write(b)
+		}
+		buf.WriteByte(' ')
+	}
+	return buf.String()
+}
@@ -0,0 +1,107 @@
+package yaml
+
+import (
+	"strconv"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/parser"
+)
+
+// Kind is the kind of a node in a parsed tree.
+type Kind int
+
+const (
+	InvalidKind  Kind = iota // nil, or a node type Parse does not produce
+	ScalarKind               // *ast.LiteralNode
+	MappingKind              // *ast.ObjectNode
+	SequenceKind             // *ast.ArrayDataNode
+)
+
+var kindNames = [...]string{
+	InvalidKind:  "InvalidKind",
+	ScalarKind:   "ScalarKind",
+	MappingKind:  "MappingKind",
+	SequenceKind: "SequenceKind",
+}
+
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// KindOf returns the kind of node, so that code walking a tree can switch
+// over the kinds instead of asserting ast types.
+//
+// Example:
+//
+//	switch yaml.KindOf(node) {
+//	case yaml.MappingKind:
+//	    m := yaml.NodeToInterface(node).(map[string]interface{})
+//	case yaml.SequenceKind:
+//	    s := yaml.NodeToInterface(node).([]interface{})
+//	case yaml.ScalarKind:
+//	    v := yaml.NodeToInterface(node)
+//	}
+func KindOf(node ast.SchemaNode) Kind {
+	switch node.(type) {
+	case *ast.LiteralNode:
+		return ScalarKind
+	case *ast.ObjectNode:
+		return MappingKind
+	case *ast.ArrayDataNode:
+		return SequenceKind
+	default:
+		return InvalidKind
+	}
+}
+
+// Style is the way a scalar is written in the source, as reported by
+// ParseWithStyles.
+type Style = parser.ScalarStyle
+
+const (
+	Plain        = parser.Plain        // unquoted, as in name: api
+	SingleQuoted = parser.SingleQuoted // 'api'
+	DoubleQuoted = parser.DoubleQuoted // "api"
+	Literal      = parser.Literal      // a | block scalar, keeping line breaks
+	Folded       = parser.Folded       // a > block scalar, folding line breaks
+)
+
+// Styles holds the style of every scalar of a tree returned by
+// ParseWithStyles. Styles.Of(node) returns the style of node, and Plain for
+// plain scalars and nodes that are not scalars.
+type Styles = parser.ScalarStyles
+
+// The core schema tags, as Tags.Of reports them.
+const (
+	NullTag  = parser.NullTag  // tag:yaml.org,2002:null
+	BoolTag  = parser.BoolTag  // tag:yaml.org,2002:bool
+	IntTag   = parser.IntTag   // tag:yaml.org,2002:int
+	FloatTag = parser.FloatTag // tag:yaml.org,2002:float
+	StrTag   = parser.StrTag   // tag:yaml.org,2002:str
+	MapTag   = parser.MapTag   // tag:yaml.org,2002:map
+	SeqTag   = parser.SeqTag   // tag:yaml.org,2002:seq
+)
+
+// TimestampTag is the tag of a !!timestamp scalar. Timestamps are not part
+// of the core schema: only an explicit tag makes a scalar one.
+const TimestampTag = parser.TimestampTag // tag:yaml.org,2002:timestamp
+
+// The tags of the other YAML 1.1 types decoding applies when a node is
+// written with one. A !!binary scalar is base64 and decodes to a []byte, or
+// to a string of its bytes; a !!set is a mapping of null values and decodes
+// to a slice of its members or a map of them to true; an !!omap is a
+// sequence of single-entry mappings and decodes to a MapSlice, or merged into
+// a map or struct.
+const (
+	BinaryTag = parser.BinaryTag // tag:yaml.org,2002:binary
+	SetTag    = parser.SetTag    // tag:yaml.org,2002:set
+	OmapTag   = parser.OmapTag   // tag:yaml.org,2002:omap
+)
+
+// Tags holds the explicit tags of a tree returned by ParseWithTags.
+// Tags.Of(node) returns the resolved tag of any node: its explicit tag with
+// the handle expanded, or else the core schema tag of its value.
+type Tags = parser.Tags
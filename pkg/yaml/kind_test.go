@@ -0,0 +1,125 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+func TestKindOf(t *testing.T) {
+	node, err := Parse("m: {a: 1}\ns: [1]\nv: x\nempty: []")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		want Kind
+	}{
+		{"m", MappingKind},
+		{"s", SequenceKind},
+		{"v", ScalarKind},
+		{"empty", SequenceKind},
+	}
+	for _, tt := range tests {
+		child := property(node, tt.key)
+		if got := KindOf(child); got != tt.want {
+			t.Errorf("KindOf(%s) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+	if got := KindOf(node); got != MappingKind {
+		t.Errorf("KindOf(root) = %v, want MappingKind", got)
+	}
+	if got := KindOf(nil); got != InvalidKind {
+		t.Errorf("KindOf(nil) = %v, want InvalidKind", got)
+	}
+	if got := KindOf(ast.NewArrayNode(nil, ast.Position{})); got != InvalidKind {
+		t.Errorf("KindOf(ArrayNode) = %v, want InvalidKind", got)
+	}
+	if got := Kind(9).String(); got != "Kind(9)" {
+		t.Errorf("Kind(9).String() = %q", got)
+	}
+}
+
+func TestParseWithStyles(t *testing.T) {
+	input := "plain: a\nsingle: 'b'\ndouble: \"c\"\nnumber: 1\n" +
+		"literal: |\n  d\nfolded: >\n  e\nlist: ['f', g]\n"
+	node, styles, err := ParseWithStyles(input)
+	if err != nil {
+		t.Fatalf("ParseWithStyles() error = %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		want Style
+	}{
+		{"plain", Plain},
+		{"single", SingleQuoted},
+		{"double", DoubleQuoted},
+		{"number", Plain},
+		{"literal", Literal},
+		{"folded", Folded},
+		{"list", Plain},
+	}
+	for _, tt := range tests {
+		child := property(node, tt.key)
+		if got := styles.Of(child); got != tt.want {
+			t.Errorf("styles.Of(%s) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+
+	list := property(node, "list").(*ast.ArrayDataNode)
+	first, second := list.Get(0), list.Get(1)
+	if got := styles.Of(first); got != SingleQuoted {
+		t.Errorf("styles.Of(list[0]) = %v, want SingleQuoted", got)
+	}
+	if got := styles.Of(second); got != Plain {
+		t.Errorf("styles.Of(list[1]) = %v, want Plain", got)
+	}
+
+	if _, _, err := ParseWithStyles("a: [1"); err == nil {
+		t.Error("ParseWithStyles() invalid document: expected error")
+	}
+}
+
+func TestParseWithTags(t *testing.T) {
+	node, tags, err := ParseWithTags("port: 8080\nname: \"8080\"\nforced: !!str 8080\npoint: !Point {x: 1}\nitems: [a]")
+	if err != nil {
+		t.Fatalf("ParseWithTags() error = %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"port", IntTag},
+		{"name", StrTag},
+		{"forced", StrTag},
+		{"point", "!Point"},
+		{"items", SeqTag},
+	}
+	for _, tt := range tests {
+		child := property(node, tt.key)
+		if got := tags.Of(child); got != tt.want {
+			t.Errorf("tags.Of(%s) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+	if got := tags.Of(node); got != MapTag {
+		t.Errorf("tags.Of(root) = %q, want %q", got, MapTag)
+	}
+
+	if _, _, err := ParseWithTags("a: [1"); err == nil {
+		t.Error("ParseWithTags() invalid document: expected error")
+	}
+}
+
+// property returns the value of key in mapping node of a parsed tree, or
+// nil if there is none.
+func property(node ast.SchemaNode, key string) ast.SchemaNode {
+	obj, ok := node.(*ast.ObjectNode)
+	if !ok {
+		return nil
+	}
+	value, _ := obj.GetProperty(key)
+	return value
+}
@@ -0,0 +1,160 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/fastparser"
+	"github.com/shapestone/shape-yaml/internal/parser"
+)
+
+// MapItem is a single key/value entry of a MapSlice.
+type MapItem = fastparser.MapItem
+
+// MapSlice is an ordered mapping, for callers who cannot define structs but
+// need control over key order.
+//
+// As an Unmarshal target, a MapSlice receives the entries of a mapping in
+// document order; nested mappings are decoded as MapSlice values as well.
+// A repeated key replaces the earlier value and keeps its first position.
+//
+// As a Marshal source, a MapSlice is emitted as a mapping with its entries in
+// slice order, unlike Go maps, whose keys are sorted.
+//
+//	doc := yaml.MapSlice{
+//	    {Key: "name", Value: "api"},
+//	    {Key: "image", Value: "api:1.2"},
+//	    {Key: "ports", Value: []int{80, 443}},
+//	}
+//	out, err := yaml.Marshal(doc) // name, image, ports - in that order
+//
+// UnmarshalWithAST also accepts a MapSlice and keeps document order the same
+// way. NodeToOrderedInterface builds MapSlice values from a parsed AST.
+type MapSlice = fastparser.MapSlice
+
+var mapSliceType = reflect.TypeOf(MapSlice(nil))
+
+// KeyOrder holds the document order of the keys of every mapping of a tree
+// returned by ParseWithKeyOrder. KeyOrder.Keys(node) returns the keys of a
+// mapping node in that order, sorted keys for a mapping the parse did not
+// produce, and nil for other nodes.
+type KeyOrder = parser.KeyOrder
+
+// yamlMapSliceEnc encodes a MapSlice as a mapping, in slice order.
+func yamlMapSliceEnc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	if rv.IsNil() {
+		return append(buf, "null"...), nil
+	}
+
+	n := rv.Len()
+	if n == 0 {
+		return append(buf, "{}"...), nil
+	}
+	if e.flows(rv) {
+		return e.appendFlow(buf, rv)
+	}
+	for i := 0; i < n; i++ {
+		item := rv.Index(i)
+		key := item.Field(0).Elem()
+		val := item.Field(1)
+
+		if i > 0 {
+			buf = append(buf, '\n')
+		}
+		buf = e.appendIndent(buf, indent)
+
+		var err error
+		buf, err = e.appendMapSliceKey(buf, key)
+		if err != nil {
+			return buf, err
+		}
+		buf = append(buf, ':', ' ')
+
+		complex := e.isBlock(val)
+		if e.anchors != nil {
+			var alias bool
+			if buf, alias = e.appendAnchor(buf, val, complex); alias {
+				continue
+			}
+		}
+
+		if complex {
+			buf = append(buf, '\n')
+			buf, err = yamlInterfaceEnc(e, buf, val, indent+1)
+		} else {
+			buf, err = yamlInterfaceEnc(e, buf, val, indent)
+		}
+		if err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
+
+// appendMapSliceKey writes a MapSlice key. String keys are written as-is, as
+// for Go maps; other scalar keys use their normal encoding.
+func (e *encodeState) appendMapSliceKey(buf []byte, key reflect.Value) ([]byte, error) {
+	if !key.IsValid() {
+		return append(buf, "null"...), nil
+	}
+	if key.Kind() == reflect.String {
+		return append(buf, key.String()...), nil
+	}
+	if isComplexType(key) {
+		return buf, fmt.Errorf("yaml: unsupported MapSlice key type %s", key.Type())
+	}
+	return yamlEncoderForType(key.Type())(e, buf, key, 0)
+}
+
+// NodeToOrderedInterface is NodeToInterface with mappings converted to
+// MapSlice values, their keys in the order recorded by ParseWithKeyOrder.
+// Marshaling the result emits the keys in their original order:
+//
+//	node, order, err := yaml.ParseWithKeyOrder(input)
+//	// ... inspect or transform node ...
+//	out, err := yaml.Marshal(yaml.NodeToOrderedInterface(node, order))
+//
+// Mappings without a recorded order, including all of them when order is
+// nil, have their keys sorted.
+func NodeToOrderedInterface(node ast.SchemaNode, order KeyOrder) interface{} {
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		return nodeToMapSlice(n, order)
+	case *ast.ArrayDataNode:
+		arr := make([]interface{}, n.Len())
+		for i, elem := range n.Elements() {
+			arr[i] = NodeToOrderedInterface(elem, order)
+		}
+		return arr
+	default:
+		return NodeToInterface(node)
+	}
+}
+
+// nodeToMapSlice converts a mapping node to a MapSlice with entries in the
+// order recorded in order, converting nested mappings to MapSlice values too.
+func nodeToMapSlice(node *ast.ObjectNode, order KeyOrder) MapSlice {
+	props := node.Properties()
+	keys := order.Keys(node)
+
+	items := make(MapSlice, 0, len(keys))
+	for _, k := range keys {
+		items = append(items, MapItem{Key: k, Value: NodeToOrderedInterface(props[k], order)})
+	}
+	return items
+}
+
+// unmarshalMapSlice unmarshals a mapping node into a MapSlice.
+func unmarshalMapSlice(node ast.SchemaNode, rv reflect.Value, order KeyOrder) error {
+	obj, ok := node.(*ast.ObjectNode)
+	if !ok {
+		kind := "sequence"
+		if lit, ok := node.(*ast.LiteralNode); ok {
+			kind = fastparser.ValueKind(lit.Value())
+		}
+		return typeError(node, kind, rv.Type())
+	}
+	rv.Set(reflect.ValueOf(nodeToMapSlice(obj, order)))
+	return nil
+}
@@ -0,0 +1,208 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarshal_MapSlice(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{
+			name: "insertion order",
+			input: MapSlice{
+				{Key: "zeta", Value: 1},
+				{Key: "alpha", Value: "two"},
+				{Key: "mid", Value: true},
+			},
+			want: "zeta: 1\nalpha: two\nmid: true",
+		},
+		{
+			name: "nested collections",
+			input: MapSlice{
+				{Key: "name", Value: "api"},
+				{Key: "ports", Value: []int{80, 443}},
+				{Key: "env", Value: MapSlice{{Key: "B", Value: "2"}, {Key: "A", Value: "1"}}},
+			},
+			want: "name: api\nports: \n  - 80\n  - 443\nenv: \n  B: \"2\"\n  A: \"1\"",
+		},
+		{
+			name:  "non-string keys and nil values",
+			input: MapSlice{{Key: 2, Value: nil}, {Key: true, Value: 1.5}},
+			want:  "2: null\ntrue: 1.5",
+		},
+		{
+			name:  "empty",
+			input: MapSlice{},
+			want:  "{}",
+		},
+		{
+			name: "empty inside a struct",
+			input: struct {
+				Labels MapSlice `yaml:"labels"`
+				Name   string   `yaml:"name"`
+			}{Labels: MapSlice{}, Name: "x"},
+			want: "labels: {}\nname: x",
+		},
+		{
+			name: "inside a struct",
+			input: struct {
+				Labels MapSlice `yaml:"labels"`
+			}{Labels: MapSlice{{Key: "b", Value: 1}, {Key: "a", Value: 2}}},
+			want: "labels: \n  b: 1\n  a: 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshal_MapSliceComplexKey(t *testing.T) {
+	_, err := Marshal(MapSlice{{Key: []int{1}, Value: 1}})
+	if err == nil {
+		t.Error("Marshal() expected error for sequence key")
+	}
+}
+
+func TestUnmarshal_MapSlice(t *testing.T) {
+	input := "zeta: 1\nalpha:\n  y: true\n  x: [a, {q: 1, p: 2}]\nmid: ~\nzeta: 3"
+
+	var got MapSlice
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := MapSlice{
+		{Key: "zeta", Value: int64(3)},
+		{Key: "alpha", Value: MapSlice{
+			{Key: "y", Value: true},
+			{Key: "x", Value: []interface{}{"a", MapSlice{{Key: "q", Value: int64(1)}, {Key: "p", Value: int64(2)}}}},
+		}},
+		{Key: "mid", Value: nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %#v, want %#v", got, want)
+	}
+
+	// Round trip keeps the document order
+	out, err := Marshal(got)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want2 := "zeta: 3\nalpha: \n  y: true\n  x: \n    - a\n    - \n      q: 1\n      p: 2\nmid: null"
+	if string(out) != want2 {
+		t.Errorf("Marshal() = %q, want %q", out, want2)
+	}
+}
+
+func TestUnmarshal_MapSliceField(t *testing.T) {
+	var cfg struct {
+		Name   string   `yaml:"name"`
+		Labels MapSlice `yaml:"labels"`
+		Other  map[string]interface{}
+	}
+	input := "name: svc\nlabels: {b: 1, a: 2}\nother:\n  k: {n: 1}"
+	if err := Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	wantLabels := MapSlice{{Key: "b", Value: int64(1)}, {Key: "a", Value: int64(2)}}
+	if !reflect.DeepEqual(cfg.Labels, wantLabels) {
+		t.Errorf("Labels = %#v, want %#v", cfg.Labels, wantLabels)
+	}
+	// Ordered mode does not leak into sibling fields
+	if _, ok := cfg.Other["k"].(map[string]interface{}); !ok {
+		t.Errorf("Other[k] = %T, want map[string]interface{}", cfg.Other["k"])
+	}
+}
+
+func TestUnmarshal_MapSliceErrors(t *testing.T) {
+	var ms MapSlice
+	if err := Unmarshal([]byte("- a\n- b"), &ms); err == nil {
+		t.Error("Unmarshal() expected error for sequence into MapSlice")
+	}
+	if err := UnmarshalWithAST([]byte("[a, b]"), &ms); err == nil {
+		t.Error("UnmarshalWithAST() expected error for sequence into MapSlice")
+	}
+}
+
+func TestUnmarshalWithAST_MapSlice(t *testing.T) {
+	input := "b: 1\na:\n  d: x\n  c: y\nf: [{z: 1, y: 2}]"
+	var got MapSlice
+	if err := UnmarshalWithAST([]byte(input), &got); err != nil {
+		t.Fatalf("UnmarshalWithAST() error = %v", err)
+	}
+
+	want := MapSlice{
+		{Key: "b", Value: int64(1)},
+		{Key: "a", Value: MapSlice{{Key: "d", Value: "x"}, {Key: "c", Value: "y"}}},
+		{Key: "f", Value: []interface{}{MapSlice{{Key: "z", Value: int64(1)}, {Key: "y", Value: int64(2)}}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalWithAST() = %#v, want %#v", got, want)
+	}
+
+	// Both decoders agree on the order
+	var fast MapSlice
+	if err := Unmarshal([]byte(input), &fast); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, fast) {
+		t.Errorf("UnmarshalWithAST() = %#v, Unmarshal() = %#v", got, fast)
+	}
+}
+
+func TestNodeToOrderedInterface(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"block", "zeta: 1\nalpha:\n  y: true\n  x: 2\nmid: ~", "zeta: 1\nalpha: \n  y: true\n  x: 2\nmid: null"},
+		{"flow", "{b: 1, a: {d: 2, c: 3}}", "b: 1\na: \n  d: 2\n  c: 3"},
+		{"in sequence", "- {z: 1, y: 2}\n- x", "- \n  z: 1\n  y: 2\n- x"},
+		{"merge keys follow", "base: &b {y: 1, x: 2}\nuse:\n  <<: *b\n  z: 3\n  x: 4", "base: \n  y: 1\n  x: 2\nuse: \n  z: 3\n  x: 4\n  y: 1"},
+		{"complex keys", "? b\n: 1\n? a\n: 2", "b: 1\na: 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, order, err := ParseWithKeyOrder(tt.input)
+			if err != nil {
+				t.Fatalf("ParseWithKeyOrder() error = %v", err)
+			}
+			out, err := Marshal(NodeToOrderedInterface(node, order))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Marshal() = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeToOrderedInterface_NoOrder(t *testing.T) {
+	node, err := Parse("b: 1\na: 2")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// Without a recorded order, keys are sorted
+	want := MapSlice{{Key: "a", Value: int64(2)}, {Key: "b", Value: int64(1)}}
+	if got := NodeToOrderedInterface(node, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("NodeToOrderedInterface() = %#v, want %#v", got, want)
+	}
+}
@@ -36,18 +36,23 @@ func putBuffer(buf *bytes.Buffer) {
 // Marshal returns the YAML encoding of v.
 //
 // Marshal traverses the value v recursively. If an encountered value implements
-// the yaml.Marshaler interface, Marshal calls its MarshalYAML method to produce YAML.
+// the yaml.Marshaler or yaml.ValueMarshaler interface, Marshal calls its
+// MarshalYAML method to produce YAML.
 //
 // Otherwise, Marshal uses the following type-dependent default encodings:
 //
 // Boolean values encode as YAML booleans (true/false).
 //
-// Floating point and integer values encode as YAML numbers.
+// Floating point and integer values encode as YAML numbers. NaN and the
+// infinities encode as .nan, .inf and -.inf; MarshalOptions.NonFinite can
+// write null or fail instead.
 //
 // String values encode as YAML strings (quoted if necessary).
 //
 // Array and slice values encode as YAML sequences, except that a nil slice
-// encodes as the null YAML value.
+// encodes as the null YAML value. A byte slice encodes as a !!binary scalar
+// of its base64 text, in a literal block wrapped at 76 characters, which
+// decodes back into a []byte.
 //
 // Struct values encode as YAML mappings. Each exported struct field becomes
 // a key-value pair, using the field name as the key, unless the field is
@@ -66,7 +71,9 @@ func putBuffer(buf *bytes.Buffer) {
 // As a special case, if the field tag is "-", the field is always omitted.
 //
 // Map values encode as YAML mappings. The map's key type must be a string;
-// the map keys are used as YAML mapping keys.
+// the map keys are used as YAML mapping keys, sorted, so that the output of
+// maps at any depth does not change from run to run. A MapSlice keeps the
+// order its keys are given in.
 //
 // Pointer values encode as the value pointed to. A nil pointer encodes as
 // the null YAML value.
@@ -80,6 +87,9 @@ func putBuffer(buf *bytes.Buffer) {
 // YAML cannot represent cyclic data structures and Marshal does not handle them.
 // Passing cyclic structures to Marshal will result in an error.
 //
+// A Node that holds the Directives of its document is written with those
+// directive lines and a "---" ahead of it.
+//
 // Example:
 //
 //	type Config struct {
@@ -90,9 +100,21 @@ func putBuffer(buf *bytes.Buffer) {
 //	data, err := yaml.Marshal(cfg)
 //	// data is []byte("name: server\nport: 8080\n")
 func Marshal(v interface{}) ([]byte, error) {
+	return defaultEncodeState.marshal(v)
+}
+
+// marshal returns the YAML encoding of v, laid out as e says.
+func (e *encodeState) marshal(v interface{}) ([]byte, error) {
 	if v == nil {
 		return []byte("null"), nil
 	}
+	if dirs, rest := splitDirectives(v); dirs != nil {
+		data, err := e.marshal(rest)
+		if err != nil {
+			return nil, err
+		}
+		return append(appendDirectives(nil, dirs), data...), nil
+	}
 
 	rv := reflect.ValueOf(v)
 	for rv.Kind() == reflect.Ptr {
@@ -102,14 +124,22 @@ func Marshal(v interface{}) ([]byte, error) {
 		rv = rv.Elem()
 	}
 
+	// A top-level Marshaler's output is the whole document
+	if isMarshaler(rv.Type()) {
+		return e.marshalerYAML(rv.Interface())
+	}
+	if rv.CanAddr() && isMarshaler(rv.Addr().Type()) {
+		return e.marshalerYAML(rv.Addr().Interface())
+	}
+
 	enc := yamlEncoderForType(rv.Type())
 
 	// Use pooled []byte slice
-	bp := yamlBufPool.Get().(*[]byte)
-	buf := (*bp)[:0]
+	bp := yamlBufPool.Get()
+	buf := *bp
 
 	var err error
-	buf, err = enc(buf, rv, 0)
+	buf, err = enc(e, buf, rv, 0)
 	if err != nil {
 		*bp = buf
 		yamlBufPool.Put(bp)
@@ -123,11 +153,67 @@ func Marshal(v interface{}) ([]byte, error) {
 	return result, nil
 }
 
-// Marshaler is the interface implemented by types that can marshal themselves into valid YAML.
+// MustMarshal is like Marshal but panics if v cannot be marshaled, for
+// values known to be representable, such as in tests and scripts.
+func MustMarshal(v interface{}) []byte {
+	data, err := Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// MarshalAll returns the YAML encoding of each of docs as one stream of
+// "---"-separated documents, as for a manifest bundle; ParseMultiDoc reads
+// it back. Each document follows the rules of Marshal and is written as by
+// an Encoder, so a Node's Directives go with its own document.
+func MarshalAll(docs []interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for i, v := range docs {
+		if err := enc.Encode(v); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ValueMarshaler is the interface implemented by types that marshal as
+// another value, such as a string for a duration:
+//
+//	func (d Duration) MarshalYAML() (interface{}, error) {
+//	    return time.Duration(d).String(), nil
+//	}
+//
+// Marshal writes the returned value in place of the original.
+type ValueMarshaler interface {
+	MarshalYAML() (interface{}, error)
+}
+
+// Marshaler is the interface implemented by types that write their own
+// YAML. The output is placed where the value goes: a scalar stays on the
+// line of its key, and a block collection starts on the line after it.
 type Marshaler interface {
 	MarshalYAML() ([]byte, error)
 }
 
+// isMarshaler reports whether t implements Marshaler or ValueMarshaler.
+func isMarshaler(t reflect.Type) bool {
+	return t.Implements(yamlMarshalerType) || t.Implements(yamlValueMarshalerType)
+}
+
+// marshalerYAML returns the YAML of m, a Marshaler or ValueMarshaler.
+func (e *encodeState) marshalerYAML(m interface{}) ([]byte, error) {
+	if raw, ok := m.(Marshaler); ok {
+		return raw.MarshalYAML()
+	}
+	v, err := m.(ValueMarshaler).MarshalYAML()
+	if err != nil {
+		return nil, err
+	}
+	return e.marshal(v)
+}
+
 // marshalValue marshals a reflect.Value to a buffer with indentation
 func marshalValue(rv reflect.Value, buf *bytes.Buffer, indent int) error {
 	// Handle invalid values
@@ -142,10 +228,9 @@ func marshalValue(rv reflect.Value, buf *bytes.Buffer, indent int) error {
 		return nil
 	}
 
-	// Check if type implements Marshaler interface
-	if rv.Type().Implements(reflect.TypeOf((*Marshaler)(nil)).Elem()) {
-		marshaler := rv.Interface().(Marshaler)
-		b, err := marshaler.MarshalYAML()
+	// Check if type implements Marshaler or ValueMarshaler interface
+	if isMarshaler(rv.Type()) {
+		b, err := defaultEncodeState.marshalerYAML(rv.Interface())
 		if err != nil {
 			return err
 		}
@@ -167,6 +252,17 @@ func marshalValue(rv reflect.Value, buf *bytes.Buffer, indent int) error {
 		return marshalValue(rv.Elem(), buf, indent)
 	}
 
+	if rv.Type() == timeType {
+		b, _ := yamlTimeEnc(defaultEncodeState, nil, rv, indent)
+		buf.Write(b)
+		return nil
+	}
+	if isBinary(rv.Type()) {
+		b, _ := yamlBinaryEnc(defaultEncodeState, nil, rv, indent)
+		buf.Write(b)
+		return nil
+	}
+
 	switch rv.Kind() {
 	case reflect.String:
 		return marshalString(rv.String(), buf)
@@ -180,7 +276,8 @@ func marshalValue(rv reflect.Value, buf *bytes.Buffer, indent int) error {
 		return nil
 
 	case reflect.Float32, reflect.Float64:
-		buf.WriteString(strconv.FormatFloat(rv.Float(), 'g', -1, 64))
+		b, _ := defaultEncodeState.appendFloat(nil, rv.Float(), 64)
+		buf.Write(b)
 		return nil
 
 	case reflect.Bool:
@@ -205,65 +302,27 @@ func marshalValue(rv reflect.Value, buf *bytes.Buffer, indent int) error {
 func marshalString(s string, buf *bytes.Buffer) error {
 	// Check if we need quoting
 	if needsQuoting(s) {
-		buf.WriteString(`"`)
-		buf.WriteString(escapeString(s))
-		buf.WriteString(`"`)
+		// Escape straight into the buffer's spare capacity instead of building a string
+		buf.WriteByte('"')
+		buf.Write(appendEscapedYAMLString(buf.AvailableBuffer(), s))
+		buf.WriteByte('"')
 	} else {
 		buf.WriteString(s)
 	}
 	return nil
 }
 
-// needsQuoting checks if a string needs to be quoted in YAML
+// needsQuoting checks if a string needs to be quoted in YAML. It applies
+// the rules of needsQuotingFast, so that both encoders quote the same
+// strings.
 func needsQuoting(s string) bool {
-	if s == "" {
-		return true
-	}
-
-	// Check for special values that would be interpreted differently
-	switch s {
-	case "true", "false", "yes", "no", "null", "~":
-		return true
-	}
-
-	// Check if it looks like a number
-	if _, err := strconv.ParseFloat(s, 64); err == nil {
-		return true
-	}
-
-	// Check for characters that require quoting
-	if strings.ContainsAny(s, ":#@`\"'{}[]|>-") {
-		return true
-	}
-
-	// Check if it starts with special characters
-	if strings.HasPrefix(s, " ") || strings.HasPrefix(s, "-") || strings.HasPrefix(s, "?") {
-		return true
-	}
-
-	return false
+	return needsQuotingFast(s)
 }
 
-// escapeString escapes special characters in a YAML string
+// escapeString escapes special characters in a YAML string.
+// It is the string form of appendEscapedYAMLString.
 func escapeString(s string) string {
-	var buf strings.Builder
-	for _, r := range s {
-		switch r {
-		case '"':
-			buf.WriteString(`\"`)
-		case '\\':
-			buf.WriteString(`\\`)
-		case '\n':
-			buf.WriteString(`\n`)
-		case '\r':
-			buf.WriteString(`\r`)
-		case '\t':
-			buf.WriteString(`\t`)
-		default:
-			buf.WriteRune(r)
-		}
-	}
-	return buf.String()
+	return string(appendEscapedYAMLString(make([]byte, 0, len(s)), s))
 }
 
 // marshalStruct marshals a struct to YAML
@@ -356,6 +415,10 @@ func marshalMap(rv reflect.Value, buf *bytes.Buffer, indent int) error {
 
 	// Get keys and sort them for deterministic output
 	keys := rv.MapKeys()
+	if len(keys) == 0 {
+		buf.WriteString("{}")
+		return nil
+	}
 	strKeys := make([]string, len(keys))
 	for i, key := range keys {
 		strKeys[i] = key.String()
@@ -403,6 +466,10 @@ func marshalSlice(rv reflect.Value, buf *bytes.Buffer, indent int) error {
 	}
 
 	length := rv.Len()
+	if length == 0 {
+		buf.WriteString("[]")
+		return nil
+	}
 	for i := 0; i < length; i++ {
 		if i > 0 {
 			buf.WriteString("\n")
@@ -445,6 +512,17 @@ func isComplexType(rv reflect.Value) bool {
 		rv = rv.Elem()
 	}
 
-	kind := rv.Kind()
-	return kind == reflect.Struct || kind == reflect.Map || kind == reflect.Slice || kind == reflect.Array
+	// Values that marshal themselves lay out their own output
+	if isMarshaler(rv.Type()) || (rv.CanAddr() && isMarshaler(reflect.PointerTo(rv.Type()))) || rv.Type() == timeType || isBinary(rv.Type()) {
+		return false
+	}
+
+	// Empty collections are written inline as {} or []
+	switch rv.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	}
+	return false
 }
@@ -0,0 +1,214 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// anchorKey identifies a pointer, map or slice by what it refers to. Slices
+// sharing an array are the same value only if they have the same length.
+type anchorKey struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// anchor is a value reached more than once, written in full with &name at
+// its first occurrence and as *name at the others.
+type anchor struct {
+	name    string
+	written bool
+}
+
+// anchorKeyOf returns the key of rv if it may be anchored: a non-empty map
+// or slice, or a pointer to a struct, map, slice or array. Marshaler values
+// are not anchored, as their output may be a scalar, nor are byte slices,
+// which are written as !!binary scalars.
+func anchorKeyOf(rv reflect.Value) (anchorKey, bool) {
+	for rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return anchorKey{}, false
+		}
+		rv = rv.Elem()
+	}
+	if isMarshaler(rv.Type()) {
+		return anchorKey{}, false
+	}
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() || isMarshaler(rv.Type().Elem()) {
+			return anchorKey{}, false
+		}
+		switch rv.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			if rv.Type().Elem() != timeType && !isBinary(rv.Type().Elem()) {
+				return anchorKey{typ: rv.Type(), ptr: rv.Pointer()}, true
+			}
+		}
+	case reflect.Map:
+		if rv.Len() > 0 {
+			return anchorKey{typ: rv.Type(), ptr: rv.Pointer()}, true
+		}
+	case reflect.Slice:
+		if rv.Len() > 0 && !isBinary(rv.Type()) {
+			return anchorKey{typ: rv.Type(), ptr: rv.Pointer(), len: rv.Len()}, true
+		}
+	}
+	return anchorKey{}, false
+}
+
+// appendAnchor writes &name ahead of the first occurrence of a value
+// reached more than once, followed by a space unless the value is a block
+// collection, or *name in place of a later occurrence. It reports whether
+// it wrote an alias, which takes the place of the value.
+func (e *encodeState) appendAnchor(buf []byte, rv reflect.Value, block bool) ([]byte, bool) {
+	key, ok := anchorKeyOf(rv)
+	if !ok {
+		return buf, false
+	}
+	a := e.anchors[key]
+	switch {
+	case a == nil:
+		return buf, false
+	case a.written:
+		buf = append(buf, '*')
+		return append(buf, a.name...), true
+	}
+	a.written = true
+	buf = append(buf, '&')
+	buf = append(buf, a.name...)
+	if !block {
+		buf = append(buf, ' ')
+	}
+	return buf, false
+}
+
+// findAnchors walks v in the order Marshal writes it and returns an anchor
+// for each value it reaches more than once, named after the key it first
+// appears under. A value holding itself is an error, as an alias cannot
+// refer to the node it is part of.
+func findAnchors(v interface{}) (map[anchorKey]*anchor, error) {
+	f := &anchorFinder{refs: make(map[anchorKey]int), active: make(map[anchorKey]bool)}
+	if err := f.walk(reflect.ValueOf(v), ""); err != nil {
+		return nil, err
+	}
+
+	anchors := make(map[anchorKey]*anchor)
+	used := make(map[string]bool)
+	for i, key := range f.order {
+		if f.refs[key] < 2 {
+			continue
+		}
+		base := anchorName(f.hints[i])
+		name := base
+		for n := 2; used[name]; n++ {
+			name = base + strconv.Itoa(n)
+		}
+		used[name] = true
+		anchors[key] = &anchor{name: name}
+	}
+	return anchors, nil
+}
+
+type anchorFinder struct {
+	refs   map[anchorKey]int
+	order  []anchorKey // in order of first occurrence
+	hints  []string    // key of each first occurrence
+	active map[anchorKey]bool
+}
+
+func (f *anchorFinder) walk(rv reflect.Value, hint string) error {
+	if !rv.IsValid() {
+		return nil
+	}
+	if key, ok := anchorKeyOf(rv); ok {
+		if f.active[key] {
+			return fmt.Errorf("yaml: %s holds itself, which an alias cannot express", key.typ)
+		}
+		f.refs[key]++
+		if f.refs[key] > 1 {
+			return nil
+		}
+		f.order = append(f.order, key)
+		f.hints = append(f.hints, hint)
+		f.active[key] = true
+		defer delete(f.active, key)
+	}
+
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if isMarshaler(rv.Type()) || (rv.CanAddr() && isMarshaler(reflect.PointerTo(rv.Type()))) {
+		return nil
+	}
+
+	switch {
+	case rv.Type() == mapSliceType || rv.Type() == hookedStructType:
+		for i := 0; i < rv.Len(); i++ {
+			item := rv.Index(i)
+			if err := f.walk(item.Field(1), fmt.Sprint(item.Field(0).Interface())); err != nil {
+				return err
+			}
+		}
+
+	case rv.Kind() == reflect.Struct:
+		type field struct {
+			name  string
+			index int
+		}
+		var fields []field
+		for i := 0; i < rv.NumField(); i++ {
+			sf := rv.Type().Field(i)
+			if info := getFieldInfo(sf); sf.PkgPath == "" && !info.skip {
+				fields = append(fields, field{info.name, i})
+			}
+		}
+		sort.Slice(fields, func(i, j int) bool { return fields[i].name+":" < fields[j].name+":" }) // as the struct encoder sorts
+		for _, fd := range fields {
+			if err := f.walk(rv.Field(fd.index), fd.name); err != nil {
+				return err
+			}
+		}
+
+	case rv.Kind() == reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			if err := f.walk(rv.MapIndex(k), k.String()); err != nil {
+				return err
+			}
+		}
+
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := f.walk(rv.Index(i), hint); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// anchorName makes an anchor name of key, keeping its letters, digits, '-'
+// and '_', or returns "anchor" if none are left.
+func anchorName(key string) string {
+	name := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' {
+			name = append(name, c)
+		}
+	}
+	if len(name) == 0 {
+		return "anchor"
+	}
+	return string(name)
+}
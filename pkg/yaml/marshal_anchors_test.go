@@ -0,0 +1,126 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type anchorDB struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+type anchorConfig struct {
+	Primary  *anchorDB   `yaml:"primary"`
+	Replicas []*anchorDB `yaml:"replicas"`
+	Tags     []string    `yaml:"tags"`
+	Other    []string    `yaml:"other"`
+}
+
+func TestMarshalWithOptions_Anchors(t *testing.T) {
+	db := &anchorDB{Host: "db", Port: 5432}
+	tags := []string{"a", "b"}
+	cfg := anchorConfig{
+		Primary:  db,
+		Replicas: []*anchorDB{db, {Host: "replica", Port: 5433}},
+		Tags:     tags,
+		Other:    tags,
+	}
+
+	out, err := MarshalWithOptions(cfg, MarshalOptions{Anchors: true})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	want := "other: &other\n  - a\n  - b\n" +
+		"primary: &primary\n  host: db\n  port: 5432\n" +
+		"replicas: \n  - *primary\n  - \n    host: replica\n    port: 5433\n" +
+		"tags: *other"
+	if string(out) != want {
+		t.Errorf("MarshalWithOptions() =\n%s\nwant:\n%s", out, want)
+	}
+
+	var got anchorConfig
+	if err := UnmarshalWithAST(out, &got); err != nil {
+		t.Fatalf("UnmarshalWithAST() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("round trip = %+v, want %+v", got, cfg)
+	}
+
+	// Without the option, shared values are repeated
+	plain, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.ContainsAny(string(plain), "&*") {
+		t.Errorf("Marshal() wrote anchors:\n%s", plain)
+	}
+}
+
+func TestMarshalWithOptions_AnchorMerge(t *testing.T) {
+	defaults := map[string]interface{}{"retries": 3, "timeout": "5s"}
+	doc := MapSlice{
+		{Key: "defaults", Value: defaults},
+		{Key: "web", Value: MapSlice{{Key: "<<", Value: defaults}, {Key: "port", Value: 80}}},
+		{Key: "worker", Value: MapSlice{{Key: "<<", Value: defaults}, {Key: "retries", Value: 5}}},
+	}
+
+	out, err := MarshalWithOptions(doc, MarshalOptions{Anchors: true, FlowThreshold: 2})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	want := "defaults: &defaults {retries: 3, timeout: 5s}\n" +
+		"web: \n  <<: *defaults\n  port: 80\n" +
+		"worker: \n  <<: *defaults\n  retries: 5"
+	if string(out) != want {
+		t.Errorf("MarshalWithOptions() =\n%s\nwant:\n%s", out, want)
+	}
+
+	var got map[string]interface{}
+	if err := UnmarshalWithAST(out, &got); err != nil {
+		t.Fatalf("UnmarshalWithAST() error = %v", err)
+	}
+	worker := got["worker"].(map[string]interface{})
+	if worker["retries"] != int64(5) || worker["timeout"] != "5s" {
+		t.Errorf("worker = %v, want the defaults merged under its own keys", worker)
+	}
+}
+
+func TestMarshalWithOptions_AnchorNames(t *testing.T) {
+	shared := []int{1, 2}
+	other := []int{3}
+	doc := MapSlice{
+		{Key: "x.y", Value: shared},
+		{Key: "xy", Value: other},
+		{Key: "a", Value: shared},
+		{Key: "b", Value: other},
+	}
+	out, err := MarshalWithOptions(doc, MarshalOptions{Anchors: true})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	want := "x.y: &xy\n  - 1\n  - 2\nxy: &xy2\n  - 3\na: *xy\nb: *xy2"
+	if string(out) != want {
+		t.Errorf("MarshalWithOptions() =\n%s\nwant:\n%s", out, want)
+	}
+	if _, err := Parse(string(out)); err != nil {
+		t.Errorf("Parse() error = %v", err)
+	}
+
+	if got := anchorName("<<"); got != "anchor" {
+		t.Errorf("anchorName(%q) = %q, want anchor", "<<", got)
+	}
+}
+
+func TestMarshalWithOptions_AnchorCycle(t *testing.T) {
+	type node struct {
+		Next *node `yaml:"next"`
+	}
+	n := &node{}
+	n.Next = n
+	_, err := MarshalWithOptions(n, MarshalOptions{Anchors: true})
+	if err == nil || !strings.Contains(err.Error(), "holds itself") {
+		t.Errorf("MarshalWithOptions() error = %v, want a cycle error", err)
+	}
+}
@@ -0,0 +1,215 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// MarshalHook is called for each value that MarshalWithOptions or an Encoder
+// writes, before it is encoded, with the path of the value as Query takes
+// it: "." for the document, and "spec.containers[0].image" for a value
+// within it. It returns the value to write in place of v, often v itself,
+// and false to leave the value out: a struct field or mapping entry is then
+// written without its key, a sequence item is dropped, and a document is
+// written as null.
+//
+// The hook is called in turn on the fields, entries and items of the value
+// it returns, so one hook can redact, convert or drop values anywhere in a
+// document without a parallel struct hierarchy:
+//
+//	hook := func(path string, v interface{}) (interface{}, bool) {
+//	    if strings.HasSuffix(path, ".password") {
+//	        return "<redacted>", true
+//	    }
+//	    return v, true
+//	}
+//	out, err := yaml.MarshalWithOptions(cfg, yaml.MarshalOptions{Hook: hook})
+//
+// Replacement values are encoded like any other value, so a string such as
+// "***" is quoted rather than read back as an alias. Fields that omitempty
+// leaves out are not passed to the hook. Values that
+// marshal themselves, such as Tagged and Node, are passed whole: the hook
+// does not see into them.
+type MarshalHook func(path string, v interface{}) (interface{}, bool)
+
+// hookedStruct holds the fields of a struct as a MarshalHook rewrote them,
+// in the order the struct encoder writes them. It is written as a MapSlice
+// is, except that, like the struct, never in flow style.
+type hookedStruct MapSlice
+
+var hookedStructType = reflect.TypeOf(hookedStruct(nil))
+
+// hooker applies a MarshalHook to a value and to everything it holds.
+type hooker struct {
+	hook   MarshalHook
+	path   []pathSegment             // path of the value being rewritten
+	active map[anchorKey]bool        // values being rewritten, to catch cycles
+	shared map[anchorKey]interface{} // rewritten values, when they are aliased
+}
+
+// applyHook returns v as hook rewrites it: structs, maps, MapSlices and
+// sequences are copied, with the values the hook returns for their
+// contents, into values that the encoders write as they would the
+// originals. With anchors, a value reached more than once is rewritten at
+// its first occurrence only, so that the others remain aliases of it.
+func applyHook(v interface{}, hook MarshalHook, anchors bool) (interface{}, error) {
+	h := &hooker{hook: hook, active: make(map[anchorKey]bool)}
+	if anchors {
+		h.shared = make(map[anchorKey]interface{})
+	}
+	v, keep := hook(".", v)
+	if !keep {
+		return nil, nil
+	}
+	return h.rewrite(reflect.ValueOf(v))
+}
+
+// child calls the hook on rv, the value at seg below the current path, and
+// rewrites what it returns. It reports false if the hook left the value out.
+func (h *hooker) child(rv reflect.Value, seg pathSegment) (interface{}, bool, error) {
+	h.path = append(h.path, seg)
+	defer func() { h.path = h.path[:len(h.path)-1] }()
+
+	v, keep := h.hook(formatPath(h.path), rv.Interface())
+	if !keep {
+		return nil, false, nil
+	}
+	v, err := h.rewrite(reflect.ValueOf(v))
+	return v, true, err
+}
+
+// rewrite returns rv with the hook applied to the contents of the
+// collections it holds. Scalars and values that marshal themselves are
+// returned as they are.
+func (h *hooker) rewrite(rv reflect.Value) (interface{}, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	v := rv.Interface()
+	key, keyed := anchorKeyOf(rv)
+	if keyed {
+		if h.active[key] {
+			return nil, fmt.Errorf("yaml: %s holds itself", key.typ)
+		}
+		if shared, ok := h.shared[key]; ok {
+			return shared, nil
+		}
+		h.active[key] = true
+		defer delete(h.active, key)
+	}
+
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return v, nil
+		}
+		rv = rv.Elem()
+	}
+	t := rv.Type()
+	if isMarshaler(t) || (rv.CanAddr() && isMarshaler(reflect.PointerTo(t))) || t == timeType || isBinary(t) {
+		return v, nil
+	}
+
+	var out interface{}
+	var err error
+	switch {
+	case t == mapSliceType:
+		out, err = h.rewriteMapSlice(rv)
+	case rv.Kind() == reflect.Struct:
+		out, err = h.rewriteStruct(rv)
+	case rv.Kind() == reflect.Map && t.Key().Kind() == reflect.String && !rv.IsNil():
+		out, err = h.rewriteMap(rv)
+	case rv.Kind() == reflect.Slice && !rv.IsNil() || rv.Kind() == reflect.Array:
+		out, err = h.rewriteSequence(rv)
+	default:
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if keyed && h.shared != nil {
+		h.shared[key] = out
+	}
+	return out, nil
+}
+
+func (h *hooker) rewriteMapSlice(rv reflect.Value) (interface{}, error) {
+	if rv.IsNil() {
+		return rv.Interface(), nil
+	}
+	out := make(MapSlice, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i).Interface().(MapItem)
+		k, ok := item.Key.(string)
+		if !ok {
+			k = fmt.Sprint(item.Key)
+		}
+		v, keep, err := h.child(rv.Index(i).Field(1), pathSegment{key: k})
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			out = append(out, MapItem{Key: item.Key, Value: v})
+		}
+	}
+	return out, nil
+}
+
+func (h *hooker) rewriteStruct(rv reflect.Value) (interface{}, error) {
+	type field struct {
+		name  string
+		index int
+	}
+	var fields []field
+	for i := 0; i < rv.NumField(); i++ {
+		sf := rv.Type().Field(i)
+		info := getFieldInfo(sf)
+		if sf.PkgPath != "" || info.skip || (info.omitEmpty && isEmptyValue(rv.Field(i))) {
+			continue
+		}
+		fields = append(fields, field{info.name, i})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].name+":" < fields[j].name+":" }) // as the struct encoder sorts
+
+	out := make(hookedStruct, 0, len(fields))
+	for _, f := range fields {
+		v, keep, err := h.child(rv.Field(f.index), pathSegment{key: f.name})
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			out = append(out, MapItem{Key: f.name, Value: v})
+		}
+	}
+	return out, nil
+}
+
+func (h *hooker) rewriteMap(rv reflect.Value) (interface{}, error) {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	out := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		v, keep, err := h.child(rv.MapIndex(k), pathSegment{key: k.String()})
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			out[k.String()] = v
+		}
+	}
+	return out, nil
+}
+
+func (h *hooker) rewriteSequence(rv reflect.Value) (interface{}, error) {
+	out := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		v, keep, err := h.child(rv.Index(i), pathSegment{index: i, isIndex: true})
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
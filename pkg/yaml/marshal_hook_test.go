@@ -0,0 +1,192 @@
+package yaml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalHook(t *testing.T) {
+	type db struct {
+		Host     string `yaml:"host"`
+		Password string `yaml:"password"`
+		Port     int    `yaml:"port,omitempty"`
+	}
+	type config struct {
+		Name  string            `yaml:"name"`
+		DB    *db               `yaml:"db"`
+		Tags  []string          `yaml:"tags"`
+		Env   map[string]string `yaml:"env"`
+		Debug bool              `yaml:"debug"`
+	}
+	cfg := config{
+		Name: "api",
+		DB:   &db{Host: "db.local", Password: "s3cret"},
+		Tags: []string{"a", "internal", "b"},
+		Env:  map[string]string{"TOKEN": "x", "MODE": "prod"},
+	}
+
+	var paths []string
+	hook := func(path string, v interface{}) (interface{}, bool) {
+		paths = append(paths, path)
+		switch {
+		case path == "db.password" || path == "env.TOKEN":
+			return "<redacted>", true
+		case path == "debug" || v == "internal":
+			return nil, false
+		case path == "name":
+			return map[string]int{"v": 1}, true
+		}
+		return v, true
+	}
+	tests := []struct {
+		name string
+		opts MarshalOptions
+		want string
+	}{
+		{
+			"block",
+			MarshalOptions{Hook: hook},
+			"db: \n  host: db.local\n  password: \"<redacted>\"\nenv: \n  MODE: prod\n  TOKEN: \"<redacted>\"\nname: \n  v: 1\ntags: \n  - a\n  - b",
+		},
+		{
+			"flow",
+			MarshalOptions{Hook: hook, FlowThreshold: 2},
+			"db: \n  host: db.local\n  password: \"<redacted>\"\nenv: {MODE: prod, TOKEN: \"<redacted>\"}\nname: {v: 1}\ntags: [a, b]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths = nil
+			got, err := MarshalWithOptions(cfg, tt.opts)
+			if err != nil {
+				t.Fatalf("MarshalWithOptions() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalWithOptions() = %q, want %q", got, tt.want)
+			}
+			want := []string{".", "db", "db.host", "db.password", "debug", "env", "env.MODE", "env.TOKEN", "name", "name.v", "tags", "tags[0]", "tags[1]", "tags[2]"}
+			if !reflect.DeepEqual(paths, want) {
+				t.Errorf("hook paths = %q, want %q", paths, want)
+			}
+		})
+	}
+}
+
+func TestMarshalHook_Document(t *testing.T) {
+	drop := func(path string, v interface{}) (interface{}, bool) { return v, path != "." }
+	if got, err := MarshalWithOptions(map[string]int{"a": 1}, MarshalOptions{Hook: drop}); err != nil || string(got) != "null" {
+		t.Errorf("MarshalWithOptions() = %q, %v; want null", got, err)
+	}
+
+	keyed := func(path string, v interface{}) (interface{}, bool) {
+		if path == "[\"a.b\"]" || path == "[1]" {
+			return "hooked", true
+		}
+		return v, true
+	}
+	got, err := MarshalWithOptions(MapSlice{{Key: "a.b", Value: 1}, {Key: "c", Value: []int{1, 2}}}, MarshalOptions{Hook: keyed})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	if want := "a.b: hooked\nc: \n  - 1\n  - 2"; string(got) != want {
+		t.Errorf("MarshalWithOptions() = %q, want %q", got, want)
+	}
+
+	self := map[string]interface{}{}
+	self["self"] = self
+	keep := func(path string, v interface{}) (interface{}, bool) { return v, true }
+	if _, err := MarshalWithOptions(self, MarshalOptions{Hook: keep}); err == nil || !strings.Contains(err.Error(), "holds itself") {
+		t.Errorf("MarshalWithOptions(cycle) error = %v, want holds itself", err)
+	}
+}
+
+func TestMarshalHook_Anchors(t *testing.T) {
+	shared := map[string]string{"token": "x"}
+	redact := func(path string, v interface{}) (interface{}, bool) {
+		if strings.HasSuffix(path, ".token") {
+			return "<redacted>", true
+		}
+		return v, true
+	}
+	got, err := MarshalWithOptions(MapSlice{{Key: "a", Value: shared}, {Key: "b", Value: shared}}, MarshalOptions{Hook: redact, Anchors: true})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	if want := "a: &a\n  token: \"<redacted>\"\nb: *a"; string(got) != want {
+		t.Errorf("MarshalWithOptions() = %q, want %q", got, want)
+	}
+}
+
+// TestMarshalHook_RedactedRoundTrip checks that replacement values are
+// quoted like any other string: "***" written plain would read back as an
+// alias.
+func TestMarshalHook_RedactedRoundTrip(t *testing.T) {
+	type config struct {
+		User     string            `yaml:"user"`
+		Password string            `yaml:"password"`
+		Env      map[string]string `yaml:"env"`
+		Keys     []string          `yaml:"keys"`
+	}
+	cfg := config{User: "u", Password: "s3cret", Env: map[string]string{"TOKEN": "t"}, Keys: []string{"k1", "k2"}}
+	redact := func(path string, v interface{}) (interface{}, bool) {
+		if path == "password" || path == "env.TOKEN" || strings.HasPrefix(path, "keys[") {
+			return "***", true
+		}
+		return v, true
+	}
+	want := map[string]interface{}{
+		"user":     "u",
+		"password": "***",
+		"env":      map[string]interface{}{"TOKEN": "***"},
+		"keys":     []interface{}{"***", "***"},
+	}
+
+	for _, opts := range []MarshalOptions{
+		{Hook: redact},
+		{Hook: redact, FlowThreshold: 2},
+		{Hook: redact, QuoteStyle: QuoteSingle},
+	} {
+		data, err := MarshalWithOptions(cfg, opts)
+		if err != nil {
+			t.Fatalf("MarshalWithOptions() error = %v", err)
+		}
+		forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+			var got map[string]interface{}
+			if err := decode(data, &got); err != nil {
+				t.Fatalf("decode %q error = %v", data, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%q decoded to %#v, want %#v", data, got, want)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetHook(redact)
+	if err := enc.Encode(cfg); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := Unmarshal(buf.Bytes(), &got); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Encoder output %q decoded to %#v, %v; want %#v", buf.String(), got, err, want)
+	}
+}
+
+func TestEncoder_SetHook(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetHook(func(path string, v interface{}) (interface{}, bool) { return v, path != "secret" })
+	if err := enc.Encode(map[string]string{"secret": "x", "user": "u"}); err != nil {
+		t.Fatal(err)
+	}
+	enc.SetHook(nil)
+	if err := enc.Encode(map[string]string{"secret": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "user: u\n---\nsecret: x\n"; buf.String() != want {
+		t.Errorf("Encoder output = %q, want %q", buf.String(), want)
+	}
+}
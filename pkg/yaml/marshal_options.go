@@ -0,0 +1,370 @@
+package yaml
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarshalOptions configures MarshalWithOptions. The zero value lays out
+// documents as Marshal does.
+type MarshalOptions struct {
+	// Indent is the number of spaces per nesting level. Zero means 2.
+	Indent int
+
+	// FlowThreshold, if positive, writes sequences and Go maps or MapSlices
+	// of at most FlowThreshold scalars in flow style, as [80, 443] or
+	// {app: web}. Struct values and collections holding collections are
+	// always written in block style.
+	FlowThreshold int
+
+	// QuoteStyle chooses how string values are quoted.
+	QuoteStyle QuoteStyle
+
+	// Anchors writes a pointer, map or slice reached more than once in full
+	// at its first occurrence, with an &anchor named after its key, and as
+	// an *alias at the others, instead of repeating it. A map shared under
+	// the key "<<", as in MapSlice{{Key: "<<", Value: defaults}, ...}, is
+	// written as a merge. A value that holds itself is an error.
+	//
+	// Aliases are resolved by Parse and UnmarshalWithAST; the fast path of
+	// Unmarshal does not read them.
+	Anchors bool
+
+	// Compact writes a block mapping or sequence that is an entry of a
+	// sequence starting on the line of its "- ", as most YAML tools do:
+	//
+	//	- name: web
+	//	  port: 80
+	//	- - a
+	//	  - b
+	//
+	// rather than on the lines below it. With an Indent above 2, the dash is
+	// padded so that the entry's lines stay aligned ("-   name: web"); with
+	// an Indent of 1 there is no room for the "- ", and Compact has no
+	// effect.
+	Compact bool
+
+	// NonFinite chooses how NaN and infinite floats are written.
+	NonFinite NonFiniteFloat
+
+	// Hook, if set, is called for every value before it is written, to
+	// replace it or leave it out. See MarshalHook.
+	Hook MarshalHook
+}
+
+// QuoteStyle is the way MarshalWithOptions quotes string values.
+type QuoteStyle int
+
+const (
+	// QuoteWhenNeeded writes strings plain unless they would read back as
+	// another value, such as "true" or "8080", contain YAML indicators or
+	// control characters such as line breaks, start with an alias, anchor,
+	// tag or directive indicator, or start or end with a space; those are
+	// double-quoted. This is what Marshal does.
+	QuoteWhenNeeded QuoteStyle = iota
+	// QuoteSingle quotes the same strings as QuoteWhenNeeded, with single
+	// quotes unless they hold control characters, which only double-quoted
+	// scalars can escape.
+	QuoteSingle
+	// QuoteDouble double-quotes every string value.
+	QuoteDouble
+)
+
+// NonFiniteFloat is the way MarshalWithOptions writes NaN and infinite
+// floats, which YAML spells .nan, .inf and -.inf, but which some consumers,
+// such as JSON converters and strict schemas, cannot hold.
+type NonFiniteFloat int
+
+const (
+	// NonFiniteSpecial writes .nan, .inf and -.inf, which read back as the
+	// same floats. This is what Marshal does.
+	NonFiniteSpecial NonFiniteFloat = iota
+	// NonFiniteNull writes null in their place.
+	NonFiniteNull
+	// NonFiniteError fails the marshal with an error naming the value.
+	NonFiniteError
+)
+
+// MarshalWithOptions is like Marshal, with the layout configured by opts.
+// The output of Marshaler values, such as Tagged, is written as they
+// produce it.
+//
+// Example:
+//
+//	out, err := yaml.MarshalWithOptions(cfg, yaml.MarshalOptions{Indent: 4, FlowThreshold: 4})
+//	// ports: [80, 443]
+//	// spec:
+//	//     replicas: 3
+func MarshalWithOptions(v interface{}, opts MarshalOptions) ([]byte, error) {
+	e := &encodeState{
+		indent:    opts.Indent,
+		flow:      opts.FlowThreshold,
+		quote:     opts.QuoteStyle,
+		compact:   opts.Compact,
+		nonFinite: opts.NonFinite,
+	}
+	if e.indent <= 0 {
+		e.indent = defaultEncodeState.indent
+	}
+	if opts.Hook != nil {
+		var err error
+		if v, err = applyHook(v, opts.Hook, opts.Anchors); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Anchors {
+		var err error
+		if e.anchors, err = findAnchors(v); err != nil {
+			return nil, err
+		}
+	}
+	return e.marshal(v)
+}
+
+// encodeState holds the layout of one Marshal or MarshalWithOptions call,
+// for the encoders, which are cached by type and shared by all calls.
+type encodeState struct {
+	indent    int // spaces per nesting level
+	flow      int // FlowThreshold
+	quote     QuoteStyle
+	compact   bool // Compact
+	nonFinite NonFiniteFloat
+
+	anchors map[anchorKey]*anchor // values to alias, when Anchors is set
+}
+
+// defaultEncodeState lays out documents as Marshal does.
+var defaultEncodeState = &encodeState{indent: 2}
+
+// spaces is sliced to write indentation without allocating.
+const spaces = "                                                                "
+
+// appendIndent appends the indentation of nesting level to buf.
+func (e *encodeState) appendIndent(buf []byte, level int) []byte {
+	n := level * e.indent
+	for n > len(spaces) {
+		buf = append(buf, spaces...)
+		n -= len(spaces)
+	}
+	if n > 0 {
+		buf = append(buf, spaces[:n]...)
+	}
+	return buf
+}
+
+// appendString appends s as a scalar, quoted as e.quote says. In a flow
+// collection (inFlow), a comma also needs quoting.
+func (e *encodeState) appendString(buf []byte, s string, inFlow bool) []byte {
+	quote := needsQuotingFast(s) || (inFlow && strings.IndexByte(s, ',') >= 0)
+	switch {
+	case e.quote == QuoteDouble || (quote && (e.quote != QuoteSingle || hasControl(s))):
+		buf = append(buf, '"')
+		buf = appendEscapedYAMLString(buf, s)
+		return append(buf, '"')
+	case quote:
+		buf = append(buf, '\'')
+		buf = append(buf, strings.ReplaceAll(s, "'", "''")...)
+		return append(buf, '\'')
+	default:
+		return append(buf, s...)
+	}
+}
+
+// appendFloat appends f, a float of bitSize bits, writing NaN and the
+// infinities as e.nonFinite says.
+func (e *encodeState) appendFloat(buf []byte, f float64, bitSize int) ([]byte, error) {
+	if !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return strconv.AppendFloat(buf, f, 'g', -1, bitSize), nil
+	}
+	switch e.nonFinite {
+	case NonFiniteNull:
+		return append(buf, "null"...), nil
+	case NonFiniteError:
+		return buf, fmt.Errorf("yaml: unsupported float value %v", f)
+	}
+	switch {
+	case math.IsNaN(f):
+		return append(buf, ".nan"...), nil
+	case f > 0:
+		return append(buf, ".inf"...), nil
+	default:
+		return append(buf, "-.inf"...), nil
+	}
+}
+
+// hasControl reports whether s holds a control character.
+func hasControl(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlock reports whether rv is written as a block collection, starting on
+// the line after its key or dash.
+func (e *encodeState) isBlock(rv reflect.Value) bool {
+	return isComplexType(rv) && !e.flows(rv)
+}
+
+// flows reports whether rv, a collection, is written in flow style: it is
+// a sequence or a map of at most e.flow scalars.
+func (e *encodeState) flows(rv reflect.Value) bool {
+	if e.flow <= 0 {
+		return false
+	}
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	if isMarshaler(rv.Type()) || (rv.CanAddr() && isMarshaler(reflect.PointerTo(rv.Type()))) {
+		return false
+	}
+
+	switch {
+	case rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array && rv.Kind() != reflect.Map:
+		return false
+	case isBinary(rv.Type()):
+		return false
+	case rv.Len() == 0 || rv.Len() > e.flow:
+		return false
+	case rv.Type() == mapSliceType:
+		for i := 0; i < rv.Len(); i++ {
+			if !isFlowScalar(rv.Index(i).Field(1)) {
+				return false
+			}
+		}
+	case rv.Kind() == reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return false // left to the map encoder to reject
+		}
+		for iter := rv.MapRange(); iter.Next(); {
+			if !isFlowScalar(iter.Value()) {
+				return false
+			}
+		}
+	default:
+		for i := 0; i < rv.Len(); i++ {
+			if !isFlowScalar(rv.Index(i)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isFlowScalar reports whether rv is written as a scalar that a flow
+// collection can hold. The output of Marshaler values is not known in
+// advance, so they do not count.
+func isFlowScalar(rv reflect.Value) bool {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return true
+		}
+		rv = rv.Elem()
+	}
+	if isMarshaler(rv.Type()) || (rv.CanAddr() && isMarshaler(reflect.PointerTo(rv.Type()))) {
+		return false
+	}
+	if rv.Type() == timeType {
+		return true
+	}
+	switch rv.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// appendFlow appends rv, for which flows reports true, as a flow sequence
+// or mapping. Keys are sorted as in block mappings, except for MapSlices.
+func (e *encodeState) appendFlow(buf []byte, rv reflect.Value) ([]byte, error) {
+	var err error
+	switch {
+	case rv.Type() == mapSliceType:
+		buf = append(buf, '{')
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				buf = append(buf, ',', ' ')
+			}
+			item := rv.Index(i)
+			if key := item.Field(0).Elem(); key.Kind() == reflect.String {
+				buf = appendFlowKey(buf, key.String())
+			} else if buf, err = e.appendMapSliceKey(buf, key); err != nil {
+				return buf, err
+			}
+			buf = append(buf, ':', ' ')
+			if buf, err = e.appendFlowScalar(buf, item.Field(1)); err != nil {
+				return buf, err
+			}
+		}
+		return append(buf, '}'), nil
+
+	case rv.Kind() == reflect.Map:
+		keys := make([]string, 0, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			keys = append(keys, iter.Key().String())
+		}
+		sort.Strings(keys)
+		buf = append(buf, '{')
+		for i, k := range keys {
+			if i > 0 {
+				buf = append(buf, ',', ' ')
+			}
+			buf = appendFlowKey(buf, k)
+			buf = append(buf, ':', ' ')
+			key := reflect.ValueOf(k).Convert(rv.Type().Key())
+			if buf, err = e.appendFlowScalar(buf, rv.MapIndex(key)); err != nil {
+				return buf, err
+			}
+		}
+		return append(buf, '}'), nil
+
+	default:
+		buf = append(buf, '[')
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				buf = append(buf, ',', ' ')
+			}
+			if buf, err = e.appendFlowScalar(buf, rv.Index(i)); err != nil {
+				return buf, err
+			}
+		}
+		return append(buf, ']'), nil
+	}
+}
+
+// appendFlowScalar appends rv, for which isFlowScalar reports true.
+func (e *encodeState) appendFlowScalar(buf []byte, rv reflect.Value) ([]byte, error) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return append(buf, "null"...), nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.String {
+		return e.appendString(buf, rv.String(), true), nil
+	}
+	return yamlEncoderForType(rv.Type())(e, buf, rv, 0)
+}
+
+// appendFlowKey appends a string key of a flow mapping. Keys are written as
+// is, as in block mappings, unless they hold flow indicators.
+func appendFlowKey(buf []byte, key string) []byte {
+	if !strings.ContainsAny(key, ",[]{}") {
+		return append(buf, key...)
+	}
+	buf = append(buf, '"')
+	buf = appendEscapedYAMLString(buf, key)
+	return append(buf, '"')
+}
@@ -0,0 +1,295 @@
+package yaml
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type optionsConfig struct {
+	Name   string            `yaml:"name"`
+	Ports  []int             `yaml:"ports"`
+	Labels map[string]string `yaml:"labels"`
+	Spec   struct {
+		Replicas int      `yaml:"replicas"`
+		Hosts    []string `yaml:"hosts"`
+	} `yaml:"spec"`
+}
+
+func newOptionsConfig() optionsConfig {
+	var c optionsConfig
+	c.Name = "web"
+	c.Ports = []int{80, 443}
+	c.Labels = map[string]string{"app": "web", "tier": "front end"}
+	c.Spec.Replicas = 3
+	c.Spec.Hosts = []string{"a.example", "b.example", "c.example"}
+	return c
+}
+
+func TestMarshalWithOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts MarshalOptions
+		want string
+	}{
+		{
+			name: "indent",
+			opts: MarshalOptions{Indent: 4},
+			want: "labels: \n    app: web\n    tier: front end\nname: web\nports: \n    - 80\n    - 443\n" +
+				"spec: \n    hosts: \n        - a.example\n        - b.example\n        - c.example\n    replicas: 3",
+		},
+		{
+			name: "flow threshold",
+			opts: MarshalOptions{FlowThreshold: 2},
+			want: "labels: {app: web, tier: front end}\nname: web\nports: [80, 443]\n" +
+				"spec: \n  hosts: \n    - a.example\n    - b.example\n    - c.example\n  replicas: 3",
+		},
+		{
+			name: "single quotes",
+			opts: MarshalOptions{QuoteStyle: QuoteSingle, FlowThreshold: 3},
+			want: "labels: {app: web, tier: front end}\nname: web\nports: [80, 443]\n" +
+				"spec: \n  hosts: [a.example, b.example, c.example]\n  replicas: 3",
+		},
+		{
+			name: "double quotes",
+			opts: MarshalOptions{QuoteStyle: QuoteDouble},
+			want: "labels: \n  app: \"web\"\n  tier: \"front end\"\nname: \"web\"\nports: \n  - 80\n  - 443\n" +
+				"spec: \n  hosts: \n    - \"a.example\"\n    - \"b.example\"\n    - \"c.example\"\n  replicas: 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MarshalWithOptions(newOptionsConfig(), tt.opts)
+			if err != nil {
+				t.Fatalf("MarshalWithOptions() error = %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("MarshalWithOptions() =\n%s\nwant:\n%s", out, tt.want)
+			}
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var got optionsConfig
+				if err := decode(out, &got); err != nil {
+					t.Fatalf("decode error = %v", err)
+				}
+				if !reflect.DeepEqual(got, newOptionsConfig()) {
+					t.Errorf("round trip = %+v", got)
+				}
+			})
+		})
+	}
+}
+
+func TestMarshalWithOptions_ZeroIsMarshal(t *testing.T) {
+	values := []interface{}{
+		newOptionsConfig(),
+		map[string]interface{}{"a": []interface{}{1, "x", map[string]interface{}{"b": true}}, "c": "true"},
+		MapSlice{{Key: "z", Value: []string{"y"}}, {Key: 1, Value: nil}},
+	}
+	for _, v := range values {
+		want, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		got, err := MarshalWithOptions(v, MarshalOptions{})
+		if err != nil || string(got) != string(want) {
+			t.Errorf("MarshalWithOptions() = %q, %v; want %q", got, err, want)
+		}
+	}
+}
+
+func TestMarshalWithOptions_Quoting(t *testing.T) {
+	tests := []struct {
+		name  string
+		style QuoteStyle
+		in    string
+		want  string
+	}{
+		{"plain stays plain", QuoteSingle, "hello", "hello"},
+		{"reserved word", QuoteSingle, "true", "'true'"},
+		{"embedded quote", QuoteSingle, "it's: here", "'it''s: here'"},
+		{"control character", QuoteSingle, "a\tb: c", "\"a\\tb: c\""},
+		{"double", QuoteDouble, "hello", "\"hello\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MarshalWithOptions(tt.in, MarshalOptions{QuoteStyle: tt.style})
+			if err != nil {
+				t.Fatalf("MarshalWithOptions() error = %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("MarshalWithOptions(%q) = %q, want %q", tt.in, out, tt.want)
+			}
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var got string
+				if err := decode(out, &got); err != nil || got != tt.in {
+					t.Errorf("decode = %q, %v; want %q", got, err, tt.in)
+				}
+			})
+		})
+	}
+}
+
+func TestMarshalWithOptions_FlowNested(t *testing.T) {
+	v := map[string]interface{}{
+		"matrix": [][]int{{1, 2}, {3, 4}},
+		"users":  []map[string]string{{"name": "a"}},
+		"empty":  []int{},
+		"tags":   []string{"a, b", "c"},
+	}
+	out, err := MarshalWithOptions(v, MarshalOptions{FlowThreshold: 4})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	want := "empty: []\nmatrix: \n  - [1, 2]\n  - [3, 4]\ntags: [\"a, b\", c]\nusers: \n  - {name: a}"
+	if string(out) != want {
+		t.Errorf("MarshalWithOptions() =\n%s\nwant:\n%s", out, want)
+	}
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var got struct {
+			Matrix [][]int             `yaml:"matrix"`
+			Users  []map[string]string `yaml:"users"`
+			Tags   []string            `yaml:"tags"`
+		}
+		if err := decode(out, &got); err != nil {
+			t.Fatalf("decode error = %v", err)
+		}
+		if !reflect.DeepEqual(got.Matrix, v["matrix"]) || !reflect.DeepEqual(got.Users, v["users"]) ||
+			!reflect.DeepEqual(got.Tags, v["tags"]) {
+			t.Errorf("round trip = %+v", got)
+		}
+	})
+}
+
+func TestMarshalWithOptions_Compact(t *testing.T) {
+	type service struct {
+		Name  string   `yaml:"name"`
+		Ports []int    `yaml:"ports"`
+		Hosts []string `yaml:"hosts,omitempty"`
+	}
+	v := map[string]interface{}{
+		"services": []service{{Name: "web", Ports: []int{80, 443}}, {Name: "db", Ports: []int{5432}, Hosts: []string{"a"}}},
+		"matrix":   [][]int{{1, 2}, {3}},
+	}
+
+	tests := []struct {
+		name string
+		opts MarshalOptions
+		want string
+	}{
+		{
+			name: "default indent",
+			opts: MarshalOptions{Compact: true},
+			want: "matrix: \n  - - 1\n    - 2\n  - - 3\n" +
+				"services: \n  - name: web\n    ports: \n      - 80\n      - 443\n" +
+				"  - hosts: \n      - a\n    name: db\n    ports: \n      - 5432",
+		},
+		{
+			name: "padded dash",
+			opts: MarshalOptions{Compact: true, Indent: 4},
+			want: "matrix: \n    -   - 1\n        - 2\n    -   - 3\n" +
+				"services: \n    -   name: web\n        ports: \n            - 80\n            - 443\n" +
+				"    -   hosts: \n            - a\n        name: db\n        ports: \n            - 5432",
+		},
+		{
+			name: "no room for the dash",
+			opts: MarshalOptions{Compact: true, Indent: 1},
+			want: "matrix: \n - \n  - 1\n  - 2\n - \n  - 3\n" +
+				"services: \n - \n  name: web\n  ports: \n   - 80\n   - 443\n" +
+				" - \n  hosts: \n   - a\n  name: db\n  ports: \n   - 5432",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MarshalWithOptions(v, tt.opts)
+			if err != nil {
+				t.Fatalf("MarshalWithOptions() error = %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("MarshalWithOptions() =\n%s\nwant:\n%s", out, tt.want)
+			}
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var got struct {
+					Services []service `yaml:"services"`
+					Matrix   [][]int   `yaml:"matrix"`
+				}
+				if err := decode(out, &got); err != nil {
+					t.Fatalf("decode error = %v", err)
+				}
+				if !reflect.DeepEqual(got.Services, v["services"]) || !reflect.DeepEqual(got.Matrix, v["matrix"]) {
+					t.Errorf("round trip = %+v", got)
+				}
+			})
+		})
+	}
+}
+
+func TestMarshalWithOptions_CompactNodes(t *testing.T) {
+	input := "- name: a\n  port: 1\n- - x\n  - y"
+	var doc Node
+	if err := Unmarshal([]byte(input), &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	for _, v := range []interface{}{doc, doc.Content} {
+		out, err := MarshalWithOptions(v, MarshalOptions{Compact: true})
+		if err != nil {
+			t.Fatalf("MarshalWithOptions() error = %v", err)
+		}
+		if string(out) != input {
+			t.Errorf("MarshalWithOptions(%T) = %q, want %q", v, out, input)
+		}
+	}
+}
+
+func TestMarshalWithOptions_NonFinite(t *testing.T) {
+	type reading struct {
+		Min  float64 `yaml:"min"`
+		Max  float32 `yaml:"max"`
+		Mean float64 `yaml:"mean"`
+		Last float64 `yaml:"last"`
+	}
+	v := reading{Min: math.Inf(-1), Max: float32(math.Inf(1)), Mean: math.NaN(), Last: 1.5}
+
+	tests := []struct {
+		name      string
+		nonFinite NonFiniteFloat
+		want      string
+	}{
+		{"special", NonFiniteSpecial, "last: 1.5\nmax: .inf\nmean: .nan\nmin: -.inf"},
+		{"null", NonFiniteNull, "last: 1.5\nmax: null\nmean: null\nmin: null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MarshalWithOptions(v, MarshalOptions{NonFinite: tt.nonFinite})
+			if err != nil || string(out) != tt.want {
+				t.Fatalf("MarshalWithOptions() = %q, %v; want %q", out, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		out, err := MarshalWithOptions(v, MarshalOptions{NonFinite: NonFiniteError})
+		if err == nil || !strings.Contains(err.Error(), "unsupported float value +Inf") {
+			t.Errorf("MarshalWithOptions() = %q, %v; want an error for +Inf", out, err)
+		}
+		if _, err := MarshalWithOptions(reading{Last: 2}, MarshalOptions{NonFinite: NonFiniteError}); err != nil {
+			t.Errorf("MarshalWithOptions() of finite floats error = %v", err)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		out, err := Marshal([]interface{}{math.NaN(), math.Inf(1), math.Inf(-1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+			var got []float64
+			if err := decode(out, &got); err != nil {
+				t.Fatalf("decode(%q) error = %v", out, err)
+			}
+			if len(got) != 3 || !math.IsNaN(got[0]) || !math.IsInf(got[1], 1) || !math.IsInf(got[2], -1) {
+				t.Errorf("decode(%q) = %v, want [NaN +Inf -Inf]", out, got)
+			}
+		})
+	})
+}
@@ -1,6 +1,10 @@
 package yaml
 
 import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -263,6 +267,84 @@ func TestMarshal_EmptyValues(t *testing.T) {
 			t.Errorf("Expected output to contain empty string quotes, got: %s", output)
 		}
 	})
+
+}
+
+// TestMarshal_EmptyCollections tests that empty maps, slices and arrays are
+// written inline as {} and [] so they decode back as empty collections rather
+// than null, and that nil ones still encode as null.
+func TestMarshal_EmptyCollections(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{name: "empty map", input: map[string]int{}, want: "{}"},
+		{name: "empty slice", input: []string{}, want: "[]"},
+		{name: "empty array", input: [0]int{}, want: "[]"},
+		{name: "in a sequence", input: []interface{}{[]int{}, map[string]int{}}, want: "- []\n- {}"},
+		{name: "in a mapping", input: map[string]interface{}{"a": []int{}, "b": map[string]int{}}, want: "a: []\nb: {}"},
+		{
+			name: "struct fields",
+			input: struct {
+				Map   map[string]int `yaml:"map"`
+				List  []string       `yaml:"list"`
+				Array [0]int         `yaml:"array"`
+				Any   interface{}    `yaml:"any"`
+			}{Map: map[string]int{}, List: []string{}, Any: map[string]interface{}{}},
+			want: "any: {}\narray: []\nlist: []\nmap: {}",
+		},
+		{
+			name: "nil struct fields",
+			input: struct {
+				Map  map[string]int `yaml:"map"`
+				List []string       `yaml:"list"`
+			}{},
+			want: "list: null\nmap: null",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %q, want %q", got, tt.want)
+			}
+
+			var buf bytes.Buffer
+			if err := marshalValue(reflect.ValueOf(tt.input), &buf, 0); err != nil {
+				t.Fatalf("marshalValue() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("marshalValue() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshal_EmptyCollectionsRoundTrip(t *testing.T) {
+	type doc struct {
+		List []string         `yaml:"list"`
+		Map  map[string]int   `yaml:"map"`
+		Any  map[string][]int `yaml:"any"`
+	}
+	in := doc{List: []string{}, Map: map[string]int{}, Any: map[string][]int{"k": {}}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var got doc
+		if err := decode(data, &got); err != nil {
+			t.Fatalf("decode %q error = %v", data, err)
+		}
+		if !reflect.DeepEqual(got, in) {
+			t.Errorf("decode %q = %#v, want %#v", data, got, in)
+		}
+	})
 }
 
 // TestMarshal_NumericTypes tests marshaling of various numeric types
@@ -571,6 +653,31 @@ func TestNeedsQuoting_EdgeCases(t *testing.T) {
 			input:         "?value",
 			shouldContain: `"?value"`,
 		},
+		{
+			name:          "starts with alias indicator",
+			input:         "*value",
+			shouldContain: `"*value"`,
+		},
+		{
+			name:          "starts with tag indicator",
+			input:         "!value",
+			shouldContain: `"!value"`,
+		},
+		{
+			name:          "trailing space",
+			input:         "value ",
+			shouldContain: `"value "`,
+		},
+		{
+			name:          "line break",
+			input:         "a\nb",
+			shouldContain: `"a\nb"`,
+		},
+		{
+			name:          "control characters",
+			input:         "a\x01\x1b\x7f",
+			shouldContain: `"a\x01\e\x7F"`,
+		},
 		{
 			name:          "simple word no quotes",
 			input:         "simple",
@@ -654,3 +761,262 @@ func TestIsComplexType(t *testing.T) {
 		})
 	}
 }
+
+// TestMarshal_KeywordLikeStringsRoundTrip tests that strings which would
+// resolve to null, bool, or a number as plain scalars are quoted, so they
+// decode back as the same string.
+func TestMarshal_KeywordLikeStringsRoundTrip(t *testing.T) {
+	type Doc struct {
+		Value string `yaml:"value"`
+	}
+	inputs := []string{
+		"yes", "No", "on", "OFF", "True", "null", "Null", "NULL", "~",
+		"0x1F", "0o17", ".inf", "-.Inf", ".NaN", "1e3", "+1", "123",
+	}
+
+	for _, s := range inputs {
+		t.Run(s, func(t *testing.T) {
+			for _, v := range []interface{}{map[string]string{"value": s}, Doc{Value: s}} {
+				data, err := Marshal(v)
+				if err != nil {
+					t.Fatalf("Marshal(%T) error: %v", v, err)
+				}
+				forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+					var got map[string]interface{}
+					if err := decode(data, &got); err != nil {
+						t.Fatalf("unmarshal %q error: %v", data, err)
+					}
+					if got["value"] != s {
+						t.Errorf("%T marshaled as %q decoded to %#v, want %q", v, data, got["value"], s)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestMarshal_QuotingRoundTrip is a property test over the classes of
+// strings that cannot be written plain: line breaks and other control
+// characters, a leading alias, anchor, tag or directive indicator, and
+// leading or trailing spaces. Every encoder and quote style must write
+// each string so that both decoders read it back unchanged.
+func TestMarshal_QuotingRoundTrip(t *testing.T) {
+	prefixes := []string{"", "*", "&", "!", "%", ",", "?", "-", " ", "\t", "\n"}
+	bodies := []string{"a", "b c", "a\nb", "a\r\nb", "x\x01y", "\x7f", "\x1b[0m", "é", "a: b", "#c", `"q"`, "'s'", `\`, "*ref", "&x y"}
+	suffixes := []string{"", " ", "  ", "\n", "\t", "\x00"}
+
+	inputs := []string{"a\n", "\n", "*alias", "&anchor", "!tag", "%TAG", "trail ", " ", "\x00", "\a\b\v\f", "line1\nline2\n"}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		inputs = append(inputs, prefixes[rng.Intn(len(prefixes))]+
+			bodies[rng.Intn(len(bodies))]+bodies[rng.Intn(len(bodies))]+
+			suffixes[rng.Intn(len(suffixes))])
+	}
+
+	marshalers := []struct {
+		name    string
+		marshal func(v interface{}) ([]byte, error)
+	}{
+		{"Marshal", Marshal},
+		{"QuoteSingle", func(v interface{}) ([]byte, error) {
+			return MarshalWithOptions(v, MarshalOptions{QuoteStyle: QuoteSingle})
+		}},
+		{"flow", func(v interface{}) ([]byte, error) {
+			return MarshalWithOptions(v, MarshalOptions{FlowThreshold: 8})
+		}},
+		{"legacy", func(v interface{}) ([]byte, error) {
+			var buf bytes.Buffer
+			err := marshalValue(reflect.ValueOf(v), &buf, 0)
+			return buf.Bytes(), err
+		}},
+	}
+	for _, m := range marshalers {
+		t.Run(m.name, func(t *testing.T) {
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				for _, s := range inputs {
+					data, err := m.marshal(map[string]interface{}{"v": s, "l": []string{s}})
+					if err != nil {
+						t.Fatalf("marshal %q error: %v", s, err)
+					}
+					var got struct {
+						V string   `yaml:"v"`
+						L []string `yaml:"l"`
+					}
+					if err := decode(data, &got); err != nil {
+						t.Errorf("%q marshaled as %q: decode error: %v", s, data, err)
+						continue
+					}
+					if got.V != s || len(got.L) != 1 || got.L[0] != s {
+						t.Errorf("%q marshaled as %q decoded to %q, %q", s, data, got.V, got.L)
+					}
+				}
+			})
+		})
+	}
+}
+
+// rawYAML marshals itself as the YAML text it holds.
+type rawYAML string
+
+func (r rawYAML) MarshalYAML() ([]byte, error) { return []byte(r), nil }
+
+// addrYAML marshals itself as a mapping, but only through a pointer.
+type addrYAML struct{ A, B int }
+
+func (a *addrYAML) MarshalYAML() ([]byte, error) {
+	return []byte(fmt.Sprintf("a: %d\nb: %d\n", a.A, a.B)), nil
+}
+
+// TestMarshal_MarshalerLayout checks that scalar Marshaler output stays inline
+// while block collections start on their own line, wherever the value sits.
+func TestMarshal_MarshalerLayout(t *testing.T) {
+	type Doc struct {
+		Value rawYAML `yaml:"value"`
+	}
+	tests := []struct {
+		name string
+		raw  rawYAML
+		doc  string // Marshal(Doc{Value: raw})
+		seq  string // Marshal([]rawYAML{raw})
+	}{
+		{"scalar", "1.2.0", "value: 1.2.0", "- 1.2.0"},
+		{"scalar with newline", "x\n", "value: x", "- x"},
+		{"flow mapping", "{a: 1}", "value: {a: 1}", "- {a: 1}"},
+		{"url", "http://example.com", "value: http://example.com", "- http://example.com"},
+		{"block mapping", "a: 1\nb: 2\n", "value: \n  a: 1\n  b: 2", "- \n  a: 1\n  b: 2"},
+		{"one-line mapping", "a: 1", "value: \n  a: 1", "- \n  a: 1"},
+		{"block sequence", "- x\n- y", "value: \n  - x\n  - y", "- \n  - x\n  - y"},
+		{"literal scalar", "|\n  one\n  two", "value: |\n    one\n    two", "- |\n    one\n    two"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(Doc{Value: tt.raw})
+			if err != nil {
+				t.Fatalf("Marshal(Doc) error = %v", err)
+			}
+			if string(got) != tt.doc {
+				t.Errorf("Marshal(Doc) = %q, want %q", got, tt.doc)
+			}
+			got, err = Marshal([]rawYAML{tt.raw})
+			if err != nil {
+				t.Fatalf("Marshal(slice) error = %v", err)
+			}
+			if string(got) != tt.seq {
+				t.Errorf("Marshal(slice) = %q, want %q", got, tt.seq)
+			}
+		})
+	}
+
+	t.Run("map value", func(t *testing.T) {
+		got, err := Marshal(map[string]interface{}{"m": rawYAML("a: 1"), "s": rawYAML("x")})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if want := "m: \n  a: 1\ns: x"; string(got) != want {
+			t.Errorf("Marshal() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("pointer receiver", func(t *testing.T) {
+		type Outer struct {
+			Inner addrYAML `yaml:"inner"`
+		}
+		got, err := Marshal(&Outer{Inner: addrYAML{A: 1, B: 2}})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if want := "inner: \n  a: 1\n  b: 2"; string(got) != want {
+			t.Errorf("Marshal() = %q, want %q", got, want)
+		}
+		var back map[string]map[string]int
+		if err := Unmarshal(got, &back); err != nil {
+			t.Fatalf("Unmarshal(%q) error = %v", got, err)
+		}
+		if back["inner"]["b"] != 2 {
+			t.Errorf("round trip = %v", back)
+		}
+	})
+
+	t.Run("top level", func(t *testing.T) {
+		got, err := Marshal(rawYAML("a: 1\n"))
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if string(got) != "a: 1\n" {
+			t.Errorf("Marshal() = %q, want the MarshalYAML output unchanged", got)
+		}
+	})
+}
+
+// TestMarshal_MapKeysSorted checks that maps nested in structs are written
+// with their keys sorted, so that generated files are stable across runs.
+func TestMarshal_MapKeysSorted(t *testing.T) {
+	type Manifest struct {
+		Name   string                 `yaml:"name"`
+		Labels map[string]interface{} `yaml:"labels"`
+		Env    map[string]string      `yaml:"env"`
+	}
+	m := Manifest{
+		Name: "web",
+		Labels: map[string]interface{}{
+			"tier": "frontend", "app": "web", "zone": "b", "owner": "team",
+			"extra": map[string]interface{}{"z": 1, "m": 2, "a": 3},
+		},
+		Env: map[string]string{"PORT": "8080", "HOST": "0.0.0.0", "DEBUG": "false", "API_URL": "x"},
+	}
+	want := "env: \n  API_URL: x\n  DEBUG: \"false\"\n  HOST: 0.0.0.0\n  PORT: \"8080\"\n" +
+		"labels: \n  app: web\n  extra: \n    a: 3\n    m: 2\n    z: 1\n  owner: team\n  tier: frontend\n  zone: b\n" +
+		"name: web"
+	for i := 0; i < 20; i++ {
+		got, err := Marshal(m)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if string(got) != want {
+			t.Fatalf("Marshal() =\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+// TestMarshalAll checks that MarshalAll writes "---"-separated documents
+// that ParseMultiDoc reads back one by one.
+func TestMarshalAll(t *testing.T) {
+	type resource struct {
+		Kind string `yaml:"kind"`
+		Name string `yaml:"name"`
+	}
+	docs := []interface{}{
+		resource{Kind: "Service", Name: "web"},
+		&resource{Kind: "Deployment", Name: "web"},
+		[]string{"a", "b"},
+		nil,
+	}
+	got, err := MarshalAll(docs)
+	if err != nil {
+		t.Fatalf("MarshalAll() error = %v", err)
+	}
+	want := "kind: Service\nname: web\n" +
+		"---\nkind: Deployment\nname: web\n" +
+		"---\n- a\n- b\n" +
+		"---\nnull\n"
+	if string(got) != want {
+		t.Errorf("MarshalAll() = %q, want %q", got, want)
+	}
+
+	parsed, err := ParseMultiDoc(string(got))
+	if err != nil {
+		t.Fatalf("ParseMultiDoc() error = %v", err)
+	}
+	if len(parsed) != len(docs) {
+		t.Errorf("ParseMultiDoc() = %d documents, want %d", len(parsed), len(docs))
+	}
+
+	if got, err := MarshalAll(nil); err != nil || len(got) != 0 {
+		t.Errorf("MarshalAll(nil) = %q, %v; want empty output", got, err)
+	}
+
+	_, err = MarshalAll([]interface{}{"ok", make(chan int)})
+	if err == nil || !strings.Contains(err.Error(), "document 1") {
+		t.Errorf("MarshalAll() of an unsupported type: error = %v, want one naming document 1", err)
+	}
+}
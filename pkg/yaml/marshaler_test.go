@@ -0,0 +1,298 @@
+package yaml
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// duration marshals as a string such as "1m30s" in both directions.
+type duration time.Duration
+
+func (d duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *duration) UnmarshalYAML(value *Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	*d = duration(v)
+	return err
+}
+
+// point records the node it was decoded from.
+type point struct {
+	X, Y int
+	node Node
+}
+
+func (p *point) UnmarshalYAML(value *Node) error {
+	p.node = *value
+	var xy [2]int
+	if value.Kind == SequenceKind {
+		if err := value.Decode(&xy); err != nil {
+			return err
+		}
+	} else {
+		var m struct{ X, Y int }
+		if err := value.Decode(&m); err != nil {
+			return err
+		}
+		xy = [2]int{m.X, m.Y}
+	}
+	p.X, p.Y = xy[0], xy[1]
+	return nil
+}
+
+func TestUnmarshaler(t *testing.T) {
+	type Config struct {
+		Timeout  duration            `yaml:"timeout"`
+		Retry    *duration           `yaml:"retry"`
+		Backoff  []duration          `yaml:"backoff"`
+		Limits   map[string]duration `yaml:"limits"`
+		Deadline duration            `yaml:"deadline"`
+	}
+	input := "timeout: 1m30s\n" +
+		"retry: 5s\n" +
+		"backoff: [1s, 2s]\n" +
+		"limits:\n  read: 10s\n  write: \"1h\"\n" +
+		"deadline: null\n"
+	want := Config{
+		Timeout:  duration(90 * time.Second),
+		Retry:    func() *duration { d := duration(5 * time.Second); return &d }(),
+		Backoff:  []duration{duration(time.Second), duration(2 * time.Second)},
+		Limits:   map[string]duration{"read": duration(10 * time.Second), "write": duration(time.Hour)},
+		Deadline: 0,
+	}
+
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		cfg := Config{Deadline: 1}
+		if err := decode([]byte(input), &cfg); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("got %+v, want %+v", cfg, want)
+		}
+	})
+}
+
+func TestUnmarshalerNode(t *testing.T) {
+	input := "name: shape\n" +
+		"origin:\n  x: 1\n  y: 2\n" +
+		"path:\n  - [3, 4]\n  - {x: 5, y: 6}\n"
+	type Doc struct {
+		Origin point   `yaml:"origin"`
+		Path   []point `yaml:"path"`
+	}
+
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var doc Doc
+		if err := decode([]byte(input), &doc); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		got := []point{doc.Origin, doc.Path[0], doc.Path[1]}
+		want := []struct {
+			x, y, line, column int
+			kind               Kind
+		}{
+			{1, 2, 3, 3, MappingKind},
+			{3, 4, 6, 5, SequenceKind},
+			{5, 6, 7, 5, MappingKind},
+		}
+		for i, w := range want {
+			p := got[i]
+			if p.X != w.x || p.Y != w.y {
+				t.Errorf("point %d = (%d, %d), want (%d, %d)", i, p.X, p.Y, w.x, w.y)
+			}
+			if p.node.Kind != w.kind || p.node.Line != w.line || p.node.Column != w.column {
+				t.Errorf("point %d node = %v at %d:%d, want %v at %d:%d",
+					i, p.node.Kind, p.node.Line, p.node.Column, w.kind, w.line, w.column)
+			}
+		}
+	})
+}
+
+func TestUnmarshalerNodeOneLine(t *testing.T) {
+	input := "a: [x, é, {k: v}, [1]]\nb: [y, z]\n"
+	type Doc struct {
+		A []Node `yaml:"a"`
+		B []Node `yaml:"b"`
+	}
+
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var doc Doc
+		if err := decode([]byte(input), &doc); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		got := append(doc.A, doc.B...)
+		want := []struct {
+			kind         Kind
+			line, column int
+		}{
+			{ScalarKind, 1, 5},
+			{ScalarKind, 1, 8},
+			{MappingKind, 1, 11},
+			{SequenceKind, 1, 19},
+			{ScalarKind, 2, 5},
+			{ScalarKind, 2, 8},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d nodes, want %d", len(got), len(want))
+		}
+		for i, w := range want {
+			if n := got[i]; n.Kind != w.kind || n.Line != w.line || n.Column != w.column {
+				t.Errorf("node %d = %v at %d:%d, want %v at %d:%d", i, n.Kind, n.Line, n.Column, w.kind, w.line, w.column)
+			}
+		}
+	})
+}
+
+func TestUnmarshalerNodeFields(t *testing.T) {
+	input := "kind: Widget\nspec:\n  size: 3\n  tags: [a, b]\n"
+	type Envelope struct {
+		Kind string `yaml:"kind"`
+		Spec Node   `yaml:"spec"`
+	}
+
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var env Envelope
+		if err := decode([]byte(input), &env); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if env.Kind != "Widget" || env.Spec.Kind != MappingKind || env.Spec.Line != 3 {
+			t.Fatalf("got kind %q, spec %v at line %d", env.Kind, env.Spec.Kind, env.Spec.Line)
+		}
+		var spec struct {
+			Size int      `yaml:"size"`
+			Tags []string `yaml:"tags"`
+		}
+		if err := env.Spec.Decode(&spec); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if spec.Size != 3 || !reflect.DeepEqual(spec.Tags, []string{"a", "b"}) {
+			t.Errorf("spec = %+v", spec)
+		}
+	})
+}
+
+func TestUnmarshalerError(t *testing.T) {
+	type Config struct {
+		Timeout duration `yaml:"timeout"`
+	}
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var cfg Config
+		err := decode([]byte("timeout: soon\n"), &cfg)
+		if err == nil || !strings.Contains(err.Error(), "soon") {
+			t.Errorf("decode = %v, want the time.ParseDuration error", err)
+		}
+	})
+
+	var pe *ParseError
+	err := Unmarshal([]byte("a: 1\ntimeout: soon\n"), &struct {
+		Timeout duration `yaml:"timeout"`
+	}{})
+	if !errors.As(err, &pe) || pe.Line != 2 || pe.Column != 10 {
+		t.Errorf("Unmarshal = %v, want a *ParseError at 2:10", err)
+	}
+}
+
+func TestUnmarshalerDecoder(t *testing.T) {
+	input := "timeout: 1s\n---\n\ntimeout: 2s\norigin: [1, 2]\n"
+	type Config struct {
+		Timeout duration `yaml:"timeout"`
+		Origin  *point   `yaml:"origin"`
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.DisallowUnknownFields()
+	var first, second Config
+	if err := dec.Decode(&first); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if err := dec.Decode(&second); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if first.Timeout != duration(time.Second) || first.Origin != nil {
+		t.Errorf("first = %+v", first)
+	}
+	if second.Timeout != duration(2*time.Second) || second.Origin == nil || second.Origin.Y != 2 {
+		t.Fatalf("second = %+v", second)
+	}
+	// Lines count from the start of the stream
+	if line := second.Origin.node.Line; line != 5 {
+		t.Errorf("origin node line = %d, want 5", line)
+	}
+}
+
+func TestMarshaler(t *testing.T) {
+	type Config struct {
+		Name    string              `yaml:"name"`
+		Timeout duration            `yaml:"timeout"`
+		Backoff []duration          `yaml:"backoff"`
+		Limits  map[string]duration `yaml:"limits"`
+	}
+	cfg := Config{
+		Name:    "api",
+		Timeout: duration(90 * time.Second),
+		Backoff: []duration{duration(time.Second)},
+		Limits:  map[string]duration{"read": duration(10 * time.Second)},
+	}
+	want := "backoff: \n  - 1s\nlimits: \n  read: 10s\nname: api\ntimeout: 1m30s"
+
+	got, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Marshal =\n%s\nwant\n%s", got, want)
+	}
+
+	var back Config
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		if err := decode(got, &back); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if !reflect.DeepEqual(back, cfg) {
+			t.Errorf("round trip = %+v, want %+v", back, cfg)
+		}
+	})
+}
+
+// labels marshals as a mapping, through a pointer receiver.
+type labels struct{ app, tier string }
+
+func (l *labels) MarshalYAML() (interface{}, error) {
+	return MapSlice{{Key: "app", Value: l.app}, {Key: "tier", Value: l.tier}}, nil
+}
+
+func TestMarshalerLayout(t *testing.T) {
+	type Doc struct {
+		Labels labels `yaml:"labels"`
+	}
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"top level", duration(time.Second), "1s"},
+		{"block mapping field", &Doc{Labels: labels{"web", "front"}}, "labels: \n  app: web\n  tier: front"},
+		{"in sequence", []duration{duration(time.Minute)}, "- 1m0s"},
+		{"node", Node{Kind: ScalarKind, Tag: IntTag, Value: "0x1F"}, "0x1F"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.v)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
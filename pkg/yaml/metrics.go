@@ -0,0 +1,87 @@
+package yaml
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// DecodePath names the decoder that handled a document.
+type DecodePath string
+
+const (
+	DecodePathFast DecodePath = "fast" // Unmarshal and its variants, and Decoder.Decode
+	DecodePathAST  DecodePath = "ast"  // UnmarshalWithAST
+)
+
+// Error codes reported by DecodeEvent.Code, stable enough to use as metric
+// labels.
+const (
+	CodeLimitExceeded = "limit_exceeded" // wraps ErrLimitExceeded
+	CodeInvalidUTF8   = "invalid_utf8"   // wraps ErrInvalidUTF8
+	CodeUnknownField  = "unknown_field"  // *UnknownFieldError
+	CodeIgnoredField  = "ignored_field"  // *FieldWarning
+	CodeMissingField  = "missing_field"  // *MissingFieldError
+	CodeInvalid       = "invalid"        // any other error: syntax, type mismatch
+)
+
+// DecodeEvent describes one decoded document.
+type DecodeEvent struct {
+	Path  DecodePath
+	Bytes int   // size of the input
+	Err   error // nil if the document decoded
+}
+
+// Code classifies e.Err as one of the Code constants, or returns "" for a
+// document that decoded.
+func (e DecodeEvent) Code() string {
+	var unknown *UnknownFieldError
+	var ignored *FieldWarning
+	var missing *MissingFieldError
+	switch {
+	case e.Err == nil:
+		return ""
+	case errors.Is(e.Err, ErrLimitExceeded):
+		return CodeLimitExceeded
+	case errors.Is(e.Err, ErrInvalidUTF8):
+		return CodeInvalidUTF8
+	case errors.As(e.Err, &unknown):
+		return CodeUnknownField
+	case errors.As(e.Err, &ignored):
+		return CodeIgnoredField
+	case errors.As(e.Err, &missing):
+		return CodeMissingField
+	default:
+		return CodeInvalid
+	}
+}
+
+// Metrics receives an event for every document passed to Unmarshal,
+// UnmarshalWithAST or Decoder.Decode, for counting decode outcomes across a
+// fleet: documents decoded, fast versus AST path, errors by code and bytes
+// processed. ObserveDecode is called synchronously on the decoding goroutine,
+// so it must be safe for concurrent use and should be cheap, such as
+// incrementing Prometheus counters.
+type Metrics interface {
+	ObserveDecode(e DecodeEvent)
+}
+
+// metricsHolder lets atomic.Value store a nil Metrics.
+type metricsHolder struct{ m Metrics }
+
+var decodeMetrics atomic.Value // metricsHolder
+
+// SetMetrics installs m as the process-wide decode metrics sink, replacing
+// any previous one. SetMetrics(nil) turns reporting off, which is the
+// default.
+func SetMetrics(m Metrics) {
+	decodeMetrics.Store(metricsHolder{m})
+}
+
+// observeDecode reports a decode outcome to the installed Metrics, if any,
+// and returns err unchanged.
+func observeDecode(path DecodePath, n int, err error) error {
+	if h, ok := decodeMetrics.Load().(metricsHolder); ok && h.m != nil {
+		h.m.ObserveDecode(DecodeEvent{Path: path, Bytes: n, Err: err})
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package yaml
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingMetrics collects every event it observes.
+type recordingMetrics struct {
+	mu     sync.Mutex
+	events []DecodeEvent
+}
+
+func (r *recordingMetrics) ObserveDecode(e DecodeEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func TestMetrics_DecodeOutcomes(t *testing.T) {
+	rec := &recordingMetrics{}
+	SetMetrics(rec)
+	defer SetMetrics(nil)
+
+	type config struct {
+		Name string `yaml:"name"`
+	}
+	var c config
+
+	_ = Unmarshal([]byte("name: a\n"), &c)
+	_ = UnmarshalWithAST([]byte("name: [\n"), &c)
+	_ = Unmarshal([]byte("name: \xff\n"), &c)
+
+	dec := NewDecoder(strings.NewReader("name: a\nport: 1\n"))
+	dec.DisallowUnknownFields()
+	_ = dec.Decode(&c)
+
+	dec = NewDecoder(strings.NewReader("a:\n  b:\n    c: 1\n"))
+	dec.SetMaxDepth(1)
+	_ = dec.Decode(&map[string]interface{}{})
+
+	var r struct {
+		Name string `yaml:"name,required"`
+	}
+	_ = Unmarshal([]byte("port: 1\n"), &r)
+
+	want := []struct {
+		path  DecodePath
+		bytes int
+		code  string
+	}{
+		{DecodePathFast, 8, ""},
+		{DecodePathAST, 8, CodeInvalid},
+		{DecodePathFast, 8, CodeInvalidUTF8},
+		{DecodePathFast, 16, CodeUnknownField},
+		{DecodePathFast, 17, CodeLimitExceeded},
+		{DecodePathFast, 8, CodeMissingField},
+	}
+	if len(rec.events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(rec.events), len(want), rec.events)
+	}
+	for i, w := range want {
+		e := rec.events[i]
+		if e.Path != w.path || e.Bytes != w.bytes || e.Code() != w.code {
+			t.Errorf("event %d = {%s %d %q} (err %v), want {%s %d %q}",
+				i, e.Path, e.Bytes, e.Code(), e.Err, w.path, w.bytes, w.code)
+		}
+	}
+}
+
+func TestMetrics_Disabled(t *testing.T) {
+	rec := &recordingMetrics{}
+	SetMetrics(rec)
+	SetMetrics(nil)
+
+	var v map[string]interface{}
+	if err := Unmarshal([]byte("a: 1\n"), &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.events) != 0 {
+		t.Errorf("got %d events after SetMetrics(nil), want 0", len(rec.events))
+	}
+}
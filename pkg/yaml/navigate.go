@@ -0,0 +1,110 @@
+package yaml
+
+// GetKey returns the value of key in a mapping node. It reports false if
+// node is not a mapping or has no such key. Use Index for sequence elements.
+//
+// Example:
+//
+//	var node yaml.Node
+//	err := yaml.Unmarshal([]byte("server:\n  port: 8080"), &node)
+//	server, _ := yaml.GetKey(&node, "server")
+//	port, _ := yaml.GetKey(server, "port")
+//	n, ok := yaml.AsInt(port) // 8080, true
+func GetKey(node *Node, key string) (*Node, bool) {
+	if node == nil {
+		return nil, false
+	}
+	i := mappingKeyIndex(node, key)
+	if i < 0 {
+		return nil, false
+	}
+	return node.Content[i+1], true
+}
+
+// Index returns element i of a sequence node. It reports false if node is
+// not a sequence or i is out of range.
+func Index(node *Node, i int) (*Node, bool) {
+	if node == nil || node.Kind != SequenceKind || i < 0 || i >= len(node.Content) {
+		return nil, false
+	}
+	return node.Content[i], true
+}
+
+// Len returns the number of elements of a sequence node or entries of a
+// mapping node, and 0 for scalars and nil.
+func Len(node *Node) int {
+	if node == nil {
+		return 0
+	}
+	switch node.Kind {
+	case MappingKind:
+		return len(mappingEntries(node))
+	case SequenceKind:
+		return len(node.Content)
+	}
+	return 0
+}
+
+// MapKeys returns the keys of a mapping node in document order, or nil if
+// node is not a mapping. A key merged in with << that the mapping also has
+// is listed once.
+func MapKeys(node *Node) []string {
+	if node == nil || node.Kind != MappingKind {
+		return nil
+	}
+	entries := mappingEntries(node)
+	keys := make([]string, len(entries))
+	for j, i := range entries {
+		keys[j] = node.Content[i].Value
+	}
+	return keys
+}
+
+// mappingEntries returns the positions in n.Content of the keys GetKey
+// finds: each scalar key the first time it appears.
+func mappingEntries(n *Node) []int {
+	entries := make([]int, 0, len(n.Content)/2)
+	seen := make(map[string]bool, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if k := n.Content[i]; k != nil && k.Kind == ScalarKind && !seen[k.Value] {
+			seen[k.Value] = true
+			entries = append(entries, i)
+		}
+	}
+	return entries
+}
+
+// AsString returns the value of a string scalar node. It reports false for
+// other scalars, so a quoted "8080" is a string but a plain 8080 is not.
+func AsString(node *Node) (string, bool) {
+	s, ok := scalarOf(node).(string)
+	return s, ok
+}
+
+// AsInt returns the value of an integer scalar node. It reports false for
+// other scalars, including floats with a whole value such as 1.0, and for
+// integers above math.MaxInt64.
+func AsInt(node *Node) (int64, bool) {
+	n, ok := scalarOf(node).(int64)
+	return n, ok
+}
+
+// AsBool returns the value of a boolean scalar node. It reports false for
+// other scalars.
+func AsBool(node *Node) (bool, bool) {
+	b, ok := scalarOf(node).(bool)
+	return b, ok
+}
+
+// scalarOf returns the value of a scalar node, or nil if node is not a
+// scalar or its value does not resolve under its tag.
+func scalarOf(node *Node) interface{} {
+	if node == nil || node.Kind != ScalarKind {
+		return nil
+	}
+	v, err := node.scalarValue()
+	if err != nil {
+		return nil
+	}
+	return v
+}
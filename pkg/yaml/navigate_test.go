@@ -0,0 +1,135 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+const navigateDoc = `
+name: api
+port: 8080
+debug: true
+version: "2"
+ratio: 1.0
+tags:
+  - web
+  - internal
+env:
+  - name: MODE
+    value: prod
+empty: {}
+`
+
+func TestNavigate(t *testing.T) {
+	node := parseNode(t, navigateDoc)
+
+	if got, want := MapKeys(node), []string{"name", "port", "debug", "version", "ratio", "tags", "env", "empty"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapKeys() = %v, want %v", got, want)
+	}
+	if got := Len(node); got != 8 {
+		t.Errorf("Len(root) = %d, want 8", got)
+	}
+
+	name, _ := GetKey(node, "name")
+	if s, ok := AsString(name); !ok || s != "api" {
+		t.Errorf("AsString(name) = %q, %v, want api, true", s, ok)
+	}
+	port, _ := GetKey(node, "port")
+	if n, ok := AsInt(port); !ok || n != 8080 {
+		t.Errorf("AsInt(port) = %d, %v, want 8080, true", n, ok)
+	}
+	if _, ok := AsString(port); ok {
+		t.Error("AsString(port) reported ok for an integer")
+	}
+	debug, _ := GetKey(node, "debug")
+	if b, ok := AsBool(debug); !ok || !b {
+		t.Errorf("AsBool(debug) = %v, %v, want true, true", b, ok)
+	}
+	version, _ := GetKey(node, "version")
+	if _, ok := AsInt(version); ok {
+		t.Error("AsInt(version) reported ok for a quoted string")
+	}
+	ratio, _ := GetKey(node, "ratio")
+	if _, ok := AsInt(ratio); ok {
+		t.Error("AsInt(ratio) reported ok for a float")
+	}
+
+	tags, _ := GetKey(node, "tags")
+	if got := Len(tags); got != 2 {
+		t.Errorf("Len(tags) = %d, want 2", got)
+	}
+	if tag, ok := Index(tags, 1); !ok {
+		t.Error("Index(tags, 1) not found")
+	} else if s, _ := AsString(tag); s != "internal" {
+		t.Errorf("Index(tags, 1) = %q, want internal", s)
+	}
+	if _, ok := Index(tags, 2); ok {
+		t.Error("Index(tags, 2) reported ok out of range")
+	}
+	if _, ok := Index(tags, -1); ok {
+		t.Error("Index(tags, -1) reported ok")
+	}
+	if keys := MapKeys(tags); keys != nil {
+		t.Errorf("MapKeys(tags) = %v, want nil", keys)
+	}
+
+	env, _ := GetKey(node, "env")
+	first, _ := Index(env, 0)
+	value, _ := GetKey(first, "value")
+	if s, _ := AsString(value); s != "prod" {
+		t.Errorf("env[0].value = %q, want prod", s)
+	}
+
+	empty, ok := GetKey(node, "empty")
+	if !ok || Len(empty) != 0 {
+		t.Errorf("GetKey(empty) = %v, %v, want empty mapping", empty, ok)
+	}
+	if _, ok := GetKey(node, "missing"); ok {
+		t.Error("GetKey(missing) reported ok")
+	}
+	if _, ok := Index(node, 0); ok {
+		t.Error("Index(root, 0) reported ok on a mapping")
+	}
+	if Len(name) != 0 || Len(nil) != 0 {
+		t.Error("Len of a scalar or nil should be 0")
+	}
+	if _, ok := GetKey(nil, "name"); ok {
+		t.Error("GetKey(nil) reported ok")
+	}
+}
+
+func TestGetKey_IntegerLikeKeys(t *testing.T) {
+	for _, input := range []string{"0: a", "0: a\n1: b"} {
+		node := parseNode(t, input)
+		value, ok := GetKey(node, "0")
+		if s, _ := AsString(value); !ok || s != "a" {
+			t.Errorf("GetKey(%q, \"0\") = %v, %v, want a, true", input, value, ok)
+		}
+	}
+
+	node := parseNode(t, "{1: b, 0: a}")
+	if got, want := MapKeys(node), []string{"1", "0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapKeys() = %v, want %v", got, want)
+	}
+	if _, ok := Index(node, 0); ok {
+		t.Error("Index(node, 0) reported ok on a mapping")
+	}
+	if got := Len(node); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+func TestNavigate_MergeKeys(t *testing.T) {
+	node := parseNode(t, "base: &b\n  x: 1\n  y: 2\nuse:\n  <<: *b\n  y: 3\n")
+	use, _ := GetKey(node, "use")
+	if got, want := MapKeys(use), []string{"y", "x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapKeys(use) = %v, want %v", got, want)
+	}
+	if got := Len(use); got != 2 {
+		t.Errorf("Len(use) = %d, want 2", got)
+	}
+	y, _ := GetKey(use, "y")
+	if n, ok := AsInt(y); !ok || n != 3 {
+		t.Errorf("use.y = %d, %v, want 3, true", n, ok)
+	}
+}
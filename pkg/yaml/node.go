@@ -0,0 +1,541 @@
+package yaml
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/canonkey"
+	"github.com/shapestone/shape-yaml/internal/parser"
+	"github.com/shapestone/shape-yaml/internal/resolve"
+	"github.com/shapestone/shape-yaml/internal/utf8input"
+)
+
+// Node is a parsed YAML node with its tag, source text and position, for
+// decoding a document in parts or choosing a target type at run time
+// without working with the AST:
+//
+//	var doc yaml.Node
+//	err := yaml.Unmarshal(data, &doc)
+//	for i := 0; i+1 < len(doc.Content); i += 2 {
+//	    if doc.Content[i].Value == "spec" {
+//	        err = doc.Content[i+1].Decode(&spec)
+//	    }
+//	}
+//
+// Content holds the items of a sequence, and the keys and values of a
+// mapping alternately, in document order; keys merged in with << follow the
+// mapping's own. An alias is a copy of the node it names, and only the
+// definition carries the Anchor. Unmarshaling into a Node works on every
+// decode path, and Marshal writes a Node back as YAML.
+//
+// Unmarshaling an empty document leaves the zero Node, of InvalidKind.
+//
+// The root Node of a document keeps the document's directives, such as
+// "%YAML 1.1", in Directives, and Marshal and Encoder write them back ahead
+// of the document's "---", so that the directives of each document in a
+// stream survive a round trip.
+type Node struct {
+	Kind       Kind
+	Style      Style
+	Tag        string // resolved tag, as Tags.Of reports it
+	Value      string // source text of a scalar; "" for an implicit null
+	Content    []*Node
+	Anchor     string
+	Line       int // 1-based; 0 where there is no position, as for merged keys
+	Column     int
+	Directives []string // directive lines of the document, on its root only
+}
+
+// UnmarshalYAML parses a YAML document into n.
+func (n *Node) UnmarshalYAML(data []byte) error {
+	input := string(data)
+	if err := utf8input.CheckString(input); err != nil {
+		return err
+	}
+	p := parser.NewParser(input)
+	b := newNodeBuilder(p, input)
+	root, err := p.Parse()
+	if err != nil {
+		return err
+	}
+
+	*n = Node{}
+	if !parser.IsEmptyDocument(root) {
+		*n = *b.build(root)
+		n.Directives = p.Directives()
+	}
+	return nil
+}
+
+// Decode decodes n into v, which must be a pointer, the way Unmarshal would
+// decode the YAML n stands for.
+func (n *Node) Decode(v interface{}) error {
+	tables := &nodeBuilder{texts: make(parser.ScalarTexts), order: make(parser.KeyOrder), tags: make(parser.Tags)}
+	root, err := n.toDocument(tables)
+	if err != nil {
+		return err
+	}
+	return unmarshalFromNode(root, v, tables)
+}
+
+// NodeFromAST returns the Node of a tree returned by Parse, for using the
+// tree functions (GetKey, Walk, Query, TransformScalars and the rest) on it.
+// The AST does not record key order or how scalars are written, so mapping
+// keys come in sorted order and scalars are plain unless they need quotes;
+// unmarshal the document into a Node to keep them.
+//
+// Example:
+//
+//	tree, err := yaml.Parse(input)
+//	port, ok := yaml.GetKey(yaml.NodeFromAST(tree), "port")
+func NodeFromAST(node ast.SchemaNode) *Node {
+	if node == nil || parser.IsEmptyDocument(node) {
+		return &Node{}
+	}
+	return (&nodeBuilder{}).build(node)
+}
+
+// NodeToAST returns the AST of n, as Parse would return it for the YAML n
+// stands for, for functions that take a parsed tree such as NodeToInterface.
+// Key order, scalar styles and tags other than those of the core schema
+// are not kept.
+func NodeToAST(n *Node) (ast.SchemaNode, error) {
+	return n.toDocument(&nodeBuilder{texts: make(parser.ScalarTexts), order: make(parser.KeyOrder), tags: make(parser.Tags)})
+}
+
+// toDocument converts n to the AST of a document, recording in b what
+// toAST records. The zero Node is an empty document.
+func (n *Node) toDocument(b *nodeBuilder) (ast.SchemaNode, error) {
+	if n.Kind == InvalidKind {
+		return ast.NewObjectNode(map[string]ast.SchemaNode{}, ast.ZeroPosition()), nil
+	}
+	return n.toAST(b)
+}
+
+// MarshalYAML returns the value Marshal writes for n, keeping mapping key
+// order, the tags other than those the values resolve to, and literal block
+// scalars below the root.
+func (n Node) MarshalYAML() (interface{}, error) {
+	v, err := n.toValue()
+	// A whole document is not written as a block scalar, whose lines would
+	// start in the first column
+	if s, ok := v.(literalScalar); ok {
+		return string(s), err
+	}
+	return v, err
+}
+
+// splitDirectives returns the directives of v, if it is a Node or *Node
+// that has some, and v without them.
+func splitDirectives(v interface{}) ([]string, interface{}) {
+	switch n := v.(type) {
+	case Node:
+		if len(n.Directives) > 0 {
+			dirs := n.Directives
+			n.Directives = nil
+			return dirs, n
+		}
+	case *Node:
+		if n != nil && len(n.Directives) > 0 {
+			c := *n
+			c.Directives = nil
+			return n.Directives, &c
+		}
+	}
+	return nil, v
+}
+
+// appendDirectives appends dirs, one per line, and the "---" that ends them.
+func appendDirectives(buf []byte, dirs []string) []byte {
+	for _, d := range dirs {
+		buf = append(buf, d...)
+		buf = append(buf, '\n')
+	}
+	return append(buf, "---\n"...)
+}
+
+// shiftLines adds delta to the line of n and every node below it.
+func (n *Node) shiftLines(delta int) {
+	if n.Line > 0 {
+		n.Line += delta
+	}
+	for _, c := range n.Content {
+		c.shiftLines(delta)
+	}
+}
+
+// shiftColumns adds delta to the column of n and every node below it that
+// is on line.
+func (n *Node) shiftColumns(line, delta int) {
+	if n.Line == line && n.Column > 0 {
+		n.Column += delta
+	}
+	for _, c := range n.Content {
+		c.shiftColumns(line, delta)
+	}
+}
+
+// scalarValue returns the value of a scalar node: the value its Value
+// resolves to under its tag, or for nodes without a core schema tag, as a
+// plain scalar unless it is quoted or a block scalar.
+func (n *Node) scalarValue() (interface{}, error) {
+	switch n.Tag {
+	case NullTag:
+		return nil, nil
+	case StrTag:
+		return n.Value, nil
+	case BoolTag, IntTag, FloatTag:
+		v := resolve.Plain(n.Value)
+		if plainTag(n.Value) == n.Tag {
+			return v, nil
+		}
+		// An integer is a valid float, as in !!float 1
+		if n.Tag == FloatTag {
+			switch i := v.(type) {
+			case int64:
+				return float64(i), nil
+			case uint64:
+				return float64(i), nil
+			}
+		}
+		return nil, fmt.Errorf("yaml: line %d: cannot decode %q as %s", n.Line, n.Value, n.Tag)
+	case TimestampTag:
+		if t, ok := resolve.Timestamp(n.Value); ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("yaml: line %d: cannot decode %q as %s", n.Line, n.Value, n.Tag)
+	case BinaryTag:
+		if b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(n.Value), "")); err == nil {
+			return b, nil
+		}
+		return nil, fmt.Errorf("yaml: line %d: cannot decode %q as %s", n.Line, n.Value, n.Tag)
+	}
+	if n.Style != Plain {
+		return n.Value, nil
+	}
+	return resolve.Plain(n.Value), nil
+}
+
+// keyString returns the mapping key the parser would make of key node n.
+func (n *Node) keyString(b *nodeBuilder) (string, error) {
+	if n.Kind == ScalarKind {
+		if n.Value == "" && n.Tag == NullTag {
+			return "null", nil
+		}
+		return n.Value, nil
+	}
+	key, err := n.toAST(b)
+	if err != nil {
+		return "", err
+	}
+	return canonkey.Encode(NodeToInterface(key)), nil
+}
+
+// toAST converts n to an AST node, recording in b the source text of
+// resolved scalars, the order of mapping keys, and the tags of collections
+// other than !!map and !!seq.
+func (n *Node) toAST(b *nodeBuilder) (ast.SchemaNode, error) {
+	pos := ast.NewPosition(0, n.Line, n.Column)
+	switch n.Kind {
+	case ScalarKind:
+		v, err := n.scalarValue()
+		if err != nil {
+			return nil, err
+		}
+		lit := ast.NewLiteralNode(v, pos)
+		if _, ok := v.(string); !ok && v != nil {
+			b.texts[lit] = n.Value
+		}
+		return lit, nil
+
+	case SequenceKind:
+		elems := make([]ast.SchemaNode, len(n.Content))
+		for i, c := range n.Content {
+			if c == nil {
+				return nil, fmt.Errorf("yaml: line %d: nil item %d in sequence node", n.Line, i)
+			}
+			elem, err := c.toAST(b)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = elem
+		}
+		seq := ast.NewArrayDataNode(elems, pos)
+		if n.Tag != SeqTag && n.Tag != "" {
+			b.tags[seq] = n.Tag
+		}
+		return seq, nil
+
+	case MappingKind:
+		if len(n.Content)%2 != 0 {
+			return nil, fmt.Errorf("yaml: line %d: mapping node has %d content nodes, want key/value pairs", n.Line, len(n.Content))
+		}
+		props := make(map[string]ast.SchemaNode, len(n.Content)/2)
+		keys := make([]string, 0, len(n.Content)/2)
+		for i := 0; i < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k == nil || v == nil {
+				return nil, fmt.Errorf("yaml: line %d: nil key or value in mapping node", n.Line)
+			}
+			key, err := k.keyString(b)
+			if err != nil {
+				return nil, err
+			}
+			value, err := v.toAST(b)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := props[key]; !exists {
+				keys = append(keys, key)
+			}
+			props[key] = value
+		}
+		obj := ast.NewObjectNode(props, pos)
+		b.order[obj] = keys
+		if n.Tag != MapTag && n.Tag != "" {
+			b.tags[obj] = n.Tag
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("yaml: line %d: cannot decode node of kind %v", n.Line, n.Kind)
+}
+
+// toValue converts n to the value Marshal writes for it: a MapSlice for a
+// mapping, a []interface{} for a sequence, and Tagged where the tag is not
+// one of the core schema.
+func (n *Node) toValue() (interface{}, error) {
+	var v interface{}
+	switch n.Kind {
+	case InvalidKind:
+		return nil, nil
+	case ScalarKind:
+		s, err := n.scalarValue()
+		if err != nil {
+			return nil, err
+		}
+		v = s
+		// Plain text that reads back the same keeps its spelling, as 1.10 or 0x1F
+		if _, isStr := s.(string); !isStr && s != nil && n.Style == Plain && (plainTag(n.Value) == n.Tag || n.Tag == TimestampTag) {
+			v = plainScalar(n.Value)
+		}
+		// Binary data is written as the base64 text it was read from
+		if n.Tag == BinaryTag {
+			v = n.Value
+		}
+		if str, isStr := s.(string); isStr && n.Style == Literal && literalText(str) {
+			v = literalScalar(str)
+		}
+	case SequenceKind:
+		items := make([]interface{}, len(n.Content))
+		for i, c := range n.Content {
+			if c == nil {
+				return nil, fmt.Errorf("yaml: line %d: nil item %d in sequence node", n.Line, i)
+			}
+			item, err := c.toValue()
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		v = items
+	case MappingKind:
+		if len(n.Content)%2 != 0 {
+			return nil, fmt.Errorf("yaml: line %d: mapping node has %d content nodes, want key/value pairs", n.Line, len(n.Content))
+		}
+		entries := make(MapSlice, 0, len(n.Content)/2)
+		for i := 0; i < len(n.Content); i += 2 {
+			if n.Content[i] == nil || n.Content[i+1] == nil {
+				return nil, fmt.Errorf("yaml: line %d: nil key or value in mapping node", n.Line)
+			}
+			key, err := n.Content[i].toValue()
+			if err != nil {
+				return nil, err
+			}
+			value, err := n.Content[i+1].toValue()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, MapItem{Key: key, Value: value})
+		}
+		v = entries
+	default:
+		return nil, fmt.Errorf("yaml: line %d: cannot marshal node of kind %v", n.Line, n.Kind)
+	}
+
+	switch n.Tag {
+	case "", NullTag, BoolTag, IntTag, FloatTag, StrTag, MapTag, SeqTag:
+		return v, nil
+	}
+	return Tagged{Tag: n.Tag, Value: v}, nil
+}
+
+// plainScalar is a plain scalar that Marshal writes as is.
+type plainScalar string
+
+func (s plainScalar) MarshalYAML() ([]byte, error) {
+	return []byte(s), nil
+}
+
+// literalScalar is a string Marshal writes as a literal block scalar, its
+// lines indented below the key or dash it follows. literalText reports
+// whether a string can be.
+type literalScalar string
+
+func (s literalScalar) MarshalYAML() ([]byte, error) {
+	text := string(s)
+	header := "|"
+	if strings.HasSuffix(text, "\n") {
+		text = text[:len(text)-1]
+	} else {
+		header = "|-"
+	}
+	return []byte(header + "\n" + text), nil
+}
+
+// literalText reports whether s can be written as a literal block scalar
+// with Marshal's "|" or "|-" header and no indentation indicator: it has
+// more than one line, ends with at most one line break, its first line with
+// content does not start with a space, and it holds no characters a block
+// scalar cannot, such as carriage returns or other control characters.
+func literalText(s string) bool {
+	body := strings.TrimSuffix(s, "\n")
+	if !strings.Contains(body, "\n") || strings.HasSuffix(body, "\n") {
+		return false
+	}
+	if strings.HasPrefix(strings.TrimLeft(body, "\n"), " ") {
+		return false
+	}
+	for _, r := range body {
+		switch {
+		case r == '\n' || r == '\t':
+		case r < 0x20 || r == 0x7f || r == 0x85 || r == 0x2028 || r == 0x2029 || r == 0xFEFF:
+			return false
+		case r >= 0x80 && r <= 0x9f:
+			return false
+		}
+	}
+	return true
+}
+
+// nodeBuilder builds Nodes from a parsed tree and the side tables recorded
+// while parsing it.
+type nodeBuilder struct {
+	texts   parser.ScalarTexts
+	styles  parser.ScalarStyles
+	tags    parser.Tags
+	order   parser.KeyOrder
+	spans   parser.KeySpans
+	anchors parser.Anchors
+	input   []rune                  // key span offsets count runes
+	defined map[ast.SchemaNode]bool // anchored nodes already built once
+}
+
+// newNodeBuilder makes p record everything a Node holds while parsing input.
+func newNodeBuilder(p *parser.Parser, input string) *nodeBuilder {
+	input = utf8input.TrimBOMString(input) // as the parser does
+	return &nodeBuilder{
+		texts:   p.RecordScalarTexts(),
+		styles:  p.RecordScalarStyles(),
+		tags:    p.RecordTags(),
+		order:   p.RecordKeyOrder(),
+		spans:   p.RecordKeySpans(),
+		anchors: p.RecordAnchors(),
+		input:   []rune(input),
+	}
+}
+
+// build converts node and the nodes below it.
+func (b *nodeBuilder) build(node ast.SchemaNode) *Node {
+	pos := node.Position()
+	n := &Node{Kind: KindOf(node), Tag: b.tags.Of(node), Line: pos.Line, Column: pos.Column}
+	if name, ok := b.anchors[node]; ok && !b.defined[node] {
+		if b.defined == nil {
+			b.defined = make(map[ast.SchemaNode]bool)
+		}
+		b.defined[node] = true
+		n.Anchor = name
+	}
+
+	switch v := node.(type) {
+	case *ast.LiteralNode:
+		n.Style = b.styles.Of(v)
+		if text, ok := b.texts[v]; ok {
+			n.Value = text
+		} else {
+			n.Value = scalarText(v.Value())
+		}
+	case *ast.ArrayDataNode:
+		n.Content = make([]*Node, 0, v.Len())
+		for _, elem := range v.Elements() {
+			n.Content = append(n.Content, b.build(elem))
+		}
+	case *ast.ObjectNode:
+		props := v.Properties()
+		keys := b.order.Keys(v)
+		n.Content = make([]*Node, 0, 2*len(keys))
+		for _, k := range keys {
+			n.Content = append(n.Content, b.keyNode(v, k), b.build(props[k]))
+		}
+	}
+	return n
+}
+
+// keyNode builds the scalar node of key in mapping obj. Keys written with ?
+// as collections are scalars holding the canonical form of the collection.
+func (b *nodeBuilder) keyNode(obj *ast.ObjectNode, key string) *Node {
+	n := &Node{Kind: ScalarKind, Value: key}
+	if span, ok := b.spans[obj][key]; ok {
+		n.Line, n.Column = span.Pos.Line, span.Pos.Column
+		if span.Pos.Offset < len(b.input) {
+			switch b.input[span.Pos.Offset] {
+			case '"':
+				n.Style = DoubleQuoted
+			case '\'':
+				n.Style = SingleQuoted
+			}
+		}
+	}
+	if n.Style == Plain {
+		n.Tag = plainTag(key)
+	} else {
+		n.Tag = StrTag
+	}
+	return n
+}
+
+// scalarText formats a scalar value without recorded source text, such as
+// one converted by a tag.
+func scalarText(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case bool:
+		return strconv.FormatBool(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case float64:
+		switch {
+		case math.IsInf(x, 1):
+			return ".inf"
+		case math.IsInf(x, -1):
+			return "-.inf"
+		case math.IsNaN(x):
+			return ".nan"
+		}
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(x)
+	}
+	return fmt.Sprint(v)
+}
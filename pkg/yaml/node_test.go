@@ -0,0 +1,305 @@
+package yaml
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshal_Node(t *testing.T) {
+	input := "name: api\nport: 8080\n\"id\": '8080'\nbase: &b {x: 1.10}\nuse:\n  <<: *b\n  p: !Point p\nlist:\n  - |\n    text\n  - ~\n"
+
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var doc Node
+		if err := decode([]byte(input), &doc); err != nil {
+			t.Fatalf("decode error = %v", err)
+		}
+		if doc.Kind != MappingKind || doc.Tag != MapTag || len(doc.Content) != 12 {
+			t.Fatalf("root = %v %s with %d content nodes, want a mapping with 12", doc.Kind, doc.Tag, len(doc.Content))
+		}
+
+		tests := []struct {
+			node         *Node
+			kind         Kind
+			style        Style
+			tag, value   string
+			anchor       string
+			line, column int
+		}{
+			{doc.Content[0], ScalarKind, Plain, StrTag, "name", "", 1, 1},
+			{doc.Content[3], ScalarKind, Plain, IntTag, "8080", "", 2, 7},
+			{doc.Content[4], ScalarKind, DoubleQuoted, StrTag, "id", "", 3, 1},
+			{doc.Content[5], ScalarKind, SingleQuoted, StrTag, "8080", "", 3, 7},
+			{doc.Content[7], MappingKind, Plain, MapTag, "", "b", 4, 10},
+			{doc.Content[7].Content[1], ScalarKind, Plain, FloatTag, "1.10", "", 4, 14},
+			{doc.Content[9].Content[0], ScalarKind, Plain, StrTag, "p", "", 7, 3},
+			{doc.Content[9].Content[1], ScalarKind, Plain, "!Point", "p", "", 7, 13},
+			{doc.Content[9].Content[2], ScalarKind, Plain, StrTag, "x", "", 0, 0}, // merged key
+			{doc.Content[11], SequenceKind, Plain, SeqTag, "", "", 9, 3},
+			{doc.Content[11].Content[0], ScalarKind, Literal, StrTag, "text\n", "", 9, 5},
+			{doc.Content[11].Content[1], ScalarKind, Plain, NullTag, "", "", 11, 5},
+		}
+		for i, tt := range tests {
+			n := tt.node
+			if n.Kind != tt.kind || n.Style != tt.style || n.Tag != tt.tag || n.Value != tt.value ||
+				n.Anchor != tt.anchor || n.Line != tt.line || n.Column != tt.column {
+				t.Errorf("%d: got %v %v %s %q &%q at %d:%d, want %v %v %s %q &%q at %d:%d", i,
+					n.Kind, n.Style, n.Tag, n.Value, n.Anchor, n.Line, n.Column,
+					tt.kind, tt.style, tt.tag, tt.value, tt.anchor, tt.line, tt.column)
+			}
+		}
+
+		// The merged copy of the anchored mapping's value has no anchor
+		if merged := doc.Content[9].Content[3]; merged.Value != "1.10" || merged.Anchor != "" {
+			t.Errorf("merged value = %+v", merged)
+		}
+	})
+}
+
+func TestUnmarshal_NodeEmptyDocument(t *testing.T) {
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		doc := Node{Kind: ScalarKind, Value: "stale"}
+		if err := decode([]byte("# nothing\n"), &doc); err != nil {
+			t.Fatalf("decode error = %v", err)
+		}
+		if !reflect.DeepEqual(doc, Node{}) {
+			t.Errorf("got %+v, want the zero Node", doc)
+		}
+	})
+}
+
+func TestNode_Decode(t *testing.T) {
+	input := "kind: Service\nspec:\n  port: 8080\n  name: \"8080\"\n  labels: {b: 1, a: 2}\n  version: 1.10\n"
+	var doc Node
+	if err := Unmarshal([]byte(input), &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	var kind string
+	if err := doc.Content[1].Decode(&kind); err != nil || kind != "Service" {
+		t.Errorf("Decode(kind) = %q, %v", kind, err)
+	}
+
+	var spec struct {
+		Port    int
+		Name    string
+		Labels  MapSlice
+		Version Version
+	}
+	if err := doc.Content[3].Decode(&spec); err != nil {
+		t.Fatalf("Decode(spec) error = %v", err)
+	}
+	if spec.Port != 8080 || spec.Name != "8080" || spec.Version.String() != "1.10.0" {
+		t.Errorf("spec = %+v", spec)
+	}
+	if want := (MapSlice{{Key: "b", Value: int64(1)}, {Key: "a", Value: int64(2)}}); !reflect.DeepEqual(spec.Labels, want) {
+		t.Errorf("Labels = %#v, want %#v", spec.Labels, want)
+	}
+
+	var zero Node
+	m := map[string]int{"a": 1}
+	if err := zero.Decode(&m); err != nil || m != nil {
+		t.Errorf("zero Node Decode = %v, %v; want nil map", m, err)
+	}
+}
+
+func TestNode_DecodeBuilt(t *testing.T) {
+	key := func(s string) *Node { return &Node{Kind: ScalarKind, Tag: StrTag, Value: s} }
+	doc := &Node{Kind: MappingKind, Content: []*Node{
+		key("n"), {Kind: ScalarKind, Value: "42"},
+		key("s"), {Kind: ScalarKind, Style: DoubleQuoted, Value: "42"},
+		key("f"), {Kind: ScalarKind, Tag: FloatTag, Value: "1"},
+		key("l"), {Kind: SequenceKind, Content: []*Node{{Kind: ScalarKind, Value: "true"}}},
+	}}
+
+	var got map[string]interface{}
+	if err := doc.Decode(&got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := map[string]interface{}{"n": int64(42), "s": "42", "f": 1.0, "l": []interface{}{true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %#v, want %#v", got, want)
+	}
+
+	bad := []*Node{
+		{Kind: MappingKind, Content: []*Node{key("a")}},
+		{Kind: ScalarKind, Tag: IntTag, Value: "abc"},
+		{Kind: SequenceKind, Content: []*Node{nil}},
+		{Kind: SequenceKind, Content: []*Node{{}}},
+	}
+	for _, n := range bad {
+		var v interface{}
+		if err := n.Decode(&v); err == nil {
+			t.Errorf("Decode(%+v) expected error, got %#v", n, v)
+		}
+	}
+}
+
+func TestMarshal_Node(t *testing.T) {
+	input := "zeta: 1.10\nalpha: !Point\n  y: 0x1F\n  x: \"2\"\nlist:\n  - a\n  - !!str 5\nnone: null"
+	var doc Node
+	if err := Unmarshal([]byte(input), &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	out, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := "zeta: 1.10\nalpha: !Point\n  y: 0x1F\n  x: \"2\"\nlist: \n  - a\n  - \"5\"\nnone: null"
+	if string(out) != want {
+		t.Errorf("Marshal() = %q, want %q", out, want)
+	}
+	if out, err := Marshal(&Node{}); err != nil || string(out) != "null" {
+		t.Errorf("Marshal(zero Node) = %q, %v", out, err)
+	}
+}
+
+// TestMarshal_NodeLiteral checks that literal block scalars are written
+// back as literal blocks where they can be, and as quoted strings where not,
+// and that either way they decode to the text they were read as.
+func TestMarshal_NodeLiteral(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"s: |\n  one\n  two\n", "s: |\n  one\n  two"},
+		{"s: |-\n  one\n\n  two", "s: |-\n  one\n\n  two"},
+		{"l:\n  - |\n    a: b\n    - c\n", "l: \n  - |\n    a: b\n    - c"},
+		{"s: |+\n  one\n  two\n\n", `s: "one\ntwo\n\n"`},
+		{"s: |\n  one line\n", `s: "one line\n"`},
+		{"|\n  whole\n  document\n", `"whole\ndocument\n"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var doc Node
+			if err := Unmarshal([]byte(tt.input), &doc); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			out, err := Marshal(doc)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Marshal() = %q, want %q", out, tt.want)
+			}
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var want, got interface{}
+				if err := UnmarshalWithAST([]byte(tt.input), &want); err != nil {
+					t.Fatalf("decode input: %v", err)
+				}
+				if err := decode(out, &got); err != nil {
+					t.Fatalf("decode output: %v", err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("output decodes to %#v, want %#v", got, want)
+				}
+			})
+		})
+	}
+
+	// Text a literal block could only hold with an indentation indicator,
+	// or not at all, is quoted
+	for _, text := range []string{" indented\ntwo\n", "one\r\ntwo\n", "bell\a\ntwo"} {
+		doc := &Node{Kind: MappingKind, Tag: MapTag, Content: []*Node{
+			{Kind: ScalarKind, Tag: StrTag, Value: "s"},
+			{Kind: ScalarKind, Tag: StrTag, Style: Literal, Value: text},
+		}}
+		out, err := Marshal(doc)
+		if err != nil {
+			t.Fatalf("Marshal(%q) error = %v", text, err)
+		}
+		var got struct{ S string }
+		if err := Unmarshal(out, &got); err != nil || got.S != text {
+			t.Errorf("Marshal(%q) = %q, which decodes to %q, %v", text, out, got.S, err)
+		}
+	}
+}
+
+func TestNodeFromAST(t *testing.T) {
+	tree, err := Parse("b: 1\na: [x, \"2\"]\n")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	n := NodeFromAST(tree)
+	if got, want := MapKeys(n), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapKeys() = %v, want %v", got, want)
+	}
+	a, _ := GetKey(n, "a")
+	if two, _ := Index(a, 1); two.Tag != StrTag || two.Value != "2" || two.Line != 2 {
+		t.Errorf("a[1] = %+v, want the string 2 on line 2", two)
+	}
+	var got interface{}
+	if err := n.Decode(&got); err != nil || !reflect.DeepEqual(got, NodeToInterface(tree)) {
+		t.Errorf("Decode() = %#v, %v, want %#v", got, err, NodeToInterface(tree))
+	}
+
+	empty, err := Parse("")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if n := NodeFromAST(empty); n.Kind != InvalidKind {
+		t.Errorf("NodeFromAST(empty document) = %+v, want the zero Node", n)
+	}
+}
+
+func TestNodeToAST(t *testing.T) {
+	input := "name: api\nport: \"8080\"\nbase: &b {x: 1}\nuse: *b\nn: !!float 1\n"
+	tree, err := NodeToAST(parseNode(t, input))
+	if err != nil {
+		t.Fatalf("NodeToAST() error = %v", err)
+	}
+	var want interface{}
+	if err := UnmarshalWithAST([]byte(input), &want); err != nil {
+		t.Fatalf("UnmarshalWithAST() error = %v", err)
+	}
+	if got := NodeToInterface(tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("NodeToAST() = %#v, want %#v", got, want)
+	}
+
+	tree, err = NodeToAST(&Node{})
+	if err != nil || KindOf(tree) != MappingKind || len(NodeToInterface(tree).(map[string]interface{})) != 0 {
+		t.Errorf("NodeToAST(zero Node) = %v, %v, want an empty document", tree, err)
+	}
+	if _, err := NodeToAST(&Node{Kind: ScalarKind, Tag: IntTag, Value: "x"}); err == nil {
+		t.Error("NodeToAST() of an invalid !!int: expected error")
+	}
+}
+
+func TestDecoder_NodeLines(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte("a: 1\n---\nb: 2\n")))
+	var first, second Node
+	if err := d.Decode(&first); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if err := d.Decode(&second); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if first.Line != 1 || second.Line != 3 || second.Content[1].Line != 3 {
+		t.Errorf("lines = %d, %d, %d; want 1, 3, 3", first.Line, second.Line, second.Content[1].Line)
+	}
+}
+
+func TestNode_Directives(t *testing.T) {
+	var doc Node
+	if err := Unmarshal([]byte("%YAML 1.1\n---\nn: 010\n"), &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := []string{"%YAML 1.1"}; !reflect.DeepEqual(doc.Directives, want) {
+		t.Errorf("Directives = %q, want %q", doc.Directives, want)
+	}
+	if doc.Content[1].Directives != nil {
+		t.Errorf("value Directives = %q, want none", doc.Content[1].Directives)
+	}
+
+	out, err := Marshal(&doc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := "%YAML 1.1\n---\nn: 010"; string(out) != want {
+		t.Errorf("Marshal() = %q, want %q", out, want)
+	}
+	var m map[string]interface{}
+	if err := Unmarshal(out, &m); err != nil || m["n"] != int64(8) {
+		t.Errorf("Unmarshal(Marshal()) = %v, %v; want n: 8", m, err)
+	}
+}
@@ -0,0 +1,202 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/fastparser"
+	"github.com/shapestone/shape-yaml/internal/parser"
+)
+
+var (
+	nodeType            = reflect.TypeOf(Node{})
+	nodeUnmarshalerType = reflect.TypeOf((*NodeUnmarshaler)(nil)).Elem()
+
+	// nodeTargets caches decodesNodes by type.
+	nodeTargets sync.Map // reflect.Type -> bool
+)
+
+// claimsNode reports whether values of type t are decoded from a Node: Node
+// itself, and types whose pointer implements NodeUnmarshaler.
+func claimsNode(t reflect.Type) bool {
+	return t == nodeType || reflect.PointerTo(t).Implements(nodeUnmarshalerType)
+}
+
+// decodesNodes reports whether decoding into a value of type t can reach a
+// type claimsNode claims, so that decoders only look for them when needed.
+func decodesNodes(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if found, ok := nodeTargets.Load(t); ok {
+		return found.(bool)
+	}
+	found := reachesNode(t, make(map[reflect.Type]bool))
+	nodeTargets.Store(t, found)
+	return found
+}
+
+func reachesNode(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	if claimsNode(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return reachesNode(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if reachesNode(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeNode stores n in rv, which is a Node or a NodeUnmarshaler.
+func decodeNode(n *Node, rv reflect.Value) error {
+	if rv.Type() == nodeType {
+		rv.Set(reflect.ValueOf(n).Elem())
+		return nil
+	}
+	if rv.CanAddr() {
+		return rv.Addr().Interface().(NodeUnmarshaler).UnmarshalYAML(n)
+	}
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	if err := ptr.Interface().(NodeUnmarshaler).UnmarshalYAML(n); err != nil {
+		return err
+	}
+	rv.Set(ptr.Elem())
+	return nil
+}
+
+// nodeSource decodes NodeUnmarshaler and Node values for the fast path, and the
+// values written with a tag, which the fast path does not resolve. The first
+// time it is asked, it parses the document with the AST parser, so that
+// UnmarshalYAML receives the same Node, and a tagged value decodes to the
+// same value, as on the AST path.
+type nodeSource struct {
+	line   int // line of the document within its stream
+	column int // column of its first byte on that line
+	b      *nodeBuilder
+	lines  map[int][]ast.SchemaNode // nodes by line in column order, each after those holding it
+	root   ast.SchemaNode           // root of the document
+	dirs   []string                 // directives of the document
+	err    error
+}
+
+// newNodeSource returns the nodeSource for decoding a document that starts
+// at line and column into v, or nil if v holds no NodeUnmarshaler or Node
+// values.
+func newNodeSource(v interface{}, line, column int) fastparser.NodeDecoder {
+	if !decodesNodes(reflect.TypeOf(v)) {
+		return nil
+	}
+	return &nodeSource{line: line, column: column}
+}
+
+// newTagSource returns the nodeSource for decoding the tagged values of a
+// document that starts at line and column.
+func newTagSource(line, column int) fastparser.NodeDecoder {
+	return &nodeSource{line: line, column: column}
+}
+
+func (s *nodeSource) Claims(t reflect.Type) bool {
+	return claimsNode(t)
+}
+
+// DecodeNode decodes the outermost node that starts at line, at the first
+// column from column on: a tag or anchor before the value is not part of its
+// position. Values of types other than those it claims are decoded as
+// UnmarshalWithAST decodes them; the parser reports errors at the value.
+func (s *nodeSource) DecodeNode(rv reflect.Value, data []byte, line, column int) error {
+	if s.lines == nil && s.err == nil {
+		s.err = s.parse(data)
+	}
+	if s.err != nil {
+		return s.err
+	}
+
+	nodes := s.lines[line]
+	i := sort.Search(len(nodes), func(i int) bool { return nodes[i].Position().Column >= column })
+	if i == len(nodes) {
+		return fmt.Errorf("no YAML node at line %d, column %d", line, column)
+	}
+	node := nodes[i]
+
+	if !claimsNode(rv.Type()) {
+		d := &nodeDecoder{texts: s.b.texts, order: s.b.order, tags: s.b.tags, nodes: s.b}
+		err := d.unmarshalValue(node, rv)
+		if err == nil {
+			err = fastparser.JoinTypeErrors(d.mismatches)
+		}
+		// Positions within the document are relative to where it starts
+		var pe *ParseError
+		if errors.As(err, &pe) && pe.Err != nil {
+			return pe.Err
+		}
+		return err
+	}
+
+	n := s.b.build(node)
+	if node == s.root {
+		n.Directives = s.dirs
+	}
+	if s.column > 1 {
+		n.shiftColumns(1, s.column-1)
+	}
+	n.shiftLines(s.line - 1)
+	return decodeNode(n, rv)
+}
+
+// parse parses data and indexes its nodes by line.
+func (s *nodeSource) parse(data []byte) error {
+	input := string(data)
+	p := parser.NewParser(input)
+	s.b = newNodeBuilder(p, input)
+	root, err := p.Parse()
+	if err != nil {
+		return err
+	}
+	s.root, s.dirs = root, p.Directives()
+
+	s.lines = make(map[int][]ast.SchemaNode)
+	seen := make(map[ast.SchemaNode]bool)
+	var index func(ast.SchemaNode)
+	index = func(node ast.SchemaNode) {
+		if node == nil || seen[node] {
+			return
+		}
+		seen[node] = true
+		line := node.Position().Line
+		s.lines[line] = append(s.lines[line], node)
+		switch v := node.(type) {
+		case *ast.ObjectNode:
+			for _, child := range v.Properties() {
+				index(child)
+			}
+		case *ast.ArrayDataNode:
+			for _, child := range v.Elements() {
+				index(child)
+			}
+		}
+	}
+	index(root)
+
+	// A long line can hold many nodes, so DecodeNode searches by column
+	for _, nodes := range s.lines {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return nodes[i].Position().Column < nodes[j].Position().Column
+		})
+	}
+	return nil
+}
@@ -18,6 +18,19 @@
 //	go func() { yaml.Parse(input2) }()
 //	go func() { yaml.Unmarshal(data, &v) }()
 //
+// # Sharing Parsed Trees
+//
+// A tree returned by Parse is never modified by this package.
+// NodeToInterface and NodeFromAST only read it, so a parsed base
+// configuration can be served to many goroutines without copying. The tree
+// stays safe to share as long as no goroutine writes to the maps returned by
+// ast.ObjectNode.Properties or calls ReleaseTree on it.
+//
+// The same holds for a Node: the readers (GetKey, Index, MapKeys, Query,
+// Walk and the As* helpers) only read it, and TransformScalars, FilterKeys,
+// RenameKeys and Template.Instantiate return new trees. Only SetPath and
+// DeletePath change the Node they are given.
+//
 // # Parsing APIs
 //
 // The package provides multiple parsing functions:
@@ -25,6 +38,7 @@
 //   - Parse(string) - Parses YAML from a string in memory (returns AST)
 //   - ParseReader(io.Reader) - Parses YAML from any io.Reader (returns AST)
 //   - Validate(string) - Validates YAML syntax without building AST
+//   - ValidateReader(io.Reader, Limits) - Validates an untrusted stream with size, node, and depth limits
 //
 // Use Parse() for small YAML documents that are already in memory as strings.
 // Use ParseReader() for large files, network streams, or any io.Reader source.
@@ -57,11 +71,15 @@
 package yaml
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/shapestone/shape-core/pkg/ast"
 	"github.com/shapestone/shape-core/pkg/tokenizer"
+	"github.com/shapestone/shape-yaml/internal/fastparser"
+	"github.com/shapestone/shape-yaml/internal/limits"
 	"github.com/shapestone/shape-yaml/internal/parser"
+	"github.com/shapestone/shape-yaml/internal/utf8input"
 )
 
 // Parse parses YAML format into an AST from a string.
@@ -69,8 +87,8 @@ import (
 // The input is a complete YAML document (mapping, sequence, or scalar).
 //
 // Returns an ast.SchemaNode representing the parsed YAML:
-//   - *ast.ObjectNode for mappings and sequences
-//     (sequences use numeric string keys "0", "1", "2", ...)
+//   - *ast.ObjectNode for mappings
+//   - *ast.ArrayDataNode for sequences
 //   - *ast.LiteralNode for scalars (string, number, boolean, null)
 //
 // For parsing large files or streaming data, use ParseReader instead.
@@ -85,10 +103,148 @@ import (
 //	nameNode, _ := obj.GetProperty("name")
 //	name := nameNode.(*ast.LiteralNode).Value().(string) // "Alice"
 func Parse(input string) (ast.SchemaNode, error) {
+	if err := utf8input.CheckString(input); err != nil {
+		return nil, err
+	}
 	p := parser.NewParser(input)
 	return p.Parse()
 }
 
+// MustParse is like Parse but panics if input is not valid YAML. It is meant
+// for documents fixed at compile time, in tests and package variables:
+//
+//	var defaults = yaml.MustParse("replicas: 1\nimage: app:latest")
+func MustParse(input string) ast.SchemaNode {
+	node, err := Parse(input)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+// ParseWithStyles parses input like Parse and also reports how each scalar
+// is written, so that tools rewriting a document can keep "8080" quoted or a
+// | block scalar literal.
+//
+// Example:
+//
+//	node, styles, err := yaml.ParseWithStyles(`port: "8080"`)
+//	port, _ := node.(*ast.ObjectNode).GetProperty("port")
+//	styles.Of(port) // yaml.DoubleQuoted
+func ParseWithStyles(input string) (ast.SchemaNode, Styles, error) {
+	if err := utf8input.CheckString(input); err != nil {
+		return nil, nil, err
+	}
+	p := parser.NewParser(input)
+	styles := p.RecordScalarStyles()
+	node, err := p.Parse()
+	if err != nil {
+		return nil, nil, err
+	}
+	return node, styles, nil
+}
+
+// ParseWithTags parses YAML like Parse and also returns the tags of the
+// tree, so that a validator can tell 8080 from "8080" without looking at the
+// source again. Explicit tags are reported in full, with %TAG handles
+// expanded; untagged nodes report the core schema tag they resolved to.
+//
+// Example:
+//
+//	node, tags, err := yaml.ParseWithTags("port: 8080\nname: \"8080\"")
+//	port, _ := node.(*ast.ObjectNode).GetProperty("port")
+//	tags.Of(port) // yaml.IntTag, "tag:yaml.org,2002:int"
+func ParseWithTags(input string) (ast.SchemaNode, Tags, error) {
+	if err := utf8input.CheckString(input); err != nil {
+		return nil, nil, err
+	}
+	p := parser.NewParser(input)
+	tags := p.RecordTags()
+	node, err := p.Parse()
+	if err != nil {
+		return nil, nil, err
+	}
+	return node, tags, nil
+}
+
+// ParseWithKeyOrder parses YAML like Parse and also returns the document
+// order of the keys of every mapping, which the AST's mapping nodes do not
+// keep. Keys merged in with << follow the mapping's own keys.
+//
+// Example:
+//
+//	node, order, err := yaml.ParseWithKeyOrder("name: api\nimage: api:1.2")
+//	order.Keys(node) // [name image]
+//	out, err := yaml.Marshal(yaml.NodeToOrderedInterface(node, order))
+func ParseWithKeyOrder(input string) (ast.SchemaNode, KeyOrder, error) {
+	if err := utf8input.CheckString(input); err != nil {
+		return nil, nil, err
+	}
+	p := parser.NewParser(input)
+	order := p.RecordKeyOrder()
+	node, err := p.Parse()
+	if err != nil {
+		return nil, nil, err
+	}
+	return node, order, nil
+}
+
+// Stats describes a parsed document, for logging and alerting on the growth
+// of configuration files over time.
+type Stats struct {
+	Bytes    int // size of the input
+	Nodes    int // scalars, collections and aliases, as Limits.MaxNodes counts them
+	MaxDepth int // deepest nesting, as Limits.MaxDepth measures it
+}
+
+// ParseWithStats parses YAML like Parse and also returns the size, node
+// count and depth of the document, counted while parsing.
+//
+// Example:
+//
+//	node, stats, err := yaml.ParseWithStats(manifest)
+//	log.Printf("config: %d bytes, %d nodes, depth %d", stats.Bytes, stats.Nodes, stats.MaxDepth)
+func ParseWithStats(input string) (ast.SchemaNode, Stats, error) {
+	if err := utf8input.CheckString(input); err != nil {
+		return nil, Stats{}, err
+	}
+	p := parser.NewParser(input)
+	node, err := p.Parse()
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	stats := Stats{Bytes: len(input)}
+	stats.Nodes, stats.MaxDepth = p.Stats()
+	return node, stats, nil
+}
+
+// ParseWithTrace parses YAML like Parse and writes the parser's decisions to
+// w as it goes, for debugging indentation and other parsing surprises: each
+// token matched, each indentation change, and each parse function entered
+// and left, with the line and column it happened at. The trace format is
+// meant for reading and may change between releases.
+//
+// Example:
+//
+//	node, err := yaml.ParseWithTrace(input, os.Stderr)
+//
+// prints, for "a:\n  b: 1", lines such as:
+//
+//	1:1    match String "a"
+//	1:2    match Colon ":"
+//	1:1    enter parseDocument
+//	...
+//	2:3    . . . . match String "b"
+//	2:3    . . . . indent 0 -> 2
+func ParseWithTrace(input string, w io.Writer) (ast.SchemaNode, error) {
+	if err := utf8input.CheckString(input); err != nil {
+		return nil, err
+	}
+	p := parser.NewParser(input)
+	p.SetTrace(w)
+	return p.Parse()
+}
+
 // ParseReader parses YAML format into an AST from an io.Reader.
 //
 // This function is designed for parsing large YAML files or streaming data with
@@ -125,9 +281,13 @@ func Parse(input string) (ast.SchemaNode, error) {
 //
 // For examples, see examples/parse_reader/.
 func ParseReader(reader io.Reader) (ast.SchemaNode, error) {
-	stream := tokenizer.NewStreamFromReader(reader)
-	p := parser.NewParserFromStream(stream)
-	return p.Parse()
+	ur := utf8input.NewReader(reader)
+	p := parser.NewParserFromStream(tokenizer.NewStreamFromReader(ur))
+	node, err := p.Parse()
+	if ur.Err() != nil {
+		return nil, ur.Err()
+	}
+	return node, err
 }
 
 // ParseMultiDoc parses a YAML stream containing multiple documents.
@@ -168,11 +328,35 @@ func ParseReader(reader io.Reader) (ast.SchemaNode, error) {
 //	for i, doc := range docs {
 //	    fmt.Printf("Document %d: %+v\n", i, doc)
 //	}
+//
+// Anchors are scoped to their document: an alias to an anchor of an earlier
+// document is an error, as the YAML spec requires.
 func ParseMultiDoc(input string) ([]ast.SchemaNode, error) {
+	if err := utf8input.CheckString(input); err != nil {
+		return nil, err
+	}
 	p := parser.NewParser(input)
 	return p.ParseMultiDoc()
 }
 
+// ParseMultiDocWithSharedAnchors parses a YAML stream like ParseMultiDoc, but
+// lets an alias refer to an anchor defined in an earlier document, for
+// streams written against earlier versions of this package, which did not
+// scope anchors to their document.
+//
+// Example:
+//
+//	docs, err := yaml.ParseMultiDocWithSharedAnchors("base: &b 1\n---\ncopy: *b")
+//	// docs[1] is {copy: 1}
+func ParseMultiDocWithSharedAnchors(input string) ([]ast.SchemaNode, error) {
+	if err := utf8input.CheckString(input); err != nil {
+		return nil, err
+	}
+	p := parser.NewParser(input)
+	p.ShareAnchorsAcrossDocuments()
+	return p.ParseMultiDoc()
+}
+
 // ParseMultiDocReader parses a YAML stream containing multiple documents from an io.Reader.
 //
 // This function is the streaming version of ParseMultiDoc, designed for parsing
@@ -197,9 +381,13 @@ func ParseMultiDoc(input string) ([]ast.SchemaNode, error) {
 //	    // ...
 //	}
 func ParseMultiDocReader(reader io.Reader) ([]ast.SchemaNode, error) {
-	stream := tokenizer.NewStreamFromReader(reader)
-	p := parser.NewParserFromStream(stream)
-	return p.ParseMultiDoc()
+	ur := utf8input.NewReader(reader)
+	p := parser.NewParserFromStream(tokenizer.NewStreamFromReader(ur))
+	docs, err := p.ParseMultiDoc()
+	if ur.Err() != nil {
+		return nil, ur.Err()
+	}
+	return docs, err
 }
 
 // Validate checks if a YAML string is syntactically valid.
@@ -221,8 +409,264 @@ func ParseMultiDocReader(reader io.Reader) ([]ast.SchemaNode, error) {
 //	}
 //
 // For validation with detailed error messages including line and column numbers,
-// use Parse() and check the error.
+// use Parse() and check the error. For linting, ValidateDiagnostics reports
+// warnings as well, each with its position and rule.
 func Validate(input string) error {
 	_, err := Parse(input)
 	return err
 }
+
+// Valid reports whether data is a valid YAML document, like json.Valid, for
+// callers that need no error message.
+func Valid(data []byte) bool {
+	return Validate(string(data)) == nil
+}
+
+// ErrLimitExceeded is wrapped by the error ValidateReader returns when the
+// input exceeds one of the configured Limits, and by decoding errors for
+// input nested deeper than the decoder's maximum depth. Test for it with
+// errors.Is.
+var ErrLimitExceeded = parser.ErrLimitExceeded
+
+// ErrUnsafe is wrapped by the error reporting an anchor, alias, tag, or
+// directive in input parsed or decoded with Limits.SafeMode. The message
+// names the construct and its position, as in "alias *base not allowed in
+// safe mode at line 3, column 7". Test for it with errors.Is.
+var ErrUnsafe = parser.ErrUnsafe
+
+// ErrAnchors is wrapped by the error Unmarshal, UnmarshalWithOptions and a
+// Decoder return for a document holding an anchor or alias, which they do
+// not resolve. Decode such documents with UnmarshalWithAST. Test for it
+// with errors.Is.
+var ErrAnchors = fastparser.ErrAnchors
+
+// DocumentLimitError is returned when a stream holds more documents than
+// allowed by Limits.MaxDocuments or Decoder.SetMaxDocuments. It wraps
+// ErrLimitExceeded.
+type DocumentLimitError = limits.DocumentLimitError
+
+// ErrInvalidUTF8 is wrapped by the error every parsing and decoding function
+// returns for input that is not valid UTF-8. The message reports the byte
+// offset of the first malformed sequence. Test for it with errors.Is; to
+// decode such input anyway, see Decoder.ReplaceInvalidUTF8.
+var ErrInvalidUTF8 = utf8input.ErrInvalid
+
+// DefaultMaxDepth is the nesting depth Unmarshal, UnmarshalWithAST, Parse and
+// Decoder accept unless configured otherwise. It bounds recursion on
+// malicious input, including into self-referential struct types.
+const DefaultMaxDepth = limits.DefaultMaxDepth
+
+// Limits bounds the resources ValidateReader, ParseWithLimits,
+// ParseMultiDocReaderWithLimits, UnmarshalWithOptions and a Decoder (see
+// Decoder.SetLimits) may spend on their input. A zero MaxDepth means
+// DefaultMaxDepth, since unbounded nesting would exhaust the stack; any
+// other zero field means no limit.
+type Limits struct {
+	// MaxBytes is the maximum number of input bytes read.
+	MaxBytes int64
+
+	// MaxDepth is the maximum node nesting depth. A top-level scalar has
+	// depth 1 and each enclosing collection adds one level. Zero means
+	// DefaultMaxDepth; a negative value removes the limit.
+	MaxDepth int
+
+	// MaxNodes is the maximum number of nodes (scalars, collections, and
+	// aliases) across all documents in the stream.
+	MaxNodes int
+
+	// MaxDocuments is the maximum number of documents in the stream.
+	// Exceeding it fails with a *DocumentLimitError.
+	MaxDocuments int
+
+	// MaxAnchors is the maximum number of distinct anchor names defined in
+	// any one document, against documents defining millions of anchors that
+	// are never used.
+	MaxAnchors int
+
+	// MaxNameLength is the maximum length in bytes of an anchor or alias
+	// name.
+	MaxNameLength int
+
+	// MaxAliases is the maximum number of aliases across all documents in
+	// the stream, against documents that expand a few anchors into
+	// billions of nodes when decoded.
+	MaxAliases int
+
+	// MaxKeyLength is the maximum length in bytes of a mapping key.
+	MaxKeyLength int
+
+	// SafeMode accepts plain data only, failing with an error wrapping
+	// ErrUnsafe on the first anchor, alias, directive, or tag other than
+	// the core schema tags !!str, !!int, !!float, !!bool, !!null, !!map and
+	// !!seq. Unmarshal, which does not resolve tags, rejects those too.
+	SafeMode bool
+}
+
+// parserLimits returns the AST parser's form of limits.
+func parserLimits(limits Limits) parser.Limits {
+	maxDepth := limits.MaxDepth
+	switch {
+	case maxDepth == 0:
+		maxDepth = DefaultMaxDepth
+	case maxDepth < 0:
+		maxDepth = 0
+	}
+	return parser.Limits{
+		MaxDepth:      maxDepth,
+		MaxNodes:      limits.MaxNodes,
+		MaxDocuments:  limits.MaxDocuments,
+		MaxAnchors:    limits.MaxAnchors,
+		MaxNameLength: limits.MaxNameLength,
+		MaxAliases:    limits.MaxAliases,
+		MaxKeyLength:  limits.MaxKeyLength,
+		SafeMode:      limits.SafeMode,
+	}
+}
+
+// ParseWithLimits parses YAML like Parse, but fails with an error wrapping
+// ErrLimitExceeded as soon as input exceeds one of the limits, for services
+// parsing user-supplied documents.
+//
+// Example:
+//
+//	node, err := yaml.ParseWithLimits(body, yaml.Limits{MaxBytes: 1 << 20, MaxAliases: 100, MaxKeyLength: 256})
+//	if errors.Is(err, yaml.ErrLimitExceeded) {
+//	    http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+//	}
+func ParseWithLimits(input string, limits Limits) (ast.SchemaNode, error) {
+	if limits.MaxBytes > 0 && int64(len(input)) > limits.MaxBytes {
+		return nil, errInputTooLarge(limits.MaxBytes)
+	}
+	if err := utf8input.CheckString(input); err != nil {
+		return nil, err
+	}
+	p := parser.NewParser(input)
+	p.SetLimits(parserLimits(limits))
+	return p.Parse()
+}
+
+// errInputTooLarge reports input of more than max bytes.
+func errInputTooLarge(max int64) error {
+	return fmt.Errorf("yaml: %w: input larger than %d bytes", ErrLimitExceeded, max)
+}
+
+// ValidateReader checks that a YAML stream read from r is syntactically valid
+// and within limits. It is intended for untrusted input such as file uploads.
+//
+// The stream is read incrementally and every document in a multi-document
+// stream is validated. Each document is discarded as soon as it has been
+// checked, so memory use is bounded by the largest document rather than the
+// whole stream, and no Go values are built. Parsing stops at the first limit
+// that is exceeded.
+//
+// Returns nil if the stream is valid, an error wrapping ErrLimitExceeded if a
+// limit was exceeded, an error from r, or a syntax error.
+//
+// Example:
+//
+//	limits := yaml.Limits{MaxBytes: 1 << 20, MaxDepth: 32, MaxNodes: 10000}
+//	if err := yaml.ValidateReader(req.Body, limits); err != nil {
+//	    if errors.Is(err, yaml.ErrLimitExceeded) {
+//	        http.Error(w, "upload too large", http.StatusRequestEntityTooLarge)
+//	        return
+//	    }
+//	    http.Error(w, err.Error(), http.StatusBadRequest)
+//	    return
+//	}
+func ValidateReader(r io.Reader, limits Limits) error {
+	lp := newLimitedParser(r, limits)
+	err := lp.p.ParseDocuments(func(doc ast.SchemaNode) error {
+		ReleaseTree(doc)
+		return lp.lr.err
+	})
+	return lp.err(err)
+}
+
+// ParseMultiDocReaderWithLimits parses a YAML stream like ParseMultiDocReader,
+// but stops with an error wrapping ErrLimitExceeded as soon as the stream
+// exceeds one of the limits, for untrusted input that could otherwise hold
+// millions of tiny documents. Documents parsed before the limit was reached
+// are discarded.
+//
+// Example:
+//
+//	docs, err := yaml.ParseMultiDocReaderWithLimits(file, yaml.Limits{MaxDocuments: 100})
+//	var tooMany *yaml.DocumentLimitError
+//	if errors.As(err, &tooMany) {
+//	    return fmt.Errorf("manifest has more than %d resources", tooMany.Max)
+//	}
+func ParseMultiDocReaderWithLimits(r io.Reader, limits Limits) ([]ast.SchemaNode, error) {
+	lp := newLimitedParser(r, limits)
+	var docs []ast.SchemaNode
+	err := lp.p.ParseDocuments(func(doc ast.SchemaNode) error {
+		docs = append(docs, doc)
+		return lp.lr.err
+	})
+	if err = lp.err(err); err != nil {
+		for _, doc := range docs {
+			ReleaseTree(doc)
+		}
+		return nil, err
+	}
+	return docs, nil
+}
+
+// limitedParser is a streaming parser enforcing Limits.
+type limitedParser struct {
+	p  *parser.Parser
+	lr *limitedReader
+	ur *utf8input.Reader
+}
+
+func newLimitedParser(r io.Reader, limits Limits) *limitedParser {
+	lr := &limitedReader{r: r, max: limits.MaxBytes, remaining: limits.MaxBytes}
+	ur := utf8input.NewReader(lr)
+	p := parser.NewParserFromStream(tokenizer.NewStreamFromReader(ur))
+	p.SetLimits(parserLimits(limits))
+	return &limitedParser{p: p, lr: lr, ur: ur}
+}
+
+// err returns the error to report for a parse that ended with err.
+func (lp *limitedParser) err(err error) error {
+	// The stream treats read errors as end of input, so a truncated read can
+	// look like a syntax error or even a valid document; report the cause.
+	if lp.lr.err != nil {
+		return lp.lr.err
+	}
+	if lp.ur.Err() != nil {
+		return lp.ur.Err()
+	}
+	return err
+}
+
+// limitedReader reads from r and fails once more than max bytes have been
+// read (max <= 0 means unlimited). The first error other than io.EOF is kept
+// in err.
+type limitedReader struct {
+	r         io.Reader
+	max       int64
+	remaining int64
+	err       error
+}
+
+func (l *limitedReader) Read(b []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	if l.max > 0 && int64(len(b)) > l.remaining+1 {
+		// Read at most one byte past the limit, so input of exactly max bytes is accepted
+		b = b[:l.remaining+1]
+	}
+	n, err := l.r.Read(b)
+	if l.max > 0 {
+		if int64(n) > l.remaining {
+			l.err = errInputTooLarge(l.max)
+			return 0, l.err
+		}
+		l.remaining -= int64(n)
+	}
+	if err != nil && err != io.EOF {
+		l.err = err
+	}
+	return n, err
+}
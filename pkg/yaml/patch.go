@@ -0,0 +1,612 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-yaml/internal/resolve"
+)
+
+// ApplyJSONPatch applies an RFC 6902 JSON Patch to a YAML document and
+// returns the patched document as YAML.
+//
+// The patch is a JSON array of operations (add, remove, replace, move, copy,
+// test) whose paths are RFC 6901 JSON Pointers into the YAML document:
+//
+//	patch := []byte(`[
+//	  {"op": "replace", "path": "/spec/replicas", "value": 3},
+//	  {"op": "add", "path": "/metadata/labels/tier", "value": "web"}
+//	]`)
+//	out, err := yaml.ApplyJSONPatch(manifest, patch)
+//
+// Operations are applied in order and the whole patch fails if any operation
+// fails, including a failed test operation.
+//
+// The document is read as a Node tree, so aliases, including the mappings
+// merged in with <<, are resolved and patch paths see through them; an
+// alias is written back as a copy of the node it names. Mapping keys keep
+// their document order and new keys are appended, scalars keep their tags,
+// and literal block scalars stay literal, so the output differs from the
+// input only where the patch changed it. Comments and the other formatting
+// choices (quoting, flow style, anchors) are not preserved; the result is
+// emitted as Marshal would emit the Node, quoting strings where needed.
+func ApplyJSONPatch(doc []byte, patch []byte) ([]byte, error) {
+	ops, err := decodeJSONValue(patch)
+	if err != nil {
+		return nil, fmt.Errorf("yaml: invalid JSON patch: %w", err)
+	}
+	list, ok := ops.([]interface{})
+	if !ok {
+		return nil, errors.New("yaml: invalid JSON patch: not an array of operations")
+	}
+
+	root, err := patchTarget(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, raw := range list {
+		op, ok := raw.(MapSlice)
+		if !ok {
+			return nil, fmt.Errorf("yaml: JSON patch operation %d is not an object", i)
+		}
+		if err := applyPatchOperation(root, op); err != nil {
+			return nil, fmt.Errorf("yaml: JSON patch operation %d: %w", i, err)
+		}
+	}
+
+	return Marshal(root)
+}
+
+// ApplyMergePatch applies an RFC 7386 JSON Merge Patch to a YAML document and
+// returns the patched document as YAML.
+//
+// Objects in the patch are merged recursively into the document, a null
+// value removes the key, and any other value replaces the target:
+//
+//	out, err := yaml.ApplyMergePatch(config, []byte(`{"server": {"port": 9090, "debug": null}}`))
+//
+// The patch may be JSON or YAML. Existing keys keep their position and new
+// keys are appended in patch order. The document is read and written as for
+// ApplyJSONPatch.
+func ApplyMergePatch(doc []byte, patch []byte) ([]byte, error) {
+	var p *Node
+	if v, err := decodeJSONValue(patch); err == nil {
+		if p, err = nodeOf(v); err != nil {
+			return nil, fmt.Errorf("yaml: invalid merge patch: %w", err)
+		}
+	} else {
+		// Merge patches are often written as YAML
+		if p, err = patchTarget(patch); err != nil {
+			return nil, fmt.Errorf("yaml: invalid merge patch: %w", err)
+		}
+	}
+
+	root, err := patchTarget(doc)
+	if err != nil {
+		return nil, err
+	}
+	merged := mergePatch(root, p)
+	merged.Directives = root.Directives
+	return Marshal(merged)
+}
+
+// patchTarget parses doc into the Node tree a patch is applied to. An empty
+// document is a null.
+func patchTarget(doc []byte) (*Node, error) {
+	var n Node
+	if err := n.UnmarshalYAML(doc); err != nil {
+		return nil, err
+	}
+	if n.Kind == InvalidKind {
+		n = Node{Kind: ScalarKind, Tag: NullTag}
+	}
+	return &n, nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7386, changing
+// target in place where it is a mapping, and returns the patched node.
+func mergePatch(target, patch *Node) *Node {
+	if patch.Kind != MappingKind {
+		return patch.clone()
+	}
+	if target == nil || target.Kind != MappingKind {
+		target = &Node{Kind: MappingKind, Tag: MapTag}
+	}
+
+	for i := 0; i+1 < len(patch.Content); i += 2 {
+		key, value := patch.Content[i].Value, patch.Content[i+1]
+		j := mappingKeyIndex(target, key)
+		if isNullNode(value) {
+			if j >= 0 {
+				target.Content = append(target.Content[:j], target.Content[j+2:]...)
+			}
+			continue
+		}
+		if j >= 0 {
+			target.Content[j+1] = mergePatch(target.Content[j+1], value)
+		} else {
+			target.Content = append(target.Content, patchKeyNode(key), mergePatch(nil, value))
+		}
+	}
+	return target
+}
+
+// isNullNode reports whether n is a null scalar.
+func isNullNode(n *Node) bool {
+	return n.Kind == ScalarKind && n.Tag == NullTag
+}
+
+// patchKeyNode returns the node of a mapping key a patch adds.
+func patchKeyNode(key string) *Node {
+	return &Node{Kind: ScalarKind, Tag: StrTag, Value: key}
+}
+
+// applyPatchOperation applies a single JSON Patch operation to root.
+func applyPatchOperation(root *Node, op MapSlice) error {
+	name, _ := mapSliceGet(op, "op").(string)
+	pathStr, ok := mapSliceGet(op, "path").(string)
+	if !ok {
+		return fmt.Errorf("%s: missing path", name)
+	}
+	path, err := parseJSONPointer(pathStr)
+	if err != nil {
+		return err
+	}
+
+	raw, hasValue := mapSliceLookup(op, "value")
+	value := func() (*Node, error) {
+		if !hasValue {
+			return nil, fmt.Errorf("%s %s: missing value", name, pathStr)
+		}
+		return nodeOf(raw)
+	}
+	from := func() ([]string, error) {
+		s, ok := mapSliceGet(op, "from").(string)
+		if !ok {
+			return nil, fmt.Errorf("%s %s: missing from", name, pathStr)
+		}
+		return parseJSONPointer(s)
+	}
+
+	switch name {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return err
+		}
+		return patchAdd(root, path, v)
+
+	case "remove":
+		_, err := patchRemove(root, path)
+		return err
+
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return err
+		}
+		return patchReplace(root, path, v)
+
+	case "move":
+		fromPath, err := from()
+		if err != nil {
+			return err
+		}
+		if isProperPrefix(fromPath, path) {
+			return fmt.Errorf("move %s: cannot move a value into one of its children", pathStr)
+		}
+		v, err := patchRemove(root, fromPath)
+		if err != nil {
+			return err
+		}
+		return patchAdd(root, path, v)
+
+	case "copy":
+		fromPath, err := from()
+		if err != nil {
+			return err
+		}
+		v, err := patchGet(root, fromPath)
+		if err != nil {
+			return err
+		}
+		return patchAdd(root, path, v.clone())
+
+	case "test":
+		if !hasValue {
+			return fmt.Errorf("%s %s: missing value", name, pathStr)
+		}
+		n, err := patchGet(root, path)
+		if err != nil {
+			return err
+		}
+		v, err := patchValue(n)
+		if err != nil {
+			return fmt.Errorf("test %s: %w", pathStr, err)
+		}
+		if !jsonEqual(v, raw) {
+			return fmt.Errorf("test %s: value does not match", pathStr)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown op %q", name)
+	}
+}
+
+// parseJSONPointer splits an RFC 6901 JSON Pointer into unescaped tokens.
+func parseJSONPointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// isProperPrefix reports whether prefix is a proper prefix of path.
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// patchGet returns the node at path below root.
+func patchGet(root *Node, path []string) (*Node, error) {
+	cur := root
+	for i, tok := range path {
+		var err error
+		if cur, err = patchChild(cur, tok); err != nil {
+			return nil, fmt.Errorf("%s: %w", formatJSONPointer(path[:i+1]), err)
+		}
+	}
+	return cur, nil
+}
+
+// patchChild returns the member tok of a mapping or sequence.
+func patchChild(n *Node, tok string) (*Node, error) {
+	switch n.Kind {
+	case MappingKind:
+		if j := mappingKeyIndex(n, tok); j >= 0 {
+			return n.Content[j+1], nil
+		}
+		return nil, errors.New("key not found")
+	case SequenceKind:
+		i, err := sequenceIndex(tok, len(n.Content)-1)
+		if err != nil {
+			return nil, err
+		}
+		return n.Content[i], nil
+	default:
+		return nil, fmt.Errorf("cannot index %s", describePatchNode(n))
+	}
+}
+
+// setRoot replaces the document root with v, keeping its directives.
+func setRoot(root, v *Node) {
+	dirs := root.Directives
+	*root = *v
+	root.Directives = dirs
+}
+
+func patchAdd(root *Node, path []string, value *Node) error {
+	if len(path) == 0 {
+		setRoot(root, value)
+		return nil
+	}
+	parent, err := patchGet(root, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	switch parent.Kind {
+	case MappingKind:
+		if j := mappingKeyIndex(parent, last); j >= 0 {
+			parent.Content[j+1] = value
+		} else {
+			parent.Content = append(parent.Content, patchKeyNode(last), value)
+		}
+	case SequenceKind:
+		if last == "-" {
+			parent.Content = append(parent.Content, value)
+			return nil
+		}
+		i, err := sequenceIndex(last, len(parent.Content))
+		if err != nil {
+			return fmt.Errorf("add %s: %w", formatJSONPointer(path), err)
+		}
+		parent.Content = append(parent.Content, nil)
+		copy(parent.Content[i+1:], parent.Content[i:])
+		parent.Content[i] = value
+	default:
+		return fmt.Errorf("add %s: cannot add to %s", formatJSONPointer(path), describePatchNode(parent))
+	}
+	return nil
+}
+
+// patchRemove removes the node at path and returns it.
+func patchRemove(root *Node, path []string) (*Node, error) {
+	if len(path) == 0 {
+		return nil, errors.New("remove: cannot remove the whole document")
+	}
+	parent, err := patchGet(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch parent.Kind {
+	case MappingKind:
+		j := mappingKeyIndex(parent, last)
+		if j < 0 {
+			return nil, fmt.Errorf("remove %s: key not found", formatJSONPointer(path))
+		}
+		v := parent.Content[j+1]
+		parent.Content = append(parent.Content[:j], parent.Content[j+2:]...)
+		return v, nil
+	case SequenceKind:
+		i, err := sequenceIndex(last, len(parent.Content)-1)
+		if err != nil {
+			return nil, fmt.Errorf("remove %s: %w", formatJSONPointer(path), err)
+		}
+		v := parent.Content[i]
+		parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
+		return v, nil
+	default:
+		return nil, fmt.Errorf("remove %s: cannot remove from %s", formatJSONPointer(path), describePatchNode(parent))
+	}
+}
+
+func patchReplace(root *Node, path []string, value *Node) error {
+	if len(path) == 0 {
+		setRoot(root, value)
+		return nil
+	}
+	parent, err := patchGet(root, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	switch parent.Kind {
+	case MappingKind:
+		j := mappingKeyIndex(parent, last)
+		if j < 0 {
+			return fmt.Errorf("replace %s: key not found", formatJSONPointer(path))
+		}
+		parent.Content[j+1] = value
+	case SequenceKind:
+		i, err := sequenceIndex(last, len(parent.Content)-1)
+		if err != nil {
+			return fmt.Errorf("replace %s: %w", formatJSONPointer(path), err)
+		}
+		parent.Content[i] = value
+	default:
+		return fmt.Errorf("replace %s: cannot replace in %s", formatJSONPointer(path), describePatchNode(parent))
+	}
+	return nil
+}
+
+// sequenceIndex parses a JSON Pointer array index and checks it against max.
+func sequenceIndex(tok string, max int) (int, error) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func formatJSONPointer(path []string) string {
+	var b strings.Builder
+	for _, tok := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(tok, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+func describeValue(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// describePatchNode names the kind of n for patch errors.
+func describePatchNode(n *Node) string {
+	if isNullNode(n) {
+		return "null"
+	}
+	return describeNode(n)
+}
+
+// patchKey returns the JSON Pointer form of a mapping key.
+func patchKey(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+func mapSliceIndex(m MapSlice, key string) int {
+	for i := range m {
+		if patchKey(m[i].Key) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func mapSliceLookup(m MapSlice, key string) (interface{}, bool) {
+	if i := mapSliceIndex(m, key); i >= 0 {
+		return m[i].Value, true
+	}
+	return nil, false
+}
+
+func mapSliceGet(m MapSlice, key string) interface{} {
+	v, _ := mapSliceLookup(m, key)
+	return v
+}
+
+// patchValue returns the value n holds, in the types decodeJSONValue
+// produces, for comparing it with the value of a test operation.
+func patchValue(n *Node) (interface{}, error) {
+	switch n.Kind {
+	case MappingKind:
+		m := make(MapSlice, 0, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v, err := patchValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, MapItem{Key: n.Content[i].Value, Value: v})
+		}
+		return m, nil
+	case SequenceKind:
+		s := make([]interface{}, len(n.Content))
+		for i, c := range n.Content {
+			v, err := patchValue(c)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = v
+		}
+		return s, nil
+	case ScalarKind:
+		return n.scalarValue()
+	}
+	return nil, nil
+}
+
+// jsonEqual compares values as JSON would: numbers by value regardless of
+// Go type, and objects regardless of key order.
+func jsonEqual(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	switch av := a.(type) {
+	case MapSlice:
+		bv, ok := b.(MapSlice)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for _, item := range av {
+			w, ok := mapSliceLookup(bv, patchKey(item.Key))
+			if !ok || !jsonEqual(item.Value, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, !math.IsNaN(n)
+	}
+	return 0, false
+}
+
+// decodeJSONValue decodes JSON into the same value types the YAML decoder
+// produces: MapSlice for objects (in order), []interface{}, string, bool, nil,
+// and int64, uint64 or float64 for numbers.
+func decodeJSONValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeJSONToken(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+func decodeJSONToken(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := MapSlice{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				val, err := decodeJSONToken(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, MapItem{Key: keyTok.(string), Value: val})
+			}
+			_, err := dec.Token() // '}'
+			return obj, err
+		case '[':
+			arr := []interface{}{}
+			for dec.More() {
+				val, err := decodeJSONToken(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, val)
+			}
+			_, err := dec.Token() // ']'
+			return arr, err
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	case json.Number:
+		if n, ok := resolve.Number(string(t)); ok {
+			return n, nil
+		}
+		return nil, fmt.Errorf("invalid number %s", t)
+	default:
+		return t, nil
+	}
+}
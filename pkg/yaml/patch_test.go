@@ -0,0 +1,301 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "add object member keeps order",
+			doc:   "zeta: 1\nalpha: 2\n",
+			patch: `[{"op": "add", "path": "/mid", "value": "x"}]`,
+			want:  "zeta: 1\nalpha: 2\nmid: x",
+		},
+		{
+			name:  "add array element",
+			doc:   "items:\n  - a\n  - c\n",
+			patch: `[{"op": "add", "path": "/items/1", "value": "b"}, {"op": "add", "path": "/items/-", "value": "d"}]`,
+			want:  "items: \n  - a\n  - b\n  - c\n  - d",
+		},
+		{
+			name:  "remove",
+			doc:   "a: 1\nb: 2\nc: [1, 2, 3]\n",
+			patch: `[{"op": "remove", "path": "/b"}, {"op": "remove", "path": "/c/0"}]`,
+			want:  "a: 1\nc: \n  - 2\n  - 3",
+		},
+		{
+			name:  "replace nested value in place",
+			doc:   "spec:\n  replicas: 1\n  image: app:v1\n",
+			patch: `[{"op": "replace", "path": "/spec/replicas", "value": 3}]`,
+			want:  "spec: \n  replicas: 3\n  image: \"app:v1\"",
+		},
+		{
+			name:  "move",
+			doc:   "a:\n  x: 1\nb: {}\n",
+			patch: `[{"op": "move", "from": "/a/x", "path": "/b/y"}]`,
+			want:  "a: {}\nb: \n  y: 1",
+		},
+		{
+			name:  "copy is deep",
+			doc:   "a:\n  x: 1\n",
+			patch: `[{"op": "copy", "from": "/a", "path": "/b"}, {"op": "replace", "path": "/b/x", "value": 2}]`,
+			want:  "a: \n  x: 1\nb: \n  x: 2",
+		},
+		{
+			name:  "test passes with numeric equality",
+			doc:   "n: 1\nm:\n  k: [true, null]\n",
+			patch: `[{"op": "test", "path": "/n", "value": 1.0}, {"op": "test", "path": "/m", "value": {"k": [true, null]}}]`,
+			want:  "n: 1\nm: \n  k: \n    - true\n    - null",
+		},
+		{
+			name:  "escaped pointer tokens",
+			doc:   "\"a/b\": 1\n\"m~n\": 2\n",
+			patch: `[{"op": "replace", "path": "/a~1b", "value": 10}, {"op": "remove", "path": "/m~0n"}]`,
+			want:  "a/b: 10",
+		},
+		{
+			name:  "added object keeps patch order",
+			doc:   "a: 1\n",
+			patch: `[{"op": "add", "path": "/b", "value": {"z": 1, "y": 2}}]`,
+			want:  "a: 1\nb: \n  z: 1\n  y: 2",
+		},
+		{
+			name:  "replace whole document",
+			doc:   "a: 1\n",
+			patch: `[{"op": "replace", "path": "", "value": [1, 2]}]`,
+			want:  "- 1\n- 2",
+		},
+		{
+			name:    "test fails",
+			doc:     "a: 1\n",
+			patch:   `[{"op": "test", "path": "/a", "value": 2}]`,
+			wantErr: "operation 0: test /a: value does not match",
+		},
+		{
+			name:    "failed operation aborts patch",
+			doc:     "a: 1\n",
+			patch:   `[{"op": "remove", "path": "/a"}, {"op": "remove", "path": "/a"}]`,
+			wantErr: "operation 1: remove /a: key not found",
+		},
+		{
+			name:    "missing parent",
+			doc:     "a: 1\n",
+			patch:   `[{"op": "add", "path": "/x/y", "value": 1}]`,
+			wantErr: "/x: key not found",
+		},
+		{
+			name:    "array index out of range",
+			doc:     "a: [1]\n",
+			patch:   `[{"op": "add", "path": "/a/5", "value": 1}]`,
+			wantErr: "array index 5 out of range",
+		},
+		{
+			name:    "leading zero index",
+			doc:     "a: [1, 2]\n",
+			patch:   `[{"op": "remove", "path": "/a/01"}]`,
+			wantErr: `invalid array index "01"`,
+		},
+		{
+			name:    "move into own child",
+			doc:     "a:\n  b: 1\n",
+			patch:   `[{"op": "move", "from": "/a", "path": "/a/c"}]`,
+			wantErr: "cannot move a value into one of its children",
+		},
+		{
+			name:    "missing value",
+			doc:     "a: 1\n",
+			patch:   `[{"op": "add", "path": "/b"}]`,
+			wantErr: "add /b: missing value",
+		},
+		{
+			name:    "unknown op",
+			doc:     "a: 1\n",
+			patch:   `[{"op": "frobnicate", "path": "/a"}]`,
+			wantErr: `unknown op "frobnicate"`,
+		},
+		{
+			name:    "patch not an array",
+			doc:     "a: 1\n",
+			patch:   `{"op": "remove", "path": "/a"}`,
+			wantErr: "not an array of operations",
+		},
+		{
+			name:    "invalid pointer",
+			doc:     "a: 1\n",
+			patch:   `[{"op": "remove", "path": "a"}]`,
+			wantErr: `invalid JSON pointer "a"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyJSONPatch([]byte(tt.doc), []byte(tt.patch))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ApplyJSONPatch() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyJSONPatch() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ApplyJSONPatch() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			// Example from RFC 7386 section 3
+			name:  "rfc example",
+			doc:   "title: Goodbye!\nauthor:\n  givenName: John\n  familyName: Doe\ntags: [example, sample]\ncontent: This will be unchanged\n",
+			patch: `{"title": "Hello!", "phoneNumber": "+01-123-456-7890", "author": {"familyName": null}, "tags": ["example"]}`,
+			want:  "title: Hello!\nauthor: \n  givenName: John\ntags: \n  - example\ncontent: This will be unchanged\nphoneNumber: \"+01-123-456-7890\"",
+		},
+		{
+			name:  "yaml patch",
+			doc:   "server:\n  port: 8080\n  debug: true\n",
+			patch: "server:\n  port: 9090\n  debug: null\n",
+			want:  "server: \n  port: 9090",
+		},
+		{
+			name:  "object replaces scalar",
+			doc:   "a: 1\n",
+			patch: `{"a": {"b": {"c": null, "d": 1}}}`,
+			want:  "a: \n  b: \n    d: 1",
+		},
+		{
+			name:  "non-object patch replaces document",
+			doc:   "a: 1\n",
+			patch: `["x"]`,
+			want:  "- x",
+		},
+		{
+			name:  "remove missing key is a no-op",
+			doc:   "a: 1\n",
+			patch: `{"b": null}`,
+			want:  "a: 1",
+		},
+		{
+			name:    "invalid patch",
+			doc:     "a: 1\n",
+			patch:   "{a: [",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyMergePatch([]byte(tt.doc), []byte(tt.patch))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ApplyMergePatch() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyMergePatch() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ApplyMergePatch() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatch_Fidelity(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string // JSON Patch; the merge patch {"n": 1} is applied as well
+		want  string
+	}{
+		{
+			name:  "alias is written as a copy",
+			doc:   "base: &b\n  x: 1\nuse: *b\n",
+			patch: `[{"op": "add", "path": "/n", "value": 1}]`,
+			want:  "base: \n  x: 1\nuse: \n  x: 1\nn: 1",
+		},
+		{
+			name:  "merge key is expanded",
+			doc:   "d: &d\n  a: 1\n  b: 2\ne:\n  <<: *d\n  b: 3\n",
+			patch: `[{"op": "add", "path": "/n", "value": 1}]`,
+			want:  "d: \n  a: 1\n  b: 2\ne: \n  b: 3\n  a: 1\nn: 1",
+		},
+		{
+			name:  "quoted colon stays quoted",
+			doc:   "q: \"a: b\"\n",
+			patch: `[{"op": "add", "path": "/n", "value": 1}]`,
+			want:  "q: \"a: b\"\nn: 1",
+		},
+		{
+			name:  "literal block stays literal",
+			doc:   "s: |\n  line one\n  line two\n",
+			patch: `[{"op": "add", "path": "/n", "value": 1}]`,
+			want:  "s: |\n  line one\n  line two\nn: 1",
+		},
+		{
+			name:  "replace through an alias",
+			doc:   "base: &b\n  x: 1\nuse: *b\n",
+			patch: `[{"op": "replace", "path": "/use/x", "value": 2}, {"op": "add", "path": "/n", "value": 1}]`,
+			want:  "base: \n  x: 1\nuse: \n  x: 2\nn: 1",
+		},
+		{
+			name:  "added value with a colon is quoted",
+			doc:   "a: 1\n",
+			patch: `[{"op": "add", "path": "/n", "value": 1}, {"op": "add", "path": "/v", "value": "x: y"}]`,
+			want:  "a: 1\nn: 1\nv: \"x: y\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyJSONPatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("ApplyJSONPatch() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ApplyJSONPatch() =\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			merged, err := ApplyMergePatch([]byte(tt.doc), []byte(`{"n": 1}`))
+			if err != nil {
+				t.Fatalf("ApplyMergePatch() error = %v", err)
+			}
+			var want interface{}
+			if err := UnmarshalWithAST([]byte(tt.doc), &want); err != nil {
+				t.Fatalf("UnmarshalWithAST(doc) error = %v", err)
+			}
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var out map[string]interface{}
+				if err := decode(merged, &out); err != nil {
+					t.Fatalf("decoding %q: %v", merged, err)
+				}
+				if out["n"] != int64(1) {
+					t.Errorf("merged n = %#v, want 1", out["n"])
+				}
+				delete(out, "n")
+				if !parityEqual(interface{}(out), want) {
+					t.Errorf("ApplyMergePatch() = %q, which decodes to %#v, want %#v", merged, out, want)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,436 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is one step of a parsed path.
+type pathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func (s pathSegment) String() string {
+	switch {
+	case s.wildcard:
+		return "[*]"
+	case s.isIndex:
+		return "[" + strconv.Itoa(s.index) + "]"
+	case s.key == "" || strings.ContainsAny(s.key, ".[]\"*") || strings.TrimSpace(s.key) != s.key:
+		return "[" + strconv.Quote(s.key) + "]"
+	}
+	return "." + s.key
+}
+
+// formatPath returns the path segs name, as it appears in errors.
+func formatPath(segs []pathSegment) string {
+	if len(segs) == 0 {
+		return "."
+	}
+	var b strings.Builder
+	for _, s := range segs {
+		b.WriteString(s.String())
+	}
+	return strings.TrimPrefix(b.String(), ".")
+}
+
+// parsePath splits a path into its segments.
+func parsePath(path string) ([]pathSegment, error) {
+	fail := func(msg string) ([]pathSegment, error) {
+		return nil, fmt.Errorf("yaml: invalid path %q: %s", path, msg)
+	}
+
+	var segs []pathSegment
+	rest := strings.TrimPrefix(path, ".")
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if strings.HasPrefix(rest, `["`) {
+				s, err := strconv.QuotedPrefix(rest[1:])
+				if err != nil {
+					return fail("unterminated quoted key")
+				}
+				end = 1 + len(s)
+				if end >= len(rest) || rest[end] != ']' {
+					return fail("missing ] after quoted key")
+				}
+				key, _ := strconv.Unquote(s)
+				segs = append(segs, pathSegment{key: key})
+			} else if end < 0 {
+				return fail("missing ]")
+			} else if inner := strings.TrimSpace(rest[1:end]); inner == "*" {
+				segs = append(segs, pathSegment{wildcard: true})
+			} else if i, err := strconv.Atoi(inner); err == nil {
+				segs = append(segs, pathSegment{index: i, isIndex: true})
+			} else {
+				return fail(fmt.Sprintf("%q is not an index, a quoted key or *", rest[1:end]))
+			}
+			rest = rest[end+1:]
+
+		case rest[0] == '"':
+			s, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return fail("unterminated quoted key")
+			}
+			key, _ := strconv.Unquote(s)
+			segs = append(segs, pathSegment{key: key})
+			rest = rest[len(s):]
+
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return fail("empty key")
+			}
+			if key == "*" {
+				segs = append(segs, pathSegment{wildcard: true})
+			} else {
+				segs = append(segs, pathSegment{key: key})
+			}
+			rest = rest[end:]
+		}
+
+		// A key follows a "."; an index needs none
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" {
+				return fail("trailing .")
+			}
+		} else if rest != "" && rest[0] != '[' {
+			return fail(fmt.Sprintf("unexpected %q", rest[0]))
+		}
+	}
+	return segs, nil
+}
+
+// children returns the nodes seg selects below n, in document order: none
+// if n has no such key or index, or is not a collection.
+func (seg pathSegment) children(n *Node) []*Node {
+	switch {
+	case n == nil:
+		return nil
+	case seg.wildcard && n.Kind == SequenceKind:
+		return n.Content
+	case seg.wildcard && n.Kind == MappingKind:
+		var values []*Node
+		for i := 1; i < len(n.Content); i += 2 {
+			values = append(values, n.Content[i])
+		}
+		return values
+	case seg.isIndex:
+		if i, ok := seg.resolveIndex(n); ok {
+			return []*Node{n.Content[i]}
+		}
+	case !seg.wildcard:
+		if i := mappingKeyIndex(n, seg.key); i >= 0 {
+			return []*Node{n.Content[i+1]}
+		}
+	}
+	return nil
+}
+
+// resolveIndex returns the position in sequence n of seg's index, counting
+// a negative index from the end.
+func (seg pathSegment) resolveIndex(n *Node) (int, bool) {
+	if n.Kind != SequenceKind {
+		return 0, false
+	}
+	i := seg.index
+	if i < 0 {
+		i += len(n.Content)
+	}
+	return i, i >= 0 && i < len(n.Content)
+}
+
+// mappingKeyIndex returns the position in n.Content of the first scalar key
+// equal to key, or -1 if n is not a mapping or has no such key. A mapping's
+// own keys come before those merged in with <<, so they win.
+func mappingKeyIndex(n *Node, key string) int {
+	if n.Kind != MappingKind {
+		return -1
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if k := n.Content[i]; k != nil && k.Kind == ScalarKind && k.Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// match returns the nodes segs select below the nodes in from.
+func match(from []*Node, segs []pathSegment) []*Node {
+	for _, seg := range segs {
+		var next []*Node
+		for _, n := range from {
+			next = append(next, seg.children(n)...)
+		}
+		from = next
+	}
+	return from
+}
+
+// Query returns the nodes path names below node, in document order, for
+// reading a document unmarshaled into a Node without decoding all of it.
+// Paths name nodes the way yq does:
+//
+//	spec.template.containers[0].image
+//	spec.containers[*].image // every element of a sequence
+//	metadata.labels.*        // every value of a mapping
+//	data["app.conf"]         // a key holding "." or "[", also "app.conf"
+//	items[-1]                // the last element
+//
+// Segments are mapping keys separated by ".", and sequence indices or
+// wildcards in brackets; a leading "." is optional, and "" or "." names the
+// root. A bare * or [*] matches every item of a sequence and every value of
+// a mapping.
+//
+// A path that matches nothing returns no nodes and no error; only a
+// malformed path is an error. The nodes returned belong to the tree, so
+// changing them changes node. For a tree returned by Parse, query
+// NodeFromAST(tree).
+//
+// Example:
+//
+//	var doc yaml.Node
+//	err := yaml.Unmarshal(data, &doc)
+//	images, err := yaml.Query(&doc, "spec.template.spec.containers[*].image")
+//	for _, image := range images {
+//	    fmt.Println(image.Value)
+//	}
+func Query(node *Node, path string) ([]*Node, error) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil || node.Kind == InvalidKind {
+		return nil, nil
+	}
+	return match([]*Node{node}, segs), nil
+}
+
+// SetPath sets the nodes path names below node, as for Query, to value, for
+// editing a document unmarshaled into a Node and marshaling it back; the
+// rest of the document keeps its key order, styles and tags.
+//
+// value is a Node or *Node, which is copied, or any value Marshal accepts.
+// Missing mapping keys along the path are created, appended after the
+// existing keys, as are mappings or sequences in place of an empty document
+// or a null. An index may also be the length of its sequence, appending an
+// element. Wildcards set every existing match, each to its own copy of
+// value. Setting a key of a scalar or a sequence, or an index of anything
+// but a sequence, is an error.
+//
+// Example:
+//
+//	err := yaml.SetPath(&doc, "spec.template.spec.containers[0].image", "nginx:1.27")
+//	out, err := yaml.Marshal(&doc)
+func SetPath(node *Node, path string, value interface{}) error {
+	if node == nil {
+		return errors.New("yaml: SetPath on a nil node")
+	}
+	segs, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	v, err := nodeOf(value)
+	if err != nil {
+		return err
+	}
+	v = v.clone() // value may be part of the tree being changed
+
+	if len(segs) == 0 {
+		dirs := node.Directives
+		*node = *v.clone()
+		node.Directives = dirs
+		return nil
+	}
+	return setPath(node, segs, 0, v)
+}
+
+// setPath sets segs[i:] below n, reached by segs[:i], to v.
+func setPath(n *Node, segs []pathSegment, i int, v *Node) error {
+	seg, last := segs[i], i == len(segs)-1
+
+	// An empty document or a null becomes the collection seg needs
+	if n.Kind == InvalidKind || (n.Kind == ScalarKind && n.Tag == NullTag) {
+		if seg.wildcard {
+			return nil
+		}
+		kind, tag := MappingKind, MapTag
+		if seg.isIndex {
+			kind, tag = SequenceKind, SeqTag
+		}
+		*n = Node{Kind: kind, Tag: tag, Anchor: n.Anchor, Line: n.Line, Column: n.Column, Directives: n.Directives}
+	}
+
+	// set stores v in the slot of a child, or goes on below it
+	set := func(slot **Node) error {
+		if last {
+			*slot = v.clone()
+			return nil
+		}
+		if *slot == nil {
+			*slot = &Node{}
+		}
+		return setPath(*slot, segs, i+1, v)
+	}
+
+	switch {
+	case seg.wildcard:
+		switch n.Kind {
+		case SequenceKind:
+			for j := range n.Content {
+				if err := set(&n.Content[j]); err != nil {
+					return err
+				}
+			}
+		case MappingKind:
+			for j := 1; j < len(n.Content); j += 2 {
+				if err := set(&n.Content[j]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	case seg.isIndex:
+		if n.Kind != SequenceKind {
+			return fmt.Errorf("yaml: path %s: cannot index a %s", formatPath(segs[:i]), describeNode(n))
+		}
+		j, ok := seg.resolveIndex(n)
+		if !ok && seg.index == len(n.Content) {
+			n.Content = append(n.Content, nil)
+			j, ok = len(n.Content)-1, true
+		}
+		if !ok {
+			return fmt.Errorf("yaml: path %s: index out of range for %d items", formatPath(segs[:i+1]), len(n.Content))
+		}
+		return set(&n.Content[j])
+
+	default:
+		if n.Kind != MappingKind {
+			return fmt.Errorf("yaml: path %s: cannot set key %q of a %s", formatPath(segs[:i]), seg.key, describeNode(n))
+		}
+		j := mappingKeyIndex(n, seg.key)
+		if j < 0 {
+			n.Content = append(n.Content, &Node{Kind: ScalarKind, Tag: StrTag, Value: seg.key}, nil)
+			j = len(n.Content) - 2
+		}
+		return set(&n.Content[j+1])
+	}
+}
+
+// DeletePath removes the nodes path names from node, as for Query: a
+// mapping entry with its key, or a sequence element, later elements moving
+// up. Wildcards remove every match. A path that matches nothing leaves node as it is; only a
+// malformed path, or one naming the root, is an error.
+//
+// Example:
+//
+//	err := yaml.DeletePath(&doc, "metadata.annotations")
+func DeletePath(node *Node, path string) error {
+	segs, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	if len(segs) == 0 {
+		return errors.New("yaml: DeletePath: cannot delete the root of the document")
+	}
+	if node == nil || node.Kind == InvalidKind {
+		return nil
+	}
+
+	seg := segs[len(segs)-1]
+	for _, parent := range match([]*Node{node}, segs[:len(segs)-1]) {
+		switch {
+		case seg.wildcard && (parent.Kind == SequenceKind || parent.Kind == MappingKind):
+			parent.Content = parent.Content[:0]
+		case seg.isIndex:
+			if j, ok := seg.resolveIndex(parent); ok {
+				parent.Content = append(parent.Content[:j], parent.Content[j+1:]...)
+			}
+		case !seg.wildcard:
+			// Remove every entry of the key, duplicates and merged ones alike
+			for j := mappingKeyIndex(parent, seg.key); j >= 0; j = mappingKeyIndex(parent, seg.key) {
+				parent.Content = append(parent.Content[:j], parent.Content[j+2:]...)
+			}
+		}
+	}
+	return nil
+}
+
+// nodeOf returns value as a Node: a Node or *Node as is, and anything else
+// as Marshal writes it, without positions.
+func nodeOf(value interface{}) (*Node, error) {
+	switch v := value.(type) {
+	case *Node:
+		if v == nil {
+			return &Node{Kind: ScalarKind, Tag: NullTag}, nil
+		}
+		return v, nil
+	case Node:
+		return &v, nil
+	}
+
+	data, err := Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var n Node
+	if err := n.UnmarshalYAML(data); err != nil {
+		return nil, err
+	}
+	if n.Kind == InvalidKind {
+		n = Node{Kind: ScalarKind, Tag: NullTag}
+	}
+	n.clearPositions()
+	return &n, nil
+}
+
+// clone returns a deep copy of n.
+func (n *Node) clone() *Node {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	if n.Content != nil {
+		c.Content = make([]*Node, len(n.Content))
+		for i, child := range n.Content {
+			c.Content[i] = child.clone()
+		}
+	}
+	if n.Directives != nil {
+		c.Directives = append([]string(nil), n.Directives...)
+	}
+	return &c
+}
+
+// clearPositions zeroes the line and column of n and every node below it.
+func (n *Node) clearPositions() {
+	n.Line, n.Column = 0, 0
+	for _, c := range n.Content {
+		c.clearPositions()
+	}
+}
+
+// describeNode names the kind of n for errors.
+func describeNode(n *Node) string {
+	switch n.Kind {
+	case ScalarKind:
+		return "scalar"
+	case MappingKind:
+		return "mapping"
+	case SequenceKind:
+		return "sequence"
+	}
+	return "empty node"
+}
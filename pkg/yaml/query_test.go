@@ -0,0 +1,264 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const queryDoc = `kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+    tier: frontend
+  annotations:
+    "app.conf": x
+spec:
+  replicas: 2
+  containers:
+    - name: app
+      image: nginx:1.25
+    - name: sidecar
+      image: envoy:1.30
+`
+
+func parseQueryDoc(t *testing.T) *Node {
+	t.Helper()
+	var doc Node
+	if err := Unmarshal([]byte(queryDoc), &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return &doc
+}
+
+func TestQuery(t *testing.T) {
+	doc := parseQueryDoc(t)
+
+	tests := []struct {
+		path string
+		want []string // scalar values, or the kinds of collections
+	}{
+		{"kind", []string{"Deployment"}},
+		{".metadata.name", []string{"web"}},
+		{"spec.containers[0].image", []string{"nginx:1.25"}},
+		{"spec.containers[-1].name", []string{"sidecar"}},
+		{"spec.containers[*].image", []string{"nginx:1.25", "envoy:1.30"}},
+		{"spec.containers.*.name", []string{"app", "sidecar"}},
+		{"metadata.labels.*", []string{"web", "frontend"}},
+		{"metadata.labels[*]", []string{"web", "frontend"}},
+		{`metadata.annotations["app.conf"]`, []string{"x"}},
+		{`metadata.annotations."app.conf"`, []string{"x"}},
+		{"spec.containers", []string{"SequenceKind"}},
+		{".", []string{"MappingKind"}},
+		{"", []string{"MappingKind"}},
+		{"spec.missing", nil},
+		{"spec.containers[2]", nil},
+		{"kind.name", nil},
+		{"spec.replicas[0]", nil},
+		{"metadata[0]", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			nodes, err := Query(doc, tt.path)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			var got []string
+			for _, n := range nodes {
+				if n.Kind == ScalarKind {
+					got = append(got, n.Value)
+				} else {
+					got = append(got, n.Kind.String())
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if nodes, err := Query(&Node{}, "a"); err != nil || nodes != nil {
+		t.Errorf("Query() of an empty document = %v, %v", nodes, err)
+	}
+}
+
+func TestQuery_InvalidPath(t *testing.T) {
+	doc := parseQueryDoc(t)
+	for _, path := range []string{
+		"a..b", "a.", "a[", "a[x]", `a["b`, `a["b"`, `"a`, `a["b"]c`, "a[0]b",
+	} {
+		if _, err := Query(doc, path); err == nil || !strings.Contains(err.Error(), "invalid path") {
+			t.Errorf("Query(%q) error = %v, want an invalid path error", path, err)
+		}
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	doc := parseQueryDoc(t)
+
+	steps := []struct {
+		path  string
+		value interface{}
+	}{
+		{"spec.containers[0].image", "nginx:1.27"},
+		{"spec.replicas", 3},
+		{"metadata.labels.env", "prod"},                          // new key, appended
+		{"spec.containers[2]", map[string]string{"name": "log"}}, // appended element
+		{"spec.strategy.rollingUpdate.maxSurge", "25%"},          // new nested mappings
+		{"spec.containers[*].pull", "Always"},                    // every element
+		{`metadata.annotations["app.conf"]`, &Node{Kind: ScalarKind, Tag: StrTag, Style: DoubleQuoted, Value: "y"}},
+	}
+	for _, step := range steps {
+		if err := SetPath(doc, step.path, step.value); err != nil {
+			t.Fatalf("SetPath(%q) error = %v", step.path, err)
+		}
+	}
+
+	out, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got, want interface{}
+	if err := Unmarshal(out, &got); err != nil {
+		t.Fatalf("Unmarshal() of %s error = %v", out, err)
+	}
+	wantDoc := `kind: Deployment
+metadata:
+  name: web
+  labels: {app: web, tier: frontend, env: prod}
+  annotations: {app.conf: "y"}
+spec:
+  replicas: 3
+  containers:
+    - {name: app, image: "nginx:1.27", pull: Always}
+    - {name: sidecar, image: "envoy:1.30", pull: Always}
+    - {name: log, pull: Always}
+  strategy: {rollingUpdate: {maxSurge: 25%}}
+`
+	if err := Unmarshal([]byte(wantDoc), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("after SetPath:\n%s\nwant the same as:\n%s", out, wantDoc)
+	}
+
+	// Key order is kept and new keys follow the old ones
+	if keys := mappingKeys(doc); !reflect.DeepEqual(keys, []string{"kind", "metadata", "spec"}) {
+		t.Errorf("root keys = %v", keys)
+	}
+	labels, _ := Query(doc, "metadata.labels")
+	if keys := mappingKeys(labels[0]); !reflect.DeepEqual(keys, []string{"app", "tier", "env"}) {
+		t.Errorf("label keys = %v", keys)
+	}
+	if n, _ := Query(doc, `metadata.annotations["app.conf"]`); n[0].Style != DoubleQuoted {
+		t.Errorf("a Node value lost its style: %+v", n[0])
+	}
+
+	// Wildcard matches get their own copies
+	pulls, _ := Query(doc, "spec.containers[*].pull")
+	if pulls[0] == pulls[1] {
+		t.Error("SetPath() shared one node between wildcard matches")
+	}
+}
+
+func TestSetPath_EmptyAndNull(t *testing.T) {
+	var doc Node
+	if err := SetPath(&doc, "items[0].name", "a"); err != nil {
+		t.Fatalf("SetPath() on an empty document error = %v", err)
+	}
+	if n, _ := Query(&doc, "items[0].name"); doc.Kind != MappingKind || len(n) != 1 || n[0].Value != "a" {
+		t.Errorf("after SetPath() on an empty document: %+v", doc)
+	}
+	if err := Unmarshal([]byte("x: ~\n"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPath(&doc, "x.y", true); err != nil {
+		t.Fatalf("SetPath() through a null error = %v", err)
+	}
+	if n, _ := Query(&doc, "x.y"); len(n) != 1 || n[0].Tag != BoolTag || n[0].Line != 0 {
+		t.Errorf("x.y = %+v", n)
+	}
+
+	var fresh Node
+	if err := SetPath(&fresh, ".", []int{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := Marshal(&fresh); err != nil || string(out) != "- 1\n- 2" {
+		t.Errorf("Marshal() after setting the root = %q, %v", out, err)
+	}
+}
+
+func TestSetPath_Errors(t *testing.T) {
+	doc := parseQueryDoc(t)
+	for _, tt := range []struct{ path, want string }{
+		{"kind.name", `path kind: cannot set key "name" of a scalar`},
+		{"spec.containers.name", `path spec.containers: cannot set key "name" of a sequence`},
+		{"metadata[0]", "path metadata: cannot index a mapping"},
+		{"spec.containers[5]", "path spec.containers[5]: index out of range for 2 items"},
+		{"a[", "invalid path"},
+	} {
+		err := SetPath(doc, tt.path, 1)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("SetPath(%q) error = %v, want %q", tt.path, err, tt.want)
+		}
+	}
+	if err := SetPath(doc, "a", make(chan int)); err == nil {
+		t.Error("SetPath() of an unsupported value: expected error")
+	}
+	if err := SetPath(nil, "a", 1); err == nil {
+		t.Error("SetPath() on a nil node: expected error")
+	}
+}
+
+func TestDeletePath(t *testing.T) {
+	doc := parseQueryDoc(t)
+	for _, path := range []string{
+		"metadata.labels.tier",
+		"spec.containers[0]",
+		"spec.containers[*].image",
+		`metadata.annotations["app.conf"]`,
+		"spec.missing",
+		"kind.name",
+	} {
+		if err := DeletePath(doc, path); err != nil {
+			t.Fatalf("DeletePath(%q) error = %v", path, err)
+		}
+	}
+
+	var got, want interface{}
+	out, err := Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	wantDoc := "kind: Deployment\nmetadata: {name: web, labels: {app: web}, annotations: {}}\n" +
+		"spec: {replicas: 2, containers: [{name: sidecar}]}\n"
+	if err := Unmarshal([]byte(wantDoc), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("after DeletePath:\n%s\nwant the same as:\n%s", out, wantDoc)
+	}
+
+	if err := DeletePath(doc, "spec.containers[-1]"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := Query(doc, "spec.containers[*]"); len(n) != 0 {
+		t.Errorf("containers after deleting [-1] = %d", len(n))
+	}
+	if err := DeletePath(doc, "."); err == nil {
+		t.Error("DeletePath() of the root: expected error")
+	}
+}
+
+// mappingKeys returns the scalar keys of mapping n in order.
+func mappingKeys(n *Node) []string {
+	var keys []string
+	for i := 0; i < len(n.Content); i += 2 {
+		keys = append(keys, n.Content[i].Value)
+	}
+	return keys
+}
@@ -0,0 +1,384 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema describes the documents a configuration format accepts: the type
+// of each node, the keys a mapping requires or allows, and the enums,
+// patterns and ranges of scalars. Validate checks a document against it.
+//
+// A Schema is built in Go or read with ParseSchema from the subset of JSON
+// Schema its fields are named after, so that the output of JSONSchema can
+// check documents before they are unmarshaled:
+//
+//	minPort, maxPort := 1.0, 65535.0
+//	schema := &yaml.Schema{
+//	    Type:     []string{"object"},
+//	    Required: []string{"name", "port"},
+//	    Properties: map[string]*yaml.Schema{
+//	        "name": {Type: []string{"string"}, Pattern: `^[a-z][a-z0-9-]*$`},
+//	        "port": {Type: []string{"integer"}, Minimum: &minPort, Maximum: &maxPort},
+//	        "mode": {Enum: []interface{}{"dev", "prod"}},
+//	    },
+//	}
+//
+// A nil or zero Schema accepts any document.
+type Schema struct {
+	// Type lists the types a node may have: "object" (a mapping), "array"
+	// (a sequence), "string", "integer", "number" (an integer or float),
+	// "boolean" or "null". Scalars have the type of their resolved tag, so
+	// a quoted "8080" is a string; !!timestamp scalars are strings. Empty
+	// allows any type.
+	Type []string
+
+	// Properties holds the schemas of the values of mapping keys, and
+	// Required the keys a mapping must have.
+	Properties map[string]*Schema
+	Required   []string
+
+	// AdditionalProperties is the schema of the values of keys not in
+	// Properties, and Closed rejects such keys altogether, as
+	// "additionalProperties: false" does. By default they are allowed.
+	AdditionalProperties *Schema
+	Closed               bool
+
+	// Items is the schema of every item of a sequence.
+	Items *Schema
+
+	// Enum lists the values a scalar may have. Numbers compare by value,
+	// whatever their Go type.
+	Enum []interface{}
+
+	// Pattern is a regular expression (package regexp syntax) a string
+	// must match somewhere; anchor it with ^ and $ to match all of it.
+	Pattern string
+
+	// Minimum and Maximum bound numbers, inclusively.
+	Minimum, Maximum *float64
+
+	// MinLength and MaxLength bound the length of strings in characters.
+	MinLength, MaxLength *int
+
+	// MinItems and MaxItems bound the length of sequences.
+	MinItems, MaxItems *int
+
+	// Ref refers to the schema a node must also match: "#" for the root
+	// schema, or "#/$defs/<name>" for one of the root schema's Defs.
+	Ref  string
+	Defs map[string]*Schema
+}
+
+// SchemaViolation is a node of a document that does not match its schema.
+type SchemaViolation struct {
+	Path    string // path of the node, as Query takes it, such as spec.ports[0]; "." for the document
+	Line    int    // 1-based position of the node; 0 where it has none, as for merged keys
+	Column  int
+	Message string
+}
+
+// String formats v as "line:column: path: message".
+func (v SchemaViolation) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", v.Line, v.Column, v.Path, v.Message)
+}
+
+// Validate checks doc against s and returns every violation, in document
+// order, or none if doc matches. The zero Node of an empty document is
+// null.
+//
+// Example:
+//
+//	var doc yaml.Node
+//	if err := yaml.Unmarshal(data, &doc); err != nil {
+//	    return err
+//	}
+//	for _, v := range schema.Validate(&doc) {
+//	    fmt.Println(v) // 4:13: spec.replicas: 0 is less than the minimum 1
+//	}
+func (s *Schema) Validate(doc *Node) []SchemaViolation {
+	v := &schemaValidator{root: s, patterns: make(map[string]*regexp.Regexp)}
+	v.validate(s, doc, nil)
+	sort.SliceStable(v.violations, func(i, j int) bool {
+		a, b := v.violations[i], v.violations[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return v.violations
+}
+
+// schemaValidator accumulates the violations of one document.
+type schemaValidator struct {
+	root       *Schema
+	patterns   map[string]*regexp.Regexp
+	violations []SchemaViolation
+}
+
+func (v *schemaValidator) report(n *Node, path []pathSegment, format string, args ...interface{}) {
+	v.violations = append(v.violations, SchemaViolation{
+		Path:    formatPath(path),
+		Line:    n.Line,
+		Column:  n.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// validate checks n, found at path, against s.
+func (v *schemaValidator) validate(s *Schema, n *Node, path []pathSegment) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		target, err := v.resolve(s)
+		if err != nil {
+			v.report(n, path, "%v", err)
+			return
+		}
+		v.validate(target, n, path)
+	}
+
+	typ := schemaType(n)
+	if len(s.Type) > 0 && !typeAllowed(s.Type, typ) {
+		v.report(n, path, "got %s, want %s", describeSchemaNode(n, typ), strings.Join(s.Type, " or "))
+		return
+	}
+
+	switch n.Kind {
+	case MappingKind:
+		v.validateMapping(s, n, path)
+	case SequenceKind:
+		if s.MinItems != nil && len(n.Content) < *s.MinItems {
+			v.report(n, path, "has %d items, fewer than the minimum %d", len(n.Content), *s.MinItems)
+		}
+		if s.MaxItems != nil && len(n.Content) > *s.MaxItems {
+			v.report(n, path, "has %d items, more than the maximum %d", len(n.Content), *s.MaxItems)
+		}
+		for i, item := range n.Content {
+			v.validate(s.Items, item, appendPath(path, pathSegment{index: i, isIndex: true}))
+		}
+	case ScalarKind, InvalidKind:
+		v.validateScalar(s, n, typ, path)
+	}
+}
+
+// validateMapping checks the keys and values of mapping n.
+func (v *schemaValidator) validateMapping(s *Schema, n *Node, path []pathSegment) {
+	seen := make(map[string]bool, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i].Value, n.Content[i+1]
+		if seen[key] {
+			continue // a merged key the mapping overrides
+		}
+		seen[key] = true
+
+		valuePath := appendPath(path, pathSegment{key: key})
+		if prop, ok := s.Properties[key]; ok {
+			v.validate(prop, value, valuePath)
+		} else if s.Closed {
+			v.report(n.Content[i], valuePath, "key %q is not allowed", key)
+		} else {
+			v.validate(s.AdditionalProperties, value, valuePath)
+		}
+	}
+	for _, key := range s.Required {
+		if !seen[key] {
+			v.report(n, path, "missing required key %q", key)
+		}
+	}
+}
+
+// validateScalar checks the value of scalar n, of JSON Schema type typ.
+func (v *schemaValidator) validateScalar(s *Schema, n *Node, typ string, path []pathSegment) {
+	if len(s.Enum) == 0 && s.Pattern == "" && s.Minimum == nil && s.Maximum == nil &&
+		s.MinLength == nil && s.MaxLength == nil {
+		return
+	}
+	var value interface{}
+	if n.Kind == ScalarKind {
+		var err error
+		if value, err = n.scalarValue(); err != nil {
+			v.report(n, path, "%v", err)
+			return
+		}
+	}
+
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		v.report(n, path, "%s is not one of %s", describeSchemaNode(n, typ), formatEnum(s.Enum))
+	}
+
+	if f, ok := schemaNumber(value); ok && (typ == "integer" || typ == "number") {
+		if s.Minimum != nil && f < *s.Minimum {
+			v.report(n, path, "%s is less than the minimum %v", n.Value, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			v.report(n, path, "%s is greater than the maximum %v", n.Value, *s.Maximum)
+		}
+	}
+
+	str, ok := value.(string)
+	if !ok || typ != "string" {
+		return
+	}
+	if length := utf8.RuneCountInString(str); s.MinLength != nil && length < *s.MinLength {
+		v.report(n, path, "%q is shorter than the minimum length %d", str, *s.MinLength)
+	} else if s.MaxLength != nil && length > *s.MaxLength {
+		v.report(n, path, "%q is longer than the maximum length %d", str, *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		re, err := v.pattern(s.Pattern)
+		if err != nil {
+			v.report(n, path, "%v", err)
+		} else if !re.MatchString(str) {
+			v.report(n, path, "%q does not match the pattern %s", str, s.Pattern)
+		}
+	}
+}
+
+// pattern returns the compiled form of pattern.
+func (v *schemaValidator) pattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := v.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema pattern %q: %v", pattern, err)
+	}
+	v.patterns[pattern] = re
+	return re, nil
+}
+
+// resolve returns the schema s.Ref refers to, following references to
+// references.
+func (v *schemaValidator) resolve(s *Schema) (*Schema, error) {
+	for hops := 0; s.Ref != ""; hops++ {
+		if hops > len(v.root.Defs) {
+			return nil, fmt.Errorf("schema $ref %q is circular", s.Ref)
+		}
+		var target *Schema
+		switch {
+		case s.Ref == "#":
+			target = v.root
+		case strings.HasPrefix(s.Ref, "#/$defs/"):
+			target = v.root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		}
+		if target == nil {
+			return nil, fmt.Errorf("schema $ref %q does not name a definition", s.Ref)
+		}
+		if target.Ref == "" {
+			return target, nil
+		}
+		s = target
+	}
+	return s, nil
+}
+
+// appendPath returns path followed by segs, leaving path itself alone.
+func appendPath(path []pathSegment, segs ...pathSegment) []pathSegment {
+	return append(path[:len(path):len(path)], segs...)
+}
+
+// schemaType returns the JSON Schema type of n, or "" for a scalar with a
+// tag outside the core schema.
+func schemaType(n *Node) string {
+	switch n.Kind {
+	case MappingKind:
+		return "object"
+	case SequenceKind:
+		return "array"
+	case InvalidKind:
+		return "null"
+	}
+	switch n.Tag {
+	case NullTag:
+		return "null"
+	case BoolTag:
+		return "boolean"
+	case IntTag:
+		return "integer"
+	case FloatTag:
+		return "number"
+	case StrTag, TimestampTag:
+		return "string"
+	}
+	return ""
+}
+
+// typeAllowed reports whether a node of type typ matches one of types. An
+// integer is also a number.
+func typeAllowed(types []string, typ string) bool {
+	for _, t := range types {
+		if t == typ || t == "number" && typ == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// describeSchemaNode names n and its type for violations.
+func describeSchemaNode(n *Node, typ string) string {
+	switch {
+	case n.Kind == MappingKind || n.Kind == SequenceKind:
+		return describeNode(n)
+	case typ == "":
+		return fmt.Sprintf("%s %q", n.Tag, n.Value)
+	case typ == "string":
+		return fmt.Sprintf("string %q", n.Value)
+	case typ == "null":
+		return "null"
+	}
+	return fmt.Sprintf("%s %s", typ, n.Value)
+}
+
+// schemaNumber returns a numeric value as a float64.
+func schemaNumber(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// enumContains reports whether value is one of enum.
+func enumContains(enum []interface{}, value interface{}) bool {
+	f, isNumber := schemaNumber(value)
+	for _, e := range enum {
+		if isNumber {
+			if g, ok := schemaNumber(e); ok && f == g {
+				return true
+			}
+			continue
+		}
+		if e != nil && !reflect.TypeOf(e).Comparable() {
+			continue
+		}
+		if e == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatEnum lists enum values for violations.
+func formatEnum(enum []interface{}) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		switch e := e.(type) {
+		case nil:
+			parts[i] = "null"
+		case string:
+			parts[i] = strconv.Quote(e)
+		default:
+			parts[i] = fmt.Sprint(e)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,241 @@
+package yaml
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// schemaAnnotations are JSON Schema keywords that describe a schema without
+// constraining documents, which ParseSchema ignores.
+var schemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// schemaTypes are the JSON Schema type names.
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "integer": true,
+	"number": true, "boolean": true, "null": true,
+}
+
+// ParseSchema reads a Schema from a YAML or JSON document using the JSON
+// Schema keywords named after its fields: type, properties, required,
+// additionalProperties, items, enum, pattern, minimum, maximum, minLength,
+// maxLength, minItems, maxItems, $ref and $defs. Annotations such as title
+// and description are ignored, and any other keyword is an error, so that a
+// schema relying on one, such as oneOf, is not silently weakened.
+//
+// Example:
+//
+//	schema, err := yaml.ParseSchema([]byte(`
+//	type: object
+//	required: [name]
+//	properties:
+//	  name: {type: string, pattern: "^[a-z-]+$"}
+//	  replicas: {type: integer, minimum: 1}
+//	`))
+func ParseSchema(data []byte) (*Schema, error) {
+	var v interface{}
+	if err := Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("yaml: schema: %w", err)
+	}
+	s, err := buildSchema(v, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("yaml: schema: the schema false rejects every document")
+	}
+	return s, nil
+}
+
+// buildSchema converts the decoded schema document v, found at path. It
+// returns nil for the schema false, which only additionalProperties allows.
+func buildSchema(v interface{}, path []pathSegment) (*Schema, error) {
+	fail := func(keyword, format string, args ...interface{}) (*Schema, error) {
+		at := formatPath(appendPath(path, pathSegment{key: keyword}))
+		if keyword == "" {
+			at = formatPath(path)
+		}
+		return nil, fmt.Errorf("yaml: schema: %s: %s", at, fmt.Sprintf(format, args...))
+	}
+
+	if b, ok := v.(bool); ok {
+		if !b {
+			return nil, nil
+		}
+		return &Schema{}, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return fail("", "want a mapping or boolean, got %s", describeValue(v))
+	}
+
+	// Report the first problem in a stable order
+	keywords := make([]string, 0, len(m))
+	for keyword := range m {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+
+	s := &Schema{}
+	for _, keyword := range keywords {
+		value := m[keyword]
+		var err error
+		switch keyword {
+		case "type":
+			s.Type, err = schemaStrings(value)
+			for _, t := range s.Type {
+				if err == nil && !schemaTypes[t] {
+					err = fmt.Errorf("unknown type %q", t)
+				}
+			}
+		case "required":
+			s.Required, err = schemaStrings(value)
+		case "properties":
+			props, ok := value.(map[string]interface{})
+			if !ok {
+				return fail(keyword, "want a mapping, got %s", describeValue(value))
+			}
+			if s.Properties, err = buildSchemas(props, appendPath(path, pathSegment{key: keyword})); err != nil {
+				return nil, err
+			}
+		case "additionalProperties":
+			s.AdditionalProperties, err = buildSchema(value, appendPath(path, pathSegment{key: keyword}))
+			if err != nil {
+				return nil, err
+			}
+			s.Closed = s.AdditionalProperties == nil
+		case "items":
+			if s.Items, err = buildSubschema(value, appendPath(path, pathSegment{key: keyword})); err != nil {
+				return nil, err
+			}
+		case "enum":
+			items, ok := value.([]interface{})
+			if !ok {
+				return fail(keyword, "want a sequence, got %s", describeValue(value))
+			}
+			for _, item := range items {
+				switch item.(type) {
+				case []interface{}, map[string]interface{}:
+					return fail(keyword, "only scalar values are supported")
+				}
+			}
+			s.Enum = items
+		case "pattern":
+			str, ok := value.(string)
+			if !ok {
+				return fail(keyword, "want a string, got %s", describeValue(value))
+			}
+			if _, err := regexp.Compile(str); err != nil {
+				return fail(keyword, "%v", err)
+			}
+			s.Pattern = str
+		case "minimum":
+			s.Minimum, err = schemaFloat(value)
+		case "maximum":
+			s.Maximum, err = schemaFloat(value)
+		case "minLength":
+			s.MinLength, err = schemaCount(value)
+		case "maxLength":
+			s.MaxLength, err = schemaCount(value)
+		case "minItems":
+			s.MinItems, err = schemaCount(value)
+		case "maxItems":
+			s.MaxItems, err = schemaCount(value)
+		case "$ref":
+			ref, ok := value.(string)
+			if !ok || ref != "#" && !strings.HasPrefix(ref, "#/$defs/") {
+				return fail(keyword, `want "#" or "#/$defs/<name>", got %v`, value)
+			}
+			s.Ref = ref
+		case "$defs":
+			defs, ok := value.(map[string]interface{})
+			if !ok {
+				return fail(keyword, "want a mapping, got %s", describeValue(value))
+			}
+			if s.Defs, err = buildSchemas(defs, appendPath(path, pathSegment{key: keyword})); err != nil {
+				return nil, err
+			}
+		default:
+			if !schemaAnnotations[keyword] {
+				return fail(keyword, "unsupported keyword")
+			}
+		}
+		if err != nil {
+			return fail(keyword, "%v", err)
+		}
+	}
+	return s, nil
+}
+
+// buildSubschema converts a schema other than that of additionalProperties,
+// which may not be false.
+func buildSubschema(v interface{}, path []pathSegment) (*Schema, error) {
+	s, err := buildSchema(v, path)
+	if err == nil && s == nil {
+		err = fmt.Errorf("yaml: schema: %s: the schema false is only supported for additionalProperties", formatPath(path))
+	}
+	return s, err
+}
+
+// buildSchemas converts the schemas of properties or $defs, found at path.
+func buildSchemas(m map[string]interface{}, path []pathSegment) (map[string]*Schema, error) {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make(map[string]*Schema, len(m))
+	for _, name := range names {
+		s, err := buildSubschema(m[name], appendPath(path, pathSegment{key: name}))
+		if err != nil {
+			return nil, err
+		}
+		out[name] = s
+	}
+	return out, nil
+}
+
+// schemaStrings converts a string or sequence of strings.
+func schemaStrings(v interface{}) ([]string, error) {
+	if s, ok := v.(string); ok {
+		return []string{s}, nil
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("want a string or sequence of strings, got %s", describeValue(v))
+	}
+	out := make([]string, len(items))
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("want a string or sequence of strings, got %s in the sequence", describeValue(item))
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// schemaFloat converts a number bound.
+func schemaFloat(v interface{}) (*float64, error) {
+	f, ok := schemaNumber(v)
+	if !ok || math.IsNaN(f) {
+		return nil, fmt.Errorf("want a number, got %s", describeValue(v))
+	}
+	return &f, nil
+}
+
+// schemaCount converts a length bound.
+func schemaCount(v interface{}) (*int, error) {
+	n, ok := v.(int64)
+	if !ok || n < 0 || n > math.MaxInt32 {
+		return nil, fmt.Errorf("want a non-negative integer, got %v", v)
+	}
+	c := int(n)
+	return &c, nil
+}
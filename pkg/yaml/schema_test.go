@@ -0,0 +1,208 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const deploymentSchema = `
+type: object
+required: [name, replicas, containers]
+additionalProperties: false
+properties:
+  name: {type: string, pattern: "^[a-z][a-z0-9-]*$", maxLength: 20}
+  replicas: {type: integer, minimum: 1, maximum: 10}
+  mode: {enum: [dev, prod]}
+  ratio: {type: number}
+  labels:
+    type: object
+    additionalProperties: {type: string}
+  containers:
+    type: array
+    minItems: 1
+    items: {$ref: "#/$defs/container"}
+$defs:
+  container:
+    type: object
+    required: [image]
+    properties:
+      image: {type: string, minLength: 1}
+      ports: {type: array, items: {type: integer, maximum: 65535}}
+`
+
+// parseNode parses a document into a Node.
+func parseNode(t *testing.T, doc string) *Node {
+	t.Helper()
+	var n Node
+	if err := Unmarshal([]byte(doc), &n); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return &n
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := ParseSchema([]byte(deploymentSchema))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		want []SchemaViolation
+	}{
+		{
+			name: "valid",
+			doc: "name: web\nreplicas: 3\nmode: prod\nratio: 1\nlabels: {app: web}\n" +
+				"containers:\n  - image: nginx\n    ports: [80, 443]\n",
+		},
+		{
+			name: "violations",
+			doc: "name: Web App\nreplicas: 0\nmode: staging\nratio: '1.5'\nlabels: {app: 1}\n" +
+				"containers:\n  - ports: [80, 70000]\n  - image: ''\nextra: true\n",
+			want: []SchemaViolation{
+				{"name", 1, 7, `"Web App" does not match the pattern ^[a-z][a-z0-9-]*$`},
+				{"replicas", 2, 11, "0 is less than the minimum 1"},
+				{"mode", 3, 7, `string "staging" is not one of "dev", "prod"`},
+				{"ratio", 4, 8, `got string "1.5", want number`},
+				{"labels.app", 5, 15, "got integer 1, want string"},
+				{"containers[0]", 7, 5, `missing required key "image"`},
+				{"containers[0].ports[1]", 7, 17, "70000 is greater than the maximum 65535"},
+				{"containers[1].image", 8, 12, `"" is shorter than the minimum length 1`},
+				{"extra", 9, 1, `key "extra" is not allowed`},
+			},
+		},
+		{
+			name: "missing keys and wrong type",
+			doc:  "containers: web\n",
+			want: []SchemaViolation{
+				{".", 1, 1, `missing required key "name"`},
+				{".", 1, 1, `missing required key "replicas"`},
+				{"containers", 1, 13, `got string "web", want array`},
+			},
+		},
+		{
+			name: "empty document",
+			doc:  "",
+			want: []SchemaViolation{{".", 0, 0, "got null, want object"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := schema.Validate(parseNode(t, tt.doc))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchema_ValidateMerged(t *testing.T) {
+	min := 1.0
+	schema := &Schema{
+		Properties: map[string]*Schema{
+			"web": {Properties: map[string]*Schema{"replicas": {Type: []string{"integer"}, Minimum: &min}}},
+		},
+	}
+	// The mapping's own replicas overrides the invalid merged one
+	doc := parseNode(t, "base: &b {replicas: 0}\nweb:\n  <<: *b\n  replicas: 2\n")
+	if got := schema.Validate(doc); len(got) != 0 {
+		t.Errorf("Validate() = %v, want no violations", got)
+	}
+	doc = parseNode(t, "base: &b {replicas: 0}\nweb:\n  <<: *b\n")
+	if got := schema.Validate(doc); len(got) != 1 || got[0].Path != "web.replicas" {
+		t.Errorf("Validate() = %v, want a violation at web.replicas", got)
+	}
+}
+
+func TestSchema_Zero(t *testing.T) {
+	var nilSchema *Schema
+	for _, s := range []*Schema{nil, nilSchema, {}} {
+		if got := s.Validate(parseNode(t, "a: [1, {b: c}]\n")); len(got) != 0 {
+			t.Errorf("Validate() = %v, want no violations", got)
+		}
+	}
+}
+
+func TestSchema_FromJSONSchema(t *testing.T) {
+	type Port struct {
+		Number   int    `yaml:"number"`
+		Protocol string `yaml:"protocol,omitempty" jsonschema:"enum=TCP|UDP"`
+	}
+	type Service struct {
+		Name  string `yaml:"name"`
+		Ports []Port `yaml:"ports"`
+		Owner *Port  `yaml:"owner,omitempty"`
+	}
+	data, err := JSONSchema((*Service)(nil))
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+	schema, err := ParseSchema(data)
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v\n%s", err, data)
+	}
+
+	got := schema.Validate(parseNode(t, "name: api\nports:\n  - number: 80\n    protocol: SCTP\n"))
+	want := []SchemaViolation{{"ports[0].protocol", 4, 15, `string "SCTP" is not one of "TCP", "UDP"`}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v, want %v", got, want)
+	}
+}
+
+func TestSchema_Ref(t *testing.T) {
+	schema, err := ParseSchema([]byte(`
+type: object
+properties:
+  name: {type: string}
+  children: {type: array, items: {$ref: "#"}}
+`))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+	got := schema.Validate(parseNode(t, "name: a\nchildren:\n  - name: b\n    children: [{name: 1}]\n"))
+	want := []SchemaViolation{{"children[0].children[0].name", 4, 23, "got integer 1, want string"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v, want %v", got, want)
+	}
+
+	loop := &Schema{Ref: "#/$defs/a", Defs: map[string]*Schema{"a": {Ref: "#/$defs/b"}, "b": {Ref: "#/$defs/a"}}}
+	if got := loop.Validate(parseNode(t, "1")); len(got) != 1 || !strings.Contains(got[0].Message, "circular") {
+		t.Errorf("Validate() with a $ref cycle = %v", got)
+	}
+	missing := &Schema{Ref: "#/$defs/nope"}
+	if got := missing.Validate(parseNode(t, "1")); len(got) != 1 || !strings.Contains(got[0].Message, "does not name a definition") {
+		t.Errorf("Validate() with a dangling $ref = %v", got)
+	}
+}
+
+func TestParseSchema_Errors(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{"[1]", "yaml: schema: .: want a mapping or boolean, got []interface {}"},
+		{"false", "rejects every document"},
+		{"type: mapping", `yaml: schema: type: unknown type "mapping"`},
+		{"oneOf: [{type: string}]", "yaml: schema: oneOf: unsupported keyword"},
+		{"properties: {a: {pattern: '('}}", "yaml: schema: properties.a.pattern: error parsing regexp"},
+		{"properties: {a: false}", "properties.a: the schema false is only supported for additionalProperties"},
+		{"minLength: -1", "yaml: schema: minLength: want a non-negative integer, got -1"},
+		{"minimum: low", "yaml: schema: minimum: want a number, got string"},
+		{"enum: [[a]]", "yaml: schema: enum: only scalar values are supported"},
+		{"$ref: other.json", `yaml: schema: $ref: want "#" or "#/$defs/<name>", got other.json`},
+	}
+	for _, tt := range tests {
+		_, err := ParseSchema([]byte(tt.schema))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseSchema(%q) error = %v, want %q", tt.schema, err, tt.want)
+		}
+	}
+
+	s, err := ParseSchema([]byte("title: Config\ndescription: ignored\nadditionalProperties: true\n"))
+	if err != nil || s.Closed || s.AdditionalProperties == nil {
+		t.Errorf("ParseSchema() = %+v, %v; want an open schema", s, err)
+	}
+}
@@ -0,0 +1,270 @@
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version (https://semver.org) that validates itself
+// when decoded and encodes in canonical form, for fields such as
+//
+//	version: 1.4.2
+//
+// ParseVersion also accepts a leading "v" and the shorthands "1" and "1.2",
+// which mean 1.0.0 and 1.2.0. String drops both, so "v1.2" encodes as 1.2.0.
+// Plain scalars reach Version as written, so an unquoted 1.20 is 1.20.0.
+type Version struct {
+	Major, Minor, Patch uint64
+	Prerelease          string // dot-separated identifiers after "-", e.g. "rc.1"
+	Build               string // dot-separated identifiers after "+", ignored by Compare
+}
+
+// ParseVersion parses a semantic version.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		v.Build, rest = rest[i+1:], rest[:i]
+		if !validIdentifiers(v.Build, false) {
+			return Version{}, fmt.Errorf("yaml: invalid build metadata in version %q", s)
+		}
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		v.Prerelease, rest = rest[i+1:], rest[:i]
+		if !validIdentifiers(v.Prerelease, true) {
+			return Version{}, fmt.Errorf("yaml: invalid prerelease in version %q", s)
+		}
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("yaml: invalid version %q", s)
+	}
+	nums := [3]*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		if !isNumeric(part) || len(part) > 1 && part[0] == '0' {
+			return Version{}, fmt.Errorf("yaml: invalid version %q", s)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("yaml: invalid version %q: %w", s, err)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// String returns the canonical form of v, such as "1.2.0-rc.1+build.5".
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 as v has lower, equal or higher precedence
+// than w. A prerelease ranks below its release, and build metadata is ignored.
+func (v Version) Compare(w Version) int {
+	if c := compareUint(v.Major, w.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, w.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, w.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, w.Prerelease)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *Version) UnmarshalText(text []byte) error {
+	parsed, err := ParseVersion(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// MarshalYAML implements Marshaler.
+func (v Version) MarshalYAML() ([]byte, error) {
+	return Marshal(v.String())
+}
+
+// Constraint is a version requirement such as ">= 1.2.0, < 2.0.0": a comma
+// separated list of comparisons that must all hold. The operators are =, !=,
+// >, >=, < and <=, and a version without an operator means =. An empty
+// constraint allows every version.
+type Constraint struct {
+	terms []constraintTerm
+}
+
+type constraintTerm struct {
+	op      string
+	version Version
+}
+
+// constraintOps lists the operators, two-character ones first so that ">="
+// is not read as ">".
+var constraintOps = []string{">=", "<=", "!=", ">", "<", "="}
+
+// ParseConstraint parses a version constraint.
+func ParseConstraint(s string) (Constraint, error) {
+	var c Constraint
+	if strings.TrimSpace(s) == "" {
+		return c, nil
+	}
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		op := "="
+		for _, candidate := range constraintOps {
+			if strings.HasPrefix(term, candidate) {
+				op, term = candidate, term[len(candidate):]
+				break
+			}
+		}
+		v, err := ParseVersion(term)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("yaml: invalid constraint %q: %w", s, err)
+		}
+		c.terms = append(c.terms, constraintTerm{op: op, version: v})
+	}
+	return c, nil
+}
+
+// Check reports whether v satisfies every comparison of c.
+func (c Constraint) Check(v Version) bool {
+	for _, t := range c.terms {
+		cmp := v.Compare(t.version)
+		var ok bool
+		switch t.op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the canonical form of c, such as ">= 1.2.0, < 2.0.0".
+func (c Constraint) String() string {
+	terms := make([]string, len(c.terms))
+	for i, t := range c.terms {
+		terms[i] = t.op + " " + t.version.String()
+	}
+	return strings.Join(terms, ", ")
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *Constraint) UnmarshalText(text []byte) error {
+	parsed, err := ParseConstraint(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// MarshalYAML implements Marshaler.
+func (c Constraint) MarshalYAML() ([]byte, error) {
+	return Marshal(c.String())
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparePrerelease orders prerelease strings by semver precedence: no
+// prerelease ranks highest, numeric identifiers compare numerically and rank
+// below alphanumeric ones, and a shorter list ranks below a longer one it
+// prefixes.
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, y := as[i], bs[i]
+		if x == y {
+			continue
+		}
+		xn, yn := isNumeric(x), isNumeric(y)
+		switch {
+		case xn && yn:
+			if len(x) != len(y) {
+				return compareUint(uint64(len(x)), uint64(len(y)))
+			}
+			return strings.Compare(x, y)
+		case xn:
+			return -1
+		case yn:
+			return 1
+		}
+		return strings.Compare(x, y)
+	}
+	return compareUint(uint64(len(as)), uint64(len(bs)))
+}
+
+// validIdentifiers reports whether s is a non-empty dot-separated list of
+// [0-9A-Za-z-] identifiers. Prerelease identifiers that are numeric must not
+// have leading zeros.
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for i := 0; i < len(id); i++ {
+			c := id[i]
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+		if prerelease && len(id) > 1 && id[0] == '0' && isNumeric(id) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
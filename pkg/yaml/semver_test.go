@@ -0,0 +1,180 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"1.2.3", "1.2.3", false},
+		{"v1.2.3", "1.2.3", false},
+		{"1.2", "1.2.0", false},
+		{"1", "1.0.0", false},
+		{"1.0.0-rc.1", "1.0.0-rc.1", false},
+		{"1.0.0-alpha+build.7", "1.0.0-alpha+build.7", false},
+		{"1.0.0+20240101", "1.0.0+20240101", false},
+		{"", "", true},
+		{"1.2.3.4", "", true},
+		{"01.2.3", "", true},
+		{"1.x.0", "", true},
+		{"1.2.3-", "", true},
+		{"1.2.3-rc..1", "", true},
+		{"1.2.3-01", "", true},
+		{"1.2.3+b_1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			v, err := ParseVersion(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVersion(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && v.String() != tt.want {
+				t.Errorf("ParseVersion(%q) = %s, want %s", tt.input, v, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	// In increasing precedence, per the semver specification
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseVersion(ordered[i])
+			b, _ := ParseVersion(ordered[j])
+			want := compareUint(uint64(i), uint64(j))
+			if got := a.Compare(b); got != want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+
+	a, _ := ParseVersion("1.0.0+a")
+	b, _ := ParseVersion("1.0.0+b")
+	if a.Compare(b) != 0 {
+		t.Error("Compare() should ignore build metadata")
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		canonical  string
+		allowed    []string
+		denied     []string
+	}{
+		{">= 1.2.0", ">= 1.2.0", []string{"1.2.0", "1.3.0", "2.0.0"}, []string{"1.1.9", "1.2.0-rc.1"}},
+		{">=1.2, <2", ">= 1.2.0, < 2.0.0", []string{"1.2.0", "1.9.9"}, []string{"1.1.0", "2.0.0"}},
+		{"1.4.2", "= 1.4.2", []string{"1.4.2", "1.4.2+b"}, []string{"1.4.3"}},
+		{"!= 1.0.0, > 0.9", "!= 1.0.0, > 0.9.0", []string{"0.9.1", "1.0.1"}, []string{"0.9.0", "1.0.0"}},
+		{"<= v3.1.0", "<= 3.1.0", []string{"3.1.0", "3.0.0"}, []string{"3.1.1"}},
+		{"", "", []string{"0.0.1", "9.9.9"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := ParseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseConstraint(%q) error = %v", tt.constraint, err)
+			}
+			if c.String() != tt.canonical {
+				t.Errorf("String() = %q, want %q", c, tt.canonical)
+			}
+			for _, s := range tt.allowed {
+				if v, _ := ParseVersion(s); !c.Check(v) {
+					t.Errorf("Check(%s) = false, want true", s)
+				}
+			}
+			for _, s := range tt.denied {
+				if v, _ := ParseVersion(s); c.Check(v) {
+					t.Errorf("Check(%s) = true, want false", s)
+				}
+			}
+		})
+	}
+
+	for _, bad := range []string{">= ", "~> 1.2", ">= 1.2.0,", "> 1.2.x"} {
+		if _, err := ParseConstraint(bad); err == nil {
+			t.Errorf("ParseConstraint(%q): expected error", bad)
+		}
+	}
+}
+
+type manifest struct {
+	Name     string     `yaml:"name"`
+	Version  Version    `yaml:"version"`
+	Requires Constraint `yaml:"requires"`
+}
+
+func TestSemVer_Decode(t *testing.T) {
+	input := "name: tool\nversion: v1.4.2-rc.1\nrequires: \">=1.2, <2\"\n"
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var m manifest
+		if err := decode([]byte(input), &m); err != nil {
+			t.Fatalf("decode error = %v", err)
+		}
+		if m.Version.String() != "1.4.2-rc.1" {
+			t.Errorf("Version = %s, want 1.4.2-rc.1", m.Version)
+		}
+		if m.Requires.String() != ">= 1.2.0, < 2.0.0" {
+			t.Errorf("Requires = %q, want %q", m.Requires, ">= 1.2.0, < 2.0.0")
+		}
+
+		err := decode([]byte("version: 1.2.x\n"), &m)
+		if err == nil || !strings.Contains(err.Error(), `"1.2.x"`) {
+			t.Errorf("invalid version error = %v, want one naming the value", err)
+		}
+		if err := decode([]byte("requires: ~> 1.2\n"), &m); err == nil {
+			t.Errorf("invalid constraint: expected error")
+		}
+	})
+}
+
+func TestSemVer_DecodeUnquoted(t *testing.T) {
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		for _, tt := range []struct{ input, want string }{
+			{"version: 1.10\n", "1.10.0"},
+			{"version: 1.20 # minor twenty\n", "1.20.0"},
+			{"version: 2\n", "2.0.0"},
+			{"{version: 1.10}", "1.10.0"},
+		} {
+			var m manifest
+			if err := decode([]byte(tt.input), &m); err != nil {
+				t.Fatalf("decode(%q) error = %v", tt.input, err)
+			}
+			if m.Version.String() != tt.want {
+				t.Errorf("decode(%q) Version = %s, want %s", tt.input, m.Version, tt.want)
+			}
+		}
+	})
+}
+
+func TestSemVer_Encode(t *testing.T) {
+	m := manifest{Name: "tool"}
+	m.Version, _ = ParseVersion("v2.1")
+	m.Requires, _ = ParseConstraint(">=1.2,<2")
+
+	out, err := Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var back manifest
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatalf("Unmarshal(%q) error = %v", out, err)
+	}
+	if !strings.Contains(string(out), "version: 2.1.0") {
+		t.Errorf("Marshal() = %q, want canonical version 2.1.0", out)
+	}
+	if back.Requires.String() != ">= 1.2.0, < 2.0.0" || back.Version != m.Version {
+		t.Errorf("round trip = %+v, want %+v", back, m)
+	}
+}
@@ -0,0 +1,146 @@
+package yaml
+
+import (
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+const sharedTreeInput = `defaults: &defaults
+  image: app:1.0
+  replicas: 2
+services:
+  web:
+    <<: *defaults
+    port: 80
+  worker:
+    base: *defaults
+    queue: jobs
+tags: [a, b, c]
+`
+
+// TestSharedTree_ConcurrentReaders reads one parsed tree, and the Node made
+// of it, from many goroutines at once. Run with -race: any write to the
+// shared trees is reported.
+func TestSharedTree_ConcurrentReaders(t *testing.T) {
+	node, err := Parse(sharedTreeInput)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := NodeToInterface(node)
+	doc := NodeFromAST(node)
+	wantDoc, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	rename := regexp.MustCompile(`^port$`)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if got := NodeToInterface(node); !reflect.DeepEqual(got, want) {
+					t.Errorf("NodeToInterface() = %v, want %v", got, want)
+					return
+				}
+				var got interface{}
+				if err := NodeFromAST(node).Decode(&got); err != nil || !reflect.DeepEqual(got, want) {
+					t.Errorf("NodeFromAST() decodes to %v, %v, want %v", got, err, want)
+					return
+				}
+				services, _ := GetKey(doc, "services")
+				if keys := MapKeys(services); len(keys) != 2 {
+					t.Errorf("MapKeys(services) = %v", keys)
+				}
+				tags, _ := GetKey(doc, "tags")
+				if s, _ := AsString(mustIndex(tags, 1)); s != "b" {
+					t.Errorf("tags[1] = %q, want b", s)
+				}
+				if ports, err := Query(doc, "services.*.port"); err != nil || len(ports) != 1 {
+					t.Errorf("Query() = %v, %v", ports, err)
+				}
+				Walk(doc, func(path []string, n *Node) bool { return true })
+				FilterKeys(doc, func(path []string, key string) bool { return key != "queue" })
+				if _, err := RenameKeys(doc, rename, "listen"); err != nil {
+					t.Errorf("RenameKeys() error = %v", err)
+				}
+				if _, err := TransformScalars(doc, func(path []string, v interface{}) interface{} { return v }); err != nil {
+					t.Errorf("TransformScalars() error = %v", err)
+				}
+				if _, err := Marshal(doc); err != nil {
+					t.Errorf("Marshal() error = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := NodeToInterface(node); !reflect.DeepEqual(got, want) {
+		t.Errorf("tree changed after concurrent reads: %v, want %v", got, want)
+	}
+	if got, _ := Marshal(doc); string(got) != string(wantDoc) {
+		t.Errorf("Node changed after concurrent reads:\n%s\nwant\n%s", got, wantDoc)
+	}
+}
+
+// TestSharedTree_DerivedTreesLeaveSourceIntact checks that the functions that
+// build new trees do not modify the one they read.
+func TestSharedTree_DerivedTreesLeaveSourceIntact(t *testing.T) {
+	doc := parseNode(t, sharedTreeInput)
+	want, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	FilterKeys(doc, func(path []string, key string) bool { return false })
+	if _, err := RenameKeys(doc, regexp.MustCompile(`^(.*)$`), "renamed_$1"); err != nil {
+		t.Fatalf("RenameKeys() error = %v", err)
+	}
+	if _, err := TransformScalars(doc, func(path []string, v interface{}) interface{} { return "changed" }); err != nil {
+		t.Fatalf("TransformScalars() error = %v", err)
+	}
+	if _, err := NewTemplate(doc).Instantiate(map[string]interface{}{"/tags/0": "changed"}); err != nil {
+		t.Fatalf("Instantiate() error = %v", err)
+	}
+
+	if got, _ := Marshal(doc); string(got) != string(want) {
+		t.Errorf("source tree modified:\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestReleaseTree_SharedAlias releases a tree whose anchor is aliased twice;
+// each node must go back to its pool only once.
+func TestReleaseTree_SharedAlias(t *testing.T) {
+	node, err := Parse(sharedTreeInput)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	ReleaseTree(node)
+
+	// Parsing again draws from the pools; a node released twice would be
+	// handed out twice and corrupt one of these trees
+	a, err := Parse("x: [1, 2]\ny: {z: 3}")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	b, err := Parse("p: [4, 5]\nq: {r: 6}")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	wantA := map[string]interface{}{"x": []interface{}{int64(1), int64(2)}, "y": map[string]interface{}{"z": int64(3)}}
+	wantB := map[string]interface{}{"p": []interface{}{int64(4), int64(5)}, "q": map[string]interface{}{"r": int64(6)}}
+	if got := NodeToInterface(a); !reflect.DeepEqual(got, wantA) {
+		t.Errorf("first tree = %v, want %v", got, wantA)
+	}
+	if got := NodeToInterface(b); !reflect.DeepEqual(got, wantB) {
+		t.Errorf("second tree = %v, want %v", got, wantB)
+	}
+}
+
+func mustIndex(node *Node, i int) *Node {
+	n, _ := Index(node, i)
+	return n
+}
@@ -0,0 +1,171 @@
+package yaml
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// An Encoder writes YAML documents to an output stream.
+//
+// Each call to Encode writes one document; documents after the first are
+// preceded by a "---" separator so the stream reads back with ParseMultiDoc.
+// SetHeaderComment and SetDocumentComment attach comment blocks for
+// generated files, so callers need not concatenate strings around the
+// encoded output.
+//
+// A Node that holds the Directives of its document is written with them:
+// they come before its "---", and a document after the first ends the one
+// before with "..." so that the directives apply to the new document only.
+//
+// Every document is written to w as soon as it is encoded, so an Encoder
+// suits log-style streams that grow one document at a time. Close ends the
+// stream.
+type Encoder struct {
+	w      io.Writer
+	state  *encodeState // layout of the documents
+	header string
+	banner string
+	hook   MarshalHook
+	docs   int
+	closed bool
+}
+
+// errEncoderClosed is returned by Encode after Close.
+var errEncoderClosed = errors.New("yaml: Encode called after Close")
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, state: defaultEncodeState}
+}
+
+// SetNonFinite sets how following documents write NaN and infinite floats,
+// as MarshalOptions.NonFinite does for MarshalWithOptions. By default they
+// are written as .nan, .inf and -.inf; a stream read by JSON tooling may
+// want NonFiniteNull, and one that must not hold them NonFiniteError.
+func (e *Encoder) SetNonFinite(f NonFiniteFloat) {
+	state := *e.state
+	state.nonFinite = f
+	e.state = &state
+}
+
+// SetHook sets a hook called for every value of the following documents
+// before it is written, as MarshalOptions.Hook is for MarshalWithOptions,
+// for example to redact secrets in a stream of records. A nil hook removes
+// it.
+func (e *Encoder) SetHook(h MarshalHook) {
+	e.hook = h
+}
+
+// SetHeaderComment sets a comment block written once at the top of the
+// stream, before the first document and its separator, for example a
+// "generated file, do not edit" notice. Each line of text becomes a "#"
+// comment line. It has no effect after the first call to Encode.
+func (e *Encoder) SetHeaderComment(text string) {
+	e.header = text
+}
+
+// SetDocumentComment sets a banner comment block written at the start of
+// every following document, directly after its "---" separator. A document
+// with a banner always gets a separator, so the banner cannot be mistaken
+// for the file header. Call it between Encode calls to vary the banner per
+// document; an empty text removes it.
+func (e *Encoder) SetDocumentComment(text string) {
+	e.banner = text
+}
+
+// Encode writes the YAML encoding of v to the stream as a new document,
+// following the rules of Marshal. A value that cannot be encoded writes
+// nothing.
+func (e *Encoder) Encode(v interface{}) error {
+	if e.closed {
+		return errEncoderClosed
+	}
+	dirs, v := splitDirectives(v)
+	if e.hook != nil {
+		var err error
+		if v, err = applyHook(v, e.hook, false); err != nil {
+			return err
+		}
+	}
+	data, err := e.state.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	bp := yamlBufPool.Get()
+	buf := (*bp)[:0]
+	defer func() {
+		*bp = buf
+		yamlBufPool.Put(bp)
+	}()
+
+	if e.docs == 0 {
+		buf = appendComment(buf, e.header)
+	}
+	switch {
+	case dirs != nil:
+		if e.docs > 0 {
+			buf = append(buf, "...\n"...)
+		}
+		buf = appendDirectives(buf, dirs)
+	case e.docs > 0 || e.banner != "":
+		buf = append(buf, "---\n"...)
+	}
+	buf = appendComment(buf, e.banner)
+	buf = append(buf, data...)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+
+	if _, err := e.w.Write(buf); err != nil {
+		return err
+	}
+	e.docs++
+	return nil
+}
+
+// EncodeAllFrom writes each value returned by next as a new document, until
+// next reports false. Producers can stream documents from a cursor or a
+// channel this way without first collecting them in a slice: only the
+// document being written is held in memory. EncodeAllFrom stops at the first
+// error, after which next is not called again.
+func (e *Encoder) EncodeAllFrom(next func() (interface{}, bool)) error {
+	for {
+		v, ok := next()
+		if !ok {
+			return nil
+		}
+		if err := e.Encode(v); err != nil {
+			return err
+		}
+	}
+}
+
+// Close ends the stream: later calls to Encode fail. Documents are never
+// buffered, so there is nothing left to flush, and as with yaml.v3 no "..."
+// terminator is written. Close does not close the underlying writer. It is
+// safe to call more than once.
+func (e *Encoder) Close() error {
+	e.closed = true
+	return nil
+}
+
+// appendComment appends text as "#" comment lines. Empty lines inside the
+// text are kept as bare "#" lines; empty text appends nothing.
+func appendComment(buf []byte, text string) []byte {
+	if text == "" {
+		return buf
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			buf = append(buf, "#\n"...)
+			continue
+		}
+		buf = append(buf, "# "...)
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
@@ -0,0 +1,273 @@
+package yaml
+
+import (
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type encoderDoc struct {
+	Kind string `yaml:"kind"`
+	Name string `yaml:"name"`
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	var sb strings.Builder
+	enc := NewEncoder(&sb)
+
+	for _, v := range []interface{}{
+		encoderDoc{Kind: "Service", Name: "web"},
+		encoderDoc{Kind: "Deployment", Name: "web"},
+		"plain",
+	} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("Encode(%v) error = %v", v, err)
+		}
+	}
+
+	want := "kind: Service\nname: web\n" +
+		"---\nkind: Deployment\nname: web\n" +
+		"---\nplain\n"
+	if got := sb.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	docs, err := ParseMultiDoc(sb.String())
+	if err != nil {
+		t.Fatalf("ParseMultiDoc() error = %v", err)
+	}
+	if len(docs) != 3 {
+		t.Errorf("ParseMultiDoc() returned %d documents, want 3", len(docs))
+	}
+}
+
+func TestEncoder_Comments(t *testing.T) {
+	var sb strings.Builder
+	enc := NewEncoder(&sb)
+	enc.SetHeaderComment("Code generated by manifests. DO NOT EDIT.\n\nSource: chart v1.2\n")
+
+	enc.SetDocumentComment("Source: templates/service.yaml")
+	if err := enc.Encode(encoderDoc{Kind: "Service", Name: "web"}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	enc.SetDocumentComment("Source: templates/deployment.yaml")
+	if err := enc.Encode(encoderDoc{Kind: "Deployment", Name: "web"}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	enc.SetDocumentComment("")
+	if err := enc.Encode(map[string]int{"replicas": 2}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := "# Code generated by manifests. DO NOT EDIT.\n#\n# Source: chart v1.2\n" +
+		"---\n# Source: templates/service.yaml\nkind: Service\nname: web\n" +
+		"---\n# Source: templates/deployment.yaml\nkind: Deployment\nname: web\n" +
+		"---\nreplicas: 2\n"
+	if got := sb.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+
+	docs, err := ParseMultiDoc(sb.String())
+	if err != nil {
+		t.Fatalf("ParseMultiDoc() error = %v", err)
+	}
+	if len(docs) != 3 {
+		t.Errorf("ParseMultiDoc() returned %d documents, want 3", len(docs))
+	}
+}
+
+func TestEncoder_HeaderOnly(t *testing.T) {
+	var sb strings.Builder
+	enc := NewEncoder(&sb)
+	enc.SetHeaderComment("generated")
+	if err := enc.Encode(encoderDoc{Kind: "Service"}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	enc.SetHeaderComment("ignored after the first document")
+	if err := enc.Encode(encoderDoc{Kind: "Pod"}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := "# generated\nkind: Service\nname: \"\"\n---\nkind: Pod\nname: \"\"\n"
+	if got := sb.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestEncoder_WriteError(t *testing.T) {
+	enc := NewEncoder(failingWriter{})
+	if err := enc.Encode("x"); err == nil || err.Error() != "disk full" {
+		t.Errorf("Encode() error = %v, want disk full", err)
+	}
+}
+
+func TestEncoder_EncodeAllFrom(t *testing.T) {
+	ch := make(chan interface{})
+	go func() {
+		defer close(ch)
+		ch <- encoderDoc{Kind: "Service", Name: "web"}
+		ch <- map[string]int{"replicas": 2}
+	}()
+
+	var sb strings.Builder
+	enc := NewEncoder(&sb)
+	err := enc.EncodeAllFrom(func() (interface{}, bool) {
+		v, ok := <-ch
+		return v, ok
+	})
+	if err != nil {
+		t.Fatalf("EncodeAllFrom() error = %v", err)
+	}
+
+	want := "kind: Service\nname: web\n---\nreplicas: 2\n"
+	if got := sb.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// Later documents continue the same stream
+	if err := enc.Encode("tail"); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if got := sb.String(); got != want+"---\ntail\n" {
+		t.Errorf("output = %q, want a separator before the next document", got)
+	}
+}
+
+func TestEncoder_EncodeAllFromError(t *testing.T) {
+	calls := 0
+	next := func() (interface{}, bool) {
+		calls++
+		if calls == 2 {
+			return make(chan int), true // cannot be encoded
+		}
+		return "doc", calls < 5
+	}
+
+	var sb strings.Builder
+	if err := NewEncoder(&sb).EncodeAllFrom(next); err == nil {
+		t.Fatal("EncodeAllFrom() error = nil, want the encoding error")
+	}
+	if calls != 2 {
+		t.Errorf("next called %d times, want 2", calls)
+	}
+	if sb.String() != "doc\n" {
+		t.Errorf("output = %q, want only the first document", sb.String())
+	}
+}
+
+func TestEncoder_Close(t *testing.T) {
+	var sb strings.Builder
+	enc := NewEncoder(&sb)
+	for i := 1; i <= 3; i++ {
+		if err := enc.Encode(map[string]int{"event": i}); err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+	if err := enc.Encode(map[string]int{"event": 4}); err == nil {
+		t.Error("Encode() after Close succeeded, want error")
+	}
+
+	// The stream reads back one document per Encode call
+	dec := NewDecoder(strings.NewReader(sb.String()))
+	for i := 1; ; i++ {
+		var v map[string]int
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			if i != 4 {
+				t.Errorf("decoded %d documents, want 3", i-1)
+			}
+			break
+		}
+		if err != nil || v["event"] != i {
+			t.Fatalf("document %d = %v, %v; want event %d", i, v, err, i)
+		}
+	}
+}
+
+func TestEncoder_Directives(t *testing.T) {
+	input := "%YAML 1.1\n%TAG !e! tag:example.com,2000:\n---\na: 010\n" +
+		"---\nb: 010\n" +
+		"...\n%YAML 1.1\n---\nc: 010\n"
+
+	var sb strings.Builder
+	enc := NewEncoder(&sb)
+	dec := NewDecoder(strings.NewReader(input))
+	for {
+		var n Node
+		if err := dec.Decode(&n); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if err := enc.Encode(&n); err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+	}
+
+	want := "%YAML 1.1\n%TAG !e! tag:example.com,2000:\n---\na: 010\n" +
+		"---\nb: 010\n" +
+		"...\n%YAML 1.1\n---\nc: 010\n"
+	if got := sb.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	dec = NewDecoder(strings.NewReader(sb.String()))
+	var got []interface{}
+	for {
+		var v map[string]interface{}
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Decode() of output error = %v", err)
+		}
+		for _, x := range v {
+			got = append(got, x)
+		}
+	}
+	if want := []interface{}{int64(8), int64(10), int64(8)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values read back = %v, want %v", got, want)
+	}
+}
+
+func TestEncoder_SetNonFinite(t *testing.T) {
+	var sb strings.Builder
+	enc := NewEncoder(&sb)
+
+	if err := enc.Encode(map[string]float64{"nan": math.NaN()}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	enc.SetNonFinite(NonFiniteNull)
+	if err := enc.Encode(map[string]float64{"nan": math.NaN()}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	enc.SetNonFinite(NonFiniteError)
+	if err := enc.Encode(map[string]float64{"inf": math.Inf(1)}); err == nil {
+		t.Error("Encode() of +Inf with NonFiniteError succeeded, want an error")
+	}
+	if err := enc.Encode(map[string]float64{"x": 1}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := "nan: .nan\n---\nnan: null\n---\nx: 1\n"
+	if got := sb.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// The setting is per Encoder
+	if out, err := Marshal(math.NaN()); err != nil || string(out) != ".nan" {
+		t.Errorf("Marshal(NaN) = %q, %v; want .nan", out, err)
+	}
+}
@@ -0,0 +1,152 @@
+package yaml
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/shapestone/shape-yaml/internal/resolve"
+)
+
+// coreTagPrefix is the prefix the secondary handle !! stands for.
+const coreTagPrefix = "tag:yaml.org,2002:"
+
+// TagForm is the way Marshal writes the tag of a Tagged value.
+type TagForm int
+
+const (
+	// ShorthandTag writes tags under tag:yaml.org,2002: with the secondary
+	// handle, as !!str, and local tags with the primary one, as !Point.
+	// Tags that have no shorthand without a %TAG directive are written
+	// verbatim.
+	ShorthandTag TagForm = iota
+	// VerbatimTag writes the full tag, as !<tag:yaml.org,2002:str>.
+	VerbatimTag
+)
+
+// Tagged is a value that Marshal writes with an explicit tag, for example
+// to mark a custom type for the program reading the document:
+//
+//	out, err := yaml.Marshal(map[string]interface{}{
+//	    "origin": yaml.Tagged{Tag: "!Point", Value: map[string]int{"x": 1, "y": 2}},
+//	    "port":   yaml.Tagged{Tag: yaml.StrTag, Value: 8080},
+//	})
+//	// origin: !Point
+//	//   x: 1
+//	//   y: 2
+//	// port: !!str 8080
+//
+// Tag is a full tag, such as IntTag or "!Point", or a shorthand such as
+// "!!int". The tag is left out when the value would resolve to it anyway,
+// so Tagged{Tag: IntTag, Value: 8080} is written as a plain 8080.
+type Tagged struct {
+	Tag   string
+	Value interface{}
+	Form  TagForm
+}
+
+// MarshalYAML writes the tag before the encoding of the value; a block
+// collection starts on the line after the tag.
+func (t Tagged) MarshalYAML() ([]byte, error) {
+	b, err := Marshal(t.Value)
+	if err != nil {
+		return nil, err
+	}
+	tag := expandTag(t.Tag)
+	if tag == "" || tag == implicitTag(b) {
+		return b, nil
+	}
+	// The tag of a byte slice takes the place of its !!binary
+	b = bytes.TrimPrefix(b, []byte(binaryPrefix))
+
+	out := appendTag(make([]byte, 0, len(tag)+len(b)+4), tag, t.Form)
+	if isBlockCollection(b) {
+		out = append(out, '\n')
+	} else {
+		out = append(out, ' ')
+	}
+	return append(out, b...), nil
+}
+
+// expandTag returns the full form of a tag written as !!name or !<tag>.
+func expandTag(tag string) string {
+	switch {
+	case strings.HasPrefix(tag, "!!"):
+		return coreTagPrefix + tag[2:]
+	case strings.HasPrefix(tag, "!<") && strings.HasSuffix(tag, ">"):
+		return tag[2 : len(tag)-1]
+	}
+	return tag
+}
+
+// appendTag writes the full tag to buf in the given form.
+func appendTag(buf []byte, tag string, form TagForm) []byte {
+	if form == ShorthandTag {
+		if name, ok := strings.CutPrefix(tag, coreTagPrefix); ok && isTagName(name) {
+			return append(append(buf, "!!"...), name...)
+		}
+		if name, ok := strings.CutPrefix(tag, "!"); ok && isTagName(name) {
+			return append(buf, tag...)
+		}
+	}
+	buf = append(buf, "!<"...)
+	buf = append(buf, tag...)
+	return append(buf, '>')
+}
+
+// isTagName reports whether s can follow a tag handle as written, using the
+// characters [a-zA-Z0-9_-].
+func isTagName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') &&
+			!(r >= '0' && r <= '9') && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// binaryPrefix starts the encoding of a byte slice.
+const binaryPrefix = "!!binary "
+
+// implicitTag returns the core schema tag that the untagged encoding b
+// resolves to when read back, or BinaryTag for that of a byte slice.
+func implicitTag(b []byte) string {
+	if bytes.HasPrefix(b, []byte(binaryPrefix)) {
+		return BinaryTag
+	}
+	if isBlockCollection(b) {
+		if b[0] == '-' {
+			return SeqTag
+		}
+		return MapTag
+	}
+	if len(b) > 0 {
+		switch b[0] {
+		case '{':
+			return MapTag
+		case '[':
+			return SeqTag
+		case '"', '\'', '|', '>':
+			return StrTag
+		}
+	}
+	return plainTag(string(b))
+}
+
+// plainTag returns the core schema tag the plain scalar s resolves to.
+func plainTag(s string) string {
+	switch resolve.Plain(s).(type) {
+	case nil:
+		return NullTag
+	case bool:
+		return BoolTag
+	case int64, uint64:
+		return IntTag
+	case float64:
+		return FloatTag
+	}
+	return StrTag
+}
@@ -0,0 +1,81 @@
+package yaml
+
+import "testing"
+
+func TestMarshal_Tagged(t *testing.T) {
+	point := map[string]int{"x": 1, "y": 2}
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{"implicit int tag left out", Tagged{Tag: IntTag, Value: 8080}, "8080"},
+		{"implicit str tag left out", Tagged{Tag: "!!str", Value: "8080"}, `"8080"`},
+		{"implicit map tag left out", Tagged{Tag: MapTag, Value: point}, "x: 1\ny: 2"},
+		{"core tag shorthand", Tagged{Tag: StrTag, Value: 8080}, "!!str 8080"},
+		{"core tag verbatim", Tagged{Tag: StrTag, Value: 8080, Form: VerbatimTag}, "!<tag:yaml.org,2002:str> 8080"},
+		{"local tag", Tagged{Tag: "!Point", Value: point}, "!Point\nx: 1\ny: 2"},
+		{"local tag verbatim", Tagged{Tag: "!Point", Value: "p", Form: VerbatimTag}, "!<!Point> p"},
+		{"global tag verbatim", Tagged{Tag: "tag:example.com,2000:set", Value: []string{"a"}}, "!<tag:example.com,2000:set>\n- a"},
+		{"verbatim input", Tagged{Tag: "!<tag:yaml.org,2002:float>", Value: 1}, "!!float 1"},
+		{"flow collection", Tagged{Tag: "!Empty", Value: map[string]int{}}, "!Empty {}"},
+		{"no tag", Tagged{Value: true}, "true"},
+		{
+			"in mapping",
+			map[string]interface{}{"origin": Tagged{Tag: "!Point", Value: point}, "port": Tagged{Tag: StrTag, Value: 8080}},
+			"origin: !Point\n  x: 1\n  y: 2\nport: !!str 8080",
+		},
+		{
+			"in sequence",
+			[]interface{}{Tagged{Tag: "!Point", Value: map[string]int{"x": 1}}, Tagged{Tag: FloatTag, Value: 2}},
+			"- !Point\n  x: 1\n- !!float 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshal_TaggedRoundTrip(t *testing.T) {
+	out, err := Marshal(MapSlice{
+		{Key: "origin", Value: Tagged{Tag: "!Point", Value: map[string]int{"x": 1}}},
+		{Key: "port", Value: Tagged{Tag: StrTag, Value: 8080}},
+		{Key: "set", Value: Tagged{Tag: "tag:example.com,2000:set", Value: []string{"a"}}},
+		{Key: "plain", Value: Tagged{Tag: IntTag, Value: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	node, tags, err := ParseWithTags(string(out))
+	if err != nil {
+		t.Fatalf("ParseWithTags(%q) error = %v", out, err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"origin", "!Point"},
+		{"port", StrTag},
+		{"set", "tag:example.com,2000:set"},
+		{"plain", IntTag},
+	}
+	for _, tt := range tests {
+		child := property(node, tt.key)
+		if got := tags.Of(child); got != tt.want {
+			t.Errorf("tags.Of(%s) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+	if port := property(node, "port"); NodeToInterface(port) != "8080" {
+		t.Errorf("port = %#v, want \"8080\"", NodeToInterface(port))
+	}
+}
@@ -0,0 +1,98 @@
+package yaml
+
+import (
+	"reflect"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/parser"
+	"github.com/shapestone/shape-yaml/internal/resolve"
+)
+
+// setTarget reports whether a !!set decoded into a value of type t becomes
+// its members rather than the mapping it is written as: t is a slice or
+// array, or a map from strings to bool.
+func setTarget(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return true
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Bool
+	}
+	return false
+}
+
+// omapTarget reports whether an !!omap decoded into a value of type t
+// becomes the mapping of its entries rather than the sequence it is written
+// as: t is a MapSlice, a map or a struct.
+func omapTarget(t reflect.Type) bool {
+	return t == mapSliceType || t.Kind() == reflect.Map || t.Kind() == reflect.Struct
+}
+
+// unmarshalSet decodes the members of a !!set, the keys of node, into a
+// slice or array in document order, or into a map as keys set to true.
+func (d *nodeDecoder) unmarshalSet(node *ast.ObjectNode, rv reflect.Value) error {
+	props := node.Properties()
+	members := d.order.Keys(node)
+
+	if rv.Kind() == reflect.Map {
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+		t := reflect.ValueOf(true).Convert(rv.Type().Elem())
+		for _, m := range members {
+			rv.SetMapIndex(reflect.ValueOf(m).Convert(rv.Type().Key()), t)
+		}
+		return nil
+	}
+
+	items := make([]ast.SchemaNode, len(members))
+	for i, m := range members {
+		items[i] = d.setMember(m, props[m].Position())
+	}
+	return d.unmarshalSequence(ast.NewArrayDataNode(items, node.Position()), rv)
+}
+
+// setMember returns a scalar node for member key of a !!set, resolved as a
+// plain scalar so that numbers decode into numeric targets, with its text
+// recorded so that string targets receive it as written.
+func (d *nodeDecoder) setMember(key string, pos ast.Position) ast.SchemaNode {
+	v := resolve.Plain(key)
+	if v == nil {
+		v = key
+	}
+	lit := ast.NewLiteralNode(v, pos)
+	if _, ok := v.(string); !ok && d.texts != nil {
+		d.texts[lit] = key
+	}
+	return lit
+}
+
+// unmarshalOmap decodes the entries of an !!omap, the single-entry mappings
+// of node, as one mapping: into a MapSlice in document order, or into a map
+// or struct.
+func (d *nodeDecoder) unmarshalOmap(node *ast.ArrayDataNode, rv reflect.Value) error {
+	props := make(map[string]ast.SchemaNode, node.Len())
+	keys := make([]string, 0, node.Len())
+	for _, elem := range node.Elements() {
+		pair, ok := elem.(*ast.ObjectNode)
+		if !ok {
+			return typeError(node, "sequence", rv.Type())
+		}
+		for _, k := range d.order.Keys(pair) {
+			if _, exists := props[k]; !exists {
+				keys = append(keys, k)
+			}
+			props[k] = pair.Properties()[k]
+		}
+	}
+
+	obj := ast.NewObjectNode(props, node.Position())
+	if d.order == nil {
+		d.order = make(parser.KeyOrder)
+	}
+	d.order[obj] = keys
+	if rv.Type() == mapSliceType {
+		return unmarshalMapSlice(obj, rv, d.order)
+	}
+	return d.unmarshalObject(obj, rv)
+}
@@ -0,0 +1,147 @@
+package yaml
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// A Template is a document parsed once and instantiated many times with
+// different scalar values, for generators that would otherwise parse the
+// same manifest for every copy they emit. A Template is never modified, so
+// one may be instantiated from many goroutines at once.
+type Template struct {
+	root *Node
+}
+
+// ParseTemplate parses input, as Unmarshal into a Node would, as a Template.
+//
+// Example:
+//
+//	tmpl, err := yaml.ParseTemplate(deploymentYAML)
+//	for _, svc := range services {
+//	    doc, err := tmpl.Instantiate(map[string]interface{}{
+//	        "/metadata/name":           svc.Name,
+//	        "/spec/replicas":           svc.Replicas,
+//	        "/spec/containers/0/image": svc.Image,
+//	    })
+//	    out, err := yaml.Marshal(doc)
+//	    ...
+//	}
+func ParseTemplate(input string) (*Template, error) {
+	var root Node
+	if err := root.UnmarshalYAML([]byte(input)); err != nil {
+		return nil, err
+	}
+	return &Template{root: &root}, nil
+}
+
+// NewTemplate returns a Template for an already parsed tree. The tree must
+// not be modified while the Template is in use.
+func NewTemplate(node *Node) *Template {
+	return &Template{root: node}
+}
+
+// Instantiate returns a deep copy of the template in which the scalar at
+// each JSON Pointer (RFC 6901) of values, such as "/spec/replicas", is
+// replaced by the value, which may be a Node or any value Marshal accepts.
+// Each pointer must name a scalar of the template. The copy keeps the key
+// order and styles of the template and shares no nodes with it, so it may
+// be modified.
+func (t *Template) Instantiate(values map[string]interface{}) (*Node, error) {
+	pointers := make([]string, 0, len(values))
+	for p := range values {
+		pointers = append(pointers, p)
+	}
+	sort.Strings(pointers) // report the same error on every call
+
+	var subst *substitution
+	for _, p := range pointers {
+		path, err := parseJSONPointer(p)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: template: %w", err)
+		}
+		target, err := patchGet(t.root, path)
+		if err != nil || target == nil || target.Kind != ScalarKind {
+			return nil, fmt.Errorf("yaml: template has no scalar at %q", p)
+		}
+		node, err := nodeOf(values[p])
+		if err != nil {
+			return nil, fmt.Errorf("yaml: template value at %q: %w", p, err)
+		}
+		node = node.clone()
+		node.Line, node.Column = target.Line, target.Column
+		if subst == nil {
+			subst = &substitution{}
+		}
+		subst.add(path, node)
+	}
+	return instantiate(t.root, subst), nil
+}
+
+// substitution holds the replacement nodes of a tree by path.
+type substitution struct {
+	node     *Node // replacement for the node at this path, if any
+	children map[string]*substitution
+}
+
+func (s *substitution) add(path []string, node *Node) {
+	for _, tok := range path {
+		child := s.children[tok]
+		if child == nil {
+			if s.children == nil {
+				s.children = make(map[string]*substitution)
+			}
+			child = &substitution{}
+			s.children[tok] = child
+		}
+		s = child
+	}
+	s.node = node
+}
+
+// instantiate copies node, replacing the nodes s holds. s is nil where
+// nothing below node is replaced.
+func instantiate(node *Node, s *substitution) *Node {
+	if s == nil || node == nil {
+		return node.clone()
+	}
+	if s.node != nil {
+		return s.node.clone()
+	}
+
+	out := *node
+	out.Content = make([]*Node, len(node.Content))
+	out.Directives = append([]string(nil), node.Directives...)
+	switch node.Kind {
+	case MappingKind:
+		// A pointer names the first entry of a key, as GetKey finds it
+		done := make(map[string]bool, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			out.Content[i] = k.clone()
+			if k != nil && k.Kind == ScalarKind && !done[k.Value] {
+				done[k.Value] = true
+				out.Content[i+1] = instantiate(v, s.child(k.Value))
+			} else {
+				out.Content[i+1] = v.clone()
+			}
+		}
+	case SequenceKind:
+		for i, elem := range node.Content {
+			out.Content[i] = instantiate(elem, s.child(strconv.Itoa(i)))
+		}
+	default:
+		for i, c := range node.Content {
+			out.Content[i] = c.clone()
+		}
+	}
+	return &out
+}
+
+func (s *substitution) child(tok string) *substitution {
+	if s == nil {
+		return nil
+	}
+	return s.children[tok]
+}
@@ -0,0 +1,132 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const templateInput = `metadata:
+  name: placeholder
+  labels: {tier: web}
+spec:
+  replicas: 1
+  containers:
+    - name: app
+      image: app:latest
+`
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate(templateInput)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	want := nodeValue(t, tmpl.root)
+
+	doc, err := tmpl.Instantiate(map[string]interface{}{
+		"/metadata/name":           "api",
+		"/spec/replicas":           3,
+		"/spec/containers/0/image": "api:1.2",
+	})
+	if err != nil {
+		t.Fatalf("Instantiate() error = %v", err)
+	}
+	got := nodeValue(t, doc).(map[string]interface{})
+	if name := got["metadata"].(map[string]interface{})["name"]; name != "api" {
+		t.Errorf("name = %v, want api", name)
+	}
+	spec := got["spec"].(map[string]interface{})
+	if spec["replicas"] != int64(3) {
+		t.Errorf("replicas = %#v, want 3", spec["replicas"])
+	}
+	if image := spec["containers"].([]interface{})[0].(map[string]interface{})["image"]; image != "api:1.2" {
+		t.Errorf("image = %v, want api:1.2", image)
+	}
+	if labels := got["metadata"].(map[string]interface{})["labels"]; !reflect.DeepEqual(labels, map[string]interface{}{"tier": "web"}) {
+		t.Errorf("labels = %v", labels)
+	}
+
+	// The instance keeps the template's key order and styles
+	out, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := "metadata: \n  name: api\n  labels: \n    tier: web\nspec: \n  replicas: 3\n  containers: \n    - \n      name: app\n      image: \"api:1.2\""; string(out) != want {
+		t.Errorf("Marshal(doc) =\n%s\nwant\n%s", out, want)
+	}
+
+	// The instance shares no nodes with the template
+	if err := SetPath(doc, "metadata.labels.tier", "db"); err != nil {
+		t.Fatalf("SetPath() error = %v", err)
+	}
+	if err := DeletePath(doc, "spec.containers[0]"); err != nil {
+		t.Fatalf("DeletePath() error = %v", err)
+	}
+	if again := nodeValue(t, tmpl.root); !reflect.DeepEqual(again, want) {
+		t.Errorf("template changed to %v, want %v", again, want)
+	}
+
+	plain, err := tmpl.Instantiate(nil)
+	if err != nil || !reflect.DeepEqual(nodeValue(t, plain), want) {
+		t.Errorf("Instantiate(nil) = %v, %v; want a copy of the template", plain, err)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	tmpl, err := ParseTemplate(templateInput)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		values  map[string]interface{}
+		wantErr string
+	}{
+		{"missing key", map[string]interface{}{"/metadata/namespace": "x"}, `no scalar at "/metadata/namespace"`},
+		{"not a scalar", map[string]interface{}{"/metadata/labels": "x"}, `no scalar at "/metadata/labels"`},
+		{"index out of range", map[string]interface{}{"/spec/containers/1/image": "x"}, `no scalar at "/spec/containers/1/image"`},
+		{"index with leading zero", map[string]interface{}{"/spec/containers/00/image": "x"}, "no scalar"},
+		{"below a scalar", map[string]interface{}{"/spec/replicas/0": 1}, "no scalar"},
+		{"invalid pointer", map[string]interface{}{"spec": 1}, "invalid JSON pointer"},
+		{"unsupported value", map[string]interface{}{"/spec/replicas": make(chan int)}, `value at "/spec/replicas"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tmpl.Instantiate(tt.values)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Instantiate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := ParseTemplate("a: [1, 2"); err == nil {
+		t.Error("ParseTemplate() of invalid YAML succeeded")
+	}
+}
+
+// TestTemplate_ConcurrentInstantiate instantiates one template from many
+// goroutines at once. Run with -race.
+func TestTemplate_ConcurrentInstantiate(t *testing.T) {
+	tmpl := NewTemplate(parseNode(t, sharedTreeInput))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			doc, err := tmpl.Instantiate(map[string]interface{}{"/services/web/port": i})
+			if err != nil {
+				t.Errorf("Instantiate() error = %v", err)
+				return
+			}
+			web, _ := GetKey(doc, "services")
+			web, _ = GetKey(web, "web")
+			port, _ := GetKey(web, "port")
+			if n, ok := AsInt(port); !ok || n != int64(i) {
+				t.Errorf("port = %v, want %d", port, i)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
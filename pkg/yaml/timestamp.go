@@ -0,0 +1,37 @@
+package yaml
+
+import (
+	"encoding"
+	"reflect"
+	"time"
+
+	"github.com/shapestone/shape-yaml/internal/resolve"
+)
+
+// timeType is decoded from any YAML timestamp and encoded as RFC 3339,
+// rather than as the struct it is.
+var timeType = reflect.TypeOf(time.Time{})
+
+// textUnmarshaler returns the encoding.TextUnmarshaler of addressable rv,
+// for types that decode themselves from a scalar's text. A time.Time reads
+// any YAML timestamp, such as 2001-12-14 or 2001-12-14 21:59:43.10 -5,
+// not only the RFC 3339 its UnmarshalText accepts.
+func textUnmarshaler(rv reflect.Value) (encoding.TextUnmarshaler, bool) {
+	if rv.Type() == timeType {
+		return (*resolve.TimestampText)(rv.Addr().Interface().(*time.Time)), true
+	}
+	u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler)
+	return u, ok
+}
+
+// yamlTimeEnc writes a time.Time in RFC 3339, with fractional seconds when
+// it has them, as a plain scalar.
+func yamlTimeEnc(e *encodeState, buf []byte, rv reflect.Value, indent int) ([]byte, error) {
+	return rv.Interface().(time.Time).AppendFormat(buf, time.RFC3339Nano), nil
+}
+
+// isZeroTime reports whether rv, a time.Time, is the zero time, which
+// omitempty omits.
+func isZeroTime(rv reflect.Value) bool {
+	return rv.Interface().(time.Time).IsZero()
+}
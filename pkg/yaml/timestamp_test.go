@@ -0,0 +1,126 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestamp(t *testing.T) {
+	type Event struct {
+		At    time.Time   `yaml:"at"`
+		Until *time.Time  `yaml:"until"`
+		Note  interface{} `yaml:"note"`
+	}
+	est := time.FixedZone("", -5*3600)
+	tests := []struct {
+		input string
+		at    time.Time
+	}{
+		{"at: 2001-12-14\n", time.Date(2001, 12, 14, 0, 0, 0, 0, time.UTC)},
+		{"at: 2001-12-14t21:59:43.10-05:00\n", time.Date(2001, 12, 14, 21, 59, 43, 1e8, est)},
+		{"at: 2001-12-14 21:59:43.10 -5\n", time.Date(2001, 12, 14, 21, 59, 43, 1e8, est)},
+		{"at: \"2001-12-14T21:59:43Z\"\n", time.Date(2001, 12, 14, 21, 59, 43, 0, time.UTC)},
+		{"{at: 2001-12-14T21:59:43Z}", time.Date(2001, 12, 14, 21, 59, 43, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+			var ev Event
+			if err := decode([]byte(tt.input), &ev); err != nil {
+				t.Fatalf("decode %q: %v", tt.input, err)
+			}
+			if !ev.At.Equal(tt.at) {
+				t.Errorf("decode %q: at = %v, want %v", tt.input, ev.At, tt.at)
+			}
+		})
+	}
+
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var ev Event
+		input := "until: 2002-01-01\nnote: 2001-12-14\n"
+		if err := decode([]byte(input), &ev); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if ev.Until == nil || !ev.Until.Equal(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("until = %v", ev.Until)
+		}
+		// Timestamps are not core schema: untyped, a date stays a string
+		if ev.Note != "2001-12-14" {
+			t.Errorf("note = %#v, want the string", ev.Note)
+		}
+
+		if err := decode([]byte("at: yesterday\n"), &ev); err == nil || !strings.Contains(err.Error(), "timestamp") {
+			t.Errorf("decode yesterday = %v, want a timestamp error", err)
+		}
+	})
+}
+
+func TestTimestampTag(t *testing.T) {
+	var v map[string]interface{}
+	if err := UnmarshalWithAST([]byte("at: !!timestamp 2001-12-14\n"), &v); err != nil {
+		t.Fatalf("UnmarshalWithAST: %v", err)
+	}
+	if at, ok := v["at"].(time.Time); !ok || !at.Equal(time.Date(2001, 12, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("at = %#v, want a time.Time", v["at"])
+	}
+
+	if err := UnmarshalWithAST([]byte("at: !!timestamp soon\n"), &v); err == nil {
+		t.Error("UnmarshalWithAST(!!timestamp soon) succeeded, want an error")
+	}
+
+	var n Node
+	if err := Unmarshal([]byte("!!timestamp 2001-12-14\n"), &n); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if n.Tag != TimestampTag || n.Value != "2001-12-14" {
+		t.Errorf("node = %s %q, want %s", n.Tag, n.Value, TimestampTag)
+	}
+}
+
+func TestTimestampMarshal(t *testing.T) {
+	type Event struct {
+		At    time.Time  `yaml:"at"`
+		Until *time.Time `yaml:"until,omitempty"`
+		Since time.Time  `yaml:"since,omitempty"`
+	}
+	at := time.Date(2001, 12, 14, 21, 59, 43, 1e8, time.FixedZone("", -5*3600))
+	got, err := Marshal(Event{At: at})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := "at: 2001-12-14T21:59:43.1-05:00"; string(got) != want {
+		t.Errorf("Marshal = %q, want %q", got, want)
+	}
+
+	forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+		var back Event
+		if err := decode(got, &back); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if !back.At.Equal(at) || back.Until != nil || !back.Since.IsZero() {
+			t.Errorf("round trip = %+v", back)
+		}
+	})
+
+	got, err = Marshal([]time.Time{at.UTC()})
+	if err != nil || string(got) != "- 2001-12-15T02:59:43.1Z" {
+		t.Errorf("Marshal = %q, %v", got, err)
+	}
+}
+
+func TestDecoderDisableImplicitTimestamps(t *testing.T) {
+	var ev struct {
+		At time.Time `yaml:"at"`
+	}
+	dec := NewDecoder(strings.NewReader("at: 2001-12-14T21:59:43Z\n---\nat: 2001-12-14\n"))
+	dec.DisableImplicitTimestamps()
+	if err := dec.Decode(&ev); err != nil {
+		t.Fatalf("Decode RFC 3339: %v", err)
+	}
+	if !ev.At.Equal(time.Date(2001, 12, 14, 21, 59, 43, 0, time.UTC)) {
+		t.Errorf("at = %v", ev.At)
+	}
+	if err := dec.Decode(&ev); err == nil {
+		t.Error("Decode date only succeeded, want time.Time's RFC 3339 error")
+	}
+}
@@ -0,0 +1,162 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// TransformScalars returns a copy of node with every scalar value replaced
+// by the result of fn. fn receives the path to the scalar, as in Walk, and
+// its value (string, int64, uint64, float64, bool or nil, or a time.Time or
+// []byte for !!timestamp and !!binary scalars), and returns the new value.
+// The new value may be a Node, or any value Marshal accepts, so a scalar
+// can also be replaced by a mapping or sequence. A scalar fn returns
+// unchanged keeps its style and tag. Mapping keys are not transformed, and
+// node itself is not modified.
+//
+// Example: bump every image tag during a migration.
+//
+//	out, err := yaml.TransformScalars(&node, func(path []string, v interface{}) interface{} {
+//	    if s, ok := v.(string); ok && path[len(path)-1] == "image" {
+//	        return strings.Replace(s, ":1.0", ":2.0", 1)
+//	    }
+//	    return v
+//	})
+func TransformScalars(node *Node, fn func(path []string, value interface{}) interface{}) (*Node, error) {
+	r := rewriter{
+		scalar: func(path []string, n *Node) (*Node, error) {
+			v, err := n.scalarValue()
+			if err != nil {
+				return nil, fmt.Errorf("yaml: transform scalar at %q: %w", formatJSONPointer(path), err)
+			}
+			replaced := fn(path, v)
+			if t := reflect.TypeOf(replaced); t == nil && v == nil || t != nil && t.Comparable() && replaced == v {
+				return n.clone(), nil
+			}
+			out, err := nodeOf(replaced)
+			if err != nil {
+				return nil, fmt.Errorf("yaml: transform scalar at %q: %w", formatJSONPointer(path), err)
+			}
+			out = out.clone()
+			out.Line, out.Column = n.Line, n.Column
+			return out, nil
+		},
+	}
+	return r.rewrite(make([]string, 0, 8), node)
+}
+
+// FilterKeys returns a copy of node without the mapping entries, at any
+// depth, for which keep returns false. keep receives the path to the mapping
+// holding the entry and the entry's key. Sequence elements are never
+// removed. node itself is not modified.
+func FilterKeys(node *Node, keep func(path []string, key string) bool) *Node {
+	r := rewriter{
+		key: func(path []string, key string) (string, bool) {
+			return key, keep(path, key)
+		},
+	}
+	// Without a scalar callback rewrite cannot fail
+	out, _ := r.rewrite(make([]string, 0, 8), node)
+	return out
+}
+
+// RenameKeys returns a copy of node in which every mapping key matching
+// pattern, at any depth, is replaced by pattern.ReplaceAllString(key, repl).
+// Anchor the pattern to rename whole keys only:
+//
+//	out, err := yaml.RenameKeys(&node, regexp.MustCompile(`^db_(\w+)$`), "database_$1")
+//
+// It fails if a renamed key collides with another key of the same mapping.
+// node itself is not modified.
+func RenameKeys(node *Node, pattern *regexp.Regexp, repl string) (*Node, error) {
+	r := rewriter{
+		key: func(path []string, key string) (string, bool) {
+			return pattern.ReplaceAllString(key, repl), true
+		},
+	}
+	return r.rewrite(make([]string, 0, 8), node)
+}
+
+// rewriter copies a tree, letting key rename or drop mapping keys and
+// scalar replace scalar nodes. Either callback may be nil. Paths passed to
+// the callbacks use the keys of the input tree.
+type rewriter struct {
+	key    func(path []string, key string) (string, bool)
+	scalar func(path []string, n *Node) (*Node, error)
+}
+
+func (r rewriter) rewrite(path []string, node *Node) (*Node, error) {
+	if node == nil {
+		return nil, nil
+	}
+	switch node.Kind {
+	case ScalarKind:
+		if r.scalar != nil {
+			return r.scalar(path, node)
+		}
+		return node.clone(), nil
+
+	case MappingKind:
+		out := *node
+		out.Content = make([]*Node, 0, len(node.Content))
+		renamed := make(map[string]string, len(node.Content)/2) // kept keys by input key
+		taken := make(map[string]bool, len(node.Content)/2)     // kept keys by output key
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if k == nil || k.Kind != ScalarKind {
+				out.Content = append(out.Content, k.clone(), v.clone())
+				continue
+			}
+
+			// A merged key the mapping overrides follows its own key
+			name, seen := renamed[k.Value]
+			if !seen {
+				name = k.Value
+				if r.key != nil {
+					var keep bool
+					if name, keep = r.key(path, k.Value); !keep {
+						continue
+					}
+				}
+				if taken[name] {
+					return nil, fmt.Errorf("yaml: renaming key %s to %q duplicates an existing key",
+						formatJSONPointer(append(path, k.Value)), name)
+				}
+				renamed[k.Value], taken[name] = name, true
+			}
+
+			key := k.clone()
+			if name != k.Value {
+				key.Value, key.Tag = name, StrTag
+				if key.Style == Plain {
+					key.Tag = plainTag(name)
+				}
+			}
+			child, err := r.rewrite(append(path, k.Value), v)
+			if err != nil {
+				return nil, err
+			}
+			out.Content = append(out.Content, key, child)
+		}
+		out.Directives = append([]string(nil), node.Directives...)
+		return &out, nil
+
+	case SequenceKind:
+		out := *node
+		out.Content = make([]*Node, len(node.Content))
+		for i, elem := range node.Content {
+			child, err := r.rewrite(append(path, strconv.Itoa(i)), elem)
+			if err != nil {
+				return nil, err
+			}
+			out.Content[i] = child
+		}
+		out.Directives = append([]string(nil), node.Directives...)
+		return &out, nil
+
+	default:
+		return node.clone(), nil
+	}
+}
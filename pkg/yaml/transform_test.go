@@ -0,0 +1,179 @@
+package yaml
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const transformDoc = `
+db_host: localhost
+db_port: 5432
+name: api
+containers:
+  - image: app:1.0
+    debug: true
+  - image: sidecar:1.0
+`
+
+func TestTransformScalars(t *testing.T) {
+	node := parseNode(t, transformDoc)
+
+	out, err := TransformScalars(node, func(path []string, v interface{}) interface{} {
+		if s, ok := v.(string); ok && path[len(path)-1] == "image" {
+			return strings.Replace(s, ":1.0", ":2.0", 1)
+		}
+		if path[len(path)-1] == "db_port" {
+			return map[string]interface{}{"value": v}
+		}
+		return v
+	})
+	if err != nil {
+		t.Fatalf("TransformScalars() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"db_host": "localhost",
+		"db_port": map[string]interface{}{"value": int64(5432)},
+		"name":    "api",
+		"containers": []interface{}{
+			map[string]interface{}{"image": "app:2.0", "debug": true},
+			map[string]interface{}{"image": "sidecar:2.0"},
+		},
+	}
+	if got := nodeValue(t, out); !reflect.DeepEqual(got, want) {
+		t.Errorf("TransformScalars() = %#v, want %#v", got, want)
+	}
+	// The copy keeps the document's key order
+	if data, err := Marshal(out); err != nil || !strings.HasPrefix(string(data), "db_host: localhost\ndb_port: \n  value: 5432\nname: api\n") {
+		t.Errorf("Marshal(out) = %q, %v", data, err)
+	}
+
+	// The input tree is unchanged
+	image, _ := GetKey(first(t, node, "containers"), "image")
+	if s, _ := AsString(image); s != "app:1.0" {
+		t.Errorf("input image = %q, want app:1.0", s)
+	}
+	// Replaced scalars keep their source position
+	host, _ := GetKey(out, "db_host")
+	if host.Line != 2 {
+		t.Errorf("db_host line = %d, want 2", host.Line)
+	}
+}
+
+func TestTransformScalars_UnsupportedValue(t *testing.T) {
+	node := parseNode(t, "a:\n  b: 1")
+	_, err := TransformScalars(node, func(path []string, v interface{}) interface{} {
+		return make(chan int)
+	})
+	if err == nil || !strings.Contains(err.Error(), `"/a/b"`) {
+		t.Errorf("TransformScalars() error = %v, want error naming /a/b", err)
+	}
+}
+
+func TestFilterKeys(t *testing.T) {
+	node := parseNode(t, transformDoc)
+
+	out := FilterKeys(node, func(path []string, key string) bool {
+		return key != "debug" && !strings.HasPrefix(key, "db_")
+	})
+
+	want := map[string]interface{}{
+		"name": "api",
+		"containers": []interface{}{
+			map[string]interface{}{"image": "app:1.0"},
+			map[string]interface{}{"image": "sidecar:1.0"},
+		},
+	}
+	if got := nodeValue(t, out); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterKeys() = %#v, want %#v", got, want)
+	}
+	if Len(node) != 4 {
+		t.Errorf("input Len = %d, want 4", Len(node))
+	}
+}
+
+func TestRenameKeys(t *testing.T) {
+	node := parseNode(t, transformDoc)
+
+	out, err := RenameKeys(node, regexp.MustCompile(`^db_(\w+)$`), "database_$1")
+	if err != nil {
+		t.Fatalf("RenameKeys() error = %v", err)
+	}
+	if got, want := MapKeys(out), []string{"database_host", "database_port", "name", "containers"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapKeys() = %v, want %v", got, want)
+	}
+
+	out, err = RenameKeys(node, regexp.MustCompile(`^image$`), "img")
+	if err != nil {
+		t.Fatalf("RenameKeys() error = %v", err)
+	}
+	if _, ok := GetKey(first(t, out, "containers"), "img"); !ok {
+		t.Error("nested key image was not renamed")
+	}
+
+	_, err = RenameKeys(node, regexp.MustCompile(`^db_\w+$`), "db")
+	if err == nil || !strings.Contains(err.Error(), "duplicates an existing key") {
+		t.Errorf("RenameKeys() collision error = %v", err)
+	}
+}
+
+func TestFilterAndRenameKeys_IntegerLikeKeys(t *testing.T) {
+	node := parseNode(t, "ports:\n  0: http\n  1: https\nlist: [a, b]")
+
+	out := FilterKeys(node, func(path []string, key string) bool { return key != "0" })
+	want := map[string]interface{}{
+		"ports": map[string]interface{}{"1": "https"},
+		"list":  []interface{}{"a", "b"},
+	}
+	if got := nodeValue(t, out); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterKeys() = %#v, want %#v", got, want)
+	}
+
+	out, err := RenameKeys(node, regexp.MustCompile(`^(\d+)$`), "port_$1")
+	if err != nil {
+		t.Fatalf("RenameKeys() error = %v", err)
+	}
+	want = map[string]interface{}{
+		"ports": map[string]interface{}{"port_0": "http", "port_1": "https"},
+		"list":  []interface{}{"a", "b"},
+	}
+	if got := nodeValue(t, out); !reflect.DeepEqual(got, want) {
+		t.Errorf("RenameKeys() = %#v, want %#v", got, want)
+	}
+}
+
+// first returns the first element of the sequence under key.
+func first(t *testing.T, node *Node, key string) *Node {
+	t.Helper()
+	seq, _ := GetKey(node, key)
+	elem, ok := Index(seq, 0)
+	if !ok {
+		t.Fatalf("%s[0] not found", key)
+	}
+	return elem
+}
+
+// nodeValue decodes node into an interface{}.
+func nodeValue(t *testing.T, node *Node) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	return v
+}
+
+func TestTransformScalars_KeepsUnchangedScalars(t *testing.T) {
+	node := parseNode(t, "a: \"1\"\nb: |\n  x\n  y\nc: 0x1F\nd: !!float 2\n")
+	out, err := TransformScalars(node, func(path []string, v interface{}) interface{} { return v })
+	if err != nil {
+		t.Fatalf("TransformScalars() error = %v", err)
+	}
+	got, _ := Marshal(out)
+	want, _ := Marshal(node)
+	if string(got) != string(want) {
+		t.Errorf("Marshal(out) =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"strconv"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/determinism"
 	"github.com/shapestone/shape-yaml/internal/fastparser"
+	"github.com/shapestone/shape-yaml/internal/parser"
+	"github.com/shapestone/shape-yaml/internal/utf8input"
 )
 
 // Unmarshal parses the YAML-encoded data and stores the result in the value pointed to by v.
@@ -30,15 +35,28 @@ import (
 //
 // To unmarshal YAML into an interface value, Unmarshal stores one of these in the interface value:
 //
-//	bool, for YAML booleans
-//	int64, for YAML integers
-//	float64, for YAML floats
+//	bool, for YAML booleans (true/false, yes/no, on/off)
+//	int64, for YAML integers (decimal, 0x hex, 0o octal)
+//	uint64, for YAML integers above math.MaxInt64
+//	float64, for YAML floats, including whole ones like 1.0 and .inf/.nan
 //	string, for YAML strings
 //	[]interface{}, for YAML sequences
 //	map[string]interface{}, for YAML mappings
 //	nil for YAML null
 //
-// If the YAML is not valid, Unmarshal returns a parse error.
+// UnmarshalWithAST and NodeToInterface produce the same types.
+//
+// Integers with a leading zero (010) are decimal, per the YAML 1.2 core schema.
+// Documents that declare %YAML 1.1 read them as octal instead, and leading-zero
+// digits that are not valid octal (09) as a string.
+//
+// Unmarshal does not resolve anchors and aliases: a document holding one
+// fails with an error wrapping ErrAnchors, rather than decoding "*base" as
+// text. UnmarshalWithAST resolves them, merge keys included.
+//
+// If the YAML is not valid, or a value does not fit its target, Unmarshal
+// returns a *ParseError locating the problem. Input that is not valid UTF-8
+// is rejected with an error wrapping ErrInvalidUTF8.
 //
 // Example:
 //
@@ -50,30 +68,256 @@ import (
 //	err := yaml.Unmarshal([]byte("name: server\nport: 8080"), &cfg)
 func Unmarshal(data []byte, v interface{}) error {
 	// Fast path: Direct parsing without AST construction (4-5x faster)
-	return fastparser.Unmarshal(data, v)
+	opts := fastparser.Options{Nodes: newNodeSource(v, 1, 1), Tagged: newTagSource}
+	return observeDecode(DecodePathFast, len(data), fastparser.UnmarshalWithOptions(data, v, opts))
+}
+
+// UnmarshalStrict is like Unmarshal, but a mapping key that matches no field
+// of the struct it is decoded into is an error rather than ignored, for
+// validating configuration files. The whole document is decoded first, and
+// the error lists every unknown key with its line, as
+// Decoder.DisallowUnknownFields does.
+//
+// Example:
+//
+//	err := yaml.UnmarshalStrict([]byte("name: web\nreplcas: 3"), &cfg)
+//	// yaml: line 2: unknown field "replcas" in main.Config, did you mean "replicas"?
+func UnmarshalStrict(data []byte, v interface{}) error {
+	return UnmarshalWithOptions(data, v, DecodeOptions{KnownFields: true})
+}
+
+// DecodeOptions configures UnmarshalWithOptions. The zero value decodes as
+// Unmarshal does.
+type DecodeOptions struct {
+	// TagName is the struct tag key that names fields, such as "config".
+	// The default is "yaml".
+	TagName string
+
+	// JSONTagFallback names fields that have no TagName tag by their json
+	// tag, so that models written for encoding/json decode without
+	// duplicating every tag. A field with neither tag is named as usual.
+	JSONTagFallback bool
+
+	// KnownFields makes keys that match no field an error, as
+	// UnmarshalStrict does.
+	KnownFields bool
+
+	// Limits bounds the resources spent on data, which fails with an error
+	// wrapping ErrLimitExceeded once it exceeds one. Unmarshal rejects
+	// anchors and aliases (see ErrAnchors), so only MaxBytes, MaxDepth,
+	// MaxKeyLength and SafeMode apply; MaxBytes also bounds the number of
+	// nodes.
+	Limits Limits
+
+	// PromoteScalars decodes a scalar into a slice as a slice of that one
+	// value, for blocks such as headers or query parameters where each key
+	// takes one value or several: decoded into a map[string][]string,
+	// "accept: text/html" and "accept: [text/html, text/plain]" both work.
+	// A null still leaves a nil slice.
+	PromoteScalars bool
+
+	// BaseOffset, BaseLine and BaseColumn place data within an enclosing
+	// file, such as the front matter of a Markdown page or a block cut
+	// from a Helm template, so that errors and Node positions refer to that
+	// file: data[0] is at byte BaseOffset, on 1-based BaseLine and
+	// BaseColumn. BaseColumn applies to the first line of data only. Zero
+	// values mean data starts the file.
+	BaseOffset int
+	BaseLine   int
+	BaseColumn int
+}
+
+// UnmarshalWithOptions is like Unmarshal, configured by opts.
+//
+// Example: decode into a model annotated for encoding/json.
+//
+//	type Service struct {
+//	    Name     string `json:"name"`
+//	    Replicas int    `json:"replicas,omitempty"`
+//	}
+//	err := yaml.UnmarshalWithOptions(data, &svc, yaml.DecodeOptions{JSONTagFallback: true})
+//
+// Example: decode front matter that starts on line 2 of a Markdown page,
+// reporting errors at lines of the page.
+//
+//	err := yaml.UnmarshalWithOptions(frontMatter, &meta, yaml.DecodeOptions{BaseLine: 2})
+func UnmarshalWithOptions(data []byte, v interface{}, opts DecodeOptions) error {
+	if max := opts.Limits.MaxBytes; max > 0 && int64(len(data)) > max {
+		return observeDecode(DecodePathFast, len(data), errInputTooLarge(max))
+	}
+	fopts := fastparser.Options{
+		Nodes:          newNodeSource(v, max(opts.BaseLine, 1), max(opts.BaseColumn, 1)),
+		Tagged:         newTagSource,
+		TagName:        opts.TagName,
+		MaxDepth:       opts.Limits.MaxDepth,
+		MaxKeyLength:   opts.Limits.MaxKeyLength,
+		SafeMode:       opts.Limits.SafeMode,
+		Offset:         opts.BaseOffset,
+		PromoteScalars: opts.PromoteScalars,
+		Line:           opts.BaseLine,
+		Column:         opts.BaseColumn,
+	}
+	if opts.JSONTagFallback {
+		fopts.FallbackTagName = "json"
+	}
+	var unknown unknownFields
+	if opts.KnownFields {
+		fopts.OnUnknownField = unknown.add
+	}
+	err := fastparser.UnmarshalWithOptions(data, v, fopts)
+	if err == nil {
+		err = unknown.err()
+	}
+	return observeDecode(DecodePathFast, len(data), err)
 }
 
 // UnmarshalWithAST parses the YAML-encoded data into an AST first, then unmarshals into v.
 // This is the slower path but allows access to the AST for advanced features.
 // Most users should use Unmarshal() instead for better performance.
 func UnmarshalWithAST(data []byte, v interface{}) error {
-	// Parse YAML into AST
-	node, err := Parse(string(data))
+	return observeDecode(DecodePathAST, len(data), unmarshalWithAST(data, v))
+}
+
+func unmarshalWithAST(data []byte, v interface{}) error {
+	// A Node keeps styles and positions that re-rendering would lose
+	if n, ok := v.(*Node); ok && n != nil {
+		return n.UnmarshalYAML(data)
+	}
+
+	input := string(data)
+	if err := utf8input.CheckString(input); err != nil {
+		return err
+	}
+
+	// Parse YAML into AST, keeping the text of resolved scalars so that
+	// TextUnmarshaler fields see 1.10 rather than 1.1, the tags that make a
+	// mapping a !!set or a sequence an !!omap, and everything a Node holds
+	// when there are NodeUnmarshaler or Node values to build
+	p := parser.NewParser(input)
+	tables := &nodeBuilder{texts: p.RecordScalarTexts(), order: p.RecordKeyOrder(), tags: p.RecordTags()}
+	if decodesNodes(reflect.TypeOf(v)) {
+		tables = newNodeBuilder(p, input)
+	}
+	node, err := p.Parse()
 	if err != nil {
 		return err
 	}
 
-	return unmarshalFromNode(node, v)
+	return withExcerpt(unmarshalFromNode(node, v, tables), input)
 }
 
-// Unmarshaler is the interface implemented by types that can unmarshal a YAML description of themselves.
+// ParseError is the error Unmarshal and Decoder.Decode return for input they
+// cannot decode, and UnmarshalWithAST for a value that does not fit its
+// target. Its embedded Position holds the byte Offset and the 1-based Line
+// and Column of the problem, Path the value it was found in, as in
+// spec.containers[2].image, and Excerpt the input line it is on; Snippet
+// renders that line with a caret under the column. Context from enclosing
+// mappings is part of Message, as in
+// `in field "spec": in field "replicas": cannot unmarshal string into int`.
+// For common mistakes, Hint suggests a fix: a missing space after a colon,
+// tab indentation, or an unclosed quote or bracket. A ParseError wraps its
+// cause, such as io.ErrUnexpectedEOF for truncated input, ErrLimitExceeded,
+// or a *TypeError.
+//
+// Example:
+//
+//	var pe *yaml.ParseError
+//	if errors.As(err, &pe) {
+//	    fmt.Fprintf(os.Stderr, "%s: %s\n%s\n", pe.Path, pe.Message, pe.Snippet())
+//	}
+type ParseError = fastparser.ParseError
+
+// TypeError reports a YAML value that does not fit the Go value it is
+// decoded into, such as a string for an int field, with the Position and
+// Path of the value. Decoding functions return it wrapped in a *ParseError;
+// find it with errors.As.
+//
+// Decoding goes on past such a value, leaving its target as it is, so that
+// one pass finds every mismatch. When there is more than one, the error is a
+// *TypeError whose Errors holds the *ParseError of each, in document order:
+//
+//	yaml: unmarshal errors:
+//	  line 2, column 13: in field "spec": in field "replicas": cannot unmarshal string into int
+//	  line 4, column 9: in field "spec": in field "port": cannot unmarshal mapping into int
+type TypeError = fastparser.TypeError
+
+// MissingFieldError reports keys of struct fields tagged required, as in
+// `yaml:"name,required"`, that a mapping does not have. Decoding functions
+// return it wrapped in a *ParseError at the mapping; find it with errors.As.
+// A key that is present with a null value counts as present.
+//
+// Example:
+//
+//	var mfe *yaml.MissingFieldError
+//	if errors.As(err, &mfe) {
+//	    fmt.Println(mfe.Fields) // [name port]
+//	}
+type MissingFieldError = fastparser.MissingFieldError
+
+// typeError returns the error for node, described by value (see
+// fastparser.ValueKind), not fitting a Go value of type t.
+func typeError(node ast.SchemaNode, value string, t reflect.Type) error {
+	te := fastparser.NewTypeError(value, t)
+	te.Position = node.Position()
+	return &ParseError{Position: te.Position, Message: te.Error(), Err: te}
+}
+
+// withExcerpt sets the Excerpt of the *ParseError err may be, found decoding
+// an AST parsed from input, to its line of input, as it does for each error
+// of a *TypeError collecting several.
+func withExcerpt(err error, input string) error {
+	var te *TypeError
+	if errors.As(err, &te) && len(te.Errors) > 0 {
+		for _, e := range te.Errors {
+			withExcerpt(e, input)
+		}
+		return err
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Excerpt != "" || pe.Line < 1 {
+		return err
+	}
+	lines := strings.SplitN(input, "\n", pe.Line+1)
+	if pe.Line <= len(lines) {
+		pe.Excerpt = strings.TrimSuffix(lines[pe.Line-1], "\r")
+	}
+	return err
+}
+
+// NodeUnmarshaler is the interface implemented by types that decode
+// themselves from a YAML node, usually by decoding it into another type first:
+//
+//	func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+//	    var s string
+//	    if err := value.Decode(&s); err != nil {
+//	        return err
+//	    }
+//	    v, err := time.ParseDuration(s)
+//	    *d = Duration(v)
+//	    return err
+//	}
+//
+// UnmarshalYAML is called for each value of the type, wherever it is in the
+// document, on every decode path; for a null value the target is left zero
+// instead. Struct fields of type Node receive their value's node the same
+// way.
+type NodeUnmarshaler interface {
+	UnmarshalYAML(value *Node) error
+}
+
+// Unmarshaler is the interface implemented by types that parse a whole
+// YAML document themselves. It is only honored for the value passed to
+// Unmarshal, which is handed the document's bytes.
 type Unmarshaler interface {
 	UnmarshalYAML([]byte) error
 }
 
-// unmarshalFromNode unmarshals an AST node into a Go value
-// This is used by both Unmarshal and potential future Decoder.Decode
-func unmarshalFromNode(node ast.SchemaNode, v interface{}) error {
+// unmarshalFromNode unmarshals an AST node into a Go value. tables holds
+// what was recorded while parsing node: the source text of resolved scalars
+// for TextUnmarshaler targets, the document order of mapping keys for
+// MapSlice targets, the tags of !!set and !!omap collections, and the rest
+// of a Node for NodeUnmarshaler targets. A nil tables records nothing.
+func unmarshalFromNode(node ast.SchemaNode, v interface{}, tables *nodeBuilder) error {
 	// Use reflection to populate v from AST
 	rv := reflect.ValueOf(v)
 	if !rv.IsValid() || v == nil {
@@ -88,10 +332,14 @@ func unmarshalFromNode(node ast.SchemaNode, v interface{}) error {
 		return errors.New("yaml: Unmarshal(nil " + rv.Type().String() + ")")
 	}
 
+	if tables == nil {
+		tables = &nodeBuilder{}
+	}
+
 	// Check if type implements Unmarshaler interface
 	if rv.Type().Implements(reflect.TypeOf((*Unmarshaler)(nil)).Elem()) {
 		// Render node back to YAML
-		yamlBytes, err := Marshal(NodeToInterface(node))
+		yamlBytes, err := Marshal(NodeToOrderedInterface(node, tables.order))
 		if err != nil {
 			return err
 		}
@@ -99,11 +347,68 @@ func unmarshalFromNode(node ast.SchemaNode, v interface{}) error {
 		return unmarshaler.UnmarshalYAML(yamlBytes)
 	}
 
-	return unmarshalValue(node, rv.Elem())
+	// An empty document leaves the zero value, as it does for Unmarshal
+	if parser.IsEmptyDocument(node) {
+		rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+		return nil
+	}
+
+	d := &nodeDecoder{texts: tables.texts, order: tables.order, tags: tables.tags}
+	if decodesNodes(rv.Type()) {
+		d.nodes = tables
+	}
+	if err := d.unmarshalValue(node, rv.Elem()); err != nil {
+		return err
+	}
+	// Mappings are decoded in key order; report in document order
+	sort.SliceStable(d.mismatches, func(i, j int) bool {
+		a, b := errorPosition(d.mismatches[i]), errorPosition(d.mismatches[j])
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return fastparser.JoinTypeErrors(d.mismatches)
+}
+
+// errorPosition returns the position of the *ParseError err is.
+func errorPosition(err error) ast.Position {
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		return pe.Position
+	}
+	return ast.Position{}
+}
+
+// nodeDecoder unmarshals AST nodes into Go values.
+type nodeDecoder struct {
+	texts      parser.ScalarTexts // source text of resolved scalars; nil if not recorded
+	order      parser.KeyOrder    // document order of mapping keys; nil if not recorded
+	tags       parser.Tags        // explicit tags; nil if not recorded
+	nodes      *nodeBuilder       // builds NodeUnmarshaler and Node values; nil if there are none
+	mismatches []error            // type mismatches decoded past, as on the fast path
+}
+
+// decodeItem unmarshals the value of a mapping entry or sequence item. A type
+// mismatch anywhere in the value is recorded rather than returned, leaving
+// the target as it is, so that decoding goes on to report every mismatch in
+// the document. wrap adds the key or index of the value to the error
+// returned and to each mismatch recorded within the value.
+func (d *nodeDecoder) decodeItem(node ast.SchemaNode, rv reflect.Value, wrap func(error) error) error {
+	n := len(d.mismatches)
+	err := d.unmarshalValue(node, rv)
+	if err != nil && errors.As(err, new(*TypeError)) {
+		d.mismatches = append(d.mismatches, err)
+		err = nil
+	}
+	for i := n; i < len(d.mismatches); i++ {
+		d.mismatches[i] = wrap(d.mismatches[i])
+	}
+	if err != nil {
+		return wrap(err)
+	}
+	return nil
 }
 
 // unmarshalValue unmarshals an AST node into a reflect.Value
-func unmarshalValue(node ast.SchemaNode, rv reflect.Value) error {
+func (d *nodeDecoder) unmarshalValue(node ast.SchemaNode, rv reflect.Value) error {
 	// Handle null
 	if lit, ok := node.(*ast.LiteralNode); ok && lit.Value() == nil {
 		// Set to zero value (nil for pointers, zero for values)
@@ -123,21 +428,62 @@ func unmarshalValue(node ast.SchemaNode, rv reflect.Value) error {
 		if rv.IsNil() {
 			rv.Set(reflect.New(rv.Type().Elem()))
 		}
-		return unmarshalValue(node, rv.Elem())
+		return d.unmarshalValue(node, rv.Elem())
+	}
+
+	if d.nodes != nil && claimsNode(rv.Type()) {
+		return decodeNode(d.nodes.build(node), rv)
+	}
+
+	// A !!set or !!omap decodes to the collection it stands for
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		if d.tags[n] == SetTag && setTarget(rv.Type()) {
+			return d.unmarshalSet(n, rv)
+		}
+	case *ast.ArrayDataNode:
+		if d.tags[n] == OmapTag && omapTarget(rv.Type()) {
+			return d.unmarshalOmap(n, rv)
+		}
+	}
+
+	if rv.Type() == mapSliceType {
+		return unmarshalMapSlice(node, rv, d.order)
+	}
+
+	if lit, ok := node.(*ast.LiteralNode); ok && rv.CanAddr() {
+		// A !!timestamp scalar is already a time.Time
+		if t, ok := lit.Value().(time.Time); ok && rv.Type() == timeType {
+			rv.Set(reflect.ValueOf(t))
+			return nil
+		}
+		if u, ok := textUnmarshaler(rv); ok {
+			text, ok := d.texts[lit]
+			if !ok {
+				text = fmt.Sprint(lit.Value())
+			}
+			if err := u.UnmarshalText([]byte(text)); err != nil {
+				return fmt.Errorf("yaml: cannot unmarshal %q into %s at %s: %w", text, rv.Type(), lit.Position(), err)
+			}
+			return nil
+		}
 	}
 
 	switch node.Type() {
 	case ast.NodeTypeLiteral:
-		return unmarshalLiteral(node.(*ast.LiteralNode), rv)
+		return d.unmarshalLiteral(node.(*ast.LiteralNode), rv)
 	case ast.NodeTypeObject:
-		return unmarshalObject(node.(*ast.ObjectNode), rv)
+		return d.unmarshalObject(node.(*ast.ObjectNode), rv)
+	case ast.NodeTypeArrayData:
+		return d.unmarshalSequence(node.(*ast.ArrayDataNode), rv)
 	default:
 		return fmt.Errorf("yaml: unsupported node type %s", node.Type())
 	}
 }
 
-// unmarshalLiteral unmarshals a literal node into a reflect.Value
-func unmarshalLiteral(node *ast.LiteralNode, rv reflect.Value) error {
+// unmarshalLiteral unmarshals a literal node into a reflect.Value. A number
+// or bool decoded into a string keeps its source text, so 0x10 stays "0x10".
+func (d *nodeDecoder) unmarshalLiteral(node *ast.LiteralNode, rv reflect.Value) error {
 	val := node.Value()
 
 	switch rv.Kind() {
@@ -146,7 +492,17 @@ func unmarshalLiteral(node *ast.LiteralNode, rv reflect.Value) error {
 			rv.SetString(s)
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal %T into Go value of type string", val)
+		// A !!binary scalar decodes to its bytes, not its base64 text
+		if b, ok := val.([]byte); ok {
+			rv.SetString(string(b))
+			return nil
+		}
+		if text, ok := d.texts[node]; ok {
+			rv.SetString(text)
+			return nil
+		}
+		rv.SetString(fmt.Sprint(val))
+		return nil
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		switch v := val.(type) {
@@ -166,9 +522,11 @@ func unmarshalLiteral(node *ast.LiteralNode, rv reflect.Value) error {
 				rv.SetInt(i)
 				return nil
 			}
-			return fmt.Errorf("yaml: cannot unmarshal number %v into Go value of type %s", v, rv.Type())
+			return typeError(node, fastparser.ValueKind(v), rv.Type())
+		case uint64:
+			return fmt.Errorf("yaml: value %d overflows %s", v, rv.Type())
 		}
-		return fmt.Errorf("yaml: cannot unmarshal %T into Go value of type %s", val, rv.Type())
+		return typeError(node, fastparser.ValueKind(val), rv.Type())
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		switch v := val.(type) {
@@ -178,9 +536,15 @@ func unmarshalLiteral(node *ast.LiteralNode, rv reflect.Value) error {
 			}
 			rv.SetUint(uint64(v))
 			return nil
+		case uint64:
+			if rv.OverflowUint(v) {
+				return fmt.Errorf("yaml: value %d overflows %s", v, rv.Type())
+			}
+			rv.SetUint(v)
+			return nil
 		case float64:
 			if v < 0 || v != float64(uint64(v)) {
-				return fmt.Errorf("yaml: cannot unmarshal number %v into Go value of type %s", v, rv.Type())
+				return typeError(node, fastparser.ValueKind(v), rv.Type())
 			}
 			u := uint64(v)
 			if rv.OverflowUint(u) {
@@ -189,7 +553,7 @@ func unmarshalLiteral(node *ast.LiteralNode, rv reflect.Value) error {
 			rv.SetUint(u)
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal %T into Go value of type %s", val, rv.Type())
+		return typeError(node, fastparser.ValueKind(val), rv.Type())
 
 	case reflect.Float32, reflect.Float64:
 		switch v := val.(type) {
@@ -206,63 +570,50 @@ func unmarshalLiteral(node *ast.LiteralNode, rv reflect.Value) error {
 			}
 			rv.SetFloat(f)
 			return nil
+		case uint64:
+			rv.SetFloat(float64(v))
+			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal %T into Go value of type %s", val, rv.Type())
+		return typeError(node, fastparser.ValueKind(val), rv.Type())
 
 	case reflect.Bool:
 		if b, ok := val.(bool); ok {
 			rv.SetBool(b)
 			return nil
 		}
-		return fmt.Errorf("yaml: cannot unmarshal %T into Go value of type bool", val)
+		return typeError(node, fastparser.ValueKind(val), rv.Type())
+
+	case reflect.Slice:
+		if b, ok := val.([]byte); ok && rv.Type().Elem().Kind() == reflect.Uint8 {
+			rv.SetBytes(append(make([]byte, 0, len(b)), b...))
+			return nil
+		}
+		return typeError(node, fastparser.ValueKind(val), rv.Type())
 
 	default:
-		return fmt.Errorf("yaml: unsupported type %s", rv.Type())
+		return typeError(node, fastparser.ValueKind(val), rv.Type())
 	}
 }
 
-// unmarshalObject unmarshals an object node into a reflect.Value (struct, map, or slice)
-func unmarshalObject(node *ast.ObjectNode, rv reflect.Value) error {
-	props := node.Properties()
-
-	// Check if this is a sequence (all keys are numeric strings "0", "1", "2", etc.)
-	if isSequence(props) {
-		return unmarshalSequence(node, rv)
-	}
-
+// unmarshalObject unmarshals an object node into a reflect.Value (struct or map)
+func (d *nodeDecoder) unmarshalObject(node *ast.ObjectNode, rv reflect.Value) error {
 	switch rv.Kind() {
 	case reflect.Struct:
-		return unmarshalStruct(node, rv)
+		return d.unmarshalStruct(node, rv)
 	case reflect.Map:
-		return unmarshalMap(node, rv)
-	case reflect.Slice:
-		return unmarshalSequence(node, rv)
-	default:
-		return fmt.Errorf("yaml: cannot unmarshal mapping into Go value of type %s", rv.Type())
+		return d.unmarshalMap(node, rv)
 	}
-}
-
-// isSequence checks if the object node represents a YAML sequence (numeric string keys)
-func isSequence(props map[string]ast.SchemaNode) bool {
-	if len(props) == 0 {
-		return false
-	}
-
-	for i := 0; i < len(props); i++ {
-		if _, ok := props[strconv.Itoa(i)]; !ok {
-			return false
-		}
-	}
-	return true
+	return typeError(node, "mapping", rv.Type())
 }
 
 // unmarshalStruct unmarshals an object node into a struct
-func unmarshalStruct(node *ast.ObjectNode, rv reflect.Value) error {
+func (d *nodeDecoder) unmarshalStruct(node *ast.ObjectNode, rv reflect.Value) error {
 	props := node.Properties()
 	structType := rv.Type()
 
 	// Build a map of YAML field names to struct field indices
 	fieldMap := make(map[string]int)
+	var required []string
 	for i := 0; i < structType.NumField(); i++ {
 		field := structType.Field(i)
 		if field.PkgPath != "" { // Skip unexported fields
@@ -275,23 +626,39 @@ func unmarshalStruct(node *ast.ObjectNode, rv reflect.Value) error {
 		}
 
 		fieldMap[info.name] = i
+		if info.required {
+			required = append(required, info.name)
+		}
 	}
 
 	// Set struct fields from YAML properties
-	for yamlName, propNode := range props {
+	err := determinism.Range(props, func(yamlName string, propNode ast.SchemaNode) error {
 		if fieldIdx, ok := fieldMap[yamlName]; ok {
-			fieldVal := rv.Field(fieldIdx)
-			if err := unmarshalValue(propNode, fieldVal); err != nil {
-				return err
-			}
+			return d.decodeItem(propNode, rv.Field(fieldIdx), func(err error) error {
+				return fastparser.InKey(err, yamlName)
+			})
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	var missing []string
+	for _, name := range required {
+		if _, ok := props[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if missing != nil {
+		mfe := &MissingFieldError{Type: structType, Fields: missing}
+		return &ParseError{Position: node.Position(), Message: mfe.Error(), Err: mfe}
+	}
 	return nil
 }
 
 // unmarshalMap unmarshals an object node into a map
-func unmarshalMap(node *ast.ObjectNode, rv reflect.Value) error {
+func (d *nodeDecoder) unmarshalMap(node *ast.ObjectNode, rv reflect.Value) error {
 	props := node.Properties()
 	mapType := rv.Type()
 
@@ -308,43 +675,40 @@ func unmarshalMap(node *ast.ObjectNode, rv reflect.Value) error {
 		return fmt.Errorf("yaml: unsupported map key type %s", keyType)
 	}
 
-	for key, propNode := range props {
+	return determinism.Range(props, func(key string, propNode ast.SchemaNode) error {
 		// Create a new value of the map's value type
 		elemVal := reflect.New(valueType).Elem()
 
 		// Unmarshal the property into the value
-		if err := unmarshalValue(propNode, elemVal); err != nil {
+		err := d.decodeItem(propNode, elemVal, func(err error) error {
+			return fastparser.InKey(err, key)
+		})
+		if err != nil {
 			return err
 		}
 
 		// Set the map entry
 		rv.SetMapIndex(reflect.ValueOf(key), elemVal)
-	}
-
-	return nil
+		return nil
+	})
 }
 
-// unmarshalSequence unmarshals a sequence (object with numeric keys) into a slice
-func unmarshalSequence(node *ast.ObjectNode, rv reflect.Value) error {
-	props := node.Properties()
-
-	// Determine sequence length
-	seqLen := len(props)
+// unmarshalSequence unmarshals a sequence node into a slice or array
+func (d *nodeDecoder) unmarshalSequence(node *ast.ArrayDataNode, rv reflect.Value) error {
+	elems := node.Elements()
 
 	switch rv.Kind() {
 	case reflect.Slice:
 		// Create a new slice of the correct length
-		sliceType := rv.Type()
-		slice := reflect.MakeSlice(sliceType, seqLen, seqLen)
+		slice := reflect.MakeSlice(rv.Type(), len(elems), len(elems))
 
 		// Unmarshal each element
-		for i := 0; i < seqLen; i++ {
-			key := strconv.Itoa(i)
-			if propNode, ok := props[key]; ok {
-				elemVal := slice.Index(i)
-				if err := unmarshalValue(propNode, elemVal); err != nil {
-					return err
-				}
+		for i, elem := range elems {
+			err := d.decodeItem(elem, slice.Index(i), func(err error) error {
+				return fastparser.InItem(err, i)
+			})
+			if err != nil {
+				return err
 			}
 		}
 
@@ -352,24 +716,23 @@ func unmarshalSequence(node *ast.ObjectNode, rv reflect.Value) error {
 		return nil
 
 	case reflect.Array:
-		if seqLen > rv.Len() {
-			return fmt.Errorf("yaml: sequence length %d exceeds target array length %d", seqLen, rv.Len())
+		if len(elems) > rv.Len() {
+			return fmt.Errorf("yaml: sequence length %d exceeds target array length %d", len(elems), rv.Len())
 		}
 
 		// Unmarshal each element
-		for i := 0; i < seqLen; i++ {
-			key := strconv.Itoa(i)
-			if propNode, ok := props[key]; ok {
-				elemVal := rv.Index(i)
-				if err := unmarshalValue(propNode, elemVal); err != nil {
-					return err
-				}
+		for i, elem := range elems {
+			err := d.decodeItem(elem, rv.Index(i), func(err error) error {
+				return fastparser.InItem(err, i)
+			})
+			if err != nil {
+				return err
 			}
 		}
 
 		return nil
 
 	default:
-		return fmt.Errorf("yaml: cannot unmarshal sequence into Go value of type %s", rv.Type())
+		return typeError(node, "sequence", rv.Type())
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-yaml/internal/determinism"
 )
 
 // TestUnmarshalWithAST tests UnmarshalWithAST function
@@ -106,6 +107,45 @@ func TestUnmarshalWithAST(t *testing.T) {
 	}
 }
 
+// TestUnmarshalWithAST_AnchorChains tests anchored mappings that merge
+// earlier aliases, each resolved to the node its anchor last named before it.
+func TestUnmarshalWithAST_AnchorChains(t *testing.T) {
+	type Service struct {
+		Image    string `yaml:"image"`
+		Replicas int    `yaml:"replicas"`
+		Port     int    `yaml:"port"`
+	}
+	input := `base: &base {image: app, replicas: 1}
+web: &web {<<: *base, port: 80}
+api: {<<: *web, replicas: 3}
+worker:
+  <<: [{image: worker}, *web]
+`
+	var got map[string]Service
+	if err := UnmarshalWithAST([]byte(input), &got); err != nil {
+		t.Fatalf("UnmarshalWithAST: %v", err)
+	}
+	want := map[string]Service{
+		"base":   {Image: "app", Replicas: 1},
+		"web":    {Image: "app", Replicas: 1, Port: 80},
+		"api":    {Image: "app", Replicas: 3, Port: 80},
+		"worker": {Image: "worker", Replicas: 1, Port: 80},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	var n Node
+	if err := UnmarshalWithAST([]byte(input), &n); err != nil {
+		t.Fatalf("UnmarshalWithAST into Node: %v", err)
+	}
+	for i, want := range []string{"base", "web", "", ""} {
+		if got := n.Content[2*i+1].Anchor; got != want {
+			t.Errorf("anchor of %s = %q, want %q", n.Content[2*i].Value, got, want)
+		}
+	}
+}
+
 // TestUnmarshalWithAST_Literals tests unmarshalLiteral through UnmarshalWithAST
 func TestUnmarshalWithAST_Literals(t *testing.T) {
 	tests := []struct {
@@ -633,7 +673,7 @@ func TestUnmarshalFromNode_ErrorCases(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := unmarshalFromNode(tt.node, tt.target)
+			err := unmarshalFromNode(tt.node, tt.target, nil)
 			if err == nil {
 				t.Fatal("Expected error, got none")
 			}
@@ -878,3 +918,26 @@ func stringPtrPtr(s string) **string {
 	p := &s
 	return &p
 }
+
+// visitRecorder records the order in which a document's values are decoded.
+type visitRecorder struct{ visits *[]string }
+
+func (r visitRecorder) UnmarshalText(text []byte) error {
+	*r.visits = append(*r.visits, string(text))
+	return nil
+}
+
+func TestUnmarshalWithAST_DeterministicOrder(t *testing.T) {
+	if !determinism.Enabled {
+		t.Skip("run with -tags yamldeterministic")
+	}
+	var visits []string
+	rec := visitRecorder{&visits}
+	v := struct{ A, B, C, D visitRecorder }{rec, rec, rec, rec}
+	if err := UnmarshalWithAST([]byte("c: c\nd: d\na: a\nb: b\n"), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(visits, want) {
+		t.Errorf("fields decoded in order %v, want %v", visits, want)
+	}
+}
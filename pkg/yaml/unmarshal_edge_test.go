@@ -1,7 +1,9 @@
 package yaml
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -402,6 +404,47 @@ ignored: should not set`,
 	}
 }
 
+// TestUnmarshal_DashKeyTag tests the "-," tag escape for a key literally named "-"
+func TestUnmarshal_DashKeyTag(t *testing.T) {
+	type Config struct {
+		Dash    string `yaml:"-,"`
+		Ignored string `yaml:"-"`
+		Name    string `yaml:"name"`
+	}
+
+	inputs := []string{
+		"-: dash\nname: Alice",
+		"name: Alice\n-: dash",
+	}
+	expected := Config{Dash: "dash", Name: "Alice"}
+
+	for _, input := range inputs {
+		var fast Config
+		if err := Unmarshal([]byte(input), &fast); err != nil {
+			t.Fatalf("Unmarshal(%q) error: %v", input, err)
+		}
+		if !reflect.DeepEqual(fast, expected) {
+			t.Errorf("Unmarshal(%q) = %+v, want %+v", input, fast, expected)
+		}
+
+		var slow Config
+		if err := UnmarshalWithAST([]byte(input), &slow); err != nil {
+			t.Fatalf("UnmarshalWithAST(%q) error: %v", input, err)
+		}
+		if !reflect.DeepEqual(slow, expected) {
+			t.Errorf("UnmarshalWithAST(%q) = %+v, want %+v", input, slow, expected)
+		}
+	}
+
+	data, err := Marshal(Config{Dash: "dash", Ignored: "x", Name: "Alice"})
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if string(data) != "-: dash\nname: Alice" {
+		t.Errorf("Marshal() = %q, want %q", data, "-: dash\nname: Alice")
+	}
+}
+
 // TestUnmarshal_DeepNesting tests deeply nested structures
 func TestUnmarshal_DeepNesting(t *testing.T) {
 	yaml := `level1:
@@ -432,3 +475,245 @@ func TestUnmarshal_DeepNesting(t *testing.T) {
 func ptr[T any](v T) *T {
 	return &v
 }
+
+// TestUnmarshal_EmptyValuesToZero tests that an empty value ("key:" alone) or
+// an explicit null resets struct, map, slice and scalar targets to their zero
+// value in both decode paths, replacing whatever the target held before.
+func TestUnmarshal_EmptyValuesToZero(t *testing.T) {
+	type Inner struct {
+		A int `yaml:"a"`
+	}
+	type Target struct {
+		Struct Inner          `yaml:"struct"`
+		Map    map[string]int `yaml:"map"`
+		Slice  []int          `yaml:"slice"`
+		Ptr    *Inner         `yaml:"ptr"`
+		Array  [2]int         `yaml:"array"`
+		Int    int            `yaml:"int"`
+		Str    string         `yaml:"str"`
+		Keep   string         `yaml:"keep"`
+	}
+	prefilled := func() *Target {
+		return &Target{
+			Struct: Inner{A: 1},
+			Map:    map[string]int{"x": 1},
+			Slice:  []int{1},
+			Ptr:    &Inner{A: 1},
+			Array:  [2]int{1, 2},
+			Int:    1,
+			Str:    "s",
+			Keep:   "kept",
+		}
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{"empty block values", "struct:\nmap:\nslice:\nptr:\narray:\nint:\nstr:\n"},
+		{"empty value at end of input", "int: 0\nstr:\nslice:\nmap:\nptr:\narray:\nstruct:"},
+		{"empty values with comments", "struct: # none\nmap:\nslice:\nptr:\narray:\nint:\nstr:\n"},
+		{"explicit nulls", "struct: null\nmap: ~\nslice: null\nptr: Null\narray: ~\nint: null\nstr: ~\n"},
+		{"flow empty values", "{struct: , map: , slice: , ptr: , array: , int: , str: }"},
+		{"flow nulls", "{struct: null, map: ~, slice: null, ptr: ~, array: null, int: ~, str: null}"},
+	}
+
+	want := &Target{Keep: "kept"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				got := prefilled()
+				if err := decode([]byte(tt.yaml), got); err != nil {
+					t.Fatalf("unmarshal error = %v", err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("got %+v, want %+v", got, want)
+				}
+			})
+		})
+	}
+}
+
+// TestUnmarshal_QuotedScalarsStayStrings tests that quoted scalars are never
+// implicitly typed, whatever their text, in block, flow and sequence context.
+func TestUnmarshal_QuotedScalarsStayStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want interface{}
+	}{
+		{"double-quoted yes", `answer: "yes"`, map[string]interface{}{"answer": "yes"}},
+		{"single-quoted yes", `answer: 'yes'`, map[string]interface{}{"answer": "yes"}},
+		{"quoted Off", `answer: "Off"`, map[string]interface{}{"answer": "Off"}},
+		{"quoted null", `answer: "null"`, map[string]interface{}{"answer": "null"}},
+		{"quoted tilde", `answer: '~'`, map[string]interface{}{"answer": "~"}},
+		{"quoted integer", `answer: "123"`, map[string]interface{}{"answer": "123"}},
+		{"quoted hex", `answer: "0x1F"`, map[string]interface{}{"answer": "0x1F"}},
+		{"quoted inf", `answer: ".inf"`, map[string]interface{}{"answer": ".inf"}},
+		{"quoted with comment", `answer: "yes" # confirmed`, map[string]interface{}{"answer": "yes"}},
+		{"flow sequence", `answer: ["yes", 'on', "1"]`, map[string]interface{}{"answer": []interface{}{"yes", "on", "1"}}},
+		{"flow mapping", `answer: {a: "no", b: 'true'}`, map[string]interface{}{"answer": map[string]interface{}{"a": "no", "b": "true"}}},
+		{"block sequence", "- \"yes\"\n- 'off'", []interface{}{"yes", "off"}},
+		{"document scalar", `"true"`, "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachDecoder(t, func(t *testing.T, decode func([]byte, interface{}) error) {
+				var got interface{}
+				if err := decode([]byte(tt.yaml), &got); err != nil {
+					t.Fatalf("unmarshal error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("got %#v, want %#v", got, tt.want)
+				}
+			})
+		})
+	}
+}
+
+// TestInvalidUTF8Rejected tests that every entry point rejects malformed
+// UTF-8 with the offset of the first bad byte instead of decoding it.
+func TestInvalidUTF8Rejected(t *testing.T) {
+	const input = "key: \"ab\xffc\"\n"
+	const want = "yaml: invalid UTF-8 at offset 8"
+
+	var v interface{}
+	var ms MapSlice
+	entryPoints := []struct {
+		name string
+		run  func() error
+	}{
+		{"Unmarshal", func() error { return Unmarshal([]byte(input), &v) }},
+		{"Unmarshal MapSlice", func() error { return Unmarshal([]byte(input), &ms) }},
+		{"UnmarshalWithAST", func() error { return UnmarshalWithAST([]byte(input), &v) }},
+		{"Parse", func() error { _, err := Parse(input); return err }},
+		{"ParseMultiDoc", func() error { _, err := ParseMultiDoc("a: 1\n---\n" + input); return err }},
+		{"ParseReader", func() error { _, err := ParseReader(strings.NewReader(input)); return err }},
+		{"ParseMultiDocReader", func() error { _, err := ParseMultiDocReader(strings.NewReader(input)); return err }},
+		{"Validate", func() error { return Validate(input) }},
+		{"ValidateReader", func() error { return ValidateReader(strings.NewReader(input), Limits{}) }},
+	}
+
+	for _, ep := range entryPoints {
+		t.Run(ep.name, func(t *testing.T) {
+			err := ep.run()
+			if !errors.Is(err, ErrInvalidUTF8) {
+				t.Fatalf("error = %v, want ErrInvalidUTF8", err)
+			}
+			if ep.name != "ParseMultiDoc" && err.Error() != want {
+				t.Errorf("error = %q, want %q", err, want)
+			}
+		})
+	}
+}
+
+// TestLocaleIndependence checks that the locale of the environment does not
+// change how numbers and booleans are read or written: a comma decimal such
+// as 1,5 is a string however LC_NUMERIC is set.
+func TestLocaleIndependence(t *testing.T) {
+	t.Setenv("LANG", "de_DE.UTF-8")
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+	t.Setenv("LC_NUMERIC", "de_DE.UTF-8")
+
+	input := "comma: 1,5\nneg: -1,5\ngrouped: 1.234,56\nexp: 0,5e3\nfloat: 1.5\nbool: wahr\nlist:\n  - 2,5\n"
+	want := map[string]interface{}{
+		"comma":   "1,5",
+		"neg":     "-1,5",
+		"grouped": "1.234,56",
+		"exp":     "0,5e3",
+		"float":   1.5,
+		"bool":    "wahr",
+		"list":    []interface{}{"2,5"},
+	}
+
+	decoders := []struct {
+		name   string
+		decode func(v *interface{}) error
+	}{
+		{"Unmarshal", func(v *interface{}) error { return Unmarshal([]byte(input), v) }},
+		{"UnmarshalWithAST", func(v *interface{}) error { return UnmarshalWithAST([]byte(input), v) }},
+		{"Parse", func(v *interface{}) error {
+			node, err := Parse(input)
+			if err == nil {
+				*v = NodeToInterface(node)
+			}
+			return err
+		}},
+	}
+	for _, d := range decoders {
+		t.Run(d.name, func(t *testing.T) {
+			var v interface{}
+			if err := d.decode(&v); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(v, want) {
+				t.Errorf("got %#v, want %#v", v, want)
+			}
+		})
+	}
+
+	t.Run("float64 target", func(t *testing.T) {
+		var f float64
+		if err := Unmarshal([]byte("1,5"), &f); err == nil {
+			t.Errorf("Unmarshal(1,5) into float64 = %v, want an error", f)
+		}
+	})
+
+	t.Run("Marshal", func(t *testing.T) {
+		out, err := Marshal(map[string]interface{}{"f": 1.5, "s": "1,5", "t": "1.5"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "f: 1.5\ns: 1,5\nt: \"1.5\""; string(out) != want {
+			t.Errorf("Marshal() = %q, want %q", out, want)
+		}
+	})
+}
+
+// TestUnmarshal_AnchorsRejected checks that the fast path rejects anchors
+// and aliases instead of reading "&b1 {x" as a key, while UnmarshalWithAST
+// resolves the same document, anchor chains included.
+func TestUnmarshal_AnchorsRejected(t *testing.T) {
+	const input = "base1: &b1 {x: 1}\nbase2: &b2 {<<: *b1, extra: 1}\nuse: *b2\n"
+	inputs := []string{
+		input,
+		"items:\n  - &a x\n  - *a\n",
+		"a: [*x]",
+		"&k a: 1",
+	}
+
+	type doc struct {
+		Use map[string]int `yaml:"use"`
+	}
+	for _, in := range inputs {
+		entryPoints := []struct {
+			name string
+			run  func() error
+		}{
+			{"Unmarshal", func() error { var v interface{}; return Unmarshal([]byte(in), &v) }},
+			{"Unmarshal struct", func() error { var v doc; return Unmarshal([]byte(in), &v) }},
+			{"UnmarshalStrict", func() error { var v interface{}; return UnmarshalStrict([]byte(in), &v) }},
+			{"Decoder", func() error { var v interface{}; return NewDecoder(strings.NewReader(in)).Decode(&v) }},
+		}
+		for _, ep := range entryPoints {
+			if err := ep.run(); !errors.Is(err, ErrAnchors) {
+				t.Errorf("%s(%q) error = %v, want ErrAnchors", ep.name, in, err)
+			}
+		}
+	}
+
+	var v doc
+	if err := UnmarshalWithAST([]byte(input), &v); err != nil {
+		t.Fatalf("UnmarshalWithAST() error = %v", err)
+	}
+	if want := map[string]int{"x": 1, "extra": 1}; !reflect.DeepEqual(v.Use, want) {
+		t.Errorf("UnmarshalWithAST() use = %v, want %v", v.Use, want)
+	}
+
+	// Safe mode still reports the construct as unsafe
+	err := UnmarshalWithOptions([]byte(input), new(interface{}), DecodeOptions{Limits: Limits{SafeMode: true}})
+	if !errors.Is(err, ErrUnsafe) {
+		t.Errorf("UnmarshalWithOptions(SafeMode) error = %v, want ErrUnsafe", err)
+	}
+}
@@ -1,7 +1,11 @@
 package yaml
 
 import (
+	"errors"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestValidate_ValidYAML(t *testing.T) {
@@ -205,3 +209,178 @@ func TestValidate_ListItems(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateReader(t *testing.T) {
+	multiDoc := "---\nname: a\nitems: [1, 2]\n---\nname: b\nnested:\n  deep:\n    value: 1\n"
+
+	tests := []struct {
+		name      string
+		yaml      string
+		limits    Limits
+		wantErr   bool
+		wantLimit bool
+	}{
+		{name: "no limits", yaml: multiDoc},
+		{name: "within limits", yaml: multiDoc, limits: Limits{MaxBytes: 1024, MaxDepth: 4, MaxNodes: 20}},
+		{name: "exactly MaxBytes", yaml: "a: 1", limits: Limits{MaxBytes: 4}},
+		{name: "empty stream", yaml: "", limits: Limits{MaxBytes: 1}},
+		{name: "too many bytes", yaml: multiDoc, limits: Limits{MaxBytes: 10}, wantErr: true, wantLimit: true},
+		{name: "too deep", yaml: multiDoc, limits: Limits{MaxDepth: 3}, wantErr: true, wantLimit: true},
+		{name: "too deep in flow", yaml: "a: [[[[1]]]]", limits: Limits{MaxDepth: 4}, wantErr: true, wantLimit: true},
+		{name: "default depth", yaml: strings.Repeat("[", DefaultMaxDepth+1), wantErr: true, wantLimit: true},
+		{name: "depth limit removed", yaml: strings.Repeat("[", DefaultMaxDepth+1) + strings.Repeat("]", DefaultMaxDepth+1), limits: Limits{MaxDepth: -1}},
+		{name: "too many nodes across documents", yaml: multiDoc, limits: Limits{MaxNodes: 8}, wantErr: true, wantLimit: true},
+		{name: "exactly MaxDocuments", yaml: multiDoc, limits: Limits{MaxDocuments: 2}},
+		{name: "too many documents", yaml: multiDoc, limits: Limits{MaxDocuments: 1}, wantErr: true, wantLimit: true},
+		{name: "anchors per document", yaml: "a: &x 1\n---\nb: &y 2\n", limits: Limits{MaxAnchors: 1}},
+		{name: "too many anchors", yaml: "a: &x 1\nb: &y 2\n", limits: Limits{MaxAnchors: 1}, wantErr: true, wantLimit: true},
+		{name: "anchor name too long", yaml: "a: &" + strings.Repeat("x", 65) + " 1\n", limits: Limits{MaxNameLength: 64}, wantErr: true, wantLimit: true},
+		{name: "too many aliases across documents", yaml: "a: &x 1\nb: *x\n---\nc: &y 2\nd: *y\n", limits: Limits{MaxAliases: 1}, wantErr: true, wantLimit: true},
+		{name: "key too long", yaml: "name: a\n" + strings.Repeat("k", 300) + ": 1\n", limits: Limits{MaxKeyLength: 256}, wantErr: true, wantLimit: true},
+		{name: "syntax error in second document", yaml: "a: 1\n---\nb: [1, 2\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateReader(strings.NewReader(tt.yaml), tt.limits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, ErrLimitExceeded); got != tt.wantLimit {
+				t.Errorf("errors.Is(err, ErrLimitExceeded) = %v, want %v (err = %v)", got, tt.wantLimit, err)
+			}
+		})
+	}
+}
+
+func TestParseMultiDocReaderWithLimits(t *testing.T) {
+	stream := "a: 1\n---\nb: 2\n---\nc: 3\n"
+
+	docs, err := ParseMultiDocReaderWithLimits(strings.NewReader(stream), Limits{MaxDocuments: 3})
+	if err != nil || len(docs) != 3 {
+		t.Fatalf("ParseMultiDocReaderWithLimits() = %d docs, %v; want 3 docs", len(docs), err)
+	}
+
+	docs, err = ParseMultiDocReaderWithLimits(strings.NewReader(stream), Limits{MaxDocuments: 2})
+	var limitErr *DocumentLimitError
+	if !errors.As(err, &limitErr) || docs != nil {
+		t.Fatalf("ParseMultiDocReaderWithLimits() = %d docs, %v; want *DocumentLimitError", len(docs), err)
+	}
+	if limitErr.Max != 2 || limitErr.Line != 4 {
+		t.Errorf("DocumentLimitError = %+v, want Max 2 at line 4", limitErr)
+	}
+}
+
+func TestValidateReader_ReadError(t *testing.T) {
+	// A read error must not be mistaken for the end of a valid document
+	readErr := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader("a: 1\nb: 2\n"), iotest.ErrReader(readErr))
+
+	err := ValidateReader(r, Limits{})
+	if !errors.Is(err, readErr) {
+		t.Errorf("ValidateReader() error = %v, want %v", err, readErr)
+	}
+}
+
+func TestParseWithLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		yaml      string
+		limits    Limits
+		wantLimit bool
+	}{
+		{name: "no limits", yaml: "a: {b: [1, 2]}"},
+		{name: "within limits", yaml: "a: &x {b: 1}\nc: *x", limits: Limits{MaxBytes: 19, MaxDepth: 4, MaxNodes: 7, MaxAliases: 1, MaxKeyLength: 1}},
+		{name: "too many bytes", yaml: "a: 1", limits: Limits{MaxBytes: 3}, wantLimit: true},
+		{name: "too deep", yaml: "a: {b: [1, 2]}", limits: Limits{MaxDepth: 3}, wantLimit: true},
+		{name: "too many nodes", yaml: "a: [1, 2, 3]", limits: Limits{MaxNodes: 4}, wantLimit: true},
+		{name: "too many aliases", yaml: "a: &x 1\nb: *x\nc: *x", limits: Limits{MaxAliases: 1}, wantLimit: true},
+		{name: "key too long", yaml: "ab: 1", limits: Limits{MaxKeyLength: 1}, wantLimit: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := ParseWithLimits(tt.yaml, tt.limits)
+			if got := errors.Is(err, ErrLimitExceeded); got != tt.wantLimit {
+				t.Fatalf("ParseWithLimits() error = %v, want limit error %v", err, tt.wantLimit)
+			}
+			if err == nil && node == nil {
+				t.Error("ParseWithLimits() returned no node")
+			}
+		})
+	}
+
+	if _, err := ParseWithLimits("a: [1", Limits{}); err == nil || errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("ParseWithLimits() of invalid YAML error = %v, want a syntax error", err)
+	}
+}
+
+func TestLimits_SafeMode(t *testing.T) {
+	safe := Limits{SafeMode: true}
+	tests := []struct {
+		name      string
+		yaml      string
+		construct string
+	}{
+		{"anchor and alias", "base: &base {x: 1}\nuse: *base", "anchor &base"},
+		{"alias in sequence", "a: [1, *x]", "alias *x"},
+		{"custom tag", "when: !Date 2024-01-02", "tag !Date"},
+		{"directive", "%YAML 1.2\n---\na: 1", "directive %YAML 1.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWithLimits(tt.yaml, safe)
+			if !errors.Is(err, ErrUnsafe) || !strings.Contains(err.Error(), tt.construct+" not allowed in safe mode") {
+				t.Errorf("ParseWithLimits() error = %v, want ErrUnsafe naming %s", err, tt.construct)
+			}
+			var v interface{}
+			err = UnmarshalWithOptions([]byte(tt.yaml), &v, DecodeOptions{Limits: safe})
+			if !errors.Is(err, ErrUnsafe) || !strings.Contains(err.Error(), tt.construct+" not allowed in safe mode") {
+				t.Errorf("UnmarshalWithOptions() error = %v, want ErrUnsafe naming %s", err, tt.construct)
+			}
+		})
+	}
+
+	plain := "name: web\nports: [80, 443]\nnote: \"&not *an !alias\""
+	if _, err := ParseWithLimits(plain, safe); err != nil {
+		t.Errorf("ParseWithLimits() of plain data error = %v", err)
+	}
+	if _, err := ParseWithLimits("port: !!int \"80\"", safe); err != nil {
+		t.Errorf("ParseWithLimits() with a core tag error = %v", err)
+	}
+	var v map[string]interface{}
+	if err := UnmarshalWithOptions([]byte(plain), &v, DecodeOptions{Limits: safe}); err != nil {
+		t.Errorf("UnmarshalWithOptions() of plain data error = %v", err)
+	}
+
+	dec := NewDecoder(strings.NewReader("a: 1\n---\nb: *x\n"))
+	dec.SetLimits(safe)
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Decode() of first document error = %v", err)
+	}
+	if err := dec.Decode(&v); !errors.Is(err, ErrUnsafe) {
+		t.Errorf("Decode() of second document error = %v, want ErrUnsafe", err)
+	}
+}
+
+func TestUnmarshalWithOptions_Limits(t *testing.T) {
+	tests := []struct {
+		name      string
+		yaml      string
+		limits    Limits
+		wantLimit bool
+	}{
+		{name: "within limits", yaml: "name: web\nports: [80]", limits: Limits{MaxBytes: 21, MaxDepth: 3, MaxKeyLength: 5}},
+		{name: "too many bytes", yaml: "name: web\nports: [80]", limits: Limits{MaxBytes: 20}, wantLimit: true},
+		{name: "too deep", yaml: "name: web\nports: [80]", limits: Limits{MaxDepth: 2}, wantLimit: true},
+		{name: "key too long", yaml: "name: web\nports: [80]", limits: Limits{MaxKeyLength: 4}, wantLimit: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v map[string]interface{}
+			err := UnmarshalWithOptions([]byte(tt.yaml), &v, DecodeOptions{Limits: tt.limits})
+			if got := errors.Is(err, ErrLimitExceeded); got != tt.wantLimit {
+				t.Errorf("UnmarshalWithOptions() error = %v, want limit error %v", err, tt.wantLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package yaml
+
+import (
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// modulePath is the module LibraryVersion looks for in the build info.
+const modulePath = "github.com/shapestone/shape-yaml"
+
+// develVersion is the version LibraryVersion reports when the build info
+// records none, as in tests of this module. It is the next minor release
+// with a -dev suffix, and is bumped when a release is tagged, along with the
+// new heading in CHANGELOG.md.
+const develVersion = "0.10.0-dev"
+
+// LibraryVersion returns the version of this module that the program was
+// built with, as recorded in its build info, such as 0.9.1. A program built
+// within this module reports the version the go command derived from its
+// version control tags, or a development version, here 0.10.0-dev, when
+// there is none.
+//
+// Example:
+//
+//	want, _ := yaml.ParseVersion("0.9.0")
+//	if yaml.LibraryVersion().Compare(want) < 0 {
+//	    // fall back
+//	}
+func LibraryVersion() Version {
+	return libraryVersion()
+}
+
+var libraryVersion = sync.OnceValue(func() Version {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, m := range info.Deps {
+			if m.Path != modulePath {
+				continue
+			}
+			if v, err := ParseVersion(m.Version); err == nil {
+				return v
+			}
+		}
+		// A build of this module itself records "(devel)", or with version
+		// control information a pseudo-version after the latest tag; one
+		// based on v0.0.0 means no tag was found
+		if info.Main.Path == modulePath && !strings.HasPrefix(info.Main.Version, "v0.0.0-") {
+			if v, err := ParseVersion(info.Main.Version); err == nil {
+				return v
+			}
+		}
+	}
+	v, _ := ParseVersion(develVersion)
+	return v
+})
+
+// Features that Supports reports on, for frameworks that adapt to the
+// version of this module they are built with.
+const (
+	FeatureMultiDoc        = "multidoc"         // several documents in one stream: ParseMultiDoc, Decoder, Encoder
+	FeatureStreaming       = "streaming"        // decoding from an io.Reader: ParseReader, Decoder
+	FeatureAnchors         = "anchors"          // anchors, aliases and merge keys, on the AST path
+	FeatureAnchorsFastPath = "anchors-fastpath" // anchors and aliases on the fast path; not supported
+	FeatureTags            = "tags"             // core schema tags and custom tags: ParseWithTags
+	FeatureComments        = "comments"         // comments kept through decode and encode; not supported
+	FeatureLimits          = "limits"           // resource limits for untrusted input: Limits
+	FeatureSafeMode        = "safe-mode"        // rejecting anchors, tags and directives: Limits.SafeMode
+	FeatureTypeErrors      = "type-errors"      // every type mismatch of a document in one *TypeError
+	FeatureJSONSchema      = "json-schema"      // JSON Schema generation: JSONSchema
+	FeatureJSONPatch       = "json-patch"       // ApplyJSONPatch and ApplyMergePatch
+	FeatureSchema          = "schema"           // validating documents against a Schema
+)
+
+// features holds the features this version supports.
+var features = map[string]bool{
+	FeatureMultiDoc:   true,
+	FeatureStreaming:  true,
+	FeatureAnchors:    true,
+	FeatureTags:       true,
+	FeatureLimits:     true,
+	FeatureSafeMode:   true,
+	FeatureTypeErrors: true,
+	FeatureJSONSchema: true,
+	FeatureJSONPatch:  true,
+	FeatureSchema:     true,
+}
+
+// Supports reports whether this version of the module has feature, one of
+// the Feature constants, so that frameworks can detect features at run time
+// rather than require a version. It returns false for features it does not
+// know, which include those of later versions, and for FeatureAnchorsFastPath
+// and FeatureComments, which are not implemented.
+func Supports(feature string) bool {
+	return features[feature]
+}
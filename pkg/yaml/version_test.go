@@ -0,0 +1,33 @@
+package yaml
+
+import "testing"
+
+func TestLibraryVersion(t *testing.T) {
+	// Tests build this module itself, whose build info has no version
+	if got := LibraryVersion().String(); got != develVersion {
+		t.Errorf("LibraryVersion() = %s, want %s", got, develVersion)
+	}
+}
+
+func TestSupports(t *testing.T) {
+	tests := []struct {
+		feature string
+		want    bool
+	}{
+		{FeatureMultiDoc, true},
+		{"multidoc", true},
+		{FeatureAnchors, true},
+		{FeatureSafeMode, true},
+		{FeatureTypeErrors, true},
+		{FeatureSchema, true},
+		{FeatureAnchorsFastPath, false},
+		{FeatureComments, false},
+		{"time-travel", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := Supports(tt.feature); got != tt.want {
+			t.Errorf("Supports(%q) = %v, want %v", tt.feature, got, tt.want)
+		}
+	}
+}
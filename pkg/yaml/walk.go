@@ -0,0 +1,72 @@
+package yaml
+
+import "strconv"
+
+// Walk visits node and all of its descendants depth-first, calling fn for
+// each node before its children. Sequence elements and mapping entries are
+// visited in document order; mapping keys are not visited themselves.
+// Returning false from fn ends the walk early; Walk reports whether it
+// visited every node.
+//
+// path holds the mapping keys and sequence indices leading from the root to
+// the visited node, and is empty for the root. The slice is reused between
+// calls, so copy it to keep it.
+//
+// Example: find secrets in any scalar value.
+//
+//	yaml.Walk(&node, func(path []string, n *yaml.Node) bool {
+//	    if s, ok := yaml.AsString(n); ok && strings.HasPrefix(s, "AKIA") {
+//	        fmt.Println("AWS key at", strings.Join(path, "."), "line", n.Line)
+//	    }
+//	    return true
+//	})
+func Walk(node *Node, fn func(path []string, node *Node) bool) bool {
+	return Visitor{Pre: fn}.Walk(node)
+}
+
+// A Visitor holds the hooks called while walking a tree. Either hook may be
+// nil. Both receive the same path as the Walk callback.
+type Visitor struct {
+	// Pre is called for a node before its children. Returning false ends
+	// the walk.
+	Pre func(path []string, node *Node) bool
+
+	// Post is called for a node after its children. Returning false ends
+	// the walk.
+	Post func(path []string, node *Node) bool
+}
+
+// Walk visits node and all of its descendants in the order described for
+// the package-level Walk, calling v.Pre before and v.Post after each node's
+// children. It reports whether it visited every node.
+func (v Visitor) Walk(node *Node) bool {
+	return v.walk(make([]string, 0, 8), node)
+}
+
+func (v Visitor) walk(path []string, node *Node) bool {
+	if v.Pre != nil && !v.Pre(path, node) {
+		return false
+	}
+
+	if node != nil {
+		switch node.Kind {
+		case MappingKind:
+			for _, i := range mappingEntries(node) {
+				if !v.walk(append(path, node.Content[i].Value), node.Content[i+1]) {
+					return false
+				}
+			}
+		case SequenceKind:
+			for i, elem := range node.Content {
+				if !v.walk(append(path, strconv.Itoa(i)), elem) {
+					return false
+				}
+			}
+		}
+	}
+
+	if v.Post != nil && !v.Post(path, node) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const walkDoc = `
+name: api
+env:
+  - name: TOKEN
+    value: secret
+  - name: MODE
+    value: prod
+port: 8080
+`
+
+func TestWalk(t *testing.T) {
+	node := parseNode(t, walkDoc)
+
+	var visited []string
+	complete := Walk(node, func(path []string, n *Node) bool {
+		visited = append(visited, "/"+strings.Join(path, "/"))
+		return true
+	})
+	if !complete {
+		t.Error("Walk() = false, want true")
+	}
+
+	want := []string{
+		"/",
+		"/name",
+		"/env",
+		"/env/0", "/env/0/name", "/env/0/value",
+		"/env/1", "/env/1/name", "/env/1/value",
+		"/port",
+	}
+	if !reflect.DeepEqual(visited, want) {
+		t.Errorf("visited =\n%v\nwant\n%v", visited, want)
+	}
+}
+
+func TestWalk_IntegerLikeKeys(t *testing.T) {
+	// A mapping with integer-like keys is walked in document order, like
+	// any other mapping, and its entries are not sequence elements.
+	node := parseNode(t, "2: b\n10: a\nseq: [x, y]")
+
+	var visited []string
+	Walk(node, func(path []string, n *Node) bool {
+		visited = append(visited, "/"+strings.Join(path, "/"))
+		return true
+	})
+	want := []string{"/", "/2", "/10", "/seq", "/seq/0", "/seq/1"}
+	if !reflect.DeepEqual(visited, want) {
+		t.Errorf("visited = %v, want %v", visited, want)
+	}
+}
+
+func TestWalk_EarlyTermination(t *testing.T) {
+	node := parseNode(t, walkDoc)
+
+	var found []string
+	complete := Walk(node, func(path []string, n *Node) bool {
+		if s, ok := AsString(n); ok && s == "secret" {
+			found = append([]string(nil), path...)
+			return false
+		}
+		return true
+	})
+	if complete {
+		t.Error("Walk() = true, want false after early termination")
+	}
+	if want := []string{"env", "0", "value"}; !reflect.DeepEqual(found, want) {
+		t.Errorf("found at %v, want %v", found, want)
+	}
+}
+
+func TestVisitor_PrePost(t *testing.T) {
+	node := parseNode(t, "a:\n  b: 1\nc: [2]")
+
+	var events []string
+	v := Visitor{
+		Pre: func(path []string, n *Node) bool {
+			events = append(events, "pre /"+strings.Join(path, "/"))
+			return true
+		},
+		Post: func(path []string, n *Node) bool {
+			events = append(events, "post /"+strings.Join(path, "/"))
+			return len(path) != 1 || path[0] != "a"
+		},
+	}
+	if v.Walk(node) {
+		t.Error("Visitor.Walk() = true, want false when Post ends the walk")
+	}
+
+	want := []string{"pre /", "pre /a", "pre /a/b", "post /a/b", "post /a"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	events = nil
+	if !(Visitor{Post: func(path []string, n *Node) bool {
+		events = append(events, "/"+strings.Join(path, "/"))
+		return true
+	}}).Walk(node) {
+		t.Error("Visitor.Walk() = false, want true")
+	}
+	if want := []string{"/a/b", "/a", "/c/0", "/c", "/"}; !reflect.DeepEqual(events, want) {
+		t.Errorf("post-order = %v, want %v", events, want)
+	}
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/shapestone/shape-yaml/pkg/yaml"
+)
+
+func main() {
+	fmt.Println(yaml.LibraryVersion())
+}